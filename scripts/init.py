@@ -47,6 +47,34 @@ def generate_config_file(
     if not os.path.exists(output):
         os.mkdir(output)
 
+    # tracing config shared by all the services, exporter can be otlp or file
+    tracing_file_template_str = '''
+[tracing]
+enable = false
+exporter = otlp
+endpoint = http://127.0.0.1:4318/v1/traces
+filePath =
+sampleRatio = 1
+'''
+
+    # security of the rpc connection between the services and the tmserver, the clients and the tmserver
+    # should use the same tls switch and token. the tmserver verifies the client certificate if caFile is set.
+    # codec and compress are the lists in the order of preference offered by the clients, or accepted by the
+    # tmserver, the codec can be bson, json or msgpack, the compress can be none or deflate. the clients use
+    # bson without compression and the tmserver accepts all of them if they are not set.
+    rpc_file_template_str = '''
+[rpc]
+tls = false
+caFile =
+certFile =
+keyFile =
+certPassword =
+serverName =
+token =
+codec =
+compress =
+'''
+
     # apiserver.conf
     apiserver_file_template_str = '''
 [auth]
@@ -55,7 +83,7 @@ appCode = $auth_app_code
 appSecret = $auth_app_secret
     '''
 
-    template = FileTemplate(apiserver_file_template_str)
+    template = FileTemplate(apiserver_file_template_str + tracing_file_template_str)
     result = template.substitute(**context)
     with open(output + "apiserver.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -101,9 +129,33 @@ database = 0
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
+
+[hostsnap]
+historyRetentionDays = 30
+historyMaxCount = 100
+
+[push]
+enable = false
+tokens =
+
+[kafka]
+enable = false
+brokers =
+groupID = cmdb_datacollection
+snapshotTopic =
+netcollectTopic =
+workers =
+
+[snmp]
+enable = false
+worker = 10
+timeout = 5
+retries = 1
+maxTargets = 4096
+period = 1H
 '''
 
-    template = FileTemplate(datacollection_file_template_str)
+    template = FileTemplate(datacollection_file_template_str + tracing_file_template_str + rpc_file_template_str)
     result = template.substitute(**context)
     with open(output + "datacollection.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -130,7 +182,7 @@ maxOpenConns = 3000
 maxIDleConns = 1000
 '''
 
-    template = FileTemplate(eventserver_file_template_str)
+    template = FileTemplate(eventserver_file_template_str + tracing_file_template_str + rpc_file_template_str)
     result = template.substitute(**context)
     with open(output + "eventserver.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -155,7 +207,7 @@ appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
 '''
-    template = FileTemplate(host_file_template_str)
+    template = FileTemplate(host_file_template_str + tracing_file_template_str)
     result = template.substitute(**context)
     with open(output + "host.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -196,7 +248,7 @@ syncWorkers = $auth_sync_workers
 syncIntervalMinutes = $auth_sync_interval_minutes
     '''
 
-    template = FileTemplate(migrate_file_template_str)
+    template = FileTemplate(migrate_file_template_str + rpc_file_template_str)
     result = template.substitute(**context)
     with open(output + "migrate.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -224,13 +276,15 @@ maxOpenConns = 3000
 maxIDleConns = 1000
 '''
 
-    template = FileTemplate(coreservice_file_template_str)
+    template = FileTemplate(coreservice_file_template_str + tracing_file_template_str + rpc_file_template_str)
     result = template.substitute(**context)
     with open(output + "coreservice.conf", 'w') as tmp_file:
         tmp_file.write(result)
 
     # proc.conf
     proc_file_template_str = '''
+[process]
+bindConflictMode = warn
 
 [auth]
 address = $auth_address
@@ -247,7 +301,7 @@ maxOpenConns = 3000
 maxIDleConns = 1000
 enable = true
 '''
-    template = FileTemplate(proc_file_template_str)
+    template = FileTemplate(proc_file_template_str + tracing_file_template_str)
     result = template.substitute(**context)
     with open(output + "proc.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -266,8 +320,13 @@ enable = true
 
 [timer]
 spec = 00:30  # 00:00 - 23:59
+customChartInterval = 10  # minutes between the computing of the custom charts
+
+[metrics]
+inventoryInterval = 5  # minutes between the refreshing of the inventory metrics
+inventoryMaxGroups = 100  # the max label values of each inventory metric, the others are summed as "other"
 '''
-    template = FileTemplate(operation_file_template_str)
+    template = FileTemplate(operation_file_template_str + tracing_file_template_str)
     result = template.substitute(**context)
     with open(output + "operation.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -288,7 +347,7 @@ enable = false
 transactionLifetimeSecond = 60
 '''
 
-    template = FileTemplate(txcserver_file_template_str)
+    template = FileTemplate(txcserver_file_template_str + tracing_file_template_str + rpc_file_template_str)
     result = template.substitute(**context)
     with open(output + "txc.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -318,7 +377,7 @@ full_text_search = $full_text_search
 url=$es_url
 '''
 
-    template = FileTemplate(topo_file_template_str)
+    template = FileTemplate(topo_file_template_str + tracing_file_template_str + rpc_file_template_str)
     result = template.substitute(**context)
     with open(output + "topo.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -348,7 +407,7 @@ full_text_search = $full_text_search
 agent_app_url = ${agent_url}/console/?app=bk_agent_setup
 authscheme = $auth_scheme
 '''
-    template = FileTemplate(webserver_file_template_str)
+    template = FileTemplate(webserver_file_template_str + tracing_file_template_str + rpc_file_template_str)
     skip = '1'
     if auth_enabled == "true":
         skip = '0'
@@ -379,7 +438,7 @@ port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
 '''
-    template = FileTemplate(taskserver_file_template_str)
+    template = FileTemplate(taskserver_file_template_str + tracing_file_template_str)
     result = template.substitute(**context)
     with open(output + "task.conf", 'w') as tmp_file:
         tmp_file.write(result)
@@ -474,7 +533,7 @@ def main(argv):
     ]
     usage = '''
     usage:
-      --discovery          <discovery>            the ZooKeeper server address, eg:127.0.0.1:2181
+      --discovery          <discovery>            the ZooKeeper server address, eg:127.0.0.1:2181, the etcd address, eg:etcd://127.0.0.1:2379, or the static discovery file, eg:file:///data/cmdb/discovery.yaml
       --database           <database>             the database name, default cmdb
       --redis_ip           <redis_ip>             the redis ip, eg:127.0.0.1
       --redis_port         <redis_port>           the redis port, default:6379
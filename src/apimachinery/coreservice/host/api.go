@@ -173,6 +173,20 @@ func (h *host) GetHostSnap(ctx context.Context, header http.Header, hostID strin
 	return resp, err
 }
 
+func (h *host) SearchHostSnapHistory(ctx context.Context, header http.Header, option *metadata.HostSnapHistoryOption) (resp *metadata.HostSnapHistoryResult, err error) {
+	resp = new(metadata.HostSnapHistoryResult)
+	subPath := "/findmany/host/snapshot/history"
+
+	err = h.client.Post().
+		Body(option).
+		WithContext(ctx).
+		SubResource(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
 func (h *host) LockHost(ctx context.Context, header http.Header, input *metadata.HostLockRequest) (resp *metadata.HostLockResponse, err error) {
 	resp = new(metadata.HostLockResponse)
 	subPath := "/find/host/lock"
@@ -147,3 +147,17 @@ func (s *operation) TimerFreshData(ctx context.Context, h http.Header, data inte
 		Into(resp)
 	return
 }
+
+func (s *operation) StatisticInventory(ctx context.Context, h http.Header, data *metadata.InventoryStatisticsOption) (resp *metadata.InventoryStatisticsResponse, err error) {
+	resp = new(metadata.InventoryStatisticsResponse)
+	subPath := "/read/operation/inventory/statistics"
+
+	err = s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
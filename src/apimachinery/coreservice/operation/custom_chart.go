@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/common/metadata"
+)
+
+func (s *operation) CreateCustomChart(ctx context.Context, h http.Header, data *metadata.CustomChart) (resp *metadata.CustomChartResponse, err error) {
+	resp = new(metadata.CustomChartResponse)
+	subPath := "/create/operation/custom_chart"
+
+	err = s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (s *operation) UpdateCustomChart(ctx context.Context, h http.Header, id uint64, data *metadata.CustomChart) (resp *metadata.CustomChartResponse, err error) {
+	resp = new(metadata.CustomChartResponse)
+	subPath := fmt.Sprintf("/update/operation/custom_chart/%d", id)
+
+	err = s.client.Put().
+		WithContext(ctx).
+		Body(data).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (s *operation) DeleteCustomChart(ctx context.Context, h http.Header, id uint64) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/delete/operation/custom_chart/%d", id)
+
+	err = s.client.Delete().
+		WithContext(ctx).
+		Body(nil).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (s *operation) SearchCustomChart(ctx context.Context, h http.Header, data *metadata.CustomChartSearchOption) (resp *metadata.SearchCustomChartResponse, err error) {
+	resp = new(metadata.SearchCustomChartResponse)
+	subPath := "/search/operation/custom_chart"
+
+	err = s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (s *operation) SearchCustomChartData(ctx context.Context, h http.Header, id uint64, data *metadata.CustomChartDataOption) (resp *metadata.CustomChartDataResponse, err error) {
+	resp = new(metadata.CustomChartDataResponse)
+	subPath := fmt.Sprintf("/search/operation/custom_chart/%d/data", id)
+
+	err = s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (s *operation) ComputeCustomChart(ctx context.Context, h http.Header, data *metadata.CustomChartComputeOption) (resp *metadata.CustomChartComputeResponse, err error) {
+	resp = new(metadata.CustomChartComputeResponse)
+	subPath := "/compute/operation/custom_chart"
+
+	err = s.client.Post().
+		WithContext(ctx).
+		Body(data).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -19,6 +19,15 @@ type OperationClientInterface interface {
 	UpdateChartPosition(ctx context.Context, h http.Header, data interface{}) (resp *metadata.Response, err error)
 	SearchChartCommon(ctx context.Context, h http.Header, data interface{}) (resp *metadata.SearchChartCommon, err error)
 	TimerFreshData(ctx context.Context, h http.Header, data interface{}) (resp *metadata.BoolResponse, err error)
+
+	CreateCustomChart(ctx context.Context, h http.Header, data *metadata.CustomChart) (resp *metadata.CustomChartResponse, err error)
+	UpdateCustomChart(ctx context.Context, h http.Header, id uint64, data *metadata.CustomChart) (resp *metadata.CustomChartResponse, err error)
+	DeleteCustomChart(ctx context.Context, h http.Header, id uint64) (resp *metadata.Response, err error)
+	SearchCustomChart(ctx context.Context, h http.Header, data *metadata.CustomChartSearchOption) (resp *metadata.SearchCustomChartResponse, err error)
+	SearchCustomChartData(ctx context.Context, h http.Header, id uint64, data *metadata.CustomChartDataOption) (resp *metadata.CustomChartDataResponse, err error)
+	ComputeCustomChart(ctx context.Context, h http.Header, data *metadata.CustomChartComputeOption) (resp *metadata.CustomChartComputeResponse, err error)
+
+	StatisticInventory(ctx context.Context, h http.Header, data *metadata.InventoryStatisticsOption) (resp *metadata.InventoryStatisticsResponse, err error)
 }
 
 func NewOperationClientInterface(client rest.ClientInterface) OperationClientInterface {
@@ -389,6 +389,29 @@ func (p *process) UpdateProcessTemplate(ctx context.Context, h http.Header, temp
 	return &ret.Data, nil
 }
 
+func (p *process) AcceptProcessTemplateUpstream(ctx context.Context, h http.Header, templateID int64, option *metadata.AcceptProcessTemplateUpstreamOption) (*metadata.ProcessTemplate, errors.CCErrorCoder) {
+	ret := new(metadata.OneProcessTemplateResult)
+	subPath := fmt.Sprintf("/update/process/process_template/%d/accept_upstream", templateID)
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("AcceptProcessTemplateUpstream failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
 func (p *process) DeleteProcessTemplate(ctx context.Context, h http.Header, templateID int64) errors.CCErrorCoder {
 	ret := new(metadata.OneProcessTemplateResult)
 	subPath := fmt.Sprintf("/delete/process/process_template/%d", templateID)
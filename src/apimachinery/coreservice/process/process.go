@@ -39,10 +39,24 @@ type ProcessInterface interface {
 	ListServiceTemplates(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateOption) (*metadata.MultipleServiceTemplate, errors.CCErrorCoder)
 	DeleteServiceTemplate(ctx context.Context, h http.Header, serviceTemplateID int64) errors.CCErrorCoder
 
+	// service template version
+	CreateServiceTemplateVersion(ctx context.Context, h http.Header, option *metadata.CreateServiceTemplateVersionOption) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder)
+	GetServiceTemplateVersion(ctx context.Context, h http.Header, templateID int64, version int64) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder)
+	ListServiceTemplateVersions(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateVersionOption) (*metadata.MultipleServiceTemplateVersion, errors.CCErrorCoder)
+	PinModuleServiceTemplateVersion(ctx context.Context, h http.Header, option *metadata.PinModuleServiceTemplateVersionOption) errors.CCErrorCoder
+	ListModuleServiceTemplateVersions(ctx context.Context, h http.Header, option *metadata.ListModuleServiceTemplateVersionOption) ([]metadata.ModuleServiceTemplateVersion, errors.CCErrorCoder)
+
+	// service template rollout
+	CreateServiceTemplateRollout(ctx context.Context, h http.Header, rollout *metadata.ServiceTemplateRollout) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+	GetServiceTemplateRollout(ctx context.Context, h http.Header, rolloutID int64) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+	ListServiceTemplateRollouts(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateRolloutOption) (*metadata.MultipleServiceTemplateRollout, errors.CCErrorCoder)
+	UpdateServiceTemplateRollout(ctx context.Context, h http.Header, rolloutID int64, option *metadata.UpdateServiceTemplateRolloutOption) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+
 	// process template
 	CreateProcessTemplate(ctx context.Context, h http.Header, template *metadata.ProcessTemplate) (*metadata.ProcessTemplate, errors.CCErrorCoder)
 	GetProcessTemplate(ctx context.Context, h http.Header, templateID int64) (*metadata.ProcessTemplate, errors.CCErrorCoder)
 	UpdateProcessTemplate(ctx context.Context, h http.Header, templateID int64, property map[string]interface{}) (*metadata.ProcessTemplate, errors.CCErrorCoder)
+	AcceptProcessTemplateUpstream(ctx context.Context, h http.Header, templateID int64, option *metadata.AcceptProcessTemplateUpstreamOption) (*metadata.ProcessTemplate, errors.CCErrorCoder)
 	ListProcessTemplates(ctx context.Context, h http.Header, option *metadata.ListProcessTemplatesOption) (*metadata.MultipleProcessTemplate, errors.CCErrorCoder)
 	DeleteProcessTemplate(ctx context.Context, h http.Header, processTemplateID int64) errors.CCErrorCoder
 	DeleteProcessTemplateBatch(ctx context.Context, h http.Header, processTemplateIDs []int64) errors.CCErrorCoder
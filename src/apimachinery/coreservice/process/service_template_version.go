@@ -0,0 +1,227 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package process
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+func (p *process) CreateServiceTemplateVersion(ctx context.Context, h http.Header, option *metadata.CreateServiceTemplateVersionOption) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder) {
+	ret := new(metadata.OneServiceTemplateVersionResult)
+	subPath := "/create/process/service_template_version"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) GetServiceTemplateVersion(ctx context.Context, h http.Header, templateID int64, version int64) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder) {
+	ret := new(metadata.OneServiceTemplateVersionResult)
+	subPath := fmt.Sprintf("/find/process/service_template/%d/version/%d", templateID, version)
+
+	err := p.client.Get().
+		WithContext(ctx).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("GetServiceTemplateVersion failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) ListServiceTemplateVersions(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateVersionOption) (*metadata.MultipleServiceTemplateVersion, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleServiceTemplateVersionResult)
+	subPath := "/findmany/process/service_template_version"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("ListServiceTemplateVersions failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) PinModuleServiceTemplateVersion(ctx context.Context, h http.Header, option *metadata.PinModuleServiceTemplateVersionOption) errors.CCErrorCoder {
+	ret := new(metadata.BaseResp)
+	subPath := "/update/process/module_service_template_version"
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("PinModuleServiceTemplateVersion failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return nil
+}
+
+func (p *process) ListModuleServiceTemplateVersions(ctx context.Context, h http.Header, option *metadata.ListModuleServiceTemplateVersionOption) ([]metadata.ModuleServiceTemplateVersion, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleModuleServiceTemplateVersionResult)
+	subPath := "/findmany/process/module_service_template_version"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("ListModuleServiceTemplateVersions failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return ret.Data, nil
+}
+
+func (p *process) CreateServiceTemplateRollout(ctx context.Context, h http.Header, rollout *metadata.ServiceTemplateRollout) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	ret := new(metadata.OneServiceTemplateRolloutResult)
+	subPath := "/create/process/service_template_rollout"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(rollout).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) GetServiceTemplateRollout(ctx context.Context, h http.Header, rolloutID int64) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	ret := new(metadata.OneServiceTemplateRolloutResult)
+	subPath := fmt.Sprintf("/find/process/service_template_rollout/%d", rolloutID)
+
+	err := p.client.Get().
+		WithContext(ctx).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("GetServiceTemplateRollout failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) ListServiceTemplateRollouts(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateRolloutOption) (*metadata.MultipleServiceTemplateRollout, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleServiceTemplateRolloutResult)
+	subPath := "/findmany/process/service_template_rollout"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("ListServiceTemplateRollouts failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) UpdateServiceTemplateRollout(ctx context.Context, h http.Header, rolloutID int64, option *metadata.UpdateServiceTemplateRolloutOption) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	ret := new(metadata.OneServiceTemplateRolloutResult)
+	subPath := fmt.Sprintf("/update/process/service_template_rollout/%d", rolloutID)
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("UpdateServiceTemplateRollout failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.Result == false || ret.Code != 0 {
+		return nil, errors.New(ret.Code, ret.ErrMsg)
+	}
+
+	return &ret.Data, nil
+}
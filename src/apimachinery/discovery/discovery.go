@@ -17,7 +17,6 @@ import (
 	"strings"
 
 	"configcenter/src/common"
-	"configcenter/src/common/backbone/service_mange/zk"
 	"configcenter/src/common/registerdiscover"
 	"configcenter/src/common/types"
 )
@@ -40,6 +39,7 @@ type DiscoveryInterface interface {
 	CoreService() Interface
 	OperationServer() Interface
 	TaskServer() Interface
+	WebServer() Interface
 	ServiceManageInterface
 }
 
@@ -48,16 +48,11 @@ type Interface interface {
 }
 
 // NewServiceDiscovery new a simple discovery module which can be used to get alive server address
-func NewServiceDiscovery(client *zk.ZkClient) (DiscoveryInterface, error) {
-	disc := registerdiscover.NewRegDiscoverEx(client)
-
+func NewServiceDiscovery(disc *registerdiscover.RegDiscover) (DiscoveryInterface, error) {
 	d := &discover{
 		servers: make(map[string]*server),
 	}
 	for component := range types.AllModule {
-		if component == types.CC_MODULE_WEBSERVER {
-			continue
-		}
 		path := fmt.Sprintf("%s/%s", types.CC_SERV_BASEPATH, component)
 		svr, err := newServerDiscover(disc, path, component)
 		if err != nil {
@@ -138,6 +133,10 @@ func (d *discover) TaskServer() Interface {
 	return d.servers[types.CC_MODULE_TASK]
 }
 
+func (d *discover) WebServer() Interface {
+	return d.servers[types.CC_MODULE_WEBSERVER]
+}
+
 // IsMaster check whether current is master
 func (d *discover) IsMaster() bool {
 	return d.servers[common.GetIdentification()].IsMaster(common.GetServerInfo().Address())
@@ -82,6 +82,10 @@ func (d *MockDiscovery) TaskServer() Interface {
 	return &mockServer{}
 }
 
+func (d *MockDiscovery) WebServer() Interface {
+	return &mockServer{}
+}
+
 func (d *MockDiscovery) IsMaster() bool {
 	return true
 }
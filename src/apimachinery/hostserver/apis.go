@@ -62,6 +62,20 @@ func (hs *hostServer) HostSnapInfo(ctx context.Context, hostID string, h http.He
 	return
 }
 
+func (hs *hostServer) HostChangeTimeline(ctx context.Context, hostID string, h http.Header, option *metadata.HostChangeTimelineOption) (resp *metadata.HostChangeTimelineResult, err error) {
+	resp = new(metadata.HostChangeTimelineResult)
+	subPath := fmt.Sprintf("/hosts/snapshot/%s/timeline", hostID)
+
+	err = hs.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 func (hs *hostServer) AddHost(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.Response, err error) {
 	resp = new(metadata.Response)
 	subPath := "/hosts/add"
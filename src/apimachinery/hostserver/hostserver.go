@@ -27,6 +27,7 @@ type HostServerClientInterface interface {
 	DeleteHostBatch(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.Response, err error)
 	GetHostInstanceProperties(ctx context.Context, ownerID string, hostID string, h http.Header) (resp *metadata.HostInstancePropertiesResult, err error)
 	HostSnapInfo(ctx context.Context, hostID string, h http.Header, dat interface{}) (resp *metadata.HostSnapResult, err error)
+	HostChangeTimeline(ctx context.Context, hostID string, h http.Header, option *metadata.HostChangeTimelineOption) (resp *metadata.HostChangeTimelineResult, err error)
 	AddHost(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.Response, err error)
 	AddHostFromAgent(ctx context.Context, h http.Header, dat interface{}) (resp *metadata.Response, err error)
 	SyncHost(ctx context.Context, h http.Header, data interface{}) (resp *metadata.Response, err error)
@@ -33,6 +33,7 @@ import (
 
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/tracing"
 	commonUtil "configcenter/src/common/util"
 )
 
@@ -213,6 +214,23 @@ func (r *Request) WrapURL() *url.URL {
 	return finalUrl
 }
 
+// startSpan start a client span as the child of the span in the context, or the upstream span in the header
+func (r *Request) startSpan() *tracing.Span {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	parent := tracing.ParentFromContext(ctx)
+	if !parent.IsValid() {
+		parent = tracing.Extract(r.headers)
+	}
+	_, span := tracing.StartSpanWithParent(ctx, parent, string(r.verb)+" "+r.subPath, tracing.SpanKindClient)
+	span.SetAttribute(tracing.AttrHTTPMethod, string(r.verb))
+	span.SetAttribute(tracing.AttrHTTPRoute, r.subPath)
+	span.SetAttribute(tracing.AttrRequestID, commonUtil.GetHTTPCCRequestID(r.headers))
+	return span
+}
+
 func (r *Request) Do() *Result {
 	result := new(Result)
 
@@ -246,6 +264,17 @@ func (r *Request) Do() *Result {
 		client = http.DefaultClient
 	}
 
+	span := r.startSpan()
+	defer func() {
+		span.SetAttribute(tracing.AttrHTTPStatusCode, result.StatusCode)
+		if result.Err != nil {
+			span.SetError(result.Err)
+		} else if result.StatusCode >= http.StatusBadRequest {
+			span.SetStatus(tracing.StatusError, result.Status)
+		}
+		span.End()
+	}()
+
 	hosts, err := r.capability.Discover.GetServers()
 	if err != nil {
 		result.Err = err
@@ -274,6 +303,7 @@ func (r *Request) Do() *Result {
 			}
 			req.Header.Set("Content-Type", "application/json")
 			req.Header.Set("Accept", "application/json")
+			tracing.Inject(span.SpanContext(), req.Header)
 
 			if retries > 0 {
 				r.tryThrottle(url)
@@ -917,7 +917,8 @@ func (ps *parseStream) cloudResourceSync() *parseStream {
 }
 
 var (
-	findHostSnapshotAPIRegexp = regexp.MustCompile(`^/api/v3/hosts/snapshot/[0-9]+/?$`)
+	findHostSnapshotAPIRegexp       = regexp.MustCompile(`^/api/v3/hosts/snapshot/[0-9]+/?$`)
+	findHostChangeTimelineAPIRegexp = regexp.MustCompile(`^/api/v3/hosts/snapshot/[0-9]+/timeline/?$`)
 )
 
 func (ps *parseStream) hostSnapshot() *parseStream {
@@ -941,6 +942,19 @@ func (ps *parseStream) hostSnapshot() *parseStream {
 		}
 		return ps
 	}
+
+	// the host server authorize the host itself
+	if ps.hitRegexp(findHostChangeTimelineAPIRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
 	return ps
 }
 
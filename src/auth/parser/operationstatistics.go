@@ -25,6 +25,12 @@ import (
  http.MethodPost,  "/update/operation/chart"
  http.MethodGet,  "/search/operation/chart"
  http.MethodPost,  "/search/operation/chart/data"
+ http.MethodPost,  "/create/operation/custom_chart"
+ http.MethodPut,  "/update/operation/custom_chart/{id}"
+ http.MethodDelete,  "/delete/operation/custom_chart/{id}"
+ http.MethodPost,  "/search/operation/custom_chart"
+ http.MethodPost,  "/search/operation/custom_chart/{id}/data"
+ http.MethodPost,  "/compute/operation/custom_chart"
 */
 var OperationStatisticAuthConfigs = []AuthConfig{
 	{
@@ -81,6 +87,60 @@ var OperationStatisticAuthConfigs = []AuthConfig{
 		ResourceType:   meta.OperationStatistic,
 		ResourceAction: meta.Update,
 	},
+	{
+		Name:           "CreateOperationCustomChartRegex",
+		Description:    "创建自定义运营图表",
+		Regex:          regexp.MustCompile(`^/api/v3/create/operation/custom_chart/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Update,
+	},
+	{
+		Name:           "UpdateOperationCustomChartRegex",
+		Description:    "更新自定义运营图表",
+		Regex:          regexp.MustCompile(`^/api/v3/update/operation/custom_chart/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodPut,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Update,
+	},
+	{
+		Name:           "DeleteOperationCustomChartRegex",
+		Description:    "删除自定义运营图表",
+		Regex:          regexp.MustCompile(`^/api/v3/delete/operation/custom_chart/([0-9]+)/?$`),
+		HTTPMethod:     http.MethodDelete,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Update,
+	},
+	{
+		Name:           "SearchOperationCustomChartRegex",
+		Description:    "查看自定义运营图表",
+		Regex:          regexp.MustCompile(`^/api/v3/search/operation/custom_chart/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Find,
+	},
+	{
+		Name:           "SearchOperationCustomChartDataRegex",
+		Description:    "查看自定义运营图表数据",
+		Regex:          regexp.MustCompile(`^/api/v3/search/operation/custom_chart/([0-9]+)/data/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Find,
+	},
+	{
+		Name:           "ComputeOperationCustomChartRegex",
+		Description:    "立即计算自定义运营图表",
+		Regex:          regexp.MustCompile(`^/api/v3/compute/operation/custom_chart/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    nil,
+		ResourceType:   meta.OperationStatistic,
+		ResourceAction: meta.Update,
+	},
 }
 
 func (ps *parseStream) OperationStatistic() *parseStream {
@@ -53,6 +53,14 @@ var ProcessInstanceAuthConfigs = []AuthConfig{
 		BizIDGetter:    DefaultBizIDGetter,
 		ResourceType:   ProcessInstanceIAMResourceType,
 		ResourceAction: meta.Find,
+	}, {
+		Name:           "listProcessBindConflicts",
+		Description:    "查找进程端口冲突",
+		Pattern:        "/api/v3/findmany/proc/process_instance/bind_conflict",
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   ProcessInstanceIAMResourceType,
+		ResourceAction: meta.Find,
 	},
 }
 
@@ -108,6 +108,38 @@ var ProcessTemplateAuthConfigs = []AuthConfig{
 			blog.Errorf("unexpected error: this code shouldn't be reached, rid: %s", request.Rid)
 			return nil, errors.New("unexpected error: this code shouldn't be reached")
 		},
+	}, {
+		Name:         "createGlobalProcessTemplateBatchPattern",
+		Description:  "创建全局进程模板",
+		Pattern:      "/api/v3/createmany/proc/global/proc_template",
+		HTTPMethod:   http.MethodPost,
+		ResourceType: meta.ProcessTemplate,
+		// authorization should implements in scene server
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:         "updateGlobalProcessTemplatePattern",
+		Description:  "更新全局进程模板",
+		Pattern:      "/api/v3/update/proc/global/proc_template",
+		HTTPMethod:   http.MethodPut,
+		ResourceType: meta.ProcessTemplate,
+		// authorization should implements in scene server
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:         "deleteGlobalProcessTemplateBatchPattern",
+		Description:  "删除全局进程模板",
+		Pattern:      "/api/v3/deletemany/proc/global/proc_template",
+		HTTPMethod:   http.MethodDelete,
+		ResourceType: meta.ProcessTemplate,
+		// authorization should implements in scene server
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:         "findGlobalProcessTemplateBatchPattern",
+		Description:  "查找全局进程模板",
+		Pattern:      "/api/v3/findmany/proc/global/proc_template",
+		HTTPMethod:   http.MethodPost,
+		ResourceType: meta.ProcessTemplate,
+		// global process templates are visible to all the businesses
+		ResourceAction: meta.SkipAction,
 	},
 }
 
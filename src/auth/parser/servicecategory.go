@@ -44,6 +44,13 @@ var ServiceCategoryAuthConfigs = []AuthConfig{
 		BizIDGetter:    DefaultBizIDGetter,
 		ResourceType:   meta.ProcessServiceCategory,
 		ResourceAction: meta.Create,
+	}, {
+		Name:           "createGlobalServiceCategoryPattern",
+		Description:    "创建全局服务分类",
+		Pattern:        "/api/v3/create/proc/global/service_category",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ProcessServiceCategory,
+		ResourceAction: meta.Create,
 	}, {
 		Name:           "deleteServiceCategoryPattern",
 		Description:    "修改服务分类",
@@ -140,9 +140,179 @@ var ServiceTemplateAuthConfigs = []AuthConfig{
 			}
 			return []int64{templateID}, nil
 		},
+	}, {
+		Name:             "createServiceTemplateVersionPattern",
+		Description:      "创建服务模板版本",
+		Pattern:          "/api/v3/create/proc/service_template_version",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: serviceTemplateIDFromBody,
+	}, {
+		Name:           "getServiceTemplateVersion",
+		Description:    "获取服务模板版本",
+		Regex:          regexp.MustCompile(`^/api/v3/find/proc/service_template/([0-9]+)/version/[0-9]+$`),
+		HTTPMethod:     http.MethodGet,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.Find,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) (int64s []int64, e error) {
+			subMatch := re.FindStringSubmatch(request.URI)
+			if len(subMatch) != 2 {
+				return nil, errors.New("invalid service template")
+			}
+			id, err := strconv.ParseInt(subMatch[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse template id to int64 failed, err: %s", err)
+			}
+			return []int64{id}, nil
+		},
+	}, {
+		Name:         "listServiceTemplateVersionPattern",
+		Description:  "查询服务模板版本",
+		Pattern:      "/api/v3/findmany/proc/service_template_version",
+		HTTPMethod:   http.MethodPost,
+		BizIDGetter:  DefaultBizIDGetter,
+		ResourceType: meta.ProcessServiceTemplate,
+		// authorization should implements in scene server
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:             "pinServiceTemplateVersionPattern",
+		Description:      "固定模块使用的服务模板版本",
+		Pattern:          "/api/v3/update/proc/service_template_version/pin",
+		HTTPMethod:       http.MethodPut,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: serviceTemplateIDFromBody,
+	}, {
+		Name:         "listServiceTemplateVersionPinPattern",
+		Description:  "查询模块使用的服务模板版本",
+		Pattern:      "/api/v3/findmany/proc/service_template_version/pin",
+		HTTPMethod:   http.MethodPost,
+		BizIDGetter:  DefaultBizIDGetter,
+		ResourceType: meta.ProcessServiceTemplate,
+		// authorization should implements in scene server
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:             "createServiceTemplateRolloutPattern",
+		Description:      "创建服务模板版本发布",
+		Pattern:          "/api/v3/create/proc/service_template_rollout",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: serviceTemplateIDFromBody,
+	}, {
+		Name:             "getServiceTemplateRolloutPattern",
+		Description:      "获取服务模板版本发布",
+		Pattern:          "/api/v3/find/proc/service_template_rollout",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Find,
+		InstanceIDGetter: serviceTemplateIDFromBody,
+	}, {
+		Name:         "listServiceTemplateRolloutPattern",
+		Description:  "查询服务模板版本发布",
+		Pattern:      "/api/v3/findmany/proc/service_template_rollout",
+		HTTPMethod:   http.MethodPost,
+		BizIDGetter:  DefaultBizIDGetter,
+		ResourceType: meta.ProcessServiceTemplate,
+		// authorization should implements in scene server
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:             "operateServiceTemplateRolloutRegex",
+		Description:      "暂停、继续或终止服务模板版本发布",
+		Regex:            regexp.MustCompile(`^/api/v3/update/proc/service_template_rollout/(pause|resume|abort)$`),
+		HTTPMethod:       http.MethodPut,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: serviceTemplateIDFromBody,
+	}, {
+		Name:           "createGlobalServiceTemplatePattern",
+		Description:    "创建全局服务模板",
+		Pattern:        "/api/v3/create/proc/global/service_template",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.Create,
+	}, {
+		Name:           "updateGlobalServiceTemplatePattern",
+		Description:    "更新全局服务模板",
+		Pattern:        "/api/v3/update/proc/global/service_template",
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.Update,
+		InstanceIDGetter: func(request *RequestContext, re *regexp.Regexp) (int64s []int64, e error) {
+			templateID := gjson.GetBytes(request.Body, common.BKFieldID).Int()
+			if templateID <= 0 {
+				return nil, errors.New("invalid service template")
+			}
+			return []int64{templateID}, nil
+		},
+	}, {
+		Name:             "deleteGlobalServiceTemplatePattern",
+		Description:      "删除全局服务模板",
+		Pattern:          "/api/v3/delete/proc/global/service_template",
+		HTTPMethod:       http.MethodDelete,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Delete,
+		InstanceIDGetter: serviceTemplateIDFromBody,
+	}, {
+		Name:         "listGlobalServiceTemplatePattern",
+		Description:  "查询全局服务模板",
+		Pattern:      "/api/v3/findmany/proc/global/service_template",
+		HTTPMethod:   http.MethodPost,
+		ResourceType: meta.ProcessServiceTemplate,
+		// global service templates are visible to all the businesses
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:             "listDownstreamServiceTemplatePattern",
+		Description:      "查询由全局服务模板创建的业务服务模板",
+		Pattern:          "/api/v3/findmany/proc/global/service_template/downstream",
+		HTTPMethod:       http.MethodPost,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Find,
+		InstanceIDGetter: serviceTemplateIDFromBody,
+	}, {
+		Name:           "createServiceTemplateFromGlobalPattern",
+		Description:    "从全局服务模板创建服务模板",
+		Pattern:        "/api/v3/create/proc/service_template/from_global",
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.Create,
+	}, {
+		Name:             "diffServiceTemplateWithUpstreamPattern",
+		Description:      "对比服务模板与全局服务模板的差异",
+		Pattern:          "/api/v3/find/proc/service_template/upstream_difference",
+		HTTPMethod:       http.MethodPost,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Find,
+		InstanceIDGetter: serviceTemplateIDFromBody,
+	}, {
+		Name:             "acceptServiceTemplateUpstreamPattern",
+		Description:      "接受全局服务模板的变更",
+		Pattern:          "/api/v3/update/proc/service_template/upstream_difference",
+		HTTPMethod:       http.MethodPut,
+		BizIDGetter:      DefaultBizIDGetter,
+		ResourceType:     meta.ProcessServiceTemplate,
+		ResourceAction:   meta.Update,
+		InstanceIDGetter: serviceTemplateIDFromBody,
 	},
 }
 
+func serviceTemplateIDFromBody(request *RequestContext, re *regexp.Regexp) (int64s []int64, e error) {
+	templateID := gjson.GetBytes(request.Body, common.BKServiceTemplateIDField).Int()
+	if templateID <= 0 {
+		return nil, errors.New("invalid service template")
+	}
+	return []int64{templateID}, nil
+}
+
 func (ps *parseStream) ServiceTemplate() *parseStream {
 	return ParseStreamWithFramework(ps, ServiceTemplateAuthConfigs)
 }
@@ -17,18 +17,17 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
-	"time"
 
 	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/common"
 	cc "configcenter/src/common/backbone/configcenter"
-	"configcenter/src/common/backbone/service_mange/zk"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
 	"configcenter/src/common/metrics"
+	"configcenter/src/common/tracing"
 	"configcenter/src/common/types"
 )
 
@@ -49,28 +48,6 @@ type BackboneParameter struct {
 	SrvInfo *types.ServerInfo
 }
 
-func newSvcManagerClient(ctx context.Context, svcManagerAddr string) (*zk.ZkClient, error) {
-	var err error
-	for retry := 0; retry < maxRetry; retry++ {
-		client := zk.NewZkClient(svcManagerAddr, 5*time.Second)
-		if err = client.Start(); err != nil {
-			blog.Errorf("connect regdiscv [%s] failed: %v", svcManagerAddr, err)
-			time.Sleep(time.Second * 2)
-			continue
-		}
-
-		if err = client.Ping(); err != nil {
-			blog.Errorf("connect regdiscv [%s] failed: %v", svcManagerAddr, err)
-			time.Sleep(time.Second * 2)
-			continue
-		}
-
-		return client, nil
-	}
-
-	return nil, err
-}
-
 func newConfig(ctx context.Context, srvInfo *types.ServerInfo, discovery discovery.DiscoveryInterface, apiMachineryConfig *util.APIMachineryConfig) (*Config, error) {
 
 	machinery, err := apimachinery.NewApiMachinery(apiMachineryConfig, discovery)
@@ -118,11 +95,11 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	if err != nil {
 		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", input.Regdiscv, err)
 	}
-	serviceDiscovery, err := discovery.NewServiceDiscovery(client)
+	serviceDiscovery, err := discovery.NewServiceDiscovery(client.RegDiscover())
 	if err != nil {
 		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", input.Regdiscv, err)
 	}
-	disc, err := NewServiceRegister(client)
+	disc, err := NewServiceRegister(client.RegDiscover())
 	if err != nil {
 		return nil, fmt.Errorf("new service discover failed, err:%v", err)
 	}
@@ -148,17 +125,17 @@ func NewBackbone(ctx context.Context, input *BackboneParameter) (*Engine, error)
 	engine.metric = metricService
 
 	handler := &cc.CCHandler{
-		OnProcessUpdate:  input.ConfigUpdate,
+		OnProcessUpdate:  engine.onProcessUpdate(input.ConfigUpdate),
 		OnLanguageUpdate: engine.onLanguageUpdate,
 		OnErrorUpdate:    engine.onErrorUpdate,
 	}
 
-	err = cc.NewConfigCenter(ctx, client, common.GetIdentification(), input.ConfigPath, handler)
+	err = cc.New(ctx, common.GetIdentification(), input.ConfigPath, client.ConfRegDiscover(), handler)
 	if err != nil {
 		return nil, fmt.Errorf("new config center failed, err: %v", err)
 	}
 
-	err = handleNotice(ctx, client.Client(), input.SrvInfo.Instance())
+	err = handleNotice(ctx, client, input.SrvInfo.Instance())
 	if err != nil {
 		return nil, fmt.Errorf("handle notice failed, err: %v", err)
 	}
@@ -170,7 +147,7 @@ func StartServer(ctx context.Context, cancel context.CancelFunc, e *Engine, HTTP
 	e.server = Server{
 		ListenAddr:   e.srvInfo.IP,
 		ListenPort:   e.srvInfo.Port,
-		Handler:      e.Metric().HTTPMiddleware(HTTPHandler),
+		Handler:      tracing.HTTPMiddleware(e.Metric().HTTPMiddleware(HTTPHandler)),
 		TLS:          TLSConfig{},
 		PProfEnabled: pprofEnabled,
 	}
@@ -197,14 +174,21 @@ type Engine struct {
 	CoreAPI            apimachinery.ClientSetInterface
 	apiMachineryConfig *util.APIMachineryConfig
 
-	client                 *zk.ZkClient
+	client                 *ServiceManageClient
 	ServiceManageInterface discovery.ServiceManageInterface
 	SvcDisc                ServiceRegisterInterface
 	discovery              discovery.DiscoveryInterface
 	metric                 *metrics.Service
+	tracingConfig          tracing.Config
 
 	sync.Mutex
 
+	// hookLock serializes the registration of the config update hooks with the config updates,
+	// so that a hook never misses the config or sees an older one after a newer one.
+	hookLock    sync.Mutex
+	configHooks []func(configMap map[string]string)
+	configMap   map[string]string
+
 	ServerInfo types.ServerInfo
 	server     Server
 	srvInfo    *types.ServerInfo
@@ -222,7 +206,7 @@ func (e *Engine) ApiMachineryConfig() *util.APIMachineryConfig {
 	return e.apiMachineryConfig
 }
 
-func (e *Engine) ServiceManageClient() *zk.ZkClient {
+func (e *Engine) ServiceManageClient() *ServiceManageClient {
 	return e.client
 }
 
@@ -230,6 +214,55 @@ func (e *Engine) Metric() *metrics.Service {
 	return e.metric
 }
 
+// onProcessUpdate reload the common process config before the config is handled by the service
+func (e *Engine) onProcessUpdate(handler cc.ProcHandlerFunc) cc.ProcHandlerFunc {
+	return func(previous, current cc.ProcessConfig) {
+		e.reloadTracing(current.ConfigMap)
+
+		e.hookLock.Lock()
+		e.configMap = current.ConfigMap
+		for _, hook := range e.configHooks {
+			hook(current.ConfigMap)
+		}
+		e.hookLock.Unlock()
+
+		handler(previous, current)
+	}
+}
+
+// AddConfigUpdateHook registers a hook called with the process config map every time it is updated,
+// it is used by the components to reload their own config without the backbone knowing about them.
+// the hook is called at once with the latest config map if the config has been received already.
+func (e *Engine) AddConfigUpdateHook(hook func(configMap map[string]string)) {
+	e.hookLock.Lock()
+	defer e.hookLock.Unlock()
+	e.configHooks = append(e.configHooks, hook)
+	if e.configMap != nil {
+		hook(e.configMap)
+	}
+}
+
+func (e *Engine) reloadTracing(configMap map[string]string) {
+	conf, err := tracing.ParseConfigFromKV("tracing", configMap)
+	if err != nil {
+		blog.Errorf("parse tracing config failed, err: %v", err)
+		return
+	}
+	conf.ServiceName = common.GetIdentification()
+
+	e.Lock()
+	defer e.Unlock()
+	if conf == e.tracingConfig {
+		return
+	}
+	if err := tracing.Init(conf); err != nil {
+		blog.Errorf("init tracing failed, config: %+v, err: %v", conf, err)
+		return
+	}
+	e.tracingConfig = conf
+	blog.Infof("init tracing success, enable: %v, exporter: %s", conf.Enable, conf.Exporter)
+}
+
 func (e *Engine) onLanguageUpdate(previous, current map[string]language.LanguageMap) {
 	e.Lock()
 	defer e.Unlock()
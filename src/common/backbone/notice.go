@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"configcenter/src/common/blog"
+	"configcenter/src/common/etcdclient"
 	"configcenter/src/common/types"
 	"configcenter/src/common/zkclient"
 
@@ -26,15 +27,26 @@ import (
 )
 
 type noticeHandler struct {
-	client   *zkclient.ZkClient
-	addrport string
+	client     *zkclient.ZkClient
+	etcdClient *etcdclient.EtcdClient
+	addrport   string
 }
 
-func handleNotice(ctx context.Context, client *zkclient.ZkClient, addrport string) error {
+func handleNotice(ctx context.Context, client *ServiceManageClient, addrport string) error {
 	handler := &noticeHandler{
-		client:   client,
 		addrport: addrport,
 	}
+	if client.IsEtcd() {
+		handler.etcdClient = client.EtcdClient().Client()
+		return handler.handleEtcdLogNotice(ctx)
+	}
+	if client.IsFile() {
+		// there is no shared storage to publish the log level in the static discovery mode
+		blog.Infof("the log level notice is not supported by the static discovery, skip it")
+		return nil
+	}
+
+	handler.client = client.ZkClient().Client()
 	if err := handler.handleLogNotice(ctx); err != nil {
 		return err
 	}
@@ -98,3 +110,76 @@ func (handler *noticeHandler) handleLogNotice(ctx context.Context) error {
 	}()
 	return nil
 }
+
+// handleEtcdLogNotice watch the log level notice in etcd, the notice key is created with the current level
+func (handler *noticeHandler) handleEtcdLogNotice(ctx context.Context) error {
+	logVPath := fmt.Sprintf("%s/%s/%s/v", types.CC_SERVNOTICE_BASEPATH, "log", handler.addrport)
+	data := map[string]int32{
+		"defaultV": blog.GetV(),
+		"v":        blog.GetV(),
+	}
+	go func() {
+		defer handler.etcdClient.Delete(logVPath)
+		for {
+			kv, err := handler.etcdClient.Get(logVPath)
+			if err != nil {
+				if err != etcdclient.ErrKeyNotFound {
+					blog.Errorf("log watch failed, will watch after 10s, path: %s, err: %v", logVPath, err)
+					if !sleepWithContext(ctx, 10*time.Second) {
+						return
+					}
+					continue
+				}
+				data["v"] = blog.GetV()
+				logVData, _ := json.Marshal(data)
+				if err := handler.etcdClient.Put(logVPath, logVData, 0); err != nil {
+					blog.Errorf("fail to register node(%s), err:%s\n", logVPath, err.Error())
+					if !sleepWithContext(ctx, 10*time.Second) {
+						return
+					}
+				}
+				continue
+			}
+
+			watchCtx, cancel := context.WithCancel(ctx)
+			ch, err := handler.etcdClient.Watch(watchCtx, logVPath, false, kv.ModRevision+1)
+			if err != nil {
+				cancel()
+				blog.Errorf("log watch failed, will watch after 10s, path: %s, err: %v", logVPath, err)
+				if !sleepWithContext(ctx, 10*time.Second) {
+					return
+				}
+				continue
+			}
+
+			select {
+			case resp := <-ch:
+				cancel()
+				for _, event := range resp.Events {
+					if event.Type != etcdclient.EventPut {
+						continue
+					}
+					if err := json.Unmarshal(event.KV.Value, &data); err != nil {
+						blog.Errorf("fail to unmarshal data(%s), err:%s\n", event.KV.Value, err.Error())
+						continue
+					}
+					blog.SetV(data["v"])
+				}
+			case <-ctx.Done():
+				cancel()
+				blog.Warnf("log watch stopped because of context done.")
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func sleepWithContext(ctx context.Context, duration time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
@@ -15,7 +15,6 @@ package backbone
 import (
 	"encoding/json"
 
-	"configcenter/src/common/backbone/service_mange/zk"
 	"configcenter/src/common/registerdiscover"
 	"configcenter/src/common/types"
 	"configcenter/src/framework/core/errors"
@@ -32,9 +31,9 @@ type ServiceRegisterInterface interface {
 	ClearRegisterPath() error
 }
 
-func NewServiceRegister(client *zk.ZkClient) (ServiceRegisterInterface, error) {
+func NewServiceRegister(client *registerdiscover.RegDiscover) (ServiceRegisterInterface, error) {
 	s := new(serviceRegister)
-	s.client = registerdiscover.NewRegDiscoverEx(client)
+	s.client = client
 	return s, nil
 }
 
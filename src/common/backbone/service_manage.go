@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backbone
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common/backbone/service_mange/etcd"
+	"configcenter/src/common/backbone/service_mange/file"
+	"configcenter/src/common/backbone/service_mange/zk"
+	"configcenter/src/common/blog"
+	crd "configcenter/src/common/confregdiscover"
+	"configcenter/src/common/registerdiscover"
+)
+
+// ServiceManageClient the client of the service register and discover center, the center is etcd if the
+// address is started with etcd:// or etcds://, the static discovery file if the address is started with
+// file://, otherwise it's zookeeper.
+type ServiceManageClient struct {
+	zk   *zk.ZkClient
+	etcd *etcd.EtcdClient
+	file *file.FileClient
+}
+
+func newSvcManagerClient(ctx context.Context, svcManagerAddr string) (*ServiceManageClient, error) {
+	var err error
+	for retry := 0; retry < maxRetry; retry++ {
+		client := new(ServiceManageClient)
+		switch {
+		case etcd.IsEtcdAddress(svcManagerAddr):
+			client.etcd, err = etcd.NewEtcdClient(svcManagerAddr, 10*time.Second)
+			if err != nil {
+				return nil, err
+			}
+			err = client.etcd.Start()
+		case file.IsFileAddress(svcManagerAddr):
+			client.file, err = file.NewFileClient(svcManagerAddr)
+			if err != nil {
+				return nil, err
+			}
+			err = client.file.Start()
+		default:
+			client.zk = zk.NewZkClient(svcManagerAddr, 5*time.Second)
+			err = client.zk.Start()
+		}
+		if err != nil {
+			blog.Errorf("connect regdiscv [%s] failed: %v", svcManagerAddr, err)
+			time.Sleep(time.Second * 2)
+			continue
+		}
+
+		if err = client.Ping(); err != nil {
+			blog.Errorf("connect regdiscv [%s] failed: %v", svcManagerAddr, err)
+			time.Sleep(time.Second * 2)
+			continue
+		}
+
+		return client, nil
+	}
+
+	return nil, err
+}
+
+// Ping to ping server
+func (c *ServiceManageClient) Ping() error {
+	if c.etcd != nil {
+		return c.etcd.Ping()
+	}
+	if c.file != nil {
+		return c.file.Ping()
+	}
+	return c.zk.Ping()
+}
+
+// IsEtcd check whether the center is etcd
+func (c *ServiceManageClient) IsEtcd() bool {
+	return c.etcd != nil
+}
+
+// IsFile check whether the center is the static discovery file
+func (c *ServiceManageClient) IsFile() bool {
+	return c.file != nil
+}
+
+// ZkClient returns the zookeeper client, it's nil if the center is not zookeeper
+func (c *ServiceManageClient) ZkClient() *zk.ZkClient {
+	return c.zk
+}
+
+// EtcdClient returns the etcd client, it's nil if the center is not etcd
+func (c *ServiceManageClient) EtcdClient() *etcd.EtcdClient {
+	return c.etcd
+}
+
+// RegDiscover returns a new service register and discover
+func (c *ServiceManageClient) RegDiscover() *registerdiscover.RegDiscover {
+	if c.etcd != nil {
+		return registerdiscover.NewEtcdRegDiscover(c.etcd)
+	}
+	if c.file != nil {
+		return registerdiscover.NewFileRegDiscover(c.file)
+	}
+	return registerdiscover.NewRegDiscoverEx(c.zk)
+}
+
+// ConfRegDiscover returns a new config register and discover
+func (c *ServiceManageClient) ConfRegDiscover() crd.ConfRegDiscvIf {
+	if c.etcd != nil {
+		return crd.NewEtcdRegDiscover(c.etcd)
+	}
+	if c.file != nil {
+		return crd.NewFileRegDiscover(c.file)
+	}
+	return crd.NewZkRegDiscover(c.zk)
+}
+
+// FileClient returns the static discovery file client, it's nil if the center is not the static discovery file
+func (c *ServiceManageClient) FileClient() *file.FileClient {
+	return c.file
+}
@@ -0,0 +1,333 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/types"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SchemeFile the address scheme of the static discovery, e.g. file:///data/cmdb/discovery.yaml
+const SchemeFile = "file://"
+
+// defaultPorts the default ports of the services, they are used in the all in one mode
+var defaultPorts = map[string]uint{
+	types.CC_MODULE_MIGRATE:          60004,
+	types.CC_MODULE_APISERVER:        8080,
+	types.CC_MODULE_DATACOLLECTION:   60005,
+	types.CC_MODULE_EVENTSERVER:      60009,
+	types.CC_MODULE_HOST:             60001,
+	types.CC_MODULE_CORESERVICE:      50009,
+	types.CC_MODULE_PROC:             60003,
+	types.CC_MODULE_TXC:              60008,
+	types.CC_MODULE_TOPO:             60002,
+	types.CC_MODULE_WEBSERVER:        8083,
+	types.CC_MODULE_SYNCHRONZESERVER: 60010,
+	types.CC_MODULE_OPERATION:        60011,
+	types.CC_MODULE_TASK:             60012,
+}
+
+// watchInterval the interval to check whether the watched files are changed
+var watchInterval = time.Second
+
+// IsFileAddress check whether the register and discover address is a static discovery file
+func IsFileAddress(address string) bool {
+	return strings.HasPrefix(address, SchemeFile)
+}
+
+// Config the static discovery config file
+type Config struct {
+	// AllInOne all the services run on this host with only one instance, the services which are not
+	// configured are discovered with the default ports, and the master election always succeeds.
+	AllInOne bool `yaml:"allInOne"`
+	// ConfigDir the directory where the admin server writes the configs for the other services,
+	// default is the configcenter directory besides the discovery file.
+	ConfigDir string `yaml:"configDir"`
+	// Services the addresses of the services, the key is the name of the service like coreservice,
+	// the address is like 127.0.0.1:50009 or https://127.0.0.1:50009
+	Services map[string][]string `yaml:"services"`
+}
+
+// FileClient do service register and discover by a static config file, the file is reloaded when it's changed.
+// the services are not really registered, the registration only takes effect in the current process.
+type FileClient struct {
+	path    string
+	cancel  context.CancelFunc
+	rootCxt context.Context
+
+	sync.RWMutex
+	conf       Config
+	registered map[string][]byte
+	// changed is closed and replaced when the config is reloaded or a service is registered
+	changed chan struct{}
+}
+
+// NewFileClient create a object of FileClient, the address is like file:///data/cmdb/discovery.yaml
+func NewFileClient(address string) (*FileClient, error) {
+	if !IsFileAddress(address) {
+		return nil, fmt.Errorf("invalid discovery file address %s, should be started with %s", address, SchemeFile)
+	}
+	path := strings.TrimPrefix(address, SchemeFile)
+	if len(path) == 0 {
+		return nil, fmt.Errorf("invalid discovery file address %s, the file path is not set", address)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discovery file address %s, err: %v", address, err)
+	}
+
+	return &FileClient{
+		path:       path,
+		registered: make(map[string][]byte),
+		changed:    make(chan struct{}),
+	}, nil
+}
+
+// Ping to check the discovery file is available
+func (f *FileClient) Ping() error {
+	_, err := os.Stat(f.path)
+	return err
+}
+
+// Start load the discovery file and watch its changes
+func (f *FileClient) Start() error {
+	if err := f.load(); err != nil {
+		return err
+	}
+
+	// create root context
+	f.rootCxt, f.cancel = context.WithCancel(context.Background())
+	go f.watch(WatchFile(f.rootCxt, f.path))
+
+	return nil
+}
+
+func (f *FileClient) watch(changes <-chan struct{}) {
+	for range changes {
+		if err := f.load(); err != nil {
+			blog.Errorf("reload discovery file %s failed, the previous config is used, err: %v", f.path, err)
+			continue
+		}
+		blog.Infof("discovery file %s is changed, reload it success", f.path)
+	}
+}
+
+// WatchFile poll the state of the file, the returned channel receives a value when the file is modified,
+// created, removed or replaced by rename, the changes not received yet are merged into one.
+// the channel is closed when the ctx is done.
+func WatchFile(ctx context.Context, path string) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	previous, _ := os.Stat(path)
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current, _ := os.Stat(path)
+			if !fileChanged(previous, current) {
+				continue
+			}
+			previous = current
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return changes
+}
+
+// fileChanged check whether the file is changed by its modification time, size and identity,
+// the info is nil if the file does not exist.
+func fileChanged(previous, current os.FileInfo) bool {
+	if previous == nil || current == nil {
+		return previous != current
+	}
+	return !os.SameFile(previous, current) || !previous.ModTime().Equal(current.ModTime()) ||
+		previous.Size() != current.Size()
+}
+
+func (f *FileClient) load() error {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("read discovery file %s failed, err: %v", f.path, err)
+	}
+	conf := Config{}
+	if err := yaml.Unmarshal(data, &conf); err != nil {
+		return fmt.Errorf("parse discovery file %s failed, err: %v", f.path, err)
+	}
+	for name, addrs := range conf.Services {
+		for _, addr := range addrs {
+			if _, err := parseAddress(addr); err != nil {
+				return fmt.Errorf("invalid address %s of %s, err: %v", addr, name, err)
+			}
+		}
+	}
+	if len(conf.ConfigDir) == 0 {
+		conf.ConfigDir = filepath.Join(filepath.Dir(f.path), "configcenter")
+	} else if !filepath.IsAbs(conf.ConfigDir) {
+		conf.ConfigDir = filepath.Join(filepath.Dir(f.path), conf.ConfigDir)
+	}
+
+	f.Lock()
+	f.conf = conf
+	f.notifyLocked()
+	f.Unlock()
+	return nil
+}
+
+func (f *FileClient) notifyLocked() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// Stop used to stop watching the discovery file
+func (f *FileClient) Stop() error {
+	if f.cancel != nil {
+		f.cancel()
+	}
+	return nil
+}
+
+// WithCancel context with cancel
+func (f *FileClient) WithCancel() (context.Context, context.CancelFunc) {
+	return context.WithCancel(f.rootCxt)
+}
+
+// ConfigDir returns the directory of the config center
+func (f *FileClient) ConfigDir() string {
+	f.RLock()
+	defer f.RUnlock()
+	return f.conf.ConfigDir
+}
+
+// Changed returns a channel which is closed when the services are changed
+func (f *FileClient) Changed() <-chan struct{} {
+	f.RLock()
+	defer f.RUnlock()
+	return f.changed
+}
+
+// Register register the service in the current process
+func (f *FileClient) Register(path string, data []byte) {
+	f.Lock()
+	defer f.Unlock()
+	f.registered[path] = data
+	f.notifyLocked()
+}
+
+// Unregister remove the service registered in the current process
+func (f *FileClient) Unregister(path string) {
+	f.Lock()
+	defer f.Unlock()
+	if _, exist := f.registered[path]; !exist {
+		return
+	}
+	delete(f.registered, path)
+	f.notifyLocked()
+}
+
+// Servers returns the server infos of the service registered under the path, the first one is the master.
+// the servers registered in the current process are returned first in the all in one mode, so that the
+// master election always succeeds.
+func (f *FileClient) Servers(path string) ([]string, error) {
+	name := filepath.Base(path)
+
+	f.RLock()
+	defer f.RUnlock()
+
+	servers := make([]string, 0)
+	exists := make(map[string]bool)
+	if f.conf.AllInOne {
+		for registerPath, data := range f.registered {
+			if filepath.Dir(registerPath) != path {
+				continue
+			}
+			info := types.ServerInfo{}
+			if err := json.Unmarshal(data, &info); err != nil {
+				return nil, fmt.Errorf("invalid registered server info %s, err: %v", data, err)
+			}
+			servers = append(servers, string(data))
+			exists[info.Address()] = true
+		}
+	}
+
+	addrs, configured := f.conf.Services[name]
+	if !configured && f.conf.AllInOne && len(servers) == 0 {
+		if port, ok := defaultPorts[name]; ok {
+			addrs = []string{net.JoinHostPort("127.0.0.1", strconv.FormatUint(uint64(port), 10))}
+		}
+	}
+	for _, addr := range addrs {
+		info, err := parseAddress(addr)
+		if err != nil {
+			return nil, err
+		}
+		if exists[info.Address()] {
+			continue
+		}
+		data, err := json.Marshal(info)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, string(data))
+	}
+	return servers, nil
+}
+
+// parseAddress parse the address like 127.0.0.1:50009 or https://127.0.0.1:50009
+func parseAddress(addr string) (*types.ServerInfo, error) {
+	info := &types.ServerInfo{Scheme: "http"}
+	if index := strings.Index(addr, "://"); index >= 0 {
+		info.Scheme = addr[:index]
+		addr = addr[index+3:]
+	}
+	if info.Scheme != "http" && info.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %s", info.Scheme)
+	}
+
+	host, port, err := net.SplitHostPort(strings.TrimRight(addr, "/"))
+	if err != nil {
+		return nil, err
+	}
+	portValue, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || portValue == 0 {
+		return nil, fmt.Errorf("invalid port %s", port)
+	}
+	if len(host) == 0 {
+		return nil, fmt.Errorf("the ip is not set")
+	}
+	info.IP = host
+	info.Port = uint(portValue)
+	return info, nil
+}
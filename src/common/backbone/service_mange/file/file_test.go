@@ -0,0 +1,196 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"configcenter/src/common/types"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write file failed, err: %v", err)
+	}
+}
+
+func newTestClient(t *testing.T, content string) (*FileClient, string) {
+	dir, err := ioutil.TempDir("", "discovery")
+	if err != nil {
+		t.Fatalf("create temp dir failed, err: %v", err)
+	}
+	path := filepath.Join(dir, "discovery.yaml")
+	writeFile(t, path, content)
+
+	client, err := NewFileClient(SchemeFile + path)
+	if err != nil {
+		t.Fatalf("new file client failed, err: %v", err)
+	}
+	if err := client.Start(); err != nil {
+		t.Fatalf("start file client failed, err: %v", err)
+	}
+	return client, dir
+}
+
+func addresses(t *testing.T, servers []string) []string {
+	addrs := make([]string, 0)
+	for _, server := range servers {
+		info := types.ServerInfo{}
+		if err := json.Unmarshal([]byte(server), &info); err != nil {
+			t.Fatalf("invalid server %s, err: %v", server, err)
+		}
+		addrs = append(addrs, info.Address())
+	}
+	return addrs
+}
+
+func TestNewFileClient(t *testing.T) {
+	if _, err := NewFileClient("127.0.0.1:2181"); err == nil {
+		t.Errorf("the address without file scheme should be invalid")
+	}
+	if _, err := NewFileClient(SchemeFile); err == nil {
+		t.Errorf("the address without path should be invalid")
+	}
+	if !IsFileAddress("file:///data/cmdb/discovery.yaml") || IsFileAddress("etcd://127.0.0.1:2379") {
+		t.Errorf("check file address failed")
+	}
+}
+
+func TestServers(t *testing.T) {
+	client, dir := newTestClient(t, `
+services:
+  coreservice:
+    - 127.0.0.1:50009
+    - https://127.0.0.2:50009
+`)
+	defer os.RemoveAll(dir)
+	defer client.Stop()
+
+	if client.ConfigDir() != filepath.Join(dir, "configcenter") {
+		t.Errorf("unexpected default config dir %s", client.ConfigDir())
+	}
+
+	servers, err := client.Servers(types.CC_SERV_BASEPATH + "/" + types.CC_MODULE_CORESERVICE)
+	if err != nil {
+		t.Fatalf("get servers failed, err: %v", err)
+	}
+	addrs := addresses(t, servers)
+	if len(addrs) != 2 || addrs[0] != "http://127.0.0.1:50009" || addrs[1] != "https://127.0.0.2:50009" {
+		t.Errorf("unexpected servers %v", addrs)
+	}
+
+	// the service not configured has no server unless in the all in one mode
+	servers, err = client.Servers(types.CC_SERV_BASEPATH + "/" + types.CC_MODULE_TOPO)
+	if err != nil || len(servers) != 0 {
+		t.Errorf("unexpected servers %v, err: %v", servers, err)
+	}
+}
+
+func TestAllInOne(t *testing.T) {
+	client, dir := newTestClient(t, `
+allInOne: true
+configDir: conf
+services:
+  coreservice:
+    - 127.0.0.1:50009
+`)
+	defer os.RemoveAll(dir)
+	defer client.Stop()
+
+	if client.ConfigDir() != filepath.Join(dir, "conf") {
+		t.Errorf("unexpected config dir %s", client.ConfigDir())
+	}
+
+	path := types.CC_SERV_BASEPATH + "/" + types.CC_MODULE_TOPO
+	servers, err := client.Servers(path)
+	if err != nil {
+		t.Fatalf("get servers failed, err: %v", err)
+	}
+	if addrs := addresses(t, servers); len(addrs) != 1 || addrs[0] != "http://127.0.0.1:60002" {
+		t.Errorf("unexpected default servers %v", addrs)
+	}
+
+	// the registered server comes first, so that it's always the master
+	path = types.CC_SERV_BASEPATH + "/" + types.CC_MODULE_CORESERVICE
+	changed := client.Changed()
+	info, _ := json.Marshal(types.ServerInfo{IP: "127.0.0.3", Port: 50009, Scheme: "http"})
+	client.Register(path+"/127.0.0.3", info)
+	select {
+	case <-changed:
+	default:
+		t.Errorf("the registration is not notified")
+	}
+	servers, err = client.Servers(path)
+	if err != nil {
+		t.Fatalf("get servers failed, err: %v", err)
+	}
+	addrs := addresses(t, servers)
+	if len(addrs) != 2 || addrs[0] != "http://127.0.0.3:50009" || addrs[1] != "http://127.0.0.1:50009" {
+		t.Errorf("unexpected servers %v", addrs)
+	}
+
+	client.Unregister(path + "/127.0.0.3")
+	servers, _ = client.Servers(path)
+	if len(servers) != 1 {
+		t.Errorf("unexpected servers %v after unregister", servers)
+	}
+}
+
+func TestReload(t *testing.T) {
+	client, dir := newTestClient(t, `
+services:
+  coreservice:
+    - 127.0.0.1:50009
+`)
+	defer os.RemoveAll(dir)
+	defer client.Stop()
+
+	path := types.CC_SERV_BASEPATH + "/" + types.CC_MODULE_CORESERVICE
+	changed := client.Changed()
+	writeFile(t, filepath.Join(dir, "discovery.yaml"), `
+services:
+  coreservice:
+    - 127.0.0.2:50009
+`)
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the change of the discovery file is not notified")
+	}
+	servers, _ := client.Servers(path)
+	if addrs := addresses(t, servers); len(addrs) != 1 || addrs[0] != "http://127.0.0.2:50009" {
+		t.Errorf("unexpected servers %v after reload", addrs)
+	}
+
+	// the invalid file is ignored, the previous config is used
+	changed = client.Changed()
+	writeFile(t, filepath.Join(dir, "discovery.yaml"), `
+services:
+  coreservice:
+    - 127.0.0.2:0
+`)
+	select {
+	case <-changed:
+		t.Errorf("the invalid discovery file should not be loaded")
+	case <-time.After(500 * time.Millisecond):
+	}
+	servers, _ = client.Servers(path)
+	if len(servers) != 1 {
+		t.Errorf("unexpected servers %v", servers)
+	}
+}
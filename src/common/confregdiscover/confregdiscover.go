@@ -13,6 +13,8 @@
 package confregdiscover
 
 import (
+	"configcenter/src/common/backbone/service_mange/etcd"
+	"configcenter/src/common/backbone/service_mange/file"
 	"configcenter/src/common/backbone/service_mange/zk"
 )
 
@@ -50,6 +52,20 @@ func NewConfRegDiscoverWithTimeOut(client *zk.ZkClient) *ConfRegDiscover {
 	return confRD
 }
 
+// NewEtcdConfRegDiscover used to create a object of ConfRegDiscover which is based on etcd
+func NewEtcdConfRegDiscover(client *etcd.EtcdClient) *ConfRegDiscover {
+	return &ConfRegDiscover{
+		confRD: ConfRegDiscvIf(NewEtcdRegDiscover(client)),
+	}
+}
+
+// NewFileConfRegDiscover used to create a object of ConfRegDiscover which is based on the static discovery file
+func NewFileConfRegDiscover(client *file.FileClient) *ConfRegDiscover {
+	return &ConfRegDiscover{
+		confRD: ConfRegDiscvIf(NewFileRegDiscover(client)),
+	}
+}
+
 // Ping to ping server
 func (crd *ConfRegDiscover) Ping() error {
 	return crd.confRD.Ping()
@@ -0,0 +1,118 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package confregdiscover
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common/backbone/service_mange/etcd"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/etcdclient"
+)
+
+// EtcdRegDiscover config register and discover by etcd
+type EtcdRegDiscover struct {
+	etcdcli *etcdclient.EtcdClient
+	cancel  context.CancelFunc
+	rootCtx context.Context
+}
+
+// NewEtcdRegDiscover create a object of EtcdRegDiscover
+func NewEtcdRegDiscover(client *etcd.EtcdClient) *EtcdRegDiscover {
+	ctx, ctxCancel := client.WithCancel()
+	return &EtcdRegDiscover{
+		etcdcli: client.Client(),
+		rootCtx: ctx,
+		cancel:  ctxCancel,
+	}
+}
+
+// Ping to ping server
+func (etcdRD *EtcdRegDiscover) Ping() error {
+	return etcdRD.etcdcli.Ping()
+}
+
+// Write to save config data into etcd
+func (etcdRD *EtcdRegDiscover) Write(key string, data []byte) error {
+	return etcdRD.etcdcli.Put(key, data, 0)
+}
+
+func (etcdRD *EtcdRegDiscover) Read(key string) (string, error) {
+	kv, err := etcdRD.etcdcli.Get(key)
+	if err != nil {
+		return "", err
+	}
+	return string(kv.Value), nil
+}
+
+func (etcdRD *EtcdRegDiscover) Discover(key string) (<-chan *DiscoverEvent, error) {
+
+	env := make(chan *DiscoverEvent, 1)
+
+	go etcdRD.loopDiscover(etcdRD.rootCtx, key, env)
+
+	return env, nil
+}
+
+func (etcdRD *EtcdRegDiscover) loopDiscover(discvCtx context.Context, key string, env chan *DiscoverEvent) {
+	for {
+		discvEnv := &DiscoverEvent{
+			Err: nil,
+			Key: key,
+		}
+
+		kv, err := etcdRD.etcdcli.Get(key)
+		if err != nil {
+			blog.Errorf("fail to get content of key(%s), err: %v", key, err)
+			if err != etcdclient.ErrKeyNotFound {
+				discvEnv.Err = err
+				env <- discvEnv
+			}
+			select {
+			case <-discvCtx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		discvEnv.Data = kv.Value
+
+		// write into discoverEvent channel
+		env <- discvEnv
+
+		watchCtx, cancel := context.WithCancel(discvCtx)
+		watchCh, err := etcdRD.etcdcli.Watch(watchCtx, key, false, kv.ModRevision+1)
+		if err != nil {
+			cancel()
+			blog.Errorf("fail to watch content of key(%s), will watch after 5s, err: %v", key, err)
+			select {
+			case <-discvCtx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		select {
+		case <-discvCtx.Done():
+			cancel()
+			blog.Infof("discover key(%s) done", key)
+			return
+		case <-watchCh:
+			blog.V(4).Infof("watch found the content of key(%s) changed", key)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package confregdiscover
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"configcenter/src/common"
+	"configcenter/src/common/backbone/service_mange/file"
+	"configcenter/src/common/blog"
+)
+
+// FileRegDiscover config register and discover by the files in the config directory of the static discovery,
+// the config of the key is saved in the file with the key as the relative path.
+type FileRegDiscover struct {
+	client  *file.FileClient
+	cancel  context.CancelFunc
+	rootCtx context.Context
+}
+
+// NewFileRegDiscover create a object of FileRegDiscover
+func NewFileRegDiscover(client *file.FileClient) *FileRegDiscover {
+	ctx, ctxCancel := client.WithCancel()
+	return &FileRegDiscover{
+		client:  client,
+		rootCtx: ctx,
+		cancel:  ctxCancel,
+	}
+}
+
+// Ping to ping server
+func (fileRD *FileRegDiscover) Ping() error {
+	return fileRD.client.Ping()
+}
+
+func (fileRD *FileRegDiscover) keyPath(key string) string {
+	return filepath.Join(fileRD.client.ConfigDir(), filepath.Clean("/"+key))
+}
+
+// Write to save config data into the file of the key, the file is replaced atomically
+func (fileRD *FileRegDiscover) Write(key string, data []byte) error {
+	path := fileRD.keyPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := common.AtomicFileNew(path, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Close()
+}
+
+func (fileRD *FileRegDiscover) Read(key string) (string, error) {
+	data, err := ioutil.ReadFile(fileRD.keyPath(key))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (fileRD *FileRegDiscover) Discover(key string) (<-chan *DiscoverEvent, error) {
+	path := fileRD.keyPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	changes := file.WatchFile(fileRD.rootCtx, path)
+
+	env := make(chan *DiscoverEvent, 1)
+
+	go fileRD.loopDiscover(key, path, changes, env)
+
+	return env, nil
+}
+
+func (fileRD *FileRegDiscover) loopDiscover(key, path string, changes <-chan struct{}, env chan *DiscoverEvent) {
+	var previous []byte
+	for {
+		data, err := ioutil.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+			blog.V(4).Infof("config file of key(%s) is not exist, wait for it", key)
+		case err != nil:
+			blog.Errorf("fail to read config file of key(%s), err: %v", key, err)
+			env <- &DiscoverEvent{Err: err, Key: key}
+		case previous == nil || !bytes.Equal(previous, data):
+			// the file may be touched without changing the data
+			previous = data
+			env <- &DiscoverEvent{Key: key, Data: data}
+		}
+
+		// the changes is closed when the context is done
+		if _, ok := <-changes; !ok {
+			blog.Infof("discover key(%s) done", key)
+			return
+		}
+	}
+}
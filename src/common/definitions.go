@@ -466,6 +466,7 @@ const (
 	BKServiceTemplateIDField = "service_template_id"
 	BKProcessTemplateIDField = "process_template_id"
 	BKServiceCategoryIDField = "service_category_id"
+	BKUpstreamTemplateIDField = "upstream_template_id"
 
 	BKSetTemplateIDField = "set_template_id"
 
@@ -486,6 +487,9 @@ const (
 	// BKBindIP the bind ip
 	BKBindIP = "bind_ip"
 
+	// BKProcBindInfo the bind info of a process, a process may listen on several ip, port and protocol
+	BKProcBindInfo = "bind_info"
+
 	// BKWorkPath the work path
 	BKWorkPath = "work_path"
 
@@ -725,6 +729,25 @@ const (
 	// FieldTypeList the lis type
 	FieldTypeList string = "list"
 
+	// FieldTypeIP the ipv4 or ipv6 address field type
+	FieldTypeIP string = "ip"
+
+	// FieldTypeCIDR the network in cidr notation field type, like 10.0.0.0/8
+	FieldTypeCIDR string = "cidr"
+
+	// FieldTypeEnumMulti the enum field type which can select multiple options, the value is an array of option ids
+	FieldTypeEnumMulti string = "enum_multi"
+
+	// FieldTypeReference the field type which refers to an instance of another object, the value is the instance id
+	FieldTypeReference string = "reference"
+
+	// FieldTypeComputed the field type whose value is evaluated from an expression over the other fields of the instance
+	FieldTypeComputed string = "computed"
+
+	// FieldTypeTable the field type whose value is an array of objects, like the bind info of a process,
+	// it can only be used by the built-in attributes, the value is validated by the owner of the attribute
+	FieldTypeTable string = "table"
+
 	// FieldTypeSingleLenChar the single char length limit
 	FieldTypeSingleLenChar int = 256
 
@@ -779,6 +802,9 @@ const (
 
 	// ExcelAsstPrimaryKeySplitChar split char
 	ExcelAsstPrimaryKeySplitChar = ","
+
+	// ExcelEnumMultiSplitChar split char of the option names of the multi-select enum field
+	ExcelEnumMultiSplitChar = ","
 	// ExcelAsstPrimaryKeyJoinChar split char
 	ExcelAsstPrimaryKeyJoinChar = "="
 	// ExcelAsstPrimaryKeyRowChar split char
@@ -984,6 +1010,9 @@ const (
 const TemplateStatusField = "status"
 const BKStatusField = "status"
 
+// BKVersionField the version field of service template version
+const BKVersionField = "version"
+
 const (
 	TemplateStatusDraft   = "draft"
 	TemplateStatusOnline  = "online"
@@ -1013,6 +1042,8 @@ const (
 	RedisCloudSyncInstancePendingStart        = BKCacheKeyV3Prefix + "cloudsyncinstancependingstart:list"
 	RedisCloudSyncInstanceStarted             = BKCacheKeyV3Prefix + "cloudsyncinstancestarted:list"
 	RedisCloudSyncInstancePendingStop         = BKCacheKeyV3Prefix + "cloudsyncinstancependingstop:list"
+	RedisWebExportTaskProgressPrefix          = BKCacheKeyV3Prefix + "webserver:export_task:"
+	RedisWebExportTaskNodePrefix              = BKCacheKeyV3Prefix + "webserver:export_task_node:"
 )
 
 // association fields
@@ -1090,6 +1121,9 @@ const (
 	ServiceTemplateIDNotSet = 0
 	SetTemplateIDNotSet     = 0
 
+	// 全局(开发商级别)的服务分类、服务模板的业务ID，它们可以被所有业务引用
+	BKGlobalBizID = 0
+
 	MetadataLabelBiz = "metadata.label.bk_biz_id"
 
 	DefaultServiceCategoryName = "Default"
@@ -1120,6 +1154,10 @@ const (
 	OptionOther          = "其他"
 	TimerPattern         = "^[\\d]+\\:[\\d]+$"
 	SyncSetTaskName      = "sync-settemplate2set"
+	ExportExcelTaskName  = "export-excel"
+
+	SyncServiceTemplateRolloutTaskName = "sync-servicetemplate-rollout"
+	RefreshComputedValuesTaskName      = "refresh-computed-values"
 
 	BKHostState = "bk_state"
 )
@@ -216,6 +216,9 @@ const (
     CCErrCommModifyFieldForbidden                            = 1199083
     CCErrCommForbiddenOperateInnerModelInstanceWithCommonAPI = 1199084
 
+	// CCErrCommTransactionNotFound transaction [%s] is not in progress
+	CCErrCommTransactionNotFound = 1199085
+
 	// unknown or unrecognized error
 	CCErrorUnknownOrUnrecognizedError = 1199998
 
@@ -448,6 +451,7 @@ const (
 	CCErrorTopoForbiddenOperateModuleOnSetInitializedByTemplate = 1101097
 	CCErrorTopoForbiddenDeleteBuiltInSetModule                  = 1101098
 	CCErrorTopoModuleNameDuplicated                             = 1101099
+	CCErrorTopoUpdateTemplateLockedAttributeForbidden           = 1101100
 
 	// object controller 1102XXX
 
@@ -586,6 +590,21 @@ const (
 
 	CCErrHostTransferFinalModuleConflict = 1108044
 
+	// CCErrProcServiceTemplateVersionNotFound service template version [%d] not found
+	CCErrProcServiceTemplateVersionNotFound = 1108045
+	// CCErrProcServiceTemplateRolloutRunning service template has a unfinished rollout [%d]
+	CCErrProcServiceTemplateRolloutRunning = 1108046
+	// CCErrProcServiceTemplateRolloutStatusInvalid rollout status is [%s], operation not allowed
+	CCErrProcServiceTemplateRolloutStatusInvalid = 1108047
+	// CCErrProcServiceTemplateRolloutNoModule no module matched to rollout
+	CCErrProcServiceTemplateRolloutNoModule = 1108048
+	// CCErrProcServiceTemplateNoUpstream service template [%d] is not created from a global service template
+	CCErrProcServiceTemplateNoUpstream = 1108049
+	// CCErrProcServiceTemplateReferenceReadonly service template [%s] references a global service template, it can only be changed by accepting upstream changes
+	CCErrProcServiceTemplateReferenceReadonly = 1108050
+	// CCErrProcBindPortConflict process binds [%s %s:%s] which conflicts with process [%s] on the same host
+	CCErrProcBindPortConflict = 1108051
+
 	// audit log 1109XXX
 	CCErrAuditSaveLogFailed      = 1109001
 	CCErrAuditTakeSnapshotFailed = 1109001
@@ -677,6 +696,10 @@ const (
 	CCErrWebGetAddNetPropertyResultFail = 1111010
 	CCErrWebGetNetDeviceFail            = 1111011
 	CCErrWebGetNetPropertyFail          = 1111012
+	CCErrWebExportTaskNotFound          = 1111013
+	CCErrWebExportTaskNotFinished       = 1111014
+	CCErrWebImportHasInvalidRow         = 1111015
+	CCErrWebImportTxnNotAvailable       = 1111016
 
 	// datacollection 1112xxx
 	CCErrCollectNetDeviceCreateFail            = 1112000
@@ -698,6 +721,14 @@ const (
 	CCErrCollectNetHistorySearchFail           = 1112016
 	CCErrCollectNetDeviceUpdateFail            = 1112017
 	CCErrCollectNetPropertyUpdateFail          = 1112018
+	CCErrCollectHostSnapMappingCreateFail      = 1112019
+	CCErrCollectHostSnapMappingUpdateFail      = 1112020
+	CCErrCollectHostSnapMappingDeleteFail      = 1112021
+	CCErrCollectHostSnapMappingSearchFail      = 1112022
+	CCErrCollectHostSnapMappingNotExist        = 1112023
+	CCErrCollectPushTokenInvalid               = 1112024
+	CCErrCollectPushQueueFull                  = 1112025
+	CCErrCollectPushPorterNotFound             = 1112026
 
 	// coreservice 1113xxx
 	// CCErrorModelAttributeGroupHasSomeAttributes the group has some attributes
@@ -755,6 +786,16 @@ const (
 	// CCErrCoreServiceModelHasAssociationErr 模型与其他模型有关联关系
 	CCErrCoreServiceModelHasAssociationErr           = 1113031
 	CCErrCoreServiceOnlyNodeServiceCategoryAvailable = 1113032
+	// CCErrCoreServiceComputedAttrUnknownField the expression of computed attribute refers to a field [%s] which does not exist
+	CCErrCoreServiceComputedAttrUnknownField = 1113033
+	// CCErrCoreServiceComputedAttrCycle the computed attributes refer to each other in a cycle [%s]
+	CCErrCoreServiceComputedAttrCycle = 1113034
+	// CCErrCoreServiceHostLocked hosts [%s] are locked
+	CCErrCoreServiceHostLocked = 1113035
+	// CCErrCoreServiceHostLockNotOwner hosts [%s] are locked by other users, only the lock owner or administrator can unlock them
+	CCErrCoreServiceHostLockNotOwner = 1113036
+	// CCErrCoreServiceRolloutUpdateConflict the rollout has been updated by others
+	CCErrCoreServiceRolloutUpdateConflict = 1113037
 
 	// synchronize data core service  11139xx
 	CCErrCoreServiceSyncError = 1113900
@@ -774,6 +815,10 @@ const (
 	CCErrOperationUpdateChartFail         = 1116006
 	CCErrOperationGetChartDataFail        = 1116007
 	CCErrOperationUpdateChartPositionFail = 1116008
+	// CCErrOperationCustomChartNotFound custom chart not found
+	CCErrOperationCustomChartNotFound = 1116009
+	// CCErrOperationCustomChartComputeFail compute custom chart failed
+	CCErrOperationCustomChartComputeFail = 1116010
 	CCErrCloudSyncDeleteSyncTaskFail      = 1116011
 	CCErrCloudSyncUpdateSyncTaskFail      = 1116012
 
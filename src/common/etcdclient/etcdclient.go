@@ -0,0 +1,431 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package etcdclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrKeyNotFound the key is not exist
+	ErrKeyNotFound = errors.New("etcd key not found")
+	// ErrLeaseNotFound the lease is expired or revoked
+	ErrLeaseNotFound = errors.New("etcd lease not found")
+	// ErrWatchCanceled the watch is canceled by the server, e.g. the revision is compacted
+	ErrWatchCanceled = errors.New("etcd watch canceled")
+)
+
+// KeyValue the key value stored in etcd
+type KeyValue struct {
+	Key            string
+	Value          []byte
+	CreateRevision int64
+	ModRevision    int64
+	Lease          int64
+}
+
+// EventType the type of the watch event
+type EventType string
+
+const (
+	EventPut    EventType = "PUT"
+	EventDelete EventType = "DELETE"
+)
+
+// WatchEvent the change of a key
+type WatchEvent struct {
+	Type EventType
+	KV   KeyValue
+}
+
+// WatchResponse the events received from the watch stream, the stream is ended when the Err is not nil
+type WatchResponse struct {
+	Events []WatchEvent
+	Err    error
+}
+
+// EtcdClient a client of the etcd v3 api through the grpc gateway, the endpoints are tried in turn
+// when the current one is unavailable.
+type EtcdClient struct {
+	endpoints []string
+	username  string
+	password  string
+	client    *http.Client
+	// watchClient is used by the long lived watch stream, it has no timeout.
+	watchClient *http.Client
+
+	sync.RWMutex
+	index int
+	token string
+}
+
+// NewEtcdClient create a client with the endpoints like http://127.0.0.1:2379, the username and
+// password are needed when the etcd auth is enabled.
+func NewEtcdClient(endpoints []string, username, password string) *EtcdClient {
+	eps := make([]string, 0, len(endpoints))
+	for _, ep := range endpoints {
+		ep = strings.TrimRight(strings.TrimSpace(ep), "/")
+		if len(ep) == 0 {
+			continue
+		}
+		if !strings.Contains(ep, "://") {
+			ep = "http://" + ep
+		}
+		eps = append(eps, ep)
+	}
+	return &EtcdClient{
+		endpoints:   eps,
+		username:    username,
+		password:    password,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		watchClient: &http.Client{},
+	}
+}
+
+// Connect authenticate the user if needed and check the etcd is available
+func (c *EtcdClient) Connect(timeout time.Duration) error {
+	if len(c.endpoints) == 0 {
+		return errors.New("no etcd endpoint is configured")
+	}
+	if timeout > 0 {
+		c.client.Timeout = timeout
+	}
+	if len(c.username) != 0 {
+		if err := c.authenticate(); err != nil {
+			return err
+		}
+	}
+	return c.Ping()
+}
+
+// Ping check the etcd is available
+func (c *EtcdClient) Ping() error {
+	return c.call(context.Background(), "/v3/maintenance/status", struct{}{}, nil)
+}
+
+// Get returns the key value of the key, ErrKeyNotFound is returned if it's not exist
+func (c *EtcdClient) Get(key string) (*KeyValue, error) {
+	kvs, _, err := c.rangeKeys(key, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(kvs) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	return &kvs[0], nil
+}
+
+// GetPrefix returns all the key values with the prefix in the order of the creation,
+// and the revision of the etcd when they are read which can be used to watch the later changes.
+func (c *EtcdClient) GetPrefix(prefix string) ([]KeyValue, int64, error) {
+	return c.rangeKeys(prefix, prefixEnd(prefix))
+}
+
+// Put set the value of the key, the key is attached to the lease if lease is not zero
+func (c *EtcdClient) Put(key string, value []byte, lease int64) error {
+	req := putRequest{Key: []byte(key), Value: value, Lease: lease}
+	return c.call(context.Background(), "/v3/kv/put", req, nil)
+}
+
+// PutIfAbsent set the value of the key only if the key is not exist, returns whether the value is set
+func (c *EtcdClient) PutIfAbsent(key string, value []byte, lease int64) (bool, error) {
+	req := txnRequest{
+		Compare: []compare{{
+			Key:            []byte(key),
+			Target:         "CREATE",
+			Result:         "EQUAL",
+			CreateRevision: 0,
+		}},
+		Success: []requestOp{{RequestPut: &putRequest{Key: []byte(key), Value: value, Lease: lease}}},
+	}
+	resp := txnResponse{}
+	if err := c.call(context.Background(), "/v3/kv/txn", req, &resp); err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// Delete delete the key
+func (c *EtcdClient) Delete(key string) error {
+	req := rangeRequest{Key: []byte(key)}
+	return c.call(context.Background(), "/v3/kv/deleterange", req, nil)
+}
+
+// DeletePrefix delete all the keys with the prefix
+func (c *EtcdClient) DeletePrefix(prefix string) error {
+	req := rangeRequest{Key: []byte(prefix), RangeEnd: []byte(prefixEnd(prefix))}
+	return c.call(context.Background(), "/v3/kv/deleterange", req, nil)
+}
+
+// Grant create a lease with the ttl seconds, returns the lease id
+func (c *EtcdClient) Grant(ttl int64) (int64, error) {
+	resp := leaseResponse{}
+	if err := c.call(context.Background(), "/v3/lease/grant", leaseRequest{TTL: ttl}, &resp); err != nil {
+		return 0, err
+	}
+	if len(resp.Error) != 0 {
+		return 0, errors.New(resp.Error)
+	}
+	return resp.ID, nil
+}
+
+// KeepAliveOnce renew the lease, returns the remaining ttl seconds. ErrLeaseNotFound is returned if the lease
+// is expired, the keys attached to the lease are deleted at that time.
+func (c *EtcdClient) KeepAliveOnce(lease int64) (int64, error) {
+	resp := struct {
+		Result leaseResponse `json:"result"`
+	}{}
+	if err := c.call(context.Background(), "/v3/lease/keepalive", leaseRequest{ID: lease}, &resp); err != nil {
+		return 0, err
+	}
+	if resp.Result.TTL <= 0 {
+		return 0, ErrLeaseNotFound
+	}
+	return resp.Result.TTL, nil
+}
+
+// Revoke revoke the lease and delete the keys attached to it
+func (c *EtcdClient) Revoke(lease int64) error {
+	err := c.call(context.Background(), "/v3/lease/revoke", leaseRequest{ID: lease}, nil)
+	if err != nil && strings.Contains(err.Error(), "requested lease not found") {
+		return ErrLeaseNotFound
+	}
+	return err
+}
+
+// Watch watch the changes of the key, or all the keys with the key as prefix if prefix is true, from the
+// revision. the returned channel is closed when the context is done or the stream is ended with an error,
+// then the caller should read the latest data and watch again.
+func (c *EtcdClient) Watch(ctx context.Context, key string, prefix bool, revision int64) (<-chan WatchResponse, error) {
+	create := watchCreateRequest{Key: []byte(key), StartRevision: revision}
+	if prefix {
+		create.RangeEnd = []byte(prefixEnd(key))
+	}
+	body, err := json.Marshal(struct {
+		CreateRequest watchCreateRequest `json:"create_request"`
+	}{CreateRequest: create})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, c.watchClient, "/v3/watch", body)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan WatchResponse, 1)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			msg := struct {
+				Result *watchResponse `json:"result"`
+				Error  *gatewayError  `json:"error"`
+			}{}
+			if err := decoder.Decode(&msg); err != nil {
+				if ctx.Err() == nil {
+					send(ctx, ch, WatchResponse{Err: fmt.Errorf("watch %s stream ended, err: %v", key, err)})
+				}
+				return
+			}
+			if msg.Error != nil {
+				send(ctx, ch, WatchResponse{Err: msg.Error})
+				return
+			}
+			if msg.Result == nil {
+				continue
+			}
+			if msg.Result.Canceled {
+				send(ctx, ch, WatchResponse{Err: ErrWatchCanceled})
+				return
+			}
+			if len(msg.Result.Events) == 0 {
+				continue
+			}
+			events := make([]WatchEvent, 0, len(msg.Result.Events))
+			for _, e := range msg.Result.Events {
+				event := WatchEvent{Type: EventPut, KV: e.KV.toKeyValue()}
+				if e.Type == string(EventDelete) {
+					event.Type = EventDelete
+				}
+				events = append(events, event)
+			}
+			if !send(ctx, ch, WatchResponse{Events: events}) {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func send(ctx context.Context, ch chan<- WatchResponse, resp WatchResponse) bool {
+	select {
+	case ch <- resp:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (c *EtcdClient) rangeKeys(key, rangeEnd string) ([]KeyValue, int64, error) {
+	req := rangeRequest{Key: []byte(key), SortOrder: "ASCEND", SortTarget: "CREATE"}
+	if len(rangeEnd) != 0 {
+		req.RangeEnd = []byte(rangeEnd)
+	}
+	resp := rangeResponse{}
+	if err := c.call(context.Background(), "/v3/kv/range", req, &resp); err != nil {
+		return nil, 0, err
+	}
+	kvs := make([]KeyValue, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		kvs = append(kvs, kv.toKeyValue())
+	}
+	return kvs, resp.Header.Revision, nil
+}
+
+func (c *EtcdClient) authenticate() error {
+	req := struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}{Name: c.username, Password: c.password}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(context.Background(), c.client, "/v3/auth/authenticate", body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	result := struct {
+		Token string `json:"token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode authenticate response failed, err: %v", err)
+	}
+
+	c.Lock()
+	c.token = result.Token
+	c.Unlock()
+	return nil
+}
+
+// call do the unary request, the token is refreshed once if it's expired
+func (c *EtcdClient) call(ctx context.Context, path string, req, result interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, c.client, path, body)
+	if err != nil && isInvalidToken(err) && len(c.username) != 0 {
+		if authErr := c.authenticate(); authErr != nil {
+			return authErr
+		}
+		resp, err = c.do(ctx, c.client, path, body)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if result == nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode %s response failed, err: %v", path, err)
+	}
+	return nil
+}
+
+// do send the request to the endpoints in turn until one of them responds
+func (c *EtcdClient) do(ctx context.Context, client *http.Client, path string, body []byte) (*http.Response, error) {
+	c.RLock()
+	start, token := c.index, c.token
+	c.RUnlock()
+
+	var lastErr error
+	for i := 0; i < len(c.endpoints); i++ {
+		index := (start + i) % len(c.endpoints)
+		req, err := http.NewRequest(http.MethodPost, c.endpoints[index]+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		if len(token) != 0 {
+			req.Header.Set("Authorization", token)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		if index != start {
+			c.Lock()
+			c.index = index
+			c.Unlock()
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			data, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			gwErr := &gatewayError{}
+			if err := json.Unmarshal(data, gwErr); err != nil || len(gwErr.message()) == 0 {
+				return nil, fmt.Errorf("etcd %s responds %s: %s", path, resp.Status, data)
+			}
+			return nil, gwErr
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("all the etcd endpoints are unavailable, err: %v", lastErr)
+}
+
+func isInvalidToken(err error) bool {
+	gwErr, ok := err.(*gatewayError)
+	if !ok {
+		return false
+	}
+	// the grpc code 16 is unauthenticated
+	return gwErr.Code == 16 || strings.Contains(gwErr.message(), "invalid auth token")
+}
+
+// prefixEnd returns the end of the range which contains all the keys with the prefix
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	// the prefix is all 0xff, means all the keys
+	return "\x00"
+}
@@ -0,0 +1,344 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package etcdclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGateway a minimal in memory implementation of the etcd v3 grpc gateway
+type fakeGateway struct {
+	sync.Mutex
+	revision int64
+	kvs      map[string]keyValue
+	leases   map[int64]bool
+	watchers []chan watchEvent
+	token    string
+}
+
+func newFakeGateway() *fakeGateway {
+	return &fakeGateway{kvs: make(map[string]keyValue), leases: make(map[int64]bool)}
+}
+
+func (g *fakeGateway) inRange(key string, start, end []byte) bool {
+	if len(end) == 0 {
+		return key == string(start)
+	}
+	return key >= string(start) && key < string(end)
+}
+
+// kvJSON encode the key value as the gateway does, the int64 fields are strings
+func kvJSON(kv keyValue) map[string]interface{} {
+	return map[string]interface{}{
+		"key":             kv.Key,
+		"value":           kv.Value,
+		"create_revision": int64String(kv.CreateRevision),
+		"mod_revision":    int64String(kv.ModRevision),
+		"lease":           int64String(kv.Lease),
+	}
+}
+
+func int64String(i int64) string {
+	data, _ := json.Marshal(i)
+	return string(data)
+}
+
+func (g *fakeGateway) put(key, value []byte, lease int64) {
+	g.revision++
+	kv, exist := g.kvs[string(key)]
+	if !exist {
+		kv.CreateRevision = g.revision
+	}
+	kv.Key, kv.Value, kv.ModRevision, kv.Lease = key, value, g.revision, lease
+	g.kvs[string(key)] = kv
+	g.notify(watchEvent{KV: kv})
+}
+
+func (g *fakeGateway) delete(key string) {
+	g.revision++
+	kv := g.kvs[key]
+	delete(g.kvs, key)
+	kv.ModRevision = g.revision
+	g.notify(watchEvent{Type: "DELETE", KV: kv})
+}
+
+func (g *fakeGateway) notify(event watchEvent) {
+	for _, w := range g.watchers {
+		w <- event
+	}
+}
+
+func (g *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(g.token) != 0 && r.URL.Path != "/v3/auth/authenticate" && r.Header.Get("Authorization") != g.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": "etcdserver: invalid auth token", "code": 16})
+		return
+	}
+
+	body := make(map[string]json.RawMessage)
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	g.Lock()
+	switch r.URL.Path {
+	case "/v3/maintenance/status":
+		g.write(w, map[string]interface{}{"version": "3.4.0"})
+	case "/v3/auth/authenticate":
+		g.token = "token-1"
+		g.write(w, map[string]interface{}{"token": g.token})
+	case "/v3/kv/range":
+		req := rangeRequest{}
+		g.decode(body, &req)
+		kvs := make([]keyValue, 0)
+		for key, kv := range g.kvs {
+			if g.inRange(key, req.Key, req.RangeEnd) {
+				kvs = append(kvs, kv)
+			}
+		}
+		sort.Slice(kvs, func(i, j int) bool { return kvs[i].CreateRevision < kvs[j].CreateRevision })
+		result := make([]map[string]interface{}, 0)
+		for _, kv := range kvs {
+			result = append(result, kvJSON(kv))
+		}
+		g.write(w, map[string]interface{}{
+			"header": map[string]interface{}{"revision": int64String(g.revision)},
+			"kvs":    result,
+		})
+	case "/v3/kv/put":
+		req := putRequest{}
+		g.decode(body, &req)
+		g.put(req.Key, req.Value, req.Lease)
+		g.write(w, map[string]interface{}{})
+	case "/v3/kv/txn":
+		req := txnRequest{}
+		g.decode(body, &req)
+		_, exist := g.kvs[string(req.Compare[0].Key)]
+		if !exist {
+			put := req.Success[0].RequestPut
+			g.put(put.Key, put.Value, put.Lease)
+		}
+		g.write(w, map[string]interface{}{"succeeded": !exist})
+	case "/v3/kv/deleterange":
+		req := rangeRequest{}
+		g.decode(body, &req)
+		for key := range g.kvs {
+			if g.inRange(key, req.Key, req.RangeEnd) {
+				g.delete(key)
+			}
+		}
+		g.write(w, map[string]interface{}{})
+	case "/v3/lease/grant":
+		g.revision++
+		g.leases[g.revision] = true
+		g.write(w, map[string]interface{}{"ID": int64String(g.revision), "TTL": "10"})
+	case "/v3/lease/keepalive":
+		req := leaseRequest{}
+		g.decode(body, &req)
+		result := map[string]interface{}{"ID": int64String(req.ID)}
+		if g.leases[req.ID] {
+			result["TTL"] = "10"
+		}
+		g.write(w, map[string]interface{}{"result": result})
+	case "/v3/lease/revoke":
+		req := leaseRequest{}
+		g.decode(body, &req)
+		if !g.leases[req.ID] {
+			w.WriteHeader(http.StatusNotFound)
+			g.write(w, map[string]interface{}{"error": "etcdserver: requested lease not found", "code": 5})
+			break
+		}
+		delete(g.leases, req.ID)
+		for key, kv := range g.kvs {
+			if kv.Lease == req.ID {
+				g.delete(key)
+			}
+		}
+		g.write(w, map[string]interface{}{})
+	case "/v3/watch":
+		req := struct {
+			CreateRequest watchCreateRequest `json:"create_request"`
+		}{}
+		data, _ := json.Marshal(body)
+		_ = json.Unmarshal(data, &req)
+		ch := make(chan watchEvent, 10)
+		g.watchers = append(g.watchers, ch)
+		g.Unlock()
+		g.watch(w, r, req.CreateRequest, ch)
+		return
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+	g.Unlock()
+}
+
+func (g *fakeGateway) watch(w http.ResponseWriter, r *http.Request, req watchCreateRequest, ch chan watchEvent) {
+	g.write(w, map[string]interface{}{"result": map[string]interface{}{"created": true}})
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-ch:
+			if !g.inRange(string(event.KV.Key), req.Key, req.RangeEnd) {
+				continue
+			}
+			e := map[string]interface{}{"kv": kvJSON(event.KV)}
+			if len(event.Type) != 0 {
+				e["type"] = event.Type
+			}
+			g.write(w, map[string]interface{}{"result": map[string]interface{}{"events": []interface{}{e}}})
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func (g *fakeGateway) decode(body map[string]json.RawMessage, result interface{}) {
+	data, _ := json.Marshal(body)
+	_ = json.Unmarshal(data, result)
+}
+
+func (g *fakeGateway) write(w http.ResponseWriter, data interface{}) {
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func newTestClient(t *testing.T) (*EtcdClient, *fakeGateway, func()) {
+	gateway := newFakeGateway()
+	server := httptest.NewServer(gateway)
+	client := NewEtcdClient([]string{server.URL}, "", "")
+	require.NoError(t, client.Connect(time.Second))
+	return client, gateway, server.Close
+}
+
+func TestKV(t *testing.T) {
+	client, _, closer := newTestClient(t)
+	defer closer()
+
+	_, err := client.Get("/cc/a")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	require.NoError(t, client.Put("/cc/b", []byte("b"), 0))
+	require.NoError(t, client.Put("/cc/a", []byte("a"), 0))
+	require.NoError(t, client.Put("/cd", []byte("d"), 0))
+	kv, err := client.Get("/cc/a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(kv.Value))
+
+	kvs, revision, err := client.GetPrefix("/cc/")
+	require.NoError(t, err)
+	require.Len(t, kvs, 2)
+	// ordered by the creation
+	assert.Equal(t, "/cc/b", kvs[0].Key)
+	assert.Equal(t, "/cc/a", kvs[1].Key)
+	assert.Equal(t, int64(3), revision)
+
+	ok, err := client.PutIfAbsent("/cc/a", []byte("aa"), 0)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = client.PutIfAbsent("/cc/c", []byte("c"), 0)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, client.DeletePrefix("/cc/"))
+	kvs, _, err = client.GetPrefix("/cc/")
+	require.NoError(t, err)
+	assert.Empty(t, kvs)
+	_, err = client.Get("/cd")
+	assert.NoError(t, err)
+}
+
+func TestLease(t *testing.T) {
+	client, _, closer := newTestClient(t)
+	defer closer()
+
+	lease, err := client.Grant(10)
+	require.NoError(t, err)
+	require.NoError(t, client.Put("/cc/services/a", []byte("a"), lease))
+	ttl, err := client.KeepAliveOnce(lease)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), ttl)
+
+	require.NoError(t, client.Revoke(lease))
+	_, err = client.Get("/cc/services/a")
+	assert.Equal(t, ErrKeyNotFound, err)
+	_, err = client.KeepAliveOnce(lease)
+	assert.Equal(t, ErrLeaseNotFound, err)
+	assert.Equal(t, ErrLeaseNotFound, client.Revoke(lease))
+}
+
+func TestWatch(t *testing.T) {
+	client, _, closer := newTestClient(t)
+	defer closer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := client.Watch(ctx, "/cc/", true, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, client.Put("/other", []byte("x"), 0))
+	require.NoError(t, client.Put("/cc/a", []byte("a"), 0))
+	require.NoError(t, client.Delete("/cc/a"))
+
+	resp := <-ch
+	require.NoError(t, resp.Err)
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, EventPut, resp.Events[0].Type)
+	assert.Equal(t, "/cc/a", resp.Events[0].KV.Key)
+	assert.Equal(t, "a", string(resp.Events[0].KV.Value))
+
+	resp = <-ch
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, EventDelete, resp.Events[0].Type)
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestEndpointsAndAuth(t *testing.T) {
+	gateway := newFakeGateway()
+	gateway.token = "token-0"
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	// the unavailable endpoint is skipped
+	client := NewEtcdClient([]string{"127.0.0.1:1", strings.TrimPrefix(server.URL, "http://")}, "root", "pwd")
+	require.NoError(t, client.Connect(time.Second))
+	require.NoError(t, client.Put("/cc/a", []byte("a"), 0))
+
+	// the expired token is refreshed
+	gateway.Lock()
+	gateway.token = "token-2"
+	gateway.Unlock()
+	kv, err := client.Get("/cc/a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(kv.Value))
+
+	client = NewEtcdClient([]string{server.URL}, "", "")
+	err = client.Ping()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid auth token")
+}
+
+func TestPrefixEnd(t *testing.T) {
+	assert.Equal(t, "/cc0", prefixEnd("/cc/"))
+	assert.Equal(t, "b", prefixEnd("a\xff"))
+	assert.Equal(t, "\x00", prefixEnd("\xff"))
+}
@@ -0,0 +1,127 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package etcdclient
+
+import (
+	"fmt"
+)
+
+// the messages of the etcd v3 grpc gateway, the []byte fields are encoded as base64, and the int64 fields
+// in the responses are encoded as strings.
+
+type responseHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+type keyValue struct {
+	Key            []byte `json:"key"`
+	Value          []byte `json:"value"`
+	CreateRevision int64  `json:"create_revision,string"`
+	ModRevision    int64  `json:"mod_revision,string"`
+	Lease          int64  `json:"lease,string"`
+}
+
+func (kv keyValue) toKeyValue() KeyValue {
+	return KeyValue{
+		Key:            string(kv.Key),
+		Value:          kv.Value,
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Lease:          kv.Lease,
+	}
+}
+
+type rangeRequest struct {
+	Key        []byte `json:"key"`
+	RangeEnd   []byte `json:"range_end,omitempty"`
+	SortOrder  string `json:"sort_order,omitempty"`
+	SortTarget string `json:"sort_target,omitempty"`
+}
+
+type rangeResponse struct {
+	Header responseHeader `json:"header"`
+	Kvs    []keyValue     `json:"kvs"`
+}
+
+type putRequest struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+	Lease int64  `json:"lease,omitempty"`
+}
+
+type compare struct {
+	Key            []byte `json:"key"`
+	Target         string `json:"target"`
+	Result         string `json:"result"`
+	CreateRevision int64  `json:"create_revision"`
+}
+
+type requestOp struct {
+	RequestPut *putRequest `json:"request_put,omitempty"`
+}
+
+type txnRequest struct {
+	Compare []compare   `json:"compare"`
+	Success []requestOp `json:"success"`
+}
+
+type txnResponse struct {
+	Succeeded bool `json:"succeeded"`
+}
+
+type leaseRequest struct {
+	ID  int64 `json:"ID,omitempty"`
+	TTL int64 `json:"TTL,omitempty"`
+}
+
+type leaseResponse struct {
+	ID    int64  `json:"ID,string"`
+	TTL   int64  `json:"TTL,string"`
+	Error string `json:"error"`
+}
+
+type watchCreateRequest struct {
+	Key           []byte `json:"key"`
+	RangeEnd      []byte `json:"range_end,omitempty"`
+	StartRevision int64  `json:"start_revision,omitempty"`
+}
+
+type watchEvent struct {
+	Type string   `json:"type"`
+	KV   keyValue `json:"kv"`
+}
+
+type watchResponse struct {
+	Header   responseHeader `json:"header"`
+	Created  bool           `json:"created"`
+	Canceled bool           `json:"canceled"`
+	Events   []watchEvent   `json:"events"`
+}
+
+// gatewayError the error responded by the gateway
+type gatewayError struct {
+	ErrMsg  string `json:"error"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+func (e *gatewayError) message() string {
+	if len(e.Message) != 0 {
+		return e.Message
+	}
+	return e.ErrMsg
+}
+
+func (e *gatewayError) Error() string {
+	return fmt.Sprintf("etcd error, code: %d, message: %s", e.Code, e.message())
+}
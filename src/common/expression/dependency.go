@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"fmt"
+	"sort"
+)
+
+// FindCycle find a dependency cycle which passes the start node, the dependencies is the map of
+// node to the nodes it depends on. returns the cycle path beginning and ending with start, or nil.
+func FindCycle(dependencies map[string][]string, start string) []string {
+	visited := make(map[string]bool)
+	var path []string
+	var walk func(name string) bool
+	walk = func(name string) bool {
+		path = append(path, name)
+		for _, dep := range dependencies[name] {
+			if dep == start {
+				path = append(path, dep)
+				return true
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if walk(dep) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if walk(start) {
+		return path
+	}
+	return nil
+}
+
+// SortByDependency sort the nodes so that a node is always after the nodes it depends on,
+// the dependencies which are not nodes of the map are ignored. nodes without order are sorted by name.
+func SortByDependency(dependencies map[string][]string) ([]string, error) {
+	names := make([]string, 0, len(dependencies))
+	for name := range dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	sorted := make([]string, 0, len(names))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle found at %s", name)
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range dependencies[name] {
+			if _, exists := dependencies[dep]; !exists {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		sorted = append(sorted, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,367 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDivideByZero the divisor of / or % is zero
+var ErrDivideByZero = errors.New("divide by zero")
+
+// Getter is the data source which is not a plain map, such as mapstr.MapStr
+type Getter interface {
+	Get(key string) (interface{}, bool)
+}
+
+// Evaluate evaluate the expression with the data. a dotted variable such as parent.bk_set_name
+// is looked up in the nested map of the data, a variable not found is null.
+// the result is null, bool, string, int64 or float64.
+func (e *Expression) Evaluate(data map[string]interface{}) (interface{}, error) {
+	val, err := e.root.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	if num, ok := val.(float64); ok && num == math.Trunc(num) && math.Abs(num) < 1<<53 {
+		return int64(num), nil
+	}
+	return val, nil
+}
+
+type node interface {
+	eval(data map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(data map[string]interface{}) (interface{}, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(data map[string]interface{}) (interface{}, error) {
+	var current interface{} = data
+	for _, key := range strings.Split(n.name, ".") {
+		switch m := current.(type) {
+		case map[string]interface{}:
+			current = m[key]
+		case Getter:
+			current, _ = m.Get(key)
+		default:
+			return nil, nil
+		}
+	}
+	return normalize(current), nil
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(data map[string]interface{}) (interface{}, error) {
+	val, err := n.operand.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !truthy(val), nil
+	}
+	if val == nil {
+		return nil, nil
+	}
+	num, err := toNumber(val)
+	if err != nil {
+		return nil, err
+	}
+	return -num, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) eval(data map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	// short circuit for the logical operators
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(right), nil
+	}
+
+	right, err := n.right.eval(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	}
+
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	if n.op == "+" {
+		_, leftIsStr := left.(string)
+		_, rightIsStr := right.(string)
+		if leftIsStr || rightIsStr {
+			return toString(left) + toString(right), nil
+		}
+	}
+	l, err := toNumber(left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := toNumber(right)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, ErrDivideByZero
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return nil, ErrDivideByZero
+		}
+		return math.Mod(l, r), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", n.op)
+}
+
+type ternaryNode struct {
+	cond, then, otherwise node
+}
+
+func (n *ternaryNode) eval(data map[string]interface{}) (interface{}, error) {
+	cond, err := n.cond.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(cond) {
+		return n.then.eval(data)
+	}
+	return n.otherwise.eval(data)
+}
+
+type callNode struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (n *callNode) eval(data map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for idx, arg := range n.args {
+		val, err := arg.eval(data)
+		if err != nil {
+			return nil, err
+		}
+		args[idx] = val
+	}
+	val, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("call %s failed, %v", n.name, err)
+	}
+	return val, nil
+}
+
+// normalize convert the value to null, bool, string or float64
+func normalize(val interface{}) interface{} {
+	switch v := val.(type) {
+	case nil, bool, string, float64:
+		return v
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if num, err := v.Float64(); err == nil {
+			return num
+		}
+		return v.String()
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(s), 64)
+}
+
+func toNumber(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		num, err := parseNumber(v)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", v)
+		}
+		return num, nil
+	}
+	return 0, fmt.Errorf("%v is not a number", val)
+}
+
+func toString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", val)
+}
+
+func truthy(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+func equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	l, leftIsNum := left.(float64)
+	r, rightIsNum := right.(float64)
+	if leftIsNum && rightIsNum {
+		return l == r
+	}
+	if leftIsNum || rightIsNum {
+		// compare number with a numeric string
+		ln, lerr := toNumber(left)
+		rn, rerr := toNumber(right)
+		if lerr == nil && rerr == nil {
+			return ln == rn
+		}
+		return false
+	}
+	return left == right
+}
+
+func compare(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+	var result int
+	ls, leftIsStr := left.(string)
+	rs, rightIsStr := right.(string)
+	if leftIsStr && rightIsStr {
+		result = strings.Compare(ls, rs)
+	} else {
+		l, err := toNumber(left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := toNumber(right)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case l < r:
+			result = -1
+		case l > r:
+			result = 1
+		}
+	}
+	switch op {
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	default:
+		return result >= 0, nil
+	}
+}
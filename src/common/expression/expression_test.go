@@ -0,0 +1,172 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression_test
+
+import (
+	"testing"
+
+	"configcenter/src/common/expression"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	data := map[string]interface{}{
+		"bk_mem":         int64(16384),
+		"bk_cpu":         8,
+		"bk_disk":        "500",
+		"bk_module_name": "gameserver",
+		"empty":          "",
+		"parent": map[string]interface{}{
+			"bk_set_name": "prod-sh-01",
+			"parent": map[string]interface{}{
+				"bk_biz_name": "demo",
+			},
+		},
+	}
+	cases := []struct {
+		expr   string
+		expect interface{}
+	}{
+		{"bk_mem / 1024", int64(16)},
+		{"bk_mem / 1000", 16.384},
+		{"round(bk_mem / 1000, 1)", 16.4},
+		{"1 + 2 * 3 - 4 % 3", int64(6)},
+		{"(1 + 2) * 3", int64(9)},
+		{"-bk_cpu", int64(-8)},
+		{"bk_disk * 2", int64(1000)},
+		{"bk_cpu > 4 ? 'large' : 'small'", "large"},
+		{"bk_cpu >= 16 ? 'large' : bk_cpu >= 8 ? 'medium' : 'small'", "medium"},
+		{"parent.bk_set_name + '/' + bk_module_name", "prod-sh-01/gameserver"},
+		{"split(parent.bk_set_name, '-', 0)", "prod"},
+		{"split(parent.bk_set_name, '-', -1)", "01"},
+		{"split(parent.bk_set_name, '-', 5)", nil},
+		{"upper(parent.parent.bk_biz_name)", "DEMO"},
+		{"concat(bk_module_name, \"-\", bk_cpu)", "gameserver-8"},
+		{"substr(bk_module_name, 0, 4)", "game"},
+		{"substr(bk_module_name, -6)", "server"},
+		{"len(bk_module_name)", int64(10)},
+		{"coalesce(empty, not_exist, 'default')", "default"},
+		{"not_exist + 1", nil},
+		{"not_exist == null", true},
+		{"bk_disk == 500", true},
+		{"bk_module_name != 'gameserver'", false},
+		{"bk_cpu > 4 && contains(bk_module_name, 'game')", true},
+		{"!empty || false", true},
+		{"max(bk_cpu, 2, not_exist)", int64(8)},
+		{"min(bk_cpu, 2)", int64(2)},
+		{"floor(2.7) + ceil(2.2) + abs(-1)", int64(6)},
+		{"replace(bk_module_name, 'server', 'svr')", "gamesvr"},
+		{"number('1.5') + 1", 2.5},
+		{"string(1.5) + 1", "1.51"},
+	}
+	for _, c := range cases {
+		expr, err := expression.Parse(c.expr)
+		if !assert.NoError(t, err, c.expr) {
+			continue
+		}
+		result, err := expr.Evaluate(data)
+		if !assert.NoError(t, err, c.expr) {
+			continue
+		}
+		assert.Equal(t, c.expect, result, c.expr)
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	data := map[string]interface{}{"name": "abc", "zero": 0}
+	cases := []string{
+		"1 / zero",
+		"1 % 0",
+		"name * 2",
+		"name > 1",
+		"round(name)",
+	}
+	for _, c := range cases {
+		expr, err := expression.Parse(c)
+		if !assert.NoError(t, err, c) {
+			continue
+		}
+		_, err = expr.Evaluate(data)
+		assert.Error(t, err, c)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	cases := []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"a ? b",
+		"unknown_func(a)",
+		"upper(a, b)",
+		"split(a, '-')",
+		"'unterminated",
+		"a..b",
+		"a.",
+		"1.2.3",
+		"a # b",
+		"a b",
+	}
+	for _, c := range cases {
+		_, err := expression.Parse(c)
+		assert.Error(t, err, c)
+	}
+}
+
+func TestVariables(t *testing.T) {
+	expr, err := expression.Parse("concat(b, parent.name, a) + (b > 1 ? c : 'x')")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"a", "b", "c", "parent.name"}, expr.Variables())
+}
+
+func TestMaxDeep(t *testing.T) {
+	deep := ""
+	for i := 0; i <= expression.MaxDeep+1; i++ {
+		deep += "("
+	}
+	deep += "1"
+	for i := 0; i <= expression.MaxDeep+1; i++ {
+		deep += ")"
+	}
+	_, err := expression.Parse(deep)
+	assert.Error(t, err)
+}
+
+func TestFindCycle(t *testing.T) {
+	deps := map[string][]string{
+		"a": {"b", "x"},
+		"b": {"c"},
+		"c": {"a"},
+		"d": {"a"},
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a"}, expression.FindCycle(deps, "a"))
+	assert.Nil(t, expression.FindCycle(deps, "d"))
+	assert.Equal(t, []string{"e", "e"}, expression.FindCycle(map[string][]string{"e": {"e"}}, "e"))
+}
+
+func TestSortByDependency(t *testing.T) {
+	sorted, err := expression.SortByDependency(map[string][]string{
+		"total": {"sub1", "sub2", "bk_mem"},
+		"sub1":  {"bk_cpu"},
+		"sub2":  {"sub1"},
+		"other": nil,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"other", "sub1", "sub2", "total"}, sorted)
+
+	_, err = expression.SortByDependency(map[string][]string{"a": {"b"}, "b": {"a"}})
+	assert.Error(t, err)
+}
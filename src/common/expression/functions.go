@@ -0,0 +1,201 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"math"
+	"strings"
+)
+
+type function struct {
+	minArgs int
+	// maxArgs -1 means no limit
+	maxArgs int
+	call    func(args []interface{}) (interface{}, error)
+}
+
+// functions the builtin functions, a function returns null when the input is null
+var functions = map[string]function{
+	"concat": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		var sb strings.Builder
+		for _, arg := range args {
+			sb.WriteString(toString(arg))
+		}
+		return sb.String(), nil
+	}},
+	"upper": {minArgs: 1, maxArgs: 1, call: stringFunc(strings.ToUpper)},
+	"lower": {minArgs: 1, maxArgs: 1, call: stringFunc(strings.ToLower)},
+	"trim":  {minArgs: 1, maxArgs: 1, call: stringFunc(strings.TrimSpace)},
+	"len": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return float64(0), nil
+		}
+		return float64(len([]rune(toString(args[0])))), nil
+	}},
+	// substr(s, start[, length]), the index is counted by character
+	"substr": {minArgs: 2, maxArgs: 3, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		runes := []rune(toString(args[0]))
+		start, err := toNumber(args[1])
+		if err != nil {
+			return nil, err
+		}
+		begin := clamp(int(start), len(runes))
+		end := len(runes)
+		if len(args) == 3 {
+			length, err := toNumber(args[2])
+			if err != nil {
+				return nil, err
+			}
+			if length < 0 {
+				return "", nil
+			}
+			end = clamp(begin+int(length), len(runes))
+		}
+		return string(runes[begin:end]), nil
+	}},
+	// split(s, sep, index) return the index-th part of s, null if out of range
+	"split": {minArgs: 3, maxArgs: 3, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		parts := strings.Split(toString(args[0]), toString(args[1]))
+		index, err := toNumber(args[2])
+		if err != nil {
+			return nil, err
+		}
+		idx := int(index)
+		if idx < 0 {
+			idx += len(parts)
+		}
+		if idx < 0 || idx >= len(parts) {
+			return nil, nil
+		}
+		return parts[idx], nil
+	}},
+	"replace": {minArgs: 3, maxArgs: 3, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return strings.Replace(toString(args[0]), toString(args[1]), toString(args[2]), -1), nil
+	}},
+	"contains": {minArgs: 2, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return false, nil
+		}
+		return strings.Contains(toString(args[0]), toString(args[1])), nil
+	}},
+	// round(x[, digits])
+	"round": {minArgs: 1, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		num, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		digits := float64(0)
+		if len(args) == 2 {
+			if digits, err = toNumber(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		pow := math.Pow(10, math.Trunc(digits))
+		return math.Round(num*pow) / pow, nil
+	}},
+	"floor": {minArgs: 1, maxArgs: 1, call: numberFunc(math.Floor)},
+	"ceil":  {minArgs: 1, maxArgs: 1, call: numberFunc(math.Ceil)},
+	"abs":   {minArgs: 1, maxArgs: 1, call: numberFunc(math.Abs)},
+	"min": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		return extremum(args, func(a, b float64) bool { return a < b })
+	}},
+	"max": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		return extremum(args, func(a, b float64) bool { return a > b })
+	}},
+	// coalesce return the first argument which is not null or empty string
+	"coalesce": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		for _, arg := range args {
+			if arg != nil && arg != "" {
+				return arg, nil
+			}
+		}
+		return nil, nil
+	}},
+	"number": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return toNumber(args[0])
+	}},
+	"string": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return toString(args[0]), nil
+	}},
+}
+
+func stringFunc(fn func(string) string) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return fn(toString(args[0])), nil
+	}
+}
+
+func numberFunc(fn func(float64) float64) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		num, err := toNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return fn(num), nil
+	}
+}
+
+// extremum return the min or max of the arguments, the null arguments are ignored
+func extremum(args []interface{}, better func(a, b float64) bool) (interface{}, error) {
+	var result interface{}
+	for _, arg := range args {
+		if arg == nil {
+			continue
+		}
+		num, err := toNumber(arg)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil || better(num, result.(float64)) {
+			result = num
+		}
+	}
+	return result, nil
+}
+
+func clamp(idx, length int) int {
+	if idx < 0 {
+		idx += length
+		if idx < 0 {
+			return 0
+		}
+	}
+	if idx > length {
+		return length
+	}
+	return idx
+}
@@ -0,0 +1,242 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MaxDeep the max nesting deep of an expression
+const MaxDeep = 32
+
+// Expression a parsed expression which can be evaluated many times
+type Expression struct {
+	raw       string
+	root      node
+	variables []string
+}
+
+// Parse parse the expression, the syntax is like:
+//
+//	bk_mem / 1024
+//	parent.bk_set_name + "-" + bk_module_name
+//	bk_cpu > 8 ? "large" : "small"
+func Parse(expr string) (*Expression, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, variables: make(map[string]bool)}
+	root, err := p.parseTernary(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at %d", tok.text, tok.pos)
+	}
+
+	variables := make([]string, 0, len(p.variables))
+	for name := range p.variables {
+		variables = append(variables, name)
+	}
+	sort.Strings(variables)
+	return &Expression{raw: expr, root: root, variables: variables}, nil
+}
+
+// String return the raw expression
+func (e *Expression) String() string {
+	return e.raw
+}
+
+// Variables return the variables referenced by the expression, sorted and unique
+func (e *Expression) Variables() []string {
+	return e.variables
+}
+
+type parser struct {
+	tokens    []token
+	pos       int
+	variables map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOperator(op) {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return fmt.Errorf("expect %s at the end", op)
+		}
+		return fmt.Errorf("expect %s at %d, got %s", op, tok.pos, tok.text)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) parseTernary(deep int) (node, error) {
+	if deep > MaxDeep {
+		return nil, fmt.Errorf("expression exceed max deep %d", MaxDeep)
+	}
+	cond, err := p.parseBinary(0, deep)
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("?") {
+		return cond, nil
+	}
+	p.next()
+	then, err := p.parseTernary(deep + 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseTernary(deep + 1)
+	if err != nil {
+		return nil, err
+	}
+	return &ternaryNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// binaryLevels the binary operators from the lowest precedence to the highest
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level, deep int) (node, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary(deep)
+	}
+	left, err := p.parseBinary(level+1, deep)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator(binaryLevels[level]...) {
+		op := p.next().text
+		right, err := p.parseBinary(level+1, deep)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary(deep int) (node, error) {
+	if p.isOperator("!", "-") {
+		if deep > MaxDeep {
+			return nil, fmt.Errorf("expression exceed max deep %d", MaxDeep)
+		}
+		op := p.next().text
+		operand, err := p.parseUnary(deep + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary(deep)
+}
+
+func (p *parser) parsePrimary(deep int) (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber, tokenString:
+		return &literalNode{value: tok.value}, nil
+
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if !p.isOperator("(") {
+			p.variables[tok.text] = true
+			return &variableNode{name: tok.text}, nil
+		}
+		fn, exists := functions[tok.text]
+		if !exists {
+			return nil, fmt.Errorf("unknown function %s at %d", tok.text, tok.pos)
+		}
+		p.next()
+		args := make([]node, 0)
+		if !p.isOperator(")") {
+			for {
+				arg, err := p.parseTernary(deep + 1)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if !p.isOperator(",") {
+					break
+				}
+				p.next()
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+			return nil, fmt.Errorf("invalid argument count %d of function %s", len(args), tok.text)
+		}
+		return &callNode{name: tok.text, fn: fn, args: args}, nil
+
+	case tokenOperator:
+		if tok.text == "(" {
+			inner, err := p.parseTernary(deep + 1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+		return nil, fmt.Errorf("unexpected %s at %d", tok.text, tok.pos)
+
+	default:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+}
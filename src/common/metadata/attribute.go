@@ -70,6 +70,35 @@ type Attribute struct {
 	LastTime   *Time  `json:"last_time" bson:"last_time"`
 }
 
+// ReferenceDisplayField the field of the instance search result, which contains the display info of the reference attributes
+const ReferenceDisplayField = "bk_reference"
+
+// ReferenceOption the option of the reference attribute, the attribute value is the instance id of the target object
+type ReferenceOption struct {
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+}
+
+// ReferenceDisplay the display info of a reference attribute value
+type ReferenceDisplay struct {
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id" bson:"bk_inst_id"`
+	InstName string `json:"bk_inst_name" bson:"bk_inst_name"`
+}
+
+// ComputedParentVariable the variable in the computed attribute expression refers to the mainline parent instance,
+// such as parent.bk_set_name
+const ComputedParentVariable = "parent"
+
+// ComputedOption the option of the computed attribute, the attribute value is evaluated from the expression
+type ComputedOption struct {
+	Expression string `json:"expression" bson:"expression"`
+}
+
+// RefreshComputedValuesTask is the task_server task data to backfill the computed values of the model instances
+type RefreshComputedValuesTask struct {
+	ObjectID string `json:"bk_obj_id"`
+}
+
 // AttributeGroup attribute metadata definition
 type AttributeGroup struct {
 	ID         int64  `field:"id" json:"id" bson:"id"`
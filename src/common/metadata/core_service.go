@@ -328,6 +328,8 @@ type ListServiceTemplateOption struct {
 	ServiceTemplateIDs []int64  `json:"service_template_ids"`
 	Page               BasePage `json:"page,omitempty"`
 	Search             string   `json:"search"`
+	// UpstreamTemplateID list the templates created from the global service template
+	UpstreamTemplateID int64 `json:"upstream_template_id"`
 }
 
 type OneServiceTemplateResult struct {
@@ -382,6 +384,7 @@ type ListProcessInstanceRelationOption struct {
 	ServiceInstanceIDs []int64  `json:"service_instance_id,omitempty"`
 	ProcessTemplateID  int64    `json:"process_template_id,omitempty"`
 	HostID             int64    `json:"host_id,omitempty"`
+	HostIDs            []int64  `json:"host_ids,omitempty"` // relations of all businesses are listed if BusinessID is 0
 	Page               BasePage `json:"page" field:"page"`
 }
 
@@ -15,16 +15,26 @@ package metadata
 import (
 	"time"
 
+	"configcenter/src/common"
 	"configcenter/src/common/mapstr"
 )
 
+// HostLockRequest lock or unlock hosts, the hosts are specified by host id or by inner ip with cloud id
 type HostLockRequest struct {
 	IPS     []string `json:"ip_list"`
+	IDS     []int64  `json:"id_list"`
 	CloudID int64    `json:"bk_cloud_id"`
+	// Reason why the hosts are locked, only used when lock
+	Reason string `json:"reason"`
+	// TTL seconds the lock lasts, the lock never expires when it is 0, only used when lock
+	TTL int64 `json:"ttl"`
+	// Force unlock the hosts locked by other users, only the administrator can do this, only used when unlock
+	Force bool `json:"force"`
 }
 
 type QueryHostLockRequest struct {
 	IPS     []string `json:"ip_list"`
+	IDS     []int64  `json:"id_list"`
 	CloudID int64    `json:"bk_cloud_id"`
 }
 
@@ -34,11 +44,30 @@ type HostLockResultResponse struct {
 }
 
 type HostLockData struct {
-	User       string    `json:"bk_user" bson:"bk_user"`
-	IP         string    `json:"bk_host_innerip" bson:"bk_host_innerip"`
-	CloudID    int64     `json:"bk_cloud_id" bson:"bk_cloud_id"`
-	CreateTime time.Time `json:"create_time" bson:"create_time"`
-	OwnerID    string    `json:"-" bson:"bk_supplier_account"`
+	User       string     `json:"bk_user" bson:"bk_user"`
+	HostID     int64      `json:"bk_host_id" bson:"bk_host_id"`
+	IP         string     `json:"bk_host_innerip" bson:"bk_host_innerip"`
+	CloudID    int64      `json:"bk_cloud_id" bson:"bk_cloud_id"`
+	Reason     string     `json:"reason" bson:"reason"`
+	CreateTime time.Time  `json:"create_time" bson:"create_time"`
+	ExpireTime *time.Time `json:"expire_time,omitempty" bson:"expire_time,omitempty"`
+	OwnerID    string     `json:"-" bson:"bk_supplier_account"`
+}
+
+// Expired check whether the lock is released automatically
+func (h HostLockData) Expired(now time.Time) bool {
+	return h.ExpireTime != nil && !h.ExpireTime.After(now)
+}
+
+// HostLockActiveCondition returns the condition matches the locks which are not expired at now
+func HostLockActiveCondition(now time.Time) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			// a lock without expire_time never expires
+			{"expire_time": nil},
+			{"expire_time": mapstr.MapStr{common.BKDBGT: now}},
+		},
+	}
 }
 
 type HostLockQueryResponse struct {
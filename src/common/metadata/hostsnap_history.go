@@ -0,0 +1,85 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+const (
+	// HostChangeSourceAgent the change is reported by the agent snapshot
+	HostChangeSourceAgent = "agent"
+	// HostChangeSourceManual the change is made by a user
+	HostChangeSourceManual = "manual"
+)
+
+// HostSnapChange a host attribute changed by the snapshot
+type HostSnapChange struct {
+	PropertyID string      `json:"bk_property_id" bson:"bk_property_id"`
+	PreValue   interface{} `json:"pre_value" bson:"pre_value"`
+	CurValue   interface{} `json:"cur_value" bson:"cur_value"`
+}
+
+// HostSnapHistory the compacted history of the host snapshot, only the changed attributes are saved
+type HostSnapHistory struct {
+	HostID     int64            `json:"bk_host_id" bson:"bk_host_id"`
+	OwnerID    string           `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Changes    []HostSnapChange `json:"changes" bson:"changes"`
+	CreateTime time.Time        `json:"create_time" bson:"create_time"`
+}
+
+// HostSnapHistoryOption the option to search the snapshot history of a host, sorted by create time desc
+type HostSnapHistoryOption struct {
+	HostID    int64      `json:"bk_host_id"`
+	StartTime *time.Time `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Page      BasePage   `json:"page"`
+}
+
+// HostSnapHistoryResult the snapshot history of a host
+type HostSnapHistoryResult struct {
+	BaseResp `json:",inline"`
+	Data     struct {
+		Count int64             `json:"count"`
+		Info  []HostSnapHistory `json:"info"`
+	} `json:"data"`
+}
+
+// HostChangeTimelineOption the option to get the change timeline of a host
+type HostChangeTimelineOption struct {
+	StartTime *time.Time `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	// Source filter by the source of the changes, agent or manual, empty means all
+	Source string   `json:"source"`
+	Page   BasePage `json:"page"`
+}
+
+// HostChangeTimelineItem the changes of a host at a point in time
+type HostChangeTimelineItem struct {
+	Source   string           `json:"source"`
+	Operator string           `json:"operator"`
+	Changes  []HostSnapChange `json:"changes"`
+	Time     time.Time        `json:"time"`
+}
+
+// HostChangeTimeline the change timeline of a host, sorted by time desc
+type HostChangeTimeline struct {
+	Count int                      `json:"count"`
+	Info  []HostChangeTimelineItem `json:"info"`
+}
+
+// HostChangeTimelineResult the change timeline of a host
+type HostChangeTimelineResult struct {
+	BaseResp `json:",inline"`
+	Data     HostChangeTimeline `json:"data"`
+}
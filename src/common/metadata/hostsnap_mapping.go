@@ -0,0 +1,141 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"configcenter/src/common/util"
+)
+
+// HostSnapTransform how the values found by the snapshot path are reduced to one value
+type HostSnapTransform string
+
+const (
+	// HostSnapTransformFirst use the first value, it's the default transform
+	HostSnapTransformFirst HostSnapTransform = "first"
+	// HostSnapTransformSum sum the numeric values
+	HostSnapTransformSum HostSnapTransform = "sum"
+	// HostSnapTransformMax use the max numeric value
+	HostSnapTransformMax HostSnapTransform = "max"
+	// HostSnapTransformMin use the min numeric value
+	HostSnapTransformMin HostSnapTransform = "min"
+	// HostSnapTransformCount count the values
+	HostSnapTransformCount HostSnapTransform = "count"
+	// HostSnapTransformJoin join the values with comma
+	HostSnapTransformJoin HostSnapTransform = "join"
+)
+
+// Validate validate the transform, empty means the default transform
+func (t HostSnapTransform) Validate() error {
+	validValues := []HostSnapTransform{"", HostSnapTransformFirst, HostSnapTransformSum, HostSnapTransformMax,
+		HostSnapTransformMin, HostSnapTransformCount, HostSnapTransformJoin}
+	if util.InArray(t, validValues) == false {
+		return fmt.Errorf("invalid transform, value: %s, available values: %+v", t, validValues)
+	}
+	return nil
+}
+
+// HostSnapOverwritePolicy decide whether the collected value overwrites the value of the host attribute
+type HostSnapOverwritePolicy string
+
+const (
+	// HostSnapOverwriteAlways always overwrite with the collected value, it's the default policy
+	HostSnapOverwriteAlways HostSnapOverwritePolicy = "always"
+	// HostSnapOverwriteIfEmpty only fill the host attribute which is empty
+	HostSnapOverwriteIfEmpty HostSnapOverwritePolicy = "if_empty"
+	// HostSnapOverwriteNotEmpty only overwrite when the collected value is not empty
+	HostSnapOverwriteNotEmpty HostSnapOverwritePolicy = "not_empty"
+)
+
+// Validate validate the policy, empty means the default policy
+func (p HostSnapOverwritePolicy) Validate() error {
+	validValues := []HostSnapOverwritePolicy{"", HostSnapOverwriteAlways, HostSnapOverwriteIfEmpty, HostSnapOverwriteNotEmpty}
+	if util.InArray(p, validValues) == false {
+		return fmt.Errorf("invalid overwrite policy, value: %s, available values: %+v", p, validValues)
+	}
+	return nil
+}
+
+// HostSnapUnits the units can be converted to each other, the value is the multiple of the base unit
+var HostSnapUnits = map[string]map[string]float64{
+	"byte":   {"B": 1, "KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30, "TB": 1 << 40},
+	"hertz":  {"Hz": 1, "KHz": 1e3, "MHz": 1e6, "GHz": 1e9},
+	"second": {"ms": 1e-3, "s": 1, "min": 60, "h": 3600, "d": 86400},
+}
+
+// HostSnapUnitFactor returns the factor which converts the value in unit from to unit to
+func HostSnapUnitFactor(from, to string) (float64, error) {
+	for _, units := range HostSnapUnits {
+		fromFactor, fromExists := units[from]
+		toFactor, toExists := units[to]
+		if fromExists && toExists {
+			return fromFactor / toFactor, nil
+		}
+	}
+	return 0, fmt.Errorf("can not convert unit %s to %s", from, to)
+}
+
+// HostSnapMapping describe how a value in the host snapshot is saved to a host attribute
+type HostSnapMapping struct {
+	ID int64 `field:"id" json:"id" bson:"id"`
+	// Path the gjson path in the snapshot, such as data.system.info.kernelVersion or data.cpu.cpuinfo.#.cores
+	Path      string            `field:"path" json:"path" bson:"path"`
+	Transform HostSnapTransform `field:"transform" json:"transform" bson:"transform"`
+	// UnitFrom and UnitTo convert the numeric value between units, such as B to GB, both are empty or set
+	UnitFrom   string                  `field:"unit_from" json:"unit_from" bson:"unit_from"`
+	UnitTo     string                  `field:"unit_to" json:"unit_to" bson:"unit_to"`
+	PropertyID string                  `field:"bk_property_id" json:"bk_property_id" bson:"bk_property_id"`
+	Overwrite  HostSnapOverwritePolicy `field:"overwrite" json:"overwrite" bson:"overwrite"`
+	Enabled    bool                    `field:"enabled" json:"enabled" bson:"enabled"`
+	OwnerID    string                  `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime time.Time               `field:"create_time" json:"create_time" bson:"create_time"`
+	LastTime   time.Time               `field:"last_time" json:"last_time" bson:"last_time"`
+}
+
+// Validate validate the mapping, the host attribute is checked by the caller
+func (m *HostSnapMapping) Validate() (field string, err error) {
+	if len(m.Path) == 0 {
+		return "path", errors.New("path can't be empty")
+	}
+	if len(m.PropertyID) == 0 {
+		return "bk_property_id", errors.New("bk_property_id can't be empty")
+	}
+	if err := m.Transform.Validate(); err != nil {
+		return "transform", err
+	}
+	if err := m.Overwrite.Validate(); err != nil {
+		return "overwrite", err
+	}
+	if len(m.UnitFrom) != 0 || len(m.UnitTo) != 0 {
+		if _, err := HostSnapUnitFactor(m.UnitFrom, m.UnitTo); err != nil {
+			return "unit_to", err
+		}
+	}
+	return "", nil
+}
+
+// HostSnapMappingSearchOption the option to search host snapshot mappings
+type HostSnapMappingSearchOption struct {
+	PropertyID string   `json:"bk_property_id"`
+	Enabled    *bool    `json:"enabled"`
+	Page       BasePage `json:"page"`
+}
+
+// HostSnapMappingSearchResult the host snapshot mappings
+type HostSnapMappingSearchResult struct {
+	Count int64             `json:"count"`
+	Info  []HostSnapMapping `json:"info"`
+}
@@ -109,6 +109,9 @@ type HostIdentProcess struct {
 	FuncName        string  `json:"bk_func_name" bson:"bk_func_name"`                 // 功能名称
 	StartParamRegex string  `json:"bk_start_param_regex" bson:"bk_start_param_regex"` // 启动参数匹配规则
 	BindModules     []int64 `json:"bind_modules" bson:"bind_modules"`                 // 进程绑定的模块ID，数字数组
+
+	// BindInfo 进程的绑定信息列表，一个进程可以监听多个IP、端口和协议
+	BindInfo []ProcBindInfo `json:"bind_info" bson:"bind_info"`
 }
 
 type HostIdentProcessSorter []HostIdentProcess
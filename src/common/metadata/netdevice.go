@@ -13,6 +13,8 @@
 package metadata
 
 import (
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -96,6 +98,106 @@ type NetcollectConfig struct {
 	ScanRange []string `json:"scan_range" bson:"scan_range"`
 	Period    string   `json:"period" bson:"period"`
 	Community string   `json:"community" bson:"community"`
+	// Snmp the snmp options used by the built-in snmp poller of datacollection, snmp v2c with the community is used when it's empty
+	Snmp *NetcollectSnmp `json:"snmp,omitempty" bson:"snmp,omitempty"`
+}
+
+// NetcollectSnmp define the snmp options of the collector
+type NetcollectSnmp struct {
+	// Version v2c or v3
+	Version string `json:"version" bson:"version"`
+	Port    uint16 `json:"port,omitempty" bson:"port,omitempty"`
+
+	// the fields below are only used by snmp v3
+	UserName string `json:"user_name,omitempty" bson:"user_name,omitempty"`
+	// SecurityLevel noAuthNoPriv, authNoPriv or authPriv
+	SecurityLevel string `json:"security_level,omitempty" bson:"security_level,omitempty"`
+	// AuthProtocol MD5, SHA, SHA224, SHA256, SHA384 or SHA512
+	AuthProtocol   string `json:"auth_protocol,omitempty" bson:"auth_protocol,omitempty"`
+	AuthPassphrase string `json:"auth_passphrase,omitempty" bson:"auth_passphrase,omitempty"`
+	// PrivProtocol DES, AES, AES192 or AES256
+	PrivProtocol   string `json:"priv_protocol,omitempty" bson:"priv_protocol,omitempty"`
+	PrivPassphrase string `json:"priv_passphrase,omitempty" bson:"priv_passphrase,omitempty"`
+}
+
+// NetcollectSnmp define
+const (
+	SnmpVersion2c = "v2c"
+	SnmpVersion3  = "v3"
+
+	SnmpNoAuthNoPriv = "noAuthNoPriv"
+	SnmpAuthNoPriv   = "authNoPriv"
+	SnmpAuthPriv     = "authPriv"
+
+	// SnmpSecretMask replace the snmp passphrases in the responses
+	SnmpSecretMask = "******"
+)
+
+// MaskSecrets replace the passphrases with the mask, they're never returned to the caller
+func (s *NetcollectSnmp) MaskSecrets() {
+	if len(s.AuthPassphrase) != 0 {
+		s.AuthPassphrase = SnmpSecretMask
+	}
+	if len(s.PrivPassphrase) != 0 {
+		s.PrivPassphrase = SnmpSecretMask
+	}
+}
+
+// KeepSecrets keep the saved passphrases when the updated ones are empty or masked
+func (s *NetcollectSnmp) KeepSecrets(saved *NetcollectSnmp) {
+	if saved == nil {
+		saved = &NetcollectSnmp{}
+	}
+	if len(s.AuthPassphrase) == 0 || s.AuthPassphrase == SnmpSecretMask {
+		s.AuthPassphrase = saved.AuthPassphrase
+	}
+	if len(s.PrivPassphrase) == 0 || s.PrivPassphrase == SnmpSecretMask {
+		s.PrivPassphrase = saved.PrivPassphrase
+	}
+}
+
+// Validate validate the snmp options of the collector
+func (s *NetcollectSnmp) Validate() (field string, err error) {
+	switch s.Version {
+	case SnmpVersion2c:
+		return "", nil
+	case SnmpVersion3:
+	default:
+		return "snmp.version", fmt.Errorf("unsupported snmp version %s", s.Version)
+	}
+
+	if len(s.UserName) == 0 {
+		return "snmp.user_name", errors.New("user_name can't be empty")
+	}
+	switch s.SecurityLevel {
+	case SnmpNoAuthNoPriv:
+		return "", nil
+	case SnmpAuthNoPriv, SnmpAuthPriv:
+	default:
+		return "snmp.security_level", fmt.Errorf("unsupported security level %s", s.SecurityLevel)
+	}
+
+	switch s.AuthProtocol {
+	case "MD5", "SHA", "SHA224", "SHA256", "SHA384", "SHA512":
+	default:
+		return "snmp.auth_protocol", fmt.Errorf("unsupported auth protocol %s", s.AuthProtocol)
+	}
+	if len(s.AuthPassphrase) == 0 {
+		return "snmp.auth_passphrase", errors.New("auth_passphrase can't be empty")
+	}
+	if s.SecurityLevel == SnmpAuthNoPriv {
+		return "", nil
+	}
+
+	switch s.PrivProtocol {
+	case "DES", "AES", "AES192", "AES256":
+	default:
+		return "snmp.priv_protocol", fmt.Errorf("unsupported priv protocol %s", s.PrivProtocol)
+	}
+	if len(s.PrivPassphrase) == 0 {
+		return "snmp.priv_passphrase", errors.New("priv_passphrase can't be empty")
+	}
+	return "", nil
 }
 
 type ParamSearchNetcollectReport struct {
@@ -0,0 +1,238 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"configcenter/src/common/querybuilder"
+)
+
+// CustomChart bucket define, the time bucket of the points of the custom chart
+const (
+	// CustomChartBucketNone only the latest result is kept
+	CustomChartBucketNone  = ""
+	CustomChartBucketHour  = "hour"
+	CustomChartBucketDay   = "day"
+	CustomChartBucketWeek  = "week"
+	CustomChartBucketMonth = "month"
+)
+
+// CustomChart metric define
+const (
+	CustomChartMetricCount = "count"
+	CustomChartMetricSum   = "sum"
+	CustomChartMetricAvg   = "avg"
+	CustomChartMetricMin   = "min"
+	CustomChartMetricMax   = "max"
+)
+
+// CustomChartMaxGroupBy the max count of the group by fields of a custom chart
+const CustomChartMaxGroupBy = 3
+
+// CustomChartMetric the metric of the custom chart, the field is required except count
+type CustomChartMetric struct {
+	Type  string `json:"type" bson:"type"`
+	Field string `json:"field,omitempty" bson:"field,omitempty"`
+}
+
+// CustomChart the chart definition computed over the instances of a model by the operation timer,
+// the instances matching the filter are grouped by the fields and the metric is computed for each group.
+type CustomChart struct {
+	ID     uint64                    `json:"id" bson:"id"`
+	Name   string                    `json:"name" bson:"name"`
+	ObjID  string                    `json:"bk_obj_id" bson:"bk_obj_id"`
+	Filter *querybuilder.QueryFilter `json:"filter" bson:"-"`
+	// FilterRaw the json of the filter, the rule interface can't be decoded from db directly
+	FilterRaw string            `json:"-" bson:"filter"`
+	GroupBy   []string          `json:"group_by" bson:"group_by"`
+	Metric    CustomChartMetric `json:"metric" bson:"metric"`
+	Bucket    string            `json:"bucket" bson:"bucket"`
+	// Retention the days to keep the points, 0 means keep forever
+	Retention       int64      `json:"retention" bson:"retention"`
+	OwnerID         string     `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator         string     `json:"creator" bson:"creator"`
+	CreateTime      time.Time  `json:"create_time" bson:"create_time"`
+	LastTime        time.Time  `json:"last_time" bson:"last_time"`
+	LastComputeTime *time.Time `json:"last_compute_time,omitempty" bson:"last_compute_time,omitempty"`
+}
+
+// Validate validate the chart definition, the fields are checked with the model attributes by the caller
+func (c *CustomChart) Validate() (field string, err error) {
+	if len(c.Name) == 0 {
+		return "name", errors.New("name can't be empty")
+	}
+	if len(c.ObjID) == 0 {
+		return "bk_obj_id", errors.New("bk_obj_id can't be empty")
+	}
+	if c.Filter != nil {
+		if key, err := c.Filter.Validate(); err != nil {
+			return "filter." + key, err
+		}
+	}
+
+	if len(c.GroupBy) > CustomChartMaxGroupBy {
+		return "group_by", fmt.Errorf("group by %d fields at most", CustomChartMaxGroupBy)
+	}
+	exists := make(map[string]bool)
+	for _, field := range c.GroupBy {
+		if len(field) == 0 || exists[field] {
+			return "group_by", fmt.Errorf("group by field %s empty or duplicated", field)
+		}
+		exists[field] = true
+	}
+
+	switch c.Metric.Type {
+	case CustomChartMetricCount:
+	case CustomChartMetricSum, CustomChartMetricAvg, CustomChartMetricMin, CustomChartMetricMax:
+		if len(c.Metric.Field) == 0 {
+			return "metric.field", fmt.Errorf("metric %s requires the field", c.Metric.Type)
+		}
+	default:
+		return "metric.type", fmt.Errorf("unsupported metric %s", c.Metric.Type)
+	}
+
+	switch c.Bucket {
+	case CustomChartBucketNone, CustomChartBucketHour, CustomChartBucketDay, CustomChartBucketWeek, CustomChartBucketMonth:
+	default:
+		return "bucket", fmt.Errorf("unsupported bucket %s", c.Bucket)
+	}
+	if c.Retention < 0 {
+		return "retention", errors.New("retention can't be negative")
+	}
+	return "", nil
+}
+
+// EncodeFilter save the filter to FilterRaw before the chart is saved
+func (c *CustomChart) EncodeFilter() error {
+	c.FilterRaw = ""
+	if c.Filter == nil || c.Filter.Rule == nil {
+		return nil
+	}
+	raw, err := json.Marshal(c.Filter)
+	if err != nil {
+		return err
+	}
+	c.FilterRaw = string(raw)
+	return nil
+}
+
+// DecodeFilter restore the filter from FilterRaw after the chart is read from db
+func (c *CustomChart) DecodeFilter() error {
+	c.Filter = nil
+	if len(c.FilterRaw) == 0 {
+		return nil
+	}
+	filter := new(querybuilder.QueryFilter)
+	if err := json.Unmarshal([]byte(c.FilterRaw), filter); err != nil {
+		return err
+	}
+	c.Filter = filter
+	return nil
+}
+
+// BucketStart returns the start time of the bucket the time belongs to, the zero time if the chart has no bucket
+func (c *CustomChart) BucketStart(t time.Time) time.Time {
+	year, month, day := t.Date()
+	switch c.Bucket {
+	case CustomChartBucketHour:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location())
+	case CustomChartBucketDay:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	case CustomChartBucketWeek:
+		// the week starts from monday
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+	case CustomChartBucketMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// CustomChartGroup the metric value of a group, the keys are the values of the group by fields,
+// the labels are the display names of the enum values and the business ids in the keys.
+type CustomChartGroup struct {
+	Keys   map[string]interface{} `json:"keys" bson:"keys"`
+	Labels map[string]interface{} `json:"labels" bson:"labels"`
+	Value  float64                `json:"value" bson:"value"`
+}
+
+// CustomChartPoint the result of the custom chart in a time bucket, the result of the current bucket
+// is refreshed by each computing until the bucket is over.
+type CustomChartPoint struct {
+	ChartID  uint64             `json:"chart_id" bson:"chart_id"`
+	Time     time.Time          `json:"time" bson:"time"`
+	Groups   []CustomChartGroup `json:"groups" bson:"groups"`
+	OwnerID  string             `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	LastTime time.Time          `json:"last_time" bson:"last_time"`
+}
+
+// CustomChartSearchOption the option to search the custom charts
+type CustomChartSearchOption struct {
+	ObjID string   `json:"bk_obj_id"`
+	Page  BasePage `json:"page"`
+}
+
+// CustomChartSearchResult the custom charts
+type CustomChartSearchResult struct {
+	Count uint64        `json:"count"`
+	Info  []CustomChart `json:"info"`
+}
+
+// CustomChartDataOption the time range of the points to search, the points of the last 30 buckets are returned by default
+type CustomChartDataOption struct {
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+}
+
+// CustomChartData the custom chart and its points in time order
+type CustomChartData struct {
+	Chart  CustomChart        `json:"chart"`
+	Points []CustomChartPoint `json:"points"`
+}
+
+// CustomChartComputeOption the charts to compute, all the charts of the supplier account are computed if it's empty,
+// the super owner computes the charts of all the supplier accounts.
+type CustomChartComputeOption struct {
+	IDs []uint64 `json:"ids"`
+}
+
+// CustomChartComputeResult the charts computed
+type CustomChartComputeResult struct {
+	Computed []uint64 `json:"computed"`
+	Failed   []uint64 `json:"failed"`
+}
+
+type CustomChartResponse struct {
+	BaseResp `json:",inline"`
+	Data     CustomChart `json:"data"`
+}
+
+type SearchCustomChartResponse struct {
+	BaseResp `json:",inline"`
+	Data     CustomChartSearchResult `json:"data"`
+}
+
+type CustomChartDataResponse struct {
+	BaseResp `json:",inline"`
+	Data     CustomChartData `json:"data"`
+}
+
+type CustomChartComputeResponse struct {
+	BaseResp `json:",inline"`
+	Data     CustomChartComputeResult `json:"data"`
+}
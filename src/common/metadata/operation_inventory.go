@@ -0,0 +1,50 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+// InventoryStatisticsOption the option to statistic the inventory, the groups of each dimension
+// are sorted by the count and only the top MaxGroups are returned to limit the label cardinality.
+type InventoryStatisticsOption struct {
+	MaxGroups int `json:"max_groups"`
+}
+
+// InventoryGroupCount the count of a group, the id and the name are exported as the labels
+type InventoryGroupCount struct {
+	ID    string `json:"id" bson:"_id"`
+	Name  string `json:"name" bson:"name"`
+	Count int64  `json:"count" bson:"count"`
+}
+
+// InventoryDimension the counts of the groups of a dimension, the total is the count of all
+// the groups, so the count of the groups not returned is the total minus the returned groups.
+type InventoryDimension struct {
+	Total  int64                 `json:"total"`
+	Groups []InventoryGroupCount `json:"groups"`
+}
+
+// InventoryStatistics the statistics of the inventory of cmdb
+type InventoryStatistics struct {
+	Hosts                int64              `json:"hosts"`
+	UnassignedHosts      int64              `json:"unassigned_hosts"`
+	HostsWithoutSnapshot int64              `json:"hosts_without_snapshot"`
+	HostsByBiz           InventoryDimension `json:"hosts_by_biz"`
+	HostsByCloud         InventoryDimension `json:"hosts_by_cloud"`
+	HostsByModule        InventoryDimension `json:"hosts_by_module"`
+	InstancesByModel     InventoryDimension `json:"instances_by_model"`
+	ProcessesByBiz       InventoryDimension `json:"processes_by_biz"`
+}
+
+type InventoryStatisticsResponse struct {
+	BaseResp `json:",inline"`
+	Data     InventoryStatistics `json:"data"`
+}
@@ -14,6 +14,7 @@ package metadata
 import (
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -124,12 +125,16 @@ type DiffModuleWithTemplateOption struct {
 	Metadata  *Metadata `json:"metadata"`
 	BizID     int64     `json:"bk_biz_id"`
 	ModuleIDs []int64   `json:"bk_module_ids"`
+	// Version diff modules with this service template version, 0 means the version the module pinned,
+	// or the latest definition if not pinned.
+	Version int64 `json:"version"`
 }
 
 type DiffOneModuleWithTemplateOption struct {
 	Metadata *Metadata `json:"metadata"`
 	BizID    int64     `json:"bk_biz_id"`
 	ModuleID int64     `json:"bk_module_id"`
+	Version  int64     `json:"version"`
 }
 
 type DeleteServiceInstanceOption struct {
@@ -252,6 +257,9 @@ type SyncServiceInstanceByTemplateOption struct {
 	Metadata  *Metadata `json:"metadata"`
 	BizID     int64     `json:"bk_biz_id"`
 	ModuleIDs []int64   `json:"bk_module_ids"`
+	// Version sync modules to this service template version and pin them to it,
+	// 0 means sync to the version the module pinned, or the latest definition if not pinned.
+	Version int64 `json:"version"`
 }
 
 // 用于同步单个模块的服务实例
@@ -259,6 +267,7 @@ type SyncModuleServiceInstanceByTemplateOption struct {
 	Metadata *Metadata `json:"metadata"`
 	BizID    int64     `json:"bk_biz_id"`
 	ModuleID int64     `json:"bk_module_id"`
+	Version  int64     `json:"version"`
 }
 
 type ListServiceInstancesWithHostInput struct {
@@ -332,6 +341,12 @@ func (p *SocketBindType) String() string {
 	}
 }
 
+// IsSocketBindType check whether the value is a SocketBindType, including the ones depend on the host
+func IsSocketBindType(value string) bool {
+	validValues := []SocketBindType{BindLocalHost, BindAll, BindInnerIP, BindOtterIP}
+	return util.InArray(SocketBindType(value), validValues)
+}
+
 func (p SocketBindType) Validate() error {
 	// validValues := []SocketBindType{BindLocalHost, BindAll, BindInnerIP, BindOtterIP}
 	validValues := []SocketBindType{BindLocalHost, BindAll}
@@ -367,6 +382,155 @@ func (p ProtocolType) Validate() error {
 	return nil
 }
 
+// ProcBindInfo is one of the ip, port and protocol that a process listens on
+type ProcBindInfo struct {
+	// IP is an ipv4 or ipv6 address, or a SocketBindType like "3" which means the first inner ip of the host
+	IP       *string       `field:"ip" json:"ip" bson:"ip" structs:"ip" mapstructure:"ip"`
+	Port     *string       `field:"port" json:"port" bson:"port" structs:"port" mapstructure:"port"`
+	Protocol *ProtocolType `field:"protocol" json:"protocol" bson:"protocol" structs:"protocol" mapstructure:"protocol"`
+	// Enable a disabled bind info is kept but the process doesn't listen on it, nil means enabled
+	Enable *bool `field:"enable" json:"enable" bson:"enable" structs:"enable" mapstructure:"enable"`
+}
+
+func (b ProcBindInfo) Validate() (field string, err error) {
+	if b.IP == nil || len(*b.IP) == 0 {
+		return "ip", fmt.Errorf("field [%s] is required", "ip")
+	}
+	if IsSocketBindType(*b.IP) == false && net.ParseIP(*b.IP) == nil {
+		return "ip", fmt.Errorf("invalid bind ip, value: %s", *b.IP)
+	}
+	if b.Port == nil || len(*b.Port) == 0 {
+		return "port", fmt.Errorf("field [%s] is required", "port")
+	}
+	if matched := ProcessPortFormat.MatchString(*b.Port); matched == false {
+		return "port", fmt.Errorf("port format invalid")
+	}
+	if b.Protocol != nil && len(*b.Protocol) != 0 {
+		if err := b.Protocol.Validate(); err != nil {
+			return "protocol", err
+		}
+	}
+	return "", nil
+}
+
+func (b ProcBindInfo) IsEnabled() bool {
+	return b.Enable == nil || *b.Enable == true
+}
+
+// BindIP returns the ip the bind info listens on, the ip selectors that depends on the host are resolved with
+// the host's first inner or outer ip, they are returned as it is if the host doesn't have such an ip.
+func (b ProcBindInfo) BindIP(innerIP, outerIP string) string {
+	if b.IP == nil {
+		return ""
+	}
+	bindType := SocketBindType(*b.IP)
+	switch bindType {
+	case BindInnerIP:
+		if ip := firstHostIP(innerIP); ip != "" {
+			return ip
+		}
+	case BindOtterIP:
+		if ip := firstHostIP(outerIP); ip != "" {
+			return ip
+		}
+	default:
+		if ip := bindType.IP(); ip != "" {
+			return ip
+		}
+	}
+	return *b.IP
+}
+
+// firstHostIP returns the first ip of the host ip field, which may contain several ips separated by comma
+func firstHostIP(ips string) string {
+	return strings.TrimSpace(strings.Split(ips, ",")[0])
+}
+
+func (b ProcBindInfo) Equal(other ProcBindInfo) bool {
+	if (b.IP == nil) != (other.IP == nil) || (b.IP != nil && *b.IP != *other.IP) {
+		return false
+	}
+	if (b.Port == nil) != (other.Port == nil) || (b.Port != nil && *b.Port != *other.Port) {
+		return false
+	}
+	if (b.Protocol == nil) != (other.Protocol == nil) || (b.Protocol != nil && *b.Protocol != *other.Protocol) {
+		return false
+	}
+	return b.IsEnabled() == other.IsEnabled()
+}
+
+// ProcBindInfosEqual compare two bind info list in order, nil equals to an empty list
+func ProcBindInfosEqual(a, b []ProcBindInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx].Equal(b[idx]) == false {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncLegacyBindFields derive the legacy bind_ip, port and protocol of the process from the first enabled entry of
+// the bind info, the bind info is the only source of truth once it is set, so the legacy fields are overwritten on
+// each write. the derived fields are returned to be written along with the bind info, nil if bind info is not set.
+func (p *Process) SyncLegacyBindFields(innerIP, outerIP string) map[string]interface{} {
+	if len(p.BindInfo) == 0 {
+		return nil
+	}
+
+	p.BindIP, p.Port, p.Protocol = nil, nil, nil
+	for _, item := range p.BindInfo {
+		if item.IsEnabled() == false {
+			continue
+		}
+		// the legacy bind ip is an address, selectors that can not be resolved are left empty
+		if ip := item.BindIP(innerIP, outerIP); ip != "" && IsSocketBindType(ip) == false {
+			p.BindIP = &ip
+		}
+		p.Port = item.Port
+		p.Protocol = item.Protocol
+		break
+	}
+
+	fields := map[string]interface{}{
+		common.BKBindIP:   nil,
+		common.BKPort:     nil,
+		common.BKProtocol: nil,
+	}
+	if p.BindIP != nil {
+		fields[common.BKBindIP] = *p.BindIP
+	}
+	if p.Port != nil {
+		fields[common.BKPort] = *p.Port
+	}
+	if p.Protocol != nil {
+		fields[common.BKProtocol] = *p.Protocol
+	}
+	return fields
+}
+
+// NewProcBindInfo convert the legacy bind_ip, port and protocol of a process to a bind info,
+// ok is false if the process doesn't listen on any port
+func NewProcBindInfo(bindIP *string, port *string, protocol *ProtocolType) (info ProcBindInfo, ok bool) {
+	if port == nil || len(*port) == 0 {
+		return info, false
+	}
+	ip := BindAllIP
+	if bindIP != nil && len(*bindIP) != 0 {
+		ip = *bindIP
+	}
+	info = ProcBindInfo{
+		IP:   &ip,
+		Port: port,
+	}
+	if protocol != nil && len(*protocol) != 0 {
+		info.Protocol = protocol
+	}
+	return info, true
+}
+
 type Process struct {
 	ProcNum         *int64        `field:"proc_num" json:"proc_num" bson:"proc_num" structs:"proc_num" mapstructure:"proc_num"`
 	StopCmd         *string       `field:"stop_cmd" json:"stop_cmd" bson:"stop_cmd" structs:"stop_cmd" mapstructure:"stop_cmd"`
@@ -394,6 +558,9 @@ type Process struct {
 	Description     *string       `field:"description" json:"description" bson:"description" structs:"description" mapstructure:"description"`
 	SupplierAccount string        `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account" structs:"bk_supplier_account" mapstructure:"bk_supplier_account"`
 	StartParamRegex *string       `field:"bk_start_param_regex" json:"bk_start_param_regex" bson:"bk_start_param_regex" structs:"bk_start_param_regex" mapstructure:"bk_start_param_regex"`
+
+	// BindInfo all the ip, port and protocol the process listens on
+	BindInfo []ProcBindInfo `field:"bind_info" json:"bind_info" bson:"bind_info" structs:"bind_info" mapstructure:"bind_info"`
 }
 
 type ServiceCategory struct {
@@ -448,6 +615,14 @@ type ServiceTemplate struct {
 	// now, the class must have two labels.
 	ServiceCategoryID int64 `field:"service_category_id" json:"service_category_id" bson:"service_category_id"`
 
+	// the attribute values of the modules created by this template.
+	Attributes []TemplateAttribute `field:"attributes" json:"attributes" bson:"attributes"`
+
+	// UpstreamTemplateID the global service template this template is created from, 0 if not created from one.
+	UpstreamTemplateID int64 `field:"upstream_template_id" json:"upstream_template_id" bson:"upstream_template_id"`
+	// ShareMode how this template follows it's upstream template, empty if it has no upstream template.
+	ShareMode ServiceTemplateShareMode `field:"share_mode" json:"share_mode" bson:"share_mode"`
+
 	Creator         string    `field:"creator" json:"creator" bson:"creator"`
 	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier"`
 	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time"`
@@ -463,7 +638,7 @@ func (st *ServiceTemplate) Validate() (field string, err error) {
 	if len(st.Name) > common.NameFieldMaxLength {
 		return "name", fmt.Errorf("name too long, input: %d > max: %d", len(st.Name), common.NameFieldMaxLength)
 	}
-	return "", nil
+	return ValidateTemplateAttributes(st.Attributes, ServiceTemplateReservedFields)
 }
 
 // this works for the process instance which is used for a template.
@@ -478,6 +653,12 @@ type ProcessTemplate struct {
 	// properties's value.
 	Property *ProcessProperty `field:"property" json:"property" bson:"property"`
 
+	// UpstreamTemplateID the global process template this template is copied from, 0 if not copied from one.
+	UpstreamTemplateID int64 `field:"upstream_template_id" json:"upstream_template_id" bson:"upstream_template_id"`
+	// UpstreamProperty the property of the global process template when it's copied or accepted last time,
+	// it's the base of the three-way diff between this template and the global one.
+	UpstreamProperty *ProcessProperty `field:"upstream_property" json:"upstream_property,omitempty" bson:"upstream_property,omitempty"`
+
 	Creator         string    `field:"creator" json:"creator" bson:"creator"`
 	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier"`
 	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time"`
@@ -621,6 +802,12 @@ func (pt *ProcessTemplate) NewProcess(bizID int64, supplierAccount string) *Proc
 		processInstance.StartParamRegex = property.StartParamRegex.Value
 	}
 
+	processInstance.BindInfo = nil
+	if IsAsDefaultValue(property.BindInfo.AsDefaultValue) {
+		processInstance.BindInfo = property.BindInfo.Value
+		processInstance.SyncLegacyBindFields("", "")
+	}
+
 	return processInstance
 }
 
@@ -659,6 +846,7 @@ func GetAllProcessPropertyFields() []string {
 	fields = append(fields, "bind_ip")
 	fields = append(fields, "priority")
 	fields = append(fields, "start_cmd")
+	fields = append(fields, "bind_info")
 	return fields
 }
 
@@ -749,7 +937,8 @@ func (pt *ProcessTemplate) ExtractChangeInfo(i *Process) (mapstr.MapStr, bool) {
 		}
 	}
 
-	if IsAsDefaultValue(t.BindIP.AsDefaultValue) {
+	// the legacy bind fields are derived from the bind info if it is set, which is compared instead
+	if IsAsDefaultValue(t.BindIP.AsDefaultValue) && len(t.BindInfo.Value) == 0 {
 		if t.BindIP.Value == nil && i.BindIP != nil {
 			process["bind_ip"] = nil
 			changed = true
@@ -801,7 +990,7 @@ func (pt *ProcessTemplate) ExtractChangeInfo(i *Process) (mapstr.MapStr, bool) {
 		}
 	}
 
-	if IsAsDefaultValue(t.Port.AsDefaultValue) {
+	if IsAsDefaultValue(t.Port.AsDefaultValue) && len(t.BindInfo.Value) == 0 {
 		if t.Port.Value == nil && i.Port != nil {
 			process["port"] = nil
 			changed = true
@@ -905,7 +1094,7 @@ func (pt *ProcessTemplate) ExtractChangeInfo(i *Process) (mapstr.MapStr, bool) {
 		}
 	}
 
-	if IsAsDefaultValue(t.Protocol.AsDefaultValue) {
+	if IsAsDefaultValue(t.Protocol.AsDefaultValue) && len(t.BindInfo.Value) == 0 {
 		if t.Protocol.Value == nil && i.Protocol != nil {
 			process["protocol"] = nil
 			changed = true
@@ -944,6 +1133,13 @@ func (pt *ProcessTemplate) ExtractChangeInfo(i *Process) (mapstr.MapStr, bool) {
 		}
 	}
 
+	if IsAsDefaultValue(t.BindInfo.AsDefaultValue) {
+		if ProcBindInfosEqual(t.BindInfo.Value, i.BindInfo) == false {
+			process["bind_info"] = t.BindInfo.Value
+			changed = true
+		}
+	}
+
 	return process, changed
 }
 
@@ -1025,6 +1221,9 @@ func (pt *ProcessTemplate) ExtractEditableFields() []string {
 	if IsAsDefaultValue(property.StartCmd.AsDefaultValue) == false {
 		editableFields = append(editableFields, "start_cmd")
 	}
+	if IsAsDefaultValue(property.BindInfo.AsDefaultValue) == false {
+		editableFields = append(editableFields, "bind_info")
+	}
 	return editableFields
 }
 
@@ -1137,6 +1336,11 @@ func (pt *ProcessTemplate) ExtractInstanceUpdateData(input *Process) map[string]
 			data["start_cmd"] = *input.StartCmd
 		}
 	}
+	if IsAsDefaultValue(property.BindInfo.AsDefaultValue) == false {
+		if input.BindInfo != nil {
+			data["bind_info"] = input.BindInfo
+		}
+	}
 	return data
 }
 
@@ -1162,6 +1366,32 @@ type ProcessProperty struct {
 	Protocol           PropertyProtocol `field:"protocol" json:"protocol" bson:"protocol"`
 	Description        PropertyString   `field:"description" json:"description" bson:"description"`
 	StartParamRegex    PropertyString   `field:"bk_start_param_regex" json:"bk_start_param_regex" bson:"bk_start_param_regex"`
+	BindInfo           PropertyBindInfo `field:"bind_info" json:"bind_info" bson:"bind_info"`
+}
+
+// SyncLegacyBindFields derive the legacy bind_ip, port and protocol of the template from the first enabled entry of
+// the bind info, the legacy bind ip of a template is a SocketBindType, so it's left empty for an address.
+func (pt *ProcessProperty) SyncLegacyBindFields() {
+	if len(pt.BindInfo.Value) == 0 {
+		return
+	}
+
+	pt.BindIP.Value, pt.Port.Value, pt.Protocol.Value = nil, nil, nil
+	for _, item := range pt.BindInfo.Value {
+		if item.IsEnabled() == false {
+			continue
+		}
+		if item.IP != nil && SocketBindType(*item.IP).Validate() == nil {
+			bindType := SocketBindType(*item.IP)
+			pt.BindIP.Value = &bindType
+		}
+		pt.Port.Value = item.Port
+		pt.Protocol.Value = item.Protocol
+		break
+	}
+	pt.BindIP.AsDefaultValue = pt.BindInfo.AsDefaultValue
+	pt.Port.AsDefaultValue = pt.BindInfo.AsDefaultValue
+	pt.Protocol.AsDefaultValue = pt.BindInfo.AsDefaultValue
 }
 
 func (pt *ProcessProperty) Validate() (field string, err error) {
@@ -1249,6 +1479,11 @@ func (pt *ProcessProperty) Update(input ProcessProperty, rawProperty map[string]
 				selfFieldValuePtr.Set(inputFieldPtr)
 				continue
 			}
+			// value of list type is replaced as a whole
+			if inputFieldPtr.Kind() != reflect.Ptr {
+				selfFieldValuePtr.Set(inputFieldPtr)
+				continue
+			}
 			inputFieldValue := inputFieldPtr.Elem()
 
 			if selfFieldValuePtr.Kind() == reflect.Ptr {
@@ -1395,6 +1630,20 @@ func (ti *PropertyProtocol) Validate() error {
 	return nil
 }
 
+type PropertyBindInfo struct {
+	Value          []ProcBindInfo `field:"value" json:"value" bson:"value"`
+	AsDefaultValue *bool          `field:"as_default_value" json:"as_default_value" bson:"as_default_value"`
+}
+
+func (ti *PropertyBindInfo) Validate() error {
+	for idx, item := range ti.Value {
+		if field, err := item.Validate(); err != nil {
+			return fmt.Errorf("bind info [%d] field %s invalid, err: %v", idx, field, err)
+		}
+	}
+	return nil
+}
+
 // ServiceInstance is a service, which created when a host binding with a service template.
 type ServiceInstance struct {
 	BizID  int64           `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
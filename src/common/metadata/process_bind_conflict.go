@@ -0,0 +1,235 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ProcessBindConflictMode how to deal with a process which binds the same ip, port and protocol with another
+// process on the same host when it's created or updated.
+type ProcessBindConflictMode string
+
+const (
+	// BindConflictModeStrict refuse the process
+	BindConflictModeStrict ProcessBindConflictMode = "strict"
+	// BindConflictModeWarn accept the process and log a warning, the conflicts can be found by the report api
+	BindConflictModeWarn ProcessBindConflictMode = "warn"
+	// BindConflictModeOff do not check the conflicts at all
+	BindConflictModeOff ProcessBindConflictMode = "off"
+)
+
+func (m ProcessBindConflictMode) Validate() error {
+	if m != BindConflictModeStrict && m != BindConflictModeWarn && m != BindConflictModeOff {
+		return fmt.Errorf("bind conflict mode should be one of %s, %s and %s, got: %s", BindConflictModeStrict, BindConflictModeWarn, BindConflictModeOff, m)
+	}
+	return nil
+}
+
+// BindAllIP is the wildcard address which conflicts with any other ip
+const BindAllIP = "0.0.0.0"
+
+// isWildcardBindIP check whether the bind ip listens on all the addresses, like 0.0.0.0 and ::,
+// an empty ip is treated as wildcard too.
+func isWildcardBindIP(ip string) bool {
+	if ip == "" {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.IsUnspecified()
+}
+
+// sameBindIP compare two bind ips, addresses are compared after parsed, so that
+// different notations of an ipv6 address are the same, selectors are compared literally.
+func sameBindIP(ip, other string) bool {
+	parsed, otherParsed := net.ParseIP(ip), net.ParseIP(other)
+	if parsed != nil && otherParsed != nil {
+		return parsed.Equal(otherParsed)
+	}
+	return ip == other
+}
+
+// BindHost is the host that a process runs on, the host dependent ip selectors in bind info are resolved with its ips
+type BindHost struct {
+	HostID  int64
+	InnerIP string
+	OuterIP string
+}
+
+// PortRange is a closed interval of ports, a single port has the same Start and End
+type PortRange struct {
+	Start int64
+	End   int64
+}
+
+// ParseProcessPort parse process port like "80,8000-8010" into port ranges
+func ParseProcessPort(port string) ([]PortRange, error) {
+	ranges := make([]PortRange, 0)
+	if len(port) == 0 {
+		return ranges, nil
+	}
+	if ProcessPortFormat.MatchString(port) == false {
+		return nil, fmt.Errorf("port format invalid: %s", port)
+	}
+	for _, item := range strings.Split(port, ",") {
+		bounds := strings.SplitN(item, "-", 2)
+		start, err := strconv.ParseInt(bounds[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("port format invalid: %s, err: %v", port, err)
+		}
+		end := start
+		if len(bounds) == 2 {
+			end, err = strconv.ParseInt(bounds[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("port format invalid: %s, err: %v", port, err)
+			}
+		}
+		if start > end {
+			start, end = end, start
+		}
+		ranges = append(ranges, PortRange{Start: start, End: end})
+	}
+	return ranges, nil
+}
+
+// ProcessBindInfo is the ip, port and protocol a process listens on
+type ProcessBindInfo struct {
+	ProcessID         int64        `json:"bk_process_id"`
+	ProcessName       string       `json:"bk_process_name"`
+	BizID             int64        `json:"bk_biz_id"`
+	HostID            int64        `json:"bk_host_id"`
+	ServiceInstanceID int64        `json:"service_instance_id"`
+	BindIP            string       `json:"bind_ip"`
+	Port              string       `json:"port"`
+	Protocol          ProtocolType `json:"protocol"`
+}
+
+// NewProcessBindInfos extract the bind infos of a process, the enabled entries of the bind info list are used if it is set,
+// otherwise the legacy bind_ip, port and protocol are used, processes don't listen on any port have no bind info.
+func NewProcessBindInfos(process *Process, host BindHost, serviceInstanceID int64) []ProcessBindInfo {
+	infos := make([]ProcessBindInfo, 0)
+	if process == nil {
+		return infos
+	}
+
+	bindInfos := process.BindInfo
+	if len(bindInfos) == 0 {
+		if legacy, ok := NewProcBindInfo(process.BindIP, process.Port, process.Protocol); ok == true {
+			bindInfos = []ProcBindInfo{legacy}
+		}
+	}
+
+	for _, item := range bindInfos {
+		if item.IsEnabled() == false || item.Port == nil || len(*item.Port) == 0 {
+			continue
+		}
+		info := ProcessBindInfo{
+			ProcessID:         process.ProcessID,
+			BizID:             process.BusinessID,
+			HostID:            host.HostID,
+			ServiceInstanceID: serviceInstanceID,
+			BindIP:            item.BindIP(host.InnerIP, host.OuterIP),
+			Port:              *item.Port,
+		}
+		if process.ProcessName != nil {
+			info.ProcessName = *process.ProcessName
+		}
+		if item.Protocol != nil {
+			info.Protocol = *item.Protocol
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// ConflictWith check whether the two processes on the same host listen on the same ip, port and protocol,
+// an empty or wildcard ip, 0.0.0.0 or ::, conflicts with any ip, and an empty protocol conflicts with any protocol.
+func (b ProcessBindInfo) ConflictWith(other ProcessBindInfo) bool {
+	if b.HostID != other.HostID || b.ProcessID == other.ProcessID {
+		return false
+	}
+	if b.Protocol != "" && other.Protocol != "" && b.Protocol != other.Protocol {
+		return false
+	}
+	if isWildcardBindIP(b.BindIP) == false && isWildcardBindIP(other.BindIP) == false && sameBindIP(b.BindIP, other.BindIP) == false {
+		return false
+	}
+
+	ranges, err := ParseProcessPort(b.Port)
+	if err != nil {
+		return false
+	}
+	otherRanges, err := ParseProcessPort(other.Port)
+	if err != nil {
+		return false
+	}
+	for _, r := range ranges {
+		for _, o := range otherRanges {
+			if r.Start <= o.End && o.Start <= r.End {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Redact returns the bind info with only the business id and port, which is reported to the users of
+// another business, the details of the process are hidden from them.
+func (b ProcessBindInfo) Redact() ProcessBindInfo {
+	return ProcessBindInfo{BizID: b.BizID, Port: b.Port}
+}
+
+// ProcessBindConflict two processes on the same host bind the same ip, port and protocol
+type ProcessBindConflict struct {
+	HostID    int64             `json:"bk_host_id"`
+	Processes []ProcessBindInfo `json:"processes"`
+}
+
+// FindProcessBindConflicts find all the conflicted pairs in the bind infos
+func FindProcessBindConflicts(infos []ProcessBindInfo) []ProcessBindConflict {
+	hostInfos := make(map[int64][]ProcessBindInfo)
+	hostIDs := make([]int64, 0)
+	for _, info := range infos {
+		if _, exist := hostInfos[info.HostID]; exist == false {
+			hostIDs = append(hostIDs, info.HostID)
+		}
+		hostInfos[info.HostID] = append(hostInfos[info.HostID], info)
+	}
+
+	conflicts := make([]ProcessBindConflict, 0)
+	for _, hostID := range hostIDs {
+		items := hostInfos[hostID]
+		for i := 0; i < len(items); i++ {
+			for j := i + 1; j < len(items); j++ {
+				if items[i].ConflictWith(items[j]) {
+					conflicts = append(conflicts, ProcessBindConflict{
+						HostID:    hostID,
+						Processes: []ProcessBindInfo{items[i], items[j]},
+					})
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// ListProcessBindConflictOption list bind conflicts of the processes in a business, the conflicted process
+// may belong to other businesses, hosts of the business are all checked if HostIDs is empty.
+type ListProcessBindConflictOption struct {
+	Metadata *Metadata `json:"metadata"`
+	BizID    int64     `json:"bk_biz_id"`
+	HostIDs  []int64   `json:"bk_host_ids"`
+}
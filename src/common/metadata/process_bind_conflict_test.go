@@ -0,0 +1,135 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProcessPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    string
+		want    []PortRange
+		wantErr bool
+	}{
+		{"empty", "", []PortRange{}, false},
+		{"single", "80", []PortRange{{Start: 80, End: 80}}, false},
+		{"range", "8000-8010", []PortRange{{Start: 8000, End: 8010}}, false},
+		{"reversed range", "8010-8000", []PortRange{{Start: 8000, End: 8010}}, false},
+		{"mixed", "80,8000-8010,443", []PortRange{{Start: 80, End: 80}, {Start: 8000, End: 8010}, {Start: 443, End: 443}}, false},
+		{"max port", "65535", []PortRange{{Start: 65535, End: 65535}}, false},
+		{"zero port", "0", nil, true},
+		{"out of range", "65536", nil, true},
+		{"open range", "80-", nil, true},
+		{"trailing comma", "80,", nil, true},
+		{"not number", "http", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProcessPort(tt.port)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProcessPort(%q) error = %v, wantErr %v", tt.port, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseProcessPort(%q) = %v, want %v", tt.port, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessBindInfoConflictWith(t *testing.T) {
+	base := ProcessBindInfo{ProcessID: 1, HostID: 1, BindIP: "127.0.0.1", Port: "80", Protocol: ProtocolTypeTCP}
+	with := func(modify func(info *ProcessBindInfo)) ProcessBindInfo {
+		info := base
+		info.ProcessID = 2
+		modify(&info)
+		return info
+	}
+	tests := []struct {
+		name  string
+		info  ProcessBindInfo
+		other ProcessBindInfo
+		want  bool
+	}{
+		{"same bind", base, with(func(info *ProcessBindInfo) {}), true},
+		{"same process", base, base, false},
+		{"different host", base, with(func(info *ProcessBindInfo) { info.HostID = 2 }), false},
+		{"different protocol", base, with(func(info *ProcessBindInfo) { info.Protocol = ProtocolTypeUDP }), false},
+		{"empty protocol", base, with(func(info *ProcessBindInfo) { info.Protocol = "" }), true},
+		{"different ip", base, with(func(info *ProcessBindInfo) { info.BindIP = "127.0.0.2" }), false},
+		{"empty ip", base, with(func(info *ProcessBindInfo) { info.BindIP = "" }), true},
+		{"ipv4 wildcard", base, with(func(info *ProcessBindInfo) { info.BindIP = BindAllIP }), true},
+		{"ipv6 wildcard", base, with(func(info *ProcessBindInfo) { info.BindIP = "::" }), true},
+		{"ipv6 notations",
+			with(func(info *ProcessBindInfo) { info.ProcessID = 1; info.BindIP = "fe80::1" }),
+			with(func(info *ProcessBindInfo) { info.BindIP = "fe80:0:0:0:0:0:0:1" }), true},
+		{"port in range", base, with(func(info *ProcessBindInfo) { info.Port = "70-90" }), true},
+		{"range overlapped",
+			with(func(info *ProcessBindInfo) { info.ProcessID = 1; info.Port = "8000-8010" }),
+			with(func(info *ProcessBindInfo) { info.Port = "443,8010-8020" }), true},
+		{"port not overlapped", base, with(func(info *ProcessBindInfo) { info.Port = "81-90" }), false},
+		{"invalid port", base, with(func(info *ProcessBindInfo) { info.Port = "http" }), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.ConflictWith(tt.other); got != tt.want {
+				t.Errorf("%+v ConflictWith %+v = %v, want %v", tt.info, tt.other, got, tt.want)
+			}
+			if got := tt.other.ConflictWith(tt.info); got != tt.want {
+				t.Errorf("%+v ConflictWith %+v = %v, want %v", tt.other, tt.info, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindProcessBindConflicts(t *testing.T) {
+	web := ProcessBindInfo{ProcessID: 1, HostID: 1, BindIP: "127.0.0.1", Port: "80", Protocol: ProtocolTypeTCP}
+	proxy := ProcessBindInfo{ProcessID: 2, HostID: 1, BindIP: BindAllIP, Port: "80,443", Protocol: ProtocolTypeTCP}
+	dns := ProcessBindInfo{ProcessID: 3, HostID: 1, BindIP: BindAllIP, Port: "53", Protocol: ProtocolTypeUDP}
+	otherWeb := ProcessBindInfo{ProcessID: 4, HostID: 2, BindIP: "127.0.0.1", Port: "80", Protocol: ProtocolTypeTCP}
+	otherProxy := ProcessBindInfo{ProcessID: 5, HostID: 2, BindIP: "", Port: "443,80", Protocol: ""}
+
+	tests := []struct {
+		name  string
+		infos []ProcessBindInfo
+		want  []ProcessBindConflict
+	}{
+		{"empty", nil, []ProcessBindConflict{}},
+		{"no conflict", []ProcessBindInfo{web, dns, otherWeb}, []ProcessBindConflict{}},
+		{"conflicts on hosts",
+			[]ProcessBindInfo{web, otherWeb, proxy, dns, otherProxy},
+			[]ProcessBindConflict{
+				{HostID: 1, Processes: []ProcessBindInfo{web, proxy}},
+				{HostID: 2, Processes: []ProcessBindInfo{otherWeb, otherProxy}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindProcessBindConflicts(tt.infos); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindProcessBindConflicts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessBindInfoRedact(t *testing.T) {
+	info := ProcessBindInfo{ProcessID: 1, ProcessName: "nginx", BizID: 2, HostID: 3, ServiceInstanceID: 4,
+		BindIP: "127.0.0.1", Port: "80", Protocol: ProtocolTypeTCP}
+	want := ProcessBindInfo{BizID: 2, Port: "80"}
+	if got := info.Redact(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Redact() = %+v, want %+v", got, want)
+	}
+}
@@ -19,10 +19,11 @@ type CreateServiceCategoryOption struct {
 }
 
 type CreateServiceTemplateOption struct {
-	Metadata          *Metadata `field:"metadata" json:"metadata" bson:"metadata"`
-	BizID             int64     `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
-	Name              string    `field:"name" json:"name,omitempty" bson:"name"`
-	ServiceCategoryID int64     `field:"service_category_id" json:"service_category_id,omitempty" bson:"service_category_id"`
+	Metadata          *Metadata           `field:"metadata" json:"metadata" bson:"metadata"`
+	BizID             int64               `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
+	Name              string              `field:"name" json:"name,omitempty" bson:"name"`
+	ServiceCategoryID int64               `field:"service_category_id" json:"service_category_id,omitempty" bson:"service_category_id"`
+	Attributes        []TemplateAttribute `field:"attributes" json:"attributes,omitempty" bson:"attributes"`
 }
 
 type UpdateServiceTemplateOption struct {
@@ -31,6 +32,8 @@ type UpdateServiceTemplateOption struct {
 	ID                int64     `field:"id" json:"id,omitempty" bson:"id"`
 	Name              string    `field:"name" json:"name,omitempty" bson:"name"`
 	ServiceCategoryID int64     `field:"service_category_id" json:"service_category_id,omitempty" bson:"service_category_id"`
+	// Attributes nil means keep the template attributes unchanged
+	Attributes []TemplateAttribute `field:"attributes" json:"attributes" bson:"attributes"`
 }
 
 type RemoveFromModuleHost struct {
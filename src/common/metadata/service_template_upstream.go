@@ -0,0 +1,88 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"fmt"
+)
+
+// ServiceTemplateShareMode how a business service template follows the global service template it's created from
+type ServiceTemplateShareMode string
+
+const (
+	// ShareModeReference the process templates can only be changed by accepting changes of the upstream template
+	ShareModeReference ServiceTemplateShareMode = "reference"
+	// ShareModeFork the process templates can be changed freely, changes of the upstream template are optional
+	ShareModeFork ServiceTemplateShareMode = "fork"
+)
+
+func (sm ServiceTemplateShareMode) Validate() error {
+	if sm != ShareModeReference && sm != ShareModeFork {
+		return fmt.Errorf("share mode should be %s or %s, got: %s", ShareModeReference, ShareModeFork, sm)
+	}
+	return nil
+}
+
+// CreateServiceTemplateFromGlobalOption create a business service template from a global service template
+type CreateServiceTemplateFromGlobalOption struct {
+	Metadata           *Metadata                `json:"metadata"`
+	BizID              int64                    `json:"bk_biz_id"`
+	UpstreamTemplateID int64                    `json:"upstream_template_id"`
+	ShareMode          ServiceTemplateShareMode `json:"share_mode"`
+	// Name name of the new template, use the name of the upstream template if empty
+	Name string `json:"name"`
+}
+
+type DiffServiceTemplateWithUpstreamOption struct {
+	Metadata          *Metadata `json:"metadata"`
+	BizID             int64     `json:"bk_biz_id"`
+	ServiceTemplateID int64     `json:"service_template_id"`
+}
+
+// ProcessTemplateUpstreamDifference changes of a global process template compared to the process template copied from it
+type ProcessTemplateUpstreamDifference struct {
+	ProcessTemplateID         int64                     `json:"process_template_id"`
+	UpstreamProcessTemplateID int64                     `json:"upstream_process_template_id"`
+	ProcessName               string                    `json:"bk_process_name"`
+	ChangedAttributes         []ProcessChangedAttribute `json:"changed_attributes"`
+}
+
+// ServiceTemplateUpstreamDifference changes of the global service template that a business service template not accepted yet
+type ServiceTemplateUpstreamDifference struct {
+	ServiceTemplateID  int64 `json:"service_template_id"`
+	UpstreamTemplateID int64 `json:"upstream_template_id"`
+	// Added process templates of the upstream template which are not copied yet
+	Added []ProcessTemplate `json:"added"`
+	// Changed process templates whose property are different with the upstream process template
+	Changed []ProcessTemplateUpstreamDifference `json:"changed"`
+	// Removed process templates whose upstream process template are removed
+	Removed []ProcessTemplate `json:"removed"`
+	// ChangedAttributes service template's attributes differ from the upstream template
+	ChangedAttributes []ModuleChangedAttribute `json:"changed_attributes"`
+	HasDifference     bool                     `json:"has_difference"`
+}
+
+// AcceptServiceTemplateUpstreamOption apply the changes of the upstream template to a business service template,
+// the changes are identified by the upstream process template id, all changes are accepted if empty.
+type AcceptServiceTemplateUpstreamOption struct {
+	Metadata                   *Metadata `json:"metadata"`
+	BizID                      int64     `json:"bk_biz_id"`
+	ServiceTemplateID          int64     `json:"service_template_id"`
+	UpstreamProcessTemplateIDs []int64   `json:"upstream_process_template_ids"`
+}
+
+// AcceptProcessTemplateUpstreamOption apply the upstream changes to a process template copied from a global one,
+// Property only contains the fields changed by the upstream, UpstreamProperty is recorded as the new base to diff with.
+type AcceptProcessTemplateUpstreamOption struct {
+	Property         map[string]interface{} `json:"property"`
+	UpstreamProperty *ProcessProperty       `json:"upstream_property"`
+}
@@ -0,0 +1,232 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ServiceTemplateVersion is an immutable snapshot of a service template and it's process templates,
+// modules can be pinned to a version so that later template changes will not affect them
+// until the version they pinned is changed by a rollout or a sync.
+type ServiceTemplateVersion struct {
+	ID                int64  `field:"id" json:"id" bson:"id"`
+	BizID             int64  `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
+	ServiceTemplateID int64  `field:"service_template_id" json:"service_template_id" bson:"service_template_id"`
+	Version           int64  `field:"version" json:"version" bson:"version"`
+	Description       string `field:"description" json:"description" bson:"description"`
+
+	ServiceTemplate  ServiceTemplate   `field:"service_template" json:"service_template" bson:"service_template"`
+	ProcessTemplates []ProcessTemplate `field:"process_templates" json:"process_templates" bson:"process_templates"`
+
+	Creator         string    `field:"creator" json:"creator" bson:"creator"`
+	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time"`
+	SupplierAccount string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+type CreateServiceTemplateVersionOption struct {
+	Metadata          *Metadata `json:"metadata"`
+	BizID             int64     `json:"bk_biz_id"`
+	ServiceTemplateID int64     `json:"service_template_id"`
+	Description       string    `json:"description"`
+}
+
+type ListServiceTemplateVersionOption struct {
+	Metadata          *Metadata `json:"metadata"`
+	BizID             int64     `json:"bk_biz_id"`
+	ServiceTemplateID int64     `json:"service_template_id"`
+	Versions          []int64   `json:"versions"`
+	Page              BasePage  `json:"page"`
+}
+
+type MultipleServiceTemplateVersion struct {
+	Count uint64                   `json:"count"`
+	Info  []ServiceTemplateVersion `json:"info"`
+}
+
+type OneServiceTemplateVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     ServiceTemplateVersion `json:"data"`
+}
+
+type MultipleServiceTemplateVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleServiceTemplateVersion `json:"data"`
+}
+
+// ModuleServiceTemplateVersion records the service template version a module pinned to
+type ModuleServiceTemplateVersion struct {
+	BizID             int64     `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
+	ModuleID          int64     `field:"bk_module_id" json:"bk_module_id" bson:"bk_module_id"`
+	ServiceTemplateID int64     `field:"service_template_id" json:"service_template_id" bson:"service_template_id"`
+	Version           int64     `field:"version" json:"version" bson:"version"`
+	Modifier          string    `field:"modifier" json:"modifier" bson:"modifier"`
+	LastTime          time.Time `field:"last_time" json:"last_time" bson:"last_time"`
+	SupplierAccount   string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// PinModuleServiceTemplateVersionOption pin modules to a version, version 0 means unpin
+type PinModuleServiceTemplateVersionOption struct {
+	Metadata          *Metadata `json:"metadata"`
+	BizID             int64     `json:"bk_biz_id"`
+	ServiceTemplateID int64     `json:"service_template_id"`
+	ModuleIDs         []int64   `json:"bk_module_ids"`
+	Version           int64     `json:"version"`
+}
+
+type ListModuleServiceTemplateVersionOption struct {
+	Metadata          *Metadata `json:"metadata"`
+	BizID             int64     `json:"bk_biz_id"`
+	ServiceTemplateID int64     `json:"service_template_id"`
+	ModuleIDs         []int64   `json:"bk_module_ids"`
+}
+
+type MultipleModuleServiceTemplateVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     []ModuleServiceTemplateVersion `json:"data"`
+}
+
+type RolloutStatus string
+
+func (rs RolloutStatus) IsFinished() bool {
+	return rs == RolloutStatusFinished || rs == RolloutStatusAborted || rs == RolloutStatusFailure
+}
+
+var (
+	RolloutStatusRunning  = RolloutStatus("running")  // 执行中
+	RolloutStatusPaused   = RolloutStatus("paused")   // 已暂停
+	RolloutStatusAborted  = RolloutStatus("aborted")  // 已终止
+	RolloutStatusFinished = RolloutStatus("finished") // 已完成
+	RolloutStatusFailure  = RolloutStatus("failure")  // 失败
+)
+
+// RolloutWave is a batch of modules synchronized by one task_server task
+type RolloutWave struct {
+	ModuleIDs []int64       `field:"bk_module_ids" json:"bk_module_ids" bson:"bk_module_ids"`
+	TaskID    string        `field:"task_id" json:"task_id" bson:"task_id"`
+	Status    RolloutStatus `field:"status" json:"status" bson:"status"`
+}
+
+// ServiceTemplateRollout sync a service template version to modules wave by wave
+type ServiceTemplateRollout struct {
+	ID                int64         `field:"id" json:"id" bson:"id"`
+	BizID             int64         `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
+	ServiceTemplateID int64         `field:"service_template_id" json:"service_template_id" bson:"service_template_id"`
+	Version           int64         `field:"version" json:"version" bson:"version"`
+	Waves             []RolloutWave `field:"waves" json:"waves" bson:"waves"`
+	CurrentWave       int64         `field:"current_wave" json:"current_wave" bson:"current_wave"`
+	Status            RolloutStatus `field:"status" json:"status" bson:"status"`
+	// PauseBetweenWaves pause the rollout after each wave, so that it can be verified before continue
+	PauseBetweenWaves bool `field:"pause_between_waves" json:"pause_between_waves" bson:"pause_between_waves"`
+	// Revision increase on every update, used to avoid concurrent update
+	Revision    int64  `field:"revision" json:"revision" bson:"revision"`
+	UpdateToken string `field:"update_token" json:"-" bson:"update_token"`
+
+	Creator         string    `field:"creator" json:"creator" bson:"creator"`
+	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier"`
+	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time"`
+	LastTime        time.Time `field:"last_time" json:"last_time" bson:"last_time"`
+	SupplierAccount string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// CreateServiceTemplateRolloutOption target modules are specified by module ids or a percentage of
+// the modules created by the service template, they are synchronized in waves of WaveSize modules.
+type CreateServiceTemplateRolloutOption struct {
+	Metadata          *Metadata `json:"metadata"`
+	BizID             int64     `json:"bk_biz_id"`
+	ServiceTemplateID int64     `json:"service_template_id"`
+	Version           int64     `json:"version"`
+	ModuleIDs         []int64   `json:"bk_module_ids"`
+	Percentage        int64     `json:"percentage"`
+	WaveSize          int64     `json:"wave_size"`
+	PauseBetweenWaves bool      `json:"pause_between_waves"`
+}
+
+func (option *CreateServiceTemplateRolloutOption) Validate() (string, error) {
+	if option.ServiceTemplateID <= 0 {
+		return "service_template_id", errors.New("service template id not set")
+	}
+	if option.Version <= 0 {
+		return "version", errors.New("version not set")
+	}
+	if len(option.ModuleIDs) > 0 && option.Percentage != 0 {
+		return "percentage", errors.New("bk_module_ids and percentage can not be set at the same time")
+	}
+	if len(option.ModuleIDs) == 0 && (option.Percentage <= 0 || option.Percentage > 100) {
+		return "percentage", fmt.Errorf("percentage should between 1 and 100, got: %d", option.Percentage)
+	}
+	if option.WaveSize < 0 {
+		return "wave_size", fmt.Errorf("wave size should not be negative, got: %d", option.WaveSize)
+	}
+	return "", nil
+}
+
+type ListServiceTemplateRolloutOption struct {
+	Metadata          *Metadata       `json:"metadata"`
+	BizID             int64           `json:"bk_biz_id"`
+	ServiceTemplateID int64           `json:"service_template_id"`
+	RolloutIDs        []int64         `json:"rollout_ids"`
+	Status            []RolloutStatus `json:"status"`
+	Page              BasePage        `json:"page"`
+}
+
+type MultipleServiceTemplateRollout struct {
+	Count uint64                   `json:"count"`
+	Info  []ServiceTemplateRollout `json:"info"`
+}
+
+type OneServiceTemplateRolloutResult struct {
+	BaseResp `json:",inline"`
+	Data     ServiceTemplateRollout `json:"data"`
+}
+
+type MultipleServiceTemplateRolloutResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleServiceTemplateRollout `json:"data"`
+}
+
+// UpdateServiceTemplateRolloutOption update rollout state, the update only takes effect
+// when the rollout's revision still equals to Revision.
+type UpdateServiceTemplateRolloutOption struct {
+	Revision    int64         `json:"revision"`
+	Status      RolloutStatus `json:"status"`
+	CurrentWave *int64        `json:"current_wave"`
+	Waves       []RolloutWave `json:"waves"`
+}
+
+// ServiceTemplateRolloutOperationOption is used to pause, resume or abort a rollout
+type ServiceTemplateRolloutOperationOption struct {
+	Metadata          *Metadata `json:"metadata"`
+	BizID             int64     `json:"bk_biz_id"`
+	ServiceTemplateID int64     `json:"service_template_id"`
+	RolloutID         int64     `json:"rollout_id"`
+}
+
+// SyncModuleRolloutTask is the task_server sub task data of rollout, one for each module
+type SyncModuleRolloutTask struct {
+	Header    http.Header `json:"header"`
+	BizID     int64       `json:"bk_biz_id"`
+	RolloutID int64       `json:"rollout_id"`
+	Wave      int64       `json:"wave"`
+	ModuleID  int64       `json:"bk_module_id"`
+	// LastOfWave the last module of a wave is responsible for moving the rollout to next wave
+	LastOfWave bool `json:"last_of_wave"`
+}
+
+// GetServiceTemplateRolloutIndex 返回task_server中任务的检索值(flag)
+func GetServiceTemplateRolloutIndex(rolloutID int64, wave int64) string {
+	return fmt.Sprintf("service_template_rollout:%d:%d", rolloutID, wave)
+}
@@ -25,6 +25,9 @@ type SetTemplate struct {
 	Name  string `field:"name" json:"name" bson:"name"`
 	BizID int64  `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
 
+	// Attributes 通过该模板创建的集群的属性值
+	Attributes []TemplateAttribute `field:"attributes" json:"attributes" bson:"attributes"`
+
 	// 通用字段
 	Creator         string    `field:"creator" json:"creator" bson:"creator"`
 	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier"`
@@ -39,9 +42,103 @@ func (st SetTemplate) Validate() (key string, err error) {
 	if nameLen == 0 || nameLen > common.NameFieldMaxLength {
 		return common.BKFieldName, fmt.Errorf("%s field length is: %d", common.BKFieldName, nameLen)
 	}
+	return ValidateTemplateAttributes(st.Attributes, SetTemplateReservedFields)
+}
+
+// TemplateAttribute 模板上定义的实例属性值
+// Locked 为 true 时该属性由模板管控, 实例不允许修改, 同步时以模板值覆盖实例值;
+// 否则仅作为通过模板创建实例时的默认值, 实例可自行修改
+type TemplateAttribute struct {
+	PropertyID string      `field:"bk_property_id" json:"bk_property_id" bson:"bk_property_id" mapstructure:"bk_property_id"`
+	Value      interface{} `field:"value" json:"value" bson:"value" mapstructure:"value"`
+	Locked     bool        `field:"locked" json:"locked" bson:"locked" mapstructure:"locked"`
+}
+
+var (
+	// SetTemplateReservedFields 集群模板属性中不允许定义的字段, 这些字段由拓扑逻辑维护
+	SetTemplateReservedFields = []string{
+		common.BKSetIDField,
+		common.BKSetNameField,
+		common.BKAppIDField,
+		common.BKParentIDField,
+		common.BKSetTemplateIDField,
+		common.BKDefaultField,
+		common.BKOwnerIDField,
+		common.CreateTimeField,
+		common.LastTimeField,
+	}
+
+	// ServiceTemplateReservedFields 服务模板属性中不允许定义的字段, 这些字段由拓扑逻辑维护
+	ServiceTemplateReservedFields = []string{
+		common.BKModuleIDField,
+		common.BKModuleNameField,
+		common.BKSetIDField,
+		common.BKAppIDField,
+		common.BKParentIDField,
+		common.BKSetTemplateIDField,
+		common.BKServiceTemplateIDField,
+		common.BKServiceCategoryIDField,
+		common.BKDefaultField,
+		common.BKOwnerIDField,
+		common.CreateTimeField,
+		common.LastTimeField,
+	}
+)
+
+// ValidateTemplateAttributes 校验模板属性, 属性不能重复且不能是保留字段
+func ValidateTemplateAttributes(attributes []TemplateAttribute, reserved []string) (key string, err error) {
+	exist := make(map[string]bool)
+	for _, attribute := range attributes {
+		if len(attribute.PropertyID) == 0 {
+			return "attributes", fmt.Errorf("%s can't be empty", common.BKPropertyIDField)
+		}
+		if exist[attribute.PropertyID] {
+			return "attributes", fmt.Errorf("property %s duplicated", attribute.PropertyID)
+		}
+		exist[attribute.PropertyID] = true
+		for _, field := range reserved {
+			if attribute.PropertyID == field {
+				return "attributes", fmt.Errorf("property %s is reserved", attribute.PropertyID)
+			}
+		}
+	}
 	return "", nil
 }
 
+// ApplyTemplateAttributes 将模板属性值应用到待创建的实例数据上,
+// 锁定属性强制使用模板值, 非锁定属性仅在实例数据未指定时作为默认值
+func ApplyTemplateAttributes(data map[string]interface{}, attributes []TemplateAttribute) {
+	for _, attribute := range attributes {
+		if _, exist := data[attribute.PropertyID]; exist && !attribute.Locked {
+			continue
+		}
+		data[attribute.PropertyID] = attribute.Value
+	}
+}
+
+// FindLockedTemplateAttributeConflict 返回更新数据中试图修改模板锁定属性的字段
+func FindLockedTemplateAttributeConflict(data map[string]interface{}, attributes []TemplateAttribute) (string, bool) {
+	for _, attribute := range attributes {
+		if !attribute.Locked {
+			continue
+		}
+		value, exist := data[attribute.PropertyID]
+		if exist && !IsTemplateAttributeValueEqual(attribute.Value, value) {
+			return attribute.PropertyID, true
+		}
+	}
+	return "", false
+}
+
+// IsTemplateAttributeValueEqual 比较模板属性值与实例属性值是否一致
+// 实例数据经过 json 编解码后数值类型可能发生变化, 故按字面值比较
+func IsTemplateAttributeValueEqual(templateValue, instanceValue interface{}) bool {
+	if templateValue == nil || instanceValue == nil {
+		return templateValue == nil && instanceValue == nil
+	}
+	return fmt.Sprintf("%v", templateValue) == fmt.Sprintf("%v", instanceValue)
+}
+
 // 拓扑模板与服务模板多对多关系, 记录拓扑模板的构成
 type SetServiceTemplateRelation struct {
 	BizID             int64  `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
@@ -20,19 +20,25 @@ import (
 )
 
 type CreateSetTemplateOption struct {
-	Name               string  `field:"name" json:"name" bson:"name" mapstructure:"name"`
-	ServiceTemplateIDs []int64 `field:"service_template_ids" json:"service_template_ids" bson:"service_template_ids" mapstructure:"service_template_ids"`
+	Name               string              `field:"name" json:"name" bson:"name" mapstructure:"name"`
+	ServiceTemplateIDs []int64             `field:"service_template_ids" json:"service_template_ids" bson:"service_template_ids" mapstructure:"service_template_ids"`
+	Attributes         []TemplateAttribute `field:"attributes" json:"attributes" bson:"attributes" mapstructure:"attributes"`
 }
 
 type UpdateSetTemplateOption struct {
 	Name               string  `field:"name" json:"name" bson:"name"`
 	ServiceTemplateIDs []int64 `field:"service_template_ids" json:"service_template_ids" bson:"service_template_ids"`
+	// Attributes 为 nil 时不更新模板属性
+	Attributes []TemplateAttribute `field:"attributes" json:"attributes" bson:"attributes"`
 }
 
 func (option UpdateSetTemplateOption) Validate() (string, error) {
-	if len(option.Name) == 0 && option.ServiceTemplateIDs == nil {
+	if len(option.Name) == 0 && option.ServiceTemplateIDs == nil && option.Attributes == nil {
 		return "", errors.New("at least one update field not empty")
 	}
+	if option.Attributes != nil {
+		return ValidateTemplateAttributes(option.Attributes, SetTemplateReservedFields)
+	}
 	return "", nil
 }
 
@@ -96,24 +102,44 @@ type SetSyncStatusOption struct {
 	SetIDs []int64 `field:"bk_set_ids" json:"bk_set_ids" bson:"bk_set_ids" mapstructure:"bk_set_ids"`
 }
 
+// AttributeDiff 模板属性值与实例属性值的差异
+type AttributeDiff struct {
+	PropertyID    string      `json:"bk_property_id" mapstructure:"bk_property_id"`
+	TemplateValue interface{} `json:"template_value" mapstructure:"template_value"`
+	InstanceValue interface{} `json:"instance_value" mapstructure:"instance_value"`
+	Locked        bool        `json:"locked" mapstructure:"locked"`
+}
+
 type SetModuleDiff struct {
-	ModuleID            int64  `json:"bk_module_id" mapstructure:"bk_module_id"`
-	ModuleName          string `json:"bk_module_name" mapstructure:"bk_module_name"`
-	ServiceTemplateID   int64  `json:"service_template_id" mapstructure:"service_template_id"`
-	ServiceTemplateName string `json:"service_template_name" mapstructure:"service_template_name"`
-	DiffType            string `json:"diff_type" mapstructure:"diff_type"`
+	ModuleID            int64           `json:"bk_module_id" mapstructure:"bk_module_id"`
+	ModuleName          string          `json:"bk_module_name" mapstructure:"bk_module_name"`
+	ServiceTemplateID   int64           `json:"service_template_id" mapstructure:"service_template_id"`
+	ServiceTemplateName string          `json:"service_template_name" mapstructure:"service_template_name"`
+	DiffType            string          `json:"diff_type" mapstructure:"diff_type"`
+	AttributeDiffs      []AttributeDiff `json:"attribute_diffs" mapstructure:"attribute_diffs"`
+}
+
+// LockedAttributes 返回需要同步到模块的锁定属性值
+func (md SetModuleDiff) LockedAttributes() map[string]interface{} {
+	return lockedAttributeDiffValues(md.AttributeDiffs)
 }
 
 type SetDiff struct {
-	ModuleDiffs []SetModuleDiff            `json:"module_diffs"`
-	SetID       int64                      `json:"bk_set_id"`
-	SetDetail   SetInst                    `json:"set_detail"`
-	TopoPath    []TopoInstanceNodeSimplify `json:"topo_path"`
-	NeedSync    bool                       `json:"need_sync"`
+	ModuleDiffs    []SetModuleDiff            `json:"module_diffs"`
+	SetID          int64                      `json:"bk_set_id"`
+	SetDetail      SetInst                    `json:"set_detail"`
+	AttributeDiffs []AttributeDiff            `json:"attribute_diffs"`
+	TopoPath       []TopoInstanceNodeSimplify `json:"topo_path"`
+	NeedSync       bool                       `json:"need_sync"`
+}
+
+// LockedAttributes 返回需要同步到集群的锁定属性值
+func (sd *SetDiff) LockedAttributes() map[string]interface{} {
+	return lockedAttributeDiffValues(sd.AttributeDiffs)
 }
 
 func (sd *SetDiff) UpdateNeedSyncField() {
-	sd.NeedSync = false
+	sd.NeedSync = len(sd.LockedAttributes()) > 0
 	for _, module := range sd.ModuleDiffs {
 		if module.DiffType != ModuleDiffUnchanged {
 			sd.NeedSync = true
@@ -122,6 +148,16 @@ func (sd *SetDiff) UpdateNeedSyncField() {
 	}
 }
 
+func lockedAttributeDiffValues(diffs []AttributeDiff) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, diff := range diffs {
+		if diff.Locked {
+			values[diff.PropertyID] = diff.TemplateValue
+		}
+	}
+	return values
+}
+
 type SetTplDiffResult struct {
 	Difference      []SetDiff       `json:"difference"`
 	ModuleHostCount map[int64]int64 `json:"module_host_count"`
@@ -120,3 +120,73 @@ type ExcelAssocation struct {
 	SrcPrimary   string                 `json:"src_primary_key"`
 	DstPrimary   string                 `json:"dst_primary_key"`
 }
+
+// ExportExcelTask the sub task data of an asynchronous excel export, which is executed by task server
+type ExportExcelTask struct {
+	// ExportID identify the export, it is used as the task flag and the name of the exported file
+	ExportID string `json:"export_id"`
+	ObjID    string `json:"bk_obj_id"`
+	OwnerID  string `json:"bk_supplier_account"`
+	// AppID and InstIDs are the same as the form values of the synchronous export
+	AppID        string    `json:"bk_biz_id"`
+	InstIDs      string    `json:"inst_ids"`
+	CustomFields string    `json:"export_custom_fields"`
+	Metadata     *Metadata `json:"metadata"`
+}
+
+// ExportExcelProgress the progress of an asynchronous excel export
+type ExportExcelProgress struct {
+	TaskID   string        `json:"task_id"`
+	Status   APITaskStatus `json:"status"`
+	Total    int           `json:"total"`
+	Exported int           `json:"exported"`
+}
+
+// ImportRowAction what will happen to an excel row when it is imported
+type ImportRowAction string
+
+const (
+	ImportRowActionCreate    ImportRowAction = "create"
+	ImportRowActionUpdate    ImportRowAction = "update"
+	ImportRowActionUnchanged ImportRowAction = "unchanged"
+	ImportRowActionInvalid   ImportRowAction = "invalid"
+)
+
+// ImportMode how the rows of an excel are committed
+type ImportMode string
+
+const (
+	// ImportModeDefault commit all the rows, the same as before the preview is supported
+	ImportModeDefault ImportMode = ""
+	// ImportModeValidOnly only commit the rows to be created or updated, the invalid rows are skipped
+	ImportModeValidOnly ImportMode = "valid_only"
+	// ImportModeAllOrNothing commit nothing if any row is invalid, and the rows are committed in one transaction
+	ImportModeAllOrNothing ImportMode = "all_or_nothing"
+)
+
+// ImportFieldDiff the difference of a field between the excel row and the existing instance
+type ImportFieldDiff struct {
+	PropertyID string      `json:"bk_property_id"`
+	Before     interface{} `json:"before"`
+	After      interface{} `json:"after"`
+}
+
+// ImportRowPreview the preview of an excel row
+type ImportRowPreview struct {
+	Row    int               `json:"row"`
+	Action ImportRowAction   `json:"action"`
+	InstID int64             `json:"inst_id,omitempty"`
+	Diff   []ImportFieldDiff `json:"diff,omitempty"`
+	Errors []string          `json:"errors,omitempty"`
+}
+
+// ImportPreviewResult the preview of an excel import
+type ImportPreviewResult struct {
+	Rows    []ImportRowPreview      `json:"rows"`
+	Summary map[ImportRowAction]int `json:"summary"`
+}
+
+// HasInvalidRow whether any row can not be imported
+func (r *ImportPreviewResult) HasInvalidRow() bool {
+	return r.Summary[ImportRowActionInvalid] > 0
+}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"configcenter/src/common"
+	"configcenter/src/common/util"
 )
 
 type Rule interface {
@@ -83,6 +84,10 @@ var (
 	OperatorsEndsWith     = Operator("ends_with")
 	OperatorNotEndsWith   = Operator("not_ends_with")
 
+	// network operator
+	OperatorIPInCIDR    = Operator("ip_in_cidr")
+	OperatorIPNotInCIDR = Operator("ip_not_in_cidr")
+
 	// array operator
 	OperatorIsEmpty    = Operator("is_empty")
 	OperatorIsNotEmpty = Operator("is_not_empty")
@@ -120,6 +125,9 @@ var SupportOperators = map[Operator]bool{
 	OperatorsEndsWith:     true,
 	OperatorNotEndsWith:   true,
 
+	OperatorIPInCIDR:    true,
+	OperatorIPNotInCIDR: true,
+
 	OperatorIsEmpty:    false,
 	OperatorIsNotEmpty: false,
 
@@ -185,6 +193,8 @@ func (r AtomRule) validateValue() error {
 		return validateDatetimeStringType(r.Value)
 	case OperatorBeginsWith, OperatorNotBeginsWith, OperatorContains, OperatorNotContains, OperatorsEndsWith, OperatorNotEndsWith:
 		return validateNotEmptyStringType(r.Value)
+	case OperatorIPInCIDR, OperatorIPNotInCIDR:
+		return validateIPv4CIDRType(r.Value)
 	case OperatorIsEmpty, OperatorIsNotEmpty:
 		return nil
 	case OperatorIsNull, OperatorIsNotNull:
@@ -292,6 +302,22 @@ func (r AtomRule) ToMgo() (mgoFiler map[string]interface{}, key string, err erro
 		filter[r.Field] = map[string]interface{}{
 			common.BKDBNot: fmt.Sprintf("%s$", r.Value),
 		}
+	case OperatorIPInCIDR:
+		expr, err := util.IPv4CIDRRegexp(r.Value.(string))
+		if err != nil {
+			return nil, "value", err
+		}
+		filter[r.Field] = map[string]interface{}{
+			common.BKDBLIKE: expr,
+		}
+	case OperatorIPNotInCIDR:
+		expr, err := util.IPv4CIDRRegexp(r.Value.(string))
+		if err != nil {
+			return nil, "value", err
+		}
+		filter[r.Field] = map[string]interface{}{
+			common.BKDBNot: expr,
+		}
 	case OperatorIsEmpty:
 		// array empty
 		filter[r.Field] = map[string]interface{}{
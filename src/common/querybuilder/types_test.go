@@ -138,6 +138,14 @@ func TestNormalAtomRule(t *testing.T) {
 			Operator: querybuilder.OperatorNotEndsWith,
 			Field:    "field",
 			Value:    "test",
+		}, {
+			Operator: querybuilder.OperatorIPInCIDR,
+			Field:    "field",
+			Value:    "10.0.0.0/8",
+		}, {
+			Operator: querybuilder.OperatorIPNotInCIDR,
+			Field:    "field",
+			Value:    "192.168.0.0/16",
 		}, {
 			Operator: querybuilder.OperatorIsEmpty,
 			Field:    "field",
@@ -87,6 +87,16 @@ func validateDatetimeStringType(value interface{}) error {
 	return nil
 }
 
+func validateIPv4CIDRType(value interface{}) error {
+	if err := validateStringType(value); err != nil {
+		return err
+	}
+	if _, err := util.IPv4CIDRRegexp(value.(string)); err != nil {
+		return err
+	}
+	return nil
+}
+
 func validateSliceOfBasicType(value interface{}, requireSameType bool) error {
 	t := reflect.TypeOf(value)
 	if t.Kind() != reflect.Array && t.Kind() != reflect.Slice {
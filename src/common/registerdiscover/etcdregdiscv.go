@@ -0,0 +1,247 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/backbone/service_mange/etcd"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/etcdclient"
+)
+
+// EtcdRegDiscv do register and discover by etcd. the server is registered as a key attached to a lease,
+// so the key is deleted when the server is down and the lease is expired. the servers are discovered in
+// the order of the registration, so the first one is the master like the sequential node of zookeeper.
+type EtcdRegDiscv struct {
+	etcdcli        *etcdclient.EtcdClient
+	cancel         context.CancelFunc
+	rootCxt        context.Context
+	sessionTimeOut time.Duration
+
+	sync.Mutex
+	registerPath string
+	leaseID      int64
+}
+
+// NewEtcdRegDiscv create a object of EtcdRegDiscv
+func NewEtcdRegDiscv(client *etcd.EtcdClient) *EtcdRegDiscv {
+	ctx, ctxCancel := client.WithCancel()
+	return &EtcdRegDiscv{
+		etcdcli:        client.Client(),
+		sessionTimeOut: client.SessionTimeOut(),
+		cancel:         ctxCancel,
+		rootCxt:        ctx,
+	}
+}
+
+// leaseTTL the ttl seconds of the lease, the server is regarded as down if the lease is not renewed in it
+func (etcdRD *EtcdRegDiscv) leaseTTL() int64 {
+	ttl := int64(etcdRD.sessionTimeOut / time.Second)
+	if ttl < 5 {
+		ttl = 5
+	}
+	return ttl
+}
+
+// RegisterAndWatch register the server with a lease and keep it alive. if the lease is expired or the key
+// is deleted, register again.
+func (etcdRD *EtcdRegDiscv) RegisterAndWatch(path string, data []byte) error {
+	blog.Infof("register server and watch it. path(%s), data(%s)", path, string(data))
+	go func() {
+		ttl := etcdRD.leaseTTL()
+		for {
+			if err := etcdRD.keepRegistered(path, data, ttl); err != nil {
+				blog.Errorf("fail to keep server node(%s) registered, err: %v", path, err)
+			}
+
+			select {
+			case <-etcdRD.rootCxt.Done():
+				blog.Infof("watch register node(%s) done, now exist service register.", path)
+				return
+			case <-time.After(time.Duration(ttl) * time.Second / 3):
+			}
+		}
+	}()
+
+	blog.Infof("finish register server node(%s) and watch it", path)
+	return nil
+}
+
+// keepRegistered renew the lease of the registered key, register again if it's not exist
+func (etcdRD *EtcdRegDiscv) keepRegistered(path string, data []byte, ttl int64) error {
+	etcdRD.Lock()
+	defer etcdRD.Unlock()
+
+	if etcdRD.leaseID != 0 {
+		_, err := etcdRD.etcdcli.KeepAliveOnce(etcdRD.leaseID)
+		if err == nil {
+			_, err = etcdRD.etcdcli.Get(etcdRD.registerPath)
+			if err == nil {
+				return nil
+			}
+		}
+		if err != etcdclient.ErrLeaseNotFound && err != etcdclient.ErrKeyNotFound {
+			return err
+		}
+		blog.Warnf("register node(%s) is lost, err: %v, register it again", etcdRD.registerPath, err)
+		if err == etcdclient.ErrKeyNotFound {
+			_ = etcdRD.etcdcli.Revoke(etcdRD.leaseID)
+		}
+		etcdRD.leaseID = 0
+	}
+
+	leaseID, err := etcdRD.etcdcli.Grant(ttl)
+	if err != nil {
+		return fmt.Errorf("grant lease failed, err: %v", err)
+	}
+	// the lease id is unique, use it to distinguish the servers with the same ip
+	registerPath := fmt.Sprintf("%s_%016x", path, leaseID)
+	if err := etcdRD.etcdcli.Put(registerPath, data, leaseID); err != nil {
+		_ = etcdRD.etcdcli.Revoke(leaseID)
+		return fmt.Errorf("put register node(%s) failed, err: %v", registerPath, err)
+	}
+	etcdRD.leaseID = leaseID
+	etcdRD.registerPath = registerPath
+	blog.Infof("register server node(%s) success, lease: %d", registerPath, leaseID)
+	return nil
+}
+
+// GetServNodes get server nodes by path
+func (etcdRD *EtcdRegDiscv) GetServNodes(path string) ([]string, error) {
+	kvs, _, err := etcdRD.etcdcli.GetPrefix(path + "/")
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]string, 0)
+	for _, kv := range childrenOf(path, kvs) {
+		nodes = append(nodes, strings.TrimPrefix(kv.Key, path+"/"))
+	}
+	return nodes, nil
+}
+
+// Ping to ping server
+func (etcdRD *EtcdRegDiscv) Ping() error {
+	return etcdRD.etcdcli.Ping()
+}
+
+// Discover watch the children
+func (etcdRD *EtcdRegDiscv) Discover(path string) (<-chan *DiscoverEvent, error) {
+	blog.Infof("begin to discover by watch children of path(%s)", path)
+	env := make(chan *DiscoverEvent, 1)
+
+	go etcdRD.loopDiscover(etcdRD.rootCxt, path, env)
+
+	return env, nil
+}
+
+func (etcdRD *EtcdRegDiscv) loopDiscover(discvCtx context.Context, path string, env chan *DiscoverEvent) {
+	for {
+		kvs, revision, err := etcdRD.etcdcli.GetPrefix(path + "/")
+		if err != nil {
+			blog.Errorf("fail to get children of path(%s), will retry after 5s, err: %v", path, err)
+			if !sleepWithContext(discvCtx, 5*time.Second) {
+				return
+			}
+			continue
+		}
+
+		discvEnv := &DiscoverEvent{
+			Err: nil,
+			Key: path,
+		}
+		for _, kv := range childrenOf(path, kvs) {
+			discvEnv.Nodes = append(discvEnv.Nodes, strings.TrimPrefix(kv.Key, path+"/"))
+			discvEnv.Server = append(discvEnv.Server, string(kv.Value))
+		}
+
+		select {
+		case env <- discvEnv:
+		case <-discvCtx.Done():
+			blog.Infof("discover path(%s) done", path)
+			return
+		}
+
+		watchCtx, cancel := context.WithCancel(discvCtx)
+		watchCh, err := etcdRD.etcdcli.Watch(watchCtx, path+"/", true, revision+1)
+		if err != nil {
+			cancel()
+			blog.Errorf("fail to watch children of path(%s), will retry after 5s, err: %v", path, err)
+			if !sleepWithContext(discvCtx, 5*time.Second) {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-discvCtx.Done():
+			cancel()
+			blog.Infof("discover path(%s) done", path)
+			return
+		case resp, ok := <-watchCh:
+			if ok && resp.Err != nil {
+				blog.Warnf("watch children of path(%s) ended, err: %v", path, resp.Err)
+			} else {
+				blog.V(4).Infof("watch found the children of path(%s) changed", path)
+			}
+		}
+		cancel()
+	}
+}
+
+// childrenOf returns the key values which are the direct children of the path
+func childrenOf(path string, kvs []etcdclient.KeyValue) []etcdclient.KeyValue {
+	children := make([]etcdclient.KeyValue, 0, len(kvs))
+	for _, kv := range kvs {
+		name := strings.TrimPrefix(kv.Key, path+"/")
+		if len(name) == 0 || strings.Contains(name, "/") {
+			continue
+		}
+		children = append(children, kv)
+	}
+	return children
+}
+
+func sleepWithContext(ctx context.Context, duration time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(duration):
+		return true
+	}
+}
+
+// Cancel to stop server register and discover
+func (etcdRD *EtcdRegDiscv) Cancel() {
+	etcdRD.cancel()
+}
+
+// ClearRegisterPath to delete server register path from etcd
+func (etcdRD *EtcdRegDiscv) ClearRegisterPath() error {
+	etcdRD.Lock()
+	defer etcdRD.Unlock()
+	if etcdRD.leaseID == 0 {
+		return nil
+	}
+	// the registered key is deleted with the lease
+	err := etcdRD.etcdcli.Revoke(etcdRD.leaseID)
+	if err != nil && err != etcdclient.ErrLeaseNotFound {
+		return err
+	}
+	etcdRD.leaseID = 0
+	return nil
+}
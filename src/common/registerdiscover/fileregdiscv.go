@@ -0,0 +1,143 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package registerdiscover
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"configcenter/src/common/backbone/service_mange/file"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/types"
+)
+
+// FileRegDiscv do register and discover by the static discovery file. the servers are read from the file
+// and discovered again when the file is changed, the registration only takes effect in the current process.
+type FileRegDiscv struct {
+	client  *file.FileClient
+	cancel  context.CancelFunc
+	rootCxt context.Context
+
+	sync.Mutex
+	registerPath string
+}
+
+// NewFileRegDiscv create a object of FileRegDiscv
+func NewFileRegDiscv(client *file.FileClient) *FileRegDiscv {
+	ctx, ctxCancel := client.WithCancel()
+	return &FileRegDiscv{
+		client:  client,
+		cancel:  ctxCancel,
+		rootCxt: ctx,
+	}
+}
+
+// RegisterAndWatch register the server in the current process
+func (fileRD *FileRegDiscv) RegisterAndWatch(path string, data []byte) error {
+	blog.Infof("register server to the static discovery. path(%s), data(%s)", path, string(data))
+	fileRD.Lock()
+	defer fileRD.Unlock()
+	fileRD.registerPath = path
+	fileRD.client.Register(path, data)
+	return nil
+}
+
+// GetServNodes get server nodes by path
+func (fileRD *FileRegDiscv) GetServNodes(path string) ([]string, error) {
+	servers, err := fileRD.client.Servers(path)
+	if err != nil {
+		return nil, err
+	}
+	return serverNodes(servers), nil
+}
+
+// Ping to ping server
+func (fileRD *FileRegDiscv) Ping() error {
+	return fileRD.client.Ping()
+}
+
+// Discover returns the servers of the path, and discover again when the discovery file is changed
+func (fileRD *FileRegDiscv) Discover(path string) (<-chan *DiscoverEvent, error) {
+	blog.Infof("begin to discover path(%s) from the static discovery file", path)
+	env := make(chan *DiscoverEvent, 1)
+
+	go fileRD.loopDiscover(fileRD.rootCxt, path, env)
+
+	return env, nil
+}
+
+func (fileRD *FileRegDiscv) loopDiscover(discvCtx context.Context, path string, env chan *DiscoverEvent) {
+	for {
+		// get the changed channel before reading the servers, so no change is missed
+		changed := fileRD.client.Changed()
+
+		discvEnv := &DiscoverEvent{
+			Err: nil,
+			Key: path,
+		}
+		servers, err := fileRD.client.Servers(path)
+		if err != nil {
+			blog.Errorf("fail to get servers of path(%s) from the static discovery file, err: %v", path, err)
+			discvEnv.Err = err
+		} else {
+			discvEnv.Server = servers
+			discvEnv.Nodes = serverNodes(servers)
+		}
+
+		select {
+		case env <- discvEnv:
+		case <-discvCtx.Done():
+			blog.Infof("discover path(%s) done", path)
+			return
+		}
+
+		select {
+		case <-discvCtx.Done():
+			blog.Infof("discover path(%s) done", path)
+			return
+		case <-changed:
+			blog.V(4).Infof("the static discovery of path(%s) changed", path)
+		}
+	}
+}
+
+// serverNodes returns the node names of the servers, which are the addresses of the servers
+func serverNodes(servers []string) []string {
+	nodes := make([]string, 0, len(servers))
+	for _, server := range servers {
+		info := types.ServerInfo{}
+		if err := json.Unmarshal([]byte(server), &info); err != nil {
+			continue
+		}
+		nodes = append(nodes, info.Address())
+	}
+	return nodes
+}
+
+// Cancel to stop server register and discover
+func (fileRD *FileRegDiscv) Cancel() {
+	fileRD.cancel()
+}
+
+// ClearRegisterPath to remove the server registered in the current process
+func (fileRD *FileRegDiscv) ClearRegisterPath() error {
+	fileRD.Lock()
+	defer fileRD.Unlock()
+	if len(fileRD.registerPath) == 0 {
+		return nil
+	}
+	fileRD.client.Unregister(fileRD.registerPath)
+	fileRD.registerPath = ""
+	return nil
+}
@@ -15,6 +15,8 @@ package registerdiscover
 import (
 	"time"
 
+	"configcenter/src/common/backbone/service_mange/etcd"
+	"configcenter/src/common/backbone/service_mange/file"
 	"configcenter/src/common/backbone/service_mange/zk"
 )
 
@@ -53,6 +55,20 @@ func NewRegDiscoverEx(client *zk.ZkClient) *RegDiscover {
 	return regDiscv
 }
 
+// NewEtcdRegDiscover used to create a object of RegDiscover which is based on etcd
+func NewEtcdRegDiscover(client *etcd.EtcdClient) *RegDiscover {
+	return &RegDiscover{
+		rdServer: RegDiscvServer(NewEtcdRegDiscv(client)),
+	}
+}
+
+// NewFileRegDiscover used to create a object of RegDiscover which is based on the static discovery file
+func NewFileRegDiscover(client *file.FileClient) *RegDiscover {
+	return &RegDiscover{
+		rdServer: RegDiscvServer(NewFileRegDiscv(client)),
+	}
+}
+
 // RegisterAndWatchService register service info into register-discover platform
 // and then watch the service info, if not exist, then register again
 // key is the index of registered service
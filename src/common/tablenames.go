@@ -80,6 +80,9 @@ const (
 
 	BKTableNameHostLock = "cc_HostLock"
 
+	BKTableNameHostSnapMapping = "cc_HostSnapMapping"
+	BKTableNameHostSnapHistory = "cc_HostSnapHistory"
+
 	// Cloud sync tables
 	BKTableNameCloudTask              = "cc_CloudTask"
 	BKTableNameCloudSyncHistory       = "cc_CloudSyncHistory"
@@ -91,6 +94,9 @@ const (
 	BKTableNameChartPosition = "cc_ChartPosition"
 	BKTableNameChartData     = "cc_ChartData"
 
+	BKTableNameCustomChart     = "cc_CustomChart"
+	BKTableNameCustomChartData = "cc_CustomChartData"
+
 	// process tables
 	BKTableNameServiceCategory         = "cc_ServiceCategory"
 	BKTableNameServiceTemplate         = "cc_ServiceTemplate"
@@ -98,6 +104,10 @@ const (
 	BKTableNameProcessTemplate         = "cc_ProcessTemplate"
 	BKTableNameProcessInstanceRelation = "cc_ProcessInstanceRelation"
 
+	BKTableNameServiceTemplateVersion       = "cc_ServiceTemplateVersion"
+	BKTableNameModuleServiceTemplateVersion = "cc_ModuleServiceTemplateVersion"
+	BKTableNameServiceTemplateRollout       = "cc_ServiceTemplateRollout"
+
 	BKTableNameSetTemplate                = "cc_SetTemplate"
 	BKTableNameSetServiceTemplateRelation = "cc_SetServiceTemplateRelation"
 	BKTableNameAPITask                    = "cc_APITask"
@@ -153,11 +163,18 @@ var AllTables = []string{
 	BKTableNameServiceInstance,
 	BKTableNameProcessTemplate,
 	BKTableNameProcessInstanceRelation,
+	BKTableNameServiceTemplateVersion,
+	BKTableNameModuleServiceTemplateVersion,
+	BKTableNameServiceTemplateRollout,
 	BKTableNameSetTemplate,
 	BKTableNameSetServiceTemplateRelation,
 	BKTableNameChartConfig,
 	BKTableNameChartPosition,
 	BKTableNameChartData,
+	BKTableNameCustomChart,
+	BKTableNameCustomChartData,
+	BKTableNameHostSnapMapping,
+	BKTableNameHostSnapHistory,
 }
 
 // GetInstTableName returns inst data table name
@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// exporter types
+const (
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+// Config the tracing config
+type Config struct {
+	Enable      bool
+	ServiceName string
+	// Exporter the exporter type, otlp or file
+	Exporter string
+	// Endpoint the otlp http endpoint, such as http://127.0.0.1:4318/v1/traces
+	Endpoint string
+	// FilePath the file the spans are written to as json lines by the file exporter
+	FilePath string
+	// SampleRatio the ratio of the root spans to sample, in [0, 1]
+	SampleRatio float64
+	// BatchSize the max count of the spans exported once
+	BatchSize int
+	// FlushInterval the max duration the spans are cached before exported
+	FlushInterval time.Duration
+}
+
+// ParseConfigFromKV returns the tracing config, the tracing is disabled if it's not configured
+func ParseConfigFromKV(prefix string, configMap map[string]string) (Config, error) {
+	conf := Config{
+		Exporter:      configMap[prefix+".exporter"],
+		Endpoint:      configMap[prefix+".endpoint"],
+		FilePath:      configMap[prefix+".filePath"],
+		SampleRatio:   1,
+		BatchSize:     512,
+		FlushInterval: 5 * time.Second,
+	}
+	conf.Enable = configMap[prefix+".enable"] == "true"
+	if !conf.Enable {
+		return conf, nil
+	}
+
+	if ratio, ok := configMap[prefix+".sampleRatio"]; ok {
+		value, err := strconv.ParseFloat(ratio, 64)
+		if err != nil || value < 0 || value > 1 {
+			return conf, fmt.Errorf("invalid %s.sampleRatio %s, should be in [0, 1]", prefix, ratio)
+		}
+		conf.SampleRatio = value
+	}
+	if size, ok := configMap[prefix+".batchSize"]; ok {
+		value, err := strconv.Atoi(size)
+		if err != nil || value <= 0 {
+			return conf, fmt.Errorf("invalid %s.batchSize %s", prefix, size)
+		}
+		conf.BatchSize = value
+	}
+	if interval, ok := configMap[prefix+".flushInterval"]; ok {
+		value, err := strconv.Atoi(interval)
+		if err != nil || value <= 0 {
+			return conf, fmt.Errorf("invalid %s.flushInterval %s, should be seconds", prefix, interval)
+		}
+		conf.FlushInterval = time.Duration(value) * time.Second
+	}
+	return conf, conf.Validate()
+}
+
+// Validate validate the config
+func (c Config) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	switch c.Exporter {
+	case ExporterOTLP:
+		if len(c.Endpoint) == 0 {
+			return fmt.Errorf("the endpoint of the %s exporter is not set", c.Exporter)
+		}
+	case ExporterFile:
+		if len(c.FilePath) == 0 {
+			return fmt.Errorf("the file path of the %s exporter is not set", c.Exporter)
+		}
+	default:
+		return fmt.Errorf("unsupported exporter %s", c.Exporter)
+	}
+	return nil
+}
+
+// Init init the global tracer with the config, the previous tracer is replaced and shutdown,
+// the tracing is disabled if the config is not enabled.
+func Init(conf Config) error {
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+
+	var newTracer *tracer
+	if conf.Enable {
+		exporter, err := newExporter(conf)
+		if err != nil {
+			return err
+		}
+		newTracer = &tracer{
+			serviceName: conf.ServiceName,
+			sampleRatio: conf.SampleRatio,
+			processor:   newBatchProcessor(exporter, conf.BatchSize, conf.FlushInterval),
+		}
+	}
+
+	globalLock.Lock()
+	previous := globalTracer
+	globalTracer = newTracer
+	globalLock.Unlock()
+
+	if previous != nil {
+		previous.processor.shutdown()
+	}
+	return nil
+}
+
+// Shutdown export the cached spans and disable the tracing
+func Shutdown() {
+	_ = Init(Config{})
+}
+
+func newExporter(conf Config) (Exporter, error) {
+	switch conf.Exporter {
+	case ExporterOTLP:
+		return NewOTLPExporter(conf.Endpoint, conf.ServiceName), nil
+	case ExporterFile:
+		return NewFileExporter(conf.FilePath, conf.ServiceName)
+	default:
+		return nil, fmt.Errorf("unsupported exporter %s", conf.Exporter)
+	}
+}
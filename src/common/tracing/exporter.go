@@ -0,0 +1,295 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+)
+
+// Exporter export the finished spans
+type Exporter interface {
+	Export(spans []*SpanData) error
+	Close() error
+}
+
+// batchProcessor cache the finished spans and export them in batch, the spans are dropped when the queue is full
+// so the tracing never blocks the requests.
+type batchProcessor struct {
+	exporter  Exporter
+	batchSize int
+	interval  time.Duration
+
+	queue chan *SpanData
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newBatchProcessor(exporter Exporter, batchSize int, interval time.Duration) *batchProcessor {
+	p := &batchProcessor{
+		exporter:  exporter,
+		batchSize: batchSize,
+		interval:  interval,
+		queue:     make(chan *SpanData, batchSize*4),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *batchProcessor) onEnd(span *SpanData) {
+	select {
+	case p.queue <- span:
+	default:
+		blog.V(5).Infof("tracing queue is full, drop span %s of trace %s", span.SpanID, span.TraceID)
+	}
+}
+
+func (p *batchProcessor) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	batch := make([]*SpanData, 0, p.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := p.exporter.Export(batch); err != nil {
+			blog.Errorf("export %d spans failed, err: %v", len(batch), err)
+		}
+		batch = make([]*SpanData, 0, p.batchSize)
+	}
+
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) >= p.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.stop:
+			for {
+				select {
+				case span := <-p.queue:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// shutdown export the cached spans and close the exporter
+func (p *batchProcessor) shutdown() {
+	p.once.Do(func() {
+		close(p.stop)
+		<-p.done
+		if err := p.exporter.Close(); err != nil {
+			blog.Errorf("close tracing exporter failed, err: %v", err)
+		}
+	})
+}
+
+// otlp json encoding of the spans, see opentelemetry-proto/opentelemetry/proto/trace/v1/trace.proto
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func toOTLPValue(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case uint64:
+		return map[string]interface{}{"intValue": strconv.FormatUint(v, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprintf("%v", v)}
+	}
+}
+
+func toOTLPSpan(span *SpanData) otlpSpan {
+	s := otlpSpan{
+		TraceID:           span.TraceID.String(),
+		SpanID:            span.SpanID.String(),
+		Name:              span.Name,
+		Kind:              span.Kind,
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+		Status:            otlpStatus{Code: span.Status, Message: span.StatusMsg},
+	}
+	if span.ParentSpanID.IsValid() {
+		s.ParentSpanID = span.ParentSpanID.String()
+	}
+	for key, value := range span.Attributes {
+		if key == AttrServiceName {
+			continue
+		}
+		s.Attributes = append(s.Attributes, otlpKeyValue{Key: key, Value: toOTLPValue(value)})
+	}
+	return s
+}
+
+func toOTLPTraces(serviceName string, spans []*SpanData) otlpTraces {
+	scope := otlpScopeSpans{Scope: otlpScope{Name: "configcenter"}, Spans: make([]otlpSpan, 0, len(spans))}
+	for _, span := range spans {
+		scope.Spans = append(scope.Spans, toOTLPSpan(span))
+	}
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{{Key: AttrServiceName, Value: toOTLPValue(serviceName)}}},
+		ScopeSpans: []otlpScopeSpans{scope},
+	}}}
+}
+
+// otlpExporter export the spans to the otlp http endpoint with the json encoding
+type otlpExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+}
+
+// NewOTLPExporter returns the exporter posting the spans to the otlp/http endpoint
+func NewOTLPExporter(endpoint, serviceName string) Exporter {
+	return &otlpExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *otlpExporter) Export(spans []*SpanData) error {
+	body, err := json.Marshal(toOTLPTraces(e.serviceName, spans))
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("otlp endpoint responds %s, %s", resp.Status, msg)
+	}
+	return nil
+}
+
+func (e *otlpExporter) Close() error {
+	return nil
+}
+
+// fileExporter write the spans to the file as json lines, each line is an otlp span with the service name
+type fileExporter struct {
+	lock        sync.Mutex
+	serviceName string
+	file        io.WriteCloser
+}
+
+// FileSpan the span written by the file exporter
+type FileSpan struct {
+	ServiceName string `json:"serviceName"`
+	otlpSpan
+}
+
+// NewFileExporter returns the exporter appending the spans to the file, it's used to check the spans in the tests
+func NewFileExporter(path, serviceName string) (Exporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open tracing file %s failed, err: %v", path, err)
+	}
+	return &fileExporter{serviceName: serviceName, file: file}, nil
+}
+
+func (e *fileExporter) Export(spans []*SpanData) error {
+	buf := bytes.Buffer{}
+	encoder := json.NewEncoder(&buf)
+	for _, span := range spans {
+		if err := encoder.Encode(FileSpan{ServiceName: e.serviceName, otlpSpan: toOTLPSpan(span)}); err != nil {
+			return err
+		}
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	_, err := e.file.Write(buf.Bytes())
+	return err
+}
+
+func (e *fileExporter) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.file.Close()
+}
@@ -0,0 +1,173 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"configcenter/src/common"
+)
+
+// TraceParentHeader the w3c trace context header
+const TraceParentHeader = "traceparent"
+
+// span attribute keys, following the opentelemetry semantic conventions
+const (
+	AttrServiceName    = "service.name"
+	AttrHTTPMethod     = "http.method"
+	AttrHTTPURL        = "http.url"
+	AttrHTTPRoute      = "http.target"
+	AttrHTTPStatusCode = "http.status_code"
+	AttrDBSystem       = "db.system"
+	AttrDBCollection   = "db.mongodb.collection"
+	AttrDBOperation    = "db.operation"
+	AttrRPCSystem      = "rpc.system"
+	AttrRPCMethod      = "rpc.method"
+	AttrRequestID      = "cc.request_id"
+)
+
+// FormatTraceParent format the span context as the w3c traceparent header value
+func FormatTraceParent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceParent parse the w3c traceparent header value
+func ParseTraceParent(value string) (SpanContext, error) {
+	sc := SpanContext{}
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return sc, fmt.Errorf("invalid traceparent %s", value)
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	// the future versions may have more fields, but the version ff is forbidden
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("invalid traceparent version %s", version)
+	}
+	if len(traceID) != 32 || len(spanID) != 16 || len(flags) != 2 {
+		return sc, fmt.Errorf("invalid traceparent %s", value)
+	}
+
+	if _, err := hex.Decode(sc.TraceID[:], []byte(traceID)); err != nil {
+		return sc, fmt.Errorf("invalid trace id %s", traceID)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(spanID)); err != nil {
+		return sc, fmt.Errorf("invalid parent id %s", spanID)
+	}
+	flagBytes := make([]byte, 1)
+	if _, err := hex.Decode(flagBytes, []byte(flags)); err != nil {
+		return sc, fmt.Errorf("invalid trace flags %s", flags)
+	}
+	sc.Sampled = flagBytes[0]&0x01 == 0x01
+
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid traceparent %s, the ids are all zero", value)
+	}
+	return sc, nil
+}
+
+// Inject set the span context as the traceparent header
+func Inject(sc SpanContext, header http.Header) {
+	if !sc.IsValid() || header == nil {
+		return
+	}
+	header.Set(TraceParentHeader, FormatTraceParent(sc))
+}
+
+// Extract returns the span context in the traceparent header, the span context is invalid if there is none
+func Extract(header http.Header) SpanContext {
+	if header == nil {
+		return SpanContext{}
+	}
+	value := header.Get(TraceParentHeader)
+	if len(value) == 0 {
+		return SpanContext{}
+	}
+	sc, err := ParseTraceParent(value)
+	if err != nil {
+		return SpanContext{}
+	}
+	return sc
+}
+
+// ContextWithHeader returns a new context carrying the span context in the header as the remote parent,
+// so the spans started with the context are the children of the upstream span.
+func ContextWithHeader(ctx context.Context, header http.Header) context.Context {
+	return ContextWithRemoteParent(ctx, Extract(header))
+}
+
+// statusRecorder record the status code of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Flush implements http.Flusher so the streaming handlers still work
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, the rpc of the tmserver is served on the hijacked connection
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer is not a hijacker")
+	}
+	return hijacker.Hijack()
+}
+
+// HTTPMiddleware start a server span for each request as the child of the upstream span, the traceparent header
+// of the request is replaced with the server span, so the handlers passing the header to the downstream requests
+// propagate the trace without any change.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, span := StartSpanWithParent(r.Context(), Extract(r.Header), r.Method+" "+r.URL.Path, SpanKindServer)
+		defer span.End()
+		span.SetAttribute(AttrHTTPMethod, r.Method)
+		span.SetAttribute(AttrHTTPRoute, r.URL.Path)
+		if rid := r.Header.Get(common.BKHTTPCCRequestID); len(rid) > 0 {
+			span.SetAttribute(AttrRequestID, rid)
+		}
+		Inject(span.SpanContext(), r.Header)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttribute(AttrHTTPStatusCode, recorder.status)
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(StatusError, http.StatusText(recorder.status))
+		}
+	})
+}
@@ -0,0 +1,289 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tracing implements the opentelemetry style tracing of the cmdb processes, the spans are
+// propagated between the processes with the w3c trace context and exported by the configured exporter.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// SpanKind the kind of the span, the values are the same with the otlp span kind
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode the status of the span, the values are the same with the otlp status code
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// TraceID the w3c trace id
+type TraceID [16]byte
+
+// IsValid returns whether the trace id is not all zero
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID the w3c parent id
+type SpanID [8]byte
+
+// IsValid returns whether the span id is not all zero
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext the identity of the span propagated to the children and the downstream processes
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid returns whether the span context can be used as the parent
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanData the finished span to export
+type SpanData struct {
+	SpanContext
+	ParentSpanID SpanID
+	Name         string
+	Kind         SpanKind
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   map[string]interface{}
+	Status       StatusCode
+	StatusMsg    string
+}
+
+// Span a unit of work, all the methods are safe to be called on the nil span which is returned
+// when the tracing is disabled.
+type Span struct {
+	lock   sync.Mutex
+	data   SpanData
+	tracer *tracer
+	ended  bool
+}
+
+// SpanContext returns the identity of the span
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// SetAttribute set an attribute of the span, the value should be a string, bool or number
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Attributes[key] = value
+	s.lock.Unlock()
+}
+
+// SetError mark the span failed with the error, nil error is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Status = StatusError
+	s.data.StatusMsg = err.Error()
+	s.lock.Unlock()
+}
+
+// SetStatus set the status of the span
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Status = code
+	s.data.StatusMsg = msg
+	s.lock.Unlock()
+}
+
+// End finish the span and export it if it's sampled, the span can only be ended once
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.lock.Unlock()
+
+	if data.Sampled {
+		s.tracer.processor.onEnd(&data)
+	}
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// ContextWithSpan returns a new context carrying the span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span in the context, nil if there is none
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent returns a new context carrying the span context of the upstream process
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// ParentFromContext returns the span context of the span in the context, or the remote parent if there is no span
+func ParentFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// StartSpan start a span as the child of the span or the remote parent in the context, a root span is started
+// if there is no parent. the returned context carries the new span, the span is nil if the tracing is disabled.
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return StartSpanWithParent(ctx, ParentFromContext(ctx), name, kind)
+}
+
+// StartSpanWithParent start a span as the child of the parent, a root span is started if the parent is invalid
+func StartSpanWithParent(ctx context.Context, parent SpanContext, name string, kind SpanKind) (context.Context, *Span) {
+	t := getTracer()
+	if t == nil {
+		return ctx, nil
+	}
+
+	data := SpanData{
+		Name:       name,
+		Kind:       kind,
+		StartTime:  time.Now(),
+		Attributes: make(map[string]interface{}),
+	}
+	if parent.IsValid() {
+		data.TraceID = parent.TraceID
+		data.ParentSpanID = parent.SpanID
+		data.Sampled = parent.Sampled
+	} else {
+		data.TraceID = newTraceID()
+		data.Sampled = t.sampled(data.TraceID)
+	}
+	data.SpanID = newSpanID()
+	data.Attributes[AttrServiceName] = t.serviceName
+
+	span := &Span{data: data, tracer: t}
+	return ContextWithSpan(ctx, span), span
+}
+
+func newTraceID() TraceID {
+	id := TraceID{}
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	id := SpanID{}
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// tracer the global tracer, nil means the tracing is disabled
+type tracer struct {
+	serviceName string
+	sampleRatio float64
+	processor   *batchProcessor
+}
+
+// sampled decide whether to sample the trace by the trace id, so all the processes make the same decision
+func (t *tracer) sampled(id TraceID) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	if t.sampleRatio <= 0 {
+		return false
+	}
+	bound := uint64(t.sampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:16])>>1 < bound
+}
+
+var (
+	globalLock   sync.RWMutex
+	globalTracer *tracer
+)
+
+func getTracer() *tracer {
+	globalLock.RLock()
+	defer globalLock.RUnlock()
+	return globalTracer
+}
+
+// Enabled returns whether the tracing is enabled
+func Enabled() bool {
+	return getTracer() != nil
+}
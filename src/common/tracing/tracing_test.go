@@ -0,0 +1,214 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceParent(t *testing.T) {
+	value := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceParent(value)
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, value, FormatTraceParent(sc))
+
+	sc, err = ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	require.NoError(t, err)
+	assert.False(t, sc.Sampled)
+
+	invalids := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	}
+	for _, invalid := range invalids {
+		_, err := ParseTraceParent(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// the future versions may carry more fields
+	_, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.NoError(t, err)
+}
+
+func TestDisabled(t *testing.T) {
+	Shutdown()
+	ctx, span := StartSpan(context.Background(), "disabled", SpanKindInternal)
+	assert.Nil(t, span)
+	assert.Nil(t, SpanFromContext(ctx))
+
+	// all the methods are safe on the nil span
+	span.SetAttribute("key", "value")
+	span.SetError(os.ErrNotExist)
+	span.End()
+	assert.False(t, span.SpanContext().IsValid())
+}
+
+func initFileTracer(t *testing.T, ratio float64) string {
+	dir, err := ioutil.TempDir("", "tracing")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "spans.json")
+	require.NoError(t, Init(Config{
+		Enable:        true,
+		ServiceName:   "test",
+		Exporter:      ExporterFile,
+		FilePath:      path,
+		SampleRatio:   ratio,
+		BatchSize:     10,
+		FlushInterval: time.Hour,
+	}))
+	return path
+}
+
+func readSpans(t *testing.T, path string) []FileSpan {
+	// shutdown flush the cached spans
+	Shutdown()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	spans := make([]FileSpan, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		span := FileSpan{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &span))
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+func TestSpanTree(t *testing.T) {
+	path := initFileTracer(t, 1)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	ctx, root := StartSpan(context.Background(), "root", SpanKindServer)
+	require.NotNil(t, root)
+	assert.True(t, root.SpanContext().Sampled)
+	_, child := StartSpan(ctx, "child", SpanKindClient)
+	child.SetAttribute(AttrDBCollection, "cc_HostBase")
+	child.SetError(os.ErrNotExist)
+	child.End()
+	child.End()
+	root.End()
+
+	spans := readSpans(t, path)
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, "root", spans[1].Name)
+	assert.Equal(t, spans[1].TraceID, spans[0].TraceID)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	assert.Empty(t, spans[1].ParentSpanID)
+	assert.Equal(t, StatusError, spans[0].Status.Code)
+	assert.Equal(t, SpanKindClient, spans[0].Kind)
+	assert.Equal(t, "test", spans[0].ServiceName)
+	require.Len(t, spans[0].Attributes, 1)
+	assert.Equal(t, AttrDBCollection, spans[0].Attributes[0].Key)
+}
+
+func TestSampling(t *testing.T) {
+	path := initFileTracer(t, 0)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	ctx, root := StartSpan(context.Background(), "root", SpanKindServer)
+	require.NotNil(t, root)
+	assert.False(t, root.SpanContext().Sampled)
+	_, child := StartSpan(ctx, "child", SpanKindInternal)
+	assert.False(t, child.SpanContext().Sampled)
+	child.End()
+	root.End()
+
+	// the sampled upstream decision is respected
+	remote := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	_, span := StartSpan(ContextWithRemoteParent(context.Background(), remote), "remote", SpanKindServer)
+	span.End()
+
+	spans := readSpans(t, path)
+	require.Len(t, spans, 1)
+	assert.Equal(t, "remote", spans[0].Name)
+	assert.Equal(t, remote.SpanID.String(), spans[0].ParentSpanID)
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	path := initFileTracer(t, 1)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	upstream := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	var downstream SpanContext
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the handlers pass the header to the downstream requests
+		downstream = Extract(r.Header)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v3/hosts/search", nil)
+	Inject(upstream, req.Header)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := readSpans(t, path)
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST /api/v3/hosts/search", spans[0].Name)
+	assert.Equal(t, upstream.TraceID.String(), spans[0].TraceID)
+	assert.Equal(t, upstream.SpanID.String(), spans[0].ParentSpanID)
+	assert.Equal(t, spans[0].SpanID, downstream.SpanID.String())
+	assert.Equal(t, StatusError, spans[0].Status.Code)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body otlpTraces
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	span := &SpanData{
+		SpanContext: SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true},
+		Name:        "span",
+		Kind:        SpanKindServer,
+		StartTime:   time.Now(),
+		EndTime:     time.Now(),
+		Attributes:  map[string]interface{}{AttrHTTPStatusCode: 200},
+	}
+	require.NoError(t, NewOTLPExporter(server.URL, "test").Export([]*SpanData{span}))
+	require.Len(t, body.ResourceSpans, 1)
+	assert.Equal(t, "test", body.ResourceSpans[0].Resource.Attributes[0].Value["stringValue"])
+	require.Len(t, body.ResourceSpans[0].ScopeSpans[0].Spans, 1)
+	assert.Equal(t, span.TraceID.String(), body.ResourceSpans[0].ScopeSpans[0].Spans[0].TraceID)
+	assert.Equal(t, "200", body.ResourceSpans[0].ScopeSpans[0].Spans[0].Attributes[0].Value["intValue"])
+
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failed.Close()
+	assert.Error(t, NewOTLPExporter(failed.URL, "test").Export([]*SpanData{span}))
+}
@@ -23,6 +23,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/tracing"
 	"configcenter/src/storage/dal"
 
 	"github.com/emicklei/go-restful"
@@ -101,6 +102,7 @@ func NewContextFromHTTPHeader(header http.Header) context.Context {
 	ctx = context.WithValue(ctx, common.ContextRequestIDField, rid)
 	ctx = context.WithValue(ctx, common.ContextRequestUserField, user)
 	ctx = context.WithValue(ctx, common.ContextRequestOwnerField, owner)
+	ctx = tracing.ContextWithHeader(ctx, header)
 	return ctx
 }
 
@@ -148,6 +150,7 @@ func GetDBContext(parent context.Context, header http.Header) context.Context {
 	ctx = context.WithValue(ctx, common.ContextRequestIDField, rid)
 	ctx = context.WithValue(ctx, common.ContextRequestUserField, user)
 	ctx = context.WithValue(ctx, common.ContextRequestOwnerField, owner)
+	ctx = tracing.ContextWithHeader(ctx, header)
 	return ctx
 }
 
@@ -14,10 +14,14 @@ package util
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 )
 
 // GetDailAddress returns the address for net.Dail
@@ -53,3 +57,50 @@ type closerWrapper struct {
 }
 
 func (c *closerWrapper) Close() error { return c.closeFunc() }
+
+// IsIP check if the value is an ipv4 or ipv6 address
+func IsIP(value string) bool {
+	return net.ParseIP(value) != nil
+}
+
+// IsCIDR check if the value is a network in cidr notation, like 10.0.0.0/8, the host bits must be zero
+func IsCIDR(value string) bool {
+	ip, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return false
+	}
+	return ip.Equal(ipNet.IP)
+}
+
+// IPv4CIDRRegexp returns the regular expression matching the ipv4 addresses in the cidr network,
+// the addresses may be separated by comma in one value, like the inner ip of a host.
+func IPv4CIDRRegexp(cidr string) (string, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	// ipv6 networks are rejected, including the ipv4-mapped ones like ::ffff:10.0.0.0/104
+	ones, bits := ipNet.Mask.Size()
+	ip := ipNet.IP.To4()
+	if ip == nil || bits != 8*net.IPv4len {
+		return "", fmt.Errorf("%s is an ipv6 network, only ipv4 network is supported", cidr)
+	}
+
+	octets := make([]string, 0)
+	for idx := 0; idx < net.IPv4len; idx++ {
+		switch {
+		case ones >= (idx+1)*8:
+			octets = append(octets, strconv.Itoa(int(ip[idx])))
+		case ones > idx*8:
+			count := 1 << uint((idx+1)*8-ones)
+			candidates := make([]string, 0)
+			for val := int(ip[idx]); val < int(ip[idx])+count; val++ {
+				candidates = append(candidates, strconv.Itoa(val))
+			}
+			octets = append(octets, "("+strings.Join(candidates, "|")+")")
+		default:
+			octets = append(octets, `\d{1,3}`)
+		}
+	}
+	return "(^|,)" + strings.Join(octets, `\.`) + "(,|$)", nil
+}
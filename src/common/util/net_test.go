@@ -16,6 +16,7 @@ import (
 	"bytes"
 	"io/ioutil"
 	"net/http"
+	"regexp"
 	"testing"
 
 	"github.com/emicklei/go-restful"
@@ -65,3 +66,49 @@ func TestPeekRequest(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "", string(ncontent))
 }
+
+func TestIPv4CIDRRegexp(t *testing.T) {
+	tests := []struct {
+		cidr     string
+		match    []string
+		notMatch []string
+		wantErr  bool
+	}{
+		{"10.0.0.0/8", []string{"10.1.2.3", "10.255.0.1"}, []string{"11.0.0.1", "110.0.0.1"}, false},
+		{"192.168.16.0/20", []string{"192.168.16.1", "192.168.31.255", "1.1.1.1,192.168.20.3"}, []string{"192.168.32.1", "192.168.15.1", "192.168.160.1"}, false},
+		{"172.16.1.5/32", []string{"172.16.1.5"}, []string{"172.16.1.50", "172.16.1.6"}, false},
+		{"0.0.0.0/0", []string{"1.2.3.4"}, []string{}, false},
+		{"fe80::/64", nil, nil, true},
+		{"::ffff:10.0.0.0/104", nil, nil, true},
+		{"::/0", nil, nil, true},
+		{"10.0.0.0", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			expr, err := IPv4CIDRRegexp(tt.cidr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IPv4CIDRRegexp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			reg := regexp.MustCompile(expr)
+			for _, ip := range tt.match {
+				require.True(t, reg.MatchString(ip), "%s should match %s", ip, tt.cidr)
+			}
+			for _, ip := range tt.notMatch {
+				require.False(t, reg.MatchString(ip), "%s should not match %s", ip, tt.cidr)
+			}
+		})
+	}
+}
+
+func TestIsCIDR(t *testing.T) {
+	require.True(t, IsCIDR("10.0.0.0/8"))
+	require.True(t, IsCIDR("fe80::/64"))
+	require.False(t, IsCIDR("10.0.0.1/8"))
+	require.False(t, IsCIDR("10.0.0.1"))
+	require.True(t, IsIP("10.0.0.1"))
+	require.True(t, IsIP("fe80::1"))
+	require.False(t, IsIP("10.0.0.256"))
+}
@@ -18,17 +18,22 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/expression"
 )
 
 // ValidPropertyOption valid property field option
 func ValidPropertyOption(propertyType string, option interface{}, errProxy errors.DefaultCCErrorIf) error {
 	switch propertyType {
-	case common.FieldTypeEnum:
+	case common.FieldTypeEnum, common.FieldTypeEnumMulti:
 		return ValidFieldTypeEnumOption(option, errProxy)
 	case common.FieldTypeInt:
 		return ValidFieldTypeIntOption(option, errProxy)
 	case common.FieldTypeList:
 		return ValidFieldTypeListOption(option, errProxy)
+	case common.FieldTypeReference:
+		return ValidFieldTypeReferenceOption(option, errProxy)
+	case common.FieldTypeComputed:
+		return ValidFieldTypeComputedOption(option, errProxy)
 	}
 	return nil
 }
@@ -156,6 +161,50 @@ func ValidFieldTypeListOption(option interface{}, errProxy errors.DefaultCCError
 	return nil
 }
 
+// ValidFieldTypeReferenceOption valid the reference option, which must contain the target object id
+func ValidFieldTypeReferenceOption(option interface{}, errProxy errors.DefaultCCErrorIf) error {
+	if nil == option {
+		return errProxy.Errorf(common.CCErrCommParamsLostField, "option")
+	}
+
+	mapOption, ok := option.(map[string]interface{})
+	if false == ok {
+		blog.Errorf(" option %v not reference option", option)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+	objID, ok := mapOption[common.BKObjIDField].(string)
+	if false == ok || 0 == len(objID) {
+		blog.Errorf(" option %v not reference option, reference option must have bk_obj_id", option)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option.bk_obj_id")
+	}
+
+	return nil
+}
+
+// ValidFieldTypeComputedOption valid the computed option, which must contain a valid expression
+func ValidFieldTypeComputedOption(option interface{}, errProxy errors.DefaultCCErrorIf) error {
+	if nil == option {
+		return errProxy.Errorf(common.CCErrCommParamsLostField, "option")
+	}
+
+	mapOption, ok := option.(map[string]interface{})
+	if false == ok {
+		blog.Errorf(" option %v not computed option", option)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+	expr, ok := mapOption["expression"].(string)
+	if false == ok || 0 == len(expr) {
+		blog.Errorf(" option %v not computed option, computed option must have expression", option)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option.expression")
+	}
+	if _, err := expression.Parse(expr); err != nil {
+		blog.Errorf(" option %v has invalid expression, err: %v", option, err)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option.expression")
+	}
+
+	return nil
+}
+
 // IsStrProperty  is string property
 func IsStrProperty(propertyType string) bool {
 	if common.FieldTypeLongChar == propertyType || common.FieldTypeSingleChar == propertyType {
@@ -34,6 +34,14 @@ func (ei errif) New(errCode int, msg string) error {
 	return nil
 }
 
+func (ei errif) CCError(errCode int) errors.CCErrorCoder {
+	return nil
+}
+
+func (ei errif) CCErrorf(errCode int, args ...interface{}) errors.CCErrorCoder {
+	return nil
+}
+
 func TestValidPropertyOption(t *testing.T) {
 	type args struct {
 		propertyType string
@@ -67,7 +67,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	service.Config = *process.Config
 	process.Core = engine
 	process.Service = service
-	process.ConfigCenter = configures.NewConfCenter(ctx, engine.ServiceManageClient())
+	process.ConfigCenter = configures.NewConfCenter(ctx, engine.ServiceManageClient().ConfRegDiscover())
 
 	// adminserver conf not depend discovery
 	err = process.ConfigCenter.Start(
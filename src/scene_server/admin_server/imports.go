@@ -73,4 +73,11 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201911141015"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201911141516"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201911261109"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201912021530"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201912041100"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201912101100"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201912121100"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201912161100"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201912171100"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.6.201912181100"
 )
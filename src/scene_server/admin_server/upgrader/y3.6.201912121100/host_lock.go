@@ -0,0 +1,129 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_6_201912121100
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// addHostLockHostID the host locks are checked by host id now, fill the host id of the existing locks
+// which only recorded the inner ip and cloud id, the locks of the hosts which do not exist any more are removed.
+func addHostLockHostID(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	locks := make([]struct {
+		IP      string `bson:"bk_host_innerip"`
+		CloudID int64  `bson:"bk_cloud_id"`
+		OwnerID string `bson:"bk_supplier_account"`
+	}, 0)
+	lockCond := mapstr.MapStr{
+		common.BKHostIDField: mapstr.MapStr{common.BKDBExists: false},
+	}
+	if err := db.Table(common.BKTableNameHostLock).Find(lockCond).All(ctx, &locks); err != nil {
+		return fmt.Errorf("find host lock failed, err: %v", err)
+	}
+
+	for _, lock := range locks {
+		hostCond := mapstr.MapStr{
+			common.BKHostInnerIPField: lock.IP,
+			common.BKCloudIDField:     lock.CloudID,
+			common.BKOwnerIDField:     lock.OwnerID,
+		}
+		hosts := make([]struct {
+			HostID int64 `bson:"bk_host_id"`
+		}, 0)
+		if err := db.Table(common.BKTableNameBaseHost).Find(hostCond).Fields(common.BKHostIDField).All(ctx, &hosts); err != nil {
+			return fmt.Errorf("find host of lock %s:%d failed, err: %v", lock.IP, lock.CloudID, err)
+		}
+
+		cond := mapstr.MapStr{
+			common.BKHostInnerIPField: lock.IP,
+			common.BKCloudIDField:     lock.CloudID,
+			common.BKOwnerIDField:     lock.OwnerID,
+		}
+		if len(hosts) == 0 {
+			if err := db.Table(common.BKTableNameHostLock).Delete(ctx, cond); err != nil {
+				return fmt.Errorf("delete lock of host %s:%d failed, err: %v", lock.IP, lock.CloudID, err)
+			}
+			continue
+		}
+		if err := db.Table(common.BKTableNameHostLock).Update(ctx, cond, mapstr.MapStr{common.BKHostIDField: hosts[0].HostID}); err != nil {
+			return fmt.Errorf("update lock of host %s:%d failed, err: %v", lock.IP, lock.CloudID, err)
+		}
+	}
+
+	if err := removeDuplicateHostLock(ctx, db); err != nil {
+		return err
+	}
+
+	// a host can be locked only once, the duplicate key error of the lock is treated as locked by others
+	index := dal.Index{
+		Name:       "bk_host_id",
+		Keys:       map[string]int32{common.BKHostIDField: 1},
+		Unique:     true,
+		Background: true,
+	}
+	existIndices, err := db.Table(common.BKTableNameHostLock).Indexes(ctx)
+	if err != nil {
+		return fmt.Errorf("get indexes of %s failed, err: %v", common.BKTableNameHostLock, err)
+	}
+	for _, idx := range existIndices {
+		if idx.Name != index.Name {
+			continue
+		}
+		if idx.Unique {
+			return nil
+		}
+		if err := db.Table(common.BKTableNameHostLock).DropIndex(ctx, idx.Name); err != nil {
+			return fmt.Errorf("drop index %s of %s failed, err: %v", idx.Name, common.BKTableNameHostLock, err)
+		}
+	}
+	if err := db.Table(common.BKTableNameHostLock).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+		return fmt.Errorf("create index of %s failed, err: %v", common.BKTableNameHostLock, err)
+	}
+	return nil
+}
+
+// removeDuplicateHostLock keep the earliest lock of each host, so that the unique index can be created
+func removeDuplicateHostLock(ctx context.Context, db dal.RDB) error {
+	locks := make([]metadata.HostLockData, 0)
+	if err := db.Table(common.BKTableNameHostLock).Find(nil).Sort("create_time").All(ctx, &locks); err != nil {
+		return fmt.Errorf("find host lock failed, err: %v", err)
+	}
+
+	earliest := make(map[int64]metadata.HostLockData)
+	duplicated := make(map[int64]bool)
+	for _, lock := range locks {
+		if _, exists := earliest[lock.HostID]; exists {
+			duplicated[lock.HostID] = true
+			continue
+		}
+		earliest[lock.HostID] = lock
+	}
+
+	for hostID := range duplicated {
+		cond := mapstr.MapStr{common.BKHostIDField: hostID}
+		if err := db.Table(common.BKTableNameHostLock).Delete(ctx, cond); err != nil {
+			return fmt.Errorf("delete locks of host %d failed, err: %v", hostID, err)
+		}
+		if err := db.Table(common.BKTableNameHostLock).Insert(ctx, earliest[hostID]); err != nil {
+			return fmt.Errorf("save lock of host %d failed, err: %v", hostID, err)
+		}
+	}
+	return nil
+}
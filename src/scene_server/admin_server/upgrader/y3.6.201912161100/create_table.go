@@ -0,0 +1,70 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package y3_6_201912161100
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+
+	"gopkg.in/mgo.v2"
+)
+
+func createServiceTemplateVersionTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tables := map[string][]dal.Index{
+		common.BKTableNameServiceTemplateVersion: {
+			{Name: "id", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true},
+			{Name: "service_template_id_version", Keys: map[string]int32{common.BKServiceTemplateIDField: 1, common.BKVersionField: 1}, Unique: true, Background: true},
+		},
+		common.BKTableNameModuleServiceTemplateVersion: {
+			{Name: "bk_module_id", Keys: map[string]int32{common.BKModuleIDField: 1}, Unique: true, Background: true},
+			{Name: "service_template_id", Keys: map[string]int32{common.BKServiceTemplateIDField: 1}, Background: true},
+		},
+		common.BKTableNameServiceTemplateRollout: {
+			{Name: "id", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true},
+			{Name: "service_template_id_status", Keys: map[string]int32{common.BKServiceTemplateIDField: 1, common.BKStatusField: 1}, Background: true},
+		},
+	}
+
+	for tableName, indices := range tables {
+		exists, err := db.HasTable(tableName)
+		if err != nil {
+			return fmt.Errorf("check HasTable failed, tableName: %s, err: %+v", tableName, err)
+		}
+		if exists == false {
+			if err = db.CreateTable(tableName); err != nil && !mgo.IsDup(err) {
+				return fmt.Errorf("CreateTable failed, tableName: %s, err: %+v", tableName, err)
+			}
+		}
+
+		existIndices, err := db.Table(tableName).Indexes(ctx)
+		if err != nil {
+			return fmt.Errorf("get indexes failed, tableName: %s, err:%+v", tableName, err)
+		}
+		existIdxMap := make(map[string]bool)
+		for _, idx := range existIndices {
+			existIdxMap[idx.Name] = true
+		}
+		for _, index := range indices {
+			if _, ok := existIdxMap[index.Name]; ok == true {
+				continue
+			}
+			if err = db.Table(tableName).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+				return fmt.Errorf("CreateIndex failed, tableName: %s, err:%+v", tableName, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_6_201912171100
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// addUpstreamTemplateIndex business templates are looked up by the global template they are created from
+func addUpstreamTemplateIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	index := dal.Index{
+		Name:       common.BKUpstreamTemplateIDField,
+		Keys:       map[string]int32{common.BKUpstreamTemplateIDField: 1},
+		Background: true,
+	}
+	for _, tableName := range []string{common.BKTableNameServiceTemplate, common.BKTableNameProcessTemplate} {
+		existIndices, err := db.Table(tableName).Indexes(ctx)
+		if err != nil {
+			return fmt.Errorf("get indexes failed, tableName: %s, err:%+v", tableName, err)
+		}
+		exist := false
+		for _, idx := range existIndices {
+			if idx.Name == index.Name {
+				exist = true
+				break
+			}
+		}
+		if exist == true {
+			continue
+		}
+		if err = db.Table(tableName).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			return fmt.Errorf("CreateIndex failed, tableName: %s, err:%+v", tableName, err)
+		}
+	}
+	return nil
+}
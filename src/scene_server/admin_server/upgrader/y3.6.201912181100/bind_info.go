@@ -0,0 +1,250 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_6_201912181100
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	mCommon "configcenter/src/scene_server/admin_server/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+type Attribute struct {
+	ID            int64       `field:"id" json:"id" bson:"id"`
+	OwnerID       string      `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+	ObjectID      string      `field:"bk_obj_id" json:"bk_obj_id" bson:"bk_obj_id"`
+	PropertyID    string      `field:"bk_property_id" json:"bk_property_id" bson:"bk_property_id"`
+	PropertyName  string      `field:"bk_property_name" json:"bk_property_name" bson:"bk_property_name"`
+	PropertyGroup string      `field:"bk_property_group" json:"bk_property_group" bson:"bk_property_group"`
+	PropertyIndex int64       `field:"bk_property_index" json:"bk_property_index" bson:"bk_property_index"`
+	Unit          string      `field:"unit" json:"unit" bson:"unit"`
+	Placeholder   string      `field:"placeholder" json:"placeholder" bson:"placeholder"`
+	IsEditable    bool        `field:"editable" json:"editable" bson:"editable"`
+	IsPre         bool        `field:"ispre" json:"ispre" bson:"ispre"`
+	IsRequired    bool        `field:"isrequired" json:"isrequired" bson:"isrequired"`
+	IsReadOnly    bool        `field:"isreadonly" json:"isreadonly" bson:"isreadonly"`
+	IsOnly        bool        `field:"isonly" json:"isonly" bson:"isonly"`
+	IsSystem      bool        `field:"bk_issystem" json:"bk_issystem" bson:"bk_issystem"`
+	IsAPI         bool        `field:"bk_isapi" json:"bk_isapi" bson:"bk_isapi"`
+	PropertyType  string      `field:"bk_property_type" json:"bk_property_type" bson:"bk_property_type"`
+	Option        interface{} `field:"option" json:"option" bson:"option"`
+	Description   string      `field:"description" json:"description" bson:"description"`
+	Creator       string      `field:"creator" json:"creator" bson:"creator"`
+	CreateTime    *time.Time  `json:"create_time" bson:"create_time"`
+	LastTime      *time.Time  `json:"last_time" bson:"last_time"`
+}
+
+// addProcessBindInfoAttribute add the bind_info attribute to process, a process can listen on several ip, port and protocol
+func addProcessBindInfoAttribute(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	now := time.Now()
+	row := &Attribute{
+		ObjectID:      common.BKInnerObjIDProc,
+		PropertyID:    common.BKProcBindInfo,
+		PropertyName:  "绑定信息",
+		IsRequired:    false,
+		IsOnly:        false,
+		IsEditable:    true,
+		PropertyGroup: mCommon.ProcPort,
+		PropertyType:  common.FieldTypeTable,
+		Option:        "",
+		OwnerID:       conf.OwnerID,
+		IsPre:         true,
+		IsReadOnly:    false,
+		IsAPI:         true,
+		CreateTime:    &now,
+		Creator:       common.CCSystemOperatorUserName,
+		LastTime:      &now,
+		Description:   "进程监听的IP、端口和协议列表，IP可以是具体的IP地址，也可以是第一内网IP等主机相关的IP",
+	}
+	uniqueKeys := []string{common.BKObjIDField, common.BKPropertyIDField, common.BKOwnerIDField}
+	if _, _, err := upgrader.Upsert(ctx, db, common.BKTableNameObjAttDes, row, "id", uniqueKeys, []string{}); err != nil {
+		return fmt.Errorf("upsert process bind info attribute failed, err: %v", err)
+	}
+	return nil
+}
+
+// templateBindInfo convert the legacy bind_ip, port and protocol of a process template to bind info,
+// it is used as default value only if all of the three properties are used as default value.
+func templateBindInfo(property *metadata.ProcessProperty) metadata.PropertyBindInfo {
+	asDefaultValue := metadata.IsAsDefaultValue(property.BindIP.AsDefaultValue) &&
+		metadata.IsAsDefaultValue(property.Port.AsDefaultValue) &&
+		metadata.IsAsDefaultValue(property.Protocol.AsDefaultValue)
+	bindInfo := metadata.PropertyBindInfo{
+		Value:          make([]metadata.ProcBindInfo, 0),
+		AsDefaultValue: &asDefaultValue,
+	}
+
+	var bindIP *string
+	if property.BindIP.Value != nil && len(*property.BindIP.Value) != 0 {
+		ip := string(*property.BindIP.Value)
+		bindIP = &ip
+	}
+	if info, ok := metadata.NewProcBindInfo(bindIP, property.Port.Value, property.Protocol.Value); ok == true {
+		bindInfo.Value = append(bindInfo.Value, info)
+	}
+	return bindInfo
+}
+
+func migrateProcessTemplateBindInfo(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	filter := map[string]interface{}{
+		"property." + common.BKProcBindInfo: map[string]interface{}{
+			common.BKDBExists: false,
+		},
+	}
+	limit := uint64(200)
+	for {
+		// migrated templates no longer match the filter, so always read from the beginning
+		templates := make([]metadata.ProcessTemplate, 0)
+		if err := db.Table(common.BKTableNameProcessTemplate).Find(filter).Limit(limit).All(ctx, &templates); err != nil {
+			blog.ErrorJSON("migrateProcessTemplateBindInfo failed, find process templates failed, filter: %s, err: %s", filter, err)
+			return fmt.Errorf("find process templates failed, err: %v", err)
+		}
+		if len(templates) == 0 {
+			break
+		}
+
+		for _, template := range templates {
+			if template.Property == nil {
+				template.Property = new(metadata.ProcessProperty)
+			}
+			cond := map[string]interface{}{
+				common.BKFieldID: template.ID,
+			}
+			data := map[string]interface{}{
+				"property." + common.BKProcBindInfo: templateBindInfo(template.Property),
+			}
+			if err := db.Table(common.BKTableNameProcessTemplate).Update(ctx, cond, data); err != nil {
+				blog.ErrorJSON("migrateProcessTemplateBindInfo failed, update process template failed, cond: %s, data: %s, err: %s", cond, data, err)
+				return fmt.Errorf("update process template %d failed, err: %v", template.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+type processBindFields struct {
+	ProcessID int64                  `bson:"bk_process_id"`
+	BindIP    *string                `bson:"bind_ip"`
+	Port      *string                `bson:"port"`
+	Protocol  *metadata.ProtocolType `bson:"protocol"`
+}
+
+// migrateProcessBindInfo convert the legacy bind_ip, port and protocol of processes to bind info, processes created
+// by a process template whose bind info is used as default value use the template's bind info directly.
+func migrateProcessBindInfo(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	filter := map[string]interface{}{
+		common.BKProcBindInfo: map[string]interface{}{
+			common.BKDBExists: false,
+		},
+	}
+	fields := []string{common.BKProcessIDField, common.BKBindIP, common.BKPort, common.BKProtocol}
+	limit := uint64(200)
+	for {
+		// migrated processes no longer match the filter, so always read from the beginning
+		processes := make([]processBindFields, 0)
+		if err := db.Table(common.BKTableNameBaseProcess).Find(filter).Fields(fields...).Limit(limit).All(ctx, &processes); err != nil {
+			blog.ErrorJSON("migrateProcessBindInfo failed, find processes failed, filter: %s, err: %s", filter, err)
+			return fmt.Errorf("find processes failed, err: %v", err)
+		}
+		if len(processes) == 0 {
+			break
+		}
+
+		processIDs := make([]int64, 0)
+		for _, process := range processes {
+			processIDs = append(processIDs, process.ProcessID)
+		}
+		templateBindInfos, err := getProcessTemplateBindInfos(ctx, db, processIDs)
+		if err != nil {
+			return err
+		}
+
+		for _, process := range processes {
+			bindInfo, exist := templateBindInfos[process.ProcessID]
+			if exist == false {
+				bindInfo = make([]metadata.ProcBindInfo, 0)
+				if info, ok := metadata.NewProcBindInfo(process.BindIP, process.Port, process.Protocol); ok == true {
+					bindInfo = append(bindInfo, info)
+				}
+			}
+			cond := map[string]interface{}{
+				common.BKProcessIDField: process.ProcessID,
+			}
+			data := map[string]interface{}{
+				common.BKProcBindInfo: bindInfo,
+			}
+			if err := db.Table(common.BKTableNameBaseProcess).Update(ctx, cond, data); err != nil {
+				blog.ErrorJSON("migrateProcessBindInfo failed, update process failed, cond: %s, data: %s, err: %s", cond, data, err)
+				return fmt.Errorf("update process %d failed, err: %v", process.ProcessID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// getProcessTemplateBindInfos get the template bind info of processes whose template's bind info is used as default value
+func getProcessTemplateBindInfos(ctx context.Context, db dal.RDB, processIDs []int64) (map[int64][]metadata.ProcBindInfo, error) {
+	result := make(map[int64][]metadata.ProcBindInfo)
+
+	relationFilter := map[string]interface{}{
+		common.BKProcessIDField: map[string]interface{}{
+			common.BKDBIN: processIDs,
+		},
+		common.BKProcessTemplateIDField: map[string]interface{}{
+			common.BKDBNE: common.ServiceTemplateIDNotSet,
+		},
+	}
+	relations := make([]metadata.ProcessInstanceRelation, 0)
+	if err := db.Table(common.BKTableNameProcessInstanceRelation).Find(relationFilter).All(ctx, &relations); err != nil {
+		blog.ErrorJSON("getProcessTemplateBindInfos failed, find process instance relations failed, filter: %s, err: %s", relationFilter, err)
+		return nil, fmt.Errorf("find process instance relations failed, err: %v", err)
+	}
+	if len(relations) == 0 {
+		return result, nil
+	}
+
+	templateIDs := make([]int64, 0)
+	for _, relation := range relations {
+		templateIDs = append(templateIDs, relation.ProcessTemplateID)
+	}
+	templateFilter := map[string]interface{}{
+		common.BKFieldID: map[string]interface{}{
+			common.BKDBIN: templateIDs,
+		},
+	}
+	templates := make([]metadata.ProcessTemplate, 0)
+	if err := db.Table(common.BKTableNameProcessTemplate).Find(templateFilter).All(ctx, &templates); err != nil {
+		blog.ErrorJSON("getProcessTemplateBindInfos failed, find process templates failed, filter: %s, err: %s", templateFilter, err)
+		return nil, fmt.Errorf("find process templates failed, err: %v", err)
+	}
+	templateBindInfos := make(map[int64][]metadata.ProcBindInfo)
+	for _, template := range templates {
+		if template.Property == nil || metadata.IsAsDefaultValue(template.Property.BindInfo.AsDefaultValue) == false {
+			continue
+		}
+		templateBindInfos[template.ID] = template.Property.BindInfo.Value
+	}
+
+	for _, relation := range relations {
+		if bindInfo, exist := templateBindInfos[relation.ProcessTemplateID]; exist == true {
+			result[relation.ProcessID] = bindInfo
+		}
+	}
+	return result, nil
+}
@@ -38,7 +38,7 @@ func NewServerOption() *ServerOption {
 func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.AddrPort, "addrport", "127.0.0.1:50006", "The ip address and port for the serve on")
 
-	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181")
+	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181, etcd://127.0.0.1:2379 for etcd, or file:///data/cmdb/discovery.yaml for the static discovery")
 	fs.StringVar(&s.ServConf.ExConfig, "config", "", "The config path. e.g conf/api.conf")
 	fs.Var(auth.EnableAuthFlag, "enable-auth", "The auth center enable status, true for enabled, false for disabled")
 }
@@ -51,9 +51,55 @@ type Config struct {
 	NetCollectRedis SnapRedis
 	Esb             esbutil.EsbConfig
 	AuthConfig      authcenter.AuthConfig
+	HostSnap        HostSnap
+	Push            Push
+	Kafka           Kafka
+	Snmp            Snmp
 }
 
 type SnapRedis struct {
 	redis.Config
 	Enable string
 }
+
+// HostSnap the config of the host snapshot
+type HostSnap struct {
+	// HistoryRetentionDays the days to keep the snapshot history
+	HistoryRetentionDays int
+	// HistoryMaxCount the max count of snapshot history kept for each host
+	HistoryMaxCount int
+}
+
+// Push the config of the reports pushed by http
+type Push struct {
+	Enable string
+	// Tokens the reporter must carry one of the tokens
+	Tokens []string
+}
+
+// Kafka the config of the kafka message source
+type Kafka struct {
+	Enable  string
+	Brokers []string
+	GroupID string
+	// SnapshotTopic the topic of the host snapshot
+	SnapshotTopic string
+	// NetcollectTopic the topic of the network device data
+	NetcollectTopic string
+	// Workers the count of the messages handled concurrently by each process
+	Workers int
+}
+
+// Snmp the config of the built-in snmp poller
+type Snmp struct {
+	Enable string
+	// Worker the count of the addresses polled concurrently
+	Worker int
+	// Timeout the timeout seconds of one snmp request
+	Timeout int
+	Retries int
+	// MaxTargets the max count of the addresses scanned for each collector
+	MaxTargets int
+	// Period the default poll period, like 30M or 1H
+	Period string
+}
@@ -17,6 +17,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,9 +30,13 @@ import (
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/types"
+	"configcenter/src/common/util"
 	"configcenter/src/common/version"
 	"configcenter/src/scene_server/datacollection/app/options"
 	"configcenter/src/scene_server/datacollection/datacollection"
+	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
+	"configcenter/src/scene_server/datacollection/datacollection/netcollect"
+	"configcenter/src/scene_server/datacollection/datacollection/source"
 	"configcenter/src/scene_server/datacollection/logics"
 	svc "configcenter/src/scene_server/datacollection/service"
 	"configcenter/src/storage/dal"
@@ -143,10 +149,48 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 			datacollection.AuthManager = *extensions.NewAuthManager(engine.CoreAPI, authorize)
 		}
 
+		datacollection.HistoryRetention = hostsnap.HistoryRetention{
+			Days:     process.Config.HostSnap.HistoryRetentionDays,
+			MaxCount: process.Config.HostSnap.HistoryMaxCount,
+		}
+		datacollection.EnablePush = process.Config.Push.Enable == "true"
+		if process.Config.Kafka.Enable == "true" {
+			blog.Infof("[data-collection] kafka message source enabled, brokers: %v", process.Config.Kafka.Brokers)
+			datacollection.Kafka = &source.KafkaConfig{
+				Brokers:         process.Config.Kafka.Brokers,
+				GroupID:         process.Config.Kafka.GroupID,
+				SnapshotTopic:   process.Config.Kafka.SnapshotTopic,
+				NetcollectTopic: process.Config.Kafka.NetcollectTopic,
+				Workers:         process.Config.Kafka.Workers,
+			}
+		}
+
+		if process.Config.Snmp.Enable == "true" {
+			blog.Info("[data-collection] built-in snmp poller enabled")
+			datacollection.Snmp = &netcollect.SnmpOption{
+				Worker:     process.Config.Snmp.Worker,
+				Timeout:    time.Duration(process.Config.Snmp.Timeout) * time.Second,
+				Retries:    process.Config.Snmp.Retries,
+				MaxTargets: process.Config.Snmp.MaxTargets,
+			}
+			if len(process.Config.Snmp.Period) != 0 {
+				period, err := util.FormatPeriod(process.Config.Snmp.Period)
+				if err != nil {
+					return fmt.Errorf("snmp period %s invalid, err: %v", process.Config.Snmp.Period, err)
+				}
+				datacollection.Snmp.Period = netcollect.ParsePeriod(period, 0)
+			}
+			process.Service.Logics.BuiltinSnmp = true
+		}
+
 		err = datacollection.Run(redisCli, snapcli, disCli, netCli)
 		if err != nil {
 			return fmt.Errorf("run datacollection routine failed %s", err.Error())
 		}
+		if datacollection.EnablePush {
+			blog.Info("[data-collection] http report enabled")
+			process.Service.SetPusher(datacollection, process.Config.Push.Tokens)
+		}
 		break
 	}
 
@@ -208,6 +252,40 @@ func (h *DCServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		h.Config.Esb.AppCode = current.ConfigMap[esbPrefix+".appCode"]
 		h.Config.Esb.AppSecret = current.ConfigMap[esbPrefix+".appSecret"]
 
+		hostSnapPrefix := "hostsnap"
+		h.Config.HostSnap.HistoryRetentionDays, _ = strconv.Atoi(current.ConfigMap[hostSnapPrefix+".historyRetentionDays"])
+		h.Config.HostSnap.HistoryMaxCount, _ = strconv.Atoi(current.ConfigMap[hostSnapPrefix+".historyMaxCount"])
+
+		pushPrefix := "push"
+		h.Config.Push.Enable = current.ConfigMap[pushPrefix+".enable"]
+		h.Config.Push.Tokens = make([]string, 0)
+		for _, token := range strings.Split(current.ConfigMap[pushPrefix+".tokens"], ",") {
+			if token = strings.TrimSpace(token); len(token) != 0 {
+				h.Config.Push.Tokens = append(h.Config.Push.Tokens, token)
+			}
+		}
+
+		kafkaPrefix := "kafka"
+		h.Config.Kafka.Enable = current.ConfigMap[kafkaPrefix+".enable"]
+		h.Config.Kafka.Brokers = make([]string, 0)
+		for _, broker := range strings.Split(current.ConfigMap[kafkaPrefix+".brokers"], ",") {
+			if broker = strings.TrimSpace(broker); len(broker) != 0 {
+				h.Config.Kafka.Brokers = append(h.Config.Kafka.Brokers, broker)
+			}
+		}
+		h.Config.Kafka.GroupID = current.ConfigMap[kafkaPrefix+".groupID"]
+		h.Config.Kafka.SnapshotTopic = current.ConfigMap[kafkaPrefix+".snapshotTopic"]
+		h.Config.Kafka.NetcollectTopic = current.ConfigMap[kafkaPrefix+".netcollectTopic"]
+		h.Config.Kafka.Workers, _ = strconv.Atoi(current.ConfigMap[kafkaPrefix+".workers"])
+
+		snmpPrefix := "snmp"
+		h.Config.Snmp.Enable = current.ConfigMap[snmpPrefix+".enable"]
+		h.Config.Snmp.Worker, _ = strconv.Atoi(current.ConfigMap[snmpPrefix+".worker"])
+		h.Config.Snmp.Timeout, _ = strconv.Atoi(current.ConfigMap[snmpPrefix+".timeout"])
+		h.Config.Snmp.Retries, _ = strconv.Atoi(current.ConfigMap[snmpPrefix+".retries"])
+		h.Config.Snmp.MaxTargets, _ = strconv.Atoi(current.ConfigMap[snmpPrefix+".maxTargets"])
+		h.Config.Snmp.Period = current.ConfigMap[snmpPrefix+".period"]
+
 		var err error
 		authPrefix := "auth"
 		h.Config.AuthConfig, err = authcenter.ParseConfigFromKV(authPrefix, current.ConfigMap)
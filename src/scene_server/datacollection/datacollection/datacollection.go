@@ -26,6 +26,7 @@ import (
 	"configcenter/src/scene_server/datacollection/datacollection/hostsnap"
 	"configcenter/src/scene_server/datacollection/datacollection/middleware"
 	"configcenter/src/scene_server/datacollection/datacollection/netcollect"
+	"configcenter/src/scene_server/datacollection/datacollection/source"
 	"configcenter/src/storage/dal"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -38,6 +39,16 @@ type DataCollection struct {
 	ctx         context.Context
 	registry    prometheus.Registerer
 	AuthManager extensions.AuthManager
+	// HistoryRetention the retention policy of the host snapshot history
+	HistoryRetention hostsnap.HistoryRetention
+	// EnablePush accept the host snapshot reported by http, even if the snap redis is not configured
+	EnablePush bool
+	// Kafka consume the host snapshot and network device data from kafka instead of redis when it's set
+	Kafka *source.KafkaConfig
+	// Snmp poll the network devices over snmp by datacollection itself when it's set
+	Snmp *netcollect.SnmpOption
+
+	manager *Manager
 }
 
 func NewDataCollection(ctx context.Context, backbone *backbone.Engine, db dal.RDB, registry prometheus.Registerer) *DataCollection {
@@ -59,30 +70,76 @@ func (d *DataCollection) Run(redisCli, snapCli, disCli, netCli *redis.Client) er
 	}
 
 	manager := NewManager()
+	d.manager = manager
 
-	if snapCli != nil {
-		snapChanName := d.getSnapChanName(defaultAppID)
-		hostsnapCollector := hostsnap.NewHostSnap(d.ctx, redisCli, d.db, d.Engine, d.AuthManager)
-		snapPorter := BuildChanPorter("hostsnap", hostsnapCollector, redisCli, snapCli, snapChanName, hostsnap.MockMessage, d.registry, d.Engine)
+	var snapTopic, netTopic string
+	if d.Kafka != nil {
+		snapTopic, netTopic = d.Kafka.SnapshotTopic, d.Kafka.NetcollectTopic
+	}
+
+	snapSource, err := d.newSource(snapCli, d.getSnapChanName(defaultAppID), snapTopic)
+	if err != nil {
+		return fmt.Errorf("new hostsnap message source failed, err: %v", err)
+	}
+	if snapSource != nil || d.EnablePush {
+		hostsnapCollector := hostsnap.NewHostSnap(d.ctx, redisCli, d.db, d.Engine, d.AuthManager, d.HistoryRetention)
+		snapPorter := BuildChanPorter(d.ctx, "hostsnap", hostsnapCollector, redisCli, snapSource, hostsnap.MockMessage, d.registry, d.Engine)
 		manager.AddPorter(snapPorter)
 	}
 	if disCli != nil {
-		discoverChanName := d.getDiscoverChanName(defaultAppID)
+		discoverSource := source.NewRedisSource(disCli, d.getDiscoverChanName(defaultAppID))
 		middlewareCollector := middleware.NewDiscover(d.ctx, redisCli, d.Engine, d.AuthManager)
-		middlewarePorter := BuildChanPorter("middleware", middlewareCollector, redisCli, disCli, discoverChanName, middleware.MockMessage, d.registry, d.Engine)
+		middlewarePorter := BuildChanPorter(d.ctx, "middleware", middlewareCollector, redisCli, discoverSource, middleware.MockMessage, d.registry, d.Engine)
 		manager.AddPorter(middlewarePorter)
 	}
-	if netCli != nil {
-		netDevChanName := d.getNetcollectChanName(defaultAppID)
-		netCollector := netcollect.NewNetCollect(d.ctx, d.db, d.AuthManager)
-		netCollectPorter := BuildChanPorter("netcollect", netCollector, redisCli, netCli, netDevChanName, netcollect.MockMessage, d.registry, d.Engine)
+	netSource, err := d.newSource(netCli, d.getNetcollectChanName(defaultAppID), netTopic)
+	if err != nil {
+		return fmt.Errorf("new netcollect message source failed, err: %v", err)
+	}
+	netCollector := netcollect.NewNetCollect(d.ctx, d.db, d.AuthManager)
+	if netSource != nil {
+		netCollectPorter := BuildChanPorter(d.ctx, "netcollect", netCollector, redisCli, netSource, netcollect.MockMessage, d.registry, d.Engine)
 		manager.AddPorter(netCollectPorter)
 	}
+	if d.Snmp != nil {
+		poller := netcollect.NewSnmpPoller(d.ctx, d.db, netCollector, *d.Snmp, d.Engine.ServiceManageInterface.IsMaster)
+		go poller.Run()
+	}
 
 	blog.Infof("data-collection started")
 	return nil
 }
 
+// Push push the messages reported by http to the porter, returns the count of the accepted messages.
+// the messages are accepted in order, ErrQueueFull is returned when the porter is busy.
+func (d *DataCollection) Push(name string, mesgs ...string) (int, error) {
+	if d.manager == nil {
+		return 0, ErrPorterNotFound
+	}
+	porter, ok := d.manager.GetPorter(name)
+	if !ok {
+		return 0, ErrPorterNotFound
+	}
+	for index, mesg := range mesgs {
+		if err := porter.Push(mesg); err != nil {
+			return index, err
+		}
+	}
+	return len(mesgs), nil
+}
+
+// newSource create the message source of the porter, the kafka topic takes precedence over the redis channels,
+// returns nil when neither of them is configured.
+func (d *DataCollection) newSource(cli *redis.Client, channels []string, topic string) (source.MessageSource, error) {
+	if len(topic) > 0 {
+		return source.NewKafkaSource(*d.Kafka, topic)
+	}
+	if cli != nil {
+		return source.NewRedisSource(cli, channels), nil
+	}
+	return nil, nil
+}
+
 func (d *DataCollection) getNetcollectChanName(defaultAppID string) []string {
 	return []string{"netdevice2"}
 }
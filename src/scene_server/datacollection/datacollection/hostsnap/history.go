@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	defaultHistoryRetentionDays = 30
+	defaultHistoryMaxCount      = 100
+)
+
+var (
+	cleanHistoryInterval = time.Hour
+)
+
+// HistoryRetention the retention policy of the host snapshot history
+type HistoryRetention struct {
+	// Days the history older than the days is removed
+	Days int
+	// MaxCount the max count of history kept for each host, the oldest is removed first
+	MaxCount int
+}
+
+func (r HistoryRetention) days() int {
+	if r.Days <= 0 {
+		return defaultHistoryRetentionDays
+	}
+	return r.Days
+}
+
+func (r HistoryRetention) maxCount() int {
+	if r.MaxCount <= 0 {
+		return defaultHistoryMaxCount
+	}
+	return r.MaxCount
+}
+
+// snapChanges returns the host attributes which will be changed by the setter
+func snapChanges(setter map[string]interface{}, host *HostInst) []metadata.HostSnapChange {
+	keys := make([]string, 0)
+	for key, value := range setter {
+		if host.get(key) != value {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := make([]metadata.HostSnapChange, len(keys))
+	for index, key := range keys {
+		changes[index] = metadata.HostSnapChange{
+			PropertyID: key,
+			PreValue:   host.get(key),
+			CurValue:   setter[key],
+		}
+	}
+	return changes
+}
+
+// saveHistory save the changes of the host, the history beyond the retention is removed by cleanHistoryLoop,
+// so that the snapshot handling only costs one insert.
+func (h *HostSnap) saveHistory(hostID int64, ownerID string, changes []metadata.HostSnapChange) {
+	if len(changes) == 0 {
+		return
+	}
+	history := metadata.HostSnapHistory{
+		HostID:     hostID,
+		OwnerID:    ownerID,
+		Changes:    changes,
+		CreateTime: time.Now(),
+	}
+	if err := h.db.Table(common.BKTableNameHostSnapHistory).Insert(h.ctx, history); err != nil {
+		blog.Errorf("[data-collection][hostsnap] save snapshot history of host %d failed, err: %v", hostID, err)
+	}
+}
+
+// cleanHistoryLoop remove the expired history and the history beyond the max count periodically,
+// only the master does the cleaning.
+func (h *HostSnap) cleanHistoryLoop() {
+	for {
+		if h.ServiceManageInterface.IsMaster() {
+			h.removeExpiredHistory()
+			h.removeExceededHistory()
+		}
+		time.Sleep(cleanHistoryInterval)
+	}
+}
+
+func (h *HostSnap) removeExpiredHistory() {
+	expireTime := time.Now().AddDate(0, 0, -h.historyRetention.days())
+	cond := map[string]interface{}{
+		common.CreateTimeField: map[string]interface{}{common.BKDBLT: expireTime},
+	}
+	if err := h.db.Table(common.BKTableNameHostSnapHistory).Delete(h.ctx, cond); err != nil {
+		blog.Errorf("[data-collection][hostsnap] remove snapshot history before %s failed, err: %v", expireTime, err)
+	}
+}
+
+type historyCount struct {
+	HostID int64 `bson:"_id"`
+	Count  int64 `bson:"count"`
+}
+
+// removeExceededHistory remove the oldest history of the hosts which have more history than the max count
+func (h *HostSnap) removeExceededHistory() {
+	maxCount := h.historyRetention.maxCount()
+	pipeline := []map[string]interface{}{
+		{"$group": map[string]interface{}{"_id": "$" + common.BKHostIDField, "count": map[string]interface{}{"$sum": 1}}},
+		{"$match": map[string]interface{}{"count": map[string]interface{}{common.BKDBGT: maxCount}}},
+	}
+	counts := make([]historyCount, 0)
+	if err := h.db.Table(common.BKTableNameHostSnapHistory).AggregateAll(h.ctx, pipeline, &counts); err != nil {
+		blog.Errorf("[data-collection][hostsnap] count snapshot history of hosts failed, err: %v", err)
+		return
+	}
+
+	for _, count := range counts {
+		// find the create time of the oldest history which should be kept
+		cond := map[string]interface{}{common.BKHostIDField: count.HostID}
+		kept := make([]metadata.HostSnapHistory, 0)
+		err := h.db.Table(common.BKTableNameHostSnapHistory).Find(cond).Fields(common.CreateTimeField).
+			Sort("-"+common.CreateTimeField).Start(uint64(maxCount-1)).Limit(1).All(h.ctx, &kept)
+		if err != nil || len(kept) == 0 {
+			blog.Errorf("[data-collection][hostsnap] get snapshot history of host %d failed, err: %v", count.HostID, err)
+			continue
+		}
+		cond[common.CreateTimeField] = map[string]interface{}{common.BKDBLT: kept[0].CreateTime}
+		if err := h.db.Table(common.BKTableNameHostSnapHistory).Delete(h.ctx, cond); err != nil {
+			blog.Errorf("[data-collection][hostsnap] remove snapshot history of host %d failed, err: %v", count.HostID, err)
+		}
+	}
+}
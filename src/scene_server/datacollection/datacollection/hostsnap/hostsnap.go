@@ -49,6 +49,11 @@ type HostSnap struct {
 	cachelock sync.RWMutex
 	ctx       context.Context
 	db        dal.RDB
+
+	mappings    *snapMappings
+	mappingLock sync.RWMutex
+
+	historyRetention HistoryRetention
 }
 
 type Cache struct {
@@ -56,7 +61,8 @@ type Cache struct {
 	flag  bool
 }
 
-func NewHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB, engine *backbone.Engine, authManager extensions.AuthManager) *HostSnap {
+func NewHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB, engine *backbone.Engine, authManager extensions.AuthManager,
+	historyRetention HistoryRetention) *HostSnap {
 	header := http.Header{}
 	header.Add(common.BKHTTPOwnerID, common.BKDefaultOwnerID)
 	header.Add(common.BKHTTPHeaderUser, common.CCSystemCollectorUserName)
@@ -69,10 +75,13 @@ func NewHostSnap(ctx context.Context, redisCli *redis.Client, db dal.RDB, engine
 			cache: map[bool]*HostCache{},
 			flag:  false,
 		},
-		authManager: authManager,
-		Engine:      engine,
+		authManager:      authManager,
+		Engine:           engine,
+		historyRetention: historyRetention,
 	}
 	go h.fetchDBLoop()
+	go h.fetchMappingLoop()
+	go h.cleanHistoryLoop()
 	return h
 }
 
@@ -113,11 +122,13 @@ func (h *HostSnap) Analyze(mesg string) error {
 		blog.Warnf("[data-collection][hostsnap] outerip is not string, %s", val.String())
 	}
 	setter := parseSetter(&val, innerIp, outIp)
+	h.applyMappings(&val, setter, host)
 	// no need to update
 	if !needToUpdate(setter, host) {
 		return nil
 	}
 
+	changes := snapChanges(setter, host)
 	cond := mapstr.New()
 	cond.Set(common.BKHostIDField, hostID)
 	opt := &metadata.UpdateOption{
@@ -133,6 +144,7 @@ func (h *HostSnap) Analyze(mesg string) error {
 		blog.Errorf("failed to update host, error msg: %v", res.ErrMsg)
 		return fmt.Errorf("UpdateInstacne http response error,err code: %d, err msg: %v, opt: %v", res.Code, res.ErrMsg, opt)
 	}
+	h.saveHistory(hostIdInt64, fmt.Sprint(host.get(common.BKOwnerIDField)), changes)
 	defer copyVal(setter, host)
 
 	// add auditLog
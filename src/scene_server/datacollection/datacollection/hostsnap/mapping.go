@@ -0,0 +1,231 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hostsnap
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	fetchMappingInterval = time.Minute
+)
+
+// snapMappings the enabled mappings and the host attribute types, grouped by the supplier account
+type snapMappings struct {
+	mappings      map[string][]metadata.HostSnapMapping
+	propertyTypes map[string]map[string]string
+}
+
+func (h *HostSnap) getMappings() *snapMappings {
+	h.mappingLock.RLock()
+	defer h.mappingLock.RUnlock()
+	return h.mappings
+}
+
+// fetchMappingLoop reload the mappings periodically, so that the changes take effect without restart
+func (h *HostSnap) fetchMappingLoop() {
+	for {
+		mappings, err := h.fetchMappings()
+		if err != nil {
+			blog.Errorf("[data-collection][hostsnap] fetch host snapshot mappings failed, err: %v", err)
+		} else {
+			h.mappingLock.Lock()
+			h.mappings = mappings
+			h.mappingLock.Unlock()
+		}
+		time.Sleep(fetchMappingInterval)
+	}
+}
+
+func (h *HostSnap) fetchMappings() (*snapMappings, error) {
+	mappingList := make([]metadata.HostSnapMapping, 0)
+	cond := map[string]interface{}{"enabled": true}
+	if err := h.db.Table(common.BKTableNameHostSnapMapping).Find(cond).Sort(common.BKFieldID).All(h.ctx, &mappingList); err != nil {
+		return nil, err
+	}
+
+	mappings := &snapMappings{
+		mappings:      make(map[string][]metadata.HostSnapMapping),
+		propertyTypes: make(map[string]map[string]string),
+	}
+	if len(mappingList) == 0 {
+		return mappings, nil
+	}
+	for _, mapping := range mappingList {
+		mappings.mappings[mapping.OwnerID] = append(mappings.mappings[mapping.OwnerID], mapping)
+	}
+
+	attrs := make([]metadata.Attribute, 0)
+	attrCond := map[string]interface{}{common.BKObjIDField: common.BKInnerObjIDHost}
+	if err := h.db.Table(common.BKTableNameObjAttDes).Find(attrCond).All(h.ctx, &attrs); err != nil {
+		return nil, err
+	}
+	for _, attr := range attrs {
+		if _, exists := mappings.propertyTypes[attr.OwnerID]; !exists {
+			mappings.propertyTypes[attr.OwnerID] = make(map[string]string)
+		}
+		mappings.propertyTypes[attr.OwnerID][attr.PropertyID] = attr.PropertyType
+	}
+	return mappings, nil
+}
+
+// applyMappings add the values of the configured mappings to the setter
+func (h *HostSnap) applyMappings(val *gjson.Result, setter map[string]interface{}, host *HostInst) {
+	mappings := h.getMappings()
+	if mappings == nil {
+		return
+	}
+	ownerID := fmt.Sprint(host.get(common.BKOwnerIDField))
+	for _, mapping := range mappings.mappings[ownerID] {
+		value, err := mappingValue(val, mapping, mappings.propertyTypes[ownerID][mapping.PropertyID])
+		if err != nil {
+			blog.Warnf("[data-collection][hostsnap] apply mapping %d to %s failed, err: %v", mapping.ID, mapping.PropertyID, err)
+			continue
+		}
+
+		switch mapping.Overwrite {
+		case metadata.HostSnapOverwriteIfEmpty:
+			if !isEmptyValue(host.get(mapping.PropertyID)) {
+				continue
+			}
+		case metadata.HostSnapOverwriteNotEmpty:
+			if isEmptyValue(value) {
+				continue
+			}
+		}
+		setter[mapping.PropertyID] = value
+	}
+}
+
+// mappingValue get the value from the snapshot and convert it to the type of the host attribute
+func mappingValue(val *gjson.Result, mapping metadata.HostSnapMapping, propertyType string) (interface{}, error) {
+	result := val.Get(mapping.Path)
+	values := []gjson.Result{result}
+	if result.IsArray() {
+		values = result.Array()
+	}
+	if !result.Exists() {
+		values = []gjson.Result{}
+	}
+
+	var value interface{}
+	switch mapping.Transform {
+	case metadata.HostSnapTransformSum, metadata.HostSnapTransformMax, metadata.HostSnapTransformMin:
+		var number float64
+		for index, item := range values {
+			switch {
+			case index == 0:
+				number = item.Float()
+			case mapping.Transform == metadata.HostSnapTransformSum:
+				number += item.Float()
+			case mapping.Transform == metadata.HostSnapTransformMax:
+				number = math.Max(number, item.Float())
+			default:
+				number = math.Min(number, item.Float())
+			}
+		}
+		value = number
+	case metadata.HostSnapTransformCount:
+		value = float64(len(values))
+	case metadata.HostSnapTransformJoin:
+		items := make([]string, len(values))
+		for index, item := range values {
+			items[index] = item.String()
+		}
+		value = strings.Join(items, ",")
+	default:
+		if len(values) == 0 {
+			value = nil
+		} else {
+			value = values[0].Value()
+		}
+	}
+
+	if len(mapping.UnitFrom) != 0 && value != nil {
+		factor, err := metadata.HostSnapUnitFactor(mapping.UnitFrom, mapping.UnitTo)
+		if err != nil {
+			return nil, err
+		}
+		number, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		value = number * factor
+	}
+
+	return convertValue(value, propertyType)
+}
+
+// convertValue convert the value to the type of the host attribute
+func convertValue(value interface{}, propertyType string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch propertyType {
+	case common.FieldTypeInt:
+		number, err := toFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		return int64(number), nil
+	case common.FieldTypeFloat:
+		return toFloat(value)
+	case common.FieldTypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		default:
+			return strconv.ParseBool(fmt.Sprint(v))
+		}
+	default:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+	}
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		return 0, fmt.Errorf("bool value %v is not a number", v)
+	default:
+		return strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(v)), 64)
+	}
+}
+
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if s, ok := value.(string); ok && len(s) == 0 {
+		return true
+	}
+	return false
+}
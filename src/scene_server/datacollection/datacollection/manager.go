@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"net/http"
 	"runtime/debug"
+	"sync"
 
 	"configcenter/src/common/blog"
 	"configcenter/src/common/version"
@@ -26,6 +27,7 @@ import (
 type Manager struct {
 	porterC chan Porter
 	porters map[string]Porter
+	lock    sync.RWMutex
 }
 
 func NewManager() *Manager {
@@ -42,7 +44,9 @@ func NewManager() *Manager {
 
 func (m *Manager) run() error {
 	for porter := range m.porterC {
+		m.lock.Lock()
 		m.porters[porter.Name()] = porter
+		m.lock.Unlock()
 		go m.porterLoop(porter)
 	}
 
@@ -63,7 +67,7 @@ func (m *Manager) mockServer() {
 			resp.WriteHeader(400)
 			return
 		}
-		if porter, ok := m.porters[mockMSG.Name]; ok {
+		if porter, ok := m.GetPorter(mockMSG.Name); ok {
 			if err := porter.Mock(mockMSG.Message); err != nil {
 				fmt.Fprintf(resp, "mock failed: %v", err)
 				resp.WriteHeader(400)
@@ -85,13 +89,25 @@ func (m *Manager) AddPorter(p Porter) {
 	m.porterC <- p
 }
 
+// GetPorter get the running porter by name
+func (m *Manager) GetPorter(name string) (Porter, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	porter, ok := m.porters[name]
+	return porter, ok
+}
+
 func (m *Manager) porterLoop(p Porter) {
 	for {
-		m.runPorter(p)
+		if stopped := m.runPorter(p); stopped {
+			blog.Infof("[manager] porter [%s] stopped", p.Name())
+			return
+		}
 	}
 }
 
-func (m *Manager) runPorter(p Porter) {
+// runPorter run the porter, returns true when the porter is stopped as the process exits
+func (m *Manager) runPorter(p Porter) (stopped bool) {
 	defer func() {
 		if sysErr := recover(); sysErr != nil {
 			blog.Errorf("[manager] porter [%s] panic by: %v, stack:\n %s", p.Name(), sysErr, debug.Stack())
@@ -99,5 +115,7 @@ func (m *Manager) runPorter(p Porter) {
 	}()
 	if err := p.Run(); err != nil {
 		blog.Errorf("[manager] porter [%s] return an error %v", p.Name(), err)
+		return false
 	}
+	return true
 }
@@ -0,0 +1,98 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// expandScanRange expand the scan range of the collector to ipv4 addresses,
+// the item of the scan range could be an ip, a cidr like 10.0.0.0/24 or a range like 10.0.0.1-10.0.0.20
+func expandScanRange(ranges []string, limit int) ([]string, error) {
+	ips := make([]string, 0)
+	exists := make(map[uint32]bool)
+	add := func(ip uint32) error {
+		if exists[ip] {
+			return nil
+		}
+		if len(ips) >= limit {
+			return fmt.Errorf("the scan range exceeds the limit of %d addresses", limit)
+		}
+		exists[ip] = true
+		ips = append(ips, uint32ToIP(ip).String())
+		return nil
+	}
+
+	for _, item := range ranges {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		var first, last uint32
+		switch {
+		case strings.Contains(item, "/"):
+			_, ipnet, err := net.ParseCIDR(item)
+			if err != nil || ipnet.IP.To4() == nil {
+				return nil, fmt.Errorf("invalid scan range %s", item)
+			}
+			ones, bits := ipnet.Mask.Size()
+			first = ipToUint32(ipnet.IP)
+			last = first | (1<<uint(bits-ones) - 1)
+			// skip the network and broadcast address
+			if bits-ones >= 2 {
+				first, last = first+1, last-1
+			}
+		case strings.Contains(item, "-"):
+			parts := strings.SplitN(item, "-", 2)
+			start, end := net.ParseIP(strings.TrimSpace(parts[0])), net.ParseIP(strings.TrimSpace(parts[1]))
+			if start.To4() == nil || end.To4() == nil {
+				return nil, fmt.Errorf("invalid scan range %s", item)
+			}
+			first, last = ipToUint32(start), ipToUint32(end)
+			if first > last {
+				return nil, fmt.Errorf("invalid scan range %s", item)
+			}
+		default:
+			ip := net.ParseIP(item)
+			if ip.To4() == nil {
+				return nil, fmt.Errorf("invalid scan range %s", item)
+			}
+			first = ipToUint32(ip)
+			last = first
+		}
+
+		for ip := first; ; ip++ {
+			if err := add(ip); err != nil {
+				return nil, err
+			}
+			if ip == last {
+				break
+			}
+		}
+	}
+	return ips, nil
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uint32ToIP(n uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, n)
+	return ip
+}
@@ -0,0 +1,132 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandScanRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		ranges  []string
+		limit   int
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "single ip",
+			ranges: []string{"10.0.0.1"},
+			limit:  10,
+			want:   []string{"10.0.0.1"},
+		},
+		{
+			name:   "cidr skips the network and broadcast address",
+			ranges: []string{"10.0.0.0/30"},
+			limit:  10,
+			want:   []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:   "cidr of one address",
+			ranges: []string{"10.0.0.5/32"},
+			limit:  10,
+			want:   []string{"10.0.0.5"},
+		},
+		{
+			name:   "cidr of two addresses",
+			ranges: []string{"10.0.0.4/31"},
+			limit:  10,
+			want:   []string{"10.0.0.4", "10.0.0.5"},
+		},
+		{
+			name:   "range across the octet",
+			ranges: []string{"10.0.0.254 - 10.0.1.1"},
+			limit:  10,
+			want:   []string{"10.0.0.254", "10.0.0.255", "10.0.1.0", "10.0.1.1"},
+		},
+		{
+			name:   "range ends with the last address",
+			ranges: []string{"255.255.255.254-255.255.255.255"},
+			limit:  10,
+			want:   []string{"255.255.255.254", "255.255.255.255"},
+		},
+		{
+			name:   "duplicated and empty items",
+			ranges: []string{"10.0.0.1", " ", "10.0.0.1-10.0.0.2", "10.0.0.0/30"},
+			limit:  2,
+			want:   []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:   "empty",
+			ranges: nil,
+			limit:  10,
+			want:   []string{},
+		},
+		{
+			name:    "exceeds the limit",
+			ranges:  []string{"10.0.0.0/24"},
+			limit:   100,
+			wantErr: true,
+		},
+		{
+			name:    "reversed range",
+			ranges:  []string{"10.0.0.2-10.0.0.1"},
+			limit:   10,
+			wantErr: true,
+		},
+		{
+			name:    "invalid ip",
+			ranges:  []string{"10.0.0.256"},
+			limit:   10,
+			wantErr: true,
+		},
+		{
+			name:    "invalid cidr",
+			ranges:  []string{"10.0.0.0/33"},
+			limit:   10,
+			wantErr: true,
+		},
+		{
+			name:    "ipv6 is not supported",
+			ranges:  []string{"fe80::1"},
+			limit:   10,
+			wantErr: true,
+		},
+		{
+			name:    "ipv6 cidr is not supported",
+			ranges:  []string{"fe80::/126"},
+			limit:   10,
+			wantErr: true,
+		},
+		{
+			name:    "incomplete range",
+			ranges:  []string{"10.0.0.1-"},
+			limit:   10,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandScanRange(tt.ranges, tt.limit)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,606 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	snmpDefaultPort = 161
+	// snmpCheckInterval the interval to check which collectors are due to poll
+	snmpCheckInterval = time.Minute
+	// snmpSweepBudget the max time spent on polling in each check, the rest addresses of the sweeps
+	// are polled in the next checks, so that a large scan range does not block the check loop.
+	snmpSweepBudget = 45 * time.Second
+
+	oidSysDescr = ".1.3.6.1.2.1.1.1.0"
+	oidSysName  = ".1.3.6.1.2.1.1.5.0"
+)
+
+var snmpAuthProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"MD5":    gosnmp.MD5,
+	"SHA":    gosnmp.SHA,
+	"SHA224": gosnmp.SHA224,
+	"SHA256": gosnmp.SHA256,
+	"SHA384": gosnmp.SHA384,
+	"SHA512": gosnmp.SHA512,
+}
+
+var snmpPrivProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"DES":    gosnmp.DES,
+	"AES":    gosnmp.AES,
+	"AES192": gosnmp.AES192,
+	"AES256": gosnmp.AES256,
+}
+
+// SnmpOption the option of the built-in snmp poller
+type SnmpOption struct {
+	// Worker the count of the addresses polled concurrently, shared by all the collectors
+	Worker int
+	// Timeout the timeout of one snmp request
+	Timeout time.Duration
+	// Retries the retry times of one snmp request
+	Retries int
+	// MaxTargets the max count of the addresses scanned for each collector
+	MaxTargets int
+	// Period the poll period of the collectors whose period is not set, PeriodInfinite disables the periodic poll
+	Period time.Duration
+}
+
+// PeriodInfinite the infinite period, the collectors are not polled periodically but only when they are pending
+const PeriodInfinite time.Duration = -1
+
+// SnmpPoller poll the network devices in the scan range of the collectors over snmp, the OIDs to get are
+// defined by the netcollect devices and properties, the results are saved as the reports of netdevicebeat.
+type SnmpPoller struct {
+	ctx      context.Context
+	db       dal.RDB
+	collect  *NetCollect
+	option   SnmpOption
+	isMaster func() bool
+
+	// lastPoll the last poll time of the collectors
+	lastPoll map[string]time.Time
+	// sweeps the unfinished sweeps of the collectors
+	sweeps map[string]*collectorPoll
+}
+
+// snmpDevice the device and the properties to collect of it
+type snmpDevice struct {
+	metadata.NetcollectDevice
+	properties []metadata.NetcollectProperty
+}
+
+// NewSnmpPoller returns a new snmp poller, only the master polls the devices
+func NewSnmpPoller(ctx context.Context, db dal.RDB, collect *NetCollect, option SnmpOption, isMaster func() bool) *SnmpPoller {
+	if option.Worker <= 0 {
+		option.Worker = 10
+	}
+	if option.Timeout <= 0 {
+		option.Timeout = time.Second * 5
+	}
+	if option.Retries < 0 {
+		option.Retries = 0
+	}
+	if option.MaxTargets <= 0 {
+		option.MaxTargets = 4096
+	}
+	if option.Period == 0 {
+		option.Period = time.Hour
+	}
+	return &SnmpPoller{
+		ctx:      ctx,
+		db:       db,
+		collect:  collect,
+		option:   option,
+		isMaster: isMaster,
+		lastPoll: make(map[string]time.Time),
+		sweeps:   make(map[string]*collectorPoll),
+	}
+}
+
+// Run check the collectors every minute, and poll the collectors which are due or pending,
+// the loop is restarted after it's recovered from a panic until the ctx is done.
+func (p *SnmpPoller) Run() {
+	blog.Infof("[data-collection][netcollect][snmp] snmp poller started, option: %+v", p.option)
+	for {
+		p.loop()
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(snmpCheckInterval):
+			blog.Warnf("[data-collection][netcollect][snmp] snmp poller restarted")
+		}
+	}
+}
+
+// loop returns when the ctx is done or it panics
+func (p *SnmpPoller) loop() {
+	defer func() {
+		if sysErr := recover(); sysErr != nil {
+			blog.Errorf("[data-collection][netcollect][snmp] panic by: %v, stack:\n %s", sysErr, debug.Stack())
+		}
+	}()
+
+	ticker := time.NewTicker(snmpCheckInterval)
+	defer ticker.Stop()
+	for {
+		if p.isMaster() {
+			if err := p.pollDue(); err != nil {
+				blog.Errorf("[data-collection][netcollect][snmp] poll collectors failed: %v", err)
+			}
+		} else if len(p.sweeps) > 0 {
+			// the new master sweeps them from the beginning
+			p.sweeps = make(map[string]*collectorPoll)
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectorPoll the sweep of a collector, the addresses before next are polled
+type collectorPoll struct {
+	collector *metadata.Netcollector
+	targets   []string
+	next      int
+	responded int
+	reported  int64
+	reports   []metadata.NetcollectReport
+}
+
+// pollDue start the sweeps of the collectors which are due or pending, and poll the addresses of the
+// unfinished sweeps in the sweep budget, the sweep is saved when all of its addresses are polled.
+func (p *SnmpPoller) pollDue() error {
+	collectors := make([]metadata.Netcollector, 0)
+	if err := p.db.Table(common.BKTableNameNetcollectConfig).Find(nil).All(p.ctx, &collectors); err != nil {
+		return fmt.Errorf("find collectors failed: %v", err)
+	}
+
+	exists := make(map[string]bool)
+	for index := range collectors {
+		collector := &collectors[index]
+		key := collectorKey(collector)
+		exists[key] = true
+		sweep, sweeping := p.sweeps[key]
+		// the pending collector is updated or asked to discover again, poll it at once
+		if collector.Status.ConfigStatus == metadata.CollectorConfigStatusPending {
+			if sweeping && reflect.DeepEqual(sweep.collector.Config, collector.Config) {
+				continue
+			}
+		} else {
+			if sweeping {
+				continue
+			}
+			period := ParsePeriod(collector.Config.Period, p.option.Period)
+			if period == PeriodInfinite {
+				continue
+			}
+			if last, polled := p.lastPoll[key]; polled && time.Since(last) < period {
+				continue
+			}
+		}
+		p.lastPoll[key] = time.Now()
+		delete(p.sweeps, key)
+
+		targets, err := expandScanRange(collector.Config.ScanRange, p.option.MaxTargets)
+		if err != nil {
+			blog.Errorf("[data-collection][netcollect][snmp] collector %s scan range invalid: %v", key, err)
+			p.saveStatus(collector, metadata.CollectorConfigStatusAbnormal, metadata.CollectorReportStatusAbnormal, 0)
+			continue
+		}
+		blog.V(3).Infof("[data-collection][netcollect][snmp] sweeping collector %s, %d addresses", key, len(targets))
+		p.sweeps[key] = &collectorPoll{collector: collector, targets: targets}
+	}
+	// the removed collectors are not polled any more
+	for key := range p.sweeps {
+		if !exists[key] {
+			delete(p.sweeps, key)
+		}
+	}
+	if len(p.sweeps) == 0 {
+		return nil
+	}
+
+	devices, err := p.findDevices()
+	if err != nil {
+		return err
+	}
+	polls := make([]*collectorPoll, 0, len(p.sweeps))
+	for _, sweep := range p.sweeps {
+		polls = append(polls, sweep)
+	}
+	p.pollTargets(polls, devices, time.Now().Add(snmpSweepBudget))
+	for _, poll := range polls {
+		p.saveReports(poll)
+		if poll.next < len(poll.targets) {
+			blog.V(3).Infof("[data-collection][netcollect][snmp] collector %s polled %d/%d addresses, continue in the next check",
+				collectorKey(poll.collector), poll.next, len(poll.targets))
+			continue
+		}
+		p.savePoll(poll)
+		delete(p.sweeps, collectorKey(poll.collector))
+	}
+	return nil
+}
+
+func collectorKey(collector *metadata.Netcollector) string {
+	return fmt.Sprintf("%d:%s", collector.CloudID, collector.InnerIP)
+}
+
+// findDevices returns the devices which have properties to collect
+func (p *SnmpPoller) findDevices() ([]*snmpDevice, error) {
+	devices := make([]metadata.NetcollectDevice, 0)
+	if err := p.db.Table(common.BKTableNameNetcollectDevice).Find(nil).All(p.ctx, &devices); err != nil {
+		return nil, fmt.Errorf("find devices failed: %v", err)
+	}
+	properties := make([]metadata.NetcollectProperty, 0)
+	if err := p.db.Table(common.BKTableNameNetcollectProperty).Find(nil).All(p.ctx, &properties); err != nil {
+		return nil, fmt.Errorf("find properties failed: %v", err)
+	}
+
+	deviceMap := make(map[uint64]*snmpDevice)
+	for _, device := range devices {
+		deviceMap[device.DeviceID] = &snmpDevice{NetcollectDevice: device}
+	}
+	for _, property := range properties {
+		if device, ok := deviceMap[property.DeviceID]; ok && len(property.OID) > 0 {
+			device.properties = append(device.properties, property)
+		}
+	}
+
+	result := make([]*snmpDevice, 0)
+	for _, device := range devices {
+		if len(deviceMap[device.DeviceID].properties) > 0 {
+			result = append(result, deviceMap[device.DeviceID])
+		}
+	}
+	return result, nil
+}
+
+// pollTargets poll the rest addresses of the collectors with the bounded workers until the deadline,
+// the addresses not dispatched before the deadline are left to the next call.
+func (p *SnmpPoller) pollTargets(polls []*collectorPoll, devices []*snmpDevice, deadline time.Time) {
+	type job struct {
+		poll   *collectorPoll
+		target string
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	jobC := make(chan job)
+	for i := 0; i < p.option.Worker; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobC {
+				ok, reports := p.safePollTarget(j.poll.collector, j.target, devices)
+				lock.Lock()
+				if ok {
+					j.poll.responded++
+				}
+				j.poll.reports = append(j.poll.reports, reports...)
+				lock.Unlock()
+			}
+		}()
+	}
+
+	defer func() {
+		close(jobC)
+		wg.Wait()
+	}()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	for _, poll := range polls {
+		for poll.next < len(poll.targets) {
+			select {
+			case jobC <- job{poll: poll, target: poll.targets[poll.next]}:
+				poll.next++
+			case <-timer.C:
+				return
+			case <-p.ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// saveReports save the reports polled in this check
+func (p *SnmpPoller) saveReports(poll *collectorPoll) {
+	for index := range poll.reports {
+		if err := p.collect.handleReport(&poll.reports[index]); err == nil {
+			poll.reported++
+		}
+	}
+	poll.reports = nil
+}
+
+// savePoll save the status of the collector whose sweep is finished
+func (p *SnmpPoller) savePoll(poll *collectorPoll) {
+	collector := poll.collector
+	blog.Infof("[data-collection][netcollect][snmp] collector %d:%s polled, %d addresses responded, %d reports saved",
+		collector.CloudID, collector.InnerIP, poll.responded, poll.reported)
+
+	reportStatus := metadata.CollectorReportStatusNormal
+	if poll.responded == 0 {
+		reportStatus = metadata.CollectorReportStatusAbnormal
+	}
+	p.saveStatus(collector, metadata.CollectorConfigStatusNormal, reportStatus, poll.reported)
+}
+
+func (p *SnmpPoller) saveStatus(collector *metadata.Netcollector, configStatus, reportStatus string, reported int64) {
+	filter := map[string]interface{}{
+		common.BKCloudIDField:     collector.CloudID,
+		common.BKHostInnerIPField: collector.InnerIP,
+	}
+	data := map[string]interface{}{
+		"status.config_status": configStatus,
+		"status.report_status": reportStatus,
+		"report_total":         collector.ReportTotal + reported,
+	}
+	if err := p.db.Table(common.BKTableNameNetcollectConfig).Update(p.ctx, filter, data); err != nil {
+		blog.Errorf("[data-collection][netcollect][snmp] save collector %d:%s status failed: %v", collector.CloudID, collector.InnerIP, err)
+	}
+}
+
+// safePollTarget poll the address, the panic is recovered so that the worker goes on
+func (p *SnmpPoller) safePollTarget(collector *metadata.Netcollector, target string, devices []*snmpDevice) (ok bool, reports []metadata.NetcollectReport) {
+	defer func() {
+		if sysErr := recover(); sysErr != nil {
+			blog.Errorf("[data-collection][netcollect][snmp] poll %s panic by: %v, stack:\n %s", target, sysErr, debug.Stack())
+			ok, reports = false, nil
+		}
+	}()
+	return p.pollTarget(collector, target, devices)
+}
+
+// pollTarget poll the address, returns false if the address does not respond
+func (p *SnmpPoller) pollTarget(collector *metadata.Netcollector, target string, devices []*snmpDevice) (bool, []metadata.NetcollectReport) {
+	client := p.newClient(collector.Config, target)
+	if err := client.Connect(); err != nil {
+		blog.Errorf("[data-collection][netcollect][snmp] connect %s failed: %v", target, err)
+		return false, nil
+	}
+	defer client.Conn.Close()
+
+	result, err := client.Get([]string{oidSysDescr, oidSysName})
+	if err != nil || len(result.Variables) < 2 {
+		blog.V(5).Infof("[data-collection][netcollect][snmp] %s does not respond: %v", target, err)
+		return false, nil
+	}
+	sysDescr, _ := pduValue(result.Variables[0])
+	sysName, _ := pduValue(result.Variables[1])
+
+	reports := make([]metadata.NetcollectReport, 0)
+	for _, device := range devices {
+		if !device.match(fmt.Sprint(sysDescr)) {
+			continue
+		}
+		attributes := p.pollProperties(client, device)
+		if len(attributes) == 0 {
+			continue
+		}
+		reports = append(reports, buildReport(collector, device, target, sysName, attributes))
+	}
+	return true, reports
+}
+
+// pollProperties get the values of the device properties, the properties with action getnext are got by GetNext
+func (p *SnmpPoller) pollProperties(client *gosnmp.GoSNMP, device *snmpDevice) map[string]interface{} {
+	groups := make(map[string][]metadata.NetcollectProperty)
+	for _, property := range device.properties {
+		action := property.Action
+		if action != common.SNMPActionGetNext {
+			action = common.SNMPActionGet
+		}
+		groups[action] = append(groups[action], property)
+	}
+
+	attributes := make(map[string]interface{})
+	for action, properties := range groups {
+		for start := 0; start < len(properties); start += client.MaxOids {
+			end := start + client.MaxOids
+			if end > len(properties) {
+				end = len(properties)
+			}
+			chunk := properties[start:end]
+			oids := make([]string, len(chunk))
+			for index, property := range chunk {
+				oids[index] = property.OID
+			}
+
+			var result *gosnmp.SnmpPacket
+			var err error
+			if action == common.SNMPActionGetNext {
+				result, err = client.GetNext(oids)
+			} else {
+				result, err = client.Get(oids)
+			}
+			if err != nil {
+				blog.Warnf("[data-collection][netcollect][snmp] %s %s %v failed: %v", action, client.Target, oids, err)
+				continue
+			}
+			for index, pdu := range result.Variables {
+				if index >= len(chunk) {
+					break
+				}
+				if value, ok := pduValue(pdu); ok {
+					attributes[chunk[index].PropertyID] = value
+				}
+			}
+		}
+	}
+	return attributes
+}
+
+func (p *SnmpPoller) newClient(config metadata.NetcollectConfig, target string) *gosnmp.GoSNMP {
+	client := &gosnmp.GoSNMP{
+		Target:    target,
+		Port:      snmpDefaultPort,
+		Community: config.Community,
+		Version:   gosnmp.Version2c,
+		Context:   p.ctx,
+		Timeout:   p.option.Timeout,
+		Retries:   p.option.Retries,
+		MaxOids:   gosnmp.MaxOids,
+	}
+
+	snmp := config.Snmp
+	if snmp == nil {
+		return client
+	}
+	if snmp.Port > 0 {
+		client.Port = snmp.Port
+	}
+	if snmp.Version != metadata.SnmpVersion3 {
+		return client
+	}
+
+	params := &gosnmp.UsmSecurityParameters{UserName: snmp.UserName}
+	client.Version = gosnmp.Version3
+	client.SecurityModel = gosnmp.UserSecurityModel
+	client.MsgFlags = gosnmp.NoAuthNoPriv
+	if snmp.SecurityLevel == metadata.SnmpAuthNoPriv || snmp.SecurityLevel == metadata.SnmpAuthPriv {
+		client.MsgFlags = gosnmp.AuthNoPriv
+		params.AuthenticationProtocol = snmpAuthProtocols[snmp.AuthProtocol]
+		params.AuthenticationPassphrase = snmp.AuthPassphrase
+	}
+	if snmp.SecurityLevel == metadata.SnmpAuthPriv {
+		client.MsgFlags = gosnmp.AuthPriv
+		params.PrivacyProtocol = snmpPrivProtocols[snmp.PrivProtocol]
+		params.PrivacyPassphrase = snmp.PrivPassphrase
+	}
+	client.SecurityParameters = params
+	return client
+}
+
+// match check whether the sysDescr of the address belongs to the device by the vendor and the device model
+func (d *snmpDevice) match(sysDescr string) bool {
+	if len(d.BkVendor) == 0 && len(d.DeviceModel) == 0 {
+		return false
+	}
+	sysDescr = strings.ToLower(sysDescr)
+	if len(d.BkVendor) > 0 && !strings.Contains(sysDescr, strings.ToLower(d.BkVendor)) {
+		return false
+	}
+	if len(d.DeviceModel) > 0 && !strings.Contains(sysDescr, strings.ToLower(d.DeviceModel)) {
+		return false
+	}
+	return true
+}
+
+// buildReport build the report of the device like netdevicebeat does, the host is identified by the address,
+// the other objects are identified by the instance name, which is the sysName or the address if it's not collected.
+func buildReport(collector *metadata.Netcollector, device *snmpDevice, target string, sysName interface{}, attributes map[string]interface{}) metadata.NetcollectReport {
+	var instKey string
+	if device.ObjectID == common.BKInnerObjIDHost {
+		instKey = target
+		attributes[common.BKHostInnerIPField] = target
+	} else {
+		nameField := common.GetInstNameField(device.ObjectID)
+		if name, ok := attributes[nameField]; ok && len(fmt.Sprint(name)) > 0 {
+			instKey = fmt.Sprint(name)
+		} else if name := fmt.Sprint(sysName); sysName != nil && len(name) > 0 {
+			instKey = name
+		} else {
+			instKey = target
+		}
+		attributes[nameField] = instKey
+	}
+
+	ownerID := device.OwnerID
+	if len(ownerID) == 0 {
+		ownerID = common.BKDefaultOwnerID
+	}
+	report := metadata.NetcollectReport{
+		CloudID:      collector.CloudID,
+		ObjectID:     device.ObjectID,
+		InnerIP:      collector.InnerIP,
+		OwnerID:      ownerID,
+		InstKey:      instKey,
+		LastTime:     metadata.Time{Time: time.Now()},
+		Attributes:   make([]metadata.NetcollectReportAttribute, 0, len(attributes)),
+		Associations: make([]metadata.NetcollectReportAssociation, 0),
+	}
+	for propertyID, value := range attributes {
+		report.Attributes = append(report.Attributes, metadata.NetcollectReportAttribute{
+			PropertyID: propertyID,
+			CurValue:   value,
+		})
+	}
+	return report
+}
+
+// pduValue convert the value of the pdu, returns false if there is no such object
+func pduValue(pdu gosnmp.SnmpPDU) (interface{}, bool) {
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return nil, false
+	case gosnmp.OctetString:
+		if value, ok := pdu.Value.([]byte); ok {
+			return string(value), true
+		}
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		value := gosnmp.ToBigInt(pdu.Value)
+		if value.IsInt64() {
+			return value.Int64(), true
+		}
+		return value.String(), true
+	}
+	return pdu.Value, pdu.Value != nil
+}
+
+// ParsePeriod parse the period like 30S, 5M, 1H or 1D, returns PeriodInfinite if it's infinite,
+// or the default period if it's invalid
+func ParsePeriod(period string, defaultPeriod time.Duration) time.Duration {
+	if period == common.Infinite {
+		return PeriodInfinite
+	}
+	if len(period) < 2 {
+		return defaultPeriod
+	}
+	num, err := strconv.Atoi(period[:len(period)-1])
+	if err != nil || num <= 0 {
+		return defaultPeriod
+	}
+	switch strings.ToUpper(period[len(period)-1:]) {
+	case "D":
+		return time.Duration(num) * time.Hour * 24
+	case "H":
+		return time.Duration(num) * time.Hour
+	case "M":
+		return time.Duration(num) * time.Minute
+	case "S":
+		return time.Duration(num) * time.Second
+	default:
+		return defaultPeriod
+	}
+}
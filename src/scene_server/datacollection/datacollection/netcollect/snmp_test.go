@@ -0,0 +1,184 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package netcollect
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		period string
+		want   time.Duration
+	}{
+		{period: "30S", want: 30 * time.Second},
+		{period: "5m", want: 5 * time.Minute},
+		{period: "1H", want: time.Hour},
+		{period: "2D", want: 48 * time.Hour},
+		{period: common.Infinite, want: PeriodInfinite},
+		{period: "", want: time.Minute},
+		{period: "H", want: time.Minute},
+		{period: "0H", want: time.Minute},
+		{period: "-1H", want: time.Minute},
+		{period: "1W", want: time.Minute},
+		{period: "xH", want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, ParsePeriod(tt.period, time.Minute), "period %q", tt.period)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	poller := NewSnmpPoller(context.Background(), nil, nil, SnmpOption{}, func() bool { return true })
+
+	tests := []struct {
+		name      string
+		config    metadata.NetcollectConfig
+		version   gosnmp.SnmpVersion
+		port      uint16
+		community string
+		msgFlags  gosnmp.SnmpV3MsgFlags
+		usmParams *gosnmp.UsmSecurityParameters
+		isSnmpV3  bool
+	}{
+		{
+			name:      "community without snmp options",
+			config:    metadata.NetcollectConfig{Community: "public"},
+			version:   gosnmp.Version2c,
+			port:      snmpDefaultPort,
+			community: "public",
+		},
+		{
+			name: "v2c with port",
+			config: metadata.NetcollectConfig{
+				Community: "public",
+				Snmp:      &metadata.NetcollectSnmp{Version: metadata.SnmpVersion2c, Port: 1161},
+			},
+			version:   gosnmp.Version2c,
+			port:      1161,
+			community: "public",
+		},
+		{
+			name: "v3 noAuthNoPriv",
+			config: metadata.NetcollectConfig{
+				Snmp: &metadata.NetcollectSnmp{
+					Version:       metadata.SnmpVersion3,
+					UserName:      "user",
+					SecurityLevel: metadata.SnmpNoAuthNoPriv,
+				},
+			},
+			version:   gosnmp.Version3,
+			port:      snmpDefaultPort,
+			msgFlags:  gosnmp.NoAuthNoPriv,
+			usmParams: &gosnmp.UsmSecurityParameters{UserName: "user"},
+			isSnmpV3:  true,
+		},
+		{
+			name: "v3 authNoPriv",
+			config: metadata.NetcollectConfig{
+				Snmp: &metadata.NetcollectSnmp{
+					Version:        metadata.SnmpVersion3,
+					UserName:       "user",
+					SecurityLevel:  metadata.SnmpAuthNoPriv,
+					AuthProtocol:   "SHA256",
+					AuthPassphrase: "auth-secret",
+					PrivProtocol:   "AES",
+					PrivPassphrase: "priv-secret",
+				},
+			},
+			version:  gosnmp.Version3,
+			port:     snmpDefaultPort,
+			msgFlags: gosnmp.AuthNoPriv,
+			usmParams: &gosnmp.UsmSecurityParameters{
+				UserName:                 "user",
+				AuthenticationProtocol:   gosnmp.SHA256,
+				AuthenticationPassphrase: "auth-secret",
+			},
+			isSnmpV3: true,
+		},
+		{
+			name: "v3 authPriv",
+			config: metadata.NetcollectConfig{
+				Snmp: &metadata.NetcollectSnmp{
+					Version:        metadata.SnmpVersion3,
+					Port:           10161,
+					UserName:       "user",
+					SecurityLevel:  metadata.SnmpAuthPriv,
+					AuthProtocol:   "MD5",
+					AuthPassphrase: "auth-secret",
+					PrivProtocol:   "AES256",
+					PrivPassphrase: "priv-secret",
+				},
+			},
+			version:  gosnmp.Version3,
+			port:     10161,
+			msgFlags: gosnmp.AuthPriv,
+			usmParams: &gosnmp.UsmSecurityParameters{
+				UserName:                 "user",
+				AuthenticationProtocol:   gosnmp.MD5,
+				AuthenticationPassphrase: "auth-secret",
+				PrivacyProtocol:          gosnmp.AES256,
+				PrivacyPassphrase:        "priv-secret",
+			},
+			isSnmpV3: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := poller.newClient(tt.config, "10.0.0.1")
+			require.Equal(t, "10.0.0.1", client.Target)
+			require.Equal(t, tt.version, client.Version)
+			require.Equal(t, tt.port, client.Port)
+			require.Equal(t, tt.community, client.Community)
+			require.Equal(t, 5*time.Second, client.Timeout)
+			if !tt.isSnmpV3 {
+				require.Nil(t, client.SecurityParameters)
+				return
+			}
+			require.Equal(t, gosnmp.UserSecurityModel, client.SecurityModel)
+			require.Equal(t, tt.msgFlags, client.MsgFlags)
+			require.Equal(t, tt.usmParams, client.SecurityParameters)
+		})
+	}
+}
+
+func TestSnmpDeviceMatch(t *testing.T) {
+	sysDescr := "Cisco IOS Software, C2960 Software (C2960-LANBASEK9-M)"
+	tests := []struct {
+		vendor string
+		model  string
+		want   bool
+	}{
+		{vendor: "cisco", model: "c2960", want: true},
+		{vendor: "Cisco", want: true},
+		{model: "C2960", want: true},
+		{vendor: "huawei", model: "c2960", want: false},
+		{vendor: "cisco", model: "c3750", want: false},
+		{want: false},
+	}
+
+	for _, tt := range tests {
+		device := &snmpDevice{NetcollectDevice: metadata.NetcollectDevice{BkVendor: tt.vendor, DeviceModel: tt.model}}
+		require.Equal(t, tt.want, device.match(sysDescr), "vendor %q, model %q", tt.vendor, tt.model)
+	}
+}
@@ -13,8 +13,8 @@
 package datacollection
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"net"
 	"runtime"
 	"runtime/debug"
@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common/backbone"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/datacollection/source"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/xid"
@@ -37,15 +38,16 @@ type chanCollector struct {
 // 14kB * 10000 = 140M
 const cacheSize = 10000
 
-func BuildChanPorter(name string, analyzer Analyzer, redisCli, snapCli *redis.Client, channels []string, mockmesg string, registry prometheus.Registerer, engine *backbone.Engine) *chanPorter {
+func BuildChanPorter(ctx context.Context, name string, analyzer Analyzer, redisCli *redis.Client, mesgSource source.MessageSource, mockmesg string, registry prometheus.Registerer, engine *backbone.Engine) *chanPorter {
 	porter := &chanPorter{
+		ctx:             ctx,
 		analyzer:        analyzer,
 		name:            name,
 		pid:             xid.New().String(),
 		isMaster:        util.NewBool(false),
 		redisCli:        redisCli,
-		snapCli:         snapCli,
-		channels:        channels,
+		source:          mesgSource,
+		receiveTs:       time.Now(),
 		analyzeC:        make(chan string, cacheSize),
 		slaveC:          make(chan string, cacheSize),
 		analyzeCounterC: make(chan int, runtime.NumCPU()),
@@ -103,6 +105,14 @@ func BuildChanPorter(name string, analyzer Analyzer, redisCli, snapCli *redis.Cl
 	)
 	registry.MustRegister(porter.pushTotal)
 
+	porter.rejectTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: ns + "reject_total",
+			Help: "number of rejected pushed message because of the queue is full.",
+		},
+	)
+	registry.MustRegister(porter.rejectTotal)
+
 	porter.analyzeTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: ns + "analyze_total",
@@ -123,8 +133,12 @@ type chanPorter struct {
 	analyseDuration prometheus.Histogram
 	receiveTotal    prometheus.Counter
 	pushTotal       prometheus.Counter
+	rejectTotal     prometheus.Counter
 	analyzeTotal    *prometheus.CounterVec
 
+	// 进程退出时停止消费并关闭数据来源
+	ctx context.Context
+
 	// 分析器
 	analyzer Analyzer
 
@@ -140,11 +154,8 @@ type chanPorter struct {
 	// cc自己的redis，用于抢master锁，缓存slavequeue
 	redisCli *redis.Client
 
-	// 数据来源的redis，master 从这个redis读channel
-	snapCli *redis.Client
-
-	// redis channel 名称
-	channels []string
+	// 数据来源，为空时只处理通过http上报的消息
+	source source.MessageSource
 
 	// 待处理队列，analyzer只消费这个队列的消息
 	analyzeC chan string
@@ -158,6 +169,10 @@ type chanPorter struct {
 	// 最后一次收到消息的时间，用于健康检查
 	lastMesgTs time.Time
 
+	// 用于统计接收效率的
+	receiveCount int64
+	receiveTs    time.Time
+
 	// 用于统计处理效率的
 	analyzeCounterC chan int
 
@@ -178,6 +193,22 @@ func (p *chanPorter) Mock(mesg string) error {
 	return nil
 }
 
+// Push 接收通过http上报的消息，本进程处理不过来时推送到slavequeue，都满时拒绝，由上报方稍后重试
+func (p *chanPorter) Push(mesg string) error {
+	select {
+	case p.analyzeC <- mesg:
+	default:
+		select {
+		case p.slaveC <- mesg:
+		default:
+			p.rejectTotal.Inc()
+			return ErrQueueFull
+		}
+	}
+	p.receiveTotal.Inc()
+	return nil
+}
+
 func (p *chanPorter) Run() error {
 	p.runed.Set()
 	if p.runed.IsSet() {
@@ -196,8 +227,25 @@ func (p *chanPorter) Run() error {
 			blog.Errorf("[data-collection][%s] collect message failed: %v, retry 3s later", p.name, err)
 		}
 		// 睡3秒， 防止空跑导致CPU占用高涨
-		time.Sleep(time.Second * 3)
+		select {
+		case <-p.ctx.Done():
+			p.close()
+			return nil
+		case <-time.After(time.Second * 3):
+		}
+	}
+}
+
+// close 进程退出时关闭数据来源，分区消费时退出消费组以便分区尽快分配给其他进程
+func (p *chanPorter) close() {
+	if p.source == nil {
+		return
+	}
+	if err := p.source.Close(); err != nil {
+		blog.Errorf("[data-collection][%s] close %s failed: %v", p.name, p.source.Name(), err)
+		return
 	}
+	blog.Infof("[data-collection][%s] %s closed", p.name, p.source.Name())
 }
 
 func (p *chanPorter) analyzeLoop() {
@@ -214,19 +262,31 @@ func (p *chanPorter) analyze() {
 	}()
 
 	var mesg string
-	var err error
-
 	for mesg = range p.analyzeC {
-		before := time.Now()
-		if err = p.analyzer.Analyze(mesg); err != nil {
-			blog.Errorf("[data-collection][%s] analyze message failed: %v, raw msg: %s", p.name, err, mesg)
-			p.analyzeTotal.WithLabelValues("failed").Inc()
-		} else {
-			p.analyzeTotal.WithLabelValues("success").Inc()
+		p.analyzeMesg(mesg)
+	}
+}
+
+// analyzeSync 同步处理分区消费的消息，返回后数据来源才提交该消息的位置，由数据来源的多个worker并发调用
+func (p *chanPorter) analyzeSync(mesg string) {
+	defer func() {
+		if sysErr := recover(); sysErr != nil {
+			blog.Errorf("[data-collection][%s] analyze panic by: %v, stack:\n %s", p.name, sysErr, debug.Stack())
 		}
-		p.analyseDuration.Observe(time.Since(before).Seconds())
-		p.analyzeCounterC <- 1
+	}()
+	p.analyzeMesg(mesg)
+}
+
+func (p *chanPorter) analyzeMesg(mesg string) {
+	before := time.Now()
+	if err := p.analyzer.Analyze(mesg); err != nil {
+		blog.Errorf("[data-collection][%s] analyze message failed: %v, raw msg: %s", p.name, err, mesg)
+		p.analyzeTotal.WithLabelValues("failed").Inc()
+	} else {
+		p.analyzeTotal.WithLabelValues("success").Inc()
 	}
+	p.analyseDuration.Observe(time.Since(before).Seconds())
+	p.analyzeCounterC <- 1
 }
 
 func (p *chanPorter) analyzeCount() {
@@ -243,88 +303,77 @@ func (p *chanPorter) analyzeCount() {
 	}
 }
 
-// collect 获取待处理消息，当是master时从redis channel获取，当是slave时从 redis queue 获取
+// collect 获取待处理消息，独占的数据来源只在master时消费，当是slave时从 redis queue 获取
+// 分区消费的数据来源由所有进程一起消费，消息队列按分区分配给各个进程
 func (p *chanPorter) collect() error {
-	if !p.Engine.ServiceManageInterface.IsMaster() {
-		p.isMaster.UnSet()
-		blog.Infof("[data-collection][%s] %v", p.name, "there is other master")
+	if p.source == nil {
+		// 没有配置数据来源，只处理通过http上报的消息
 		return nil
 	}
-	blog.Infof("[data-collection][%s] i am master(id: %s) from now", p.name, p.pid)
-	defer blog.Infof("[data-collection][%s] exist master(id: %s) from now", p.name, p.pid)
-	p.isMaster.Set()
-	defer p.isMaster.UnSet()
+	if p.source.Exclusive() {
+		if !p.Engine.ServiceManageInterface.IsMaster() {
+			p.isMaster.UnSet()
+			blog.Infof("[data-collection][%s] %v", p.name, "there is other master")
+			return nil
+		}
+		blog.Infof("[data-collection][%s] i am master(id: %s) from now", p.name, p.pid)
+		defer blog.Infof("[data-collection][%s] exist master(id: %s) from now", p.name, p.pid)
+		p.isMaster.Set()
+		defer p.isMaster.UnSet()
+	}
 
-	// 开始订阅
-	err := p.subscribeLoop()
-	if err != nil {
-		return fmt.Errorf("subscribe channel return an error: %v", err)
+	// 开始消费
+	if err := p.source.Consume(p.consuming, p.receive); err != nil {
+		return fmt.Errorf("consume %s return an error: %v", p.source.Name(), err)
 	}
 
 	return nil
 }
 
-func (p *chanPorter) subscribeLoop() error {
-	subChan, err := p.snapCli.Subscribe(p.channels...)
-	if nil != err {
-		return fmt.Errorf("subscribe channel failed, %v", err)
+// consuming 判断是否继续消费，进程退出时停止消费，独占的数据来源在失去master后停止消费
+func (p *chanPorter) consuming() bool {
+	if p.ctx.Err() != nil {
+		return false
 	}
-	defer subChan.Unsubscribe(p.channels...)
-
-	blog.Info("[data-collection][%s] subscribing channel %v from redis", p.name, p.channels)
-	defer blog.Info("[data-collection][%s] unsubscribe channel %v from redis", p.name, p.channels)
-
-	ts := time.Now()
-	var cnt int64
-	var timeoutErr net.Error
-	var ok bool
-	var received interface{}
-	var name = p.name + "[receive]"
-	for p.Engine.ServiceManageInterface.IsMaster() {
+	if !p.source.Exclusive() {
+		return true
+	}
+	if p.Engine.ServiceManageInterface.IsMaster() {
 		p.isMaster.Set()
-		received, err = subChan.ReceiveTimeout(time.Second * 10)
-		if err == redis.Nil || err == io.EOF {
-			continue
-		}
-		if timeoutErr, ok = err.(net.Error); ok && timeoutErr.Timeout() {
-			continue
-		}
-		if nil != err {
-			return fmt.Errorf("receive message from redis failed: %v", err)
-		}
-		msg, ok := received.(*redis.Message)
-		if !ok {
-			continue
-		}
-
-		if "" == msg.Payload {
-			continue
-		}
+		return true
+	}
+	p.isMaster.UnSet()
+	return false
+}
 
-		p.receiveTotal.Inc()
+// receive 接收数据来源的消息
+func (p *chanPorter) receive(mesg string) {
+	p.receiveTotal.Inc()
 
+	if p.source.Exclusive() {
 		// 当mesgC满时表明已达到本进程的处理速度上限，此时我们推送该消息到slavequeue让其他进程协助处理
 		select {
-		case p.analyzeC <- msg.Payload:
+		case p.analyzeC <- mesg:
 		default:
 			select {
-			case p.slaveC <- msg.Payload:
+			case p.slaveC <- mesg:
 			default:
-				writeOrClearChan(p.analyzeC, name, msg.Payload)
+				writeOrClearChan(p.analyzeC, p.name+"[receive]", mesg)
 			}
 		}
+	} else {
+		// 分区消费时同步处理，处理完成后才提交位置，处理不过来的消息留在消息队列中，保证消息至少被处理一次，
+		// 各进程内由数据来源的worker并发处理，同一分区的消息按顺序处理
+		p.analyzeSync(mesg)
+	}
 
-		cnt++
-
-		p.lastMesgTs = time.Now()
-		if time.Since(ts) > time.Minute*10 {
-			blog.Infof("[data-collection][%s] receive rate: %d message in last %v", p.name, cnt, time.Now().Sub(ts))
-			cnt = 0
-			ts = time.Now()
-		}
+	p.receiveCount++
+	p.lastMesgTs = time.Now()
+	if time.Since(p.receiveTs) > time.Minute*10 {
+		blog.Infof("[data-collection][%s] receive rate: %d message in last %v", p.name, p.receiveCount, time.Now().Sub(p.receiveTs))
+		p.receiveCount = 0
+		p.receiveTs = time.Now()
 	}
-	p.isMaster.UnSet()
-	return nil
 }
 
 func (p *chanPorter) renewalMasterLoop() {
@@ -358,9 +407,9 @@ func (p *chanPorter) healthCheck() {
 	var now time.Time
 	for now = range ticker.C {
 		var channelStatus int
-		if err = p.snapCli.Ping().Err(); err != nil {
+		if err = p.pingSource(); err != nil {
 			channelStatus = common.CCErrHostGetSnapshotChannelClose
-			blog.Errorf("[data-collection][%s][healthCheck] snap redis server connection error: %s", p.name, err.Error())
+			blog.Errorf("[data-collection][%s][healthCheck] message source connection error: %s", p.name, err.Error())
 		} else if err = p.redisCli.Ping().Err(); err != nil {
 			channelStatus = common.CCErrHostGetSnapshotChannelClose
 			blog.Errorf("[data-collection][%s][healthCheck] cc redis server connection error: %s", p.name, err.Error())
@@ -376,6 +425,13 @@ func (p *chanPorter) healthCheck() {
 	}
 }
 
+func (p *chanPorter) pingSource() error {
+	if p.source == nil {
+		return nil
+	}
+	return p.source.Ping()
+}
+
 // popLoop 从slave处理队列获取消息，从而协助master处理
 // 因为有可能单机部署，所以即使是master也要处理slavequeue
 func (p *chanPorter) popLoop() {
@@ -0,0 +1,110 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package source
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	defaultKafkaGroupID = "cmdb_datacollection"
+)
+
+// KafkaConfig the config of the kafka brokers
+type KafkaConfig struct {
+	Brokers []string
+	// GroupID the consumer group of the datacollection processes
+	GroupID string
+	// SnapshotTopic the topic of the host snapshot, empty means consume the snapshot from redis
+	SnapshotTopic string
+	// NetcollectTopic the topic of the network device data, empty means consume it from redis
+	NetcollectTopic string
+	// Workers the count of the messages handled concurrently by each process, the count of the cpus by default
+	Workers int
+}
+
+// kafkaReader read the messages of the topic as a member of the kafka consumer group
+type kafkaReader struct {
+	brokers []string
+	reader  *kafka.Reader
+}
+
+// NewKafkaSource create a source which consumes the topic with the kafka consumer group,
+// the partitions are assigned to the datacollection processes by the kafka group coordinator.
+func NewKafkaSource(config KafkaConfig, topic string) (MessageSource, error) {
+	if len(config.Brokers) == 0 {
+		return nil, errors.New("kafka brokers not set")
+	}
+	if len(topic) == 0 {
+		return nil, errors.New("kafka topic not set")
+	}
+	groupID := config.GroupID
+	if len(groupID) == 0 {
+		groupID = defaultKafkaGroupID
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     config.Brokers,
+		GroupID:     groupID,
+		Topic:       topic,
+		MinBytes:    1,
+		MaxBytes:    10e6,
+		MaxWait:     time.Second,
+		StartOffset: kafka.LastOffset,
+	})
+	return NewGroupSource("kafka["+topic+"]", &kafkaReader{brokers: config.Brokers, reader: reader}, config.Workers), nil
+}
+
+func (r *kafkaReader) Fetch(ctx context.Context) (GroupMessage, error) {
+	mesg, err := r.reader.FetchMessage(ctx)
+	if err != nil {
+		return GroupMessage{}, err
+	}
+	return GroupMessage{
+		Partition: mesg.Partition,
+		Offset:    mesg.Offset,
+		Value:     string(mesg.Value),
+		raw:       mesg,
+	}, nil
+}
+
+func (r *kafkaReader) Commit(ctx context.Context, mesg GroupMessage) error {
+	raw, ok := mesg.raw.(kafka.Message)
+	if !ok {
+		return errors.New("not a kafka message")
+	}
+	return r.reader.CommitMessages(ctx, raw)
+}
+
+// Ping check whether any of the brokers is reachable
+func (r *kafkaReader) Ping() error {
+	var err error
+	for _, broker := range r.brokers {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		var conn *kafka.Conn
+		conn, err = kafka.DialContext(ctx, "tcp", broker)
+		cancel()
+		if err == nil {
+			return conn.Close()
+		}
+	}
+	return err
+}
+
+func (r *kafkaReader) Close() error {
+	return r.reader.Close()
+}
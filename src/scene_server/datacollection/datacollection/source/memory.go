@@ -0,0 +1,243 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// MemoryBroker an in-memory stand-in of the kafka broker. the messages of a topic are distributed to
+// the partitions by the key, the partitions are assigned to the members of a consumer group in round
+// robin, and the committed offsets are kept for each group, so that the group sources can be tested
+// without a real broker.
+type MemoryBroker struct {
+	lock   sync.Mutex
+	topics map[string][][]string
+	groups map[string]*memoryGroup
+	// notify is closed and replaced when a message is published or a group is rebalanced
+	notify chan struct{}
+	// robin the partition of the next message without key
+	robin  int
+	nextID int
+}
+
+type memoryGroup struct {
+	topic      string
+	members    []*memoryReader
+	offsets    []int64
+	generation int
+}
+
+// NewMemoryBroker create an in-memory broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		topics: make(map[string][][]string),
+		groups: make(map[string]*memoryGroup),
+		notify: make(chan struct{}),
+	}
+}
+
+// CreateTopic create the topic with the partitions, it's ignored if the topic exists
+func (b *MemoryBroker) CreateTopic(topic string, partitions int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if _, exists := b.topics[topic]; exists {
+		return
+	}
+	if partitions <= 0 {
+		partitions = 1
+	}
+	b.topics[topic] = make([][]string, partitions)
+}
+
+// Publish add the message to the topic, the messages with the same key are in the same partition
+func (b *MemoryBroker) Publish(topic, key, value string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	partitions, exists := b.topics[topic]
+	if !exists {
+		return fmt.Errorf("topic %s does not exist", topic)
+	}
+
+	var partition int
+	if len(key) == 0 {
+		partition = b.robin % len(partitions)
+		b.robin++
+	} else {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(key))
+		partition = int(h.Sum32() % uint32(len(partitions)))
+	}
+	partitions[partition] = append(partitions[partition], value)
+	b.wakeup()
+	return nil
+}
+
+// NewReader join the consumer group of the topic, the partitions of the topic are reassigned to the members
+func (b *MemoryBroker) NewReader(topic, groupID string) (GroupReader, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	partitions, exists := b.topics[topic]
+	if !exists {
+		return nil, fmt.Errorf("topic %s does not exist", topic)
+	}
+
+	key := groupID + "/" + topic
+	group, exists := b.groups[key]
+	if !exists {
+		group = &memoryGroup{topic: topic, offsets: make([]int64, len(partitions))}
+		b.groups[key] = group
+	}
+	b.nextID++
+	reader := &memoryReader{broker: b, group: group, id: b.nextID, generation: -1}
+	group.members = append(group.members, reader)
+	b.rebalance(group)
+	return reader, nil
+}
+
+// Assignment returns the partitions assigned to each member of the group, in the joined order
+func (b *MemoryBroker) Assignment(topic, groupID string) [][]int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	group, exists := b.groups[groupID+"/"+topic]
+	if !exists {
+		return nil
+	}
+	assignment := make([][]int, len(group.members))
+	for index, member := range group.members {
+		assignment[index] = append([]int{}, member.partitions...)
+	}
+	return assignment
+}
+
+// rebalance assign the partitions to the members in round robin, must be called with the lock held
+func (b *MemoryBroker) rebalance(group *memoryGroup) {
+	sort.Slice(group.members, func(i, j int) bool {
+		return group.members[i].id < group.members[j].id
+	})
+	for _, member := range group.members {
+		member.partitions = make([]int, 0)
+	}
+	if len(group.members) > 0 {
+		for partition := range group.offsets {
+			member := group.members[partition%len(group.members)]
+			member.partitions = append(member.partitions, partition)
+		}
+	}
+	group.generation++
+	b.wakeup()
+}
+
+// wakeup notify the waiting readers, must be called with the lock held
+func (b *MemoryBroker) wakeup() {
+	close(b.notify)
+	b.notify = make(chan struct{})
+}
+
+// memoryReader a member of the consumer group on the memory broker
+type memoryReader struct {
+	broker     *MemoryBroker
+	group      *memoryGroup
+	id         int
+	partitions []int
+	// positions the offset of the next message to fetch of the assigned partitions
+	positions  map[int]int64
+	generation int
+	// next the index of the partition to fetch first, so that the partitions are fetched in turn
+	next   int
+	closed bool
+}
+
+func (r *memoryReader) Fetch(ctx context.Context) (GroupMessage, error) {
+	for {
+		r.broker.lock.Lock()
+		if r.closed {
+			r.broker.lock.Unlock()
+			return GroupMessage{}, ErrClosed
+		}
+
+		// the positions start from the committed offsets after rebalance, the uncommitted messages are fetched again
+		if r.generation != r.group.generation {
+			r.generation = r.group.generation
+			r.positions = make(map[int]int64)
+			for _, partition := range r.partitions {
+				r.positions[partition] = r.group.offsets[partition]
+			}
+		}
+
+		messages := r.broker.topics[r.group.topic]
+		for i := 0; i < len(r.partitions); i++ {
+			partition := r.partitions[(r.next+i)%len(r.partitions)]
+			offset := r.positions[partition]
+			if offset < int64(len(messages[partition])) {
+				r.positions[partition] = offset + 1
+				r.next = (r.next + i + 1) % len(r.partitions)
+				value := messages[partition][offset]
+				r.broker.lock.Unlock()
+				return GroupMessage{Partition: partition, Offset: offset, Value: value}, nil
+			}
+		}
+
+		notify := r.broker.notify
+		r.broker.lock.Unlock()
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return GroupMessage{}, ctx.Err()
+		}
+	}
+}
+
+// Commit the offset is saved only when the partition is still assigned to the reader
+func (r *memoryReader) Commit(ctx context.Context, mesg GroupMessage) error {
+	r.broker.lock.Lock()
+	defer r.broker.lock.Unlock()
+	if r.closed {
+		return ErrClosed
+	}
+	if r.generation != r.group.generation {
+		return fmt.Errorf("partition %d is reassigned, offset %d is not committed", mesg.Partition, mesg.Offset)
+	}
+	for _, partition := range r.partitions {
+		if partition == mesg.Partition && mesg.Offset+1 > r.group.offsets[partition] {
+			r.group.offsets[partition] = mesg.Offset + 1
+		}
+	}
+	return nil
+}
+
+func (r *memoryReader) Ping() error {
+	return nil
+}
+
+// Close leave the consumer group, the partitions are reassigned to the other members
+func (r *memoryReader) Close() error {
+	r.broker.lock.Lock()
+	defer r.broker.lock.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	for index, member := range r.group.members {
+		if member == r {
+			r.group.members = append(r.group.members[:index], r.group.members[index+1:]...)
+			break
+		}
+	}
+	r.broker.rebalance(r.group)
+	return nil
+}
@@ -0,0 +1,258 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package source
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBrokerAssignment(t *testing.T) {
+	broker := NewMemoryBroker()
+	broker.CreateTopic("snapshot", 4)
+
+	first, err := broker.NewReader("snapshot", "cmdb")
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{0, 1, 2, 3}}, broker.Assignment("snapshot", "cmdb"))
+
+	second, err := broker.NewReader("snapshot", "cmdb")
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{0, 2}, {1, 3}}, broker.Assignment("snapshot", "cmdb"))
+
+	// the other group consumes all the partitions by itself
+	_, err = broker.NewReader("snapshot", "other")
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{0, 1, 2, 3}}, broker.Assignment("snapshot", "other"))
+
+	require.NoError(t, first.Close())
+	require.Equal(t, [][]int{{0, 1, 2, 3}}, broker.Assignment("snapshot", "cmdb"))
+	require.NoError(t, second.Close())
+	require.Equal(t, [][]int{}, broker.Assignment("snapshot", "cmdb"))
+
+	_, err = broker.NewReader("not_exist", "cmdb")
+	require.Error(t, err)
+	require.Error(t, broker.Publish("not_exist", "", "mesg"))
+}
+
+func TestMemoryReaderResumeFromCommitted(t *testing.T) {
+	broker := NewMemoryBroker()
+	broker.CreateTopic("snapshot", 1)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, broker.Publish("snapshot", "host", fmt.Sprintf("mesg%d", i)))
+	}
+
+	ctx := context.Background()
+	first, err := broker.NewReader("snapshot", "cmdb")
+	require.NoError(t, err)
+	mesg, err := first.Fetch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "mesg0", mesg.Value)
+	require.NoError(t, first.Commit(ctx, mesg))
+	mesg, err = first.Fetch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "mesg1", mesg.Value)
+
+	// the member leaves without committing mesg1, the new member fetches it again
+	require.NoError(t, first.Close())
+	_, err = first.Fetch(ctx)
+	require.Equal(t, ErrClosed, err)
+
+	second, err := broker.NewReader("snapshot", "cmdb")
+	require.NoError(t, err)
+	mesg, err = second.Fetch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "mesg1", mesg.Value)
+	require.Equal(t, int64(1), mesg.Offset)
+}
+
+func TestMemoryReaderCommitAfterRebalance(t *testing.T) {
+	broker := NewMemoryBroker()
+	broker.CreateTopic("snapshot", 2)
+	require.NoError(t, broker.Publish("snapshot", "", "mesg"))
+
+	ctx := context.Background()
+	first, err := broker.NewReader("snapshot", "cmdb")
+	require.NoError(t, err)
+	mesg, err := first.Fetch(ctx)
+	require.NoError(t, err)
+
+	_, err = broker.NewReader("snapshot", "cmdb")
+	require.NoError(t, err)
+	require.Error(t, first.Commit(ctx, mesg))
+}
+
+func TestMemoryReaderFetchTimeout(t *testing.T) {
+	broker := NewMemoryBroker()
+	broker.CreateTopic("snapshot", 1)
+	reader, err := broker.NewReader("snapshot", "cmdb")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	_, err = reader.Fetch(ctx)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestGroupSourceConsume(t *testing.T) {
+	broker := NewMemoryBroker()
+	broker.CreateTopic("snapshot", 3)
+
+	const total = 100
+	var lock sync.Mutex
+	received := make(map[string]int)
+	consuming := func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received) < total
+	}
+	handle := func(mesg string) {
+		lock.Lock()
+		defer lock.Unlock()
+		received[mesg]++
+	}
+
+	var wg sync.WaitGroup
+	readers := make([]GroupReader, 0)
+	for i := 0; i < 2; i++ {
+		reader, err := broker.NewReader("snapshot", "cmdb")
+		require.NoError(t, err)
+		readers = append(readers, reader)
+		source := NewGroupSource("memory", reader, 2)
+		require.False(t, source.Exclusive())
+		require.NoError(t, source.Ping())
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = source.Consume(consuming, handle)
+		}()
+	}
+
+	for i := 0; i < total; i++ {
+		require.NoError(t, broker.Publish("snapshot", fmt.Sprintf("host%d", i), fmt.Sprintf("mesg%d", i)))
+	}
+	// close the readers to unblock the member who is waiting for the messages
+	deadline := time.Now().Add(time.Second * 5)
+	for consuming() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	for _, reader := range readers {
+		require.NoError(t, reader.Close())
+	}
+	wg.Wait()
+
+	require.Len(t, received, total)
+	for mesg, count := range received {
+		require.Equal(t, 1, count, mesg)
+	}
+}
+
+func TestGroupSourceWorkers(t *testing.T) {
+	broker := NewMemoryBroker()
+	broker.CreateTopic("snapshot", 4)
+	const hosts, perHost = 8, 20
+	for i := 0; i < perHost; i++ {
+		for host := 0; host < hosts; host++ {
+			require.NoError(t, broker.Publish("snapshot", fmt.Sprintf("host%d", host), fmt.Sprintf("host%d/%02d", host, i)))
+		}
+	}
+
+	defer func(timeout time.Duration) { fetchTimeout = timeout }(fetchTimeout)
+	fetchTimeout = time.Millisecond * 100
+
+	reader, err := broker.NewReader("snapshot", "cmdb")
+	require.NoError(t, err)
+	source := NewGroupSource("memory", reader, 3)
+
+	var lock sync.Mutex
+	received := make(map[string][]string)
+	running, maxRunning := 0, 0
+	handle := func(mesg string) {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		lock.Lock()
+		defer lock.Unlock()
+		running--
+		host := mesg[:strings.Index(mesg, "/")]
+		received[host] = append(received[host], mesg)
+	}
+	consuming := func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		count := 0
+		for _, mesgs := range received {
+			count += len(mesgs)
+		}
+		return count < hosts*perHost
+	}
+	require.NoError(t, source.Consume(consuming, handle))
+
+	// the messages of a partition are handled in order by the same worker, the workers run concurrently
+	require.Len(t, received, hosts)
+	for host, mesgs := range received {
+		require.Len(t, mesgs, perHost, host)
+		require.True(t, sort.StringsAreSorted(mesgs), host)
+	}
+	require.True(t, maxRunning > 1)
+
+	// all the handled messages are committed
+	group := broker.groups["cmdb/snapshot"]
+	for partition, offset := range group.offsets {
+		require.Equal(t, int64(len(broker.topics["snapshot"][partition])), offset)
+	}
+
+	// close leaves the group
+	require.NoError(t, source.Close())
+	require.Equal(t, [][]int{}, broker.Assignment("snapshot", "cmdb"))
+}
+
+func TestGroupSourceCommitFailed(t *testing.T) {
+	broker := NewMemoryBroker()
+	broker.CreateTopic("snapshot", 2)
+	require.NoError(t, broker.Publish("snapshot", "", "mesg"))
+
+	reader, err := broker.NewReader("snapshot", "cmdb")
+	require.NoError(t, err)
+	source := NewGroupSource("memory", reader, 2)
+
+	defer func(timeout time.Duration) { fetchTimeout = timeout }(fetchTimeout)
+	fetchTimeout = time.Millisecond * 100
+
+	// the partitions are reassigned while the message is handled, the commit fails and the consuming
+	// stops, the uncommitted message may be fetched and handled again before it stops.
+	var handled int32
+	handle := func(mesg string) {
+		if atomic.AddInt32(&handled, 1) == 1 {
+			_, err := broker.NewReader("snapshot", "cmdb")
+			require.NoError(t, err)
+		}
+	}
+	err = source.Consume(func() bool { return true }, handle)
+	require.Error(t, err)
+	require.True(t, atomic.LoadInt32(&handled) >= 1)
+}
@@ -0,0 +1,91 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package source
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"configcenter/src/common/blog"
+
+	"gopkg.in/redis.v5"
+)
+
+// redisSource subscribe the messages from the redis channels
+type redisSource struct {
+	cli      *redis.Client
+	channels []string
+}
+
+// NewRedisSource create a source which subscribes the redis channels
+func NewRedisSource(cli *redis.Client, channels []string) MessageSource {
+	return &redisSource{cli: cli, channels: channels}
+}
+
+func (s *redisSource) Name() string {
+	return "redis[" + strings.Join(s.channels, ",") + "]"
+}
+
+// Exclusive the subscribers of the redis channel receive all the messages, so only the master subscribes
+func (s *redisSource) Exclusive() bool {
+	return true
+}
+
+func (s *redisSource) Consume(consuming func() bool, handle func(mesg string)) error {
+	subChan, err := s.cli.Subscribe(s.channels...)
+	if nil != err {
+		return fmt.Errorf("subscribe channel failed, %v", err)
+	}
+	defer subChan.Unsubscribe(s.channels...)
+
+	blog.Infof("[data-collection] subscribing channel %v from redis", s.channels)
+	defer blog.Infof("[data-collection] unsubscribe channel %v from redis", s.channels)
+
+	var timeoutErr net.Error
+	var ok bool
+	var received interface{}
+	for consuming() {
+		received, err = subChan.ReceiveTimeout(time.Second * 10)
+		if err == redis.Nil || err == io.EOF {
+			continue
+		}
+		if timeoutErr, ok = err.(net.Error); ok && timeoutErr.Timeout() {
+			continue
+		}
+		if nil != err {
+			return fmt.Errorf("receive message from redis failed: %v", err)
+		}
+		msg, ok := received.(*redis.Message)
+		if !ok {
+			continue
+		}
+
+		if "" == msg.Payload {
+			continue
+		}
+		handle(msg.Payload)
+	}
+	return nil
+}
+
+func (s *redisSource) Ping() error {
+	return s.cli.Ping().Err()
+}
+
+// Close the redis client is shared with the others, it's closed by its owner
+func (s *redisSource) Close() error {
+	return nil
+}
@@ -0,0 +1,173 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package source provides the sources of the messages consumed by the datacollection porters.
+//
+// The redis source subscribes the channels written by the GSE data pipeline, which can only be
+// consumed by one process, so the porter consumes it when the process is the master.
+// The group sources (kafka and the in-memory broker) are consumed by all the processes of
+// the consumer group, the partitions of the topic are assigned to the processes, so that the
+// ingestion can scale horizontally. The messages of a group source are handled by a bounded pool of
+// workers, the messages of a partition are always handled by the same worker in order.
+package source
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+var (
+	// fetchTimeout the timeout of each fetch, the porter checks whether to go on consuming after it
+	fetchTimeout = time.Second * 10
+
+	// ErrClosed the source or the reader is closed
+	ErrClosed = errors.New("source closed")
+)
+
+// workerQueueSize the count of the fetched messages waiting for each worker of the group source
+const workerQueueSize = 64
+
+// MessageSource the source of the messages consumed by the porter
+type MessageSource interface {
+	// Name the name of the source, used for log
+	Name() string
+	// Exclusive returns true when the source can be consumed by only one process at the same time,
+	// then the porter consumes it only when the process is the master.
+	Exclusive() bool
+	// Consume receives the messages and calls handle for each message, it blocks until consuming
+	// returns false or an error occurs. the group sources call handle concurrently and commit the
+	// message after handle returns, so handle should return only after the message is processed.
+	Consume(consuming func() bool, handle func(mesg string)) error
+	// Ping check the connection to the source
+	Ping() error
+	// Close release the connection to the source, it can't be consumed any more
+	Close() error
+}
+
+// GroupMessage the message read by a member of the consumer group
+type GroupMessage struct {
+	Partition int
+	Offset    int64
+	Value     string
+
+	// raw the message of the underlying client
+	raw interface{}
+}
+
+// GroupReader reads the messages of the partitions assigned to it as a member of the consumer group
+type GroupReader interface {
+	// Fetch the next message, blocks until a message is available or the ctx is done
+	Fetch(ctx context.Context) (GroupMessage, error)
+	// Commit the message is handled, the member which gets the partition next time starts after it
+	Commit(ctx context.Context, mesg GroupMessage) error
+	// Ping check the connection to the broker
+	Ping() error
+	Close() error
+}
+
+// groupSource consume the messages as a member of the consumer group
+type groupSource struct {
+	name    string
+	reader  GroupReader
+	workers int
+}
+
+// NewGroupSource create a source which consumes the messages from the group reader with the workers,
+// the count of the cpus is used if workers is not positive.
+func NewGroupSource(name string, reader GroupReader, workers int) MessageSource {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &groupSource{name: name, reader: reader, workers: workers}
+}
+
+func (s *groupSource) Name() string {
+	return s.name
+}
+
+func (s *groupSource) Exclusive() bool {
+	return false
+}
+
+// Consume fetch the messages and dispatch them to the workers by partition, each worker handles its
+// messages in order and commits them after they are handled, so that the offsets of a partition are
+// committed in order and the messages are processed at least once. the fetched messages are all handled
+// before Consume returns.
+func (s *groupSource) Consume(consuming func() bool, handle func(mesg string)) error {
+	// each worker reports at most one error, so that it never blocks on the error channel
+	errC := make(chan error, s.workers)
+	queues := make([]chan GroupMessage, s.workers)
+	var wg sync.WaitGroup
+	for index := range queues {
+		queues[index] = make(chan GroupMessage, workerQueueSize)
+		wg.Add(1)
+		go func(queue chan GroupMessage) {
+			defer wg.Done()
+			reported := false
+			for mesg := range queue {
+				handle(mesg.Value)
+				if err := s.reader.Commit(context.Background(), mesg); err != nil && !reported {
+					errC <- err
+					reported = true
+				}
+			}
+		}(queues[index])
+	}
+
+	err := s.dispatch(consuming, queues, errC)
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+	if err == nil {
+		select {
+		case err = <-errC:
+		default:
+		}
+	}
+	return err
+}
+
+// dispatch fetch the messages to the queues of the workers until consuming returns false or an error occurs
+func (s *groupSource) dispatch(consuming func() bool, queues []chan GroupMessage, errC chan error) error {
+	for consuming() {
+		select {
+		case err := <-errC:
+			return err
+		default:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		mesg, err := s.reader.Fetch(ctx)
+		cancel()
+		if err == context.DeadlineExceeded {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		queues[mesg.Partition%len(queues)] <- mesg
+	}
+	return nil
+}
+
+func (s *groupSource) Ping() error {
+	return s.reader.Ping()
+}
+
+// Close leave the consumer group, the partitions are reassigned to the other members
+func (s *groupSource) Close() error {
+	return s.reader.Close()
+}
@@ -13,6 +13,7 @@
 package datacollection
 
 import (
+	"errors"
 	"time"
 
 	"configcenter/src/common"
@@ -40,12 +41,22 @@ const (
 	SnapShotChan = "snapshot"
 )
 
+var (
+	// ErrQueueFull the porter can not handle more messages, the reporter should retry later
+	ErrQueueFull = errors.New("message queue fulled")
+	// ErrPorterNotFound the porter does not exist or is not running
+	ErrPorterNotFound = errors.New("porter not found")
+)
+
 type Analyzer interface {
 	Analyze(mesg string) error
 }
 
 type Porter interface {
 	Name() string
+	// Run consume the messages until the process exits, returns nil after it's stopped
 	Run() error
 	Mock(string) error
+	// Push add the message reported by http to the analyze queue, returns ErrQueueFull when the porter is busy
+	Push(string) error
 }
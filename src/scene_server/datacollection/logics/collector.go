@@ -22,6 +22,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/thirdpartyclient/esbserver/nodeman"
@@ -33,6 +34,9 @@ import (
 const Netdevicebeat = "netdevicebeat"
 
 func (lgc *Logics) SearchCollector(header http.Header, cond metadata.ParamNetcollectorSearch) (int64, []metadata.Netcollector, error) {
+	if lgc.BuiltinSnmp {
+		return lgc.searchBuiltinCollector(header, cond)
+	}
 	collectors := make([]metadata.Netcollector, 0)
 
 	// fetch package info
@@ -150,10 +154,94 @@ func (lgc *Logics) SearchCollector(header http.Header, cond metadata.ParamNetcol
 		}
 		collector.Status.ConfigStatus = existsOne.Status.ConfigStatus
 		collector.Status.ReportStatus = existsOne.Status.ReportStatus
+		if collector.Config.Snmp != nil {
+			collector.Config.Snmp.MaskSecrets()
+		}
 	}
 	return int64(len(collectors)), collectors, nil
 }
 
+// searchBuiltinCollector returns the collectors saved in db, they are polled by the built-in snmp poller
+func (lgc *Logics) searchBuiltinCollector(header http.Header, param metadata.ParamNetcollectorSearch) (int64, []metadata.Netcollector, error) {
+	rid := util.GetHTTPCCRequestID(header)
+	cond := map[string]interface{}{}
+	if param.Query != "" {
+		cloudCond := condition.CreateCondition()
+		cloudCond.Field(common.BKCloudNameField).Like(param.Query)
+		clouds, err := lgc.findInst(header, common.BKInnerObjIDPlat, &metadata.QueryCondition{Condition: cloudCond.ToMapStr()})
+		if err != nil {
+			blog.Errorf("[NetDevice][SearchCollector] find clouds by %+v failed: %v, rid: %s", cloudCond.ToMapStr(), err, rid)
+			return 0, nil, err
+		}
+		queryCloudIDs := make([]int64, 0)
+		for _, cloud := range clouds {
+			id, err := cloud.Int64(common.BKCloudIDField)
+			if err != nil {
+				return 0, nil, err
+			}
+			queryCloudIDs = append(queryCloudIDs, id)
+		}
+		cond[common.BKDBOR] = []map[string]interface{}{
+			{
+				common.BKHostInnerIPField: map[string]interface{}{
+					common.BKDBLIKE: param.Query,
+				},
+			},
+			{
+				common.BKCloudIDField: map[string]interface{}{
+					common.BKDBIN: queryCloudIDs,
+				},
+			},
+		}
+	}
+
+	count, err := lgc.db.Table(common.BKTableNameNetcollectConfig).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[NetDevice][SearchCollector] count collectors by %+v failed: %v, rid: %s", cond, err, rid)
+		return 0, nil, err
+	}
+	collectors := make([]metadata.Netcollector, 0)
+	err = lgc.db.Table(common.BKTableNameNetcollectConfig).Find(cond).Sort(param.Page.Sort).Start(uint64(param.Page.Start)).
+		Limit(uint64(param.Page.Limit)).All(lgc.ctx, &collectors)
+	if err != nil {
+		blog.Errorf("[NetDevice][SearchCollector] find collectors by %+v failed: %v, rid: %s", cond, err, rid)
+		return 0, nil, err
+	}
+
+	cloudIDs := make([]int64, 0)
+	for _, collector := range collectors {
+		cloudIDs = append(cloudIDs, collector.CloudID)
+	}
+	cloudCond := map[string]interface{}{
+		common.BKCloudIDField: map[string]interface{}{
+			common.BKDBIN: cloudIDs,
+		},
+	}
+	cloudMap, err := lgc.findInstMap(header, common.BKInnerObjIDPlat, &metadata.QueryCondition{Condition: cloudCond})
+	if err != nil {
+		blog.Errorf("[NetDevice][SearchCollector] find clouds by %+v failed: %v, rid: %s", cloudCond, err, rid)
+		return 0, nil, err
+	}
+
+	for index := range collectors {
+		collector := &collectors[index]
+		if cloudInst, ok := cloudMap[collector.CloudID]; ok {
+			collector.CloudName, _ = cloudInst.String(common.BKCloudNameField)
+		}
+		collector.Status.CollectorStatus = metadata.CollectorStatusNormal
+		if collector.Status.ConfigStatus == "" {
+			collector.Status.ConfigStatus = metadata.CollectorConfigStatusPending
+		}
+		if collector.Status.ReportStatus == "" {
+			collector.Status.ReportStatus = metadata.CollectorReportStatusAbnormal
+		}
+		if collector.Config.Snmp != nil {
+			collector.Config.Snmp.MaskSecrets()
+		}
+	}
+	return int64(count), collectors, nil
+}
+
 func (lgc *Logics) queryCollectTask(header http.Header, bizID int64, taskID int64) (string, error) {
 	resp, err := lgc.ESB.NodemanSrv().SearchTask(context.Background(), header, bizID, taskID)
 	if err != nil {
@@ -197,6 +285,29 @@ func (lgc *Logics) findCollectorMap(cond interface{}) (map[string]metadata.Netco
 	return collectorMap, nil
 }
 
+// KeepCollectorSecrets fill the empty or masked snmp passphrases of the collector with the saved ones,
+// the passphrases are masked in the search result, so the caller can leave them unchanged.
+func (lgc *Logics) KeepCollectorSecrets(header http.Header, config *metadata.Netcollector) error {
+	if config.Config.Snmp == nil {
+		return nil
+	}
+	filter := map[string]interface{}{
+		common.BKCloudIDField:     config.CloudID,
+		common.BKHostInnerIPField: config.InnerIP,
+	}
+	saved := make([]metadata.Netcollector, 0)
+	if err := lgc.db.Table(common.BKTableNameNetcollectConfig).Find(filter).Limit(1).All(lgc.ctx, &saved); err != nil {
+		blog.Errorf("[UpdateCollector] find collector by %+v failed: %v, rid: %s", filter, err, util.GetHTTPCCRequestID(header))
+		return err
+	}
+	var savedSnmp *metadata.NetcollectSnmp
+	if len(saved) > 0 {
+		savedSnmp = saved[0].Config.Snmp
+	}
+	config.Config.Snmp.KeepSecrets(savedSnmp)
+	return nil
+}
+
 func (lgc *Logics) UpdateCollector(header http.Header, config metadata.Netcollector) error {
 	filter := map[string]interface{}{
 		common.BKCloudIDField:     config.CloudID,
@@ -208,25 +319,34 @@ func (lgc *Logics) UpdateCollector(header http.Header, config metadata.Netcollec
 		blog.Errorf("[UpdateCollector] count by %+v error: %v", filter, err)
 		return err
 	}
+	if lgc.BuiltinSnmp {
+		// the built-in snmp poller polls the pending collector at once
+		config.Status.ConfigStatus = metadata.CollectorConfigStatusPending
+	}
 	if count > 0 {
 		err = lgc.db.Table(common.BKTableNameNetcollectConfig).Update(lgc.ctx, filter, config)
 		if err != nil {
 			blog.Errorf("[UpdateCollector] UpdateByCondition by %+v to %+v error: %v", filter, config, err)
 			return err
 		}
-		return lgc.DiscoverNetDevice(header, []metadata.Netcollector{config})
+	} else {
+		err = lgc.db.Table(common.BKTableNameNetcollectConfig).Insert(lgc.ctx, config)
+		if err != nil {
+			blog.Errorf("[UpdateCollector] Insert %+v error: %v", config, err)
+			return err
+		}
 	}
-
-	err = lgc.db.Table(common.BKTableNameNetcollectConfig).Insert(lgc.ctx, config)
-	if err != nil {
-		blog.Errorf("[UpdateCollector] Insert %+v error: %v", config, err)
-		return err
+	if lgc.BuiltinSnmp {
+		return nil
 	}
 
 	return lgc.DiscoverNetDevice(header, []metadata.Netcollector{config})
 }
 
 func (lgc *Logics) DiscoverNetDevice(header http.Header, configs []metadata.Netcollector) error {
+	if lgc.BuiltinSnmp {
+		return lgc.discoverBuiltin(configs)
+	}
 
 	// fetch global_params
 	pkgResp, err := lgc.ESB.NodemanSrv().SearchPackage(context.Background(), header, Netdevicebeat)
@@ -314,6 +434,24 @@ func (lgc *Logics) DiscoverNetDevice(header http.Header, configs []metadata.Netc
 	return err
 }
 
+// discoverBuiltin mark the collectors as pending, the built-in snmp poller polls them at once
+func (lgc *Logics) discoverBuiltin(configs []metadata.Netcollector) error {
+	for _, config := range configs {
+		filter := map[string]interface{}{
+			common.BKCloudIDField:     config.CloudID,
+			common.BKHostInnerIPField: config.InnerIP,
+		}
+		data := map[string]interface{}{
+			"status.config_status": metadata.CollectorConfigStatusPending,
+		}
+		if err := lgc.db.Table(common.BKTableNameNetcollectConfig).Update(lgc.ctx, filter, data); err != nil {
+			blog.Errorf("[NetDevice][DiscoverNetDevice] mark collector %+v pending failed: %v", filter, err)
+			return err
+		}
+	}
+	return nil
+}
+
 func (lgc *Logics) saveCollectTask(collector *metadata.Netcollector, taskID int64, status string) error {
 	data := map[string]interface{}{
 		"task_id":              taskID,
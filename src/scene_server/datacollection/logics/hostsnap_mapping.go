@@ -0,0 +1,175 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateHostSnapMapping create a mapping from the host snapshot to a host attribute
+func (lgc *Logics) CreateHostSnapMapping(header http.Header, mapping meta.HostSnapMapping) (*meta.HostSnapMapping, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	rid := util.GetHTTPCCRequestID(header)
+
+	if err := lgc.validHostSnapMapping(header, &mapping); err != nil {
+		return nil, err
+	}
+
+	id, err := lgc.db.NextSequence(lgc.ctx, common.BKTableNameHostSnapMapping)
+	if err != nil {
+		blog.Errorf("[HostSnapMapping] create mapping failed, get id failed, err: %v, rid: %s", err, rid)
+		return nil, defErr.Error(common.CCErrCollectHostSnapMappingCreateFail)
+	}
+	now := time.Now()
+	mapping.ID = int64(id)
+	mapping.OwnerID = util.GetOwnerID(header)
+	mapping.CreateTime = now
+	mapping.LastTime = now
+	if err := lgc.db.Table(common.BKTableNameHostSnapMapping).Insert(lgc.ctx, mapping); err != nil {
+		blog.Errorf("[HostSnapMapping] create mapping failed, mapping: %+v, err: %v, rid: %s", mapping, err, rid)
+		return nil, defErr.Error(common.CCErrCollectHostSnapMappingCreateFail)
+	}
+	return &mapping, nil
+}
+
+// UpdateHostSnapMapping update the mapping, all the fields except id and owner are replaced
+func (lgc *Logics) UpdateHostSnapMapping(header http.Header, id int64, mapping meta.HostSnapMapping) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	rid := util.GetHTTPCCRequestID(header)
+
+	if err := lgc.validHostSnapMapping(header, &mapping); err != nil {
+		return err
+	}
+
+	cond := map[string]interface{}{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: util.GetOwnerID(header),
+	}
+	cnt, err := lgc.db.Table(common.BKTableNameHostSnapMapping).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[HostSnapMapping] update mapping %d failed, count failed, err: %v, rid: %s", id, err, rid)
+		return defErr.Error(common.CCErrCollectHostSnapMappingUpdateFail)
+	}
+	if cnt == 0 {
+		blog.Errorf("[HostSnapMapping] update mapping %d failed, not exist, rid: %s", id, rid)
+		return defErr.Error(common.CCErrCollectHostSnapMappingNotExist)
+	}
+
+	data := map[string]interface{}{
+		"path":                   mapping.Path,
+		"transform":              mapping.Transform,
+		"unit_from":              mapping.UnitFrom,
+		"unit_to":                mapping.UnitTo,
+		common.BKPropertyIDField: mapping.PropertyID,
+		"overwrite":              mapping.Overwrite,
+		"enabled":                mapping.Enabled,
+		common.LastTimeField:     time.Now(),
+	}
+	if err := lgc.db.Table(common.BKTableNameHostSnapMapping).Update(lgc.ctx, cond, data); err != nil {
+		blog.Errorf("[HostSnapMapping] update mapping %d failed, data: %+v, err: %v, rid: %s", id, data, err, rid)
+		return defErr.Error(common.CCErrCollectHostSnapMappingUpdateFail)
+	}
+	return nil
+}
+
+// DeleteHostSnapMapping delete the mapping
+func (lgc *Logics) DeleteHostSnapMapping(header http.Header, id int64) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	rid := util.GetHTTPCCRequestID(header)
+
+	cond := map[string]interface{}{
+		common.BKFieldID:      id,
+		common.BKOwnerIDField: util.GetOwnerID(header),
+	}
+	if err := lgc.db.Table(common.BKTableNameHostSnapMapping).Delete(lgc.ctx, cond); err != nil {
+		blog.Errorf("[HostSnapMapping] delete mapping %d failed, err: %v, rid: %s", id, err, rid)
+		return defErr.Error(common.CCErrCollectHostSnapMappingDeleteFail)
+	}
+	return nil
+}
+
+// SearchHostSnapMapping search the mappings of the owner
+func (lgc *Logics) SearchHostSnapMapping(header http.Header, option meta.HostSnapMappingSearchOption) (*meta.HostSnapMappingSearchResult, error) {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	rid := util.GetHTTPCCRequestID(header)
+
+	cond := map[string]interface{}{
+		common.BKOwnerIDField: util.GetOwnerID(header),
+	}
+	if option.PropertyID != "" {
+		cond[common.BKPropertyIDField] = option.PropertyID
+	}
+	if option.Enabled != nil {
+		cond["enabled"] = *option.Enabled
+	}
+
+	cnt, err := lgc.db.Table(common.BKTableNameHostSnapMapping).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[HostSnapMapping] search mapping failed, count failed, cond: %+v, err: %v, rid: %s", cond, err, rid)
+		return nil, defErr.Error(common.CCErrCollectHostSnapMappingSearchFail)
+	}
+
+	mappings := make([]meta.HostSnapMapping, 0)
+	sort := option.Page.Sort
+	if sort == "" {
+		sort = common.BKFieldID
+	}
+	query := lgc.db.Table(common.BKTableNameHostSnapMapping).Find(cond).Sort(sort).Start(uint64(option.Page.Start))
+	if option.Page.Limit > 0 {
+		query = query.Limit(uint64(option.Page.Limit))
+	}
+	if err := query.All(lgc.ctx, &mappings); err != nil {
+		blog.Errorf("[HostSnapMapping] search mapping failed, cond: %+v, err: %v, rid: %s", cond, err, rid)
+		return nil, defErr.Error(common.CCErrCollectHostSnapMappingSearchFail)
+	}
+	return &meta.HostSnapMappingSearchResult{Count: int64(cnt), Info: mappings}, nil
+}
+
+// validHostSnapMapping valid the mapping, the target must be an attribute of host
+func (lgc *Logics) validHostSnapMapping(header http.Header, mapping *meta.HostSnapMapping) error {
+	defErr := lgc.Engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	rid := util.GetHTTPCCRequestID(header)
+
+	if field, err := mapping.Validate(); err != nil {
+		blog.Errorf("[HostSnapMapping] mapping %+v is invalid, err: %v, rid: %s", mapping, err, rid)
+		return defErr.Errorf(common.CCErrCommParamsInvalid, field)
+	}
+	if mapping.Transform == "" {
+		mapping.Transform = meta.HostSnapTransformFirst
+	}
+	if mapping.Overwrite == "" {
+		mapping.Overwrite = meta.HostSnapOverwriteAlways
+	}
+
+	cond := map[string]interface{}{
+		common.BKObjIDField:      common.BKInnerObjIDHost,
+		common.BKPropertyIDField: mapping.PropertyID,
+	}
+	cond = util.SetModOwner(cond, util.GetOwnerID(header))
+	cnt, err := lgc.db.Table(common.BKTableNameObjAttDes).Find(cond).Count(lgc.ctx)
+	if err != nil {
+		blog.Errorf("[HostSnapMapping] get host attribute %s failed, err: %v, rid: %s", mapping.PropertyID, err, rid)
+		return defErr.Error(common.CCErrCommDBSelectFailed)
+	}
+	if cnt == 0 {
+		blog.Errorf("[HostSnapMapping] host attribute %s does not exist, rid: %s", mapping.PropertyID, rid)
+		return defErr.Errorf(common.CCErrCommParamsInvalid, common.BKPropertyIDField)
+	}
+	return nil
+}
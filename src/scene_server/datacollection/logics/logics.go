@@ -26,6 +26,8 @@ type Logics struct {
 	db  dal.RDB
 	ESB esbserver.EsbClientInterface
 	ctx context.Context
+	// BuiltinSnmp the network devices are polled by the built-in snmp poller instead of the netdevicebeat plugin
+	BuiltinSnmp bool
 }
 
 func NewLogics(ctx context.Context, engine *backbone.Engine, mgoCli dal.RDB, esb esbserver.EsbClientInterface) *Logics {
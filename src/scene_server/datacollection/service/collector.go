@@ -71,6 +71,20 @@ func (s *Service) UpdateCollector(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	if cond.Config.Snmp != nil {
+		// the passphrases are masked in the search result, the empty or masked ones are left unchanged
+		if err := s.Logics.KeepCollectorSecrets(pheader, &cond); err != nil {
+			resp.WriteError(http.StatusInternalServerError,
+				&metadata.RespError{Msg: defErr.Error(common.CCErrCollectNetCollectorUpdateFail)})
+			return
+		}
+		if field, err := cond.Config.Snmp.Validate(); err != nil {
+			blog.Errorf("[NetDevice][UpdateCollector] snmp config invalid, field: %s, err: %v", field, err)
+			resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, field)})
+			return
+		}
+	}
+
 	err := s.Logics.UpdateCollector(pheader, cond)
 	if err != nil {
 		resp.WriteError(http.StatusInternalServerError,
@@ -0,0 +1,119 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// CreateHostSnapMapping create a mapping from host snapshot to host attribute
+func (s *Service) CreateHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	mapping := meta.HostSnapMapping{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&mapping); nil != err {
+		blog.Errorf("[HostSnapMapping] create mapping failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.CreateHostSnapMapping(pHeader, mapping)
+	if nil != err {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(result))
+}
+
+// UpdateHostSnapMapping update a mapping from host snapshot to host attribute
+func (s *Service) UpdateHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	id, err := strconv.ParseInt(req.PathParameter(common.BKFieldID), 10, 64)
+	if nil != err {
+		blog.Errorf("[HostSnapMapping] update mapping failed, invalid id %s, rid: %s", req.PathParameter(common.BKFieldID), rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+
+	mapping := meta.HostSnapMapping{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&mapping); nil != err {
+		blog.Errorf("[HostSnapMapping] update mapping failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := s.Logics.UpdateHostSnapMapping(pHeader, id, mapping); nil != err {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// DeleteHostSnapMapping delete a mapping from host snapshot to host attribute
+func (s *Service) DeleteHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	id, err := strconv.ParseInt(req.PathParameter(common.BKFieldID), 10, 64)
+	if nil != err {
+		blog.Errorf("[HostSnapMapping] delete mapping failed, invalid id %s, rid: %s", req.PathParameter(common.BKFieldID), rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+
+	if err := s.Logics.DeleteHostSnapMapping(pHeader, id); nil != err {
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(nil))
+}
+
+// SearchHostSnapMapping search the mappings from host snapshot to host attribute
+func (s *Service) SearchHostSnapMapping(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	option := meta.HostSnapMappingSearchOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); nil != err {
+		blog.Errorf("[HostSnapMapping] search mapping failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.Logics.SearchHostSnapMapping(pHeader, option)
+	if nil != err {
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(meta.NewSuccessResp(result))
+}
@@ -0,0 +1,170 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/datacollection/datacollection"
+)
+
+const (
+	// maxPushBodySize the max size of the report body, the snapshot of a host is about 14kB
+	maxPushBodySize = 32 << 20
+	// maxPushBatchSize the max count of reports in a batch
+	maxPushBatchSize = 1000
+	// pushRetryAfterSeconds the seconds the reporter should wait when the queue is full
+	pushRetryAfterSeconds = "5"
+)
+
+// ReportPusher push the reported messages to the porters
+type ReportPusher interface {
+	Push(porter string, mesgs ...string) (int, error)
+}
+
+// PushResult the result of the pushed reports, the reports after the accepted ones should be retried
+type PushResult struct {
+	Accepted int `json:"accepted"`
+	Total    int `json:"total"`
+}
+
+// BatchPushOption the reports in the same format as the snapshot channel
+type BatchPushOption struct {
+	Reports []json.RawMessage `json:"reports"`
+}
+
+// SetPusher enable the http report, the reporter must carry one of the tokens
+func (s *Service) SetPusher(pusher ReportPusher, tokens []string) {
+	s.pusher = pusher
+	s.pushTokens = tokens
+}
+
+// pushAuthFilter check the token of the reporter, the reporter is not a cmdb user, so the global filter is not used
+func (s *Service) pushAuthFilter(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	if rid == "" {
+		rid = util.GenerateRID()
+		req.Request.Header.Set(common.BKHTTPCCRequestID, rid)
+	}
+	resp.Header().Set(common.BKHTTPCCRequestID, rid)
+
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	token := strings.TrimSpace(strings.TrimPrefix(req.Request.Header.Get("Authorization"), "Bearer "))
+	if len(token) == 0 || !s.validPushToken(token) {
+		blog.Errorf("[push] invalid report token from %s, rid: %s", req.Request.RemoteAddr, rid)
+		_ = resp.WriteError(http.StatusUnauthorized, &meta.RespError{Msg: defErr.Error(common.CCErrCollectPushTokenInvalid)})
+		return
+	}
+	fchain.ProcessFilter(req, resp)
+}
+
+func (s *Service) validPushToken(token string) bool {
+	valid := false
+	for _, t := range s.pushTokens {
+		if len(t) != 0 && subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// PushReport push a report to the porter
+func (s *Service) PushReport(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(resp, req.Request.Body, maxPushBodySize))
+	if err != nil {
+		blog.Errorf("[push] read report body failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommHTTPReadBodyFailed)})
+		return
+	}
+	if !json.Valid(body) {
+		blog.Errorf("[push] report body is not valid json, rid: %s", rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	s.push(req, resp, []string{string(body)})
+}
+
+// BatchPushReport push the reports to the porter in order
+func (s *Service) BatchPushReport(req *restful.Request, resp *restful.Response) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+
+	option := BatchPushOption{}
+	if err := json.NewDecoder(http.MaxBytesReader(resp, req.Request.Body, maxPushBodySize)).Decode(&option); err != nil {
+		blog.Errorf("[push] batch push failed with decode body err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if len(option.Reports) > maxPushBatchSize {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Errorf(common.CCErrCommXXExceedLimit, "reports", maxPushBatchSize)})
+		return
+	}
+
+	mesgs := make([]string, len(option.Reports))
+	for index, report := range option.Reports {
+		mesgs[index] = string(report)
+	}
+	s.push(req, resp, mesgs)
+}
+
+func (s *Service) push(req *restful.Request, resp *restful.Response, mesgs []string) {
+	pHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pHeader))
+	rid := util.GetHTTPCCRequestID(pHeader)
+	name := req.PathParameter("porter")
+
+	if s.pusher == nil {
+		_ = resp.WriteError(http.StatusNotFound, &meta.RespError{Msg: defErr.Errorf(common.CCErrCollectPushPorterNotFound, name)})
+		return
+	}
+
+	accepted, err := s.pusher.Push(name, mesgs...)
+	result := PushResult{Accepted: accepted, Total: len(mesgs)}
+	switch err {
+	case nil:
+		_ = resp.WriteEntity(meta.NewSuccessResp(result))
+	case datacollection.ErrPorterNotFound:
+		_ = resp.WriteError(http.StatusNotFound, &meta.RespError{Msg: defErr.Errorf(common.CCErrCollectPushPorterNotFound, name)})
+	case datacollection.ErrQueueFull:
+		// backpressure, the reporter should retry the rejected reports later
+		blog.Warnf("[push] porter %s is busy, accepted %d of %d reports, rid: %s", name, accepted, len(mesgs), rid)
+		resp.Header().Set("Retry-After", pushRetryAfterSeconds)
+		_ = resp.WriteHeaderAndEntity(http.StatusTooManyRequests, meta.Response{
+			BaseResp: meta.BaseResp{
+				Result: false,
+				Code:   common.CCErrCollectPushQueueFull,
+				ErrMsg: defErr.Error(common.CCErrCollectPushQueueFull).Error(),
+			},
+			Data: result,
+		})
+	default:
+		blog.Errorf("[push] push reports to porter %s failed, err: %v, rid: %s", name, err, rid)
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+	}
+}
@@ -35,6 +35,9 @@ type Service struct {
 	disCli  *redis.Client
 	netCli  *redis.Client
 	*logics.Logics
+
+	pusher     ReportPusher
+	pushTokens []string
 }
 
 func (s *Service) SetDB(db dal.RDB) {
@@ -89,8 +92,20 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/netcollect/collector/action/update").To(s.UpdateCollector))
 	api.Route(api.POST("/netcollect/collector/action/discover").To(s.DiscoverNetDevice))
 
+	api.Route(api.POST("/hostsnap/mapping/action/create").To(s.CreateHostSnapMapping))
+	api.Route(api.POST("/hostsnap/mapping/{id}/action/update").To(s.UpdateHostSnapMapping))
+	api.Route(api.DELETE("/hostsnap/mapping/{id}/action/delete").To(s.DeleteHostSnapMapping))
+	api.Route(api.POST("/hostsnap/mapping/action/search").To(s.SearchHostSnapMapping))
+
 	container.Add(api)
 
+	// the reports are pushed by the agents directly, which are authenticated by the token
+	pushAPI := new(restful.WebService)
+	pushAPI.Path("/report/v3").Filter(s.Engine.Metric().RestfulMiddleWare).Filter(s.pushAuthFilter).Produces(restful.MIME_JSON)
+	pushAPI.Route(pushAPI.POST("/{porter}/action/push").To(s.PushReport))
+	pushAPI.Route(pushAPI.POST("/{porter}/action/batch").To(s.BatchPushReport))
+	container.Add(pushAPI)
+
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(s.Healthz))
 	container.Add(healthzAPI)
@@ -16,6 +16,7 @@ import (
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/scene_server/event_server/identifier/sink"
 	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/redis"
 	"configcenter/src/storage/rpc"
@@ -42,7 +43,7 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.AddrPort, "addrport", "127.0.0.1:60009", "The ip address and port for the serve on")
 	// fs.UintVar(&s.ServConf.Port, "port", 60009, "The port for the serve on")
 	fs.StringVar(&s.ServConf.ExConfig, "config", "", "The config path. e.g conf/api.conf")
-	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181")
+	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181, etcd://127.0.0.1:2379 for etcd, or file:///data/cmdb/discovery.yaml for the static discovery")
 	fs.Var(auth.EnableAuthFlag, "enable-auth", "The auth center enable status, true for enabled, false for disabled")
 }
 
@@ -51,4 +52,6 @@ type Config struct {
 	Redis   redis.Config
 	RPC     rpc.ClientConfig
 	Auth    authcenter.AuthConfig
+	// IdentifierSink the sinks that host identifiers are pushed to
+	IdentifierSink sink.Config
 }
@@ -29,6 +29,7 @@ import (
 	"configcenter/src/common/version"
 	"configcenter/src/scene_server/event_server/app/options"
 	"configcenter/src/scene_server/event_server/distribution"
+	"configcenter/src/scene_server/event_server/identifier/sink"
 	svc "configcenter/src/scene_server/event_server/service"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/mongo"
@@ -78,7 +79,15 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		if process.Config.MongoDB.Enable == "true" {
 			db, err = local.NewMgo(process.Config.MongoDB.BuildURI(), time.Minute)
 		} else {
-			rpcCli, err = rpc.NewClientPool("tcp", engine.ServiceManageInterface.TMServer().GetServers, "/txn/v3/rpc")
+			var rpcOpts *rpc.Options
+			rpcConfig := rpc.NewConfigLoader("rpc")
+			engine.AddConfigUpdateHook(rpcConfig.Reload)
+			rpcOpts, err = rpcConfig.Config().ClientOptions()
+			if err != nil {
+				return fmt.Errorf("load rpc security options failed, err: %s", err.Error())
+			}
+			rpcCli, err = rpc.NewClientPoolWithOptions("tcp", engine.ServiceManageInterface.TMServer().GetServers,
+				"/txn/v3/rpc", rpcOpts)
 			if err != nil {
 				return fmt.Errorf("connect rpc server failed, err: %s", err.Error())
 			}
@@ -112,7 +121,7 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		}()
 
 		go func() {
-			errCh <- distribution.Start(ctx, cache, db, rpcCli)
+			errCh <- distribution.Start(ctx, cache, db, rpcCli, process.Config.IdentifierSink)
 		}()
 
 		break
@@ -162,6 +171,11 @@ func (h *EventServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		if err != nil {
 			blog.Errorf("parse auth center config failed: %v", err)
 		}
+
+		h.Config.IdentifierSink, err = sink.ParseConfigFromKV("identifier", current.ConfigMap)
+		if err != nil {
+			blog.Errorf("parse identifier sink config failed: %v", err)
+		}
 	}
 }
 
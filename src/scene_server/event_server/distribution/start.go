@@ -25,11 +25,12 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/event_server/identifier"
+	"configcenter/src/scene_server/event_server/identifier/sink"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/rpc"
 )
 
-func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client) error {
+func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client, sinkConf sink.Config) error {
 	chErr := make(chan error, 1)
 	err := migrateIDToMongo(ctx, cache, db)
 	if err != nil {
@@ -46,7 +47,11 @@ func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client)
 		chErr <- dh.StartDistribute()
 	}()
 
-	ih := identifier.NewIdentifierHandler(ctx, cache, db)
+	sinks, err := sink.NewManager(sinkConf, cache)
+	if err != nil {
+		return fmt.Errorf("new identifier sink manager failed: %v", err)
+	}
+	ih := identifier.NewIdentifierHandler(ctx, cache, db, sinks)
 	go func() {
 		chErr <- ih.Run()
 	}()
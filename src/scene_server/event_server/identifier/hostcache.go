@@ -137,6 +137,7 @@ func fillIdentifier(identifier *metadata.HostIdentifier, ctx context.Context, ca
 		process.Protocol = getString(proc.data[common.BKProtocol])
 		process.Port = getString(proc.data[common.BKPort])
 		process.StartParamRegex = getString(proc.data[common.BKStartParamRegex])
+		process.BindInfo = getProcBindInfo(proc.data[common.BKProcBindInfo])
 	}
 
 	return identifier, nil
@@ -26,6 +26,7 @@ import (
 	"configcenter/src/common/condition"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/identifier/sink"
 	"configcenter/src/scene_server/event_server/types"
 	"configcenter/src/storage/dal"
 
@@ -58,6 +59,7 @@ var hostIndentDiffFields = map[string][]string{
 		common.BKProtocol,
 		common.BKPort,
 		common.BKStartParamRegex,
+		common.BKProcBindInfo,
 	},
 	common.BKInnerObjIDHost: {
 		common.BKHostNameField,
@@ -81,6 +83,31 @@ func (ih *IdentifierHandler) handleEvent(event *metadata.EventInstCtx) {
 		(event.ObjType == metadata.EventObjTypeModuleTransfer ||
 			event.ObjType == metadata.EventObjTypeProcModule) {
 		ih.handleHostRelationChange(event)
+	} else if event.ObjType == common.BKInnerObjIDHost &&
+		event.Action == metadata.EventActionDelete &&
+		event.EventType == metadata.EventTypeInstData {
+		ih.handleHostDelete(event)
+	}
+}
+
+// handleHostDelete tell the sinks that the hosts are deleted, so that the identifiers of them can be removed
+func (ih *IdentifierHandler) handleHostDelete(event *metadata.EventInstCtx) {
+	if ih.sinks.Enabled() == false {
+		return
+	}
+	rid := util.ExtractRequestIDFromContext(ih.ctx)
+	for _, data := range event.Data {
+		preData, ok := data.PreData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hostID, err := getInt(preData, common.BKHostIDField)
+		if err != nil || 0 == hostID {
+			blog.Errorf("identifier: convert hostID failed the raw is %+v, rid: %s", preData[common.BKHostIDField], rid)
+			continue
+		}
+		revision := ih.cache.Incr(types.EventCacheEventIDKey).Val()
+		ih.sinks.Push(sink.NewDeletedPayload(revision, hostID))
 	}
 }
 
@@ -144,6 +171,7 @@ func (ih *IdentifierHandler) handleInstFieldChange(event *metadata.EventInstCtx,
 
 			ih.cache.LPush(types.EventCacheEventQueueKey, &hostIdentify)
 			blog.InfoJSON("identifier: pushed event inst %s, rid: %s", hostIdentify, rid)
+			ih.pushToSinks(hostIdentify)
 		} else {
 			if err := ih.handleRelatedInst(hostIdentify, event.ObjType, instID); err != nil {
 				blog.Warnf("handleRelatedInst failed objType: %s, inst: %d, error: %v, rid: %s", event.ObjType, instID, err, rid)
@@ -279,11 +307,24 @@ func (ih *IdentifierHandler) handleHostBatch(hostIdentify metadata.EventInst, ho
 		} else {
 			blog.InfoJSON("identifier: pushed event inst %s, rid: %s", hostIdentify, rid)
 		}
+		ih.pushToSinks(hostIdentify)
 
 	}
 	return nil
 }
 
+// pushToSinks push the changed host identifiers to the identifier sinks, the event id is used as their revision
+func (ih *IdentifierHandler) pushToSinks(hostIdentify metadata.EventInst) {
+	if ih.sinks.Enabled() == false {
+		return
+	}
+	for _, data := range hostIdentify.Data {
+		if ident, ok := data.CurData.(*metadata.HostIdentifier); ok == true && ident != nil {
+			ih.sinks.Push(sink.NewPayload(hostIdentify.ID, ident))
+		}
+	}
+}
+
 func getInt(data map[string]interface{}, key string) (int64, error) {
 	i, err := util.GetInt64ByInterface(data[key])
 	if err != nil {
@@ -299,6 +340,23 @@ func getString(value interface{}) string {
 	return fmt.Sprintf("%s", value)
 }
 
+// getProcBindInfo convert the bind info of process stored in db or cache to struct
+func getProcBindInfo(value interface{}) []metadata.ProcBindInfo {
+	bindInfo := make([]metadata.ProcBindInfo, 0)
+	if value == nil {
+		return bindInfo
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		blog.Errorf("identifier: marshal process bind info %+v failed, err: %v", value, err)
+		return bindInfo
+	}
+	if err := json.Unmarshal(out, &bindInfo); err != nil {
+		blog.Errorf("identifier: unmarshal process bind info %s failed, err: %v", out, err)
+	}
+	return bindInfo
+}
+
 func (ih *IdentifierHandler) findHost(objType string, instID int64) (hostIDs []int64, err error) {
 	rid := util.ExtractRequestIDFromContext(ih.ctx)
 	relations := make([]metadata.ModuleHost, 0)
@@ -576,6 +634,11 @@ func getHostIdentifierProcInfo(ctx context.Context, db dal.RDB, hostIDs []int64)
 
 func (ih *IdentifierHandler) Run() error {
 	blog.Infof("identifier: handle identifiers started")
+	go func() {
+		if err := ih.sinks.Run(ih.ctx); err != nil {
+			blog.Errorf("identifier sink stopped, err: %+v", err)
+		}
+	}()
 	go func() {
 		ih.fetchHostCache()
 	}()
@@ -700,6 +763,11 @@ func (ih *IdentifierHandler) fetchHostCache() {
 		relationMap[relate.HostID] = append(relationMap[relate.HostID], relate)
 	}
 
+	// all hosts are pushed to the sinks with the same revision on a full sync
+	var revision int64
+	if ih.sinks.FullSyncOnStart() {
+		revision = ih.cache.Incr(types.EventCacheEventIDKey).Val()
+	}
 	for _, ident := range hosts {
 		ident.HostIdentModule = map[string]*metadata.HostIdentModule{}
 		for _, relate := range relationMap[ident.HostID] {
@@ -721,13 +789,17 @@ func (ih *IdentifierHandler) fetchHostCache() {
 			blog.Errorf("set cache error %s, rid: %s", err.Error(), rid)
 			continue
 		}
+		if ih.sinks.FullSyncOnStart() {
+			ih.sinks.Push(sink.NewPayload(revision, ident))
+		}
 	}
 	blog.Infof("identifier: fetched %d hosts", len(hosts))
 }
 
 func hasChanged(curData, preData map[string]interface{}, fields ...string) (isDifferent bool) {
 	for _, field := range fields {
-		if curData[field] != preData[field] {
+		// the value may be a list, like the bind info of process, which can not be compared by operator
+		if reflect.DeepEqual(curData[field], preData[field]) == false {
 			return true
 		}
 	}
@@ -738,10 +810,11 @@ type IdentifierHandler struct {
 	cache *redis.Client
 	db    dal.RDB
 	ctx   context.Context
+	sinks *sink.Manager
 }
 
-func NewIdentifierHandler(ctx context.Context, cache *redis.Client, db dal.RDB) *IdentifierHandler {
-	return &IdentifierHandler{ctx: ctx, cache: cache, db: db}
+func NewIdentifierHandler(ctx context.Context, cache *redis.Client, db dal.RDB, sinks *sink.Manager) *IdentifierHandler {
+	return &IdentifierHandler{ctx: ctx, cache: cache, db: db, sinks: sinks}
 }
 
 func getInstCacheKey(objType string, instID int64) string {
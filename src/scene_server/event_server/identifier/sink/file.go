@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/util"
+)
+
+// fileSink writes the identifier of a host to a file named by each inner ip of the host,
+// so that the tooling on the host can read it's own identifier by it's cloud id and ip.
+// files of the ips a host no longer has are removed, so are all the files of a deleted host.
+type fileSink struct {
+	dir string
+	// hostFiles the files written for each host, fileHosts the host each file is written for
+	hostFiles map[int64][]string
+	fileHosts map[string]int64
+}
+
+func NewFileSink(dir string) Sink {
+	s := &fileSink{
+		dir:       dir,
+		hostFiles: make(map[int64][]string),
+		fileHosts: make(map[string]int64),
+	}
+	s.loadFiles()
+	return s
+}
+
+// loadFiles find out the files written before restart, so that they can be removed when they are outdated
+func (s *fileSink) loadFiles() {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*", "*.json"))
+	if err != nil {
+		blog.Errorf("identifier sink: list identifier files in %s failed, err: %v", s.dir, err)
+		return
+	}
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			blog.Errorf("identifier sink: read identifier file %s failed, err: %v", path, err)
+			continue
+		}
+		payload := Payload{}
+		if err := json.Unmarshal(content, &payload); err != nil || payload.HostID == 0 {
+			blog.Errorf("identifier sink: identifier file %s is invalid, err: %v", path, err)
+			continue
+		}
+		s.hostFiles[payload.HostID] = append(s.hostFiles[payload.HostID], path)
+		s.fileHosts[path] = payload.HostID
+	}
+}
+
+func (s *fileSink) Name() string {
+	return TypeFile
+}
+
+func (s *fileSink) Push(payloads []Payload) error {
+	failed := 0
+	for _, payload := range payloads {
+		if payload.Identifier == nil && payload.Deleted == false {
+			continue
+		}
+		paths := make([]string, 0)
+		if payload.Deleted == false {
+			out, err := json.Marshal(payload)
+			if err != nil {
+				blog.Errorf("identifier sink: marshal identifier of host %d failed, err: %v", payload.HostID, err)
+				failed++
+				continue
+			}
+			paths = s.paths(payload)
+			for _, path := range paths {
+				if err := writeFileAtomic(path, out); err != nil {
+					blog.Errorf("identifier sink: write identifier of host %d to %s failed, err: %v", payload.HostID, path, err)
+					failed++
+					continue
+				}
+				s.fileHosts[path] = payload.HostID
+			}
+		}
+		failed += s.removeOutdatedFiles(payload.HostID, paths)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d identifier files write failed", failed)
+	}
+	return nil
+}
+
+// removeOutdatedFiles remove the files written for the host before but not in the current paths,
+// files taken over by another host which has the same ip are kept. it returns the count of failures.
+func (s *fileSink) removeOutdatedFiles(hostID int64, paths []string) int {
+	failed := 0
+	for _, path := range s.hostFiles[hostID] {
+		if util.InArray(path, paths) == true || s.fileHosts[path] != hostID {
+			continue
+		}
+		if err := os.Remove(path); err != nil && os.IsNotExist(err) == false {
+			blog.Errorf("identifier sink: remove outdated identifier file %s of host %d failed, err: %v", path, hostID, err)
+			failed++
+			paths = append(paths, path)
+			continue
+		}
+		delete(s.fileHosts, path)
+	}
+	if len(paths) == 0 {
+		delete(s.hostFiles, hostID)
+	} else {
+		s.hostFiles[hostID] = paths
+	}
+	return failed
+}
+
+func (s *fileSink) paths(payload Payload) []string {
+	cloudDir := filepath.Join(s.dir, strconv.FormatInt(payload.Identifier.CloudID, 10))
+	paths := make([]string, 0)
+	for _, ip := range strings.Split(payload.Identifier.InnerIP, ",") {
+		ip = strings.TrimSpace(ip)
+		// the ip is used as file name, make sure it doesn't escape from the directory
+		if len(ip) == 0 || strings.ContainsAny(ip, `/\`) || ip == "." || ip == ".." {
+			continue
+		}
+		paths = append(paths, filepath.Join(cloudDir, ip+".json"))
+	}
+	return paths
+}
+
+// writeFileAtomic write to a temporary file and rename it, so that readers never see a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(dir, ".identifier-")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sink
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSinkPaths(t *testing.T) {
+	s := &fileSink{dir: "/data/identifier"}
+	tests := []struct {
+		cloudID int64
+		innerIP string
+		want    []string
+	}{
+		{0, "10.0.0.1", []string{"/data/identifier/0/10.0.0.1.json"}},
+		{2, "10.0.0.1, 10.0.0.2", []string{"/data/identifier/2/10.0.0.1.json", "/data/identifier/2/10.0.0.2.json"}},
+		{0, "", []string{}},
+		// ips which may escape from the directory are ignored
+		{0, "../10.0.0.1,..,.,a/b,a\\b,10.0.0.3", []string{"/data/identifier/0/10.0.0.3.json"}},
+	}
+	for _, tt := range tests {
+		payload := NewPayload(1, &metadata.HostIdentifier{HostID: 1, CloudID: tt.cloudID, InnerIP: tt.innerIP})
+		require.Equal(t, tt.want, s.paths(payload), "inner ip: %s", tt.innerIP)
+	}
+}
+
+func TestFileSinkRemoveOutdatedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "identifier-sink-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	exist := func(path string) bool {
+		_, err := os.Stat(filepath.Join(dir, path))
+		return err == nil
+	}
+
+	s := NewFileSink(dir)
+	require.NoError(t, s.Push([]Payload{
+		NewPayload(1, &metadata.HostIdentifier{HostID: 1, InnerIP: "10.0.0.1,10.0.0.2"}),
+		NewPayload(1, &metadata.HostIdentifier{HostID: 2, InnerIP: "10.0.0.3"}),
+	}))
+	require.True(t, exist("0/10.0.0.1.json"))
+	require.True(t, exist("0/10.0.0.2.json"))
+	require.True(t, exist("0/10.0.0.3.json"))
+
+	// the inner ip of host 1 is changed, and host 2 takes over the ip that host 1 no longer has
+	require.NoError(t, s.Push([]Payload{
+		NewPayload(2, &metadata.HostIdentifier{HostID: 2, InnerIP: "10.0.0.2"}),
+		NewPayload(2, &metadata.HostIdentifier{HostID: 1, InnerIP: "10.0.0.1"}),
+	}))
+	require.True(t, exist("0/10.0.0.1.json"))
+	require.True(t, exist("0/10.0.0.2.json"))
+	require.False(t, exist("0/10.0.0.3.json"))
+
+	// files written before restart are removed when the host is deleted
+	s = NewFileSink(dir)
+	require.NoError(t, s.Push([]Payload{NewDeletedPayload(3, 1)}))
+	require.False(t, exist("0/10.0.0.1.json"))
+	require.True(t, exist("0/10.0.0.2.json"))
+}
@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"configcenter/src/common/http/httpclient"
+)
+
+// httpSink posts batches of host identifiers to an url, any 2xx response means the batch is accepted
+type httpSink struct {
+	url     string
+	timeout time.Duration
+	client  *httpclient.HttpClient
+}
+
+func NewHTTPSink(url string, timeout time.Duration) Sink {
+	return &httpSink{
+		url:     url,
+		timeout: timeout,
+		client:  httpclient.NewHttpClient(),
+	}
+}
+
+func (s *httpSink) Name() string {
+	return TypeHTTP
+}
+
+func (s *httpSink) Push(payloads []Payload) error {
+	body, err := json.Marshal(Batch{Version: PayloadVersion, Payloads: payloads})
+	if err != nil {
+		return fmt.Errorf("marshal batch failed, err: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request failed, err: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.DoWithTimeout(s.timeout, req)
+	if err != nil {
+		return fmt.Errorf("send request to %s failed, err: %v", s.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respData, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s responded with status %d, body: %s", s.url, resp.StatusCode, respData)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/redis.v5"
+)
+
+// queueSink pushes host identifiers to a redis list one by one, consumers pop them from the other side
+type queueSink struct {
+	cache     *redis.Client
+	key       string
+	maxLength int64
+}
+
+func NewQueueSink(cache *redis.Client, key string, maxLength int64) Sink {
+	return &queueSink{
+		cache:     cache,
+		key:       key,
+		maxLength: maxLength,
+	}
+}
+
+func (s *queueSink) Name() string {
+	return TypeQueue
+}
+
+func (s *queueSink) Push(payloads []Payload) error {
+	values := make([]interface{}, 0, len(payloads))
+	for _, payload := range payloads {
+		out, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("marshal identifier of host %d failed, err: %v", payload.HostID, err)
+		}
+		values = append(values, string(out))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := s.cache.Pipelined(func(pipe *redis.Pipeline) error {
+		pipe.LPush(s.key, values...)
+		if s.maxLength > 0 {
+			pipe.LTrim(s.key, 0, s.maxLength-1)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("push to queue %s failed, err: %v", s.key, err)
+	}
+	return nil
+}
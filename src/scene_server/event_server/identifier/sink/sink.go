@@ -0,0 +1,335 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sink
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+
+	"gopkg.in/redis.v5"
+)
+
+// PayloadVersion the version of the payload format, it changes when the payload is changed incompatibly
+const PayloadVersion = "v1"
+
+// Payload is the host identifier delivered to sinks
+type Payload struct {
+	Version string `json:"version"`
+	// Revision increases every time the identifier is changed, payloads with a lower revision are stale
+	Revision   int64                    `json:"revision"`
+	Timestamp  int64                    `json:"timestamp"`
+	HostID     int64                    `json:"bk_host_id"`
+	Identifier *metadata.HostIdentifier `json:"identifier"`
+	// Deleted the host is deleted, the identifier is not set then
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+func NewPayload(revision int64, identifier *metadata.HostIdentifier) Payload {
+	return Payload{
+		Version:    PayloadVersion,
+		Revision:   revision,
+		Timestamp:  time.Now().Unix(),
+		HostID:     identifier.HostID,
+		Identifier: identifier,
+	}
+}
+
+// NewDeletedPayload returns the payload which tells the sinks that the host is deleted
+func NewDeletedPayload(revision int64, hostID int64) Payload {
+	return Payload{
+		Version:   PayloadVersion,
+		Revision:  revision,
+		Timestamp: time.Now().Unix(),
+		HostID:    hostID,
+		Deleted:   true,
+	}
+}
+
+// Batch is the payloads delivered to a sink at once
+type Batch struct {
+	Version  string    `json:"version"`
+	Payloads []Payload `json:"payloads"`
+}
+
+// Sink delivers host identifiers to the place where host-side tooling can read them
+type Sink interface {
+	Name() string
+	Push(payloads []Payload) error
+}
+
+const (
+	TypeFile  = "file"
+	TypeHTTP  = "http"
+	TypeQueue = "queue"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+	defaultHTTPTimeout   = 10 * time.Second
+)
+
+type Config struct {
+	// Sinks the enabled sink types, identifiers are not pushed if no sink is enabled
+	Sinks         []string
+	BatchSize     int
+	FlushInterval time.Duration
+	// FullSyncOnStart push identifiers of all hosts when the identifier cache is built on start
+	FullSyncOnStart bool
+
+	// FileDir the directory that host identifiers are written to, one file for each inner ip of a host,
+	// the file of a host is <FileDir>/<bk_cloud_id>/<bk_host_innerip>.json
+	FileDir string
+
+	// HTTPURL the url that batches of host identifiers are posted to
+	HTTPURL     string
+	HTTPTimeout time.Duration
+
+	// QueueKey the redis list that host identifiers are pushed to, the oldest ones are dropped
+	// if the length of the list exceeds QueueMaxLength, 0 means no limit.
+	QueueKey       string
+	QueueMaxLength int64
+}
+
+func ParseConfigFromKV(prefix string, configmap map[string]string) (Config, error) {
+	conf := Config{
+		Sinks:         make([]string, 0),
+		BatchSize:     defaultBatchSize,
+		FlushInterval: defaultFlushInterval,
+		FileDir:       configmap[prefix+".file.dir"],
+		HTTPURL:       configmap[prefix+".http.url"],
+		HTTPTimeout:   defaultHTTPTimeout,
+		QueueKey:      types.EventCacheIdentSinkQueueKey,
+	}
+
+	for _, sinkType := range strings.Split(configmap[prefix+".sinks"], ",") {
+		sinkType = strings.TrimSpace(sinkType)
+		if len(sinkType) == 0 {
+			continue
+		}
+		if sinkType != TypeFile && sinkType != TypeHTTP && sinkType != TypeQueue {
+			return conf, fmt.Errorf("invalid %s.sinks value %s, available values: %s", prefix, sinkType, strings.Join([]string{TypeFile, TypeHTTP, TypeQueue}, ","))
+		}
+		conf.Sinks = append(conf.Sinks, sinkType)
+	}
+
+	if val, exist := configmap[prefix+".batchSize"]; exist == true && len(val) != 0 {
+		batchSize, err := strconv.Atoi(val)
+		if err != nil || batchSize <= 0 {
+			return conf, fmt.Errorf("invalid %s.batchSize value %s", prefix, val)
+		}
+		conf.BatchSize = batchSize
+	}
+	if val, exist := configmap[prefix+".flushIntervalSeconds"]; exist == true && len(val) != 0 {
+		seconds, err := strconv.Atoi(val)
+		if err != nil || seconds <= 0 {
+			return conf, fmt.Errorf("invalid %s.flushIntervalSeconds value %s", prefix, val)
+		}
+		conf.FlushInterval = time.Duration(seconds) * time.Second
+	}
+	if val, exist := configmap[prefix+".fullSyncOnStart"]; exist == true && len(val) != 0 {
+		fullSync, err := strconv.ParseBool(val)
+		if err != nil {
+			return conf, fmt.Errorf("invalid %s.fullSyncOnStart value %s", prefix, val)
+		}
+		conf.FullSyncOnStart = fullSync
+	}
+	if val, exist := configmap[prefix+".http.timeoutSeconds"]; exist == true && len(val) != 0 {
+		seconds, err := strconv.Atoi(val)
+		if err != nil || seconds <= 0 {
+			return conf, fmt.Errorf("invalid %s.http.timeoutSeconds value %s", prefix, val)
+		}
+		conf.HTTPTimeout = time.Duration(seconds) * time.Second
+	}
+	if val, exist := configmap[prefix+".queue.key"]; exist == true && len(val) != 0 {
+		conf.QueueKey = val
+	}
+	if val, exist := configmap[prefix+".queue.maxLength"]; exist == true && len(val) != 0 {
+		maxLength, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || maxLength < 0 {
+			return conf, fmt.Errorf("invalid %s.queue.maxLength value %s", prefix, val)
+		}
+		conf.QueueMaxLength = maxLength
+	}
+	return conf, nil
+}
+
+// Manager collects host identifiers and delivers them to all the enabled sinks in batches,
+// only the latest identifier of a host in a batch is delivered.
+type Manager struct {
+	sinks           []Sink
+	batchSize       int
+	flushInterval   time.Duration
+	fullSyncOnStart bool
+
+	// pending the identifiers not delivered yet, merged by host, so it never holds more than one payload of a host
+	pendingLock sync.Mutex
+	pending     map[int64]Payload
+	// full notifies Run that the pending identifiers reach the batch size
+	full chan struct{}
+	// failed the identifiers failed to deliver to each sink, they are retried with the next batch
+	failed map[string]map[int64]Payload
+}
+
+// mergePayload add the payload to the pending identifiers of hosts, the stale one with lower revision is dropped
+func mergePayload(pending map[int64]Payload, payload Payload) {
+	if exist, ok := pending[payload.HostID]; ok == true && exist.Revision > payload.Revision {
+		return
+	}
+	pending[payload.HostID] = payload
+}
+
+func NewManager(conf Config, cache *redis.Client) (*Manager, error) {
+	m := &Manager{
+		sinks:           make([]Sink, 0),
+		batchSize:       conf.BatchSize,
+		flushInterval:   conf.FlushInterval,
+		fullSyncOnStart: conf.FullSyncOnStart,
+		pending:         make(map[int64]Payload),
+		full:            make(chan struct{}, 1),
+		failed:          make(map[string]map[int64]Payload),
+	}
+	if m.batchSize <= 0 {
+		m.batchSize = defaultBatchSize
+	}
+	if m.flushInterval <= 0 {
+		m.flushInterval = defaultFlushInterval
+	}
+	for _, sinkType := range conf.Sinks {
+		switch sinkType {
+		case TypeFile:
+			if len(conf.FileDir) == 0 {
+				return nil, fmt.Errorf("file sink is enabled, but the directory is not set")
+			}
+			m.sinks = append(m.sinks, NewFileSink(conf.FileDir))
+		case TypeHTTP:
+			if len(conf.HTTPURL) == 0 {
+				return nil, fmt.Errorf("http sink is enabled, but the url is not set")
+			}
+			m.sinks = append(m.sinks, NewHTTPSink(conf.HTTPURL, conf.HTTPTimeout))
+		case TypeQueue:
+			if len(conf.QueueKey) == 0 {
+				return nil, fmt.Errorf("queue sink is enabled, but the queue key is not set")
+			}
+			m.sinks = append(m.sinks, NewQueueSink(cache, conf.QueueKey, conf.QueueMaxLength))
+		default:
+			return nil, fmt.Errorf("unknown sink type %s", sinkType)
+		}
+	}
+	return m, nil
+}
+
+func (m *Manager) Enabled() bool {
+	return m != nil && len(m.sinks) > 0
+}
+
+func (m *Manager) FullSyncOnStart() bool {
+	return m.Enabled() && m.fullSyncOnStart
+}
+
+// Push add identifiers to the pending batch, it never blocks, identifiers of a host not delivered
+// yet are merged into the latest one when the sinks are slower than the identifiers are changed.
+func (m *Manager) Push(payloads ...Payload) {
+	if m.Enabled() == false {
+		return
+	}
+	m.pendingLock.Lock()
+	for _, payload := range payloads {
+		mergePayload(m.pending, payload)
+	}
+	full := len(m.pending) >= m.batchSize
+	m.pendingLock.Unlock()
+
+	if full {
+		select {
+		case m.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// takePending returns the pending identifiers and clears them
+func (m *Manager) takePending() map[int64]Payload {
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+	pending := m.pending
+	m.pending = make(map[int64]Payload)
+	return pending
+}
+
+// Run deliver the pending identifiers when the batch is full or the flush interval is reached,
+// the identifiers failed to deliver are retried on the next flush, it returns when ctx is done.
+func (m *Manager) Run(ctx context.Context) error {
+	if m.Enabled() == false {
+		return nil
+	}
+	blog.Infof("identifier sink: started, sinks: %d, batch size: %d, flush interval: %s", len(m.sinks), m.batchSize, m.flushInterval)
+
+	ticker := time.NewTicker(m.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			blog.Infof("identifier sink: stopped, err: %v", ctx.Err())
+			return nil
+		case <-m.full:
+		case <-ticker.C:
+		}
+		m.flush(m.takePending())
+	}
+}
+
+func (m *Manager) flush(pending map[int64]Payload) {
+	for _, s := range m.sinks {
+		// retry the failed identifiers of the sink, unless they are replaced by newer ones
+		batch := m.failed[s.Name()]
+		if batch == nil {
+			if len(pending) == 0 {
+				continue
+			}
+			batch = make(map[int64]Payload, len(pending))
+		}
+		for _, payload := range pending {
+			mergePayload(batch, payload)
+		}
+		payloads := sortPayloads(batch)
+
+		if err := s.Push(payloads); err != nil {
+			blog.Errorf("identifier sink: push %d identifiers to %s sink failed, retry later, err: %v", len(payloads), s.Name(), err)
+			m.failed[s.Name()] = batch
+			continue
+		}
+		delete(m.failed, s.Name())
+		blog.V(4).Infof("identifier sink: pushed %d identifiers to %s sink", len(payloads), s.Name())
+	}
+}
+
+func sortPayloads(pending map[int64]Payload) []Payload {
+	payloads := make([]Payload, 0, len(pending))
+	for _, payload := range pending {
+		payloads = append(payloads, payload)
+	}
+	sort.Slice(payloads, func(i, j int) bool {
+		return payloads[i].HostID < payloads[j].HostID
+	})
+	return payloads
+}
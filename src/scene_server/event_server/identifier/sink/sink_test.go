@@ -0,0 +1,179 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package sink
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigFromKV(t *testing.T) {
+	conf, err := ParseConfigFromKV("identifier", map[string]string{})
+	require.NoError(t, err)
+	require.Empty(t, conf.Sinks)
+	require.Equal(t, defaultBatchSize, conf.BatchSize)
+	require.Equal(t, defaultFlushInterval, conf.FlushInterval)
+	require.Equal(t, defaultHTTPTimeout, conf.HTTPTimeout)
+	require.Equal(t, types.EventCacheIdentSinkQueueKey, conf.QueueKey)
+
+	conf, err = ParseConfigFromKV("identifier", map[string]string{
+		"identifier.sinks":                " file, queue ,,http",
+		"identifier.batchSize":            "10",
+		"identifier.flushIntervalSeconds": "2",
+		"identifier.fullSyncOnStart":      "true",
+		"identifier.file.dir":             "/data/identifier",
+		"identifier.http.url":             "http://127.0.0.1/identifier",
+		"identifier.http.timeoutSeconds":  "3",
+		"identifier.queue.key":            "queue",
+		"identifier.queue.maxLength":      "1000",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{TypeFile, TypeQueue, TypeHTTP}, conf.Sinks)
+	require.Equal(t, 10, conf.BatchSize)
+	require.Equal(t, 2*time.Second, conf.FlushInterval)
+	require.True(t, conf.FullSyncOnStart)
+	require.Equal(t, "/data/identifier", conf.FileDir)
+	require.Equal(t, "http://127.0.0.1/identifier", conf.HTTPURL)
+	require.Equal(t, 3*time.Second, conf.HTTPTimeout)
+	require.Equal(t, "queue", conf.QueueKey)
+	require.Equal(t, int64(1000), conf.QueueMaxLength)
+
+	invalids := []map[string]string{
+		{"identifier.sinks": "file,kafka"},
+		{"identifier.batchSize": "0"},
+		{"identifier.batchSize": "abc"},
+		{"identifier.flushIntervalSeconds": "-1"},
+		{"identifier.fullSyncOnStart": "yes please"},
+		{"identifier.http.timeoutSeconds": "0"},
+		{"identifier.queue.maxLength": "-1"},
+	}
+	for _, configmap := range invalids {
+		_, err := ParseConfigFromKV("identifier", configmap)
+		require.Error(t, err, "config %v should be invalid", configmap)
+	}
+}
+
+func TestMergePayload(t *testing.T) {
+	pending := make(map[int64]Payload)
+	mergePayload(pending, Payload{HostID: 1, Revision: 2})
+	mergePayload(pending, Payload{HostID: 2, Revision: 1})
+
+	// stale payload is dropped
+	mergePayload(pending, Payload{HostID: 1, Revision: 1})
+	require.Equal(t, int64(2), pending[1].Revision)
+
+	// newer payload replaces the pending one
+	mergePayload(pending, Payload{HostID: 1, Revision: 3, Deleted: true})
+	require.Equal(t, int64(3), pending[1].Revision)
+	require.True(t, pending[1].Deleted)
+
+	// payloads of a full sync share the same revision, the last one wins
+	mergePayload(pending, Payload{HostID: 2, Revision: 1, Timestamp: 100})
+	require.Equal(t, int64(100), pending[2].Timestamp)
+	require.Len(t, pending, 2)
+}
+
+type fakeSink struct {
+	fail   bool
+	pushed [][]Payload
+}
+
+func (s *fakeSink) Name() string {
+	return "fake"
+}
+
+func (s *fakeSink) Push(payloads []Payload) error {
+	if s.fail {
+		return errors.New("fake sink is down")
+	}
+	s.pushed = append(s.pushed, payloads)
+	return nil
+}
+
+func TestManagerRetryFailedBatch(t *testing.T) {
+	s := &fakeSink{fail: true}
+	m := &Manager{
+		sinks:     []Sink{s},
+		batchSize: 2,
+		pending:   make(map[int64]Payload),
+		full:      make(chan struct{}, 1),
+		failed:    make(map[string]map[int64]Payload),
+	}
+
+	// push never blocks, payloads of a host are merged
+	for revision := int64(1); revision <= 10; revision++ {
+		m.Push(Payload{HostID: 1, Revision: revision})
+	}
+	m.Push(Payload{HostID: 2, Revision: 11})
+	require.Len(t, m.pending, 2)
+
+	m.flush(m.takePending())
+	require.Empty(t, s.pushed)
+	require.Len(t, m.failed["fake"], 2)
+
+	// the failed batch is retried with the new payloads, the newer revision wins
+	s.fail = false
+	m.Push(Payload{HostID: 2, Revision: 12}, Payload{HostID: 3, Revision: 13})
+	m.flush(m.takePending())
+	require.Len(t, s.pushed, 1)
+	require.Equal(t, []Payload{{HostID: 1, Revision: 10}, {HostID: 2, Revision: 12}, {HostID: 3, Revision: 13}}, s.pushed[0])
+	require.Empty(t, m.failed)
+
+	// nothing to push
+	m.flush(m.takePending())
+	require.Len(t, s.pushed, 1)
+}
+
+func TestManagerRunStopsOnCancel(t *testing.T) {
+	s := &fakeSink{}
+	m := &Manager{
+		sinks:         []Sink{s},
+		batchSize:     1,
+		flushInterval: time.Hour,
+		pending:       make(map[int64]Payload),
+		full:          make(chan struct{}, 1),
+		failed:        make(map[string]map[int64]Payload),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- m.Run(ctx)
+	}()
+
+	m.Push(NewPayload(1, &metadata.HostIdentifier{HostID: 1}))
+	// the full batch is taken by Run and delivered before it checks ctx again
+	for retry := 0; retry < 100; retry++ {
+		m.pendingLock.Lock()
+		count := len(m.pending)
+		m.pendingLock.Unlock()
+		if count == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run should return after ctx is canceled")
+	}
+	require.Len(t, s.pushed, 1)
+}
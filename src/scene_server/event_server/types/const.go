@@ -43,6 +43,8 @@ const (
 	EventCacheProcessChannel   = common.BKCacheKeyV3Prefix + "event_process_channel"
 
 	EventCacheIdentInstPrefix = common.BKCacheKeyV3Prefix + "ident:inst_"
+	// EventCacheIdentSinkQueueKey the default queue that host identifiers are pushed to by the queue sink
+	EventCacheIdentSinkQueueKey = common.BKCacheKeyV3Prefix + "ident:sink_queue"
 )
 
 // EventSubscriberCacheKey returns EventSubscriberCacheKey
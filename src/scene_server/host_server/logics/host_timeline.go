@@ -0,0 +1,199 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+// GetHostChangeTimeline get the changes of the host from the snapshot history and the audit log,
+// the changes made by the collector are only taken from the snapshot history, which is compacted.
+// the page is pushed down to the sources, each source returns at most start+limit latest changes when
+// both are queried, which is enough to make up the page after they are merged by time.
+func (lgc *Logics) GetHostChangeTimeline(ctx context.Context, hostID int64, option *metadata.HostChangeTimelineOption) (*metadata.HostChangeTimeline, errors.CCError) {
+	bothSources := option.Source == ""
+	page := option.Page
+	if bothSources {
+		page = metadata.BasePage{Start: 0, Limit: 0}
+		if option.Page.Limit > 0 {
+			page.Limit = option.Page.Start + option.Page.Limit
+		}
+	}
+
+	items := make([]metadata.HostChangeTimelineItem, 0)
+	count := 0
+	if bothSources || option.Source == metadata.HostChangeSourceAgent {
+		agentItems, agentCount, err := lgc.getHostAgentChanges(ctx, hostID, option, page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, agentItems...)
+		count += agentCount
+	}
+
+	if bothSources || option.Source == metadata.HostChangeSourceManual {
+		manualItems, manualCount, err := lgc.getHostManualChanges(ctx, hostID, option, page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, manualItems...)
+		count += manualCount
+	}
+
+	timeline := &metadata.HostChangeTimeline{Count: count, Info: items}
+	if !bothSources {
+		return timeline, nil
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Time.After(items[j].Time)
+	})
+	start := option.Page.Start
+	if start > len(items) {
+		start = len(items)
+	}
+	end := len(items)
+	if option.Page.Limit > 0 && start+option.Page.Limit < end {
+		end = start + option.Page.Limit
+	}
+	timeline.Info = items[start:end]
+	return timeline, nil
+}
+
+// getHostAgentChanges get one page of the changes from the snapshot history, latest first, and the total count
+func (lgc *Logics) getHostAgentChanges(ctx context.Context, hostID int64, option *metadata.HostChangeTimelineOption,
+	page metadata.BasePage) ([]metadata.HostChangeTimelineItem, int, errors.CCError) {
+
+	input := &metadata.HostSnapHistoryOption{
+		HostID:    hostID,
+		StartTime: option.StartTime,
+		EndTime:   option.EndTime,
+		Page:      page,
+	}
+	result, err := lgc.CoreAPI.CoreService().Host().SearchHostSnapHistory(ctx, lgc.header, input)
+	if err != nil {
+		blog.Errorf("get host snapshot history, http request error, err: %v, input: %+v, rid: %s", err, input, lgc.rid)
+		return nil, 0, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("get host snapshot history failed, error code: %d, error message: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, input, lgc.rid)
+		return nil, 0, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+
+	items := make([]metadata.HostChangeTimelineItem, len(result.Data.Info))
+	for index, history := range result.Data.Info {
+		items[index] = metadata.HostChangeTimelineItem{
+			Source:   metadata.HostChangeSourceAgent,
+			Operator: common.CCSystemCollectorUserName,
+			Changes:  history.Changes,
+			Time:     history.CreateTime,
+		}
+	}
+	return items, int(result.Data.Count), nil
+}
+
+// getHostManualChanges get one page of the changes from the audit log, latest first, and the total count
+func (lgc *Logics) getHostManualChanges(ctx context.Context, hostID int64, option *metadata.HostChangeTimelineOption,
+	page metadata.BasePage) ([]metadata.HostChangeTimelineItem, int, errors.CCError) {
+
+	cond := map[string]interface{}{
+		common.BKOpTargetField: common.BKInnerObjIDHost,
+		common.BKOpTypeField:   auditoplog.AuditOpTypeModify,
+		"inst_id":              hostID,
+		common.BKOperatorField: map[string]interface{}{common.BKDBNE: common.CCSystemCollectorUserName},
+	}
+	timeCond := make(map[string]interface{})
+	if option.StartTime != nil {
+		timeCond[common.BKDBGTE] = option.StartTime.Unix()
+	}
+	if option.EndTime != nil {
+		timeCond[common.BKDBLTE] = option.EndTime.Unix()
+	}
+	if len(timeCond) > 0 {
+		timeCond[common.BKTimeTypeParseFlag] = "1"
+		cond[common.BKOpTimeField] = timeCond
+	}
+	query := metadata.QueryInput{
+		Condition: cond,
+		Start:     page.Start,
+		Limit:     page.Limit,
+		Sort:      "-" + common.BKOpTimeField,
+	}
+
+	result, err := lgc.CoreAPI.CoreService().Audit().SearchAuditLog(ctx, lgc.header, query)
+	if err != nil {
+		blog.Errorf("get host audit log, http request error, err: %v, input: %+v, rid: %s", err, query, lgc.rid)
+		return nil, 0, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("get host audit log failed, error code: %d, error message: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, query, lgc.rid)
+		return nil, 0, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+
+	items := make([]metadata.HostChangeTimelineItem, 0)
+	for _, log := range result.Data.Info {
+		changes := auditLogChanges(log.Content)
+		if len(changes) == 0 {
+			continue
+		}
+		items = append(items, metadata.HostChangeTimelineItem{
+			Source:   metadata.HostChangeSourceManual,
+			Operator: log.User,
+			Changes:  changes,
+			Time:     log.CreateTime,
+		})
+	}
+	return items, result.Data.Count, nil
+}
+
+// auditLogChanges get the changed attributes from the pre data and current data of the audit log
+func auditLogChanges(content interface{}) []metadata.HostSnapChange {
+	contentMap, ok := content.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	preData, _ := contentMap["pre_data"].(map[string]interface{})
+	curData, _ := contentMap["cur_data"].(map[string]interface{})
+	if curData == nil {
+		return nil
+	}
+
+	keys := make([]string, 0)
+	for key, value := range curData {
+		if key == common.LastTimeField || key == common.CreateTimeField {
+			continue
+		}
+		if !reflect.DeepEqual(preData[key], value) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := make([]metadata.HostSnapChange, len(keys))
+	for index, key := range keys {
+		changes[index] = metadata.HostSnapChange{
+			PropertyID: key,
+			PreValue:   preData[key],
+			CurValue:   curData[key],
+		}
+	}
+	return changes
+}
@@ -14,6 +14,9 @@ package logics
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
@@ -49,8 +52,38 @@ func (lgc *Logics) UnlockHost(ctx context.Context, input *metadata.HostLockReque
 	return nil
 }
 
+// QueryHostLock returns whether the hosts are locked, the key is the host id if the hosts
+// are specified by host id, otherwise it is the inner ip
 func (lgc *Logics) QueryHostLock(ctx context.Context, input *metadata.QueryHostLockRequest) (map[string]bool, errors.CCError) {
 
+	hostLocks, err := lgc.QueryHostLockDetail(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	hostLockMap := make(map[string]bool, 0)
+	if len(input.IDS) > 0 {
+		for _, id := range input.IDS {
+			hostLockMap[strconv.FormatInt(id, 10)] = false
+		}
+		for _, hostLock := range hostLocks {
+			hostLockMap[strconv.FormatInt(hostLock.HostID, 10)] = true
+		}
+		return hostLockMap, nil
+	}
+
+	for _, ip := range input.IPS {
+		hostLockMap[ip] = false
+	}
+	for _, hostLock := range hostLocks {
+		hostLockMap[hostLock.IP] = true
+	}
+
+	return hostLockMap, nil
+}
+
+// QueryHostLockDetail returns the locks which are not expired of the hosts
+func (lgc *Logics) QueryHostLockDetail(ctx context.Context, input *metadata.QueryHostLockRequest) ([]metadata.HostLockData, errors.CCError) {
+
 	hostLockResult, err := lgc.CoreAPI.CoreService().Host().QueryHostLock(ctx, lgc.header, input)
 	if nil != err {
 		blog.Errorf("query lock host, http request error, error:%s,input:%+v,logID:%s", err.Error(), input, lgc.rid)
@@ -60,13 +93,26 @@ func (lgc *Logics) QueryHostLock(ctx context.Context, input *metadata.QueryHostL
 		blog.Errorf("query host lock  error, error code:%d error message:%s,input:%+v,logID:%s", hostLockResult.Code, hostLockResult.ErrMsg, input, lgc.rid)
 		return nil, lgc.ccErr.New(hostLockResult.Code, hostLockResult.ErrMsg)
 	}
-	hostLockMap := make(map[string]bool, 0)
-	for _, ip := range input.IPS {
-		hostLockMap[ip] = false
+	return hostLockResult.Data.Info, nil
+}
+
+// CheckHostLocked returns error if any of the hosts is locked,
+// it's used to fail fast before the operations which can't be rolled back
+func (lgc *Logics) CheckHostLocked(ctx context.Context, hostIDArr []int64) errors.CCError {
+	if len(hostIDArr) == 0 {
+		return nil
 	}
-	for _, hostLock := range hostLockResult.Data.Info {
-		hostLockMap[hostLock.IP] = true
+	hostLocks, err := lgc.QueryHostLockDetail(ctx, &metadata.QueryHostLockRequest{IDS: hostIDArr})
+	if err != nil {
+		return err
 	}
-
-	return hostLockMap, nil
+	if len(hostLocks) == 0 {
+		return nil
+	}
+	lockedHosts := make([]string, 0)
+	for _, hostLock := range hostLocks {
+		lockedHosts = append(lockedHosts, fmt.Sprintf("%s(%s: %s)", hostLock.IP, hostLock.User, hostLock.Reason))
+	}
+	blog.Errorf("hosts are locked: %v, rid: %s", lockedHosts, lgc.rid)
+	return lgc.ccErr.Errorf(common.CCErrCoreServiceHostLocked, strings.Join(lockedHosts, ","))
 }
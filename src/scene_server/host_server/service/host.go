@@ -98,6 +98,12 @@ func (s *Service) DeleteHostBatchFromResourcePool(req *restful.Request, resp *re
 		return
 	}
 
+	if err := srvData.lgc.CheckHostLocked(srvData.ctx, iHostIDArr); err != nil {
+		blog.Errorf("delete host batch failed, err: %v, input: %+v, rid: %s", err, opt, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
 	// auth: unregister hosts
 	if err := s.AuthManager.DeregisterHostsByID(srvData.ctx, srvData.header, iHostIDArr...); err != nil {
 		blog.Errorf("deregister host from iam failed, hosts: %+v, err: %v, rid: %s", iHostIDArr, err, srvData.rid)
@@ -267,6 +273,43 @@ func (s *Service) HostSnapInfo(req *restful.Request, resp *restful.Response) {
 	_ = resp.WriteEntity(responseData)
 }
 
+// HostChangeTimeline return the changes of the host, both from the agent snapshot and the manual edits
+func (s *Service) HostChangeTimeline(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	hostID, err := strconv.ParseInt(req.PathParameter(common.BKHostIDField), 10, 64)
+	if err != nil {
+		blog.Errorf("HostChangeTimeline hostID convert to int64 failed, err:%v, input:%+v, rid:%s", err, req.PathParameter(common.BKHostIDField), srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommParamsNeedInt)})
+		return
+	}
+
+	option := new(meta.HostChangeTimelineOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(option); err != nil {
+		blog.Errorf("HostChangeTimeline failed with decode body err: %v, rid:%s", err, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if option.Source != "" && option.Source != meta.HostChangeSourceAgent && option.Source != meta.HostChangeSourceManual {
+		_ = resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, "source")})
+		return
+	}
+
+	// auth: check authorization
+	if err := s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, authmeta.Find, hostID); err != nil {
+		blog.Errorf("check host authorization failed, hosts: %+v, err: %v, rid: %s", hostID, err, srvData.rid)
+		_ = resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+
+	timeline, err := srvData.lgc.GetHostChangeTimeline(srvData.ctx, hostID, option)
+	if err != nil {
+		_ = resp.WriteError(http.StatusInternalServerError, &meta.RespError{Msg: err})
+		return
+	}
+	_ = resp.WriteEntity(meta.NewSuccessResp(timeline))
+}
+
 // add host to host resource pool
 func (s *Service) AddHost(req *restful.Request, resp *restful.Response) {
 	srvData := s.newSrvComm(req.Request.Header)
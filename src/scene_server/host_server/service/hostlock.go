@@ -22,6 +22,7 @@ import (
 	authmeta "configcenter/src/auth/meta"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 )
 
@@ -35,36 +36,13 @@ func (s *Service) LockHost(req *restful.Request, resp *restful.Response) {
 		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
-	if 0 == len(input.IPS) {
-		blog.Errorf("lock host, ip_list is empty,input:%+v, rid:%s", input, srvData.rid)
-		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "ip_list")})
+	if input.TTL < 0 {
+		blog.Errorf("lock host, ttl is negative, input:%+v, rid:%s", input, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsInvalid, "ttl")})
 		return
 	}
 
-	// check authorization
-	hostIDArr := make([]int64, 0)
-	for _, ip := range input.IPS {
-		hostID, err := s.ip2hostID(srvData, ip, input.CloudID)
-		if err != nil {
-			blog.Errorf("invalid ip %s:%s, err: %s, rid:%s", ip, input.CloudID, err.Error(), srvData.rid)
-			_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommParamsIsInvalid)})
-			return
-		}
-		hostIDArr = append(hostIDArr, hostID)
-	}
-	// auth: check authorization
-	if err := s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, authmeta.Update, hostIDArr...); err != nil {
-		if err != auth.NoAuthorizeError {
-			blog.Errorf("check host authorization failed, hosts: %+v, err: %v", hostIDArr, err)
-			resp.WriteError(http.StatusOK, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
-			return
-		}
-		perm, err := s.AuthManager.GenEditBizHostNoPermissionResp(srvData.ctx, srvData.header, hostIDArr)
-		if err != nil {
-			resp.WriteError(http.StatusOK, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
-			return
-		}
-		resp.WriteEntity(perm)
+	if !s.authorizeHostLock(srvData, resp, input.IPS, input.IDS, input.CloudID) {
 		return
 	}
 
@@ -87,38 +65,25 @@ func (s *Service) UnlockHost(req *restful.Request, resp *restful.Response) {
 		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
-	if 0 == len(input.IPS) {
-		blog.Errorf("unlock host, ip_list is empty, input:%+v,rid:%s", input, srvData.rid)
-		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "ip_list")})
+
+	if !s.authorizeHostLock(srvData, resp, input.IPS, input.IDS, input.CloudID) {
 		return
 	}
 
-	// check authorization
-	hostIDArr := make([]int64, 0)
-	for _, ip := range input.IPS {
-		hostID, err := s.ip2hostID(srvData, ip, input.CloudID)
+	// only the administrator can unlock the hosts locked by other users
+	if input.Force && s.AuthManager.Enabled() {
+		user := authmeta.UserInfo{UserName: srvData.user, SupplierAccount: srvData.ownerID}
+		systems, err := s.AuthManager.Authorize.AdminEntrance(srvData.ctx, user)
 		if err != nil {
-			blog.Errorf("invalid ip %s:%s, err: %s, rid:%s", ip, input.CloudID, err.Error(), srvData.rid)
-			_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommParamsIsInvalid)})
+			blog.Errorf("force unlock host, check admin authorization failed, user: %s, err: %v, rid: %s", srvData.user, err, srvData.rid)
+			_ = resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommCheckAuthorizeFailed)})
 			return
 		}
-		hostIDArr = append(hostIDArr, hostID)
-	}
-	// auth: check authorization
-	if err := s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, authmeta.Update, hostIDArr...); err != nil {
-		if err != auth.NoAuthorizeError {
-			blog.Errorf("check host authorization failed, hosts: %+v, err: %v, rid: %s", hostIDArr, err, srvData.rid)
+		if len(systems) == 0 {
+			blog.Errorf("force unlock host, user %s is not administrator, rid: %s", srvData.user, srvData.rid)
 			_ = resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
 			return
 		}
-		perm, err := s.AuthManager.GenEditBizHostNoPermissionResp(srvData.ctx, srvData.header, hostIDArr)
-		if err != nil {
-			blog.Errorf("gen no permission response failed, err: %v, rid: %s", err, srvData.rid)
-			resp.WriteError(http.StatusOK, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
-			return
-		}
-		resp.WriteEntity(perm)
-		return
 	}
 
 	err := srvData.lgc.UnlockHost(srvData.ctx, input)
@@ -140,49 +105,99 @@ func (s *Service) QueryHostLock(req *restful.Request, resp *restful.Response) {
 		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
-	if 0 == len(input.IPS) {
-		blog.Errorf("query lock host, ip_list is empty, input:%+v,rid:%s", input, srvData.rid)
-		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "ip_list")})
+
+	if !s.authorizeHostLock(srvData, resp, input.IPS, input.IDS, input.CloudID) {
 		return
 	}
 
-	// check authorization
-	hostIDArr := make([]int64, 0)
-	for _, ip := range input.IPS {
-		hostID, err := s.ip2hostID(srvData, ip, input.CloudID)
-		if err != nil {
-			blog.Errorf("invalid ip %s:%s, err: %s, rid:%s", ip, input.CloudID, err.Error(), srvData.rid)
-			_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommParamsIsInvalid)})
-			return
-		}
-		hostIDArr = append(hostIDArr, hostID)
+	hostLockInfos, err := srvData.lgc.QueryHostLock(srvData.ctx, input)
+	if nil != err {
+		blog.Errorf("query lock host, handle query host lock error, error:%s, input:%+v,rid:%s", err.Error(), input, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+
+	_ = resp.WriteEntity(metadata.HostLockResultResponse{
+		BaseResp: metadata.SuccessBaseResp,
+		Data:     hostLockInfos,
+	})
+}
+
+// QueryHostLockDetail returns the lock owner, reason and expire time of the locked hosts
+func (s *Service) QueryHostLockDetail(req *restful.Request, resp *restful.Response) {
+
+	srvData := s.newSrvComm(req.Request.Header)
+	input := &metadata.QueryHostLockRequest{}
+
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("query lock host detail, but decode body failed, err: %s, rid:%s", err.Error(), srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if !s.authorizeHostLock(srvData, resp, input.IPS, input.IDS, input.CloudID) {
+		return
+	}
+
+	hostLocks, err := srvData.lgc.QueryHostLockDetail(srvData.ctx, input)
+	if nil != err {
+		blog.Errorf("query lock host detail failed, error:%s, input:%+v,rid:%s", err.Error(), input, srvData.rid)
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return
+	}
+
+	result := metadata.HostLockQueryResponse{BaseResp: metadata.SuccessBaseResp}
+	result.Data.Info = hostLocks
+	result.Data.Count = int64(len(hostLocks))
+	_ = resp.WriteEntity(metadata.NewSuccessResp(result.Data))
+}
+
+// authorizeHostLock check the hosts specified by id or by ip are valid, and the user has the authority to update them.
+// the response has been written when it returns false
+func (s *Service) authorizeHostLock(srvData *srvComm, resp *restful.Response, ips []string, ids []int64, cloudID int64) bool {
+	hostIDArr, err := s.getHostLockHostIDs(srvData, ips, ids, cloudID)
+	if err != nil {
+		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
+		return false
 	}
+
 	// auth: check authorization
 	if err := s.AuthManager.AuthorizeByHostsIDs(srvData.ctx, srvData.header, authmeta.Update, hostIDArr...); err != nil {
 		if err != auth.NoAuthorizeError {
 			blog.Errorf("check host authorization failed, hosts: %+v, err: %v, rid: %s", hostIDArr, err, srvData.rid)
 			_ = resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
-			return
+			return false
 		}
 		perm, err := s.AuthManager.GenEditBizHostNoPermissionResp(srvData.ctx, srvData.header, hostIDArr)
 		if err != nil {
 			blog.Errorf("gen no permission response failed, err: %v, rid: %s", err, srvData.rid)
-			resp.WriteError(http.StatusOK, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
-			return
+			_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+			return false
 		}
-		resp.WriteEntity(perm)
-		return
+		_ = resp.WriteEntity(perm)
+		return false
 	}
+	return true
+}
 
-	hostLockInfos, err := srvData.lgc.QueryHostLock(srvData.ctx, input)
-	if nil != err {
-		blog.Errorf("query lock host, handle query host lock error, error:%s, input:%+v,rid:%s", err.Error(), input, srvData.rid)
-		_ = resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: err})
-		return
+// getHostLockHostIDs the hosts are specified by host id first, then by inner ip with cloud id
+func (s *Service) getHostLockHostIDs(srvData *srvComm, ips []string, ids []int64, cloudID int64) ([]int64, errors.CCError) {
+	if 0 != len(ids) {
+		return ids, nil
+	}
+	if 0 == len(ips) {
+		blog.Errorf("host lock, ip_list and id_list are both empty, rid:%s", srvData.rid)
+		return nil, srvData.ccErr.Errorf(common.CCErrCommParamsNeedSet, "ip_list")
 	}
 
-	_ = resp.WriteEntity(metadata.HostLockResultResponse{
-		BaseResp: metadata.SuccessBaseResp,
-		Data:     hostLockInfos,
-	})
+	hostIDArr := make([]int64, 0)
+	for _, ip := range ips {
+		hostID, err := s.ip2hostID(srvData, ip, cloudID)
+		if err != nil {
+			blog.Errorf("invalid ip %s:%d, err: %s, rid:%s", ip, cloudID, err.Error(), srvData.rid)
+			return nil, srvData.ccErr.Error(common.CCErrCommParamsIsInvalid)
+		}
+		hostIDArr = append(hostIDArr, hostID)
+	}
+	return hostIDArr, nil
 }
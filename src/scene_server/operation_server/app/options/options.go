@@ -13,6 +13,8 @@
 package options
 
 import (
+	"time"
+
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/core/cc/config"
@@ -32,6 +34,17 @@ type Config struct {
 	Redis     redis.Config
 	Auth      authcenter.AuthConfig
 	Timer     string
+	// CustomChartInterval the interval to compute the custom charts
+	CustomChartInterval time.Duration
+	InventoryMetric     InventoryMetricConfig
+}
+
+// InventoryMetricConfig the config of the inventory metrics exporter
+type InventoryMetricConfig struct {
+	// Interval the interval to refresh the inventory statistics
+	Interval time.Duration
+	// MaxGroups the max label values of each inventory metric, the others are summed as "other"
+	MaxGroups int
 }
 
 func NewServerOption() *ServerOption {
@@ -44,7 +57,7 @@ func NewServerOption() *ServerOption {
 
 func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.AddrPort, "addrport", "127.0.0.1:60021", "The ip address and port for the serve on")
-	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "127.0.0.1:2181", "hosts of register and discover server. e.g: 127.0.0.1:2181")
+	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "127.0.0.1:2181", "hosts of register and discover server. e.g: 127.0.0.1:2181, etcd://127.0.0.1:2379 for etcd, or file:///data/cmdb/discovery.yaml for the static discovery")
 	fs.StringVar(&s.ServConf.ExConfig, "config", "", "The config path. e.g conf/api.conf")
 	fs.Var(auth.EnableAuthFlag, "enable-auth", "The auth center enable status, true for enabled, false for disabled")
 }
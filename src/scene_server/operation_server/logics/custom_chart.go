@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// ValidateCustomChart check the model and the group by and metric fields of the custom chart are exist
+func (lgc *Logics) ValidateCustomChart(kit *rest.Kit, chart *metadata.CustomChart) error {
+	if field, err := chart.Validate(); err != nil {
+		blog.Errorf("custom chart field %s invalid, err: %v, rid: %s", field, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	modelCond := &metadata.QueryCondition{Condition: mapstr.MapStr{common.BKObjIDField: chart.ObjID}}
+	models, err := lgc.CoreAPI.CoreService().Model().ReadModel(kit.Ctx, kit.Header, modelCond)
+	if err != nil {
+		blog.Errorf("search model %s failed, err: %v, rid: %s", chart.ObjID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !models.Result {
+		return models.CCError()
+	}
+	if models.Data.Count == 0 {
+		blog.Errorf("custom chart model %s not exist, rid: %s", chart.ObjID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKObjIDField)
+	}
+
+	attrCond := &metadata.QueryCondition{Condition: mapstr.MapStr{common.BKObjIDField: chart.ObjID}}
+	attrs, err := lgc.CoreAPI.CoreService().Model().ReadModelAttr(kit.Ctx, kit.Header, chart.ObjID, attrCond)
+	if err != nil {
+		blog.Errorf("search attributes of model %s failed, err: %v, rid: %s", chart.ObjID, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !attrs.Result {
+		return attrs.CCError()
+	}
+	attributes := make(map[string]metadata.Attribute)
+	for _, attr := range attrs.Data.Info {
+		attributes[attr.PropertyID] = attr
+	}
+
+	for _, field := range chart.GroupBy {
+		// the business of the host is looked up from the host module relations
+		if chart.ObjID == common.BKInnerObjIDHost && field == common.BKAppIDField {
+			continue
+		}
+		if _, exist := attributes[field]; !exist {
+			blog.Errorf("custom chart group by field %s not exist in %s, rid: %s", field, chart.ObjID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommInstFieldNotFound, field, chart.ObjID)
+		}
+	}
+
+	if chart.Metric.Type != metadata.CustomChartMetricCount {
+		attr, exist := attributes[chart.Metric.Field]
+		if !exist {
+			blog.Errorf("custom chart metric field %s not exist in %s, rid: %s", chart.Metric.Field, chart.ObjID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommInstFieldNotFound, chart.Metric.Field, chart.ObjID)
+		}
+		if attr.PropertyType != common.FieldTypeInt && attr.PropertyType != common.FieldTypeFloat {
+			blog.Errorf("custom chart metric field %s is %s, not a number, rid: %s", attr.PropertyID, attr.PropertyType, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "metric.field")
+		}
+	}
+	return nil
+}
+
+// TimerComputeCustomChart compute all the custom charts periodically on the master
+func (lgc *Logics) TimerComputeCustomChart(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		blog.Errorf("invalid custom chart compute interval %v, the custom charts won't be computed", interval)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !lgc.Engine.ServiceManageInterface.IsMaster() {
+			continue
+		}
+
+		header := make(http.Header)
+		util.CopyHeader(lgc.header, header)
+		rid := util.GenerateRID()
+		header.Set(common.BKHTTPCCRequestID, rid)
+		blog.V(4).Infof("begin compute custom charts, rid: %s", rid)
+		option := &metadata.CustomChartComputeOption{}
+		resp, err := lgc.CoreAPI.CoreService().Operation().ComputeCustomChart(ctx, header, option)
+		if err != nil {
+			blog.Errorf("compute custom charts failed, err: %v, rid: %s", err, rid)
+			continue
+		}
+		if !resp.Result {
+			blog.Errorf("compute custom charts failed, err: %s, rid: %s", resp.ErrMsg, rid)
+			continue
+		}
+		if len(resp.Data.Failed) > 0 {
+			blog.Errorf("compute custom charts %v failed, rid: %s", resp.Data.Failed, rid)
+		}
+		blog.V(4).Infof("compute custom charts finished, computed: %d, rid: %s", len(resp.Data.Computed), rid)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const inventoryNamespace = "cmdb_inventory_"
+
+// inventoryOtherGroup the label value of the groups exceeding the max groups
+const inventoryOtherGroup = "other"
+
+// InventoryCollector export the inventory statistics of cmdb as prometheus gauges, the statistics are refreshed
+// by the master periodically and cached, the slaves export nothing so the inventory is not counted repeatedly.
+type InventoryCollector struct {
+	lgc       *Logics
+	interval  time.Duration
+	maxGroups int
+
+	lock        sync.RWMutex
+	stats       *metadata.InventoryStatistics
+	refreshTime time.Time
+
+	hosts                *prometheus.Desc
+	unassignedHosts      *prometheus.Desc
+	hostsWithoutSnapshot *prometheus.Desc
+	hostsByBiz           *prometheus.Desc
+	hostsByCloud         *prometheus.Desc
+	hostsByModule        *prometheus.Desc
+	instances            *prometheus.Desc
+	processes            *prometheus.Desc
+	refreshTimestamp     *prometheus.Desc
+}
+
+// NewInventoryCollector new the inventory collector, the max groups limits the label cardinality of each gauge
+func NewInventoryCollector(lgc *Logics, interval time.Duration, maxGroups int) *InventoryCollector {
+	return &InventoryCollector{
+		lgc:       lgc,
+		interval:  interval,
+		maxGroups: maxGroups,
+		hosts: prometheus.NewDesc(inventoryNamespace+"hosts",
+			"the count of all the hosts.", nil, nil),
+		unassignedHosts: prometheus.NewDesc(inventoryNamespace+"unassigned_hosts",
+			"the count of the hosts in the resource pool.", nil, nil),
+		hostsWithoutSnapshot: prometheus.NewDesc(inventoryNamespace+"hosts_without_snapshot",
+			"the count of the hosts without the snapshot reported by the agent.", nil, nil),
+		hostsByBiz: prometheus.NewDesc(inventoryNamespace+"biz_hosts",
+			"the count of the hosts of the business.", []string{common.BKAppIDField, common.BKAppNameField}, nil),
+		hostsByCloud: prometheus.NewDesc(inventoryNamespace+"cloud_hosts",
+			"the count of the hosts of the cloud area.", []string{common.BKCloudIDField, common.BKCloudNameField}, nil),
+		hostsByModule: prometheus.NewDesc(inventoryNamespace+"module_hosts",
+			"the count of the hosts of the module.", []string{common.BKModuleIDField, common.BKModuleNameField}, nil),
+		instances: prometheus.NewDesc(inventoryNamespace+"model_instances",
+			"the count of the instances of the model.", []string{common.BKObjIDField, common.BKObjNameField}, nil),
+		processes: prometheus.NewDesc(inventoryNamespace+"biz_processes",
+			"the count of the process instances of the business.", []string{common.BKAppIDField, common.BKAppNameField}, nil),
+		refreshTimestamp: prometheus.NewDesc(inventoryNamespace+"refresh_timestamp_seconds",
+			"the unix timestamp the inventory statistics are refreshed.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *InventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hosts
+	ch <- c.unassignedHosts
+	ch <- c.hostsWithoutSnapshot
+	ch <- c.hostsByBiz
+	ch <- c.hostsByCloud
+	ch <- c.hostsByModule
+	ch <- c.instances
+	ch <- c.processes
+	ch <- c.refreshTimestamp
+}
+
+// Collect implements prometheus.Collector, the cached statistics are exported
+func (c *InventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	stats, refreshTime := c.stats, c.refreshTime
+	c.lock.RUnlock()
+	if stats == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.hosts, prometheus.GaugeValue, float64(stats.Hosts))
+	ch <- prometheus.MustNewConstMetric(c.unassignedHosts, prometheus.GaugeValue, float64(stats.UnassignedHosts))
+	ch <- prometheus.MustNewConstMetric(c.hostsWithoutSnapshot, prometheus.GaugeValue, float64(stats.HostsWithoutSnapshot))
+	collectInventoryDimension(ch, c.hostsByBiz, stats.HostsByBiz)
+	collectInventoryDimension(ch, c.hostsByCloud, stats.HostsByCloud)
+	collectInventoryDimension(ch, c.hostsByModule, stats.HostsByModule)
+	collectInventoryDimension(ch, c.instances, stats.InstancesByModel)
+	collectInventoryDimension(ch, c.processes, stats.ProcessesByBiz)
+	ch <- prometheus.MustNewConstMetric(c.refreshTimestamp, prometheus.GaugeValue, float64(refreshTime.Unix()))
+}
+
+// collectInventoryDimension export a gauge for each group, the groups exceeding the max groups are summed as the other group
+func collectInventoryDimension(ch chan<- prometheus.Metric, desc *prometheus.Desc, dimension metadata.InventoryDimension) {
+	others := dimension.Total
+	for _, group := range dimension.Groups {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(group.Count), group.ID, group.Name)
+		others -= group.Count
+	}
+	if others > 0 {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(others), inventoryOtherGroup, inventoryOtherGroup)
+	}
+}
+
+// Run refresh the statistics periodically until the context is done
+func (c *InventoryCollector) Run(ctx context.Context) {
+	if c.interval <= 0 {
+		blog.Errorf("invalid inventory metrics refresh interval %v, the inventory metrics won't be refreshed", c.interval)
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *InventoryCollector) refresh(ctx context.Context) {
+	if !c.lgc.Engine.ServiceManageInterface.IsMaster() {
+		// the role may be changed, drop the statistics so that only the master exports them
+		c.lock.Lock()
+		c.stats = nil
+		c.lock.Unlock()
+		return
+	}
+
+	header := make(http.Header)
+	util.CopyHeader(c.lgc.header, header)
+	rid := util.GenerateRID()
+	header.Set(common.BKHTTPCCRequestID, rid)
+
+	option := &metadata.InventoryStatisticsOption{MaxGroups: c.maxGroups}
+	resp, err := c.lgc.CoreAPI.CoreService().Operation().StatisticInventory(ctx, header, option)
+	if err != nil {
+		blog.Errorf("refresh inventory metrics failed, err: %v, rid: %s", err, rid)
+		return
+	}
+	if !resp.Result {
+		blog.Errorf("refresh inventory metrics failed, err: %s, rid: %s", resp.ErrMsg, rid)
+		return
+	}
+
+	c.lock.Lock()
+	c.stats = &resp.Data
+	c.refreshTime = time.Now()
+	c.lock.Unlock()
+	blog.V(4).Infof("refresh inventory metrics success, hosts: %d, rid: %s", resp.Data.Hosts, rid)
+}
@@ -0,0 +1,191 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+func (o *OperationServer) CreateCustomChart(ctx *rest.Contexts) {
+	chart := new(metadata.CustomChart)
+	if err := ctx.DecodeInto(chart); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	srvData := o.newSrvComm(ctx.Kit.Header)
+	if err := srvData.lgc.ValidateCustomChart(ctx.Kit, chart); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	resp, err := o.CoreAPI.CoreService().Operation().CreateCustomChart(ctx.Kit.Ctx, ctx.Kit.Header, chart)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrOperationNewAddStatisticFail, "create custom chart failed, err: %v, rid: %v", err, ctx.Kit.Rid)
+		return
+	}
+	if !resp.Result {
+		ctx.RespAutoError(resp.CCError())
+		return
+	}
+	ctx.RespEntity(resp.Data)
+}
+
+func (o *OperationServer) UpdateCustomChart(ctx *rest.Contexts) {
+	id, err := strconv.ParseUint(ctx.Request.PathParameter("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("update custom chart failed, parse id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "id"))
+		return
+	}
+	chart := new(metadata.CustomChart)
+	if err := ctx.DecodeInto(chart); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	// the model of the chart can't be changed, the attributes are checked with the saved one
+	exist, err := o.getCustomChart(ctx, id)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	chart.ObjID = exist.ObjID
+	srvData := o.newSrvComm(ctx.Kit.Header)
+	if err := srvData.lgc.ValidateCustomChart(ctx.Kit, chart); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	resp, err := o.CoreAPI.CoreService().Operation().UpdateCustomChart(ctx.Kit.Ctx, ctx.Kit.Header, id, chart)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrOperationUpdateChartFail, "update custom chart %d failed, err: %v, rid: %v", id, err, ctx.Kit.Rid)
+		return
+	}
+	if !resp.Result {
+		ctx.RespAutoError(resp.CCError())
+		return
+	}
+	ctx.RespEntity(resp.Data)
+}
+
+func (o *OperationServer) DeleteCustomChart(ctx *rest.Contexts) {
+	id, err := strconv.ParseUint(ctx.Request.PathParameter("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("delete custom chart failed, parse id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "id"))
+		return
+	}
+
+	resp, err := o.CoreAPI.CoreService().Operation().DeleteCustomChart(ctx.Kit.Ctx, ctx.Kit.Header, id)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrOperationDeleteChartFail, "delete custom chart %d failed, err: %v, rid: %v", id, err, ctx.Kit.Rid)
+		return
+	}
+	if !resp.Result {
+		ctx.RespAutoError(resp.CCError())
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (o *OperationServer) SearchCustomChart(ctx *rest.Contexts) {
+	option := new(metadata.CustomChartSearchOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field, err := option.Page.Validate(true); err != nil {
+		blog.Errorf("search custom chart failed, invalid page, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	resp, err := o.CoreAPI.CoreService().Operation().SearchCustomChart(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrOperationSearchChartFail, "search custom chart failed, err: %v, rid: %v", err, ctx.Kit.Rid)
+		return
+	}
+	if !resp.Result {
+		ctx.RespAutoError(resp.CCError())
+		return
+	}
+	ctx.RespEntity(resp.Data)
+}
+
+func (o *OperationServer) SearchCustomChartData(ctx *rest.Contexts) {
+	id, err := strconv.ParseUint(ctx.Request.PathParameter("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("search custom chart data failed, parse id failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "id"))
+		return
+	}
+	option := new(metadata.CustomChartDataOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	resp, err := o.CoreAPI.CoreService().Operation().SearchCustomChartData(ctx.Kit.Ctx, ctx.Kit.Header, id, option)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrOperationGetChartDataFail, "search custom chart %d data failed, err: %v, rid: %v", id, err, ctx.Kit.Rid)
+		return
+	}
+	if !resp.Result {
+		ctx.RespAutoError(resp.CCError())
+		return
+	}
+	ctx.RespEntity(resp.Data)
+}
+
+// ComputeCustomChart compute the charts immediately instead of waiting for the timer
+func (o *OperationServer) ComputeCustomChart(ctx *rest.Contexts) {
+	option := new(metadata.CustomChartComputeOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if len(option.IDs) == 0 {
+		blog.Errorf("compute custom chart failed, ids is empty, rid: %s", ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "ids"))
+		return
+	}
+
+	resp, err := o.CoreAPI.CoreService().Operation().ComputeCustomChart(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrOperationCustomChartComputeFail, "compute custom chart failed, err: %v, rid: %v", err, ctx.Kit.Rid)
+		return
+	}
+	if !resp.Result {
+		ctx.RespAutoError(resp.CCError())
+		return
+	}
+	ctx.RespEntity(resp.Data)
+}
+
+func (o *OperationServer) getCustomChart(ctx *rest.Contexts, id uint64) (*metadata.CustomChart, error) {
+	resp, err := o.CoreAPI.CoreService().Operation().SearchCustomChartData(ctx.Kit.Ctx, ctx.Kit.Header, id, &metadata.CustomChartDataOption{})
+	if err != nil {
+		blog.Errorf("get custom chart %d failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCError(common.CCErrOperationSearchChartFail)
+	}
+	if !resp.Result {
+		return nil, resp.CCError()
+	}
+	return &resp.Data.Chart, nil
+}
@@ -16,7 +16,9 @@ import (
 	"net/http"
 
 	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/operation_server/logics"
 )
 
 func (o *OperationServer) InitFunc() {
@@ -28,4 +30,12 @@ func (o *OperationServer) InitFunc() {
 
 	srvData := o.newSrvComm(header)
 	go srvData.lgc.TimerFreshData(srvData.ctx)
+	go srvData.lgc.TimerComputeCustomChart(srvData.ctx, o.Config.CustomChartInterval)
+
+	inventory := logics.NewInventoryCollector(srvData.lgc, o.Config.InventoryMetric.Interval, o.Config.InventoryMetric.MaxGroups)
+	if err := o.Engine.Metric().Registry().Register(inventory); err != nil {
+		blog.Errorf("register inventory metrics failed, err: %v", err)
+		return
+	}
+	go inventory.Run(srvData.ctx)
 }
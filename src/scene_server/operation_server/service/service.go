@@ -19,6 +19,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/auth/extensions"
@@ -114,6 +115,14 @@ func (o *OperationServer) newOperationService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/search/operation/chart/data", Handler: o.SearchChartData})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/operation/chart/position", Handler: o.UpdateChartPosition})
 
+	// custom chart
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/operation/custom_chart", Handler: o.CreateCustomChart})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/operation/custom_chart/{id}", Handler: o.UpdateCustomChart})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/operation/custom_chart/{id}", Handler: o.DeleteCustomChart})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/search/operation/custom_chart", Handler: o.SearchCustomChart})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/search/operation/custom_chart/{id}/data", Handler: o.SearchCustomChartData})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/compute/operation/custom_chart", Handler: o.ComputeCustomChart})
+
 	utility.AddToRestfulWebService(web)
 }
 
@@ -169,6 +178,8 @@ func (o *OperationServer) OnOperationConfigUpdate(previous, current cc.ProcessCo
 		Mongo: cfg,
 	}
 	o.Config.ConfigMap = current.ConfigMap
+	o.Config.CustomChartInterval = parseCustomChartInterval("timer", current.ConfigMap)
+	o.Config.InventoryMetric = parseInventoryMetricConfig("metrics", current.ConfigMap)
 
 	o.Config.Auth, err = authcenter.ParseConfigFromKV("auth", current.ConfigMap)
 	if err != nil {
@@ -183,6 +194,49 @@ func (o *OperationServer) OnOperationConfigUpdate(previous, current cc.ProcessCo
 	}
 }
 
+// parseCustomChartInterval parse the minutes between the computing of the custom charts, 10 minutes by default
+func parseCustomChartInterval(prefix string, configMap map[string]string) time.Duration {
+	defaultInterval := 10 * time.Minute
+
+	intervalStr, ok := configMap[prefix+".customChartInterval"]
+	if !ok {
+		return defaultInterval
+	}
+	minutes, err := strconv.Atoi(intervalStr)
+	if err != nil || minutes <= 0 {
+		blog.Errorf("parse timer config failed, invalid customChartInterval %s, set default value: 10 minutes", intervalStr)
+		return defaultInterval
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// parseInventoryMetricConfig parse the inventory metrics config, refresh every 5 minutes with 100 groups at most by default
+func parseInventoryMetricConfig(prefix string, configMap map[string]string) options.InventoryMetricConfig {
+	conf := options.InventoryMetricConfig{
+		Interval:  5 * time.Minute,
+		MaxGroups: 100,
+	}
+
+	if intervalStr, ok := configMap[prefix+".inventoryInterval"]; ok {
+		minutes, err := strconv.Atoi(intervalStr)
+		if err != nil || minutes <= 0 {
+			blog.Errorf("parse metrics config failed, invalid inventoryInterval %s, set default value: 5 minutes", intervalStr)
+		} else {
+			conf.Interval = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if maxGroupsStr, ok := configMap[prefix+".inventoryMaxGroups"]; ok {
+		maxGroups, err := strconv.Atoi(maxGroupsStr)
+		if err != nil || maxGroups <= 0 {
+			blog.Errorf("parse metrics config failed, invalid inventoryMaxGroups %s, set default value: 100", maxGroupsStr)
+		} else {
+			conf.MaxGroups = maxGroups
+		}
+	}
+	return conf
+}
+
 func (o *OperationServer) ParseTimerConfigFromKV(prefix string, configMap map[string]string) (string, error) {
 	// 若是timer没配置，或者解析失败，给一个默认的定时时间
 	defaultSpec := "30 0 * * *"
@@ -15,6 +15,7 @@ package options
 import (
 	"configcenter/src/common/auth"
 	"configcenter/src/common/core/cc/config"
+	"configcenter/src/common/metadata"
 	"configcenter/src/storage/dal/mongo"
 
 	"github.com/spf13/pflag"
@@ -38,11 +39,13 @@ func NewServerOption() *ServerOption {
 func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ServConf.AddrPort, "addrport", "127.0.0.1:60003", "The ip address and port for the serve on")
 	// fs.UintVar(&s.ServConf.Port, "port", 60003, "The port for the serve on")
-	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181")
+	fs.StringVar(&s.ServConf.RegDiscover, "regdiscv", "", "hosts of register and discover server. e.g: 127.0.0.1:2181, etcd://127.0.0.1:2379 for etcd, or file:///data/cmdb/discovery.yaml for the static discovery")
 	fs.StringVar(&s.ServConf.ExConfig, "config", "", "The config path. e.g conf/api.conf")
 	fs.Var(auth.EnableAuthFlag, "enable-auth", "The auth center enable status, true for enabled, false for disabled")
 }
 
 type Config struct {
 	Mongo *mongo.Config
+	// BindConflictMode how to deal with processes bind the same ip, port and protocol on a host
+	BindConflictMode metadata.ProcessBindConflictMode
 }
@@ -0,0 +1,119 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// GetProcessBindHosts get the ips of the hosts which are used to resolve the host dependent ip selectors of bind info
+func (lgc *Logic) GetProcessBindHosts(kit *rest.Kit, hostIDs []int64) (map[int64]metadata.BindHost, errors.CCErrorCoder) {
+	hosts := make(map[int64]metadata.BindHost)
+	if len(hostIDs) == 0 {
+		return hosts, nil
+	}
+
+	query := &metadata.QueryCondition{
+		Fields: []string{common.BKHostIDField, common.BKHostInnerIPField, common.BKHostOuterIPField},
+		Condition: mapstr.MapStr{
+			common.BKHostIDField: mapstr.MapStr{
+				common.BKDBIN: hostIDs,
+			},
+		},
+		Limit: metadata.SearchLimit{
+			Limit: common.BKNoLimit,
+		},
+	}
+	result, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(kit.Ctx, kit.Header, common.BKInnerObjIDHost, query)
+	if err != nil {
+		blog.ErrorJSON("GetProcessBindHosts failed, ReadInstance failed, query: %s, err: %s, rid: %s", query, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if result.Result == false {
+		blog.ErrorJSON("GetProcessBindHosts failed, ReadInstance failed, query: %s, result: %s, rid: %s", query, result, kit.Rid)
+		return nil, errors.New(result.Code, result.ErrMsg)
+	}
+
+	for _, hostID := range hostIDs {
+		hosts[hostID] = metadata.BindHost{HostID: hostID}
+	}
+	for _, item := range result.Data.Info {
+		hostID, err := item.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.ErrorJSON("GetProcessBindHosts failed, parse host id failed, host: %s, err: %s, rid: %s", item, err, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommInstFieldConvertFail, common.BKInnerObjIDHost, common.BKHostIDField, "int", err.Error())
+		}
+		innerIP, _ := item.String(common.BKHostInnerIPField)
+		outerIP, _ := item.String(common.BKHostOuterIPField)
+		hosts[hostID] = metadata.BindHost{
+			HostID:  hostID,
+			InnerIP: innerIP,
+			OuterIP: outerIP,
+		}
+	}
+	return hosts, nil
+}
+
+// ListHostProcessBindInfos list the bind info of all the processes on the hosts, including processes of other businesses,
+// processes that don't listen on any port are ignored.
+func (lgc *Logic) ListHostProcessBindInfos(kit *rest.Kit, hostIDs []int64) ([]metadata.ProcessBindInfo, errors.CCErrorCoder) {
+	infos := make([]metadata.ProcessBindInfo, 0)
+	if len(hostIDs) == 0 {
+		return infos, nil
+	}
+
+	option := &metadata.ListProcessInstanceRelationOption{
+		HostIDs: hostIDs,
+		Page:    metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	relations, err := lgc.CoreAPI.CoreService().Process().ListProcessInstanceRelation(kit.Ctx, kit.Header, option)
+	if err != nil {
+		blog.ErrorJSON("ListHostProcessBindInfos failed, ListProcessInstanceRelation failed, option: %s, err: %s, rid: %s", option, err, kit.Rid)
+		return nil, err
+	}
+	if len(relations.Info) == 0 {
+		return infos, nil
+	}
+
+	processIDs := make([]int64, 0)
+	for _, relation := range relations.Info {
+		processIDs = append(processIDs, relation.ProcessID)
+	}
+	processes, err := lgc.ListProcessInstanceWithIDs(kit, processIDs)
+	if err != nil {
+		blog.Errorf("ListHostProcessBindInfos failed, ListProcessInstanceWithIDs failed, processIDs: %v, err: %s, rid: %s", processIDs, err, kit.Rid)
+		return nil, err
+	}
+	processMap := make(map[int64]*metadata.Process)
+	for idx := range processes {
+		processMap[processes[idx].ProcessID] = &processes[idx]
+	}
+	hosts, err := lgc.GetProcessBindHosts(kit, hostIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, relation := range relations.Info {
+		host, exist := hosts[relation.HostID]
+		if exist == false {
+			host = metadata.BindHost{HostID: relation.HostID}
+		}
+		infos = append(infos, metadata.NewProcessBindInfos(processMap[relation.ProcessID], host, relation.ServiceInstanceID)...)
+	}
+	return infos, nil
+}
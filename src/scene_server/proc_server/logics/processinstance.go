@@ -260,7 +260,8 @@ func (lgc *Logic) DiffWithProcessTemplate(t *metadata.ProcessProperty, i *metada
 		}
 	}
 
-	if metadata.IsAsDefaultValue(t.BindIP.AsDefaultValue) {
+	// the legacy bind fields are derived from the bind info if it is set, which is compared instead
+	if metadata.IsAsDefaultValue(t.BindIP.AsDefaultValue) && len(t.BindInfo.Value) == 0 {
 		if (t.BindIP.Value == nil && i.BindIP != nil) ||
 			(t.BindIP.Value != nil && i.BindIP == nil) ||
 			(t.BindIP.Value != nil && i.BindIP != nil && t.BindIP.Value.IP() != *i.BindIP) {
@@ -316,7 +317,7 @@ func (lgc *Logic) DiffWithProcessTemplate(t *metadata.ProcessProperty, i *metada
 		}
 	}
 
-	if metadata.IsAsDefaultValue(t.Port.AsDefaultValue) {
+	if metadata.IsAsDefaultValue(t.Port.AsDefaultValue) && len(t.BindInfo.Value) == 0 {
 		if (t.Port.Value == nil && i.Port != nil) ||
 			(t.Port.Value != nil && i.Port == nil) ||
 			(t.Port.Value != nil && i.Port != nil && *t.Port.Value != *i.Port) {
@@ -428,7 +429,7 @@ func (lgc *Logic) DiffWithProcessTemplate(t *metadata.ProcessProperty, i *metada
 		}
 	}
 
-	if metadata.IsAsDefaultValue(t.Protocol.AsDefaultValue) {
+	if metadata.IsAsDefaultValue(t.Protocol.AsDefaultValue) && len(t.BindInfo.Value) == 0 {
 		if (t.Protocol.Value == nil && i.Protocol != nil) ||
 			(t.Protocol.Value != nil && i.Protocol == nil) ||
 			(t.Protocol.Value != nil && i.Protocol != nil && *t.Protocol.Value != *i.Protocol) {
@@ -470,5 +471,17 @@ func (lgc *Logic) DiffWithProcessTemplate(t *metadata.ProcessProperty, i *metada
 		}
 	}
 
+	if metadata.IsAsDefaultValue(t.BindInfo.AsDefaultValue) {
+		if metadata.ProcBindInfosEqual(t.BindInfo.Value, i.BindInfo) == false {
+			changes = append(changes, metadata.ProcessChangedAttribute{
+				ID:                    attrMap[common.BKProcBindInfo].ID,
+				PropertyID:            common.BKProcBindInfo,
+				PropertyName:          attrMap[common.BKProcBindInfo].PropertyName,
+				PropertyValue:         i.BindInfo,
+				TemplatePropertyValue: t.BindInfo,
+			})
+		}
+	}
+
 	return changes
 }
@@ -0,0 +1,239 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstruct"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+func (ps *ProcServer) bindConflictMode() metadata.ProcessBindConflictMode {
+	if ps.Config == nil || ps.Config.BindConflictMode == "" {
+		return metadata.BindConflictModeWarn
+	}
+	return ps.Config.BindConflictMode
+}
+
+// processBindFields are the fields which decide what a process listens on
+var processBindFields = []string{common.BKProcessNameField, "bind_ip", "port", "protocol", common.BKProcBindInfo}
+
+// applyProcessBindChange return the process with the bind fields in data applied,
+// so that the bind info can be validated before the process is updated.
+func applyProcessBindChange(process metadata.Process, data map[string]interface{}) (*metadata.Process, error) {
+	changes := make(map[string]interface{})
+	for _, field := range processBindFields {
+		if value, exist := data[field]; exist == true {
+			changes[field] = value
+		}
+	}
+	if len(changes) == 0 {
+		return &process, nil
+	}
+
+	changed := metadata.Process{}
+	if err := mapstruct.Decode2Struct(changes, &changed); err != nil {
+		return nil, err
+	}
+	if _, exist := changes[common.BKProcessNameField]; exist == true {
+		process.ProcessName = changed.ProcessName
+	}
+	if _, exist := changes["bind_ip"]; exist == true {
+		process.BindIP = changed.BindIP
+	}
+	if _, exist := changes["port"]; exist == true {
+		process.Port = changed.Port
+	}
+	if _, exist := changes["protocol"]; exist == true {
+		process.Protocol = changed.Protocol
+	}
+	if _, exist := changes[common.BKProcBindInfo]; exist == true {
+		process.BindInfo = changed.BindInfo
+	}
+	return &process, nil
+}
+
+// prepareProcessBindChange apply the bind fields in data to the process, and derive the legacy bind_ip, port and
+// protocol from the bind info into data, so that the legacy fields never disagree with the bind info once it is set.
+func prepareProcessBindChange(process metadata.Process, host metadata.BindHost, data map[string]interface{}) (*metadata.Process, error) {
+	changed, err := applyProcessBindChange(process, data)
+	if err != nil {
+		return nil, err
+	}
+	for field, value := range changed.SyncLegacyBindFields(host.InnerIP, host.OuterIP) {
+		data[field] = value
+	}
+	return changed, nil
+}
+
+// getProcessBindHost get the host used to resolve the ip selectors of the process bind info
+func (ps *ProcServer) getProcessBindHost(ctx *rest.Contexts, hostID int64) (metadata.BindHost, errors.CCErrorCoder) {
+	hosts, err := ps.Logic.GetProcessBindHosts(ctx.Kit, []int64{hostID})
+	if err != nil {
+		blog.Errorf("getProcessBindHost failed, GetProcessBindHosts failed, hostID: %d, err: %s, rid: %s", hostID, err, ctx.Kit.Rid)
+		return metadata.BindHost{}, err
+	}
+	return hosts[hostID], nil
+}
+
+// validateProcBindInfo validate the bind info list of a process instance, the bind info is not validated by core service
+func validateProcBindInfo(ctx *rest.Contexts, bindInfo []metadata.ProcBindInfo) errors.CCErrorCoder {
+	for idx, item := range bindInfo {
+		if field, err := item.Validate(); err != nil {
+			blog.Errorf("validate process bind info failed, bind info [%d] field %s invalid, err: %v, rid: %s", idx, field, err, ctx.Kit.Rid)
+			return ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKProcBindInfo+"."+field)
+		}
+	}
+	return nil
+}
+
+// validateProcessBindConflict check whether the process binds the same ip, port and protocol with other processes
+// on the host, the process is refused in strict mode and only a warning is logged in warn mode.
+func (ps *ProcServer) validateProcessBindConflict(ctx *rest.Contexts, host metadata.BindHost, process *metadata.Process) errors.CCErrorCoder {
+	mode := ps.bindConflictMode()
+	if mode == metadata.BindConflictModeOff {
+		return nil
+	}
+	hostID := host.HostID
+	infos := metadata.NewProcessBindInfos(process, host, 0)
+	if len(infos) == 0 {
+		return nil
+	}
+
+	existInfos, err := ps.Logic.ListHostProcessBindInfos(ctx.Kit, []int64{hostID})
+	if err != nil {
+		blog.Errorf("validateProcessBindConflict failed, ListHostProcessBindInfos failed, hostID: %d, err: %s, rid: %s", hostID, err, ctx.Kit.Rid)
+		return err
+	}
+	for _, info := range infos {
+		conflictNames := make([]string, 0)
+		for _, existInfo := range existInfos {
+			if info.ConflictWith(existInfo) {
+				conflictNames = append(conflictNames, existInfo.ProcessName)
+			}
+		}
+		if len(conflictNames) == 0 {
+			continue
+		}
+
+		if mode == metadata.BindConflictModeWarn {
+			blog.Warnf("process %d binds %s %s:%s which conflicts with process %v on host %d, rid: %s", info.ProcessID, info.Protocol.String(), info.BindIP, info.Port, conflictNames, hostID, ctx.Kit.Rid)
+			continue
+		}
+		blog.Errorf("process %d binds %s %s:%s which conflicts with process %v on host %d, rid: %s", info.ProcessID, info.Protocol.String(), info.BindIP, info.Port, conflictNames, hostID, ctx.Kit.Rid)
+		return ctx.Kit.CCError.CCErrorf(common.CCErrProcBindPortConflict, info.Protocol.String(), info.BindIP, info.Port, strings.Join(conflictNames, ","))
+	}
+	return nil
+}
+
+// ListProcessBindConflicts list processes of the business which bind the same ip, port and protocol
+// with other processes on the same host.
+func (ps *ProcServer) ListProcessBindConflicts(ctx *rest.Contexts) {
+	input := new(metadata.ListProcessBindConflictOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	bizID := input.BizID
+	if bizID == 0 && input.Metadata != nil {
+		var err error
+		bizID, err = metadata.BizIDFromMetadata(*input.Metadata)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "list process bind conflicts, but get business id failed, err: %v", err)
+			return
+		}
+	}
+	if bizID == 0 {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "list process bind conflicts, but business id not set", common.BKAppIDField)
+		return
+	}
+
+	// find the hosts that processes of the business are on
+	option := &metadata.ListProcessInstanceRelationOption{
+		BusinessID: bizID,
+		HostIDs:    input.HostIDs,
+		Page:       metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	relations, err := ps.CoreAPI.CoreService().Process().ListProcessInstanceRelation(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrProcGetProcessInstanceRelationFailed, "list process bind conflicts, but list process instance relation failed, option: %+v", option)
+		return
+	}
+	hostIDs := make([]int64, 0)
+	for _, relation := range relations.Info {
+		hostIDs = append(hostIDs, relation.HostID)
+	}
+	hostIDs = util.IntArrayUnique(hostIDs)
+
+	infos, err := ps.Logic.ListHostProcessBindInfos(ctx.Kit, hostIDs)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrProcGetProcessInstanceRelationFailed, "list process bind conflicts, but list process bind info failed, hostIDs: %v", hostIDs)
+		return
+	}
+
+	// only the conflicts that the business is involved in are reported, and the processes of
+	// other businesses are redacted to their business id and port.
+	conflicts := make([]metadata.ProcessBindConflict, 0)
+	for _, conflict := range metadata.FindProcessBindConflicts(infos) {
+		involved := false
+		for idx, info := range conflict.Processes {
+			if info.BizID == bizID {
+				involved = true
+				continue
+			}
+			conflict.Processes[idx] = info.Redact()
+		}
+		if involved == true {
+			conflicts = append(conflicts, conflict)
+		}
+	}
+
+	ctx.RespEntityWithCount(int64(len(conflicts)), conflicts)
+}
+
+// validateProcessUpdateBindConflict validate the bind conflict of a process as if data is updated to it,
+// the legacy bind fields derived from the bind info are written into data too.
+func (ps *ProcServer) validateProcessUpdateBindConflict(ctx *rest.Contexts, hostID int64, processID int64, data map[string]interface{}) errors.CCErrorCoder {
+	bindChanged := false
+	for _, field := range processBindFields {
+		if _, exist := data[field]; exist == true {
+			bindChanged = true
+			break
+		}
+	}
+	if bindChanged == false {
+		return nil
+	}
+	existProcess, err := ps.Logic.GetProcessInstanceWithID(ctx.Kit, processID)
+	if err != nil {
+		blog.Errorf("validateProcessUpdateBindConflict failed, GetProcessInstanceWithID failed, processID: %d, err: %s, rid: %s", processID, err, ctx.Kit.Rid)
+		return err
+	}
+	host, err := ps.getProcessBindHost(ctx, hostID)
+	if err != nil {
+		return err
+	}
+	process, e := prepareProcessBindChange(*existProcess, host, data)
+	if e != nil {
+		blog.ErrorJSON("validateProcessUpdateBindConflict failed, apply process change failed, data: %s, err: %s, rid: %s", data, e.Error(), ctx.Kit.Rid)
+		return ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	return ps.validateProcessBindConflict(ctx, host, process)
+}
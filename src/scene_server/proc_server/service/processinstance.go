@@ -73,6 +73,11 @@ func (ps *ProcServer) createProcessInstances(ctx *rest.Contexts, input *metadata
 		return nil, ctx.Kit.CCError.CCError(common.CCErrProcEditProcessInstanceCreateByTemplateForbidden)
 	}
 
+	host, err := ps.getProcessBindHost(ctx, serviceInstance.HostID)
+	if err != nil {
+		return nil, err
+	}
+
 	processIDs := make([]int64, 0)
 	for _, item := range input.Processes {
 		now := time.Now()
@@ -85,6 +90,17 @@ func (ps *ProcServer) createProcessInstances(ctx *rest.Contexts, input *metadata
 		if err := ps.validateRawInstanceUnique(ctx, serviceInstance.ID, item.ProcessData); err != nil {
 			return nil, err
 		}
+		process, e := prepareProcessBindChange(metadata.Process{BusinessID: bizID}, host, item.ProcessData)
+		if e != nil {
+			blog.ErrorJSON("create process instance failed, decode process failed, process: %s, err: %s, rid: %s", item.ProcessData, e.Error(), ctx.Kit.Rid)
+			return nil, ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+		}
+		if err := validateProcBindInfo(ctx, process.BindInfo); err != nil {
+			return nil, err
+		}
+		if err := ps.validateProcessBindConflict(ctx, host, process); err != nil {
+			return nil, err
+		}
 
 		processID, err := ps.Logic.CreateProcessInstance(ctx.Kit, item.ProcessData)
 		if err != nil {
@@ -145,6 +161,9 @@ func (ps *ProcServer) updateProcessInstances(ctx *rest.Contexts, input metadata.
 			return nil, ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
 		}
 		input.Processes = append(input.Processes, process)
+		if err := validateProcBindInfo(ctx, process.BindInfo); err != nil {
+			return nil, err
+		}
 
 		if process.ProcessID == 0 {
 			blog.Errorf("update process instance failed, process_id invalid, rid: %s", rid)
@@ -257,6 +276,10 @@ func (ps *ProcServer) updateProcessInstances(ctx *rest.Contexts, input metadata.
 			processData[field] = nil
 		}
 
+		if err := ps.validateProcessUpdateBindConflict(ctx, relation.HostID, process.ProcessID, processData); err != nil {
+			return nil, err
+		}
+
 		if err := ps.Logic.UpdateProcessInstance(ctx.Kit, process.ProcessID, processData); err != nil {
 			blog.Errorf("update process failed, processID: %d, process: %+v, err: %v, rid: %s", process.ProcessID, process, err, rid)
 			return nil, err
@@ -38,13 +38,26 @@ func (ps *ProcServer) CreateProcessTemplateBatch(ctx *rest.Contexts) {
 			return
 		}
 	}
+	if bizID == common.BKGlobalBizID {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "create process template, but business id not set", common.BKAppIDField)
+		return
+	}
+
+	ps.createProcessTemplates(ctx, bizID, input)
+}
 
+func (ps *ProcServer) createProcessTemplates(ctx *rest.Contexts, bizID int64, input *metadata.CreateProcessTemplateBatchInput) {
 	// authorize
 	if err := ps.AuthManager.AuthorizeByServiceTemplateID(ctx.Kit.Ctx, ctx.Kit.Header, meta.Update, input.ServiceTemplateID); err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommCheckAuthorizeFailed, "authorize by service template id failed, id: %d, err: %+v", input.ServiceTemplateID, err)
 		return
 	}
 
+	if err := ps.checkServiceTemplatesEditable(ctx, bizID, input.ServiceTemplateID); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
 	ids := make([]int64, 0)
 	for _, process := range input.Processes {
 		t := &metadata.ProcessTemplate{
@@ -81,11 +94,19 @@ func (ps *ProcServer) DeleteProcessTemplateBatch(ctx *rest.Contexts) {
 			return
 		}
 	}
+	if bizID == common.BKGlobalBizID {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "delete process template, but business id not set", common.BKAppIDField)
+		return
+	}
 	input.BizID = bizID
 
+	ps.deleteProcessTemplates(ctx, input)
+}
+
+func (ps *ProcServer) deleteProcessTemplates(ctx *rest.Contexts, input *metadata.DeleteProcessTemplateBatchInput) {
 	// authorize by service template
 	listOption := &metadata.ListProcessTemplatesOption{
-		BusinessID:         bizID,
+		BusinessID:         input.BizID,
 		ProcessTemplateIDs: input.ProcessTemplates,
 		Page: metadata.BasePage{
 			Limit: common.BKNoLimit,
@@ -104,6 +125,10 @@ func (ps *ProcServer) DeleteProcessTemplateBatch(ctx *rest.Contexts) {
 		ctx.RespErrorCodeOnly(common.CCErrCommCheckAuthorizeFailed, "authorize by service template id failed, id: %+v, err: %+v", serviceTemplateIDs, err)
 		return
 	}
+	if err := ps.checkServiceTemplatesEditable(ctx, input.BizID, serviceTemplateIDs...); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
 
 	err = ps.CoreAPI.CoreService().Process().DeleteProcessTemplateBatch(ctx.Kit.Ctx, ctx.Kit.Header, input.ProcessTemplates)
 	if err != nil {
@@ -131,8 +156,16 @@ func (ps *ProcServer) UpdateProcessTemplate(ctx *rest.Contexts) {
 			return
 		}
 	}
+	if bizID == common.BKGlobalBizID {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "update process template, but business id not set", common.BKAppIDField)
+		return
+	}
 	input.BizID = bizID
 
+	ps.updateProcessTemplate(ctx, input)
+}
+
+func (ps *ProcServer) updateProcessTemplate(ctx *rest.Contexts, input *metadata.UpdateProcessTemplateInput) {
 	if input.Property == nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "update process template, but property empty, input: %+v", input)
 		return
@@ -143,7 +176,7 @@ func (ps *ProcServer) UpdateProcessTemplate(ctx *rest.Contexts) {
 	}
 
 	listOption := &metadata.ListProcessTemplatesOption{
-		BusinessID:         bizID,
+		BusinessID:         input.BizID,
 		ProcessTemplateIDs: []int64{input.ProcessTemplateID},
 	}
 	processTemplates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(ctx.Kit.Ctx, ctx.Kit.Header, listOption)
@@ -159,6 +192,10 @@ func (ps *ProcServer) UpdateProcessTemplate(ctx *rest.Contexts) {
 		ctx.RespErrorCodeOnly(common.CCErrCommCheckAuthorizeFailed, "authorize by service template id failed, id: %+v, err: %+v", serviceTemplateIDs, err)
 		return
 	}
+	if err := ps.checkServiceTemplatesEditable(ctx, input.BizID, serviceTemplateIDs...); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
 
 	template, err := ps.CoreAPI.CoreService().Process().UpdateProcessTemplate(ctx.Kit.Ctx, ctx.Kit.Header, input.ProcessTemplateID, input.Property)
 	if err != nil {
@@ -219,7 +256,15 @@ func (ps *ProcServer) ListProcessTemplate(ctx *rest.Contexts) {
 			return
 		}
 	}
+	if bizID == common.BKGlobalBizID {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "list process template, but business id not set", common.BKAppIDField)
+		return
+	}
+
+	ps.listProcessTemplates(ctx, bizID, input)
+}
 
+func (ps *ProcServer) listProcessTemplates(ctx *rest.Contexts, bizID int64, input *metadata.ListProcessTemplateWithServiceTemplateInput) {
 	option := &metadata.ListProcessTemplatesOption{
 		BusinessID:         bizID,
 		ServiceTemplateIDs: []int64{input.ServiceTemplateID},
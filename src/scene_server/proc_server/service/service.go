@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
 	cfnc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/language"
@@ -128,6 +129,21 @@ func (ps *ProcServer) newProcessService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template/with_detail", Handler: ps.ListServiceTemplatesWithDetails})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/proc/service_template", Handler: ps.DeleteServiceTemplate})
 
+	// service template version
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/proc/service_template_version", Handler: ps.CreateServiceTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/find/proc/service_template/{service_template_id}/version/{version}", Handler: ps.GetServiceTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template_version", Handler: ps.ListServiceTemplateVersions})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/service_template_version/pin", Handler: ps.PinModuleServiceTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template_version/pin", Handler: ps.ListModuleServiceTemplateVersions})
+
+	// service template rollout
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/proc/service_template_rollout", Handler: ps.CreateServiceTemplateRollout})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/proc/service_template_rollout", Handler: ps.GetServiceTemplateRollout})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_template_rollout", Handler: ps.ListServiceTemplateRollouts})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/service_template_rollout/pause", Handler: ps.PauseServiceTemplateRollout})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/service_template_rollout/resume", Handler: ps.ResumeServiceTemplateRollout})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/service_template_rollout/abort", Handler: ps.AbortServiceTemplateRollout})
+
 	// process template
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/proc/proc_template", Handler: ps.CreateProcessTemplateBatch})
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/proc_template", Handler: ps.UpdateProcessTemplate})
@@ -135,6 +151,21 @@ func (ps *ProcServer) newProcessService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/proc/proc_template/id/{processTemplateID}", Handler: ps.GetProcessTemplate})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/proc_template", Handler: ps.ListProcessTemplate})
 
+	// global service template shared by businesses
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/proc/global/service_category", Handler: ps.CreateGlobalServiceCategory})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/proc/global/service_template", Handler: ps.CreateGlobalServiceTemplate})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/global/service_template", Handler: ps.UpdateGlobalServiceTemplate})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/proc/global/service_template", Handler: ps.DeleteGlobalServiceTemplate})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/global/service_template", Handler: ps.ListGlobalServiceTemplates})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/global/service_template/downstream", Handler: ps.ListDownstreamServiceTemplates})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/proc/global/proc_template", Handler: ps.CreateGlobalProcessTemplateBatch})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/global/proc_template", Handler: ps.UpdateGlobalProcessTemplate})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/deletemany/proc/global/proc_template", Handler: ps.DeleteGlobalProcessTemplateBatch})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/global/proc_template", Handler: ps.ListGlobalProcessTemplate})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/proc/service_template/from_global", Handler: ps.CreateServiceTemplateFromGlobal})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/proc/service_template/upstream_difference", Handler: ps.DiffServiceTemplateWithUpstream})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/service_template/upstream_difference", Handler: ps.AcceptServiceTemplateUpstream})
+
 	// service instance
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/proc/service_instance", Handler: ps.CreateServiceInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/service_instance", Handler: ps.SearchServiceInstancesInModule})
@@ -155,10 +186,14 @@ func (ps *ProcServer) newProcessService(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/proc/process_instance", Handler: ps.UpdateProcessInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/proc/process_instance", Handler: ps.DeleteProcessInstance})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/process_instance", Handler: ps.ListProcessInstances})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/proc/process_instance/bind_conflict", Handler: ps.ListProcessBindConflicts})
 
 	// module
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/proc/template_binding_on_module", Handler: ps.RemoveTemplateBindingOnModule})
 
+	// task_server callback
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/internal/task/service_template_rollout", Handler: ps.ServiceTemplateRolloutTaskHandler})
+
 	utility.AddToRestfulWebService(web)
 }
 
@@ -243,4 +278,14 @@ func (ps *ProcServer) OnProcessConfigUpdate(previous, current cfnc.ProcessConfig
 	dbPrefix := "mongodb"
 	mongoCfg := mongo.ParseConfigFromKV(dbPrefix, current.ConfigMap)
 	ps.Config.Mongo = &mongoCfg
+
+	ps.Config.BindConflictMode = metadata.BindConflictModeWarn
+	if val, ok := current.ConfigMap["process.bindConflictMode"]; ok {
+		mode := metadata.ProcessBindConflictMode(val)
+		if err := mode.Validate(); err != nil {
+			blog.Errorf("invalid process.bindConflictMode, use %s instead, err: %v", ps.Config.BindConflictMode, err)
+		} else {
+			ps.Config.BindConflictMode = mode
+		}
+	}
 }
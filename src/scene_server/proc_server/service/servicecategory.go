@@ -119,7 +119,15 @@ func (ps *ProcServer) CreateServiceCategory(ctx *rest.Contexts) {
 			return
 		}
 	}
+	if bizID == common.BKGlobalBizID {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "create service category, but business id not set", common.BKAppIDField)
+		return
+	}
+
+	ps.createServiceCategory(ctx, bizID, input)
+}
 
+func (ps *ProcServer) createServiceCategory(ctx *rest.Contexts, bizID int64, input *metadata.CreateServiceCategoryOption) {
 	newCategory := &metadata.ServiceCategory{
 		BizID:    bizID,
 		Name:     input.Name,
@@ -465,6 +465,7 @@ func (ps *ProcServer) DiffServiceInstanceWithTemplate(ctx *rest.Contexts) {
 			Metadata: diffOption.Metadata,
 			BizID:    diffOption.BizID,
 			ModuleID: moduleID,
+			Version:  diffOption.Version,
 		}
 		oneModuleResult, err := ps.diffServiceInstanceWithTemplate(ctx, option)
 		if err != nil {
@@ -520,14 +521,10 @@ func (ps *ProcServer) diffServiceInstanceWithTemplate(ctx *rest.Contexts, diffOp
 		attributeMap[attr.PropertyID] = attr
 	}
 
-	// step2. get process templates
-	listProcessTemplateOption := &metadata.ListProcessTemplatesOption{
-		BusinessID:         module.BizID,
-		ServiceTemplateIDs: []int64{module.ServiceTemplateID},
-	}
-	processTemplates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(ctx.Kit.Ctx, ctx.Kit.Header, listProcessTemplateOption)
+	// step2. get process templates of the service template version the module uses
+	serviceTemplate, processTemplates, err := ps.getModuleTemplateDefinition(ctx, module, diffOption.Version)
 	if err != nil {
-		blog.ErrorJSON("diffServiceInstanceWithTemplate failed, ListProcessTemplates failed, option: %s, err: %s, rid: %s", listProcessTemplateOption, err, rid)
+		blog.Errorf("diffServiceInstanceWithTemplate failed, getModuleTemplateDefinition failed, moduleID: %d, version: %d, err: %s, rid: %s", module.ModuleID, diffOption.Version, err, rid)
 		return nil, err
 	}
 
@@ -535,8 +532,8 @@ func (ps *ProcServer) diffServiceInstanceWithTemplate(ctx *rest.Contexts, diffOp
 	// find process instance's relations, which allows us know the relationship between
 	// process instance and it's template, service instance, etc.
 	pTemplateMap := make(map[int64]*metadata.ProcessTemplate)
-	for idx, pTemplate := range processTemplates.Info {
-		pTemplateMap[pTemplate.ID] = &processTemplates.Info[idx]
+	for idx, pTemplate := range processTemplates {
+		pTemplateMap[pTemplate.ID] = &processTemplates[idx]
 	}
 
 	// step 4:
@@ -735,7 +732,7 @@ func (ps *ProcServer) diffServiceInstanceWithTemplate(ctx *rest.Contexts, diffOp
 		})
 	}
 
-	moduleChangedAttributes, err := ps.CalculateModuleAttributeDifference(ctx.Kit.Ctx, ctx.Kit.Header, *module)
+	moduleChangedAttributes, err := ps.CalculateModuleAttributeDifference(ctx.Kit.Ctx, ctx.Kit.Header, *module, serviceTemplate)
 	if err != nil {
 		blog.ErrorJSON("diffServiceInstanceWithTemplate failed, CalculateModuleAttributeDifference failed, module: %s, err: %s, rid: %s", module, err.Error(), rid)
 		return nil, err
@@ -753,17 +750,15 @@ func (ps *ProcServer) diffServiceInstanceWithTemplate(ctx *rest.Contexts, diffOp
 	return &moduleDifference, nil
 }
 
-func (ps *ProcServer) CalculateModuleAttributeDifference(ctx context.Context, header http.Header, module metadata.ModuleInst) ([]metadata.ModuleChangedAttribute, errors.CCErrorCoder) {
+// CalculateModuleAttributeDifference compare module with serviceTpl, which is the definition of the service
+// template the module should be synchronized to.
+func (ps *ProcServer) CalculateModuleAttributeDifference(ctx context.Context, header http.Header, module metadata.ModuleInst, serviceTpl *metadata.ServiceTemplate) ([]metadata.ModuleChangedAttribute, errors.CCErrorCoder) {
 	rid := util.ExtractRequestIDFromContext(ctx)
 
 	changedAttributes := make([]metadata.ModuleChangedAttribute, 0)
-	if module.ServiceTemplateID == common.ServiceTemplateIDNotSet {
+	if module.ServiceTemplateID == common.ServiceTemplateIDNotSet || serviceTpl == nil {
 		return changedAttributes, nil
 	}
-	serviceTpl, err := ps.CoreAPI.CoreService().Process().GetServiceTemplate(ctx, header, module.ServiceTemplateID)
-	if err != nil {
-		return nil, err
-	}
 
 	// just for better performance
 	if module.ServiceCategoryID == serviceTpl.ServiceCategoryID &&
@@ -836,6 +831,7 @@ func (ps *ProcServer) SyncServiceInstanceByTemplate(ctx *rest.Contexts) {
 			Metadata: syncOption.Metadata,
 			BizID:    syncOption.BizID,
 			ModuleID: moduleID,
+			Version:  syncOption.Version,
 		}
 		err := ps.syncServiceInstanceByTemplate(ctx, option)
 		if err != nil {
@@ -888,19 +884,15 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 	}
 
 	// step 1:
-	// find all the process template according to the service template id
-	processTemplateFilter := &metadata.ListProcessTemplatesOption{
-		BusinessID:         bizID,
-		ServiceTemplateIDs: []int64{module.ServiceTemplateID},
-	}
-	processTemplate, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(ctx.Kit.Ctx, ctx.Kit.Header, processTemplateFilter)
+	// find all the process template of the service template version the module should be synchronized to
+	serviceTemplate, processTemplates, err := ps.getModuleTemplateDefinition(ctx, module, syncOption.Version)
 	if err != nil {
-		blog.ErrorJSON("syncServiceInstanceByTemplate failed, ListProcessTemplates failed, option: %s, err: %s, rid: %s", processTemplateFilter, err.Error(), rid)
+		blog.Errorf("syncServiceInstanceByTemplate failed, getModuleTemplateDefinition failed, moduleID: %d, version: %d, err: %s, rid: %s", module.ModuleID, syncOption.Version, err.Error(), rid)
 		return err
 	}
 	processTemplateMap := make(map[int64]*metadata.ProcessTemplate)
-	for idx, t := range processTemplate.Info {
-		processTemplateMap[t.ID] = &processTemplate.Info[idx]
+	for idx, t := range processTemplates {
+		processTemplateMap[t.ID] = &processTemplates[idx]
 	}
 
 	// step2:
@@ -944,6 +936,15 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 		serviceInstanceWithTemplateMap[serviceInstance.ID] = make(map[int64]bool)
 		serviceInstance2HostMap[serviceInstance.ID] = serviceInstance.HostID
 	}
+	hostIDs := make([]int64, 0)
+	for _, hostID := range serviceInstance2HostMap {
+		hostIDs = append(hostIDs, hostID)
+	}
+	bindHosts, err := ps.Logic.GetProcessBindHosts(ctx.Kit, util.IntArrayUnique(hostIDs))
+	if err != nil {
+		blog.Errorf("syncServiceInstanceByTemplate failed, GetProcessBindHosts failed, hostIDs: %v, err: %s, rid: %s", hostIDs, err.Error(), rid)
+		return err
+	}
 	processInstanceWithTemplateMap := make(map[int64]int64)
 	for _, r := range relations.Info {
 		p, exist := processInstanceMap[r.ProcessID]
@@ -960,7 +961,7 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 
 	// step 5:
 	// compare the difference between process instance and process template from one service instance to another.
-	for _, processes := range serviceInstance2ProcessMap {
+	for svcID, processes := range serviceInstance2ProcessMap {
 		for _, process := range processes {
 			processTemplateID := processInstanceWithTemplateMap[process.ProcessID]
 			template, exist := processTemplateMap[processTemplateID]
@@ -988,6 +989,14 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 			if !changed {
 				continue
 			}
+			changedProcess, e := prepareProcessBindChange(*process, bindHosts[serviceInstance2HostMap[svcID]], proc)
+			if e != nil {
+				blog.ErrorJSON("syncServiceInstanceByTemplate failed, apply process change failed, change: %s, err: %s, rid: %s", proc, e.Error(), rid)
+				return ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+			}
+			if err := ps.validateProcessBindConflict(ctx, bindHosts[serviceInstance2HostMap[svcID]], changedProcess); err != nil {
+				return err
+			}
 			if err := ps.Logic.UpdateProcessInstance(ctx.Kit, process.ProcessID, proc); err != nil {
 				blog.Errorf("syncServiceInstanceByTemplate failed, UpdateProcessInstance failed, processID:%d, err: %s, rid:%s", process.ProcessID, err.Error(), rid)
 				return err
@@ -1007,6 +1016,11 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 			// we can not find this process template in all this service instance,
 			// which means that a new process template need to be added to this service instance
 			newProcess := processTemplate.NewProcess(bizID, ctx.Kit.SupplierAccount)
+			bindHost := bindHosts[serviceInstance2HostMap[svcID]]
+			newProcess.SyncLegacyBindFields(bindHost.InnerIP, bindHost.OuterIP)
+			if err := ps.validateProcessBindConflict(ctx, bindHost, newProcess); err != nil {
+				return err
+			}
 			processData, e := mapstruct.Struct2Map(newProcess)
 			if e != nil {
 				blog.ErrorJSON("SyncServiceInstanceByTemplate failed, Struct2Map failed, process: %s, err: %s, rid: %s", newProcess, e.Error(), rid)
@@ -1043,13 +1057,6 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 		}
 	}
 
-	// get service template
-	serviceTemplate, err := ps.CoreAPI.CoreService().Process().GetServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, module.ServiceTemplateID)
-	if err != nil {
-		blog.Errorf("syncServiceInstanceByTemplate failed, GetServiceTemplate failed, serviceTemplateID:%d, err:%s, rid:%s", module.ServiceTemplateID, err.Error(), rid)
-		return err
-	}
-
 	// step 7:
 	// update module service category and name field
 	moduleUpdateOption := &metadata.UpdateOption{
@@ -1070,6 +1077,21 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 		blog.ErrorJSON("syncServiceInstanceByTemplate failed, UpdateInstance failed, option: %s, result: %s, rid: %s", moduleUpdateOption, resp, rid)
 		return errors.New(resp.Code, resp.ErrMsg)
 	}
+
+	// step 8:
+	// pin the module to the version it synchronized to, so that it won't follow the latest definition any more
+	if syncOption.Version > 0 {
+		pinOption := &metadata.PinModuleServiceTemplateVersionOption{
+			BizID:             bizID,
+			ServiceTemplateID: module.ServiceTemplateID,
+			ModuleIDs:         []int64{module.ModuleID},
+			Version:           syncOption.Version,
+		}
+		if err := ps.CoreAPI.CoreService().Process().PinModuleServiceTemplateVersion(ctx.Kit.Ctx, ctx.Kit.Header, pinOption); err != nil {
+			blog.ErrorJSON("syncServiceInstanceByTemplate failed, PinModuleServiceTemplateVersion failed, option: %s, err: %s, rid: %s", pinOption, err.Error(), rid)
+			return err
+		}
+	}
 	return nil
 }
 
@@ -38,11 +38,20 @@ func (ps *ProcServer) CreateServiceTemplate(ctx *rest.Contexts) {
 			return
 		}
 	}
+	if bizID == common.BKGlobalBizID {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "create service template, but business id not set", common.BKAppIDField)
+		return
+	}
+
+	ps.createServiceTemplate(ctx, bizID, option)
+}
 
+func (ps *ProcServer) createServiceTemplate(ctx *rest.Contexts, bizID int64, option *metadata.CreateServiceTemplateOption) {
 	newTemplate := &metadata.ServiceTemplate{
 		BizID:             bizID,
 		Name:              option.Name,
 		ServiceCategoryID: option.ServiceCategoryID,
+		Attributes:        option.Attributes,
 		SupplierAccount:   ctx.Kit.SupplierAccount,
 	}
 	tpl, err := ps.CoreAPI.CoreService().Process().CreateServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, newTemplate)
@@ -110,10 +119,28 @@ func (ps *ProcServer) UpdateServiceTemplate(ctx *rest.Contexts) {
 		}
 	}
 
+	ps.updateServiceTemplate(ctx, option)
+}
+
+func (ps *ProcServer) updateServiceTemplate(ctx *rest.Contexts, option *metadata.UpdateServiceTemplateOption) {
+	// service category of the template created in reference mode follows the upstream template
+	if option.ServiceCategoryID != 0 {
+		template, err := ps.CoreAPI.CoreService().Process().GetServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, option.ID)
+		if err != nil {
+			ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "update service template, but get service template failed, err: %v", err)
+			return
+		}
+		if template.ShareMode == metadata.ShareModeReference && template.ServiceCategoryID != option.ServiceCategoryID {
+			ctx.RespErrorCodeF(common.CCErrProcServiceTemplateReferenceReadonly, "update service template, but it's created in reference mode", template.Name)
+			return
+		}
+	}
+
 	updateParam := &metadata.ServiceTemplate{
 		ID:                option.ID,
 		Name:              option.Name,
 		ServiceCategoryID: option.ServiceCategoryID,
+		Attributes:        option.Attributes,
 	}
 	tpl, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, option.ID, updateParam)
 	if err != nil {
@@ -312,16 +339,20 @@ func (ps *ProcServer) DeleteServiceTemplate(ctx *rest.Contexts) {
 		}
 	}
 
-	iamResources, err := ps.AuthManager.MakeResourcesByServiceTemplateIDs(ctx.Kit.Ctx, ctx.Kit.Header, meta.Delete, bizID, input.ServiceTemplateID)
+	ps.deleteServiceTemplate(ctx, bizID, input.ServiceTemplateID)
+}
+
+func (ps *ProcServer) deleteServiceTemplate(ctx *rest.Contexts, bizID int64, serviceTemplateID int64) {
+	iamResources, err := ps.AuthManager.MakeResourcesByServiceTemplateIDs(ctx.Kit.Ctx, ctx.Kit.Header, meta.Delete, bizID, serviceTemplateID)
 	if err != nil {
-		blog.Errorf("make iam resource by service template failed, templateID: %d, err: %+v, rid: %s", input.ServiceTemplateID, err, ctx.Kit.Rid)
+		blog.Errorf("make iam resource by service template failed, templateID: %d, err: %+v, rid: %s", serviceTemplateID, err, ctx.Kit.Rid)
 		ctx.RespAutoError(err)
 		return
 	}
 
-	err = ps.CoreAPI.CoreService().Process().DeleteServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, input.ServiceTemplateID)
+	err = ps.CoreAPI.CoreService().Process().DeleteServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, serviceTemplateID)
 	if err != nil {
-		ctx.RespWithError(err, common.CCErrProcDeleteServiceTemplateFailed, "delete service template: %d failed", input.ServiceTemplateID)
+		ctx.RespWithError(err, common.CCErrProcDeleteServiceTemplateFailed, "delete service template: %d failed", serviceTemplateID)
 		return
 	}
 
@@ -0,0 +1,602 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// global service templates, process templates and service categories are not belong to any business,
+// they are shared by all the businesses, a business can create it's own service template from a global
+// one in reference or fork mode, then the changes of the global template are accepted as differences.
+
+// checkServiceTemplatesEditable make sure process templates of the service templates can be edited directly,
+// templates created in reference mode can only be changed by accepting the upstream differences.
+func (ps *ProcServer) checkServiceTemplatesEditable(ctx *rest.Contexts, bizID int64, serviceTemplateIDs ...int64) errors.CCErrorCoder {
+	if bizID == common.BKGlobalBizID || len(serviceTemplateIDs) == 0 {
+		return nil
+	}
+	option := &metadata.ListServiceTemplateOption{
+		BusinessID:         bizID,
+		ServiceTemplateIDs: serviceTemplateIDs,
+	}
+	templates, err := ps.CoreAPI.CoreService().Process().ListServiceTemplates(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.ErrorJSON("checkServiceTemplatesEditable failed, ListServiceTemplates failed, option: %s, err: %s, rid: %s", option, err, ctx.Kit.Rid)
+		return err
+	}
+	for _, template := range templates.Info {
+		if template.ShareMode == metadata.ShareModeReference {
+			blog.Errorf("service template %d is created from global template %d in reference mode, rid: %s", template.ID, template.UpstreamTemplateID, ctx.Kit.Rid)
+			return ctx.Kit.CCError.CCErrorf(common.CCErrProcServiceTemplateReferenceReadonly, template.Name)
+		}
+	}
+	return nil
+}
+
+// getGlobalServiceTemplate get the service template and make sure it's a global template
+func (ps *ProcServer) getGlobalServiceTemplate(ctx *rest.Contexts, serviceTemplateID int64) (*metadata.ServiceTemplate, errors.CCErrorCoder) {
+	template, err := ps.CoreAPI.CoreService().Process().GetServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, serviceTemplateID)
+	if err != nil {
+		blog.Errorf("getGlobalServiceTemplate failed, GetServiceTemplate failed, id: %d, err: %s, rid: %s", serviceTemplateID, err, ctx.Kit.Rid)
+		return nil, err
+	}
+	if template.BizID != common.BKGlobalBizID {
+		blog.Errorf("service template %d belongs to business %d, not a global template, rid: %s", template.ID, template.BizID, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKServiceTemplateIDField)
+	}
+	return template, nil
+}
+
+func (ps *ProcServer) CreateGlobalServiceCategory(ctx *rest.Contexts) {
+	input := new(metadata.CreateServiceCategoryOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ps.createServiceCategory(ctx, common.BKGlobalBizID, input)
+}
+
+func (ps *ProcServer) CreateGlobalServiceTemplate(ctx *rest.Contexts) {
+	option := new(metadata.CreateServiceTemplateOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ps.createServiceTemplate(ctx, common.BKGlobalBizID, option)
+}
+
+func (ps *ProcServer) UpdateGlobalServiceTemplate(ctx *rest.Contexts) {
+	option := new(metadata.UpdateServiceTemplateOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if _, err := ps.getGlobalServiceTemplate(ctx, option.ID); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ps.updateServiceTemplate(ctx, option)
+}
+
+func (ps *ProcServer) DeleteGlobalServiceTemplate(ctx *rest.Contexts) {
+	input := new(metadata.DeleteServiceTemplatesInput)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if _, err := ps.getGlobalServiceTemplate(ctx, input.ServiceTemplateID); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ps.deleteServiceTemplate(ctx, common.BKGlobalBizID, input.ServiceTemplateID)
+}
+
+func (ps *ProcServer) ListGlobalServiceTemplates(ctx *rest.Contexts) {
+	input := new(metadata.ListServiceTemplateInput)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if input.Page.Limit > common.BKMaxPageSize {
+		ctx.RespErrorCodeOnly(common.CCErrCommPageLimitIsExceeded, "list global service template, but page limit:%d is over limited.", input.Page.Limit)
+		return
+	}
+
+	option := metadata.ListServiceTemplateOption{
+		BusinessID:        common.BKGlobalBizID,
+		Page:              input.Page,
+		ServiceCategoryID: &input.ServiceCategoryID,
+		Search:            input.Search,
+	}
+	templates, err := ps.CoreAPI.CoreService().Process().ListServiceTemplates(ctx.Kit.Ctx, ctx.Kit.Header, &option)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "list global service template failed, input: %+v", input)
+		return
+	}
+
+	ctx.RespEntity(templates)
+}
+
+// ListDownstreamServiceTemplates list business service templates created from a global service template
+func (ps *ProcServer) ListDownstreamServiceTemplates(ctx *rest.Contexts) {
+	input := new(metadata.DeleteServiceTemplatesInput)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if _, err := ps.getGlobalServiceTemplate(ctx, input.ServiceTemplateID); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	option := metadata.ListServiceTemplateOption{
+		BusinessID:         common.BKGlobalBizID,
+		UpstreamTemplateID: input.ServiceTemplateID,
+	}
+	templates, err := ps.CoreAPI.CoreService().Process().ListServiceTemplates(ctx.Kit.Ctx, ctx.Kit.Header, &option)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "list downstream service template of %d failed", input.ServiceTemplateID)
+		return
+	}
+
+	ctx.RespEntity(templates)
+}
+
+func (ps *ProcServer) CreateGlobalProcessTemplateBatch(ctx *rest.Contexts) {
+	input := new(metadata.CreateProcessTemplateBatchInput)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ps.createProcessTemplates(ctx, common.BKGlobalBizID, input)
+}
+
+func (ps *ProcServer) UpdateGlobalProcessTemplate(ctx *rest.Contexts) {
+	input := new(metadata.UpdateProcessTemplateInput)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	input.BizID = common.BKGlobalBizID
+
+	ps.updateProcessTemplate(ctx, input)
+}
+
+func (ps *ProcServer) DeleteGlobalProcessTemplateBatch(ctx *rest.Contexts) {
+	input := new(metadata.DeleteProcessTemplateBatchInput)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	input.BizID = common.BKGlobalBizID
+
+	ps.deleteProcessTemplates(ctx, input)
+}
+
+func (ps *ProcServer) ListGlobalProcessTemplate(ctx *rest.Contexts) {
+	input := new(metadata.ListProcessTemplateWithServiceTemplateInput)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ps.listProcessTemplates(ctx, common.BKGlobalBizID, input)
+}
+
+// CreateServiceTemplateFromGlobal create a business service template with all the process templates of a global template
+func (ps *ProcServer) CreateServiceTemplateFromGlobal(ctx *rest.Contexts) {
+	option := new(metadata.CreateServiceTemplateFromGlobalOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	bizID := option.BizID
+	if bizID == 0 && option.Metadata != nil {
+		var err error
+		bizID, err = metadata.BizIDFromMetadata(*option.Metadata)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "create service template from global, but get business id failed, err: %v", err)
+			return
+		}
+	}
+	if bizID == common.BKGlobalBizID {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "create service template from global, but business id not set", common.BKAppIDField)
+		return
+	}
+	if err := option.ShareMode.Validate(); err != nil {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, err.Error(), "share_mode")
+		return
+	}
+
+	upstream, err := ps.getGlobalServiceTemplate(ctx, option.UpstreamTemplateID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	processOption := &metadata.ListProcessTemplatesOption{
+		BusinessID:         common.BKGlobalBizID,
+		ServiceTemplateIDs: []int64{upstream.ID},
+	}
+	upstreamProcessTemplates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(ctx.Kit.Ctx, ctx.Kit.Header, processOption)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrProcGetProcessTemplatesFailed, "create service template from global, but list process templates of %d failed", upstream.ID)
+		return
+	}
+
+	name := option.Name
+	if name == "" {
+		name = upstream.Name
+	}
+	newTemplate := &metadata.ServiceTemplate{
+		BizID:              bizID,
+		Name:               name,
+		ServiceCategoryID:  upstream.ServiceCategoryID,
+		Attributes:         upstream.Attributes,
+		UpstreamTemplateID: upstream.ID,
+		ShareMode:          option.ShareMode,
+		SupplierAccount:    ctx.Kit.SupplierAccount,
+	}
+	tpl, err := ps.CoreAPI.CoreService().Process().CreateServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, newTemplate)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "create service template from global %d failed, err: %v", upstream.ID, err)
+		return
+	}
+
+	for _, upstreamProcessTemplate := range upstreamProcessTemplates.Info {
+		if err := ps.copyUpstreamProcessTemplate(ctx, tpl, upstreamProcessTemplate); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
+	if err := ps.AuthManager.RegisterServiceTemplates(ctx.Kit.Ctx, ctx.Kit.Header, *tpl); err != nil {
+		blog.Errorf("create service template from global success, but register to iam failed, err: %+v, rid: %s", err, ctx.Kit.Rid)
+		err := ctx.Kit.CCError.CCError(common.CCErrCommRegistResourceToIAMFailed)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(tpl)
+}
+
+func (ps *ProcServer) copyUpstreamProcessTemplate(ctx *rest.Contexts, serviceTemplate *metadata.ServiceTemplate, upstream metadata.ProcessTemplate) errors.CCErrorCoder {
+	processTemplate := &metadata.ProcessTemplate{
+		BizID:              serviceTemplate.BizID,
+		ServiceTemplateID:  serviceTemplate.ID,
+		Property:           upstream.Property,
+		UpstreamTemplateID: upstream.ID,
+		UpstreamProperty:   upstream.Property,
+	}
+	if _, err := ps.CoreAPI.CoreService().Process().CreateProcessTemplate(ctx.Kit.Ctx, ctx.Kit.Header, processTemplate); err != nil {
+		blog.ErrorJSON("copy upstream process template failed, CreateProcessTemplate failed, template: %s, err: %s, rid: %s", processTemplate, err, ctx.Kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// getServiceTemplateWithUpstream return the business service template and it's upstream template
+func (ps *ProcServer) getServiceTemplateWithUpstream(ctx *rest.Contexts, serviceTemplateID int64) (*metadata.ServiceTemplate, *metadata.ServiceTemplate, errors.CCErrorCoder) {
+	template, err := ps.CoreAPI.CoreService().Process().GetServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, serviceTemplateID)
+	if err != nil {
+		blog.Errorf("getServiceTemplateWithUpstream failed, GetServiceTemplate failed, id: %d, err: %s, rid: %s", serviceTemplateID, err, ctx.Kit.Rid)
+		return nil, nil, err
+	}
+	if template.UpstreamTemplateID == 0 {
+		return nil, nil, ctx.Kit.CCError.CCErrorf(common.CCErrProcServiceTemplateNoUpstream, template.ID)
+	}
+	upstream, err := ps.getGlobalServiceTemplate(ctx, template.UpstreamTemplateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return template, upstream, nil
+}
+
+// processPropertyFields return the fields of a process property keyed by the property id,
+// each field contains both the value and whether the value is used as default.
+func processPropertyFields(property *metadata.ProcessProperty) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if property == nil {
+		return fields, nil
+	}
+	propertyJSON, err := json.Marshal(property)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(propertyJSON, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// diffServiceTemplateWithUpstream compare the business template with it's upstream template, the process templates
+// are compared in three-way with the upstream property recorded when copied or accepted last time as the base, so
+// only the fields changed by the upstream are reported, the fields changed by the business itself are kept.
+// the returned patches are the upstream changed property fields of each changed process template.
+func (ps *ProcServer) diffServiceTemplateWithUpstream(ctx *rest.Contexts, template *metadata.ServiceTemplate, upstream *metadata.ServiceTemplate) (*metadata.ServiceTemplateUpstreamDifference, map[int64]*metadata.AcceptProcessTemplateUpstreamOption, errors.CCErrorCoder) {
+	rid := ctx.Kit.Rid
+
+	// step 1:
+	// find process object's attribute
+	cond := &metadata.QueryCondition{
+		Condition: mapstr.MapStr(map[string]interface{}{
+			common.BKObjIDField: common.BKInnerObjIDProc,
+		}),
+	}
+	attrResult, e := ps.CoreAPI.CoreService().Model().ReadModelAttr(ctx.Kit.Ctx, ctx.Kit.Header, common.BKInnerObjIDProc, cond)
+	if e != nil {
+		blog.ErrorJSON("diffServiceTemplateWithUpstream failed, ReadModelAttr failed, option: %s, err: %s, rid: %s", cond, e, rid)
+		return nil, nil, ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	attributeMap := make(map[string]metadata.Attribute)
+	for _, attr := range attrResult.Data.Info {
+		attributeMap[attr.PropertyID] = attr
+	}
+
+	// step 2:
+	// get process templates of both the business template and the upstream template
+	option := &metadata.ListProcessTemplatesOption{
+		BusinessID:         template.BizID,
+		ServiceTemplateIDs: []int64{template.ID},
+	}
+	processTemplates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.ErrorJSON("diffServiceTemplateWithUpstream failed, ListProcessTemplates failed, option: %s, err: %s, rid: %s", option, err, rid)
+		return nil, nil, err
+	}
+	upstreamOption := &metadata.ListProcessTemplatesOption{
+		BusinessID:         common.BKGlobalBizID,
+		ServiceTemplateIDs: []int64{upstream.ID},
+	}
+	upstreamProcessTemplates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(ctx.Kit.Ctx, ctx.Kit.Header, upstreamOption)
+	if err != nil {
+		blog.ErrorJSON("diffServiceTemplateWithUpstream failed, ListProcessTemplates failed, option: %s, err: %s, rid: %s", upstreamOption, err, rid)
+		return nil, nil, err
+	}
+
+	difference, patches, e := compareServiceTemplateWithUpstream(template, upstream, processTemplates.Info, upstreamProcessTemplates.Info, attributeMap)
+	if e != nil {
+		blog.Errorf("diffServiceTemplateWithUpstream failed, compare service template %d with upstream %d failed, err: %v, rid: %s", template.ID, upstream.ID, e, rid)
+		return nil, nil, ctx.Kit.CCError.CCError(common.CCErrCommJSONMarshalFailed)
+	}
+	return difference, patches, nil
+}
+
+// compareServiceTemplateWithUpstream compare the process templates and the service category of the business template
+// with the upstream template, see diffServiceTemplateWithUpstream for the rules.
+func compareServiceTemplateWithUpstream(template *metadata.ServiceTemplate, upstream *metadata.ServiceTemplate,
+	processTemplates []metadata.ProcessTemplate, upstreamProcessTemplates []metadata.ProcessTemplate,
+	attributeMap map[string]metadata.Attribute) (*metadata.ServiceTemplateUpstreamDifference, map[int64]*metadata.AcceptProcessTemplateUpstreamOption, error) {
+
+	// compare process templates copied from upstream with the upstream process templates,
+	// process templates created by the business itself are ignored.
+	difference := &metadata.ServiceTemplateUpstreamDifference{
+		ServiceTemplateID:  template.ID,
+		UpstreamTemplateID: upstream.ID,
+		Added:              make([]metadata.ProcessTemplate, 0),
+		Changed:            make([]metadata.ProcessTemplateUpstreamDifference, 0),
+		Removed:            make([]metadata.ProcessTemplate, 0),
+		ChangedAttributes:  make([]metadata.ModuleChangedAttribute, 0),
+	}
+	patches := make(map[int64]*metadata.AcceptProcessTemplateUpstreamOption)
+	copiedMap := make(map[int64]metadata.ProcessTemplate)
+	for _, processTemplate := range processTemplates {
+		if processTemplate.UpstreamTemplateID == 0 {
+			continue
+		}
+		copiedMap[processTemplate.UpstreamTemplateID] = processTemplate
+	}
+	upstreamMap := make(map[int64]bool)
+	for _, upstreamProcessTemplate := range upstreamProcessTemplates {
+		upstreamMap[upstreamProcessTemplate.ID] = true
+		processTemplate, exist := copiedMap[upstreamProcessTemplate.ID]
+		if exist == false {
+			difference.Added = append(difference.Added, upstreamProcessTemplate)
+			continue
+		}
+
+		local, err := processPropertyFields(processTemplate.Property)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse property of process template %d failed, err: %v", processTemplate.ID, err)
+		}
+		remote, err := processPropertyFields(upstreamProcessTemplate.Property)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse property of process template %d failed, err: %v", upstreamProcessTemplate.ID, err)
+		}
+		// templates copied before the upstream property is recorded fall back to compare with the upstream directly
+		base := local
+		if processTemplate.UpstreamProperty != nil {
+			if base, err = processPropertyFields(processTemplate.UpstreamProperty); err != nil {
+				return nil, nil, fmt.Errorf("parse upstream property of process template %d failed, err: %v", processTemplate.ID, err)
+			}
+		}
+
+		// process name and func name of a process template can not be changed
+		changedAttributes := make([]metadata.ProcessChangedAttribute, 0)
+		changedProperty := make(map[string]interface{})
+		for field, value := range remote {
+			if field == common.BKProcessNameField || field == common.BKFuncName {
+				continue
+			}
+			if reflect.DeepEqual(base[field], value) || reflect.DeepEqual(local[field], value) {
+				continue
+			}
+			changedProperty[field] = value
+			changedAttributes = append(changedAttributes, metadata.ProcessChangedAttribute{
+				ID:                    attributeMap[field].ID,
+				PropertyID:            field,
+				PropertyName:          attributeMap[field].PropertyName,
+				PropertyValue:         local[field],
+				TemplatePropertyValue: value,
+			})
+		}
+		if len(changedAttributes) == 0 {
+			continue
+		}
+		sort.Slice(changedAttributes, func(i, j int) bool {
+			return changedAttributes[i].PropertyID < changedAttributes[j].PropertyID
+		})
+		patches[processTemplate.ID] = &metadata.AcceptProcessTemplateUpstreamOption{
+			Property:         changedProperty,
+			UpstreamProperty: upstreamProcessTemplate.Property,
+		}
+		difference.Changed = append(difference.Changed, metadata.ProcessTemplateUpstreamDifference{
+			ProcessTemplateID:         processTemplate.ID,
+			UpstreamProcessTemplateID: upstreamProcessTemplate.ID,
+			ProcessName:               processTemplate.ProcessName,
+			ChangedAttributes:         changedAttributes,
+		})
+	}
+	for upstreamID, processTemplate := range copiedMap {
+		if upstreamMap[upstreamID] == false {
+			difference.Removed = append(difference.Removed, processTemplate)
+		}
+	}
+
+	// compare the service category
+	if template.ServiceCategoryID != upstream.ServiceCategoryID {
+		difference.ChangedAttributes = append(difference.ChangedAttributes, metadata.ModuleChangedAttribute{
+			PropertyID:            common.BKServiceCategoryIDField,
+			PropertyValue:         template.ServiceCategoryID,
+			TemplatePropertyValue: upstream.ServiceCategoryID,
+		})
+	}
+
+	difference.HasDifference = len(difference.Added) > 0 || len(difference.Changed) > 0 ||
+		len(difference.Removed) > 0 || len(difference.ChangedAttributes) > 0
+	return difference, patches, nil
+}
+
+// DiffServiceTemplateWithUpstream find the changes of the global template which the business template not accepted yet
+func (ps *ProcServer) DiffServiceTemplateWithUpstream(ctx *rest.Contexts) {
+	option := new(metadata.DiffServiceTemplateWithUpstreamOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	template, upstream, err := ps.getServiceTemplateWithUpstream(ctx, option.ServiceTemplateID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	difference, _, err := ps.diffServiceTemplateWithUpstream(ctx, template, upstream)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "diff service template %d with upstream failed", template.ID)
+		return
+	}
+
+	ctx.RespEntity(difference)
+}
+
+// AcceptServiceTemplateUpstream apply the changes of the global template to the business template,
+// the modules of the business template can be synchronized with the existing service instance sync then.
+// a failed accept can be retried safely: the differences are computed again on each call, copied process
+// templates are tracked by their upstream template id, each changed process template is updated together
+// with it's new upstream property in one write, deletion is idempotent and the service category goes last.
+func (ps *ProcServer) AcceptServiceTemplateUpstream(ctx *rest.Contexts) {
+	option := new(metadata.AcceptServiceTemplateUpstreamOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	template, upstream, err := ps.getServiceTemplateWithUpstream(ctx, option.ServiceTemplateID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	difference, patches, err := ps.diffServiceTemplateWithUpstream(ctx, template, upstream)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "accept upstream of service template %d, but diff failed", template.ID)
+		return
+	}
+
+	acceptAll := len(option.UpstreamProcessTemplateIDs) == 0
+	accepted := func(upstreamProcessTemplateID int64) bool {
+		return acceptAll || util.InArray(upstreamProcessTemplateID, option.UpstreamProcessTemplateIDs)
+	}
+
+	for _, added := range difference.Added {
+		if accepted(added.ID) == false {
+			continue
+		}
+		if err := ps.copyUpstreamProcessTemplate(ctx, template, added); err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
+	// only the upstream changed fields are applied, the fields changed by the business itself are kept
+	for _, changed := range difference.Changed {
+		if accepted(changed.UpstreamProcessTemplateID) == false {
+			continue
+		}
+		patch := patches[changed.ProcessTemplateID]
+		_, err := ps.CoreAPI.CoreService().Process().AcceptProcessTemplateUpstream(ctx.Kit.Ctx, ctx.Kit.Header, changed.ProcessTemplateID, patch)
+		if err != nil {
+			ctx.RespWithError(err, common.CCErrProcUpdateProcessTemplateFailed, "accept upstream of service template %d, but update process template %d failed", template.ID, changed.ProcessTemplateID)
+			return
+		}
+	}
+
+	removedIDs := make([]int64, 0)
+	for _, removed := range difference.Removed {
+		if accepted(removed.UpstreamTemplateID) == false {
+			continue
+		}
+		removedIDs = append(removedIDs, removed.ID)
+	}
+	if len(removedIDs) > 0 {
+		if err := ps.CoreAPI.CoreService().Process().DeleteProcessTemplateBatch(ctx.Kit.Ctx, ctx.Kit.Header, removedIDs); err != nil {
+			ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "accept upstream of service template %d, but delete process templates %v failed", template.ID, removedIDs)
+			return
+		}
+	}
+
+	if acceptAll && len(difference.ChangedAttributes) > 0 {
+		updateParam := &metadata.ServiceTemplate{
+			ServiceCategoryID: upstream.ServiceCategoryID,
+		}
+		if _, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, template.ID, updateParam); err != nil {
+			ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "accept upstream of service template %d, but update service category failed", template.ID)
+			return
+		}
+	}
+
+	ctx.RespEntity(nil)
+}
@@ -0,0 +1,217 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testProcessProperty build a process property, the empty values are left unset
+func testProcessProperty(processName, startCmd, stopCmd string) *metadata.ProcessProperty {
+	property := &metadata.ProcessProperty{}
+	funcName := processName
+	property.ProcessName.Value = &processName
+	property.FuncName.Value = &funcName
+	if startCmd != "" {
+		property.StartCmd.Value = &startCmd
+	}
+	if stopCmd != "" {
+		property.StopCmd.Value = &stopCmd
+	}
+	return property
+}
+
+func TestCompareServiceTemplateWithUpstreamProperty(t *testing.T) {
+	attributeMap := map[string]metadata.Attribute{
+		"start_cmd": {ID: 1, PropertyID: "start_cmd", PropertyName: "start command"},
+		"stop_cmd":  {ID: 2, PropertyID: "stop_cmd", PropertyName: "stop command"},
+	}
+
+	type changedField struct {
+		propertyID string
+		local      interface{}
+		remote     interface{}
+	}
+	testCases := []struct {
+		name    string
+		base    *metadata.ProcessProperty
+		local   *metadata.ProcessProperty
+		remote  *metadata.ProcessProperty
+		changed []changedField
+	}{
+		{
+			name:   "nothing changed",
+			base:   testProcessProperty("nginx", "start", "stop"),
+			local:  testProcessProperty("nginx", "start", "stop"),
+			remote: testProcessProperty("nginx", "start", "stop"),
+		},
+		{
+			name:    "changed by upstream",
+			base:    testProcessProperty("nginx", "start", "stop"),
+			local:   testProcessProperty("nginx", "start", "stop"),
+			remote:  testProcessProperty("nginx", "start -d", "stop"),
+			changed: []changedField{{propertyID: "start_cmd", local: "start", remote: "start -d"}},
+		},
+		{
+			name:   "changed by business is kept",
+			base:   testProcessProperty("nginx", "start", "stop"),
+			local:  testProcessProperty("nginx", "start -b", "stop"),
+			remote: testProcessProperty("nginx", "start", "stop"),
+		},
+		{
+			name:    "changed by both business and upstream",
+			base:    testProcessProperty("nginx", "start", "stop"),
+			local:   testProcessProperty("nginx", "start -b", "stop"),
+			remote:  testProcessProperty("nginx", "start -d", "stop"),
+			changed: []changedField{{propertyID: "start_cmd", local: "start -b", remote: "start -d"}},
+		},
+		{
+			name:   "changed by both business and upstream to the same value",
+			base:   testProcessProperty("nginx", "start", "stop"),
+			local:  testProcessProperty("nginx", "start -d", "stop"),
+			remote: testProcessProperty("nginx", "start -d", "stop"),
+		},
+		{
+			name:    "set by upstream",
+			base:    testProcessProperty("nginx", "start", ""),
+			local:   testProcessProperty("nginx", "start", ""),
+			remote:  testProcessProperty("nginx", "start", "stop"),
+			changed: []changedField{{propertyID: "stop_cmd", local: nil, remote: "stop"}},
+		},
+		{
+			name: "business and upstream changed different fields",
+			base: testProcessProperty("nginx", "start", "stop"),
+			// the business changed stop command is kept, only the upstream changed start command is reported
+			local:   testProcessProperty("nginx", "start", "stop -b"),
+			remote:  testProcessProperty("nginx", "start -d", "stop"),
+			changed: []changedField{{propertyID: "start_cmd", local: "start", remote: "start -d"}},
+		},
+		{
+			name:   "process name and func name changes are ignored",
+			base:   testProcessProperty("nginx", "start", "stop"),
+			local:  testProcessProperty("nginx", "start", "stop"),
+			remote: testProcessProperty("nginx2", "start", "stop"),
+		},
+		{
+			name:    "nil upstream property compares with upstream directly",
+			base:    nil,
+			local:   testProcessProperty("nginx", "start -b", "stop"),
+			remote:  testProcessProperty("nginx", "start -d", "stop"),
+			changed: []changedField{{propertyID: "start_cmd", local: "start -b", remote: "start -d"}},
+		},
+		{
+			name:   "nil upstream property with the same value as upstream",
+			base:   nil,
+			local:  testProcessProperty("nginx", "start", "stop"),
+			remote: testProcessProperty("nginx", "start", "stop"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			template := &metadata.ServiceTemplate{ID: 10, BizID: 2, UpstreamTemplateID: 1}
+			upstream := &metadata.ServiceTemplate{ID: 1, BizID: common.BKGlobalBizID}
+			processTemplates := []metadata.ProcessTemplate{{
+				ID:                 100,
+				ProcessName:        "nginx",
+				ServiceTemplateID:  template.ID,
+				Property:           testCase.local,
+				UpstreamTemplateID: 20,
+				UpstreamProperty:   testCase.base,
+			}}
+			upstreamProcessTemplates := []metadata.ProcessTemplate{{
+				ID:                20,
+				ProcessName:       "nginx",
+				ServiceTemplateID: upstream.ID,
+				Property:          testCase.remote,
+			}}
+
+			difference, patches, err := compareServiceTemplateWithUpstream(template, upstream, processTemplates, upstreamProcessTemplates, attributeMap)
+			require.NoError(t, err)
+			require.Empty(t, difference.Added)
+			require.Empty(t, difference.Removed)
+			require.Empty(t, difference.ChangedAttributes)
+
+			if len(testCase.changed) == 0 {
+				require.False(t, difference.HasDifference)
+				require.Empty(t, difference.Changed)
+				require.Empty(t, patches)
+				return
+			}
+
+			require.True(t, difference.HasDifference)
+			require.Len(t, difference.Changed, 1)
+			changed := difference.Changed[0]
+			require.Equal(t, int64(100), changed.ProcessTemplateID)
+			require.Equal(t, int64(20), changed.UpstreamProcessTemplateID)
+			require.Len(t, changed.ChangedAttributes, len(testCase.changed))
+
+			require.Len(t, patches, 1)
+			patch := patches[100]
+			require.NotNil(t, patch)
+			require.Equal(t, testCase.remote, patch.UpstreamProperty)
+			require.Len(t, patch.Property, len(testCase.changed))
+
+			for index, field := range testCase.changed {
+				attribute := changed.ChangedAttributes[index]
+				require.Equal(t, field.propertyID, attribute.PropertyID)
+				require.Equal(t, attributeMap[field.propertyID].ID, attribute.ID)
+				require.Equal(t, attributeMap[field.propertyID].PropertyName, attribute.PropertyName)
+				require.Equal(t, field.local, attribute.PropertyValue.(map[string]interface{})["value"])
+				require.Equal(t, field.remote, attribute.TemplatePropertyValue.(map[string]interface{})["value"])
+				require.Equal(t, field.remote, patch.Property[field.propertyID].(map[string]interface{})["value"])
+			}
+		})
+	}
+}
+
+func TestCompareServiceTemplateWithUpstreamAddedRemoved(t *testing.T) {
+	template := &metadata.ServiceTemplate{ID: 10, BizID: 2, UpstreamTemplateID: 1, ServiceCategoryID: 5}
+	upstream := &metadata.ServiceTemplate{ID: 1, BizID: common.BKGlobalBizID, ServiceCategoryID: 6}
+	processTemplates := []metadata.ProcessTemplate{
+		// copied from the upstream process template which is not changed
+		{ID: 100, ProcessName: "nginx", Property: testProcessProperty("nginx", "start", ""),
+			UpstreamTemplateID: 20, UpstreamProperty: testProcessProperty("nginx", "start", "")},
+		// copied from the upstream process template which is removed
+		{ID: 101, ProcessName: "redis", Property: testProcessProperty("redis", "start", ""),
+			UpstreamTemplateID: 21, UpstreamProperty: testProcessProperty("redis", "start", "")},
+		// created by the business itself
+		{ID: 102, ProcessName: "agent", Property: testProcessProperty("agent", "start", "")},
+	}
+	upstreamProcessTemplates := []metadata.ProcessTemplate{
+		{ID: 20, ProcessName: "nginx", Property: testProcessProperty("nginx", "start", "")},
+		// added by the upstream
+		{ID: 22, ProcessName: "mysql", Property: testProcessProperty("mysql", "start", "")},
+	}
+
+	difference, patches, err := compareServiceTemplateWithUpstream(template, upstream, processTemplates, upstreamProcessTemplates, nil)
+	require.NoError(t, err)
+	require.True(t, difference.HasDifference)
+	require.Empty(t, difference.Changed)
+	require.Empty(t, patches)
+
+	require.Len(t, difference.Added, 1)
+	require.Equal(t, int64(22), difference.Added[0].ID)
+	require.Len(t, difference.Removed, 1)
+	require.Equal(t, int64(101), difference.Removed[0].ID)
+
+	require.Len(t, difference.ChangedAttributes, 1)
+	require.Equal(t, common.BKServiceCategoryIDField, difference.ChangedAttributes[0].PropertyID)
+	require.Equal(t, int64(5), difference.ChangedAttributes[0].PropertyValue)
+	require.Equal(t, int64(6), difference.ChangedAttributes[0].TemplatePropertyValue)
+}
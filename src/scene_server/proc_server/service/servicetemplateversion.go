@@ -0,0 +1,680 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"math"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// getModuleTemplateDefinition return the service template and process templates a module should be synchronized to.
+// version > 0 means the specified version, otherwise the version the module pinned to is used,
+// a module which is not pinned follows the latest definition of the service template.
+func (ps *ProcServer) getModuleTemplateDefinition(ctx *rest.Contexts, module *metadata.ModuleInst, version int64) (*metadata.ServiceTemplate, []metadata.ProcessTemplate, errors.CCErrorCoder) {
+	rid := ctx.Kit.Rid
+
+	if module.ServiceTemplateID == common.ServiceTemplateIDNotSet {
+		return nil, make([]metadata.ProcessTemplate, 0), nil
+	}
+
+	if version == 0 {
+		pinOption := &metadata.ListModuleServiceTemplateVersionOption{
+			BizID:     module.BizID,
+			ModuleIDs: []int64{module.ModuleID},
+		}
+		pins, err := ps.CoreAPI.CoreService().Process().ListModuleServiceTemplateVersions(ctx.Kit.Ctx, ctx.Kit.Header, pinOption)
+		if err != nil {
+			blog.ErrorJSON("getModuleTemplateDefinition failed, ListModuleServiceTemplateVersions failed, option: %s, err: %s, rid: %s", pinOption, err, rid)
+			return nil, nil, err
+		}
+		for _, pin := range pins {
+			if pin.ServiceTemplateID == module.ServiceTemplateID {
+				version = pin.Version
+			}
+		}
+	}
+
+	if version > 0 {
+		templateVersion, err := ps.CoreAPI.CoreService().Process().GetServiceTemplateVersion(ctx.Kit.Ctx, ctx.Kit.Header, module.ServiceTemplateID, version)
+		if err != nil {
+			blog.Errorf("getModuleTemplateDefinition failed, GetServiceTemplateVersion failed, serviceTemplateID: %d, version: %d, err: %s, rid: %s", module.ServiceTemplateID, version, err, rid)
+			return nil, nil, err
+		}
+		return &templateVersion.ServiceTemplate, templateVersion.ProcessTemplates, nil
+	}
+
+	serviceTemplate, err := ps.CoreAPI.CoreService().Process().GetServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header, module.ServiceTemplateID)
+	if err != nil {
+		blog.Errorf("getModuleTemplateDefinition failed, GetServiceTemplate failed, serviceTemplateID: %d, err: %s, rid: %s", module.ServiceTemplateID, err, rid)
+		return nil, nil, err
+	}
+	processTemplateFilter := &metadata.ListProcessTemplatesOption{
+		BusinessID:         module.BizID,
+		ServiceTemplateIDs: []int64{module.ServiceTemplateID},
+	}
+	processTemplates, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(ctx.Kit.Ctx, ctx.Kit.Header, processTemplateFilter)
+	if err != nil {
+		blog.ErrorJSON("getModuleTemplateDefinition failed, ListProcessTemplates failed, option: %s, err: %s, rid: %s", processTemplateFilter, err, rid)
+		return nil, nil, err
+	}
+	return serviceTemplate, processTemplates.Info, nil
+}
+
+func (ps *ProcServer) CreateServiceTemplateVersion(ctx *rest.Contexts) {
+	option := metadata.CreateServiceTemplateVersionOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if option.BizID == 0 && option.Metadata != nil {
+		bizID, err := metadata.BizIDFromMetadata(*option.Metadata)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "create service template version, but get business id failed, err: %v", err)
+			return
+		}
+		option.BizID = bizID
+	}
+
+	templateVersion, err := ps.CoreAPI.CoreService().Process().CreateServiceTemplateVersion(ctx.Kit.Ctx, ctx.Kit.Header, &option)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "create service template version failed, err: %v", err)
+		return
+	}
+
+	ctx.RespEntity(templateVersion)
+}
+
+func (ps *ProcServer) GetServiceTemplateVersion(ctx *rest.Contexts) {
+	templateIDStr := ctx.Request.PathParameter(common.BKServiceTemplateIDField)
+	templateID, err := util.GetInt64ByInterface(templateIDStr)
+	if err != nil {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "get service template version failed, err: %v", common.BKServiceTemplateIDField, err)
+		return
+	}
+	versionStr := ctx.Request.PathParameter(common.BKVersionField)
+	version, err := util.GetInt64ByInterface(versionStr)
+	if err != nil {
+		ctx.RespErrorCodeF(common.CCErrCommParamsInvalid, "get service template version failed, err: %v", common.BKVersionField, err)
+		return
+	}
+
+	templateVersion, err := ps.CoreAPI.CoreService().Process().GetServiceTemplateVersion(ctx.Kit.Ctx, ctx.Kit.Header, templateID, version)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "get service template version failed, err: %v", err)
+		return
+	}
+
+	ctx.RespEntity(templateVersion)
+}
+
+func (ps *ProcServer) ListServiceTemplateVersions(ctx *rest.Contexts) {
+	option := metadata.ListServiceTemplateVersionOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if option.BizID == 0 && option.Metadata != nil {
+		bizID, err := metadata.BizIDFromMetadata(*option.Metadata)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "list service template version, but get business id failed, err: %v", err)
+			return
+		}
+		option.BizID = bizID
+	}
+
+	versions, err := ps.CoreAPI.CoreService().Process().ListServiceTemplateVersions(ctx.Kit.Ctx, ctx.Kit.Header, &option)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "list service template version failed, err: %v", err)
+		return
+	}
+
+	ctx.RespEntity(versions)
+}
+
+// PinModuleServiceTemplateVersion pin modules to a service template version, version 0 means unpin.
+// NOTE: only the pin relation is changed, use SyncServiceInstanceByTemplate to apply the version to modules.
+func (ps *ProcServer) PinModuleServiceTemplateVersion(ctx *rest.Contexts) {
+	option := metadata.PinModuleServiceTemplateVersionOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if option.BizID == 0 && option.Metadata != nil {
+		bizID, err := metadata.BizIDFromMetadata(*option.Metadata)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "pin service template version, but get business id failed, err: %v", err)
+			return
+		}
+		option.BizID = bizID
+	}
+
+	if len(option.ModuleIDs) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_module_ids"))
+		return
+	}
+
+	if err := ps.CoreAPI.CoreService().Process().PinModuleServiceTemplateVersion(ctx.Kit.Ctx, ctx.Kit.Header, &option); err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "pin service template version failed, err: %v", err)
+		return
+	}
+
+	ctx.RespEntity(nil)
+}
+
+func (ps *ProcServer) ListModuleServiceTemplateVersions(ctx *rest.Contexts) {
+	option := metadata.ListModuleServiceTemplateVersionOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if option.BizID == 0 && option.Metadata != nil {
+		bizID, err := metadata.BizIDFromMetadata(*option.Metadata)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "list module service template version, but get business id failed, err: %v", err)
+			return
+		}
+		option.BizID = bizID
+	}
+
+	pins, err := ps.CoreAPI.CoreService().Process().ListModuleServiceTemplateVersions(ctx.Kit.Ctx, ctx.Kit.Header, &option)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "list module service template version failed, err: %v", err)
+		return
+	}
+
+	ctx.RespEntity(pins)
+}
+
+// listServiceTemplateModuleIDs return ids of modules created by the service template, sorted in ascending order
+func (ps *ProcServer) listServiceTemplateModuleIDs(ctx *rest.Contexts, bizID int64, serviceTemplateID int64) ([]int64, errors.CCErrorCoder) {
+	filter := &metadata.QueryCondition{
+		Fields: []string{common.BKModuleIDField},
+		Limit:  metadata.SearchLimit{Limit: common.BKNoLimit},
+		Condition: mapstr.MapStr{
+			common.BKAppIDField:             bizID,
+			common.BKServiceTemplateIDField: serviceTemplateID,
+		},
+	}
+	modules, err := ps.CoreAPI.CoreService().Instance().ReadInstance(ctx.Kit.Ctx, ctx.Kit.Header, common.BKInnerObjIDModule, filter)
+	if err != nil {
+		blog.ErrorJSON("listServiceTemplateModuleIDs failed, ReadInstance failed, filter: %s, err: %s, rid: %s", filter, err, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if modules.Result == false || modules.Code != 0 {
+		blog.ErrorJSON("listServiceTemplateModuleIDs failed, ReadInstance failed, filter: %s, result: %s, rid: %s", filter, modules, ctx.Kit.Rid)
+		return nil, errors.New(modules.Code, modules.ErrMsg)
+	}
+
+	moduleIDs := make([]int64, 0)
+	for _, module := range modules.Data.Info {
+		moduleID, err := util.GetInt64ByInterface(module[common.BKModuleIDField])
+		if err != nil {
+			blog.ErrorJSON("listServiceTemplateModuleIDs failed, parse module id failed, module: %s, err: %s, rid: %s", module, err, ctx.Kit.Rid)
+			return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParseDBFailed, common.BKModuleIDField)
+		}
+		moduleIDs = append(moduleIDs, moduleID)
+	}
+	sort.Slice(moduleIDs, func(i, j int) bool { return moduleIDs[i] < moduleIDs[j] })
+	return moduleIDs, nil
+}
+
+// splitRolloutWaves split modules into waves of waveSize modules, waveSize 0 means all modules in one wave
+func splitRolloutWaves(moduleIDs []int64, waveSize int64) []metadata.RolloutWave {
+	if waveSize <= 0 {
+		waveSize = int64(len(moduleIDs))
+	}
+	waves := make([]metadata.RolloutWave, 0)
+	for start := int64(0); start < int64(len(moduleIDs)); start += waveSize {
+		end := start + waveSize
+		if end > int64(len(moduleIDs)) {
+			end = int64(len(moduleIDs))
+		}
+		waves = append(waves, metadata.RolloutWave{
+			ModuleIDs: moduleIDs[start:end],
+		})
+	}
+	return waves
+}
+
+// CreateServiceTemplateRollout sync a service template version to part of the modules created by the template,
+// the modules are synchronized in waves, each wave is executed as a task in task_server.
+func (ps *ProcServer) CreateServiceTemplateRollout(ctx *rest.Contexts) {
+	rid := ctx.Kit.Rid
+	option := metadata.CreateServiceTemplateRolloutOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if option.BizID == 0 && option.Metadata != nil {
+		bizID, err := metadata.BizIDFromMetadata(*option.Metadata)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "create service template rollout, but get business id failed, err: %v", err)
+			return
+		}
+		option.BizID = bizID
+	}
+	if key, err := option.Validate(); err != nil {
+		blog.Errorf("create service template rollout failed, option invalid, key: %s, err: %s, rid: %s", key, err.Error(), rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, key))
+		return
+	}
+
+	templateModuleIDs, err := ps.listServiceTemplateModuleIDs(ctx, option.BizID, option.ServiceTemplateID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	var moduleIDs []int64
+	if len(option.ModuleIDs) > 0 {
+		templateModules := make(map[int64]bool)
+		for _, moduleID := range templateModuleIDs {
+			templateModules[moduleID] = true
+		}
+		moduleIDs = util.IntArrayUnique(option.ModuleIDs)
+		for _, moduleID := range moduleIDs {
+			if _, exist := templateModules[moduleID]; exist == false {
+				blog.Errorf("create service template rollout failed, module %d not belong to service template %d, rid: %s", moduleID, option.ServiceTemplateID, rid)
+				ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_module_ids"))
+				return
+			}
+		}
+	} else {
+		count := int(math.Ceil(float64(len(templateModuleIDs)) * float64(option.Percentage) / 100))
+		moduleIDs = templateModuleIDs[:count]
+	}
+	if len(moduleIDs) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrProcServiceTemplateRolloutNoModule))
+		return
+	}
+
+	rollout := &metadata.ServiceTemplateRollout{
+		BizID:             option.BizID,
+		ServiceTemplateID: option.ServiceTemplateID,
+		Version:           option.Version,
+		Waves:             splitRolloutWaves(moduleIDs, option.WaveSize),
+		CurrentWave:       0,
+		Status:            metadata.RolloutStatusPaused,
+		PauseBetweenWaves: option.PauseBetweenWaves,
+		SupplierAccount:   ctx.Kit.SupplierAccount,
+	}
+	rollout, err = ps.CoreAPI.CoreService().Process().CreateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, rollout)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "create service template rollout failed, err: %v", err)
+		return
+	}
+
+	// the rollout is created as paused, starting the first wave is the same as resuming it
+	rollout, err = ps.startRolloutWave(ctx, rollout, 0)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(rollout)
+}
+
+// startRolloutWave move the rollout to the wave and dispatch the wave's modules to task_server.
+// the rollout record is updated before the task is created, so that only one of the concurrent
+// operations on the rollout dispatches the wave.
+func (ps *ProcServer) startRolloutWave(ctx *rest.Contexts, rollout *metadata.ServiceTemplateRollout, wave int64) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	rid := ctx.Kit.Rid
+
+	waves := rollout.Waves
+	waves[wave].Status = metadata.RolloutStatusRunning
+	waves[wave].TaskID = ""
+	updateOption := &metadata.UpdateServiceTemplateRolloutOption{
+		Revision:    rollout.Revision,
+		Status:      metadata.RolloutStatusRunning,
+		CurrentWave: &wave,
+		Waves:       waves,
+	}
+	rollout, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, rollout.ID, updateOption)
+	if err != nil {
+		blog.ErrorJSON("start rollout wave failed, UpdateServiceTemplateRollout failed, option: %s, err: %s, rid: %s", updateOption, err, rid)
+		return nil, err
+	}
+
+	moduleIDs := rollout.Waves[wave].ModuleIDs
+	tasks := make([]interface{}, 0)
+	for idx, moduleID := range moduleIDs {
+		tasks = append(tasks, metadata.SyncModuleRolloutTask{
+			Header:     ctx.Kit.Header,
+			BizID:      rollout.BizID,
+			RolloutID:  rollout.ID,
+			Wave:       wave,
+			ModuleID:   moduleID,
+			LastOfWave: idx == len(moduleIDs)-1,
+		})
+	}
+	flag := metadata.GetServiceTemplateRolloutIndex(rollout.ID, wave)
+	createTaskResult, e := ps.CoreAPI.TaskServer().Task().Create(ctx.Kit.Ctx, ctx.Kit.Header, common.SyncServiceTemplateRolloutTaskName, flag, tasks)
+	if e != nil {
+		blog.ErrorJSON("start rollout wave failed, dispatch task failed, rollout: %d, wave: %d, err: %s, rid: %s", rollout.ID, wave, e.Error(), rid)
+		return nil, errors.CCHttpError
+	}
+	if createTaskResult.Code != 0 || createTaskResult.Result == false {
+		blog.ErrorJSON("start rollout wave failed, dispatch task failed, rollout: %d, wave: %d, result: %s, rid: %s", rollout.ID, wave, createTaskResult, rid)
+		return nil, errors.New(createTaskResult.Code, createTaskResult.ErrMsg)
+	}
+
+	// record the task id for display, the task of a wave is found by it's flag, so failure here is harmless
+	rollout.Waves[wave].TaskID = createTaskResult.Data.TaskID
+	taskOption := &metadata.UpdateServiceTemplateRolloutOption{
+		Revision: rollout.Revision,
+		Waves:    rollout.Waves,
+	}
+	updated, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, rollout.ID, taskOption)
+	if err != nil {
+		blog.Warnf("start rollout wave, record task id failed, rollout: %d, wave: %d, task: %s, err: %s, rid: %s", rollout.ID, wave, createTaskResult.Data.TaskID, err, rid)
+		return rollout, nil
+	}
+	return updated, nil
+}
+
+// finishRolloutWave is called when all modules of the current wave are handled, it moves the rollout to next wave,
+// or marks it failure when any module of the wave synchronized failed.
+func (ps *ProcServer) finishRolloutWave(ctx *rest.Contexts, rollout *metadata.ServiceTemplateRollout, failed bool) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	rid := ctx.Kit.Rid
+
+	wave := rollout.CurrentWave
+	waves := rollout.Waves
+	updateOption := &metadata.UpdateServiceTemplateRolloutOption{
+		Revision: rollout.Revision,
+		Waves:    waves,
+	}
+	var startNext bool
+	waves[wave].Status, updateOption.Status, startNext = finishedRolloutWaveStatus(rollout, failed)
+
+	updated, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, rollout.ID, updateOption)
+	if err != nil {
+		blog.ErrorJSON("finish rollout wave failed, UpdateServiceTemplateRollout failed, option: %s, err: %s, rid: %s", updateOption, err, rid)
+		return nil, err
+	}
+	if startNext {
+		return ps.startRolloutWave(ctx, updated, wave+1)
+	}
+	return updated, nil
+}
+
+// finishedRolloutWaveStatus returns the status of the current wave and the rollout after the wave is finished,
+// the rollout status is empty and startNext is true when the next wave should be started at once.
+func finishedRolloutWaveStatus(rollout *metadata.ServiceTemplateRollout, failed bool) (waveStatus, rolloutStatus metadata.RolloutStatus, startNext bool) {
+	switch {
+	case failed:
+		return metadata.RolloutStatusFailure, metadata.RolloutStatusFailure, false
+	case rollout.CurrentWave == int64(len(rollout.Waves))-1:
+		return metadata.RolloutStatusFinished, metadata.RolloutStatusFinished, false
+	case rollout.PauseBetweenWaves:
+		// stay on the finished wave, resume will start the next one
+		return metadata.RolloutStatusFinished, metadata.RolloutStatusPaused, false
+	default:
+		return metadata.RolloutStatusFinished, "", true
+	}
+}
+
+// getRolloutWaveTask return the latest task dispatched for the wave, nil if not found
+func (ps *ProcServer) getRolloutWaveTask(ctx *rest.Contexts, rolloutID int64, wave int64) (*metadata.APITaskDetail, errors.CCErrorCoder) {
+	listTaskOption := metadata.ListAPITaskRequest{
+		Condition: mapstr.MapStr{
+			"flag": metadata.GetServiceTemplateRolloutIndex(rolloutID, wave),
+		},
+		Page: metadata.BasePage{
+			Sort:  "-create_time",
+			Limit: 1,
+		},
+	}
+	listResult, err := ps.CoreAPI.TaskServer().Task().ListTask(ctx.Kit.Ctx, ctx.Kit.Header, common.SyncServiceTemplateRolloutTaskName, &listTaskOption)
+	if err != nil {
+		blog.ErrorJSON("list rollout wave task failed, option: %s, err: %s, rid: %s", listTaskOption, err.Error(), ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCError(common.CCErrTaskListTaskFail)
+	}
+	if listResult.Result == false || listResult.Code != 0 {
+		blog.ErrorJSON("list rollout wave task failed, option: %s, result: %s, rid: %s", listTaskOption, listResult, ctx.Kit.Rid)
+		return nil, errors.New(listResult.Code, listResult.ErrMsg)
+	}
+	if len(listResult.Data.Info) == 0 {
+		return nil, nil
+	}
+	return &listResult.Data.Info[0], nil
+}
+
+// refreshServiceTemplateRollout finish the current wave of a running rollout whose task is already finished,
+// which happens when the last module of the wave failed to move the rollout forward.
+func (ps *ProcServer) refreshServiceTemplateRollout(ctx *rest.Contexts, rollout *metadata.ServiceTemplateRollout) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	if rollout.Status != metadata.RolloutStatusRunning {
+		return rollout, nil
+	}
+	task, err := ps.getRolloutWaveTask(ctx, rollout.ID, rollout.CurrentWave)
+	if err != nil {
+		return nil, err
+	}
+	if task == nil || task.Status.IsFinished() == false {
+		return rollout, nil
+	}
+	return ps.finishRolloutWave(ctx, rollout, task.Status.IsFailure())
+}
+
+func (ps *ProcServer) getServiceTemplateRollout(ctx *rest.Contexts, option metadata.ServiceTemplateRolloutOperationOption) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	rollout, err := ps.CoreAPI.CoreService().Process().GetServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, option.RolloutID)
+	if err != nil {
+		blog.Errorf("get service template rollout failed, rollout: %d, err: %s, rid: %s", option.RolloutID, err, ctx.Kit.Rid)
+		return nil, err
+	}
+	if rollout.ServiceTemplateID != option.ServiceTemplateID {
+		blog.Errorf("get service template rollout failed, rollout %d not belong to service template %d, rid: %s", option.RolloutID, option.ServiceTemplateID, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "rollout_id")
+	}
+	return rollout, nil
+}
+
+func (ps *ProcServer) GetServiceTemplateRollout(ctx *rest.Contexts) {
+	option := metadata.ServiceTemplateRolloutOperationOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	rollout, err := ps.getServiceTemplateRollout(ctx, option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	rollout, err = ps.refreshServiceTemplateRollout(ctx, rollout)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(rollout)
+}
+
+func (ps *ProcServer) ListServiceTemplateRollouts(ctx *rest.Contexts) {
+	option := metadata.ListServiceTemplateRolloutOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if option.BizID == 0 && option.Metadata != nil {
+		bizID, err := metadata.BizIDFromMetadata(*option.Metadata)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "list service template rollout, but get business id failed, err: %v", err)
+			return
+		}
+		option.BizID = bizID
+	}
+
+	rollouts, err := ps.CoreAPI.CoreService().Process().ListServiceTemplateRollouts(ctx.Kit.Ctx, ctx.Kit.Header, &option)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "list service template rollout failed, err: %v", err)
+		return
+	}
+
+	ctx.RespEntity(rollouts)
+}
+
+// PauseServiceTemplateRollout modules of the running wave which are not synchronized yet are skipped,
+// they are synchronized again when the rollout is resumed.
+func (ps *ProcServer) PauseServiceTemplateRollout(ctx *rest.Contexts) {
+	ps.changeServiceTemplateRolloutStatus(ctx, metadata.RolloutStatusPaused)
+}
+
+func (ps *ProcServer) ResumeServiceTemplateRollout(ctx *rest.Contexts) {
+	ps.changeServiceTemplateRolloutStatus(ctx, metadata.RolloutStatusRunning)
+}
+
+// AbortServiceTemplateRollout stop the rollout, modules already synchronized keep the version they synchronized to.
+func (ps *ProcServer) AbortServiceTemplateRollout(ctx *rest.Contexts) {
+	ps.changeServiceTemplateRolloutStatus(ctx, metadata.RolloutStatusAborted)
+}
+
+func (ps *ProcServer) changeServiceTemplateRolloutStatus(ctx *rest.Contexts, status metadata.RolloutStatus) {
+	option := metadata.ServiceTemplateRolloutOperationOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	rollout, err := ps.getServiceTemplateRollout(ctx, option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	switch status {
+	case metadata.RolloutStatusPaused:
+		if rollout.Status != metadata.RolloutStatusRunning {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrProcServiceTemplateRolloutStatusInvalid, rollout.Status))
+			return
+		}
+	case metadata.RolloutStatusRunning:
+		if rollout.Status != metadata.RolloutStatusPaused {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrProcServiceTemplateRolloutStatusInvalid, rollout.Status))
+			return
+		}
+		// a rollout paused between waves continue with the next wave, otherwise the interrupted wave is executed again.
+		wave := rollout.CurrentWave
+		if rollout.Waves[wave].Status == metadata.RolloutStatusFinished {
+			wave++
+		}
+		rollout, err = ps.startRolloutWave(ctx, rollout, wave)
+		if err != nil {
+			ctx.RespAutoError(err)
+			return
+		}
+		ctx.RespEntity(rollout)
+		return
+	case metadata.RolloutStatusAborted:
+		if rollout.Status.IsFinished() {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrProcServiceTemplateRolloutStatusInvalid, rollout.Status))
+			return
+		}
+	}
+
+	updateOption := &metadata.UpdateServiceTemplateRolloutOption{
+		Revision: rollout.Revision,
+		Status:   status,
+	}
+	rollout, err = ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, rollout.ID, updateOption)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "update service template rollout failed, err: %v", err)
+		return
+	}
+
+	ctx.RespEntity(rollout)
+}
+
+// ServiceTemplateRolloutTaskHandler is called by task_server, synchronize one module of a rollout wave
+func (ps *ProcServer) ServiceTemplateRolloutTaskHandler(ctx *rest.Contexts) {
+	rid := ctx.Kit.Rid
+	task := metadata.SyncModuleRolloutTask{}
+	if err := ctx.DecodeInto(&task); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	rollout, err := ps.CoreAPI.CoreService().Process().GetServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, task.RolloutID)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "get service template rollout failed, err: %v", err)
+		return
+	}
+	// the rollout is paused or aborted, or the task is an outdated one
+	if rollout.Status != metadata.RolloutStatusRunning || rollout.CurrentWave != task.Wave {
+		blog.Infof("skip rollout task, rollout: %d, status: %s, current wave: %d, task wave: %d, module: %d, rid: %s", rollout.ID, rollout.Status, rollout.CurrentWave, task.Wave, task.ModuleID, rid)
+		ctx.RespEntity(nil)
+		return
+	}
+
+	var syncErr errors.CCErrorCoder
+	module, err := ps.getModule(ctx, task.ModuleID)
+	switch {
+	case err != nil:
+		// module may be deleted after the rollout created
+		blog.Warnf("skip rollout task, get module %d failed, err: %s, rid: %s", task.ModuleID, err, rid)
+	case module.ServiceTemplateID != rollout.ServiceTemplateID:
+		blog.Warnf("skip rollout task, module %d not belong to service template %d any more, rid: %s", task.ModuleID, rollout.ServiceTemplateID, rid)
+	default:
+		syncOption := metadata.SyncModuleServiceInstanceByTemplateOption{
+			BizID:    rollout.BizID,
+			ModuleID: task.ModuleID,
+			Version:  rollout.Version,
+		}
+		syncErr = ps.syncServiceInstanceByTemplate(ctx, syncOption)
+		if syncErr != nil {
+			blog.ErrorJSON("rollout task sync module failed, option: %s, err: %s, rid: %s", syncOption, syncErr, rid)
+		}
+	}
+
+	if task.LastOfWave {
+		failed := syncErr != nil
+		if failed == false {
+			waveTask, err := ps.getRolloutWaveTask(ctx, rollout.ID, task.Wave)
+			if err != nil {
+				ctx.RespAutoError(err)
+				return
+			}
+			if waveTask != nil {
+				for _, subTask := range waveTask.Detail {
+					if subTask.Status.IsFailure() {
+						failed = true
+						break
+					}
+				}
+			}
+		}
+		if _, err := ps.finishRolloutWave(ctx, rollout, failed); err != nil {
+			blog.Errorf("rollout task finish wave failed, rollout: %d, wave: %d, err: %s, rid: %s", rollout.ID, task.Wave, err, rid)
+			ctx.RespAutoError(err)
+			return
+		}
+	}
+
+	if syncErr != nil {
+		ctx.RespAutoError(syncErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
@@ -0,0 +1,139 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRolloutWaves(t *testing.T) {
+	testCases := []struct {
+		name      string
+		moduleIDs []int64
+		waveSize  int64
+		expect    [][]int64
+	}{
+		{
+			name:      "zero wave size puts all modules in one wave",
+			moduleIDs: []int64{1, 2, 3},
+			waveSize:  0,
+			expect:    [][]int64{{1, 2, 3}},
+		},
+		{
+			name:      "negative wave size puts all modules in one wave",
+			moduleIDs: []int64{1, 2, 3},
+			waveSize:  -1,
+			expect:    [][]int64{{1, 2, 3}},
+		},
+		{
+			name:      "even split",
+			moduleIDs: []int64{1, 2, 3, 4},
+			waveSize:  2,
+			expect:    [][]int64{{1, 2}, {3, 4}},
+		},
+		{
+			name:      "uneven split leaves the rest in the last wave",
+			moduleIDs: []int64{1, 2, 3, 4, 5},
+			waveSize:  2,
+			expect:    [][]int64{{1, 2}, {3, 4}, {5}},
+		},
+		{
+			name:      "wave size larger than the modules",
+			moduleIDs: []int64{1, 2},
+			waveSize:  5,
+			expect:    [][]int64{{1, 2}},
+		},
+		{
+			name:      "no modules",
+			moduleIDs: []int64{},
+			waveSize:  2,
+			expect:    [][]int64{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			waves := splitRolloutWaves(testCase.moduleIDs, testCase.waveSize)
+			moduleIDs := make([][]int64, 0)
+			for _, wave := range waves {
+				require.Empty(t, wave.Status)
+				moduleIDs = append(moduleIDs, wave.ModuleIDs)
+			}
+			require.Equal(t, testCase.expect, moduleIDs)
+		})
+	}
+}
+
+func TestFinishedRolloutWaveStatus(t *testing.T) {
+	threeWaves := []metadata.RolloutWave{{}, {}, {}}
+	testCases := []struct {
+		name          string
+		rollout       metadata.ServiceTemplateRollout
+		failed        bool
+		waveStatus    metadata.RolloutStatus
+		rolloutStatus metadata.RolloutStatus
+		startNext     bool
+	}{
+		{
+			name:          "failed wave fails the rollout",
+			rollout:       metadata.ServiceTemplateRollout{Waves: threeWaves, CurrentWave: 0},
+			failed:        true,
+			waveStatus:    metadata.RolloutStatusFailure,
+			rolloutStatus: metadata.RolloutStatusFailure,
+		},
+		{
+			name:          "failed last wave fails the rollout",
+			rollout:       metadata.ServiceTemplateRollout{Waves: threeWaves, CurrentWave: 2},
+			failed:        true,
+			waveStatus:    metadata.RolloutStatusFailure,
+			rolloutStatus: metadata.RolloutStatusFailure,
+		},
+		{
+			name:          "last wave finishes the rollout",
+			rollout:       metadata.ServiceTemplateRollout{Waves: threeWaves, CurrentWave: 2},
+			waveStatus:    metadata.RolloutStatusFinished,
+			rolloutStatus: metadata.RolloutStatusFinished,
+		},
+		{
+			name:          "last wave finishes the rollout even if paused between waves",
+			rollout:       metadata.ServiceTemplateRollout{Waves: threeWaves, CurrentWave: 2, PauseBetweenWaves: true},
+			waveStatus:    metadata.RolloutStatusFinished,
+			rolloutStatus: metadata.RolloutStatusFinished,
+		},
+		{
+			name:          "pause between waves",
+			rollout:       metadata.ServiceTemplateRollout{Waves: threeWaves, CurrentWave: 0, PauseBetweenWaves: true},
+			waveStatus:    metadata.RolloutStatusFinished,
+			rolloutStatus: metadata.RolloutStatusPaused,
+		},
+		{
+			name:       "start the next wave",
+			rollout:    metadata.ServiceTemplateRollout{Waves: threeWaves, CurrentWave: 1},
+			waveStatus: metadata.RolloutStatusFinished,
+			startNext:  true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			waveStatus, rolloutStatus, startNext := finishedRolloutWaveStatus(&testCase.rollout, testCase.failed)
+			require.Equal(t, testCase.waveStatus, waveStatus)
+			require.Equal(t, testCase.rolloutStatus, rolloutStatus)
+			require.Equal(t, testCase.startNext, startNext)
+		})
+	}
+}
@@ -36,6 +36,9 @@ var (
 // init for auto task
 func init() {
 	AddCodeTaskConfig("sync-settemplate2set", types.CC_MODULE_TOPO, "/topo/v3/internal/task", 1)
+	AddCodeTaskConfig("export-excel", types.CC_MODULE_WEBSERVER, "/internal/task/export", 1)
+	AddCodeTaskConfig("sync-servicetemplate-rollout", types.CC_MODULE_PROC, "/process/v3/internal/task/service_template_rollout", 1)
+	AddCodeTaskConfig("refresh-computed-values", types.CC_MODULE_CORESERVICE, "/api/v3/internal/task/refresh_computed_values", 1)
 }
 
 // AddCodeTaskConfig add task
@@ -343,7 +343,7 @@ func (a *attribute) SetSupplierAccount(supplierAccount string) {
 
 func (a *attribute) isPropertyTypeIntEnumList(propertyType string) bool {
 	switch propertyType {
-	case common.FieldTypeInt, common.FieldTypeEnum, common.FieldTypeList:
+	case common.FieldTypeInt, common.FieldTypeEnum, common.FieldTypeList, common.FieldTypeEnumMulti, common.FieldTypeReference, common.FieldTypeComputed:
 		return true
 	default:
 		return false
@@ -552,6 +552,18 @@ func convStrToCCType(val string, attr metadata.Attribute) (interface{}, error) {
 			return nil, fmt.Errorf("not foud")
 		}
 		return getEnumIDByName(val, option), nil
+	case common.FieldTypeEnumMulti:
+		option, optionOk := attr.Option.([]interface{})
+		if !optionOk {
+			return nil, fmt.Errorf("not foud")
+		}
+		ids := make([]interface{}, 0)
+		for _, name := range strings.Split(val, common.ExcelEnumMultiSplitChar) {
+			if name = strings.TrimSpace(name); name != "" {
+				ids = append(ids, getEnumIDByName(name, option))
+			}
+		}
+		return ids, nil
 	case common.FieldTypeInt:
 		return util.GetInt64ByInterface(val)
 	case common.FieldTypeFloat:
@@ -183,6 +183,7 @@ func (m *module) CreateModule(params types.ContextParams, obj model.Object, bizI
 			return nil, params.Err.Error(common.CCErrProcServiceTemplateAndCategoryNotCoincide)
 		}
 		serviceCategoryID = stResult.Info[0].ServiceCategoryID
+		metadata.ApplyTemplateAttributes(data, stResult.Info[0].Attributes)
 	} else {
 		// 检查 service category id 是否有效
 		serviceCategory, err := m.clientSet.CoreService().Process().GetServiceCategory(params.Context, params.Header, serviceCategoryID)
@@ -421,6 +422,16 @@ func (m *module) UpdateModule(params types.ContextParams, data mapstr.MapStr, ob
 				return params.Err.CCError(common.CCErrorTopoUpdateModuleFromTplNameForbidden)
 			}
 		}
+
+		// 检查并提示禁止修改服务模板锁定的属性
+		serviceTemplate, err := m.clientSet.CoreService().Process().GetServiceTemplate(params.Context, params.Header, moduleInstance.ServiceTemplateID)
+		if err != nil {
+			blog.Errorf("update module failed, get service template failed, templateID: %d, err: %s, rid: %s", moduleInstance.ServiceTemplateID, err.Error(), params.ReqID)
+			return err
+		}
+		if propertyID, conflict := metadata.FindLockedTemplateAttributeConflict(data, serviceTemplate.Attributes); conflict {
+			return params.Err.CCErrorf(common.CCErrorTopoUpdateTemplateLockedAttributeForbidden, propertyID)
+		}
 	}
 
 	// module table don't have metadata field
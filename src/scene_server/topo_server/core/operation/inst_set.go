@@ -20,6 +20,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/mapstruct"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/scene_server/topo_server/core/inst"
@@ -106,6 +107,7 @@ func (s *set) CreateSet(params types.ContextParams, obj model.Object, bizID int6
 	}
 
 	// TODO: run in transaction
+	metadata.ApplyTemplateAttributes(data, setTemplate.Attributes)
 	data.Set(common.BKSetTemplateIDField, setTemplate.ID)
 	data.Remove(common.MetadataField)
 	setInstance, err := s.inst.CreateInst(params, obj, data)
@@ -213,5 +215,50 @@ func (s *set) UpdateSet(params types.ContextParams, data mapstr.MapStr, obj mode
 	data.Remove(common.BKAppIDField)
 	data.Remove(common.BKSetIDField)
 
+	if err := s.checkSetTemplateLockedAttributes(params, bizID, setID, data); err != nil {
+		return err
+	}
+
 	return s.inst.UpdateInst(params, data, obj, innerCond, setID)
 }
+
+// checkSetTemplateLockedAttributes forbid modifying the attributes locked by the template the set created from
+func (s *set) checkSetTemplateLockedAttributes(params types.ContextParams, bizID, setID int64, data mapstr.MapStr) error {
+	filter := &metadata.QueryCondition{
+		Condition: mapstr.MapStr{
+			common.BKAppIDField: bizID,
+			common.BKSetIDField: setID,
+		},
+	}
+	rsp, err := s.clientSet.CoreService().Instance().ReadInstance(params.Context, params.Header, common.BKInnerObjIDSet, filter)
+	if err != nil {
+		blog.Errorf("update set failed, read set failed, filter: %+v, err: %s, rid: %s", filter, err.Error(), params.ReqID)
+		return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !rsp.Result {
+		blog.Errorf("update set failed, read set failed, filter: %+v, err: %s, rid: %s", filter, rsp.ErrMsg, params.ReqID)
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+	if len(rsp.Data.Info) == 0 {
+		return params.Err.CCError(common.CCErrCommNotFound)
+	}
+
+	set := metadata.SetInst{}
+	if err := mapstruct.Decode2Struct(rsp.Data.Info[0], &set); err != nil {
+		blog.ErrorJSON("update set failed, decode set failed, set: %s, err: %s, rid: %s", rsp.Data.Info[0], err.Error(), params.ReqID)
+		return params.Err.CCError(common.CCErrCommParseDBFailed)
+	}
+	if set.SetTemplateID == common.SetTemplateIDNotSet {
+		return nil
+	}
+
+	setTemplate, err := s.clientSet.CoreService().SetTemplate().GetSetTemplate(params.Context, params.Header, bizID, set.SetTemplateID)
+	if err != nil {
+		blog.Errorf("update set failed, get set template failed, setTemplateID: %d, err: %s, rid: %s", set.SetTemplateID, err.Error(), params.ReqID)
+		return err
+	}
+	if propertyID, conflict := metadata.FindLockedTemplateAttributeConflict(data, setTemplate.Attributes); conflict {
+		return params.Err.CCErrorf(common.CCErrorTopoUpdateTemplateLockedAttributeForbidden, propertyID)
+	}
+	return nil
+}
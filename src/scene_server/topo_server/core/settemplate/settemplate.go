@@ -63,6 +63,12 @@ func (st *setTemplate) DiffSetTplWithInst(ctx context.Context, header http.Heade
 		serviceTemplateMap[svcTpl.ID] = svcTpl
 	}
 
+	setTemplate, err := st.client.CoreService().SetTemplate().GetSetTemplate(ctx, header, bizID, setTemplateID)
+	if err != nil {
+		blog.Errorf("DiffSetTemplateWithInstances failed, GetSetTemplate failed, bizID: %d, setTemplateID: %d, err: %s, rid: %s", bizID, setTemplateID, err.Error(), rid)
+		return nil, err
+	}
+
 	setIDs := util.IntArrayUnique(option.SetIDs)
 	setFilter := &metadata.QueryCondition{
 		Limit: metadata.SearchLimit{
@@ -85,6 +91,7 @@ func (st *setTemplate) DiffSetTplWithInst(ctx context.Context, header http.Heade
 		return nil, ccError.CCErrorf(common.CCErrCommParamsInvalid, "bk_set_ids")
 	}
 	setMap := make(map[int64]metadata.SetInst)
+	setDataMap := make(map[int64]mapstr.MapStr)
 	for _, setInstance := range setInstResult.Data.Info {
 		set := metadata.SetInst{}
 		if err := mapstruct.Decode2Struct(setInstance, &set); err != nil {
@@ -96,6 +103,7 @@ func (st *setTemplate) DiffSetTplWithInst(ctx context.Context, header http.Heade
 			return nil, ccError.CCError(common.CCErrCommJSONMarshalFailed)
 		}
 		setMap[set.SetID] = set
+		setDataMap[set.SetID] = setInstance
 	}
 
 	moduleFilter := &metadata.QueryCondition{
@@ -116,6 +124,7 @@ func (st *setTemplate) DiffSetTplWithInst(ctx context.Context, header http.Heade
 	}
 
 	setModules := make(map[int64][]metadata.ModuleInst)
+	moduleDataMap := make(map[int64]mapstr.MapStr)
 	// init before modules loop so that set with no modules could be initial correctly
 	for _, setID := range option.SetIDs {
 		setModules[setID] = make([]metadata.ModuleInst, 0)
@@ -130,6 +139,7 @@ func (st *setTemplate) DiffSetTplWithInst(ctx context.Context, header http.Heade
 			setModules[module.ParentID] = make([]metadata.ModuleInst, 0)
 		}
 		setModules[module.ParentID] = append(setModules[module.ParentID], module)
+		moduleDataMap[module.ModuleID] = moduleInstance
 	}
 
 	topoTree, ccErr := st.client.CoreService().Mainline().SearchMainlineInstanceTopo(ctx, header, bizID, false)
@@ -140,10 +150,11 @@ func (st *setTemplate) DiffSetTplWithInst(ctx context.Context, header http.Heade
 	// diff
 	setDiffs := make([]metadata.SetDiff, 0)
 	for setID, modules := range setModules {
-		moduleDiff := DiffServiceTemplateWithModules(serviceTemplates, modules)
+		moduleDiff := DiffServiceTemplateWithModules(serviceTemplates, modules, moduleDataMap)
 		setDiff := metadata.SetDiff{
-			ModuleDiffs: moduleDiff,
-			SetID:       setID,
+			ModuleDiffs:    moduleDiff,
+			SetID:          setID,
+			AttributeDiffs: DiffTemplateAttributes(setTemplate.Attributes, setDataMap[setID]),
 		}
 		if set, ok := setMap[setID]; ok == true {
 			setDiff.SetDetail = set
@@ -179,6 +190,13 @@ func (st *setTemplate) SyncSetTplToInst(params types.ContextParams, bizID int64,
 	}
 
 	for _, setDiff := range setDiffs {
+		// locked attributes of set are synchronized directly, modules are synchronized by task
+		if lockedAttributes := setDiff.LockedAttributes(); len(lockedAttributes) > 0 {
+			if err := st.syncSetLockedAttributes(params, bizID, setDiff.SetID, lockedAttributes); err != nil {
+				return err
+			}
+		}
+
 		indexKey := metadata.GetSetTemplateSyncIndex(setDiff.SetID)
 		blog.V(3).Infof("dispatch synchronize task on set [%s](%d), rid: %s", setDiff.SetDetail.SetName, setDiff.SetID, rid)
 		tasks := make([]metadata.SyncModuleTask, 0)
@@ -241,6 +259,27 @@ func (st *setTemplate) SyncSetTplToInst(params types.ContextParams, bizID int64,
 	return nil
 }
 
+func (st *setTemplate) syncSetLockedAttributes(params types.ContextParams, bizID int64, setID int64, attributes map[string]interface{}) errors.CCErrorCoder {
+	rid := util.GetHTTPCCRequestID(params.Header)
+	option := &metadata.UpdateOption{
+		Data: mapstr.MapStr(attributes),
+		Condition: mapstr.MapStr{
+			common.BKAppIDField: bizID,
+			common.BKSetIDField: setID,
+		},
+	}
+	result, err := st.client.CoreService().Instance().UpdateInstance(params.Context, params.Header, common.BKInnerObjIDSet, option)
+	if err != nil {
+		blog.ErrorJSON("sync set locked attributes failed, setID: %s, attributes: %s, err: %s, rid: %s", setID, attributes, err.Error(), rid)
+		return errors.CCHttpError
+	}
+	if result.Result == false {
+		blog.ErrorJSON("sync set locked attributes failed, setID: %s, attributes: %s, result: %s, rid: %s", setID, attributes, result, rid)
+		return errors.NewCCError(result.Code, result.ErrMsg)
+	}
+	return nil
+}
+
 func (st *setTemplate) DispatchTask4ModuleSync(ctx context.Context, header http.Header, indexKey string, tasks ...metadata.SyncModuleTask) (metadata.APITaskDetail, errors.CCErrorCoder) {
 	taskDetail := metadata.APITaskDetail{}
 	rid := util.GetHTTPCCRequestID(header)
@@ -262,8 +301,26 @@ func (st *setTemplate) DispatchTask4ModuleSync(ctx context.Context, header http.
 	return taskDetail, nil
 }
 
-// DiffServiceTemplateWithModules diff modules with template in one set
-func DiffServiceTemplateWithModules(serviceTemplates []metadata.ServiceTemplate, modules []metadata.ModuleInst) []metadata.SetModuleDiff {
+// DiffTemplateAttributes diff instance data with attributes defined in template, only the different attributes are returned
+func DiffTemplateAttributes(attributes []metadata.TemplateAttribute, data mapstr.MapStr) []metadata.AttributeDiff {
+	attributeDiffs := make([]metadata.AttributeDiff, 0)
+	for _, attribute := range attributes {
+		value := data[attribute.PropertyID]
+		if metadata.IsTemplateAttributeValueEqual(attribute.Value, value) {
+			continue
+		}
+		attributeDiffs = append(attributeDiffs, metadata.AttributeDiff{
+			PropertyID:    attribute.PropertyID,
+			TemplateValue: attribute.Value,
+			InstanceValue: value,
+			Locked:        attribute.Locked,
+		})
+	}
+	return attributeDiffs
+}
+
+// DiffServiceTemplateWithModules diff modules with template in one set, moduleData holds the raw module data for attribute diff
+func DiffServiceTemplateWithModules(serviceTemplates []metadata.ServiceTemplate, modules []metadata.ModuleInst, moduleData map[int64]mapstr.MapStr) []metadata.SetModuleDiff {
 	svcTplMap := make(map[int64]metadata.ServiceTemplate)
 	svcTplHitMap := make(map[int64]bool)
 	for _, svcTpl := range serviceTemplates {
@@ -292,17 +349,18 @@ func DiffServiceTemplateWithModules(serviceTemplates []metadata.ServiceTemplate,
 		if _, ok := svcTplHitMap[module.ServiceTemplateID]; ok == true {
 			svcTplHitMap[module.ServiceTemplateID] = true
 		}
-		diffType := metadata.ModuleDiffUnchanged
-		if module.ModuleName != template.Name {
-			diffType = metadata.ModuleDiffChanged
-		}
-		moduleDiffs = append(moduleDiffs, metadata.SetModuleDiff{
+		moduleDiff := metadata.SetModuleDiff{
 			ModuleID:            module.ModuleID,
 			ModuleName:          module.ModuleName,
 			ServiceTemplateID:   module.ServiceTemplateID,
 			ServiceTemplateName: template.Name,
-			DiffType:            diffType,
-		})
+			DiffType:            metadata.ModuleDiffUnchanged,
+			AttributeDiffs:      DiffTemplateAttributes(template.Attributes, moduleData[module.ModuleID]),
+		}
+		if module.ModuleName != template.Name || len(moduleDiff.LockedAttributes()) > 0 {
+			moduleDiff.DiffType = metadata.ModuleDiffChanged
+		}
+		moduleDiffs = append(moduleDiffs, moduleDiff)
 	}
 
 	for templateID, hit := range svcTplHitMap {
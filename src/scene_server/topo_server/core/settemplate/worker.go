@@ -83,6 +83,10 @@ func (bw BackendWorker) DoModuleSyncTask(header http.Header, set metadata.SetIns
 		data := mapstr.MapStr(map[string]interface{}{
 			common.BKModuleNameField: moduleDiff.ModuleName,
 		})
+		// overwrite the attributes locked by service template
+		for propertyID, value := range moduleDiff.LockedAttributes() {
+			data[propertyID] = value
+		}
 		err := bw.ModuleOperation.UpdateModule(params, data, moduleObj, bizID, setID, moduleID)
 		if err != nil {
 			blog.ErrorJSON("DoModuleSyncTask failed, UpdateModule failed, set: %s, moduleDiff: %s, err: %s, rid: %s", set, moduleDiff, err.Error(), rid)
@@ -88,6 +88,8 @@ type InstanceOperation interface {
 	SearchModelInstance(ctx ContextParams, objID string, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
 	DeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	CascadeDeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	// RefreshComputedValues evaluate the computed attributes of all the instances of the model and their mainline descendants again
+	RefreshComputedValues(ctx ContextParams, objID string) error
 }
 
 // AssociationKind association kind methods
@@ -184,6 +186,16 @@ type StatisticOperation interface {
 	UpdateOperationChart(ctx ContextParams, inputParam mapstr.MapStr) (interface{}, error)
 	SearchTimerChartData(ctx ContextParams, inputParam metadata.ChartConfig) (interface{}, error)
 	TimerFreshData(params ContextParams) error
+
+	// custom chart
+	CreateCustomChart(ctx ContextParams, chart metadata.CustomChart) (*metadata.CustomChart, error)
+	UpdateCustomChart(ctx ContextParams, id uint64, chart metadata.CustomChart) (*metadata.CustomChart, error)
+	DeleteCustomChart(ctx ContextParams, id uint64) error
+	SearchCustomChart(ctx ContextParams, option metadata.CustomChartSearchOption) (*metadata.CustomChartSearchResult, error)
+	SearchCustomChartData(ctx ContextParams, id uint64, option metadata.CustomChartDataOption) (*metadata.CustomChartData, error)
+	ComputeCustomChart(ctx ContextParams, option metadata.CustomChartComputeOption) (*metadata.CustomChartComputeResult, error)
+
+	StatisticInventory(ctx ContextParams, option metadata.InventoryStatisticsOption) (*metadata.InventoryStatistics, error)
 }
 
 // Core core itnerfaces methods
@@ -219,10 +231,24 @@ type ProcessOperation interface {
 	ListServiceTemplates(ctx ContextParams, option metadata.ListServiceTemplateOption) (*metadata.MultipleServiceTemplate, errors.CCErrorCoder)
 	DeleteServiceTemplate(ctx ContextParams, serviceTemplateID int64) errors.CCErrorCoder
 
+	// service template version
+	CreateServiceTemplateVersion(ctx ContextParams, option metadata.CreateServiceTemplateVersionOption) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder)
+	GetServiceTemplateVersion(ctx ContextParams, serviceTemplateID int64, version int64) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder)
+	ListServiceTemplateVersions(ctx ContextParams, option metadata.ListServiceTemplateVersionOption) (*metadata.MultipleServiceTemplateVersion, errors.CCErrorCoder)
+	PinModuleServiceTemplateVersion(ctx ContextParams, option metadata.PinModuleServiceTemplateVersionOption) errors.CCErrorCoder
+	ListModuleServiceTemplateVersions(ctx ContextParams, option metadata.ListModuleServiceTemplateVersionOption) ([]metadata.ModuleServiceTemplateVersion, errors.CCErrorCoder)
+
+	// service template rollout
+	CreateServiceTemplateRollout(ctx ContextParams, rollout metadata.ServiceTemplateRollout) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+	GetServiceTemplateRollout(ctx ContextParams, rolloutID int64) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+	ListServiceTemplateRollouts(ctx ContextParams, option metadata.ListServiceTemplateRolloutOption) (*metadata.MultipleServiceTemplateRollout, errors.CCErrorCoder)
+	UpdateServiceTemplateRollout(ctx ContextParams, rolloutID int64, option metadata.UpdateServiceTemplateRolloutOption) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+
 	// process template
 	CreateProcessTemplate(ctx ContextParams, template metadata.ProcessTemplate) (*metadata.ProcessTemplate, errors.CCErrorCoder)
 	GetProcessTemplate(ctx ContextParams, templateID int64) (*metadata.ProcessTemplate, errors.CCErrorCoder)
 	UpdateProcessTemplate(ctx ContextParams, templateID int64, property map[string]interface{}) (*metadata.ProcessTemplate, errors.CCErrorCoder)
+	AcceptProcessTemplateUpstream(ctx ContextParams, templateID int64, option metadata.AcceptProcessTemplateUpstreamOption) (*metadata.ProcessTemplate, errors.CCErrorCoder)
 	ListProcessTemplates(ctx ContextParams, option metadata.ListProcessTemplatesOption) (*metadata.MultipleProcessTemplate, errors.CCErrorCoder)
 	DeleteProcessTemplate(ctx ContextParams, processTemplateID int64) errors.CCErrorCoder
 
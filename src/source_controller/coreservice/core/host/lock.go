@@ -13,6 +13,8 @@
 package host
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,55 +28,99 @@ import (
 )
 
 func (hm *hostManager) LockHost(params core.ContextParams, input *metadata.HostLockRequest) errors.CCError {
-	fields := []string{common.BKHostIDField, common.BKHostInnerIPField}
-	condition := mapstr.MapStr{
-		common.BKCloudIDField:     input.CloudID,
-		common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: input.IPS},
+	hostInfos, err := hm.findLockTargetHosts(params, input.IDS, input.IPS, input.CloudID)
+	if err != nil {
+		return err
 	}
-	condition = util.SetQueryOwner(condition, params.SupplierAccount)
-	hostInfos := make([]mapstr.MapStr, 0)
-	limit := uint64(len(input.IPS))
-	err := hm.DbProxy.Table(common.BKTableNameBaseHost).Find(condition).Fields(fields...).Limit(limit).All(params.Context, &hostInfos)
-	if nil != err {
-		blog.Errorf("lock host, query host from db error, condition: %+v, err: %+v, rid: %s", condition, err, params.ReqID)
-		return params.Error.Errorf(common.CCErrCommDBSelectFailed)
+
+	hostIDs := make([]int64, 0)
+	for _, host := range hostInfos {
+		hostIDs = append(hostIDs, host.HostID)
 	}
 
-	diffIP := diffHostLockIP(input.IPS, hostInfos, params.ReqID)
-	if 0 != len(diffIP) {
-		blog.Errorf("lock host, not found, ip:%+v, rid:%s", diffIP, params.ReqID)
-		return params.Error.Errorf(common.CCErrCommParamsIsInvalid, " ip_list["+strings.Join(diffIP, ",")+"]")
+	// release the expired locks, so that they can be locked again
+	now := time.Now().UTC()
+	expiredCond := mapstr.MapStr{
+		common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs},
+		"expire_time":        mapstr.MapStr{common.BKDBLTE: now},
+	}
+	expiredCond = util.SetModOwner(expiredCond, params.SupplierAccount)
+	if err := hm.DbProxy.Table(common.BKTableNameHostLock).Delete(params.Context, expiredCond); err != nil {
+		blog.Errorf("lock host, delete expired host lock failed, condition: %+v, err: %+v, rid: %s", expiredCond, err, params.ReqID)
+		return params.Error.Errorf(common.CCErrCommDBDeleteFailed)
+	}
+
+	existLocks, err := hm.findActiveHostLock(params, mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDs}})
+	if err != nil {
+		return err
 	}
 
 	user := util.GetUser(params.Header)
-	var insertDataArr []interface{}
-	ts := time.Now().UTC()
-	for _, ip := range input.IPS {
-		conds := mapstr.MapStr{
-			common.BKHostInnerIPField: ip,
-			common.BKCloudIDField:     input.CloudID,
+	var expireTime *time.Time
+	if input.TTL > 0 {
+		expire := now.Add(time.Duration(input.TTL) * time.Second)
+		expireTime = &expire
+	}
+
+	lockedByOthers := make([]string, 0)
+	lockedBySelf := make([]int64, 0)
+	existLockMap := make(map[int64]bool)
+	for _, lock := range existLocks {
+		existLockMap[lock.HostID] = true
+		if lock.User != user {
+			lockedByOthers = append(lockedByOthers, fmt.Sprintf("%s(%s: %s)", lock.IP, lock.User, lock.Reason))
+			continue
 		}
-		conds = util.SetQueryOwner(conds, params.SupplierAccount)
-		cnt, err := hm.DbProxy.Table(common.BKTableNameHostLock).Find(conds).Count(params.Context)
-		if nil != err {
-			blog.Errorf("lock host, query host lock from db failed, err:%+v, rid:%s", err, params.ReqID)
-			return params.Error.Errorf(common.CCErrCommDBSelectFailed)
+		lockedBySelf = append(lockedBySelf, lock.HostID)
+	}
+	if len(lockedByOthers) > 0 {
+		blog.Errorf("lock host, hosts are locked by other users: %v, rid: %s", lockedByOthers, params.ReqID)
+		return params.Error.Errorf(common.CCErrCoreServiceHostLocked, strings.Join(lockedByOthers, ","))
+	}
+
+	// the user locks the hosts again, renew the reason and expire time of the lock
+	if len(lockedBySelf) > 0 {
+		renewCond := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: lockedBySelf}}
+		renewCond = util.SetModOwner(renewCond, params.SupplierAccount)
+		renewData := mapstr.MapStr{
+			"reason":      input.Reason,
+			"expire_time": expireTime,
+		}
+		if err := hm.DbProxy.Table(common.BKTableNameHostLock).Update(params.Context, renewCond, renewData); err != nil {
+			blog.Errorf("lock host, renew host lock failed, condition: %+v, err: %+v, rid: %s", renewCond, err, params.ReqID)
+			return params.Error.Errorf(common.CCErrCommDBUpdateFailed)
 		}
-		if 0 == cnt {
-			insertDataArr = append(insertDataArr, metadata.HostLockData{
-				User:       user,
-				IP:         ip,
-				CloudID:    input.CloudID,
-				CreateTime: ts,
-				OwnerID:    util.GetOwnerID(params.Header),
-			})
+	}
+
+	var insertDataArr []interface{}
+	for _, host := range hostInfos {
+		if existLockMap[host.HostID] {
+			continue
 		}
+		insertDataArr = append(insertDataArr, metadata.HostLockData{
+			User:       user,
+			HostID:     host.HostID,
+			IP:         host.InnerIP,
+			CloudID:    host.CloudID,
+			Reason:     input.Reason,
+			CreateTime: now,
+			ExpireTime: expireTime,
+			OwnerID:    util.GetOwnerID(params.Header),
+		})
 	}
 
 	if 0 < len(insertDataArr) {
 		err := hm.DbProxy.Table(common.BKTableNameHostLock).Insert(params.Context, insertDataArr)
 		if nil != err {
 			blog.Errorf("lock host, save host lock to db failed, err: %+v, rid:%s", err, params.ReqID)
+			// the host is locked by a concurrent request, which is rejected by the unique index of the host id
+			if hm.DbProxy.IsDuplicatedError(err) {
+				lockingIPs := make([]string, 0, len(insertDataArr))
+				for _, data := range insertDataArr {
+					lockingIPs = append(lockingIPs, data.(metadata.HostLockData).IP)
+				}
+				return params.Error.Errorf(common.CCErrCoreServiceHostLocked, strings.Join(lockingIPs, ","))
+			}
 			return params.Error.Errorf(common.CCErrCommDBInsertFailed)
 		}
 	}
@@ -82,10 +128,26 @@ func (hm *hostManager) LockHost(params core.ContextParams, input *metadata.HostL
 }
 
 func (hm *hostManager) UnlockHost(params core.ContextParams, input *metadata.HostLockRequest) errors.CCError {
-	conds := mapstr.MapStr{
-		common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: input.IPS},
-		common.BKCloudIDField:     input.CloudID,
+	conds := hostLockTargetCondition(input.IDS, input.IPS, input.CloudID)
+
+	if !input.Force {
+		locks, err := hm.findActiveHostLock(params, conds)
+		if err != nil {
+			return err
+		}
+		user := util.GetUser(params.Header)
+		lockedByOthers := make([]string, 0)
+		for _, lock := range locks {
+			if lock.User != user {
+				lockedByOthers = append(lockedByOthers, fmt.Sprintf("%s(%s)", lock.IP, lock.User))
+			}
+		}
+		if len(lockedByOthers) > 0 {
+			blog.Errorf("unlock host, hosts are locked by other users: %v, user: %s, rid: %s", lockedByOthers, user, params.ReqID)
+			return params.Error.CCErrorf(common.CCErrCoreServiceHostLockNotOwner, strings.Join(lockedByOthers, ","))
+		}
 	}
+
 	conds = util.SetModOwner(conds, params.SupplierAccount)
 	err := hm.DbProxy.Table(common.BKTableNameHostLock).Delete(params.Context, conds)
 	if nil != err {
@@ -97,14 +159,15 @@ func (hm *hostManager) UnlockHost(params core.ContextParams, input *metadata.Hos
 }
 
 func (hm *hostManager) QueryHostLock(params core.ContextParams, input *metadata.QueryHostLockRequest) ([]metadata.HostLockData, errors.CCError) {
+	return hm.findActiveHostLock(params, hostLockTargetCondition(input.IDS, input.IPS, input.CloudID))
+}
+
+// findActiveHostLock find the locks which are not expired
+func (hm *hostManager) findActiveHostLock(params core.ContextParams, conds mapstr.MapStr) ([]metadata.HostLockData, errors.CCError) {
 	hostLockInfoArr := make([]metadata.HostLockData, 0)
-	conds := mapstr.MapStr{
-		common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: input.IPS},
-		common.BKCloudIDField:     input.CloudID,
-	}
-	conds = util.SetModOwner(conds, params.SupplierAccount)
-	limit := uint64(len(input.IPS))
-	err := hm.DbProxy.Table(common.BKTableNameHostLock).Find(conds).Limit(limit).All(params.Context, &hostLockInfoArr)
+	conds = util.SetQueryOwner(conds, params.SupplierAccount)
+	conds.Merge(metadata.HostLockActiveCondition(time.Now().UTC()))
+	err := hm.DbProxy.Table(common.BKTableNameHostLock).Find(conds).All(params.Context, &hostLockInfoArr)
 	if nil != err {
 		blog.Errorf("query lock host, query host lock from db error, err: %+v, rid:%s", err, params.ReqID)
 		return nil, params.Error.CCErrorf(common.CCErrCommDBSelectFailed)
@@ -112,15 +175,67 @@ func (hm *hostManager) QueryHostLock(params core.ContextParams, input *metadata.
 	return hostLockInfoArr, nil
 }
 
-func diffHostLockIP(ips []string, hostInfos []mapstr.MapStr, rid string) []string {
+type lockTargetHost struct {
+	HostID  int64  `bson:"bk_host_id"`
+	InnerIP string `bson:"bk_host_innerip"`
+	CloudID int64  `bson:"bk_cloud_id"`
+}
+
+// findLockTargetHosts find the hosts to be locked by host id or by inner ip, all of them must exist
+func (hm *hostManager) findLockTargetHosts(params core.ContextParams, ids []int64, ips []string, cloudID int64) ([]lockTargetHost, errors.CCError) {
+	fields := []string{common.BKHostIDField, common.BKHostInnerIPField, common.BKCloudIDField}
+	condition := hostLockTargetCondition(ids, ips, cloudID)
+	condition = util.SetQueryOwner(condition, params.SupplierAccount)
+	hostInfos := make([]lockTargetHost, 0)
+	err := hm.DbProxy.Table(common.BKTableNameBaseHost).Find(condition).Fields(fields...).All(params.Context, &hostInfos)
+	if nil != err {
+		blog.Errorf("lock host, query host from db error, condition: %+v, err: %+v, rid: %s", condition, err, params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(ids) > 0 {
+		existIDs := make(map[int64]bool)
+		for _, host := range hostInfos {
+			existIDs[host.HostID] = true
+		}
+		diffID := make([]string, 0)
+		for _, id := range ids {
+			if !existIDs[id] {
+				diffID = append(diffID, strconv.FormatInt(id, 10))
+			}
+		}
+		if 0 != len(diffID) {
+			blog.Errorf("lock host, not found, id:%+v, rid:%s", diffID, params.ReqID)
+			return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, " id_list["+strings.Join(diffID, ",")+"]")
+		}
+		return hostInfos, nil
+	}
+
+	diffIP := diffHostLockIP(ips, hostInfos)
+	if 0 != len(diffIP) {
+		blog.Errorf("lock host, not found, ip:%+v, rid:%s", diffIP, params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsIsInvalid, " ip_list["+strings.Join(diffIP, ",")+"]")
+	}
+	return hostInfos, nil
+}
+
+// hostLockTargetCondition the hosts are specified by host id first, then by inner ip with cloud id
+func hostLockTargetCondition(ids []int64, ips []string, cloudID int64) mapstr.MapStr {
+	if len(ids) > 0 {
+		return mapstr.MapStr{
+			common.BKHostIDField: mapstr.MapStr{common.BKDBIN: ids},
+		}
+	}
+	return mapstr.MapStr{
+		common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: ips},
+		common.BKCloudIDField:     cloudID,
+	}
+}
+
+func diffHostLockIP(ips []string, hostInfos []lockTargetHost) []string {
 	mapInnerIP := make(map[string]bool, 0)
 	for _, hostInfo := range hostInfos {
-		innerIP, err := hostInfo.String(common.BKHostInnerIPField)
-		if nil != err {
-			blog.ErrorJSON("different host lock IP not inner ip, %s, rid: %s", hostInfo, rid)
-			continue
-		}
-		mapInnerIP[innerIP] = true
+		mapInnerIP[hostInfo.InnerIP] = true
 	}
 	var diffIPS []string
 	for _, ip := range ips {
@@ -13,10 +13,27 @@
 package host
 
 import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/instances"
 )
 
 func (hm *hostManager) ListHosts(ctx core.ContextParams, input metadata.ListHosts) (*metadata.ListHostResult, error) {
-	return hm.hostSearcher.ListHosts(ctx, input)
+	result, err := hm.hostSearcher.ListHosts(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]mapstr.MapStr, len(result.Info))
+	for index, host := range result.Info {
+		hosts[index] = host
+	}
+	if err := instances.FillReferenceDisplay(ctx, hm.DbProxy, common.BKInnerObjIDHost, hosts); err != nil {
+		// the display info is auxiliary, do not fail the search
+		blog.Warnf("list hosts, fill reference display info failed, err: %v, rid: %s", err, ctx.ReqID)
+	}
+	return result, nil
 }
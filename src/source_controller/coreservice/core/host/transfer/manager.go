@@ -13,6 +13,10 @@
 package transfer
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"gopkg.in/redis.v5"
 
 	"configcenter/src/common"
@@ -20,6 +24,7 @@ import (
 	"configcenter/src/common/condition"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/eventclient"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
@@ -60,6 +65,9 @@ func (manager *TransferManager) NewHostModuleTransfer(ctx core.ContextParams, bi
 
 // TransferHostToInnerModule transfer host to inner module, default module contain(idle module, fault module)
 func (manager *TransferManager) TransferToInnerModule(ctx core.ContextParams, input *metadata.TransferHostToInnerModule) ([]metadata.ExceptionResult, error) {
+	if err := manager.checkHostLocked(ctx, input.HostID); err != nil {
+		return nil, err
+	}
 
 	transfer := manager.NewHostModuleTransfer(ctx, input.ApplicationID, []int64{input.ModuleID}, false)
 
@@ -104,6 +112,10 @@ func (manager *TransferManager) TransferToInnerModule(ctx core.ContextParams, in
 // TransferHostModule transfer host to use add module
 // 目标模块不能为空闲机模块
 func (manager *TransferManager) TransferToNormalModule(ctx core.ContextParams, input *metadata.HostsModuleRelation) ([]metadata.ExceptionResult, error) {
+	if err := manager.checkHostLocked(ctx, input.HostID); err != nil {
+		return nil, err
+	}
+
 	// 确保目标模块不能为空闲机模块
 	defaultModuleFilter := map[string]interface{}{
 		common.BKDefaultField: map[string]interface{}{
@@ -212,6 +224,10 @@ func (manager *TransferManager) TransferToNormalModule(ctx core.ContextParams, i
 // 如果主机属于故障机模块，操作失败
 // 如果主机不在参数指定的模块中，操作失败
 func (manager *TransferManager) RemoveFromModule(ctx core.ContextParams, input *metadata.RemoveHostsFromModuleOption) ([]metadata.ExceptionResult, error) {
+	if err := manager.checkHostLocked(ctx, []int64{input.HostID}); err != nil {
+		return nil, err
+	}
+
 	hostConfigFilter := map[string]interface{}{
 		common.BKHostIDField: input.HostID,
 		common.BKAppIDField:  input.ApplicationID,
@@ -299,6 +315,10 @@ func (manager *TransferManager) RemoveFromModule(ctx core.ContextParams, input *
 
 // TransferHostCrossBusiness Host cross-business transfer
 func (manager *TransferManager) TransferToAnotherBusiness(ctx core.ContextParams, input *metadata.TransferHostsCrossBusinessRequest) ([]metadata.ExceptionResult, error) {
+	if err := manager.checkHostLocked(ctx, input.HostIDArr); err != nil {
+		return nil, err
+	}
+
 	// check whether there is service instance bound to hosts
 	serviceInstanceFilter := map[string]interface{}{
 		common.BKHostIDField: map[string]interface{}{
@@ -398,6 +418,9 @@ func (manager *TransferManager) GetHostModuleRelation(ctx core.ContextParams, in
 
 // DeleteHost delete host module relation and host info
 func (manager *TransferManager) DeleteFromSystem(ctx core.ContextParams, input *metadata.DeleteHostRequest) ([]metadata.ExceptionResult, error) {
+	if err := manager.checkHostLocked(ctx, input.HostIDArr); err != nil {
+		return nil, err
+	}
 
 	transfer := manager.NewHostModuleTransfer(ctx, input.ApplicationID, nil, false)
 	transfer.SetDeleteHost(ctx)
@@ -445,3 +468,31 @@ func (manager *TransferManager) getHostIDModuleMapByHostID(ctx core.ContextParam
 	}
 	return result, nil
 }
+
+// checkHostLocked the locked hosts are fenced by their lock owner, they can't be transferred or deleted
+func (manager *TransferManager) checkHostLocked(ctx core.ContextParams, hostIDArr []int64) errors.CCErrorCoder {
+	if len(hostIDArr) == 0 {
+		return nil
+	}
+	cond := mapstr.MapStr{
+		common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDArr},
+	}
+	cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+	cond.Merge(metadata.HostLockActiveCondition(time.Now().UTC()))
+
+	locks := make([]metadata.HostLockData, 0)
+	if err := manager.dbProxy.Table(common.BKTableNameHostLock).Find(cond).All(ctx.Context, &locks); err != nil {
+		blog.ErrorJSON("checkHostLocked failed, find host lock failed, cond: %s, err: %s, rid: %s", cond, err.Error(), ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(locks) == 0 {
+		return nil
+	}
+
+	lockedHosts := make([]string, 0)
+	for _, lock := range locks {
+		lockedHosts = append(lockedHosts, fmt.Sprintf("%s(%s: %s)", lock.IP, lock.User, lock.Reason))
+	}
+	blog.Errorf("checkHostLocked, hosts are locked: %v, rid: %s", lockedHosts, ctx.ReqID)
+	return ctx.Error.CCErrorf(common.CCErrCoreServiceHostLocked, strings.Join(lockedHosts, ","))
+}
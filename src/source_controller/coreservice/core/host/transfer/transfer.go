@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/instances"
 	"configcenter/src/storage/dal"
 )
 
@@ -158,6 +159,10 @@ func (t *genericTransfer) deleteHost(ctx core.ContextParams, hostID int64) (maps
 		blog.ErrorJSON("deleteHost delete host error. err:%s, cond:%s, rid:%s", err.Error(), hostCondMap, ctx.ReqID)
 		return nil, ctx.Error.CCErrorf(common.CCErrCommDBDeleteFailed)
 	}
+	if err := instances.ClearReferences(ctx, t.dbProxy, common.BKInnerObjIDHost, []int64{hostID}); err != nil {
+		blog.Errorf("deleteHost clear the references to host %d failed, err: %v, rid: %s", hostID, err, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrCommDBUpdateFailed)
+	}
 
 	return hostInfoArr[0], nil
 }
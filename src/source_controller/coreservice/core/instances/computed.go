@@ -0,0 +1,409 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"reflect"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// maxComputedParentDeep the max deep of the mainline parents a computed attribute can refer to, such as parent.parent.bk_biz_name
+const maxComputedParentDeep = 5
+
+// computedAttribute the computed attribute with the parsed expression
+type computedAttribute struct {
+	PropertyID string
+	// BizID the business of the attribute, 0 means the attribute is shared by all business
+	BizID int64
+	expr  *expression.Expression
+	// parentDeep how many levels of the mainline parents are referred by the expression
+	parentDeep int
+}
+
+// computedAttributeRecord the computed attribute stored in db
+type computedAttributeRecord struct {
+	PropertyID string                  `bson:"bk_property_id"`
+	Option     metadata.ComputedOption `bson:"option"`
+	Metadata   metadata.Metadata       `bson:"metadata"`
+}
+
+// getComputedAttributes get the computed attributes of the object, sorted by the dependency among them
+func (m *instanceManager) getComputedAttributes(ctx core.ContextParams, objID string) ([]computedAttribute, error) {
+	cond := map[string]interface{}{
+		common.BKObjIDField:        objID,
+		common.BKPropertyTypeField: common.FieldTypeComputed,
+	}
+	cond = util.SetModOwner(cond, ctx.SupplierAccount)
+	records := make([]computedAttributeRecord, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &records); err != nil {
+		blog.Errorf("get computed attributes of %s failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	attrMap := make(map[string]computedAttribute)
+	dependencies := make(map[string][]string)
+	for _, record := range records {
+		expr, err := expression.Parse(record.Option.Expression)
+		if err != nil {
+			blog.Warnf("computed attribute %s.%s has invalid expression %s, skip it, err: %v, rid: %s",
+				objID, record.PropertyID, record.Option.Expression, err, ctx.ReqID)
+			continue
+		}
+		attr := computedAttribute{PropertyID: record.PropertyID, expr: expr}
+		if bizID, err := metadata.BizIDFromMetadata(record.Metadata); err == nil {
+			attr.BizID = bizID
+		}
+		for _, variable := range expr.Variables() {
+			deep := 0
+			for strings.HasPrefix(variable, metadata.ComputedParentVariable+".") {
+				variable = strings.TrimPrefix(variable, metadata.ComputedParentVariable+".")
+				deep++
+			}
+			if deep > attr.parentDeep {
+				attr.parentDeep = deep
+			}
+		}
+		if attr.parentDeep > maxComputedParentDeep {
+			attr.parentDeep = maxComputedParentDeep
+		}
+		attrMap[record.PropertyID] = attr
+		dependencies[record.PropertyID] = expr.Variables()
+	}
+
+	sorted, err := expression.SortByDependency(dependencies)
+	if err != nil {
+		// the cycle is checked when the attribute is saved, should not happen
+		blog.Errorf("computed attributes of %s have dependency cycle, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return nil, err
+	}
+	attrs := make([]computedAttribute, 0, len(sorted))
+	for _, propertyID := range sorted {
+		attrs = append(attrs, attrMap[propertyID])
+	}
+	return attrs, nil
+}
+
+// getMainlineParentObjID get the parent object of the object in the mainline topology, empty if it's the top one
+func (m *instanceManager) getMainlineParentObjID(ctx core.ContextParams, objID string) (string, error) {
+	cond := map[string]interface{}{
+		common.BKObjIDField:           objID,
+		common.AssociationKindIDField: common.AssociationKindMainline,
+	}
+	asst := make([]metadata.Association, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond).All(ctx, &asst); err != nil {
+		blog.Errorf("get mainline parent of %s failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return "", err
+	}
+	if len(asst) == 0 {
+		return "", nil
+	}
+	return asst[0].AsstObjID, nil
+}
+
+// getMainlineChildObjID get the child object of the object in the mainline topology, empty if it's the bottom one
+func (m *instanceManager) getMainlineChildObjID(ctx core.ContextParams, objID string) (string, error) {
+	cond := map[string]interface{}{
+		common.BKAsstObjIDField:       objID,
+		common.AssociationKindIDField: common.AssociationKindMainline,
+	}
+	asst := make([]metadata.Association, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond).All(ctx, &asst); err != nil {
+		blog.Errorf("get mainline child of %s failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return "", err
+	}
+	if len(asst) == 0 {
+		return "", nil
+	}
+	return asst[0].ObjectID, nil
+}
+
+// computedParents the mainline parents of a level, keyed by the instance id
+type computedParents map[int64]mapstr.MapStr
+
+// loadComputedParents load the mainline parents of the instances level by level
+func (m *instanceManager) loadComputedParents(ctx core.ContextParams, objID string, insts []mapstr.MapStr, deep int) ([]computedParents, error) {
+	levels := make([]computedParents, 0)
+	current := insts
+	for level := 0; level < deep; level++ {
+		parentObjID, err := m.getMainlineParentObjID(ctx, objID)
+		if err != nil {
+			return nil, err
+		}
+		if parentObjID == "" {
+			break
+		}
+		parentIDs := make([]int64, 0)
+		for _, inst := range current {
+			if parentID, err := util.GetInt64ByInterface(inst[common.BKInstParentStr]); err == nil {
+				parentIDs = append(parentIDs, parentID)
+			}
+		}
+		if len(parentIDs) == 0 {
+			break
+		}
+
+		idField := common.GetInstIDField(parentObjID)
+		cond := map[string]interface{}{idField: map[string]interface{}{common.BKDBIN: util.IntArrayUnique(parentIDs)}}
+		if common.GetInstTableName(parentObjID) == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = parentObjID
+		}
+		parents := make([]mapstr.MapStr, 0)
+		if err := m.dbProxy.Table(common.GetInstTableName(parentObjID)).Find(cond).All(ctx, &parents); err != nil {
+			blog.Errorf("get mainline parents %s of %s failed, err: %v, rid: %s", parentObjID, objID, err, ctx.ReqID)
+			return nil, err
+		}
+		parentMap := make(computedParents)
+		for _, parent := range parents {
+			if parentID, err := util.GetInt64ByInterface(parent[idField]); err == nil {
+				parentMap[parentID] = parent
+			}
+		}
+		levels = append(levels, parentMap)
+		objID = parentObjID
+		current = parents
+	}
+	return levels, nil
+}
+
+// computedData build the data an expression is evaluated with, the parent is put in the "parent" field
+func computedData(inst mapstr.MapStr, levels []computedParents) map[string]interface{} {
+	data := make(map[string]interface{}, len(inst)+1)
+	for key, val := range inst {
+		data[key] = val
+	}
+	if len(levels) == 0 {
+		return data
+	}
+	parentID, err := util.GetInt64ByInterface(inst[common.BKInstParentStr])
+	if err != nil {
+		return data
+	}
+	if parent, ok := levels[0][parentID]; ok {
+		data[metadata.ComputedParentVariable] = computedData(parent, levels[1:])
+	}
+	return data
+}
+
+// evaluateComputedValues evaluate the computed attributes of the instances and set the values to the instances,
+// the value of an attribute failed to evaluate is null.
+func (m *instanceManager) evaluateComputedValues(ctx core.ContextParams, objID string, attrs []computedAttribute, insts []mapstr.MapStr) error {
+	if len(attrs) == 0 || len(insts) == 0 {
+		return nil
+	}
+	deep := 0
+	for _, attr := range attrs {
+		if attr.parentDeep > deep {
+			deep = attr.parentDeep
+		}
+	}
+	levels, err := m.loadComputedParents(ctx, objID, insts, deep)
+	if err != nil {
+		return err
+	}
+
+	for _, inst := range insts {
+		bizID, _ := FetchBizIDFromInstance(objID, inst)
+		data := computedData(inst, levels)
+		for _, attr := range attrs {
+			if attr.BizID != 0 && attr.BizID != bizID {
+				continue
+			}
+			val, err := attr.expr.Evaluate(data)
+			if err != nil {
+				blog.Warnf("evaluate computed attribute %s.%s failed, expression: %s, err: %v, rid: %s",
+					objID, attr.PropertyID, attr.expr, err, ctx.ReqID)
+				val = nil
+			}
+			inst[attr.PropertyID] = val
+			data[attr.PropertyID] = val
+		}
+	}
+	return nil
+}
+
+// fillComputedValues evaluate the computed attributes of the instances to be saved, the stored values are
+// returned by the search, and can be used to sort and filter the instances
+func (m *instanceManager) fillComputedValues(ctx core.ContextParams, objID string, insts ...mapstr.MapStr) error {
+	attrs, err := m.getComputedAttributes(ctx, objID)
+	if err != nil {
+		return err
+	}
+	return m.evaluateComputedValues(ctx, objID, attrs, insts)
+}
+
+// computedRefreshBatch the number of the instances refreshed in a batch when the computed attributes are changed
+const computedRefreshBatch = 500
+
+// RefreshComputedValues evaluate the computed attributes of all the instances of the model again, it's run by the
+// task_server task dispatched when a computed attribute is created or its expression is changed, so that the stored
+// values are backfilled in the background
+func (m *instanceManager) RefreshComputedValues(ctx core.ContextParams, objID string) error {
+	tableName := common.GetInstTableName(objID)
+	idField := common.GetInstIDField(objID)
+	var lastID int64
+	for {
+		cond := map[string]interface{}{idField: map[string]interface{}{common.BKDBGT: lastID}}
+		if tableName == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = objID
+		}
+		cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+		insts := make([]mapstr.MapStr, 0)
+		err := m.dbProxy.Table(tableName).Find(cond).Fields(idField).Sort(idField).Limit(computedRefreshBatch).All(ctx, &insts)
+		if err != nil {
+			blog.Errorf("refresh computed values, get %s instances after %d failed, err: %v, rid: %s", objID, lastID, err, ctx.ReqID)
+			return err
+		}
+		instIDs := make([]int64, 0, len(insts))
+		for _, inst := range insts {
+			if instID, err := util.GetInt64ByInterface(inst[idField]); err == nil {
+				instIDs = append(instIDs, instID)
+			}
+		}
+		if len(instIDs) == 0 {
+			return nil
+		}
+		if err := m.refreshComputedValues(ctx, objID, instIDs); err != nil {
+			return err
+		}
+		if len(insts) < computedRefreshBatch {
+			return nil
+		}
+		lastID = instIDs[len(instIDs)-1]
+	}
+}
+
+// refreshComputedValues evaluate the computed attributes of the updated instances again and save the changed
+// values, then refresh the mainline descendants which refer to their parents
+func (m *instanceManager) refreshComputedValues(ctx core.ContextParams, objID string, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return nil
+	}
+	attrs, err := m.getComputedAttributes(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if err := m.saveComputedValues(ctx, objID, attrs, instIDs); err != nil {
+		return err
+	}
+
+	// find the descendants which refer to the parents, the host is not a mainline instance with parent id
+	descendants := make([]string, 0)
+	descendantAttrs := make([][]computedAttribute, 0)
+	lastRefer := -1
+	for current := objID; len(descendants) < maxComputedParentDeep; {
+		childObjID, err := m.getMainlineChildObjID(ctx, current)
+		if err != nil {
+			return err
+		}
+		if childObjID == "" || childObjID == common.BKInnerObjIDHost {
+			break
+		}
+		childAttrs, err := m.getComputedAttributes(ctx, childObjID)
+		if err != nil {
+			return err
+		}
+		for _, attr := range childAttrs {
+			if attr.parentDeep > 0 {
+				lastRefer = len(descendants)
+				break
+			}
+		}
+		descendants = append(descendants, childObjID)
+		descendantAttrs = append(descendantAttrs, childAttrs)
+		current = childObjID
+	}
+
+	parentIDs := instIDs
+	for level := 0; level <= lastRefer && len(parentIDs) > 0; level++ {
+		childObjID := descendants[level]
+		childTable := common.GetInstTableName(childObjID)
+		childIDField := common.GetInstIDField(childObjID)
+		cond := map[string]interface{}{common.BKInstParentStr: map[string]interface{}{common.BKDBIN: parentIDs}}
+		if childTable == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = childObjID
+		}
+		children := make([]mapstr.MapStr, 0)
+		if err := m.dbProxy.Table(childTable).Find(cond).Fields(childIDField).All(ctx, &children); err != nil {
+			blog.Errorf("refresh computed values, get children %s of %s failed, err: %v, rid: %s", childObjID, objID, err, ctx.ReqID)
+			return err
+		}
+		childIDs := make([]int64, 0, len(children))
+		for _, child := range children {
+			if childID, err := util.GetInt64ByInterface(child[childIDField]); err == nil {
+				childIDs = append(childIDs, childID)
+			}
+		}
+		if err := m.saveComputedValues(ctx, childObjID, descendantAttrs[level], childIDs); err != nil {
+			return err
+		}
+		parentIDs = childIDs
+	}
+	return nil
+}
+
+// saveComputedValues evaluate the computed attributes of the instances and save the values which are changed
+func (m *instanceManager) saveComputedValues(ctx core.ContextParams, objID string, attrs []computedAttribute, instIDs []int64) error {
+	if len(attrs) == 0 || len(instIDs) == 0 {
+		return nil
+	}
+	tableName := common.GetInstTableName(objID)
+	idField := common.GetInstIDField(objID)
+	cond := map[string]interface{}{idField: map[string]interface{}{common.BKDBIN: instIDs}}
+	if tableName == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+	insts := make([]mapstr.MapStr, 0)
+	if err := m.dbProxy.Table(tableName).Find(cond).All(ctx, &insts); err != nil {
+		blog.Errorf("save computed values, get %s instances failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return err
+	}
+	origins := make([]mapstr.MapStr, len(insts))
+	for idx, inst := range insts {
+		origins[idx] = inst.Clone()
+	}
+	if err := m.evaluateComputedValues(ctx, objID, attrs, insts); err != nil {
+		return err
+	}
+
+	for idx, inst := range insts {
+		changed := mapstr.MapStr{}
+		for _, attr := range attrs {
+			if !reflect.DeepEqual(origins[idx][attr.PropertyID], inst[attr.PropertyID]) {
+				changed[attr.PropertyID] = inst[attr.PropertyID]
+			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		updateCond := map[string]interface{}{idField: inst[idField]}
+		if tableName == common.BKTableNameBaseInst {
+			updateCond[common.BKObjIDField] = objID
+		}
+		if err := m.dbProxy.Table(tableName).Update(ctx, updateCond, changed); err != nil {
+			blog.Errorf("save computed values of %s instance %v failed, err: %v, rid: %s", objID, inst[idField], err, ctx.ReqID)
+			return err
+		}
+	}
+	return nil
+}
@@ -62,6 +62,10 @@ func (m *instanceManager) CreateModelInstance(ctx core.ContextParams, objID stri
 		blog.Errorf("CreateModelInstance failed, valid error: %+v, rid: %s", err, rid)
 		return nil, err
 	}
+	if err := m.fillComputedValues(ctx, objID, inputParam.Data); err != nil {
+		blog.Errorf("CreateModelInstance failed, fill computed values failed, err: %v, rid: %s", err, rid)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
 	id, err := m.save(ctx, objID, inputParam.Data)
 	if err != nil {
 		blog.ErrorJSON("CreateModelInstance create objID(%s) instance error. err:%s, data:%s, rid:%s", objID, err.Error(), inputParam.Data, ctx.ReqID)
@@ -82,6 +86,11 @@ func (m *instanceManager) CreateModelInstance(ctx core.ContextParams, objID stri
 func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID string, inputParam metadata.CreateManyModelInstance) (*metadata.CreateManyDataResult, error) {
 	var newIDs []uint64
 	dataResult := &metadata.CreateManyDataResult{}
+	computedAttrs, err := m.getComputedAttributes(ctx, objID)
+	if err != nil {
+		blog.Errorf("CreateManyModelInstance failed, get computed attributes failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
 	for itemIdx, item := range inputParam.Datas {
 		item.Set(common.BKOwnerIDField, ctx.SupplierAccount)
 		err := m.validCreateInstanceData(ctx, objID, item)
@@ -94,6 +103,17 @@ func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID
 			})
 			continue
 		}
+		if err := m.evaluateComputedValues(ctx, objID, computedAttrs, []mapstr.MapStr{item}); err != nil {
+			blog.Errorf("CreateManyModelInstance failed, fill computed values failed, err: %v, rid: %s", err, ctx.ReqID)
+			ccErr := ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     ccErr.Error(),
+				Code:        int64(ccErr.GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
 		item.Set(common.BKOwnerIDField, ctx.SupplierAccount)
 		id, err := m.save(ctx, objID, item)
 		if nil != err {
@@ -115,7 +135,7 @@ func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID
 	instIDFieldName := common.GetInstIDField(objID)
 	// 处理事件数据的
 	eh := m.NewEventClient(objID)
-	err := eh.SetCurDataAndPush(ctx, objID, metadata.EventActionCreate, condition.CreateCondition().Field(instIDFieldName).In(newIDs).ToMapStr())
+	err = eh.SetCurDataAndPush(ctx, objID, metadata.EventActionCreate, condition.CreateCondition().Field(instIDFieldName).In(newIDs).ToMapStr())
 	if err != nil {
 		blog.ErrorJSON("CreateManyModelInstance  event push instance current data error. err:%s, objID:%s inst id:%s, rid:%s", err, objID, newIDs, ctx.ReqID)
 		return dataResult, err
@@ -155,9 +175,11 @@ func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID stri
 		inputParam.Condition.Set(metadata.BKMetadata, instMedataData)
 	}
 
+	instIDs := make([]int64, 0, len(origins))
 	for _, origin := range origins {
 		instIDI := origin[instIDFieldName]
 		instID, _ := util.GetInt64ByInterface(instIDI)
+		instIDs = append(instIDs, instID)
 		err := m.validUpdateInstanceData(ctx, objID, inputParam.Data, instMedataData, uint64(instID))
 		if nil != err {
 			blog.Errorf("update model instance validate error :%v ,rid:%s", err, ctx.ReqID)
@@ -176,6 +198,10 @@ func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID stri
 		blog.ErrorJSON("UpdateModelInstance update objID(%s) inst error. err:%s, condition:%s, rid:%s", objID, inputParam.Condition, ctx.ReqID)
 		return nil, err
 	}
+	if err := m.refreshComputedValues(ctx, objID, instIDs); err != nil {
+		blog.Errorf("UpdateModelInstance refresh computed values of %s instances %v failed, err: %v, rid: %s", objID, instIDs, err, ctx.ReqID)
+		return nil, ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+	}
 	err = eh.SetCurDataAndPush(ctx, objID, metadata.EventActionUpdate, inputParam.Condition)
 	if err != nil {
 		blog.ErrorJSON("UpdateModelInstance  event push instance current data error. err:%s, condition:%s, rid:%s", err, inputParam.Condition, ctx.ReqID)
@@ -208,6 +234,10 @@ func (m *instanceManager) SearchModelInstance(ctx core.ContextParams, objID stri
 		blog.Errorf("count instance error [%v], rid: %s", err, ctx.ReqID)
 		return &metadata.QueryResult{}, err
 	}
+	if err := m.fillReferenceDisplay(ctx, objID, instItems); err != nil {
+		// the display info is auxiliary, do not fail the search
+		blog.Warnf("search instance, fill reference display info failed, err: %v, rid: %s", err, ctx.ReqID)
+	}
 	dataResult.Info = instItems
 
 	return dataResult, nil
@@ -225,11 +255,13 @@ func (m *instanceManager) DeleteModelInstance(ctx core.ContextParams, objID stri
 	// 处理事件数据的
 	eh := m.NewEventClient(objID)
 
+	instIDs := make([]int64, 0)
 	for _, origin := range origins {
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
 			return nil, err
 		}
+		instIDs = append(instIDs, instID)
 		exists, err := m.dependent.IsInstAsstExist(ctx, objID, uint64(instID))
 		if nil != err {
 			return nil, err
@@ -244,6 +276,9 @@ func (m *instanceManager) DeleteModelInstance(ctx core.ContextParams, objID stri
 		blog.ErrorJSON("DeleteModelInstance delete objID(%s) instance error. err:%s, coniditon:%s, rid:%s", objID, err.Error(), inputParam.Condition, ctx.ReqID)
 		return &metadata.DeletedCount{}, err
 	}
+	if err := ClearReferences(ctx, m.dbProxy, objID, instIDs); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
 	err = eh.Push(ctx, objID, metadata.EventActionDelete)
 	if err != nil {
 		blog.ErrorJSON("DeleteModelInstance push delete objType(%s) instance to event server error. data:%s, rid:%s", objID, origins, ctx.ReqID)
@@ -263,11 +298,13 @@ func (m *instanceManager) CascadeDeleteModelInstance(ctx core.ContextParams, obj
 		return &metadata.DeletedCount{}, err
 	}
 
+	instIDs := make([]int64, 0)
 	for _, origin := range origins {
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
 			return &metadata.DeletedCount{}, err
 		}
+		instIDs = append(instIDs, instID)
 		err = m.dependent.DeleteInstAsst(ctx, objID, uint64(instID))
 		if nil != err {
 			return &metadata.DeletedCount{}, err
@@ -278,5 +315,8 @@ func (m *instanceManager) CascadeDeleteModelInstance(ctx core.ContextParams, obj
 	if nil != err {
 		return &metadata.DeletedCount{}, err
 	}
+	if err := ClearReferences(ctx, m.dbProxy, objID, instIDs); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
 	return &metadata.DeletedCount{Count: uint64(len(origins))}, nil
 }
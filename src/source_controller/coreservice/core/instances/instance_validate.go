@@ -135,32 +135,12 @@ func (m *instanceManager) validCreateInstanceData(ctx core.ContextParams, objID
 			// blog.Errorf("field [%s] is not a valid property for model [%s], rid: %s", key, objID, ctx.ReqID)
 			// return valid.errif.CCErrorf(common.CCErrCommParamsIsInvalid, key)
 		}
-		fieldType := property.PropertyType
-		switch fieldType {
-		case common.FieldTypeSingleChar:
-			err = valid.validChar(ctx.Context, val, key)
-		case common.FieldTypeLongChar:
-			err = valid.validLongChar(ctx.Context, val, key)
-		case common.FieldTypeInt:
-			err = valid.validInt(ctx.Context, val, key)
-		case common.FieldTypeFloat:
-			err = valid.validFloat(ctx.Context, val, key)
-		case common.FieldTypeEnum:
-			err = valid.validEnum(ctx.Context, val, key)
-		case common.FieldTypeDate:
-			err = valid.validDate(ctx.Context, val, key)
-		case common.FieldTypeTime:
-			err = valid.validTime(ctx.Context, val, key)
-		case common.FieldTypeTimeZone:
-			err = valid.validTimeZone(ctx.Context, val, key)
-		case common.FieldTypeBool:
-			err = valid.validBool(ctx.Context, val, key)
-	    case common.FieldTypeList:
-			err = valid.validList(ctx.Context, val, key)
-		default:
+		if property.PropertyType == common.FieldTypeComputed {
+			// the value of computed attribute is evaluated from the expression, the value set is ignored
+			delete(instanceData, key)
 			continue
 		}
-		if nil != err {
+		if err = valid.validValue(ctx.Context, property, key, val); nil != err {
 			return err
 		}
 	}
@@ -177,6 +157,10 @@ func (m *instanceManager) validCreateInstanceData(ctx core.ContextParams, objID
 			return err
 		}
 	}
+	if err := m.validReferenceExist(ctx, valid, instanceData); err != nil {
+		return err
+	}
+
 	return valid.validCreateUnique(ctx, instanceData, instMedataData, m)
 }
 
@@ -252,32 +236,12 @@ func (m *instanceManager) validUpdateInstanceData(ctx core.ContextParams, objID
 			delete(instanceData, key)
 			continue
 		}
-		fieldType := property.PropertyType
-		switch fieldType {
-		case common.FieldTypeSingleChar:
-			err = valid.validChar(ctx.Context, val, key)
-		case common.FieldTypeLongChar:
-			err = valid.validLongChar(ctx.Context, val, key)
-		case common.FieldTypeInt:
-			err = valid.validInt(ctx.Context, val, key)
-		case common.FieldTypeFloat:
-			err = valid.validFloat(ctx.Context, val, key)
-		case common.FieldTypeEnum:
-			err = valid.validEnum(ctx.Context, val, key)
-		case common.FieldTypeDate:
-			err = valid.validDate(ctx.Context, val, key)
-		case common.FieldTypeTime:
-			err = valid.validTime(ctx.Context, val, key)
-		case common.FieldTypeTimeZone:
-			err = valid.validTimeZone(ctx.Context, val, key)
-		case common.FieldTypeBool:
-			err = valid.validBool(ctx.Context, val, key)
-		case common.FieldTypeList:
-			err = valid.validList(ctx.Context, val, key)
-		default:
+		if property.PropertyType == common.FieldTypeComputed {
+			// the value of computed attribute is evaluated from the expression, the value set is ignored
+			delete(instanceData, key)
 			continue
 		}
-		if nil != err {
+		if err = valid.validValue(ctx.Context, property, key, val); nil != err {
 			return err
 		}
 	}
@@ -298,5 +262,9 @@ func (m *instanceManager) validUpdateInstanceData(ctx core.ContextParams, objID
 		}
 	}
 
+	if err := m.validReferenceExist(ctx, valid, instanceData); err != nil {
+		return err
+	}
+
 	return valid.validUpdateUnique(ctx, updateData, instMetaData, instID, m)
 }
@@ -0,0 +1,217 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+// referenceAttribute the reference attribute with the parsed option
+type referenceAttribute struct {
+	ObjectID   string                   `bson:"bk_obj_id"`
+	PropertyID string                   `bson:"bk_property_id"`
+	Option     metadata.ReferenceOption `bson:"option"`
+}
+
+// validReference valid object attribute that is reference type, the value is the instance id of the target object
+func (valid *validator) validReference(ctx context.Context, val interface{}, key string) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if nil == val {
+		if valid.require[key] {
+			blog.Errorf("params key: %s can not be null, rid: %s", key, rid)
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	if !valid.isNumeric(val) {
+		blog.Errorf("params %s:%#v not int, rid: %s", key, val, rid)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedInt, key)
+	}
+	if _, err := util.GetInt64ByInterface(val); err != nil {
+		blog.Errorf("params %s:%#v not int, rid: %s", key, val, rid)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedInt, key)
+	}
+
+	property, ok := valid.propertys[key]
+	if !ok {
+		return nil
+	}
+	if _, err := ParseReferenceOption(ctx, property.Option); err != nil {
+		blog.Errorf("params %s option %#v is not a reference option, err: %v, rid: %s", key, property.Option, err, rid)
+		return valid.errif.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+	return nil
+}
+
+// validReferenceExist check the instances referred by the reference attributes exist
+func (m *instanceManager) validReferenceExist(ctx core.ContextParams, valid *validator, instanceData mapstr.MapStr) error {
+	for key, val := range instanceData {
+		property, ok := valid.propertys[key]
+		if !ok || property.PropertyType != common.FieldTypeReference || nil == val {
+			continue
+		}
+		option, err := ParseReferenceOption(ctx, property.Option)
+		if err != nil {
+			return valid.errif.CCErrorf(common.CCErrCommParamsInvalid, key)
+		}
+		instID, err := util.GetInt64ByInterface(val)
+		if err != nil {
+			return valid.errif.Errorf(common.CCErrCommParamsNeedInt, key)
+		}
+
+		cond := mapstr.MapStr{common.GetInstIDField(option.ObjectID): instID}
+		if common.GetInstTableName(option.ObjectID) == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = option.ObjectID
+		}
+		cnt, err := m.countInstance(ctx, option.ObjectID, cond)
+		if err != nil {
+			blog.Errorf("valid reference %s failed, count %s instance %d failed, err: %v, rid: %s", key, option.ObjectID, instID, err, ctx.ReqID)
+			return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+		}
+		if cnt == 0 {
+			blog.Errorf("valid reference %s failed, %s instance %d not exist, rid: %s", key, option.ObjectID, instID, ctx.ReqID)
+			return valid.errif.CCErrorf(common.CCErrCommParamsInvalid, key)
+		}
+	}
+	return nil
+}
+
+// getReferenceAttributes get the reference attributes of the objects, or the reference attributes referring to
+// the target object if the target object is set
+func getReferenceAttributes(ctx core.ContextParams, db dal.RDB, objID, targetObjID string) ([]referenceAttribute, error) {
+	cond := mapstr.MapStr{
+		common.BKPropertyTypeField: common.FieldTypeReference,
+	}
+	if objID != "" {
+		cond[common.BKObjIDField] = objID
+	}
+	if targetObjID != "" {
+		cond[metadata.AttributeFieldOption+"."+common.BKObjIDField] = targetObjID
+	}
+	attrs := make([]referenceAttribute, 0)
+	if err := db.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &attrs); err != nil {
+		blog.Errorf("get reference attributes failed, condition: %#v, err: %v, rid: %s", cond, err, ctx.ReqID)
+		return nil, err
+	}
+	return attrs, nil
+}
+
+// fillReferenceDisplay add the display info of the reference attributes to the instances
+func (m *instanceManager) fillReferenceDisplay(ctx core.ContextParams, objID string, insts []mapstr.MapStr) error {
+	return FillReferenceDisplay(ctx, m.dbProxy, objID, insts)
+}
+
+// FillReferenceDisplay add the display info of the reference attributes to the instances of the object,
+// it's used by the searches that read the instance tables directly, like the host searches.
+func FillReferenceDisplay(ctx core.ContextParams, db dal.RDB, objID string, insts []mapstr.MapStr) error {
+	if len(insts) == 0 {
+		return nil
+	}
+	attrs, err := getReferenceAttributes(ctx, db, objID, "")
+	if err != nil {
+		return err
+	}
+
+	for _, attr := range attrs {
+		instIDs := make([]int64, 0)
+		for _, inst := range insts {
+			if instID, err := util.GetInt64ByInterface(inst[attr.PropertyID]); err == nil {
+				instIDs = append(instIDs, instID)
+			}
+		}
+		if len(instIDs) == 0 {
+			continue
+		}
+
+		targetObjID := attr.Option.ObjectID
+		idField := common.GetInstIDField(targetObjID)
+		nameField := referenceNameField(targetObjID)
+		cond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: util.IntArrayUnique(instIDs)}}
+		if common.GetInstTableName(targetObjID) == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = targetObjID
+		}
+		targets := make([]mapstr.MapStr, 0)
+		err := db.Table(common.GetInstTableName(targetObjID)).Find(cond).Fields(idField, nameField).All(ctx, &targets)
+		if err != nil {
+			blog.Errorf("get referred %s instances failed, condition: %#v, err: %v, rid: %s", targetObjID, cond, err, ctx.ReqID)
+			return err
+		}
+		names := make(map[int64]string)
+		for _, target := range targets {
+			targetID, err := util.GetInt64ByInterface(target[idField])
+			if err != nil {
+				continue
+			}
+			names[targetID] = util.GetStrByInterface(target[nameField])
+		}
+
+		for _, inst := range insts {
+			instID, err := util.GetInt64ByInterface(inst[attr.PropertyID])
+			if err != nil {
+				continue
+			}
+			display, ok := inst[metadata.ReferenceDisplayField].(map[string]metadata.ReferenceDisplay)
+			if !ok {
+				display = make(map[string]metadata.ReferenceDisplay)
+				inst[metadata.ReferenceDisplayField] = display
+			}
+			display[attr.PropertyID] = metadata.ReferenceDisplay{
+				ObjectID: targetObjID,
+				InstID:   instID,
+				InstName: names[instID],
+			}
+		}
+	}
+	return nil
+}
+
+// ClearReferences set the reference attributes referring to the deleted instances to null
+func ClearReferences(ctx core.ContextParams, db dal.RDB, targetObjID string, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return nil
+	}
+	attrs, err := getReferenceAttributes(ctx, db, "", targetObjID)
+	if err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		cond := mapstr.MapStr{attr.PropertyID: mapstr.MapStr{common.BKDBIN: instIDs}}
+		if common.GetInstTableName(attr.ObjectID) == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = attr.ObjectID
+		}
+		data := mapstr.MapStr{attr.PropertyID: nil}
+		if err := db.Table(common.GetInstTableName(attr.ObjectID)).Update(ctx, cond, data); err != nil {
+			blog.Errorf("clear reference %s.%s to deleted %s instances %v failed, err: %v, rid: %s",
+				attr.ObjectID, attr.PropertyID, targetObjID, instIDs, err, ctx.ReqID)
+			return err
+		}
+	}
+	return nil
+}
+
+// referenceNameField the field shown as the name of the referred instance
+func referenceNameField(objID string) string {
+	if objID == common.BKInnerObjIDHost {
+		return common.BKHostInnerIPField
+	}
+	return common.GetInstNameField(objID)
+}
@@ -13,7 +13,10 @@
 package instances
 
 import (
+	"context"
+
 	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
@@ -59,3 +62,53 @@ func NewValidator(ctx core.ContextParams, dependent OperationDependences, objID
 	valid.dependent = dependent
 	return valid, nil
 }
+
+// ValidValue validate the value of the property as the instance is created or updated,
+// the value of the computed property is ignored like the instance data, it's evaluated from the expression.
+func (valid *validator) ValidValue(ctx core.ContextParams, key string, val interface{}) error {
+	property, ok := valid.propertys[key]
+	if !ok {
+		blog.Errorf("field [%s] is not a valid property for model [%s], rid: %s", key, valid.objID, ctx.ReqID)
+		return valid.errif.CCErrorf(common.CCErrCommParamsIsInvalid, key)
+	}
+	if property.PropertyType == common.FieldTypeComputed {
+		return nil
+	}
+	return valid.validValue(ctx.Context, property, key, val)
+}
+
+// validValue validate the value by the property type
+func (valid *validator) validValue(ctx context.Context, property metadata.Attribute, key string, val interface{}) error {
+	switch property.PropertyType {
+	case common.FieldTypeSingleChar:
+		return valid.validChar(ctx, val, key)
+	case common.FieldTypeLongChar:
+		return valid.validLongChar(ctx, val, key)
+	case common.FieldTypeInt:
+		return valid.validInt(ctx, val, key)
+	case common.FieldTypeFloat:
+		return valid.validFloat(ctx, val, key)
+	case common.FieldTypeEnum:
+		return valid.validEnum(ctx, val, key)
+	case common.FieldTypeDate:
+		return valid.validDate(ctx, val, key)
+	case common.FieldTypeTime:
+		return valid.validTime(ctx, val, key)
+	case common.FieldTypeTimeZone:
+		return valid.validTimeZone(ctx, val, key)
+	case common.FieldTypeBool:
+		return valid.validBool(ctx, val, key)
+	case common.FieldTypeList:
+		return valid.validList(ctx, val, key)
+	case common.FieldTypeIP:
+		return valid.validIP(ctx, val, key)
+	case common.FieldTypeCIDR:
+		return valid.validCIDR(ctx, val, key)
+	case common.FieldTypeEnumMulti:
+		return valid.validEnumMulti(ctx, val, key)
+	case common.FieldTypeReference:
+		return valid.validReference(ctx, val, key)
+	default:
+		return nil
+	}
+}
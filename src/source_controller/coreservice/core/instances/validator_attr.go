@@ -415,6 +415,111 @@ func (valid *validator) validList(ctx context.Context, val interface{}, key stri
 	return nil
 }
 
+// validIP valid object attribute that is ip type
+func (valid *validator) validIP(ctx context.Context, val interface{}, key string) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if nil == val || "" == val {
+		if valid.require[key] {
+			blog.Errorf("params key: %s can not be null, rid: %s", key, rid)
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	valStr, ok := val.(string)
+	if !ok {
+		blog.Errorf("params key: %s should be string, rid: %s", key, rid)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedString, key)
+	}
+	if !util.IsIP(valStr) {
+		blog.Errorf("params key: %s, value: %s is not a valid ip, rid: %s", key, valStr, rid)
+		return valid.errif.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+	return nil
+}
+
+// validCIDR valid object attribute that is cidr type
+func (valid *validator) validCIDR(ctx context.Context, val interface{}, key string) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if nil == val || "" == val {
+		if valid.require[key] {
+			blog.Errorf("params key: %s can not be null, rid: %s", key, rid)
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	valStr, ok := val.(string)
+	if !ok {
+		blog.Errorf("params key: %s should be string, rid: %s", key, rid)
+		return valid.errif.Errorf(common.CCErrCommParamsNeedString, key)
+	}
+	if !util.IsCIDR(valStr) {
+		blog.Errorf("params key: %s, value: %s is not a valid cidr, rid: %s", key, valStr, rid)
+		return valid.errif.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+	return nil
+}
+
+// validEnumMulti valid object attribute that is multi-select enum type, the value is an array of option ids
+func (valid *validator) validEnumMulti(ctx context.Context, val interface{}, key string) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if nil == val {
+		if valid.require[key] {
+			blog.Errorf("params key: %s can not be null, rid: %s", key, rid)
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	// validate type
+	values, ok := val.([]interface{})
+	if !ok {
+		blog.Errorf("params key: %s should be array, value: %#v, rid: %s", key, val, rid)
+		return valid.errif.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+	if 0 == len(values) {
+		if valid.require[key] {
+			blog.Errorf("params key: %s can not be empty, rid: %s", key, rid)
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	option, ok := valid.propertys[key]
+	if !ok {
+		return nil
+	}
+	// validate within enum
+	enumOption, err := ParseEnumOption(ctx, option.Option)
+	if err != nil {
+		blog.Warnf("ParseEnumOption failed: %v, rid: %s", err, rid)
+		return valid.errif.CCErrorf(common.CCErrCommParamsInvalid, key)
+	}
+	selected := make(map[string]bool)
+	for _, value := range values {
+		valStr, ok := value.(string)
+		if !ok || selected[valStr] {
+			blog.Errorf("params %s not valid, value %#v is not string or duplicated, rid: %s", key, value, rid)
+			return valid.errif.CCErrorf(common.CCErrCommParamsInvalid, key)
+		}
+		selected[valStr] = true
+
+		match := false
+		for _, k := range enumOption {
+			if k.ID == valStr {
+				match = true
+				break
+			}
+		}
+		if !match {
+			blog.Errorf("params %s not valid, option %#v, raw option %#v, value: %#v, rid: %s", key, enumOption, option, val, rid)
+			return valid.errif.CCErrorf(common.CCErrCommParamsInvalid, key)
+		}
+	}
+	return nil
+}
+
 // isNumeric judges if value is a number
 func (valid *validator) isNumeric(val interface{}) bool {
 	switch val.(type) {
@@ -144,6 +144,34 @@ func ParseEnumOption(ctx context.Context, val interface{}) (EnumOption, error) {
 	return enumOptions, nil
 }
 
+// ParseReferenceOption parse the target object of the reference attribute from the option
+func ParseReferenceOption(ctx context.Context, val interface{}) (metadata.ReferenceOption, error) {
+	refOption := metadata.ReferenceOption{}
+	switch option := val.(type) {
+	case metadata.ReferenceOption:
+		refOption = option
+	case string:
+		refOption.ObjectID = gjson.Get(option, common.BKObjIDField).String()
+	case map[string]interface{}:
+		refOption.ObjectID = getString(option[common.BKObjIDField])
+	case mapstr.MapStr:
+		refOption.ObjectID = getString(option[common.BKObjIDField])
+	case mgobson.M:
+		refOption.ObjectID = getString(option[common.BKObjIDField])
+	case bson.M:
+		refOption.ObjectID = getString(option[common.BKObjIDField])
+	case bson.D:
+		opt := option.Map()
+		refOption.ObjectID = getString(opt[common.BKObjIDField])
+	default:
+		return refOption, fmt.Errorf("unknow val type: %#v", val)
+	}
+	if refOption.ObjectID == "" {
+		return refOption, fmt.Errorf("reference option %#v has no bk_obj_id", val)
+	}
+	return refOption, nil
+}
+
 // parseIntOption  parse int data in option
 func parseIntOption(ctx context.Context, val interface{}) IntOption {
 	rid := util.ExtractRequestIDFromContext(ctx)
@@ -243,6 +271,24 @@ func FillLostedFieldValue(ctx context.Context, valData mapstr.MapStr, propertys
 				} else {
 					valData[field.PropertyID] = nil
 				}
+			case common.FieldTypeEnumMulti:
+				enumOptions, err := ParseEnumOption(ctx, field.Option)
+				if err != nil {
+					blog.Warnf("ParseEnumOption failed: %v, rid: %s", err, rid)
+					valData[field.PropertyID] = nil
+					continue
+				}
+				defaultOptions := make([]interface{}, 0)
+				for _, k := range enumOptions {
+					if k.IsDefault {
+						defaultOptions = append(defaultOptions, k.ID)
+					}
+				}
+				if len(defaultOptions) > 0 {
+					valData[field.PropertyID] = defaultOptions
+				} else {
+					valData[field.PropertyID] = nil
+				}
 			case common.FieldTypeDate:
 				valData[field.PropertyID] = nil
 			case common.FieldTypeTime:
@@ -0,0 +1,102 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.,
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the ",License",); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an ",AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/expression"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// computedAttribute the computed attribute with the parsed option
+type computedAttribute struct {
+	PropertyID string                  `bson:"bk_property_id"`
+	Option     metadata.ComputedOption `bson:"option"`
+}
+
+// computedBuiltinFields the fields can be referred by the expression but are not attributes of the model
+var computedBuiltinFields = []string{
+	common.BKInstParentStr,
+	common.BKObjIDField,
+	common.CreateTimeField,
+	common.LastTimeField,
+}
+
+// checkComputedAttribute check the expression of the computed attribute, the variables must be the attributes
+// of the model except those refer to the mainline parent, and the computed attributes must not refer to each other in a cycle
+func (m *modelAttribute) checkComputedAttribute(ctx core.ContextParams, objID, propertyID string, option interface{}) error {
+	computedOption := metadata.ComputedOption{}
+	if optionJSON, err := json.Marshal(option); err == nil {
+		_ = json.Unmarshal(optionJSON, &computedOption)
+	}
+	expr, err := expression.Parse(computedOption.Expression)
+	if err != nil {
+		blog.Errorf("computed attribute %s.%s has invalid expression %s, err: %v, rid: %s", objID, propertyID, computedOption.Expression, err, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+
+	cond := util.SetModOwner(map[string]interface{}{common.BKObjIDField: objID}, ctx.SupplierAccount)
+	attrs := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).Fields(common.BKPropertyIDField).All(ctx, &attrs); err != nil {
+		blog.Errorf("check computed attribute, get attributes of %s failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	fields := map[string]bool{common.GetInstIDField(objID): true}
+	for _, field := range computedBuiltinFields {
+		fields[field] = true
+	}
+	for _, attr := range attrs {
+		fields[attr.PropertyID] = true
+	}
+
+	cond[common.BKPropertyTypeField] = common.FieldTypeComputed
+	computedAttrs := make([]computedAttribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &computedAttrs); err != nil {
+		blog.Errorf("check computed attribute, get computed attributes of %s failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	dependencies := make(map[string][]string)
+	for _, attr := range computedAttrs {
+		attrExpr, err := expression.Parse(attr.Option.Expression)
+		if err != nil {
+			blog.Warnf("computed attribute %s.%s has invalid expression %s, err: %v, rid: %s", objID, attr.PropertyID, attr.Option.Expression, err, ctx.ReqID)
+			continue
+		}
+		dependencies[attr.PropertyID] = attrExpr.Variables()
+	}
+
+	// the attribute may be created in this request, so it's not in the db yet
+	fields[propertyID] = true
+	for _, variable := range expr.Variables() {
+		if strings.HasPrefix(variable, metadata.ComputedParentVariable+".") {
+			// the fields of the parent are not checked, the mainline topology may change
+			continue
+		}
+		if !fields[variable] {
+			blog.Errorf("computed attribute %s.%s refers to unknown field %s, rid: %s", objID, propertyID, variable, ctx.ReqID)
+			return ctx.Error.Errorf(common.CCErrCoreServiceComputedAttrUnknownField, variable)
+		}
+	}
+	dependencies[propertyID] = expr.Variables()
+	if cycle := expression.FindCycle(dependencies, propertyID); cycle != nil {
+		blog.Errorf("computed attribute %s.%s has dependency cycle %v, rid: %s", objID, propertyID, cycle, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrCoreServiceComputedAttrCycle, strings.Join(cycle, " -> "))
+	}
+	return nil
+}
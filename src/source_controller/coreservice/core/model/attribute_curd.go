@@ -81,7 +81,22 @@ func (m *modelAttribute) save(ctx core.ContextParams, attribute metadata.Attribu
 	}
 
 	err = m.dbProxy.Table(common.BKTableNameObjAttDes).Insert(ctx, attribute)
-	return id, err
+	if err != nil {
+		return id, err
+	}
+	if attribute.PropertyType == common.FieldTypeComputed {
+		m.refreshComputedValues(ctx, attribute.ObjectID)
+	}
+	return id, nil
+}
+
+// refreshComputedValues backfill the computed values of the existing instances of the model by the task_server
+// task in the background, the stored values are stale until the task is done. the failure is only logged, the
+// values are refreshed as the instances are updated.
+func (m *modelAttribute) refreshComputedValues(ctx core.ContextParams, objID string) {
+	if err := m.model.dependent.DispatchComputedValuesRefresh(ctx, objID); err != nil {
+		blog.Errorf("dispatch refresh computed values of %s instances failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+	}
 }
 
 func (m *modelAttribute) checkUnique(ctx core.ContextParams, isCreate bool, objID, propertyID, propertyName string, meta metadata.Metadata) error {
@@ -188,7 +203,9 @@ func (m *modelAttribute) checkAttributeValidity(ctx core.ContextParams, attribut
 	if attribute.PropertyType != "" {
 		switch attribute.PropertyType {
 		case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeEnum,
-			common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeUser, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList:
+			common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeUser, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList,
+			common.FieldTypeIP, common.FieldTypeCIDR, common.FieldTypeEnumMulti, common.FieldTypeReference,
+			common.FieldTypeComputed:
 		default:
 			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldPropertyType)
 		}
@@ -214,6 +231,23 @@ func (m *modelAttribute) update(ctx core.ContextParams, data mapstr.MapStr, cond
 		return 0, err
 	}
 
+	// the expression of the computed attributes may be changed, backfill the instances of their models
+	if data.Exists(metadata.AttributeFieldOption) {
+		attrs, err := m.search(ctx, cond)
+		if err != nil {
+			blog.Errorf("request(%s): get the updated attributes failed, err: %v", ctx.ReqID, err)
+			return cnt, nil
+		}
+		refreshed := make(map[string]bool)
+		for _, attr := range attrs {
+			if attr.PropertyType != common.FieldTypeComputed || refreshed[attr.ObjectID] {
+				continue
+			}
+			refreshed[attr.ObjectID] = true
+			m.refreshComputedValues(ctx, attr.ObjectID)
+		}
+	}
+
 	return cnt, err
 }
 
@@ -286,6 +320,11 @@ func (m *modelAttribute) saveCheck(ctx core.ContextParams, attribute metadata.At
 	if err := m.checkAttributeValidity(ctx, attribute); err != nil {
 		return err
 	}
+	if attribute.PropertyType == common.FieldTypeComputed {
+		if err := m.checkComputedAttribute(ctx, attribute.ObjectID, attribute.PropertyID, attribute.Option); err != nil {
+			return err
+		}
+	}
 
 	// check name duplicate
 	if err := m.checkUnique(ctx, true, attribute.ObjectID, attribute.PropertyID, attribute.PropertyName, attribute.Metadata); err != nil {
@@ -366,6 +405,11 @@ func (m *modelAttribute) checkUpdate(ctx core.ContextParams, data mapstr.MapStr,
 		if err = m.checkChangeField(ctx, dbAttribute, data); err != nil {
 			return changeRow, err
 		}
+		if option, exists := data.Get(metadata.AttributeFieldOption); exists && dbAttribute.PropertyType == common.FieldTypeComputed {
+			if err = m.checkComputedAttribute(ctx, dbAttribute.ObjectID, dbAttribute.PropertyID, option); err != nil {
+				return changeRow, err
+			}
+		}
 	}
 
 	return uint64(len(dbAttributeArr)), err
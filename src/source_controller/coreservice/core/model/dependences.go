@@ -32,4 +32,8 @@ type OperationDependences interface {
 
 	// CascadeDeleteInstances cascade delete all instances(included instances, instance association) associated with modelObjID
 	CascadeDeleteInstances(ctx core.ContextParams, objIDS []string) error
+
+	// DispatchComputedValuesRefresh create the background task to evaluate the computed attributes of all the
+	// instances of the model again
+	DispatchComputedValuesRefresh(ctx core.ContextParams, objID string) error
 }
@@ -385,6 +385,8 @@ func getBasicDataType(propertyType string) (interface{}, error) {
 		return 0.0, nil
 	case common.FieldTypeUser:
 		return "", nil
+	case common.FieldTypeIP, common.FieldTypeCIDR:
+		return "", nil
 	default:
 		return nil, fmt.Errorf("unsupported type: %s", propertyType)
 	}
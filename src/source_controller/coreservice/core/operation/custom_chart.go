@@ -0,0 +1,399 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/instances"
+)
+
+// customChartDefaultPoints the count of the points returned when the time range is not set
+const customChartDefaultPoints = 30
+
+// customChartRelationField the field the host module relations are looked up as
+const customChartRelationField = "__relations"
+
+func (m *operationManager) CreateCustomChart(ctx core.ContextParams, chart metadata.CustomChart) (*metadata.CustomChart, error) {
+	id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameCustomChart)
+	if err != nil {
+		blog.Errorf("CreateCustomChart failed, generate id failed, err: %v, rid: %v", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBInsertFailed)
+	}
+	now := time.Now()
+	chart.ID = id
+	chart.OwnerID = ctx.SupplierAccount
+	chart.Creator = ctx.User
+	chart.CreateTime = now
+	chart.LastTime = now
+	chart.LastComputeTime = nil
+	if err := chart.EncodeFilter(); err != nil {
+		blog.Errorf("CreateCustomChart failed, encode filter failed, err: %v, rid: %v", err, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, "filter")
+	}
+
+	if err := m.dbProxy.Table(common.BKTableNameCustomChart).Insert(ctx, chart); err != nil {
+		blog.Errorf("CreateCustomChart failed, chart: %s, err: %v, rid: %v", chart.Name, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return &chart, nil
+}
+
+func (m *operationManager) UpdateCustomChart(ctx core.ContextParams, id uint64, chart metadata.CustomChart) (*metadata.CustomChart, error) {
+	exist, err := m.getCustomChart(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// the model of the chart can't be changed, the points computed before would be meaningless
+	exist.Name = chart.Name
+	exist.Filter = chart.Filter
+	exist.GroupBy = chart.GroupBy
+	exist.Metric = chart.Metric
+	exist.Bucket = chart.Bucket
+	exist.Retention = chart.Retention
+	exist.LastTime = time.Now()
+	if err := exist.EncodeFilter(); err != nil {
+		blog.Errorf("UpdateCustomChart failed, encode filter failed, err: %v, rid: %v", err, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, "filter")
+	}
+
+	cond := mapstr.MapStr{"id": id, common.BKOwnerIDField: ctx.SupplierAccount}
+	if err := m.dbProxy.Table(common.BKTableNameCustomChart).Update(ctx, cond, exist); err != nil {
+		blog.Errorf("UpdateCustomChart failed, id: %d, err: %v, rid: %v", id, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return exist, nil
+}
+
+func (m *operationManager) DeleteCustomChart(ctx core.ContextParams, id uint64) error {
+	if _, err := m.getCustomChart(ctx, id); err != nil {
+		return err
+	}
+
+	cond := mapstr.MapStr{"id": id, common.BKOwnerIDField: ctx.SupplierAccount}
+	if err := m.dbProxy.Table(common.BKTableNameCustomChart).Delete(ctx, cond); err != nil {
+		blog.Errorf("DeleteCustomChart failed, id: %d, err: %v, rid: %v", id, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
+	pointCond := mapstr.MapStr{"chart_id": id}
+	if err := m.dbProxy.Table(common.BKTableNameCustomChartData).Delete(ctx, pointCond); err != nil {
+		blog.Errorf("DeleteCustomChart failed, delete points of chart %d failed, err: %v, rid: %v", id, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+func (m *operationManager) SearchCustomChart(ctx core.ContextParams, option metadata.CustomChartSearchOption) (*metadata.CustomChartSearchResult, error) {
+	cond := mapstr.MapStr{common.BKOwnerIDField: ctx.SupplierAccount}
+	if len(option.ObjID) > 0 {
+		cond[common.BKObjIDField] = option.ObjID
+	}
+
+	count, err := m.dbProxy.Table(common.BKTableNameCustomChart).Find(cond).Count(ctx)
+	if err != nil {
+		blog.Errorf("SearchCustomChart failed, count failed, cond: %+v, err: %v, rid: %v", cond, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := option.Page.Sort
+	if len(sort) == 0 {
+		sort = "id"
+	}
+	charts := make([]metadata.CustomChart, 0)
+	find := m.dbProxy.Table(common.BKTableNameCustomChart).Find(cond).Sort(sort).Start(uint64(option.Page.Start))
+	if option.Page.Limit > 0 {
+		find = find.Limit(uint64(option.Page.Limit))
+	}
+	if err := find.All(ctx, &charts); err != nil {
+		blog.Errorf("SearchCustomChart failed, cond: %+v, err: %v, rid: %v", cond, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	for index := range charts {
+		if err := charts[index].DecodeFilter(); err != nil {
+			blog.Errorf("SearchCustomChart, decode filter of chart %d failed, err: %v, rid: %v", charts[index].ID, err, ctx.ReqID)
+		}
+	}
+
+	return &metadata.CustomChartSearchResult{Count: count, Info: charts}, nil
+}
+
+func (m *operationManager) SearchCustomChartData(ctx core.ContextParams, id uint64, option metadata.CustomChartDataOption) (*metadata.CustomChartData, error) {
+	chart, err := m.getCustomChart(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cond := mapstr.MapStr{"chart_id": id}
+	timeCond := mapstr.MapStr{}
+	if option.Start != nil {
+		timeCond[common.BKDBGTE] = *option.Start
+	}
+	if option.End != nil {
+		timeCond[common.BKDBLTE] = *option.End
+	}
+	if len(timeCond) > 0 {
+		cond["time"] = timeCond
+	}
+
+	points := make([]metadata.CustomChartPoint, 0)
+	find := m.dbProxy.Table(common.BKTableNameCustomChartData).Find(cond).Sort("-time")
+	if len(timeCond) == 0 {
+		find = find.Limit(customChartDefaultPoints)
+	}
+	if err := find.All(ctx, &points); err != nil {
+		blog.Errorf("SearchCustomChartData failed, cond: %+v, err: %v, rid: %v", cond, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	// return the points in time order
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	return &metadata.CustomChartData{Chart: *chart, Points: points}, nil
+}
+
+func (m *operationManager) ComputeCustomChart(ctx core.ContextParams, option metadata.CustomChartComputeOption) (*metadata.CustomChartComputeResult, error) {
+	// the timer computes the charts of all the supplier accounts as the super owner, each chart is
+	// aggregated within its own supplier account.
+	cond := mapstr.MapStr{}
+	if ctx.SupplierAccount != common.BKSuperOwnerID {
+		cond[common.BKOwnerIDField] = ctx.SupplierAccount
+	}
+	if len(option.IDs) > 0 {
+		cond["id"] = mapstr.MapStr{common.BKDBIN: option.IDs}
+	}
+
+	charts := make([]metadata.CustomChart, 0)
+	if err := m.dbProxy.Table(common.BKTableNameCustomChart).Find(cond).All(ctx, &charts); err != nil {
+		blog.Errorf("ComputeCustomChart failed, search charts failed, cond: %+v, err: %v, rid: %v", cond, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	result := &metadata.CustomChartComputeResult{Computed: make([]uint64, 0), Failed: make([]uint64, 0)}
+	for index := range charts {
+		chart := &charts[index]
+		if err := m.computeCustomChart(ctx, chart); err != nil {
+			blog.Errorf("ComputeCustomChart, compute chart %d failed, err: %v, rid: %v", chart.ID, err, ctx.ReqID)
+			result.Failed = append(result.Failed, chart.ID)
+			continue
+		}
+		result.Computed = append(result.Computed, chart.ID)
+	}
+	return result, nil
+}
+
+func (m *operationManager) getCustomChart(ctx core.ContextParams, id uint64) (*metadata.CustomChart, error) {
+	cond := mapstr.MapStr{"id": id, common.BKOwnerIDField: ctx.SupplierAccount}
+	charts := make([]metadata.CustomChart, 0)
+	if err := m.dbProxy.Table(common.BKTableNameCustomChart).Find(cond).All(ctx, &charts); err != nil {
+		blog.Errorf("get custom chart %d failed, err: %v, rid: %v", id, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(charts) == 0 {
+		return nil, ctx.Error.CCError(common.CCErrOperationCustomChartNotFound)
+	}
+	chart := &charts[0]
+	if err := chart.DecodeFilter(); err != nil {
+		blog.Errorf("get custom chart %d, decode filter failed, err: %v, rid: %v", id, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrOperationCustomChartComputeFail)
+	}
+	return chart, nil
+}
+
+// computeCustomChart aggregate the instances of the chart and save the result as the point of the current bucket
+func (m *operationManager) computeCustomChart(ctx core.ContextParams, chart *metadata.CustomChart) error {
+	if err := chart.DecodeFilter(); err != nil {
+		return fmt.Errorf("decode filter failed, err: %v", err)
+	}
+
+	pipeline, err := customChartPipeline(chart)
+	if err != nil {
+		return err
+	}
+
+	aggregated := make([]struct {
+		Keys  map[string]interface{} `bson:"_id"`
+		Value interface{}            `bson:"value"`
+	}, 0)
+	table := common.GetInstTableName(chart.ObjID)
+	if err := m.dbProxy.Table(table).AggregateAll(ctx, pipeline, &aggregated); err != nil {
+		return fmt.Errorf("aggregate %s failed, err: %v", table, err)
+	}
+
+	groups := make([]metadata.CustomChartGroup, 0, len(aggregated))
+	for _, item := range aggregated {
+		value, err := util.GetFloat64ByInterface(item.Value)
+		if err != nil {
+			// min and max of the groups without the metric field are null
+			value = 0
+		}
+		keys := item.Keys
+		if keys == nil {
+			keys = make(map[string]interface{})
+		}
+		groups = append(groups, metadata.CustomChartGroup{Keys: keys, Value: value})
+	}
+	if err := m.labelCustomChartGroups(ctx, chart, groups); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	point := metadata.CustomChartPoint{
+		ChartID:  chart.ID,
+		Time:     chart.BucketStart(now),
+		Groups:   groups,
+		OwnerID:  chart.OwnerID,
+		LastTime: now,
+	}
+	pointCond := mapstr.MapStr{"chart_id": chart.ID, "time": point.Time}
+	if err := m.dbProxy.Table(common.BKTableNameCustomChartData).Upsert(ctx, pointCond, point); err != nil {
+		return fmt.Errorf("save point failed, err: %v", err)
+	}
+
+	if chart.Retention > 0 && chart.Bucket != metadata.CustomChartBucketNone {
+		expired := mapstr.MapStr{
+			"chart_id": chart.ID,
+			"time":     mapstr.MapStr{common.BKDBLT: now.AddDate(0, 0, -int(chart.Retention))},
+		}
+		if err := m.dbProxy.Table(common.BKTableNameCustomChartData).Delete(ctx, expired); err != nil {
+			blog.Errorf("delete expired points of chart %d failed, err: %v, rid: %v", chart.ID, err, ctx.ReqID)
+		}
+	}
+
+	chartCond := mapstr.MapStr{"id": chart.ID}
+	if err := m.dbProxy.Table(common.BKTableNameCustomChart).Update(ctx, chartCond, mapstr.MapStr{"last_compute_time": now}); err != nil {
+		blog.Errorf("update compute time of chart %d failed, err: %v, rid: %v", chart.ID, err, ctx.ReqID)
+	}
+	return nil
+}
+
+// customChartPipeline build the aggregation of the chart, the business of the host is looked up
+// from the host module relations when the host chart is grouped by the business.
+func customChartPipeline(chart *metadata.CustomChart) ([]M, error) {
+	match := M{common.BKOwnerIDField: chart.OwnerID}
+	if !common.IsInnerModel(chart.ObjID) {
+		match[common.BKObjIDField] = chart.ObjID
+	}
+	if chart.Filter != nil && chart.Filter.Rule != nil {
+		filter, key, err := chart.Filter.ToMgo()
+		if err != nil {
+			return nil, fmt.Errorf("convert filter failed, key: %s, err: %v", key, err)
+		}
+		match = M{common.BKDBAND: []interface{}{match, filter}}
+	}
+	pipeline := []M{{"$match": match}}
+
+	if chart.ObjID == common.BKInnerObjIDHost && util.InStrArr(chart.GroupBy, common.BKAppIDField) {
+		pipeline = append(pipeline,
+			M{"$lookup": M{
+				"from":         common.BKTableNameModuleHostConfig,
+				"localField":   common.BKHostIDField,
+				"foreignField": common.BKHostIDField,
+				"as":           customChartRelationField,
+			}},
+			M{"$addFields": M{
+				common.BKAppIDField: M{"$arrayElemAt": []interface{}{"$" + customChartRelationField + "." + common.BKAppIDField, 0}},
+			}},
+		)
+	}
+
+	var groupID interface{}
+	if len(chart.GroupBy) > 0 {
+		keys := M{}
+		for _, field := range chart.GroupBy {
+			keys[field] = "$" + field
+		}
+		groupID = keys
+	}
+
+	var value M
+	switch chart.Metric.Type {
+	case metadata.CustomChartMetricCount:
+		value = M{"$sum": 1}
+	case metadata.CustomChartMetricSum, metadata.CustomChartMetricAvg,
+		metadata.CustomChartMetricMin, metadata.CustomChartMetricMax:
+		value = M{"$" + chart.Metric.Type: "$" + chart.Metric.Field}
+	default:
+		return nil, fmt.Errorf("unsupported metric %s", chart.Metric.Type)
+	}
+	pipeline = append(pipeline, M{"$group": M{"_id": groupID, "value": value}})
+	return pipeline, nil
+}
+
+// labelCustomChartGroups translate the enum ids and the business ids in the group keys to their names
+func (m *operationManager) labelCustomChartGroups(ctx core.ContextParams, chart *metadata.CustomChart, groups []metadata.CustomChartGroup) error {
+	if len(chart.GroupBy) == 0 || len(groups) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]map[string]string)
+	attrCond := mapstr.MapStr{
+		common.BKObjIDField:        chart.ObjID,
+		common.BKPropertyIDField:   mapstr.MapStr{common.BKDBIN: chart.GroupBy},
+		common.BKPropertyTypeField: common.FieldTypeEnum,
+	}
+	attributes := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(attrCond).All(ctx, &attributes); err != nil {
+		return fmt.Errorf("search enum attributes failed, err: %v", err)
+	}
+	for _, attribute := range attributes {
+		options, err := instances.ParseEnumOption(ctx, attribute.Option)
+		if err != nil {
+			blog.Errorf("parse enum option of %s failed, err: %v, rid: %v", attribute.PropertyID, err, ctx.ReqID)
+			continue
+		}
+		names := make(map[string]string)
+		for _, option := range options {
+			names[option.ID] = option.Name
+		}
+		labels[attribute.PropertyID] = names
+	}
+
+	if util.InStrArr(chart.GroupBy, common.BKAppIDField) && chart.ObjID != common.BKInnerObjIDApp {
+		bizs := make([]metadata.BizInst, 0)
+		bizCond := mapstr.MapStr{common.BKOwnerIDField: chart.OwnerID}
+		if err := m.dbProxy.Table(common.BKTableNameBaseApp).Find(bizCond).Fields(common.BKAppIDField, common.BKAppNameField).All(ctx, &bizs); err != nil {
+			return fmt.Errorf("search business failed, err: %v", err)
+		}
+		names := make(map[string]string)
+		for _, biz := range bizs {
+			names[strconv.FormatInt(biz.BizID, 10)] = biz.BizName
+		}
+		labels[common.BKAppIDField] = names
+	}
+
+	for index := range groups {
+		group := &groups[index]
+		group.Labels = make(map[string]interface{})
+		for field, names := range labels {
+			key, exist := group.Keys[field]
+			if !exist || key == nil {
+				continue
+			}
+			if name, ok := names[fmt.Sprintf("%v", key)]; ok {
+				group.Labels[field] = name
+			}
+		}
+	}
+	return nil
+}
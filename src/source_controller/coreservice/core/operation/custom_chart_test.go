@@ -0,0 +1,151 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+
+	"github.com/stretchr/testify/require"
+)
+
+// chartDB is an in memory db of the custom charts, the supplier accounts of the aggregations are recorded
+type chartDB struct {
+	charts           []metadata.CustomChart
+	aggregatedOwners []interface{}
+}
+
+func (db *chartDB) Clone() dal.DB                                                 { return db }
+func (db *chartDB) Table(collection string) dal.Table                             { return &chartTable{db: db, name: collection} }
+func (db *chartDB) NextSequence(ctx context.Context, name string) (uint64, error) { return 0, nil }
+func (db *chartDB) Ping() error                                                   { return nil }
+func (db *chartDB) HasTable(tablename string) (bool, error)                       { return true, nil }
+func (db *chartDB) DropTable(tablename string) error                              { return nil }
+func (db *chartDB) CreateTable(tablename string) error                            { return nil }
+func (db *chartDB) IsDuplicatedError(error) bool                                  { return false }
+func (db *chartDB) IsNotFoundError(error) bool                                    { return false }
+func (db *chartDB) Close() error                                                  { return nil }
+
+type chartTable struct {
+	db   *chartDB
+	name string
+}
+
+func (t *chartTable) Find(filter dal.Filter) dal.Find {
+	return &chartFind{table: t, filter: filter.(mapstr.MapStr)}
+}
+
+func (t *chartTable) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	return nil
+}
+
+// AggregateAll record the supplier account the chart is aggregated within, no group is returned
+func (t *chartTable) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+	match := pipeline.([]M)[0]["$match"].(M)
+	t.db.aggregatedOwners = append(t.db.aggregatedOwners, match[common.BKOwnerIDField])
+	return nil
+}
+
+func (t *chartTable) Insert(ctx context.Context, docs interface{}) error { return nil }
+func (t *chartTable) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	return nil
+}
+func (t *chartTable) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	return nil
+}
+func (t *chartTable) UpdateMultiModel(ctx context.Context, filter dal.Filter, updateModel ...dal.ModeUpdate) error {
+	return nil
+}
+func (t *chartTable) Delete(ctx context.Context, filter dal.Filter) error    { return nil }
+func (t *chartTable) CreateIndex(ctx context.Context, index dal.Index) error { return nil }
+func (t *chartTable) DropIndex(ctx context.Context, indexName string) error  { return nil }
+func (t *chartTable) Indexes(ctx context.Context) ([]dal.Index, error)       { return nil, nil }
+func (t *chartTable) AddColumn(ctx context.Context, column string, value interface{}) error {
+	return nil
+}
+func (t *chartTable) RenameColumn(ctx context.Context, oldName, newColumn string) error { return nil }
+func (t *chartTable) DropColumn(ctx context.Context, field string) error                { return nil }
+
+type chartFind struct {
+	table  *chartTable
+	filter mapstr.MapStr
+}
+
+func (f *chartFind) Fields(fields ...string) dal.Find                  { return f }
+func (f *chartFind) Sort(sort string) dal.Find                         { return f }
+func (f *chartFind) Start(start uint64) dal.Find                       { return f }
+func (f *chartFind) Limit(limit uint64) dal.Find                       { return f }
+func (f *chartFind) One(ctx context.Context, result interface{}) error { return nil }
+func (f *chartFind) Count(ctx context.Context) (uint64, error)         { return 0, nil }
+
+// All returns the charts matching the supplier account and the ids of the filter
+func (f *chartFind) All(ctx context.Context, result interface{}) error {
+	if f.table.name != common.BKTableNameCustomChart {
+		return nil
+	}
+	charts := make([]metadata.CustomChart, 0)
+	for _, chart := range f.table.db.charts {
+		if owner, exist := f.filter[common.BKOwnerIDField]; exist && owner != chart.OwnerID {
+			continue
+		}
+		if ids, exist := f.filter["id"]; exist {
+			matched := false
+			for _, id := range ids.(mapstr.MapStr)[common.BKDBIN].([]uint64) {
+				matched = matched || id == chart.ID
+			}
+			if !matched {
+				continue
+			}
+		}
+		charts = append(charts, chart)
+	}
+	*result.(*[]metadata.CustomChart) = charts
+	return nil
+}
+
+func TestComputeCustomChartOfTenants(t *testing.T) {
+	db := &chartDB{charts: []metadata.CustomChart{
+		{ID: 1, ObjID: common.BKInnerObjIDHost, Metric: metadata.CustomChartMetric{Type: metadata.CustomChartMetricCount}, OwnerID: common.BKDefaultOwnerID},
+		{ID: 2, ObjID: common.BKInnerObjIDHost, Metric: metadata.CustomChartMetric{Type: metadata.CustomChartMetricCount}, OwnerID: "tenant"},
+	}}
+	manager := New(db).(*operationManager)
+	compute := func(owner string, ids ...uint64) *metadata.CustomChartComputeResult {
+		db.aggregatedOwners = nil
+		ctx := core.ContextParams{Context: context.Background(), SupplierAccount: owner}
+		result, err := manager.ComputeCustomChart(ctx, metadata.CustomChartComputeOption{IDs: ids})
+		require.NoError(t, err)
+		return result
+	}
+
+	// the timer computes the charts of all the tenants as the super owner, each within its own tenant
+	result := compute(common.BKSuperOwnerID)
+	require.Equal(t, []uint64{1, 2}, result.Computed)
+	require.Empty(t, result.Failed)
+	require.Equal(t, []interface{}{common.BKDefaultOwnerID, "tenant"}, db.aggregatedOwners)
+
+	// a tenant computes only its own charts
+	result = compute("tenant")
+	require.Equal(t, []uint64{2}, result.Computed)
+	require.Equal(t, []interface{}{"tenant"}, db.aggregatedOwners)
+
+	// the charts of other tenants can't be computed by id
+	result = compute(common.BKDefaultOwnerID, 2)
+	require.Empty(t, result.Computed)
+	require.Empty(t, db.aggregatedOwners)
+}
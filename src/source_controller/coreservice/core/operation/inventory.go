@@ -0,0 +1,210 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"fmt"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// inventoryDefaultMaxGroups the max groups of each dimension when the option is not set
+const inventoryDefaultMaxGroups = 100
+
+// inventoryCount the count aggregated by the id
+type inventoryCount struct {
+	ID    interface{} `bson:"_id"`
+	Count int64       `bson:"count"`
+}
+
+func (m *operationManager) StatisticInventory(ctx core.ContextParams, option metadata.InventoryStatisticsOption) (*metadata.InventoryStatistics, error) {
+	maxGroups := option.MaxGroups
+	if maxGroups <= 0 {
+		maxGroups = inventoryDefaultMaxGroups
+	}
+
+	result := new(metadata.InventoryStatistics)
+	hosts, err := m.dbProxy.Table(common.BKTableNameBaseHost).Find(mapstr.MapStr{}).Count(ctx)
+	if err != nil {
+		blog.Errorf("statistic inventory, count hosts failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	result.Hosts = int64(hosts)
+
+	if result.UnassignedHosts, err = m.countUnassignedHosts(ctx); err != nil {
+		blog.Errorf("statistic inventory, count unassigned hosts failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	// the host may be in several modules of the business, count the distinct hosts
+	hostsByBiz := []M{
+		{"$group": M{"_id": M{common.BKAppIDField: "$" + common.BKAppIDField, common.BKHostIDField: "$" + common.BKHostIDField}}},
+		{"$group": M{"_id": "$_id." + common.BKAppIDField, "count": M{"$sum": 1}}},
+	}
+	if result.HostsByBiz, err = m.inventoryDimension(ctx, common.BKTableNameModuleHostConfig, hostsByBiz, maxGroups,
+		common.BKTableNameBaseApp, common.BKAppIDField, common.BKAppNameField); err != nil {
+		blog.Errorf("statistic inventory, count hosts by business failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	hostsByCloud := []M{{"$group": M{"_id": "$" + common.BKCloudIDField, "count": M{"$sum": 1}}}}
+	if result.HostsByCloud, err = m.inventoryDimension(ctx, common.BKTableNameBaseHost, hostsByCloud, maxGroups,
+		common.BKTableNameBasePlat, common.BKCloudIDField, common.BKCloudNameField); err != nil {
+		blog.Errorf("statistic inventory, count hosts by cloud area failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	hostsByModule := []M{{"$group": M{"_id": "$" + common.BKModuleIDField, "count": M{"$sum": 1}}}}
+	if result.HostsByModule, err = m.inventoryDimension(ctx, common.BKTableNameModuleHostConfig, hostsByModule, maxGroups,
+		common.BKTableNameBaseModule, common.BKModuleIDField, common.BKModuleNameField); err != nil {
+		blog.Errorf("statistic inventory, count hosts by module failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if result.InstancesByModel, err = m.countInstancesByModel(ctx, maxGroups); err != nil {
+		blog.Errorf("statistic inventory, count instances by model failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	processesByBiz := []M{{"$group": M{"_id": "$" + common.BKAppIDField, "count": M{"$sum": 1}}}}
+	if result.ProcessesByBiz, err = m.inventoryDimension(ctx, common.BKTableNameBaseProcess, processesByBiz, maxGroups,
+		common.BKTableNameBaseApp, common.BKAppIDField, common.BKAppNameField); err != nil {
+		blog.Errorf("statistic inventory, count processes by business failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return result, nil
+}
+
+// countUnassignedHosts count the hosts in the resource pool which are not assigned to any business
+func (m *operationManager) countUnassignedHosts(ctx core.ContextParams) (int64, error) {
+	bizs := make([]metadata.BizInst, 0)
+	cond := mapstr.MapStr{common.BKDefaultField: common.DefaultAppFlag}
+	if err := m.dbProxy.Table(common.BKTableNameBaseApp).Find(cond).Fields(common.BKAppIDField).All(ctx, &bizs); err != nil {
+		return 0, err
+	}
+	if len(bizs) == 0 {
+		return 0, nil
+	}
+	bizIDs := make([]int64, 0, len(bizs))
+	for _, biz := range bizs {
+		bizIDs = append(bizIDs, biz.BizID)
+	}
+
+	pipeline := []M{
+		{"$match": M{common.BKAppIDField: M{common.BKDBIN: bizIDs}}},
+		{"$group": M{"_id": "$" + common.BKHostIDField}},
+		{"$group": M{"_id": nil, "count": M{"$sum": 1}}},
+	}
+	counts := make([]inventoryCount, 0)
+	if err := m.dbProxy.Table(common.BKTableNameModuleHostConfig).AggregateAll(ctx, pipeline, &counts); err != nil {
+		return 0, err
+	}
+	if len(counts) == 0 {
+		return 0, nil
+	}
+	return counts[0].Count, nil
+}
+
+// countInstancesByModel count the instances of the custom models and the inner models
+func (m *operationManager) countInstancesByModel(ctx core.ContextParams, maxGroups int) (metadata.InventoryDimension, error) {
+	pipeline := []M{{"$group": M{"_id": "$" + common.BKObjIDField, "count": M{"$sum": 1}}}}
+	counts := make([]inventoryCount, 0)
+	if err := m.dbProxy.Table(common.BKTableNameBaseInst).AggregateAll(ctx, pipeline, &counts); err != nil {
+		return metadata.InventoryDimension{}, err
+	}
+
+	for _, objID := range []string{common.BKInnerObjIDApp, common.BKInnerObjIDSet, common.BKInnerObjIDModule,
+		common.BKInnerObjIDHost, common.BKInnerObjIDProc, common.BKInnerObjIDPlat} {
+		count, err := m.dbProxy.Table(common.GetInstTableName(objID)).Find(mapstr.MapStr{}).Count(ctx)
+		if err != nil {
+			return metadata.InventoryDimension{}, err
+		}
+		counts = append(counts, inventoryCount{ID: objID, Count: int64(count)})
+	}
+
+	dimension, ids := topInventoryGroups(counts, maxGroups)
+	if err := m.fillInventoryNames(ctx, &dimension, ids, common.BKTableNameObjDes, common.BKObjIDField, common.BKObjNameField); err != nil {
+		return metadata.InventoryDimension{}, err
+	}
+	return dimension, nil
+}
+
+// inventoryDimension aggregate the counts of a dimension, keep the top groups and fill their names
+// with the name field of the instances in the name table.
+func (m *operationManager) inventoryDimension(ctx core.ContextParams, table string, pipeline []M, maxGroups int,
+	nameTable, idField, nameField string) (metadata.InventoryDimension, error) {
+
+	counts := make([]inventoryCount, 0)
+	if err := m.dbProxy.Table(table).AggregateAll(ctx, pipeline, &counts); err != nil {
+		return metadata.InventoryDimension{}, err
+	}
+
+	dimension, ids := topInventoryGroups(counts, maxGroups)
+	if err := m.fillInventoryNames(ctx, &dimension, ids, nameTable, idField, nameField); err != nil {
+		return metadata.InventoryDimension{}, err
+	}
+	return dimension, nil
+}
+
+func (m *operationManager) fillInventoryNames(ctx core.ContextParams, dimension *metadata.InventoryDimension, ids []interface{},
+	nameTable, idField, nameField string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	cond := mapstr.MapStr{idField: mapstr.MapStr{common.BKDBIN: ids}}
+	insts := make([]mapstr.MapStr, 0)
+	if err := m.dbProxy.Table(nameTable).Find(cond).Fields(idField, nameField).All(ctx, &insts); err != nil {
+		return err
+	}
+
+	names := make(map[string]string)
+	for _, inst := range insts {
+		names[fmt.Sprintf("%v", inst[idField])] = fmt.Sprintf("%v", inst[nameField])
+	}
+	for index := range dimension.Groups {
+		dimension.Groups[index].Name = names[dimension.Groups[index].ID]
+	}
+	return nil
+}
+
+// topInventoryGroups sort the counts in descending order and keep the top groups, the total is the count of all the groups.
+// the ids of the top groups are returned as they are in db to search their names.
+func topInventoryGroups(counts []inventoryCount, maxGroups int) (metadata.InventoryDimension, []interface{}) {
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Count > counts[j].Count
+	})
+
+	dimension := metadata.InventoryDimension{Groups: make([]metadata.InventoryGroupCount, 0)}
+	ids := make([]interface{}, 0)
+	for index, count := range counts {
+		dimension.Total += count.Count
+		if index >= maxGroups {
+			continue
+		}
+		// the instances without the field are grouped with the null id
+		id := ""
+		if count.ID != nil {
+			id = fmt.Sprintf("%v", count.ID)
+			ids = append(ids, count.ID)
+		}
+		dimension.Groups = append(dimension.Groups, metadata.InventoryGroupCount{ID: id, Count: count.Count})
+	}
+	return dimension, ids
+}
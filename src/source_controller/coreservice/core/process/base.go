@@ -19,6 +19,7 @@ import (
 	"configcenter/src/common/eventclient"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/templateattr"
 	"configcenter/src/storage/dal"
 
 	"gopkg.in/redis.v5"
@@ -34,6 +35,7 @@ type processOperation struct {
 type OperationDependence interface {
 	CreateProcessInstance(params core.ContextParams, process *metadata.Process) (*metadata.Process, errors.CCErrorCoder)
 	TransferHostModuleDep(ctx core.ContextParams, input *metadata.HostsModuleRelation) ([]metadata.ExceptionResult, error)
+	templateattr.Dependence
 }
 
 // New create a new model manager instance
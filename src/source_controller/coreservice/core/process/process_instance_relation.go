@@ -153,6 +153,16 @@ func (p *processOperation) ListProcessInstanceRelation(ctx core.ContextParams, o
 		filter[common.BKHostIDField] = option.HostID
 	}
 
+	if len(option.HostIDs) > 0 {
+		filter[common.BKHostIDField] = map[string]interface{}{
+			common.BKDBIN: option.HostIDs,
+		}
+		// processes on a host may belong to different businesses
+		if option.BusinessID == 0 {
+			delete(filter, common.BKAppIDField)
+		}
+	}
+
 	if option.ProcessIDs != nil && len(option.ProcessIDs) > 0 {
 		processIDFilter := map[string]interface{}{
 			common.BKDBIN: option.ProcessIDs,
@@ -30,6 +30,8 @@ func (p *processOperation) CreateProcessTemplate(ctx core.ContextParams, templat
 		err := ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, field)
 		return nil, err
 	}
+	// the legacy bind fields are derived from the bind info once it is set
+	template.Property.SyncLegacyBindFields()
 	*template.Property.ProcessName.AsDefaultValue = true
 	*template.Property.FuncName.AsDefaultValue = true
 	if template.Property != nil && template.Property.ProcessName.Value != nil {
@@ -38,9 +40,12 @@ func (p *processOperation) CreateProcessTemplate(ctx core.ContextParams, templat
 
 	var bizID int64
 	var err error
-	if bizID, err = p.validateBizID(ctx, template.BizID); err != nil {
-		blog.Errorf("CreateProcessTemplate failed, validation failed, code: %d, err: %+v, rid: %s", common.CCErrCommParamsInvalid, err, ctx.ReqID)
-		return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	// process templates of global service template is not belong to any business
+	if template.BizID != common.BKGlobalBizID {
+		if bizID, err = p.validateBizID(ctx, template.BizID); err != nil {
+			blog.Errorf("CreateProcessTemplate failed, validation failed, code: %d, err: %+v, rid: %s", common.CCErrCommParamsInvalid, err, ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+		}
 	}
 
 	template.BizID = bizID
@@ -158,6 +163,28 @@ func (p *processOperation) UpdateProcessTemplate(ctx core.ContextParams, templat
 		return nil, err
 	}
 
+	return p.updateProcessTemplate(ctx, template, rawProperty)
+}
+
+// AcceptProcessTemplateUpstream update the upstream changed fields and record the upstream property as the
+// new diff base in one write, so that a failed accept leaves the template unchanged and can be retried.
+func (p *processOperation) AcceptProcessTemplateUpstream(ctx core.ContextParams, templateID int64, option metadata.AcceptProcessTemplateUpstreamOption) (*metadata.ProcessTemplate, errors.CCErrorCoder) {
+	template, err := p.GetProcessTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template.UpstreamTemplateID == 0 || option.UpstreamProperty == nil {
+		blog.Errorf("AcceptProcessTemplateUpstream failed, template %d has no upstream or upstream property not set, rid: %s", templateID, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, "upstream_property")
+	}
+
+	template.UpstreamProperty = option.UpstreamProperty
+	return p.updateProcessTemplate(ctx, template, option.Property)
+}
+
+func (p *processOperation) updateProcessTemplate(ctx core.ContextParams, template *metadata.ProcessTemplate, rawProperty map[string]interface{}) (*metadata.ProcessTemplate, errors.CCErrorCoder) {
+	templateID := template.ID
+
 	property := metadata.ProcessProperty{}
 	if err := mapstr.DecodeFromMapStr(&property, rawProperty); err != nil {
 		blog.ErrorJSON("UpdateProcessTemplate failed, unmarshal failed, property: %s, err: %s, rid: %s", property, err, ctx.ReqID)
@@ -174,6 +201,8 @@ func (p *processOperation) UpdateProcessTemplate(ctx core.ContextParams, templat
 		return nil, err
 	}
 
+	// the legacy bind fields are derived from the bind info once it is set
+	template.Property.SyncLegacyBindFields()
 	*template.Property.ProcessName.AsDefaultValue = true
 	*template.Property.FuncName.AsDefaultValue = true
 	template.Modifier = ctx.User
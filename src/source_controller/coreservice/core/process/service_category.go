@@ -30,9 +30,12 @@ func (p *processOperation) CreateServiceCategory(ctx core.ContextParams, categor
 
 	var bizID int64
 	var err error
-	if bizID, err = p.validateBizID(ctx, category.BizID); err != nil {
-		blog.Errorf("CreateServiceCategory failed, validation failed, code: %d, err: %+v, rid: %s", common.CCErrCommParamsInvalid, err, ctx.ReqID)
-		return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	// global service category is visible to all businesses
+	if category.BizID != common.BKGlobalBizID {
+		if bizID, err = p.validateBizID(ctx, category.BizID); err != nil {
+			blog.Errorf("CreateServiceCategory failed, validation failed, code: %d, err: %+v, rid: %s", common.CCErrCommParamsInvalid, err, ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+		}
 	}
 
 	category.BizID = bizID
@@ -44,6 +47,11 @@ func (p *processOperation) CreateServiceCategory(ctx core.ContextParams, categor
 			blog.Errorf("CreateServiceCategory failed, parent id invalid, code: %d, category: %+v, err: %+v, rid: %s", common.CCErrCommParamsInvalid, category, err, ctx.ReqID)
 			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
 		}
+		// global category can't be a child of business category, which is invisible to other businesses
+		if bizID == common.BKGlobalBizID && parentCategory.BizID != common.BKGlobalBizID {
+			blog.Errorf("CreateServiceCategory failed, parent of global category %d belongs to business %d, rid: %s", parentCategory.ID, parentCategory.BizID, ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKParentIDField)
+		}
 		category.RootID = parentCategory.RootID
 	}
 
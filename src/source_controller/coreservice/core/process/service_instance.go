@@ -114,20 +114,12 @@ func (p *processOperation) CreateServiceInstance(ctx core.ContextParams, instanc
 	}
 
 	if instance.ServiceTemplateID != common.ServiceTemplateIDNotSet {
-		listProcessTemplateOption := metadata.ListProcessTemplatesOption{
-			BusinessID:         module.BizID,
-			ServiceTemplateIDs: []int64{module.ServiceTemplateID},
-			Page: metadata.BasePage{
-				Limit: common.BKNoLimit,
-				Sort:  "id",
-			},
-		}
-		listProcTplResult, ccErr := p.ListProcessTemplates(ctx, listProcessTemplateOption)
+		processTemplates, ccErr := p.listModuleProcessTemplates(ctx, module)
 		if ccErr != nil {
-			blog.Errorf("CreateServiceInstance failed, get process templates failed, listProcessTemplateOption: %+v, err: %+v, rid: %s", listProcessTemplateOption, ccErr, ctx.ReqID)
+			blog.Errorf("CreateServiceInstance failed, get process templates of module %d failed, err: %+v, rid: %s", module.ModuleID, ccErr, ctx.ReqID)
 			return nil, ccErr
 		}
-		for _, processTemplate := range listProcTplResult.Info {
+		for _, processTemplate := range processTemplates {
 			processData := processTemplate.NewProcess(module.BizID, ctx.SupplierAccount)
 			process, ccErr := p.dependence.CreateProcessInstance(ctx, processData)
 			if ccErr != nil {
@@ -715,6 +707,12 @@ func (p *processOperation) RemoveTemplateBindingOnModule(ctx core.ContextParams,
 		return ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
 	}
 
+	// the module is no longer pinned to any service template version
+	if err := p.dbProxy.Table(common.BKTableNameModuleServiceTemplateVersion).Delete(ctx.Context, moduleFilter); err != nil {
+		blog.Errorf("remove template binding on module failed, remove version pin failed, module: %d, err: %+v, rid: %s", moduleID, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+	}
+
 	// clear service instance template
 	serviceInstanceFilter := map[string]int64{
 		common.BKModuleIDField: moduleID,
@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/templateattr"
 )
 
 func (p *processOperation) CreateServiceTemplate(ctx core.ContextParams, template metadata.ServiceTemplate) (*metadata.ServiceTemplate, errors.CCErrorCoder) {
@@ -34,14 +35,48 @@ func (p *processOperation) CreateServiceTemplate(ctx core.ContextParams, templat
 
 	var bizID int64
 	var err error
-	if bizID, err = p.validateBizID(ctx, template.BizID); err != nil {
-		blog.Errorf("CreateServiceTemplate failed, validation failed, code: %d, err: %+v, rid: %s", common.CCErrCommParamsInvalid, err, ctx.ReqID)
-		return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	// global service template is shared by all businesses, it's not belong to any business
+	if template.BizID != common.BKGlobalBizID {
+		if bizID, err = p.validateBizID(ctx, template.BizID); err != nil {
+			blog.Errorf("CreateServiceTemplate failed, validation failed, code: %d, err: %+v, rid: %s", common.CCErrCommParamsInvalid, err, ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+		}
 	}
 
 	// keep metadata clean
 	template.BizID = bizID
 
+	// validate upstream template, only business template can be created from a global template
+	if template.UpstreamTemplateID != 0 {
+		if bizID == common.BKGlobalBizID {
+			blog.Errorf("CreateServiceTemplate failed, global template can not have upstream template, rid: %s", ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKUpstreamTemplateIDField)
+		}
+		if err := template.ShareMode.Validate(); err != nil {
+			blog.Errorf("CreateServiceTemplate failed, share mode invalid, err: %+v, rid: %s", err, ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, "share_mode")
+		}
+		upstream, err := p.GetServiceTemplate(ctx, template.UpstreamTemplateID)
+		if err != nil {
+			blog.Errorf("CreateServiceTemplate failed, get upstream template failed, id: %d, err: %+v, rid: %s", template.UpstreamTemplateID, err, ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKUpstreamTemplateIDField)
+		}
+		if upstream.BizID != common.BKGlobalBizID {
+			blog.Errorf("CreateServiceTemplate failed, upstream template %d is not a global template, rid: %s", upstream.ID, ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKUpstreamTemplateIDField)
+		}
+	} else {
+		template.ShareMode = ""
+	}
+
+	// validate template attributes
+	if template.Attributes == nil {
+		template.Attributes = make([]metadata.TemplateAttribute, 0)
+	}
+	if err := templateattr.Validate(ctx, p.dependence, bizID, common.BKInnerObjIDModule, template.Attributes); err != nil {
+		return nil, err
+	}
+
 	// validate service category id field
 	category, err := p.GetServiceCategory(ctx, template.ServiceCategoryID)
 	if err != nil {
@@ -149,11 +184,20 @@ func (p *processOperation) UpdateServiceTemplate(ctx core.ContextParams, templat
 		}
 	}
 
+	if input.Attributes != nil {
+		template.Attributes = input.Attributes
+	}
+
 	if field, err := template.Validate(); err != nil {
 		blog.Errorf("UpdateServiceTemplate failed, validation failed, code: %d, err: %+v, rid: %s", common.CCErrCommParamsInvalid, err, ctx.ReqID)
 		err := ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, field)
 		return nil, err
 	}
+	if input.Attributes != nil {
+		if err := templateattr.Validate(ctx, p.dependence, template.BizID, common.BKInnerObjIDModule, template.Attributes); err != nil {
+			return nil, err
+		}
+	}
 
 	// do update
 	filter := map[string]int64{common.BKFieldID: templateID}
@@ -210,6 +254,14 @@ func (p *processOperation) ListServiceTemplates(ctx core.ContextParams, option m
 		}
 	}
 
+	if option.UpstreamTemplateID > 0 {
+		filter[common.BKUpstreamTemplateIDField] = option.UpstreamTemplateID
+		// business templates created from the global template can be listed across businesses
+		if option.BusinessID == common.BKGlobalBizID {
+			delete(filter, common.BKAppIDField)
+		}
+	}
+
 	var total uint64
 	var err error
 	if total, err = p.dbProxy.Table(common.BKTableNameServiceTemplate).Find(filter).Count(ctx.Context); nil != err {
@@ -268,10 +320,32 @@ func (p *processOperation) DeleteServiceTemplate(ctx core.ContextParams, service
 		return err
 	}
 
+	// global service template that business templates created from shouldn't be removed
+	downstreamFilter := map[string]int64{
+		common.BKUpstreamTemplateIDField: template.ID,
+	}
+	usageCount, e = p.dbProxy.Table(common.BKTableNameServiceTemplate).Find(downstreamFilter).Count(ctx.Context)
+	if nil != e {
+		blog.Errorf("DeleteServiceTemplate failed, mongodb failed, table: %s, downstreamFilter: %+v, err: %+v, rid: %s", common.BKTableNameServiceTemplate, downstreamFilter, e, ctx.ReqID)
+		return ctx.Error.CCErrorf(common.CCErrCommDBSelectFailed)
+	}
+	if usageCount > 0 {
+		blog.Errorf("DeleteServiceTemplate failed, forbidden delete global service template referenced by %d templates, rid: %s", usageCount, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommRemoveReferencedRecordForbidden)
+	}
+
 	deleteFilter := map[string]int64{common.BKFieldID: template.ID}
 	if err := p.dbProxy.Table(common.BKTableNameServiceTemplate).Delete(ctx, deleteFilter); nil != err {
 		blog.Errorf("DeleteServiceTemplate failed, mongodb failed, table: %s, deleteFilter: %+v, err: %+v, rid: %s", common.BKTableNameServiceTemplate, deleteFilter, err, ctx.ReqID)
 		return ctx.Error.CCErrorf(common.CCErrCommDBDeleteFailed)
 	}
+
+	// versions and rollouts are useless without the service template
+	for _, table := range []string{common.BKTableNameServiceTemplateVersion, common.BKTableNameServiceTemplateRollout} {
+		if err := p.dbProxy.Table(table).Delete(ctx, usageFilter); nil != err {
+			blog.Errorf("DeleteServiceTemplate failed, mongodb failed, table: %s, filter: %+v, err: %+v, rid: %s", table, usageFilter, err, ctx.ReqID)
+			return ctx.Error.CCErrorf(common.CCErrCommDBDeleteFailed)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,170 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package process
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+var unfinishedRolloutStatus = []metadata.RolloutStatus{metadata.RolloutStatusRunning, metadata.RolloutStatusPaused}
+
+func (p *processOperation) CreateServiceTemplateRollout(ctx core.ContextParams, rollout metadata.ServiceTemplateRollout) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	if _, err := p.GetServiceTemplateVersion(ctx, rollout.ServiceTemplateID, rollout.Version); err != nil {
+		return nil, err
+	}
+	if len(rollout.Waves) == 0 {
+		return nil, ctx.Error.CCError(common.CCErrProcServiceTemplateRolloutNoModule)
+	}
+
+	// only one unfinished rollout is allowed on a service template
+	unfinished := make([]metadata.ServiceTemplateRollout, 0)
+	unfinishedFilter := map[string]interface{}{
+		common.BKServiceTemplateIDField: rollout.ServiceTemplateID,
+		common.BKStatusField: map[string]interface{}{
+			common.BKDBIN: unfinishedRolloutStatus,
+		},
+	}
+	if err := p.dbProxy.Table(common.BKTableNameServiceTemplateRollout).Find(unfinishedFilter).Limit(1).All(ctx.Context, &unfinished); err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, find unfinished rollout failed, filter: %+v, err: %+v, rid: %s", unfinishedFilter, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(unfinished) > 0 {
+		blog.Errorf("CreateServiceTemplateRollout failed, service template %d has unfinished rollout %d, rid: %s", rollout.ServiceTemplateID, unfinished[0].ID, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrProcServiceTemplateRolloutRunning, unfinished[0].ID)
+	}
+
+	id, err := p.dbProxy.NextSequence(ctx, common.BKTableNameServiceTemplateRollout)
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, generate id failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := time.Now()
+	rollout.ID = int64(id)
+	rollout.Revision = 0
+	rollout.UpdateToken = util.GenerateRID()
+	rollout.Creator = ctx.User
+	rollout.Modifier = ctx.User
+	rollout.CreateTime = now
+	rollout.LastTime = now
+	rollout.SupplierAccount = ctx.SupplierAccount
+	if err := p.dbProxy.Table(common.BKTableNameServiceTemplateRollout).Insert(ctx.Context, &rollout); err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, mongodb failed, rollout: %+v, err: %+v, rid: %s", rollout, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return &rollout, nil
+}
+
+func (p *processOperation) GetServiceTemplateRollout(ctx core.ContextParams, rolloutID int64) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	rollout := &metadata.ServiceTemplateRollout{}
+	filter := map[string]interface{}{
+		common.BKFieldID: rolloutID,
+	}
+	if err := p.dbProxy.Table(common.BKTableNameServiceTemplateRollout).Find(filter).One(ctx.Context, rollout); err != nil {
+		if p.dbProxy.IsNotFoundError(err) {
+			blog.Errorf("GetServiceTemplateRollout failed, not found, filter: %+v, rid: %s", filter, ctx.ReqID)
+			return nil, ctx.Error.CCError(common.CCErrCommNotFound)
+		}
+		blog.Errorf("GetServiceTemplateRollout failed, mongodb failed, filter: %+v, err: %+v, rid: %s", filter, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return rollout, nil
+}
+
+func (p *processOperation) ListServiceTemplateRollouts(ctx core.ContextParams, option metadata.ListServiceTemplateRolloutOption) (*metadata.MultipleServiceTemplateRollout, errors.CCErrorCoder) {
+	filter := map[string]interface{}{
+		common.BKAppIDField: option.BizID,
+	}
+	if option.ServiceTemplateID != 0 {
+		filter[common.BKServiceTemplateIDField] = option.ServiceTemplateID
+	}
+	if option.RolloutIDs != nil {
+		filter[common.BKFieldID] = map[string]interface{}{
+			common.BKDBIN: option.RolloutIDs,
+		}
+	}
+	if len(option.Status) > 0 {
+		filter[common.BKStatusField] = map[string]interface{}{
+			common.BKDBIN: option.Status,
+		}
+	}
+
+	total, err := p.dbProxy.Table(common.BKTableNameServiceTemplateRollout).Find(filter).Count(ctx.Context)
+	if err != nil {
+		blog.Errorf("ListServiceTemplateRollouts failed, mongodb failed, filter: %+v, err: %+v, rid: %s", filter, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := "-" + common.BKFieldID
+	if len(option.Page.Sort) > 0 {
+		sort = option.Page.Sort
+	}
+	rollouts := make([]metadata.ServiceTemplateRollout, 0)
+	if err := p.dbProxy.Table(common.BKTableNameServiceTemplateRollout).Find(filter).Start(uint64(option.Page.Start)).Limit(uint64(option.Page.Limit)).Sort(sort).All(ctx.Context, &rollouts); err != nil {
+		blog.Errorf("ListServiceTemplateRollouts failed, mongodb failed, filter: %+v, err: %+v, rid: %s", filter, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	result := &metadata.MultipleServiceTemplateRollout{
+		Count: total,
+		Info:  rollouts,
+	}
+	return result, nil
+}
+
+// UpdateServiceTemplateRollout update rollout state only if no one else updated it since option.Revision,
+// rollout is advanced by task callbacks and user operations at the same time, so a compare-and-set is necessary.
+func (p *processOperation) UpdateServiceTemplateRollout(ctx core.ContextParams, rolloutID int64, option metadata.UpdateServiceTemplateRolloutOption) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	token := util.GenerateRID()
+	data := map[string]interface{}{
+		"revision":           option.Revision + 1,
+		"update_token":       token,
+		common.ModifierField: ctx.User,
+		common.LastTimeField: time.Now(),
+	}
+	if len(option.Status) > 0 {
+		data[common.BKStatusField] = option.Status
+	}
+	if option.CurrentWave != nil {
+		data["current_wave"] = *option.CurrentWave
+	}
+	if option.Waves != nil {
+		data["waves"] = option.Waves
+	}
+
+	filter := map[string]interface{}{
+		common.BKFieldID: rolloutID,
+		"revision":       option.Revision,
+	}
+	if err := p.dbProxy.Table(common.BKTableNameServiceTemplateRollout).Update(ctx.Context, filter, data); err != nil {
+		blog.Errorf("UpdateServiceTemplateRollout failed, mongodb failed, filter: %+v, data: %+v, err: %+v, rid: %s", filter, data, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
+	}
+
+	rollout, err := p.GetServiceTemplateRollout(ctx, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	if rollout.UpdateToken != token {
+		blog.Errorf("UpdateServiceTemplateRollout failed, rollout %d revision %d has been updated by others, rid: %s", rolloutID, option.Revision, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCoreServiceRolloutUpdateConflict)
+	}
+	return rollout, nil
+}
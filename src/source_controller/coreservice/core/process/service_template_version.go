@@ -0,0 +1,263 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package process
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// CreateServiceTemplateVersion take a snapshot of the service template and it's process templates as a new version
+func (p *processOperation) CreateServiceTemplateVersion(ctx core.ContextParams, option metadata.CreateServiceTemplateVersionOption) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder) {
+	template, err := p.GetServiceTemplate(ctx, option.ServiceTemplateID)
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, GetServiceTemplate failed, templateID: %d, err: %+v, rid: %s", option.ServiceTemplateID, err, ctx.ReqID)
+		return nil, err
+	}
+	if option.BizID != 0 && option.BizID != template.BizID {
+		blog.Errorf("CreateServiceTemplateVersion failed, input bizID: %d not equal template bizID: %d, rid: %s", option.BizID, template.BizID, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	}
+
+	listOption := metadata.ListProcessTemplatesOption{
+		BusinessID:         template.BizID,
+		ServiceTemplateIDs: []int64{template.ID},
+		Page: metadata.BasePage{
+			Limit: common.BKNoLimit,
+			Sort:  common.BKFieldID,
+		},
+	}
+	processTemplates, err := p.ListProcessTemplates(ctx, listOption)
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, ListProcessTemplates failed, option: %+v, err: %+v, rid: %s", listOption, err, ctx.ReqID)
+		return nil, err
+	}
+
+	latest := make([]metadata.ServiceTemplateVersion, 0)
+	latestFilter := map[string]interface{}{
+		common.BKServiceTemplateIDField: template.ID,
+	}
+	if err := p.dbProxy.Table(common.BKTableNameServiceTemplateVersion).Find(latestFilter).Fields(common.BKVersionField).Sort("-"+common.BKVersionField).Limit(1).All(ctx.Context, &latest); err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, get latest version failed, filter: %+v, err: %+v, rid: %s", latestFilter, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	id, e := p.dbProxy.NextSequence(ctx, common.BKTableNameServiceTemplateVersion)
+	if e != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, generate id failed, err: %+v, rid: %s", e, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	version := &metadata.ServiceTemplateVersion{
+		ID:                int64(id),
+		BizID:             template.BizID,
+		ServiceTemplateID: template.ID,
+		Version:           1,
+		Description:       option.Description,
+		ServiceTemplate:   *template,
+		ProcessTemplates:  processTemplates.Info,
+		Creator:           ctx.User,
+		CreateTime:        time.Now(),
+		SupplierAccount:   ctx.SupplierAccount,
+	}
+	if len(latest) > 0 {
+		version.Version = latest[0].Version + 1
+	}
+
+	if err := p.dbProxy.Table(common.BKTableNameServiceTemplateVersion).Insert(ctx.Context, version); err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, mongodb failed, table: %s, version: %+v, err: %+v, rid: %s", common.BKTableNameServiceTemplateVersion, version, err, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrCommDBInsertFailed)
+	}
+	return version, nil
+}
+
+func (p *processOperation) GetServiceTemplateVersion(ctx core.ContextParams, serviceTemplateID int64, version int64) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder) {
+	templateVersion := &metadata.ServiceTemplateVersion{}
+	filter := map[string]interface{}{
+		common.BKServiceTemplateIDField: serviceTemplateID,
+		common.BKVersionField:           version,
+	}
+	if err := p.dbProxy.Table(common.BKTableNameServiceTemplateVersion).Find(filter).One(ctx.Context, templateVersion); err != nil {
+		if p.dbProxy.IsNotFoundError(err) {
+			blog.Errorf("GetServiceTemplateVersion failed, not found, filter: %+v, rid: %s", filter, ctx.ReqID)
+			return nil, ctx.Error.CCErrorf(common.CCErrProcServiceTemplateVersionNotFound, version)
+		}
+		blog.Errorf("GetServiceTemplateVersion failed, mongodb failed, filter: %+v, err: %+v, rid: %s", filter, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return templateVersion, nil
+}
+
+func (p *processOperation) ListServiceTemplateVersions(ctx core.ContextParams, option metadata.ListServiceTemplateVersionOption) (*metadata.MultipleServiceTemplateVersion, errors.CCErrorCoder) {
+	filter := map[string]interface{}{
+		common.BKAppIDField: option.BizID,
+	}
+	if option.ServiceTemplateID != 0 {
+		filter[common.BKServiceTemplateIDField] = option.ServiceTemplateID
+	}
+	if option.Versions != nil {
+		filter[common.BKVersionField] = map[string]interface{}{
+			common.BKDBIN: option.Versions,
+		}
+	}
+
+	total, err := p.dbProxy.Table(common.BKTableNameServiceTemplateVersion).Find(filter).Count(ctx.Context)
+	if err != nil {
+		blog.Errorf("ListServiceTemplateVersions failed, mongodb failed, filter: %+v, err: %+v, rid: %s", filter, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := "-" + common.BKVersionField
+	if len(option.Page.Sort) > 0 {
+		sort = option.Page.Sort
+	}
+	versions := make([]metadata.ServiceTemplateVersion, 0)
+	if err := p.dbProxy.Table(common.BKTableNameServiceTemplateVersion).Find(filter).Start(uint64(option.Page.Start)).Limit(uint64(option.Page.Limit)).Sort(sort).All(ctx.Context, &versions); err != nil {
+		blog.Errorf("ListServiceTemplateVersions failed, mongodb failed, filter: %+v, err: %+v, rid: %s", filter, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	result := &metadata.MultipleServiceTemplateVersion{
+		Count: total,
+		Info:  versions,
+	}
+	return result, nil
+}
+
+// PinModuleServiceTemplateVersion pin modules to a service template version, version 0 remove the pin
+func (p *processOperation) PinModuleServiceTemplateVersion(ctx core.ContextParams, option metadata.PinModuleServiceTemplateVersionOption) errors.CCErrorCoder {
+	if len(option.ModuleIDs) == 0 {
+		return ctx.Error.CCErrorf(common.CCErrCommParamsNeedSet, "bk_module_ids")
+	}
+
+	// modules must be created by the service template
+	moduleFilter := map[string]interface{}{
+		common.BKAppIDField:             option.BizID,
+		common.BKServiceTemplateIDField: option.ServiceTemplateID,
+		common.BKModuleIDField: map[string]interface{}{
+			common.BKDBIN: option.ModuleIDs,
+		},
+	}
+	count, err := p.dbProxy.Table(common.BKTableNameBaseModule).Find(moduleFilter).Count(ctx.Context)
+	if err != nil {
+		blog.Errorf("PinModuleServiceTemplateVersion failed, count modules failed, filter: %+v, err: %+v, rid: %s", moduleFilter, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count != uint64(len(option.ModuleIDs)) {
+		blog.Errorf("PinModuleServiceTemplateVersion failed, some modules not belong to service template, filter: %+v, count: %d, rid: %s", moduleFilter, count, ctx.ReqID)
+		return ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, "bk_module_ids")
+	}
+
+	pinFilter := map[string]interface{}{
+		common.BKModuleIDField: map[string]interface{}{
+			common.BKDBIN: option.ModuleIDs,
+		},
+	}
+	if option.Version == 0 {
+		if err := p.dbProxy.Table(common.BKTableNameModuleServiceTemplateVersion).Delete(ctx.Context, pinFilter); err != nil {
+			blog.Errorf("PinModuleServiceTemplateVersion failed, remove pins failed, filter: %+v, err: %+v, rid: %s", pinFilter, err, ctx.ReqID)
+			return ctx.Error.CCError(common.CCErrCommDBDeleteFailed)
+		}
+		return nil
+	}
+
+	if _, err := p.GetServiceTemplateVersion(ctx, option.ServiceTemplateID, option.Version); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, moduleID := range option.ModuleIDs {
+		pin := metadata.ModuleServiceTemplateVersion{
+			BizID:             option.BizID,
+			ModuleID:          moduleID,
+			ServiceTemplateID: option.ServiceTemplateID,
+			Version:           option.Version,
+			Modifier:          ctx.User,
+			LastTime:          now,
+			SupplierAccount:   ctx.SupplierAccount,
+		}
+		filter := map[string]interface{}{
+			common.BKModuleIDField: moduleID,
+		}
+		if err := p.dbProxy.Table(common.BKTableNameModuleServiceTemplateVersion).Upsert(ctx.Context, filter, pin); err != nil {
+			blog.Errorf("PinModuleServiceTemplateVersion failed, upsert pin failed, pin: %+v, err: %+v, rid: %s", pin, err, ctx.ReqID)
+			return ctx.Error.CCError(common.CCErrCommDBUpdateFailed)
+		}
+	}
+	return nil
+}
+
+func (p *processOperation) ListModuleServiceTemplateVersions(ctx core.ContextParams, option metadata.ListModuleServiceTemplateVersionOption) ([]metadata.ModuleServiceTemplateVersion, errors.CCErrorCoder) {
+	filter := map[string]interface{}{
+		common.BKAppIDField: option.BizID,
+	}
+	if option.ServiceTemplateID != 0 {
+		filter[common.BKServiceTemplateIDField] = option.ServiceTemplateID
+	}
+	if option.ModuleIDs != nil {
+		filter[common.BKModuleIDField] = map[string]interface{}{
+			common.BKDBIN: option.ModuleIDs,
+		}
+	}
+	pins := make([]metadata.ModuleServiceTemplateVersion, 0)
+	if err := p.dbProxy.Table(common.BKTableNameModuleServiceTemplateVersion).Find(filter).All(ctx.Context, &pins); err != nil {
+		blog.Errorf("ListModuleServiceTemplateVersions failed, mongodb failed, filter: %+v, err: %+v, rid: %s", filter, err, ctx.ReqID)
+		return nil, ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return pins, nil
+}
+
+// listModuleProcessTemplates returns the process templates of the version the module pinned to,
+// the latest process templates are returned if the module is not pinned.
+func (p *processOperation) listModuleProcessTemplates(ctx core.ContextParams, module *metadata.ModuleInst) ([]metadata.ProcessTemplate, errors.CCErrorCoder) {
+	pinOption := metadata.ListModuleServiceTemplateVersionOption{
+		BizID:             module.BizID,
+		ServiceTemplateID: module.ServiceTemplateID,
+		ModuleIDs:         []int64{module.ModuleID},
+	}
+	pins, err := p.ListModuleServiceTemplateVersions(ctx, pinOption)
+	if err != nil {
+		blog.Errorf("listModuleProcessTemplates failed, ListModuleServiceTemplateVersions failed, option: %+v, err: %+v, rid: %s", pinOption, err, ctx.ReqID)
+		return nil, err
+	}
+	for _, pin := range pins {
+		if pin.Version <= 0 {
+			continue
+		}
+		templateVersion, err := p.GetServiceTemplateVersion(ctx, module.ServiceTemplateID, pin.Version)
+		if err != nil {
+			blog.Errorf("listModuleProcessTemplates failed, GetServiceTemplateVersion failed, serviceTemplateID: %d, version: %d, err: %+v, rid: %s", module.ServiceTemplateID, pin.Version, err, ctx.ReqID)
+			return nil, err
+		}
+		return templateVersion.ProcessTemplates, nil
+	}
+
+	listOption := metadata.ListProcessTemplatesOption{
+		BusinessID:         module.BizID,
+		ServiceTemplateIDs: []int64{module.ServiceTemplateID},
+		Page: metadata.BasePage{
+			Limit: common.BKNoLimit,
+			Sort:  common.BKFieldID,
+		},
+	}
+	processTemplates, err := p.ListProcessTemplates(ctx, listOption)
+	if err != nil {
+		blog.Errorf("listModuleProcessTemplates failed, ListProcessTemplates failed, option: %+v, err: %+v, rid: %s", listOption, err, ctx.ReqID)
+		return nil, err
+	}
+	return processTemplates.Info, nil
+}
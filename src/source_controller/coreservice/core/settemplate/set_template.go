@@ -21,17 +21,20 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/templateattr"
 	"configcenter/src/storage/dal"
 )
 
 type setTemplateOperation struct {
-	dbProxy dal.RDB
+	dbProxy   dal.RDB
+	dependent templateattr.Dependence
 }
 
 // New create a new model manager instance
-func New(dbProxy dal.RDB) core.SetTemplateOperation {
+func New(dbProxy dal.RDB, dependent templateattr.Dependence) core.SetTemplateOperation {
 	setTplOps := &setTemplateOperation{
-		dbProxy: dbProxy,
+		dbProxy:   dbProxy,
+		dependent: dependent,
 	}
 	return setTplOps
 }
@@ -76,6 +79,7 @@ func (p *setTemplateOperation) CreateSetTemplate(ctx core.ContextParams, bizID i
 	setTemplate := metadata.SetTemplate{
 		Name:            option.Name,
 		BizID:           bizID,
+		Attributes:      option.Attributes,
 		Creator:         ctx.User,
 		Modifier:        ctx.User,
 		CreateTime:      now,
@@ -93,6 +97,14 @@ func (p *setTemplateOperation) CreateSetTemplate(ctx core.ContextParams, bizID i
 		return setTemplate, err
 	}
 
+	// validate template attributes
+	if setTemplate.Attributes == nil {
+		setTemplate.Attributes = make([]metadata.TemplateAttribute, 0)
+	}
+	if err := templateattr.Validate(ctx, p.dependent, bizID, common.BKInnerObjIDSet, setTemplate.Attributes); err != nil {
+		return setTemplate, err
+	}
+
 	// validate service template id
 	if option.ServiceTemplateIDs != nil && len(option.ServiceTemplateIDs) > 0 {
 		serviceTemplateIDs, err := p.ValidateServiceTemplateIDs(ctx, bizID, option.ServiceTemplateIDs...)
@@ -157,6 +169,9 @@ func (p *setTemplateOperation) UpdateSetTemplate(ctx core.ContextParams, setTemp
 
 	if errKey, err := option.Validate(); err != nil {
 		blog.Errorf("UpdateSetTemplate failed, update option validate failed, option: %+v, key: %s, err: %+v, rid: %s", option, errKey, err, ctx.ReqID)
+		if len(errKey) > 0 {
+			return setTemplate, ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, errKey)
+		}
 		return setTemplate, ctx.Error.CCError(common.CCErrCommHTTPBodyEmpty)
 	}
 
@@ -194,6 +209,13 @@ func (p *setTemplateOperation) UpdateSetTemplate(ctx core.ContextParams, setTemp
 		setTemplate.Name = option.Name
 	}
 
+	if option.Attributes != nil {
+		if err := templateattr.Validate(ctx, p.dependent, setTemplate.BizID, common.BKInnerObjIDSet, option.Attributes); err != nil {
+			return setTemplate, err
+		}
+		setTemplate.Attributes = option.Attributes
+	}
+
 	// TODO: add transaction
 	if option.ServiceTemplateIDs != nil {
 		serviceTemplateIDs, err := p.ValidateServiceTemplateIDs(ctx, setTemplate.BizID, option.ServiceTemplateIDs...)
@@ -0,0 +1,54 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package templateattr validates the attributes of the set templates and service templates,
+// which are the property values of the sets and modules created by the templates.
+package templateattr
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/instances"
+)
+
+// Dependence the dependent methods to validate the template attributes, the same as the instance validator's
+type Dependence interface {
+	instances.OperationDependences
+}
+
+// Validate make sure every template attribute is a property of the object which is visible to the business,
+// and the value is valid for the property as the instance is created with it.
+func Validate(ctx core.ContextParams, dependent Dependence, bizID int64, objID string, attributes []metadata.TemplateAttribute) errors.CCErrorCoder {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	valid, err := instances.NewValidator(ctx, dependent, objID, bizID)
+	if err != nil {
+		blog.Errorf("validate template attributes failed, init validator of %s failed, bizID: %d, err: %v, rid: %s", objID, bizID, err, ctx.ReqID)
+		return ctx.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	for _, attribute := range attributes {
+		if err := valid.ValidValue(ctx, attribute.PropertyID, attribute.Value); err != nil {
+			blog.Errorf("validate template attributes failed, attribute %s of %s is invalid, bizID: %d, err: %v, rid: %s", attribute.PropertyID, objID, bizID, err, ctx.ReqID)
+			if ccErr, ok := err.(errors.CCErrorCoder); ok {
+				return ccErr
+			}
+			return ctx.Error.CCErrorf(common.CCErrCommParamsInvalid, attribute.PropertyID)
+		}
+	}
+	return nil
+}
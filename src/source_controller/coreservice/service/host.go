@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/source_controller/coreservice/core/instances"
 )
 
 func (s *coreService) TransferHostToInnerModule(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
@@ -170,6 +171,10 @@ func (s *coreService) GetHosts(params core.ContextParams, pathParams, queryParam
 		blog.Errorf("get object failed type:%s,input:%v error:%v, rid: %s", common.BKInnerObjIDHost, dat, err, params.ReqID)
 		return nil, params.Error.CCError(common.CCErrHostSelectInst)
 	}
+	if err := instances.FillReferenceDisplay(params, s.db, common.BKInnerObjIDHost, result); err != nil {
+		// the display info is auxiliary, do not fail the search
+		blog.Warnf("get hosts, fill reference display info failed, err: %v, rid: %s", err, params.ReqID)
+	}
 
 	count, err := s.db.Table(common.BKTableNameBaseHost).Find(condition).Count(params.Context)
 	if err != nil {
@@ -222,3 +227,53 @@ func (s *coreService) GetHostSnap(params core.ContextParams, pathParams, queryPa
 		Data: result,
 	}, nil
 }
+
+func (s *coreService) SearchHostSnapHistory(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.HostSnapHistoryOption{}
+	if err := data.MarshalJSONInto(&input); nil != err {
+		blog.Errorf("search host snapshot history failed, MarshalJSONInto error, err: %v, input: %v, rid: %s", err, data, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if input.HostID <= 0 {
+		return nil, params.Error.CCErrorf(common.CCErrCommParamsNeedSet, common.BKHostIDField)
+	}
+
+	cond := map[string]interface{}{
+		common.BKHostIDField: input.HostID,
+	}
+	timeCond := make(map[string]interface{})
+	if input.StartTime != nil {
+		timeCond[common.BKDBGTE] = *input.StartTime
+	}
+	if input.EndTime != nil {
+		timeCond[common.BKDBLTE] = *input.EndTime
+	}
+	if len(timeCond) > 0 {
+		cond[common.CreateTimeField] = timeCond
+	}
+	cond = util.SetQueryOwner(cond, params.SupplierAccount)
+
+	count, err := s.db.Table(common.BKTableNameHostSnapHistory).Find(cond).Count(params)
+	if err != nil {
+		blog.Errorf("search host snapshot history failed, count failed, cond: %+v, err: %v, rid: %s", cond, err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	histories := make([]metadata.HostSnapHistory, 0)
+	query := s.db.Table(common.BKTableNameHostSnapHistory).Find(cond).Sort("-" + common.CreateTimeField).Start(uint64(input.Page.Start))
+	if input.Page.Limit > 0 {
+		query = query.Limit(uint64(input.Page.Limit))
+	}
+	if err := query.All(params, &histories); err != nil {
+		blog.Errorf("search host snapshot history failed, cond: %+v, err: %v, rid: %s", cond, err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return struct {
+		Count int64                      `json:"count"`
+		Info  []metadata.HostSnapHistory `json:"info"`
+	}{
+		Count: int64(count),
+		Info:  histories,
+	}, nil
+}
@@ -115,3 +115,15 @@ func (s *coreService) CascadeDeleteModelInstances(params core.ContextParams, pat
 	}
 	return s.core.InstanceOperation().CascadeDeleteModelInstance(params, pathParams("bk_obj_id"), inputData)
 }
+
+// RefreshComputedValuesTask the task_server callback to backfill the computed values of the model instances
+func (s *coreService) RefreshComputedValuesTask(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	task := metadata.RefreshComputedValuesTask{}
+	if err := data.MarshalJSONInto(&task); nil != err {
+		return nil, err
+	}
+	if task.ObjectID == "" {
+		return nil, params.Error.Errorf(common.CCErrCommParamsNeedSet, common.BKObjIDField)
+	}
+	return nil, s.core.InstanceOperation().RefreshComputedValues(params, task.ObjectID)
+}
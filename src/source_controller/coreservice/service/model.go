@@ -166,7 +166,7 @@ func (s *coreService) SearchModel(params core.ContextParams, pathParams, queryPa
 		for attributeIdx := range dataResult.Info[modelIdx].Attributes {
 			dataResult.Info[modelIdx].Attributes[attributeIdx].PropertyName = s.TranslatePropertyName(params.Lang, &dataResult.Info[modelIdx].Attributes[attributeIdx])
 			dataResult.Info[modelIdx].Attributes[attributeIdx].Placeholder = s.TranslatePlaceholder(params.Lang, &dataResult.Info[modelIdx].Attributes[attributeIdx])
-			if dataResult.Info[modelIdx].Attributes[attributeIdx].PropertyType == common.FieldTypeEnum {
+			if dataResult.Info[modelIdx].Attributes[attributeIdx].PropertyType == common.FieldTypeEnum || dataResult.Info[modelIdx].Attributes[attributeIdx].PropertyType == common.FieldTypeEnumMulti {
 				dataResult.Info[modelIdx].Attributes[attributeIdx].Option = s.TranslateEnumName(params.Context, params.Lang, &dataResult.Info[modelIdx].Attributes[attributeIdx], dataResult.Info[modelIdx].Attributes[attributeIdx].Option)
 			}
 		}
@@ -401,7 +401,7 @@ func (s *coreService) SearchModelAttributesByCondition(params core.ContextParams
 	for index := range dataResult.Info {
 		dataResult.Info[index].PropertyName = s.TranslatePropertyName(params.Lang, &dataResult.Info[index])
 		dataResult.Info[index].Placeholder = s.TranslatePlaceholder(params.Lang, &dataResult.Info[index])
-		if dataResult.Info[index].PropertyType == common.FieldTypeEnum {
+		if dataResult.Info[index].PropertyType == common.FieldTypeEnum || dataResult.Info[index].PropertyType == common.FieldTypeEnumMulti {
 			dataResult.Info[index].Option = s.TranslateEnumName(params.Context, params.Lang, &dataResult.Info[index], dataResult.Info[index].Option)
 		}
 	}
@@ -425,7 +425,7 @@ func (s *coreService) SearchModelAttributes(params core.ContextParams, pathParam
 	for index := range dataResult.Info {
 		dataResult.Info[index].PropertyName = s.TranslatePropertyName(params.Lang, &dataResult.Info[index])
 		dataResult.Info[index].Placeholder = s.TranslatePlaceholder(params.Lang, &dataResult.Info[index])
-		if dataResult.Info[index].PropertyType == common.FieldTypeEnum {
+		if dataResult.Info[index].PropertyType == common.FieldTypeEnum || dataResult.Info[index].PropertyType == common.FieldTypeEnumMulti {
 			dataResult.Info[index].Option = s.TranslateEnumName(params.Context, params.Lang, &dataResult.Info[index], dataResult.Info[index].Option)
 		}
 	}
@@ -13,6 +13,7 @@
 package service
 
 import (
+	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql/mongo"
@@ -95,3 +96,19 @@ func (s *coreService) CascadeDeleteInstances(ctx core.ContextParams, objIDS []st
 
 	return nil
 }
+
+// DispatchComputedValuesRefresh create the task_server task to evaluate the computed attributes of all the
+// instances of the model again, the task is handled by RefreshComputedValuesTask
+func (s *coreService) DispatchComputedValuesRefresh(ctx core.ContextParams, objID string) error {
+	tasks := []interface{}{metadata.RefreshComputedValuesTask{ObjectID: objID}}
+	result, err := s.engin.CoreAPI.TaskServer().Task().Create(ctx, ctx.Header, common.RefreshComputedValuesTaskName, objID, tasks)
+	if err != nil {
+		blog.Errorf("dispatch refresh computed values task of %s failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return err
+	}
+	if !result.Result {
+		blog.Errorf("dispatch refresh computed values task of %s failed, result: %+v, rid: %s", objID, result, ctx.ReqID)
+		return ctx.Error.New(result.Code, result.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) CreateCustomChart(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	chart := metadata.CustomChart{}
+	if err := data.MarshalJSONInto(&chart); err != nil {
+		blog.Errorf("create custom chart failed, decode body failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if field, err := chart.Validate(); err != nil {
+		blog.Errorf("create custom chart failed, field %s invalid, err: %v, rid: %s", field, err, params.ReqID)
+		return nil, params.Error.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	return s.core.StatisticOperation().CreateCustomChart(params, chart)
+}
+
+func (s *coreService) UpdateCustomChart(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseUint(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("update custom chart failed, parse id failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCErrorf(common.CCErrCommParamsInvalid, "id")
+	}
+	chart := metadata.CustomChart{}
+	if err := data.MarshalJSONInto(&chart); err != nil {
+		blog.Errorf("update custom chart failed, decode body failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if field, err := chart.Validate(); err != nil {
+		blog.Errorf("update custom chart failed, field %s invalid, err: %v, rid: %s", field, err, params.ReqID)
+		return nil, params.Error.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	return s.core.StatisticOperation().UpdateCustomChart(params, id, chart)
+}
+
+func (s *coreService) DeleteCustomChart(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseUint(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("delete custom chart failed, parse id failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCErrorf(common.CCErrCommParamsInvalid, "id")
+	}
+
+	return nil, s.core.StatisticOperation().DeleteCustomChart(params, id)
+}
+
+func (s *coreService) SearchCustomChart(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.CustomChartSearchOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("search custom chart failed, decode body failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	return s.core.StatisticOperation().SearchCustomChart(params, option)
+}
+
+func (s *coreService) SearchCustomChartData(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	id, err := strconv.ParseUint(pathParams("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("search custom chart data failed, parse id failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCErrorf(common.CCErrCommParamsInvalid, "id")
+	}
+	option := metadata.CustomChartDataOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("search custom chart data failed, decode body failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	return s.core.StatisticOperation().SearchCustomChartData(params, id, option)
+}
+
+func (s *coreService) ComputeCustomChart(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.CustomChartComputeOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("compute custom chart failed, decode body failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	return s.core.StatisticOperation().ComputeCustomChart(params, option)
+}
@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// snapshotExistsBatch the count of the snapshot keys checked in one redis request
+const snapshotExistsBatch = 500
+
+func (s *coreService) StatisticInventory(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.InventoryStatisticsOption{}
+	if err := data.MarshalJSONInto(&option); err != nil {
+		blog.Errorf("statistic inventory failed, decode body failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.StatisticOperation().StatisticInventory(params, option)
+	if err != nil {
+		return nil, err
+	}
+
+	// the snapshots reported by the agents are kept in redis, the hosts whose snapshot expired have no agent reporting
+	hosts := make([]mapstr.MapStr, 0)
+	if err := s.db.Table(common.BKTableNameBaseHost).Find(mapstr.MapStr{}).Fields(common.BKHostIDField).All(params, &hosts); err != nil {
+		blog.Errorf("statistic inventory failed, search hosts failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Error.CCError(common.CCErrCommDBSelectFailed)
+	}
+	keys := make([]string, 0, snapshotExistsBatch)
+	withSnapshot := int64(0)
+	for index, host := range hosts {
+		hostID, err := host.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Warnf("statistic inventory, invalid host id %v, rid: %s", host[common.BKHostIDField], params.ReqID)
+		} else {
+			keys = append(keys, common.RedisSnapKeyPrefix+strconv.FormatInt(hostID, 10))
+		}
+		if len(keys) < snapshotExistsBatch && index != len(hosts)-1 {
+			continue
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		exists, err := s.cache.ExistsMulti(keys...).Result()
+		if err != nil {
+			blog.Errorf("statistic inventory failed, check host snapshots failed, err: %v, rid: %s", err, params.ReqID)
+			return nil, params.Error.CCError(common.CCErrHostGetSnapshot)
+		}
+		withSnapshot += exists
+		keys = keys[:0]
+	}
+	result.HostsWithoutSnapshot = int64(len(hosts)) - withSnapshot
+
+	return result, nil
+}
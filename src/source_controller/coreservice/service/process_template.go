@@ -101,6 +101,34 @@ func (s *coreService) UpdateProcessTemplate(params core.ContextParams, pathParam
 	return result, nil
 }
 
+func (s *coreService) AcceptProcessTemplateUpstream(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	processTemplateIDStr := pathParams(common.BKProcessTemplateIDField)
+	if len(processTemplateIDStr) == 0 {
+		blog.Errorf("AcceptProcessTemplateUpstream failed, path parameter `%s` empty, rid: %s", common.BKProcessTemplateIDField, params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsInvalid, common.BKProcessTemplateIDField)
+	}
+
+	processTemplateID, err := strconv.ParseInt(processTemplateIDStr, 10, 64)
+	if err != nil {
+		blog.Errorf("AcceptProcessTemplateUpstream failed, convert path parameter %s to int failed, value: %s, err: %v, rid: %s", common.BKProcessTemplateIDField, processTemplateIDStr, err, params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsInvalid, common.BKProcessTemplateIDField)
+	}
+
+	option := metadata.AcceptProcessTemplateUpstreamOption{}
+	if err := mapstr.DecodeFromMapStr(&option, data); err != nil {
+		blog.Errorf("AcceptProcessTemplateUpstream failed, decode request body failed, body: %+v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.ProcessOperation().AcceptProcessTemplateUpstream(params, processTemplateID, option)
+	if err != nil {
+		blog.Errorf("AcceptProcessTemplateUpstream failed, err: %+v, rid: %s", err, params.ReqID)
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (s *coreService) DeleteProcessTemplate(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
 	processTemplateIDStr := pathParams(common.BKProcessTemplateIDField)
 	if len(processTemplateIDStr) == 0 {
@@ -121,7 +121,7 @@ func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err
 		auditlog.New(db),
 		process.New(db, s, cache),
 		label.New(db),
-		settemplate.New(db),
+		settemplate.New(db, s),
 		operation.New(db),
 	)
 	return nil
@@ -70,6 +70,7 @@ func (s *coreService) initModelInstances() {
 	s.addAction(http.MethodPost, "/read/model/{bk_obj_id}/instances", s.SearchModelInstances, nil)
 	s.addAction(http.MethodDelete, "/delete/model/{bk_obj_id}/instance", s.DeleteModelInstances, nil)
 	s.addAction(http.MethodDelete, "/delete/model/{bk_obj_id}/instance/cascade", s.CascadeDeleteModelInstances, nil)
+	s.addAction(http.MethodPost, "/internal/task/refresh_computed_values", s.RefreshComputedValuesTask, nil)
 }
 
 func (s *coreService) initAssociationKind() {
@@ -123,6 +124,7 @@ func (s *coreService) host() {
 	s.addAction(http.MethodGet, "/find/host/{bk_host_id}", s.GetHostByID, nil)
 	s.addAction(http.MethodPost, "/findmany/hosts/search", s.GetHosts, nil)
 	s.addAction(http.MethodGet, "/find/host/snapshot/{bk_host_id}", s.GetHostSnap, nil)
+	s.addAction(http.MethodPost, "/findmany/host/snapshot/history", s.SearchHostSnapHistory, nil)
 
 	s.addAction(http.MethodPost, "/find/host/lock", s.LockHost, nil)
 	s.addAction(http.MethodDelete, "/delete/host/lock", s.UnlockHost, nil)
@@ -182,6 +184,15 @@ func (s *coreService) initOperation() {
 	s.addAction(http.MethodPost, "/update/operation/chart/position", s.UpdateChartPosition, nil)
 	s.addAction(http.MethodPost, "/search/operation/chart/data", s.SearchTimerChartData, nil)
 	s.addAction(http.MethodPost, "/start/operation/chart/timer", s.TimerFreshData, nil)
+
+	s.addAction(http.MethodPost, "/create/operation/custom_chart", s.CreateCustomChart, nil)
+	s.addAction(http.MethodPut, "/update/operation/custom_chart/{id}", s.UpdateCustomChart, nil)
+	s.addAction(http.MethodDelete, "/delete/operation/custom_chart/{id}", s.DeleteCustomChart, nil)
+	s.addAction(http.MethodPost, "/search/operation/custom_chart", s.SearchCustomChart, nil)
+	s.addAction(http.MethodPost, "/search/operation/custom_chart/{id}/data", s.SearchCustomChartData, nil)
+	s.addAction(http.MethodPost, "/compute/operation/custom_chart", s.ComputeCustomChart, nil)
+
+	s.addAction(http.MethodPost, "/read/operation/inventory/statistics", s.StatisticInventory, nil)
 }
 
 func (s *coreService) label() {
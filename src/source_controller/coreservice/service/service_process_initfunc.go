@@ -34,6 +34,19 @@ func (s *coreService) initProcess() {
 	s.addAction(http.MethodPut, "/update/process/service_template/{service_template_id}", s.UpdateServiceTemplate, nil)
 	s.addAction(http.MethodDelete, "/delete/process/service_template/{service_template_id}", s.DeleteServiceTemplate, nil)
 
+	// service template version
+	s.addAction(http.MethodPost, "/create/process/service_template_version", s.CreateServiceTemplateVersion, nil)
+	s.addAction(http.MethodGet, "/find/process/service_template/{service_template_id}/version/{version}", s.GetServiceTemplateVersion, nil)
+	s.addAction(http.MethodPost, "/findmany/process/service_template_version", s.ListServiceTemplateVersions, nil)
+	s.addAction(http.MethodPut, "/update/process/module_service_template_version", s.PinModuleServiceTemplateVersion, nil)
+	s.addAction(http.MethodPost, "/findmany/process/module_service_template_version", s.ListModuleServiceTemplateVersions, nil)
+
+	// service template rollout
+	s.addAction(http.MethodPost, "/create/process/service_template_rollout", s.CreateServiceTemplateRollout, nil)
+	s.addAction(http.MethodGet, "/find/process/service_template_rollout/{id}", s.GetServiceTemplateRollout, nil)
+	s.addAction(http.MethodPost, "/findmany/process/service_template_rollout", s.ListServiceTemplateRollouts, nil)
+	s.addAction(http.MethodPut, "/update/process/service_template_rollout/{id}", s.UpdateServiceTemplateRollout, nil)
+
 	// service instance
 	s.addAction(http.MethodPost, "/create/process/service_instance", s.CreateServiceInstance, nil)
 	s.addAction(http.MethodGet, "/find/process/service_instance/{service_instance_id}", s.GetServiceInstance, nil)
@@ -48,6 +61,7 @@ func (s *coreService) initProcess() {
 	s.addAction(http.MethodGet, "/find/process/process_template/{process_template_id}", s.GetProcessTemplate, nil)
 	s.addAction(http.MethodPost, "/findmany/process/process_template", s.ListProcessTemplates, nil)
 	s.addAction(http.MethodPut, "/update/process/process_template/{process_template_id}", s.UpdateProcessTemplate, nil)
+	s.addAction(http.MethodPut, "/update/process/process_template/{process_template_id}/accept_upstream", s.AcceptProcessTemplateUpstream, nil)
 	s.addAction(http.MethodDelete, "/delete/process/process_template/{process_template_id}", s.DeleteProcessTemplate, nil)
 	s.addAction(http.MethodPost, "/delete/process/process_template", s.BatchDeleteProcessTemplate, nil)
 
@@ -0,0 +1,172 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) CreateServiceTemplateVersion(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.CreateServiceTemplateVersionOption{}
+	if err := mapstr.DecodeFromMapStr(&option, data); err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, decode request body failed, body: %+v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.ProcessOperation().CreateServiceTemplateVersion(params, option)
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, err: %+v, rid: %s", err, params.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) GetServiceTemplateVersion(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	serviceTemplateIDStr := pathParams(common.BKServiceTemplateIDField)
+	serviceTemplateID, err := strconv.ParseInt(serviceTemplateIDStr, 10, 64)
+	if err != nil {
+		blog.Errorf("GetServiceTemplateVersion failed, convert path parameter %s to int failed, value: %s, err: %v, rid: %s", common.BKServiceTemplateIDField, serviceTemplateIDStr, err, params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsInvalid, common.BKServiceTemplateIDField)
+	}
+
+	versionStr := pathParams(common.BKVersionField)
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil {
+		blog.Errorf("GetServiceTemplateVersion failed, convert path parameter %s to int failed, value: %s, err: %v, rid: %s", common.BKVersionField, versionStr, err, params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsInvalid, common.BKVersionField)
+	}
+
+	result, ccErr := s.core.ProcessOperation().GetServiceTemplateVersion(params, serviceTemplateID, version)
+	if ccErr != nil {
+		blog.Errorf("GetServiceTemplateVersion failed, err: %+v, rid: %s", ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return result, nil
+}
+
+func (s *coreService) ListServiceTemplateVersions(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListServiceTemplateVersionOption{}
+	if err := mapstr.DecodeFromMapStr(&option, data); err != nil {
+		blog.Errorf("ListServiceTemplateVersions failed, decode request body failed, body: %+v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.ProcessOperation().ListServiceTemplateVersions(params, option)
+	if err != nil {
+		blog.Errorf("ListServiceTemplateVersions failed, err: %+v, rid: %s", err, params.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) PinModuleServiceTemplateVersion(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.PinModuleServiceTemplateVersionOption{}
+	if err := mapstr.DecodeFromMapStr(&option, data); err != nil {
+		blog.Errorf("PinModuleServiceTemplateVersion failed, decode request body failed, body: %+v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	if err := s.core.ProcessOperation().PinModuleServiceTemplateVersion(params, option); err != nil {
+		blog.Errorf("PinModuleServiceTemplateVersion failed, err: %+v, rid: %s", err, params.ReqID)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *coreService) ListModuleServiceTemplateVersions(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListModuleServiceTemplateVersionOption{}
+	if err := mapstr.DecodeFromMapStr(&option, data); err != nil {
+		blog.Errorf("ListModuleServiceTemplateVersions failed, decode request body failed, body: %+v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.ProcessOperation().ListModuleServiceTemplateVersions(params, option)
+	if err != nil {
+		blog.Errorf("ListModuleServiceTemplateVersions failed, err: %+v, rid: %s", err, params.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) CreateServiceTemplateRollout(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	rollout := metadata.ServiceTemplateRollout{}
+	if err := mapstr.DecodeFromMapStr(&rollout, data); err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, decode request body failed, body: %+v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.ProcessOperation().CreateServiceTemplateRollout(params, rollout)
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, err: %+v, rid: %s", err, params.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) GetServiceTemplateRollout(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	rolloutIDStr := pathParams(common.BKFieldID)
+	rolloutID, err := strconv.ParseInt(rolloutIDStr, 10, 64)
+	if err != nil {
+		blog.Errorf("GetServiceTemplateRollout failed, convert path parameter %s to int failed, value: %s, err: %v, rid: %s", common.BKFieldID, rolloutIDStr, err, params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+
+	result, ccErr := s.core.ProcessOperation().GetServiceTemplateRollout(params, rolloutID)
+	if ccErr != nil {
+		blog.Errorf("GetServiceTemplateRollout failed, err: %+v, rid: %s", ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return result, nil
+}
+
+func (s *coreService) ListServiceTemplateRollouts(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	option := metadata.ListServiceTemplateRolloutOption{}
+	if err := mapstr.DecodeFromMapStr(&option, data); err != nil {
+		blog.Errorf("ListServiceTemplateRollouts failed, decode request body failed, body: %+v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, err := s.core.ProcessOperation().ListServiceTemplateRollouts(params, option)
+	if err != nil {
+		blog.Errorf("ListServiceTemplateRollouts failed, err: %+v, rid: %s", err, params.ReqID)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *coreService) UpdateServiceTemplateRollout(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	rolloutIDStr := pathParams(common.BKFieldID)
+	rolloutID, err := strconv.ParseInt(rolloutIDStr, 10, 64)
+	if err != nil {
+		blog.Errorf("UpdateServiceTemplateRollout failed, convert path parameter %s to int failed, value: %s, err: %v, rid: %s", common.BKFieldID, rolloutIDStr, err, params.ReqID)
+		return nil, params.Error.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)
+	}
+
+	option := metadata.UpdateServiceTemplateRolloutOption{}
+	if err := mapstr.DecodeFromMapStr(&option, data); err != nil {
+		blog.Errorf("UpdateServiceTemplateRollout failed, decode request body failed, body: %+v, err: %v, rid: %s", data, err, params.ReqID)
+		return nil, params.Error.Error(common.CCErrCommJSONUnmarshalFailed)
+	}
+
+	result, ccErr := s.core.ProcessOperation().UpdateServiceTemplateRollout(params, rolloutID, option)
+	if ccErr != nil {
+		blog.Errorf("UpdateServiceTemplateRollout failed, err: %+v, rid: %s", ccErr, params.ReqID)
+		return nil, ccErr
+	}
+	return result, nil
+}
@@ -158,6 +158,7 @@ func (f *Find) Limit(limit uint64) dal.Find {
 
 // All 查询多个
 func (f *Find) All(ctx context.Context, result interface{}) error {
+	span := startSpan(ctx, f.collName, "find")
 	start := time.Now()
 	f.dbc.Refresh()
 	query := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter)
@@ -166,6 +167,7 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 	query = query.Limit(int(f.limit))
 	query = query.Sort(f.sort...)
 	err := query.All(result)
+	endSpan(span, err)
 
 	rid := ctx.Value(common.ContextRequestIDField)
 	blog.V(5).InfoDepthf(1, "Find all cost %dms, rid: %v", time.Since(start)/time.Millisecond, rid)
@@ -174,12 +176,14 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 
 // One 查询一个
 func (f *Find) One(ctx context.Context, result interface{}) error {
+	span := startSpan(ctx, f.collName, "findOne")
 	f.dbc.Refresh()
 	start := time.Now()
 	err := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter).One(result)
 	if err == mgo.ErrNotFound {
 		err = dal.ErrDocumentNotFound
 	}
+	endSpan(span, err)
 	rid := ctx.Value(common.ContextRequestIDField)
 	blog.V(5).InfoDepthf(1, "Find one cost %dms, rid: %v", time.Since(start)/time.Millisecond, rid)
 	return err
@@ -187,29 +191,38 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 
 // Count 统计数量(非事务)
 func (f *Find) Count(ctx context.Context) (uint64, error) {
+	span := startSpan(ctx, f.collName, "count")
 	count, err := f.dbc.DB(f.dbname).C(f.collName).Find(f.filter).Count()
+	endSpan(span, err)
 	return uint64(count), err
 }
 
 // Insert 插入数据, docs 可以为 单个数据 或者 多个数据
 func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
+	span := startSpan(ctx, c.collName, "insert")
 	c.dbc.Refresh()
-	return c.dbc.DB(c.dbname).C(c.collName).Insert(util.ConverToInterfaceSlice(docs)...)
+	err := c.dbc.DB(c.dbname).C(c.collName).Insert(util.ConverToInterfaceSlice(docs)...)
+	endSpan(span, err)
+	return err
 }
 
 // Update 更新数据
 func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	span := startSpan(ctx, c.collName, "update")
 	c.dbc.Refresh()
 	data := bson.M{"$set": doc}
 	_, err := c.dbc.DB(c.dbname).C(c.collName).UpdateAll(filter, data)
+	endSpan(span, err)
 	return err
 }
 
 // upsert 更新数据
 func (c *Collection) Upsert(ctx context.Context, filter dal.Filter, doc interface{}) error {
+	span := startSpan(ctx, c.collName, "upsert")
 	c.dbc.Refresh()
 	data := bson.M{"$set": doc}
 	_, err := c.dbc.DB(c.dbname).C(c.collName).Upsert(filter, data)
+	endSpan(span, err)
 	return err
 }
 
@@ -224,14 +237,18 @@ func (c *Collection) UpdateMultiModel(ctx context.Context, filter dal.Filter, up
 		data["$"+item.Op] = item.Doc
 	}
 
+	span := startSpan(ctx, c.collName, "update")
 	_, err := c.dbc.DB(c.dbname).C(c.collName).UpdateAll(filter, data)
+	endSpan(span, err)
 	return err
 }
 
 // Delete 删除数据
 func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
+	span := startSpan(ctx, c.collName, "delete")
 	c.dbc.Refresh()
 	_, err := c.dbc.DB(c.dbname).C(c.collName).RemoveAll(filter)
+	endSpan(span, err)
 	return err
 }
 
@@ -396,10 +413,16 @@ func (c *Collection) DropColumn(ctx context.Context, field string) error {
 
 // AggregateAll aggregate all operation
 func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
-	return c.dbc.DB(c.dbname).C(c.collName).Pipe(pipeline).All(result)
+	span := startSpan(ctx, c.collName, "aggregate")
+	err := c.dbc.DB(c.dbname).C(c.collName).Pipe(pipeline).All(result)
+	endSpan(span, err)
+	return err
 }
 
 // AggregateOne aggregate one operation
 func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
-	return c.dbc.DB(c.dbname).C(c.collName).Pipe(pipeline).One(result)
+	span := startSpan(ctx, c.collName, "aggregate")
+	err := c.dbc.DB(c.dbname).C(c.collName).Pipe(pipeline).One(result)
+	endSpan(span, err)
+	return err
 }
@@ -0,0 +1,37 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+
+	"configcenter/src/common/tracing"
+	"configcenter/src/storage/dal"
+)
+
+// startSpan start a client span of the db operation on the collection
+func startSpan(ctx context.Context, collName, operation string) *tracing.Span {
+	_, span := tracing.StartSpan(ctx, "mongodb "+operation+" "+collName, tracing.SpanKindClient)
+	span.SetAttribute(tracing.AttrDBSystem, "mongodb")
+	span.SetAttribute(tracing.AttrDBCollection, collName)
+	span.SetAttribute(tracing.AttrDBOperation, operation)
+	return span
+}
+
+// endSpan end the span of the db operation, the not found error is not regarded as a failure
+func endSpan(span *tracing.Span, err error) {
+	if err != nil && err != dal.ErrDocumentNotFound {
+		span.SetError(err)
+	}
+	span.End()
+}
@@ -76,7 +76,7 @@ func (c *Collection) Update(ctx context.Context, filter dal.Filter, doc interfac
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -121,7 +121,7 @@ func (c *Collection) UpdateMultiModel(ctx context.Context, filter dal.Filter, up
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -154,7 +154,7 @@ func (c *Collection) Delete(ctx context.Context, filter dal.Filter) error {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -188,7 +188,7 @@ func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -209,7 +209,7 @@ func (c *Collection) CreateIndex(ctx context.Context, index dal.Index) error {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(nil).Call(types.CommandRDBOperation, msg, &reply)
+	err := c.rpc.Option(nil).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -231,7 +231,7 @@ func (c *Collection) DropIndex(ctx context.Context, indexName string) error {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(nil).Call(types.CommandRDBOperation, msg, &reply)
+	err := c.rpc.Option(nil).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -252,7 +252,7 @@ func (c *Collection) Indexes(ctx context.Context) ([]dal.Index, error) {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(nil).Call(types.CommandRDBOperation, msg, &reply)
+	err := c.rpc.Option(nil).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return nil, err
 	}
@@ -300,7 +300,7 @@ func (c *Collection) DropColumn(ctx context.Context, field string) error {
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -333,7 +333,7 @@ func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, res
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -371,7 +371,7 @@ func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, res
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -75,7 +75,7 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 
 	// call
 	reply := types.OPReply{}
-	err := f.rpc.Option(&opt).Call(types.CommandRDBOperation, f.msg, &reply)
+	err := f.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, f.msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -106,7 +106,7 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 
 	// call
 	reply := types.OPReply{}
-	err := f.rpc.Option(&opt).Call(types.CommandRDBOperation, f.msg, &reply)
+	err := f.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, f.msg, &reply)
 	if err != nil {
 		return err
 	}
@@ -141,7 +141,7 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 
 	// call
 	reply := types.OPReply{}
-	err := f.rpc.Option(&opt).Call(types.CommandRDBOperation, f.msg, &reply)
+	err := f.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, f.msg, &reply)
 	if err != nil {
 		return 0, err
 	}
@@ -49,10 +49,16 @@ type Mongo struct {
 
 // NewWithDiscover returns new DB
 func NewWithDiscover(engine *backbone.Engine) (db *Mongo, err error) {
+	rpcConfig := rpc.NewConfigLoader("rpc")
+	engine.AddConfigUpdateHook(rpcConfig.Reload)
+	opts, err := rpcConfig.Config().ClientOptions()
+	if err != nil {
+		return nil, err
+	}
 	var pool *rpc.Pool
 	for retry := 1; retry <= maxRetry; retry++ {
 		tmServer := engine.ServiceManageInterface.TMServer()
-		p, err := rpc.NewClientPool("tcp", tmServer.GetServers, "/txn/v3/rpc")
+		p, err := rpc.NewClientPoolWithOptions("tcp", tmServer.GetServers, "/txn/v3/rpc", opts)
 		if err == nil {
 			pool = p
 			break
@@ -78,7 +84,7 @@ func NewWithDiscover(engine *backbone.Engine) (db *Mongo, err error) {
 	}
 	requestDuration = reg
 	return &Mongo{
-		rpc: NewPool(pool),
+		rpc: NewPool(pool, opts),
 	}, nil
 }
 
@@ -189,7 +195,7 @@ func (c *Mongo) NextSequence(ctx context.Context, sequenceName string) (uint64,
 
 	// call
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	if err != nil {
 		return 0, err
 	}
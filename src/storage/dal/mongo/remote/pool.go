@@ -13,11 +13,13 @@
 package remote
 
 import (
+	"context"
 	"strings"
 	"sync"
 	"time"
 
 	"configcenter/src/common/blog"
+	"configcenter/src/common/tracing"
 	"configcenter/src/common/util"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/rpc"
@@ -26,18 +28,27 @@ import (
 type pool struct {
 	cache map[string]rpc.Client
 	conn  rpc.Client
+	// opts the security options of the connections to the tmserver of the transactions
+	opts *rpc.Options
 	sync.RWMutex
 }
 
 type client struct {
 	p   *pool
 	opt *dal.JoinOption
+	ctx context.Context
 }
 
-func NewPool(client rpc.Client) *pool {
+// traceCarrier the message which can carry the trace context to the tmserver
+type traceCarrier interface {
+	SetTraceParent(traceParent string)
+}
+
+func NewPool(client rpc.Client, opts *rpc.Options) *pool {
 	return &pool{
 		cache: make(map[string]rpc.Client, 0),
 		conn:  client,
+		opts:  opts,
 	}
 }
 
@@ -48,7 +59,29 @@ func (p *pool) Option(opt *dal.JoinOption) *client {
 	}
 }
 
-func (c *client) Call(cmd string, input interface{}, result interface{}) error {
+// Context set the context of the call, the call is traced as the child of the span in the context
+func (c *client) Context(ctx context.Context) *client {
+	c.ctx = ctx
+	return c
+}
+
+func (c *client) Call(cmd string, input interface{}, result interface{}) (err error) {
+	if c.ctx != nil {
+		_, span := tracing.StartSpan(c.ctx, "rpc "+cmd, tracing.SpanKindClient)
+		span.SetAttribute(tracing.AttrRPCSystem, "cc-rpc")
+		span.SetAttribute(tracing.AttrRPCMethod, cmd)
+		if c.opt != nil {
+			span.SetAttribute(tracing.AttrRequestID, c.opt.RequestID)
+		}
+		if carrier, ok := input.(traceCarrier); ok && span.SpanContext().IsValid() {
+			carrier.SetTraceParent(tracing.FormatTraceParent(span.SpanContext()))
+		}
+		defer func() {
+			span.SetError(err)
+			span.End()
+		}()
+	}
+
 	if requestDuration != nil {
 		before := time.Now()
 		defer func() {
@@ -127,5 +160,5 @@ func (c *client) addRPCByAddr(addr string) (rpc.Client, error) {
 	getSrvFunc := func() ([]string, error) {
 		return []string{addr}, nil
 	}
-	return rpc.NewClientPool("tcp", getSrvFunc, "/txn/v3/rpc")
+	return rpc.NewClientPoolWithOptions("tcp", getSrvFunc, "/txn/v3/rpc", c.p.opts)
 }
@@ -64,7 +64,7 @@ func (c *Mongo) Commit(ctx context.Context) error {
 	}
 
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	c.TxnID = "" // clear TxnID
 	if err != nil {
 		return err
@@ -88,7 +88,7 @@ func (c *Mongo) Abort(ctx context.Context) error {
 	}
 
 	reply := types.OPReply{}
-	err := c.rpc.Option(&opt).Call(types.CommandRDBOperation, &msg, &reply)
+	err := c.rpc.Option(&opt).Context(ctx).Call(types.CommandRDBOperation, &msg, &reply)
 	c.TxnID = "" // clear TxnID
 	if err != nil {
 		return err
@@ -57,6 +57,7 @@ type client struct {
 	localAddr    string
 	err          error
 	codec        Codec
+	protocol     Protocol
 
 	response Message
 	done     *util.AtomicBool
@@ -65,7 +66,16 @@ type client struct {
 
 //NewClient replica client
 func NewClient(conn net.Conn, compress string) (*client, error) {
-	wire, err := NewBinaryWire(conn, compress)
+	return newClient(conn, Protocol{Version: MagicVersion, Codec: CodecBSON, Compress: compress})
+}
+
+// newClient returns a client using the negotiated protocol
+func newClient(conn net.Conn, proto Protocol) (*client, error) {
+	codec, ok := GetCodec(proto.Codec)
+	if !ok {
+		return nil, fmt.Errorf("[rpc] unsupported codec %s", proto.Codec)
+	}
+	wire, err := newProtocolWire(conn, proto)
 	if err != nil {
 		return nil, fmt.Errorf("[rpc] NewWire failed %v", err)
 	}
@@ -76,10 +86,11 @@ func NewClient(conn net.Conn, compress string) (*client, error) {
 		done:      util.NewBool(false),
 		send:      make(chan *Message, 1024),
 		messages:  map[uint32]*Message{},
-		codec:     BSONCodec,
+		codec:     codec,
+		protocol:  proto,
 		stream:    newStreamStore(),
 	}
-	blog.V(3).Infof("connected to rpc server %s", c.TargetID())
+	blog.V(3).Infof("connected to rpc server %s, protocol: %s", c.TargetID(), proto)
 	go c.write()
 	go c.read()
 	return c, nil
@@ -100,21 +111,44 @@ func Dial(connect string) (*client, error) {
 // DialHTTPPath connects to an HTTP RPC server
 // at the specified network address and path.
 func DialHTTPPath(network, address, path string) (*client, error) {
+	return DialHTTPPathWithOptions(network, address, path, nil)
+}
+
+// DialHTTPPathWithOptions connects to an HTTP RPC server at the specified network address and path,
+// the connection is secured by the options.
+func DialHTTPPathWithOptions(network, address, path string, opts *Options) (*client, error) {
 	var err error
 	conn, err := net.Dial("tcp", address)
 	if err != nil {
 		return nil, fmt.Errorf("[rpc] dail tcp error: %v", err)
 	}
-	io.WriteString(conn, "CONNECT "+path+" HTTP/1.0\n\n")
+	io.WriteString(conn, connectRequest(path, opts))
 
 	// Require successful HTTP response
 	// before switching to RPC protocol.
 	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
 	if err == nil && resp.Status == connected {
-		return NewClient(conn, "deflate")
+		proto, err := clientProtocol(resp, opts)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("[rpc] incompatible protocol with %s: %v", address, err)
+		}
+		secureConn, err := clientHandshake(conn, address, opts)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return newClient(secureConn, proto)
 	}
 	if err == nil {
-		err = errors.New("unexpected HTTP response: " + resp.Status)
+		switch resp.Status {
+		case tlsRequired, tlsNotEnabled, tokenRequired:
+			err = fmt.Errorf("rejected by the rpc server, the security options are not matched: %s", resp.Status)
+		case incompatible:
+			err = fmt.Errorf("rejected by the rpc server, incompatible protocol: %s", resp.Header.Get(headerReason))
+		default:
+			err = errors.New("unexpected HTTP response: " + resp.Status)
+		}
 	}
 	conn.Close()
 	return nil, &net.OpError{
@@ -265,8 +299,9 @@ func (c *client) handleRequest(req *Message) {
 	c.messages[req.seq] = req
 	c.messageMutex.Unlock()
 
-	c.send <- req
+	// the data is replaced by the response once it's sent, log it before sending
 	blog.V(7).Infof("[rpc client]sent message data: %s", req.Data)
+	c.send <- req
 }
 
 func (c *client) handleResponse(resp *Message) {
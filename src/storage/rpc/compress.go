@@ -61,9 +61,8 @@ func newCompressor(r io.Reader, w io.Writer, compress string) (*Compressor, erro
 	var err error
 
 	bw := bufio.NewWriterSize(w, writeBufferSize)
-	compress = ""
 	switch compress {
-	case "deflate":
+	case CompressDeflate:
 		zr = flate.NewReader(r)
 		zw, err = flate.NewWriter(bw, flate.DefaultCompression)
 		if err != nil {
@@ -29,12 +29,19 @@ type Pool struct {
 
 	getServer types.GetServerFunc
 	lastIndex int
+	opts      *Options
 }
 
 func NewClientPool(network string, getServer types.GetServerFunc, path string) (*Pool, error) {
+	return NewClientPoolWithOptions(network, getServer, path, nil)
+}
+
+// NewClientPoolWithOptions create a client pool, the connections are secured by the options
+func NewClientPoolWithOptions(network string, getServer types.GetServerFunc, path string, opts *Options) (*Pool, error) {
 	pool := &Pool{
 		conns:     make(chan Client, 40),
 		getServer: getServer,
+		opts:      opts,
 	}
 	var err error
 	var conn Client
@@ -92,7 +99,7 @@ func (p *Pool) new() (Client, error) {
 		return nil, fmt.Errorf("GetDailAddress %s, failed: %v", servers[p.lastIndex], err)
 	}
 
-	return DialHTTPPath("tcp", address, "/txn/v3/rpc")
+	return DialHTTPPathWithOptions("tcp", address, "/txn/v3/rpc", p.opts)
 }
 
 func (p *Pool) pop() Client {
@@ -0,0 +1,266 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the headers to negotiate the protocol, the client offers the values it supports in the order of preference,
+// and the server responds the chosen ones.
+const (
+	headerVersion  = "Cc-Rpc-Version"
+	headerCodec    = "Cc-Rpc-Codec"
+	headerCompress = "Cc-Rpc-Compress"
+	headerReason   = "Cc-Rpc-Reason"
+)
+
+// incompatible the status of the CONNECT response when the client and server have no common protocol
+var incompatible = "406 Incompatible Protocol Of CC RPC"
+
+// codec names
+const (
+	CodecBSON    = "bson"
+	CodecJSON    = "json"
+	CodecMsgpack = "msgpack"
+)
+
+// compression names
+const (
+	CompressNone    = "none"
+	CompressDeflate = "deflate"
+)
+
+// SupportedVersions the protocol versions supported by this process, in the order of preference
+var SupportedVersions = []uint16{MagicVersion}
+
+var codecs = map[string]Codec{
+	CodecBSON:    BSONCodec,
+	CodecJSON:    JSONCodec,
+	CodecMsgpack: MsgpackCodec,
+}
+
+var compressions = []string{CompressDeflate, CompressNone}
+
+// Protocol the protocol of the connection, it's negotiated when the connection is established
+type Protocol struct {
+	Version  uint16
+	Codec    string
+	Compress string
+}
+
+func (p Protocol) String() string {
+	return fmt.Sprintf("version: 0x%x, codec: %s, compress: %s", p.Version, p.Codec, p.Compress)
+}
+
+// legacyProtocol the protocol of the peers which do not negotiate, they use bson without compression
+var legacyProtocol = Protocol{Version: MagicVersion, Codec: CodecBSON, Compress: CompressNone}
+
+// GetCodec returns the codec of the name
+func GetCodec(name string) (Codec, bool) {
+	codec, ok := codecs[name]
+	return codec, ok
+}
+
+func (o *Options) versions() []uint16 {
+	if o == nil || len(o.Versions) == 0 {
+		return SupportedVersions
+	}
+	return o.Versions
+}
+
+func (o *Options) codecs() []string {
+	if o == nil || len(o.Codecs) == 0 {
+		return []string{CodecBSON}
+	}
+	return o.Codecs
+}
+
+func (o *Options) compressions() []string {
+	if o == nil || len(o.Compressions) == 0 {
+		return []string{CompressNone}
+	}
+	return o.Compressions
+}
+
+// validateProtocolOptions check whether the codecs and compressions are supported
+func validateProtocolOptions(codecList, compressList []string) error {
+	for _, name := range codecList {
+		if _, ok := codecs[name]; !ok {
+			return fmt.Errorf("unsupported codec %s", name)
+		}
+	}
+	for _, name := range compressList {
+		if !contains(compressions, name) {
+			return fmt.Errorf("unsupported compress %s", name)
+		}
+	}
+	return nil
+}
+
+// protocolHeaders returns the CONNECT request headers offering the protocols of the client
+func protocolHeaders(opts *Options) string {
+	return headerVersion + ": " + formatVersions(opts.versions()) + "\n" +
+		headerCodec + ": " + strings.Join(opts.codecs(), ", ") + "\n" +
+		headerCompress + ": " + strings.Join(opts.compressions(), ", ") + "\n"
+}
+
+// negotiateProtocol choose the protocol offered by the client, the first one in the client order which is
+// accepted by the server is chosen. the client without offer uses the legacy protocol.
+func negotiateProtocol(req *http.Request, opts *Options) (Protocol, error) {
+	if len(req.Header.Get(headerVersion)) == 0 {
+		return legacyProtocol, nil
+	}
+
+	proto := Protocol{}
+	found := false
+	for _, value := range splitHeader(req.Header.Get(headerVersion)) {
+		version, err := parseVersion(value)
+		if err != nil {
+			continue
+		}
+		for _, supported := range opts.versions() {
+			if version == supported {
+				proto.Version, found = version, true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		return proto, fmt.Errorf("no supported version in %s, supported versions: %s",
+			req.Header.Get(headerVersion), formatVersions(opts.versions()))
+	}
+
+	var err error
+	if proto.Codec, err = chooseFirst("codec", req.Header.Get(headerCodec), opts.acceptCodecs()); err != nil {
+		return proto, err
+	}
+	if proto.Compress, err = chooseFirst("compress", req.Header.Get(headerCompress), opts.acceptCompressions()); err != nil {
+		return proto, err
+	}
+	return proto, nil
+}
+
+// acceptCodecs the codecs accepted by the server, all the codecs are accepted if it's not set
+func (o *Options) acceptCodecs() []string {
+	if o == nil || len(o.Codecs) == 0 {
+		names := make([]string, 0, len(codecs))
+		for name := range codecs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	return o.Codecs
+}
+
+// acceptCompressions the compressions accepted by the server, all the compressions are accepted if it's not set
+func (o *Options) acceptCompressions() []string {
+	if o == nil || len(o.Compressions) == 0 {
+		return compressions
+	}
+	return o.Compressions
+}
+
+// protocolResponseHeaders returns the CONNECT response headers telling the client the chosen protocol
+func protocolResponseHeaders(proto Protocol) string {
+	return headerVersion + ": " + formatVersion(proto.Version) + "\n" +
+		headerCodec + ": " + proto.Codec + "\n" +
+		headerCompress + ": " + proto.Compress + "\n"
+}
+
+// clientProtocol returns the protocol chosen by the server, and check whether it's offered by the client.
+// the server without negotiation uses the legacy protocol.
+func clientProtocol(resp *http.Response, opts *Options) (Protocol, error) {
+	proto := legacyProtocol
+	if len(resp.Header.Get(headerVersion)) > 0 {
+		version, err := parseVersion(resp.Header.Get(headerVersion))
+		if err != nil {
+			return proto, fmt.Errorf("invalid version %s chosen by the server", resp.Header.Get(headerVersion))
+		}
+		proto = Protocol{
+			Version:  version,
+			Codec:    resp.Header.Get(headerCodec),
+			Compress: resp.Header.Get(headerCompress),
+		}
+	}
+
+	versionOffered := false
+	for _, version := range opts.versions() {
+		if version == proto.Version {
+			versionOffered = true
+		}
+	}
+	if !versionOffered {
+		return proto, fmt.Errorf("the server uses version 0x%x, which is not supported", proto.Version)
+	}
+	if !contains(opts.codecs(), proto.Codec) {
+		return proto, fmt.Errorf("the server uses codec %s, which is not in %v", proto.Codec, opts.codecs())
+	}
+	if !contains(opts.compressions(), proto.Compress) {
+		return proto, fmt.Errorf("the server uses compress %s, which is not in %v", proto.Compress, opts.compressions())
+	}
+	return proto, nil
+}
+
+func chooseFirst(kind, offered string, accepted []string) (string, error) {
+	for _, name := range splitHeader(offered) {
+		if contains(accepted, name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no accepted %s in %s, accepted: %s", kind, offered, strings.Join(accepted, ", "))
+}
+
+func splitHeader(value string) []string {
+	values := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func contains(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+func formatVersion(version uint16) string {
+	return strconv.FormatUint(uint64(version), 16)
+}
+
+func formatVersions(versions []uint16) string {
+	values := make([]string, 0, len(versions))
+	for _, version := range versions {
+		values = append(values, formatVersion(version))
+	}
+	return strings.Join(values, ", ")
+}
+
+func parseVersion(value string) (uint16, error) {
+	version, err := strconv.ParseUint(strings.TrimSpace(value), 16, 16)
+	return uint16(version), err
+}
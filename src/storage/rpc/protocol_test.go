@@ -0,0 +1,188 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"configcenter/src/common/util"
+)
+
+type echoData struct {
+	Name  string `json:"name" bson:"name" codec:"name"`
+	Count int64  `json:"count" bson:"count" codec:"count"`
+}
+
+func echo(msg Request) (interface{}, error) {
+	data := echoData{}
+	if err := msg.Decode(&data); err != nil {
+		return nil, err
+	}
+	data.Count++
+	return data, nil
+}
+
+func newProtocolServer(t *testing.T, opts *Options) (string, func()) {
+	rpc := NewServer()
+	rpc.SetOptions(opts)
+	rpc.Handle("echo", echo)
+	mux := http.NewServeMux()
+	mux.Handle("/rpc", rpc)
+	ts := httptest.NewServer(mux)
+
+	address, err := util.GetDailAddress(ts.URL)
+	require.NoError(t, err)
+	return address, ts.Close
+}
+
+func callEcho(t *testing.T, cli Client) {
+	result := echoData{}
+	require.NoError(t, cli.Call("echo", echoData{Name: "cc", Count: 1}, &result))
+	require.Equal(t, echoData{Name: "cc", Count: 2}, result)
+}
+
+func TestNegotiateProtocol(t *testing.T) {
+	address, closeFn := newProtocolServer(t, nil)
+	defer closeFn()
+
+	for _, codec := range []string{CodecBSON, CodecJSON, CodecMsgpack} {
+		for _, compress := range []string{CompressNone, CompressDeflate} {
+			cli, err := DialHTTPPathWithOptions("tcp", address, "/rpc",
+				&Options{Codecs: []string{codec}, Compressions: []string{compress}})
+			require.NoError(t, err)
+			require.Equal(t, codec, cli.protocol.Codec)
+			require.Equal(t, compress, cli.protocol.Compress)
+			require.Equal(t, MagicVersion, cli.protocol.Version)
+			callEcho(t, cli)
+			cli.Close()
+		}
+	}
+}
+
+func TestNegotiateServerPreference(t *testing.T) {
+	address, closeFn := newProtocolServer(t, &Options{Codecs: []string{CodecJSON}, Compressions: []string{CompressNone}})
+	defer closeFn()
+
+	// the first codec of the client accepted by the server is chosen
+	cli, err := DialHTTPPathWithOptions("tcp", address, "/rpc",
+		&Options{Codecs: []string{CodecMsgpack, CodecJSON}, Compressions: []string{CompressDeflate, CompressNone}})
+	require.NoError(t, err)
+	require.Equal(t, Protocol{Version: MagicVersion, Codec: CodecJSON, Compress: CompressNone}, cli.protocol)
+	callEcho(t, cli)
+	cli.Close()
+
+	// no common codec
+	_, err = DialHTTPPathWithOptions("tcp", address, "/rpc", &Options{Codecs: []string{CodecBSON}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "incompatible protocol")
+	require.Contains(t, err.Error(), "no accepted codec")
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	address, closeFn := newProtocolServer(t, &Options{Versions: []uint16{MagicVersion, 0x1bfe}})
+	defer closeFn()
+
+	// the first version in the client order which is accepted by the server is chosen
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, connectRequest("/rpc", &Options{Versions: []uint16{0x1bff, 0x1bfe, MagicVersion}}))
+	require.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	require.NoError(t, err)
+	require.Equal(t, connected, resp.Status)
+	require.Equal(t, formatVersion(0x1bfe), resp.Header.Get(headerVersion))
+
+	// the client only supports a version unknown to the server
+	conn, err = net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, connectRequest("/rpc", &Options{Versions: []uint16{0x1bff}}))
+	require.NoError(t, err)
+	resp, err = http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	require.NoError(t, err)
+	require.Equal(t, incompatible, resp.Status)
+	require.Contains(t, resp.Header.Get(headerReason), "no supported version")
+}
+
+// TestLegacyClient the client which does not negotiate uses bson without compression
+func TestLegacyClient(t *testing.T) {
+	address, closeFn := newProtocolServer(t, nil)
+	defer closeFn()
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	_, err = io.WriteString(conn, "CONNECT /rpc HTTP/1.0\n\n")
+	require.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	require.NoError(t, err)
+	require.Equal(t, connected, resp.Status)
+
+	cli, err := NewClient(conn, CompressNone)
+	require.NoError(t, err)
+	defer cli.Close()
+	callEcho(t, cli)
+}
+
+// TestLegacyServer the server which does not negotiate uses bson without compression
+func TestLegacyServer(t *testing.T) {
+	rpc := NewServer()
+	rpc.Handle("echo", echo)
+	ts := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		conn, _, err := resp.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "HTTP/1.0 "+connected+"\n\n")
+		session, err := NewServerSession(rpc, conn, CompressNone)
+		if err != nil {
+			return
+		}
+		session.Run()
+	}))
+	defer ts.Close()
+	address, err := util.GetDailAddress(ts.URL)
+	require.NoError(t, err)
+
+	cli, err := DialHTTPPathWithOptions("tcp", address, "/rpc",
+		&Options{Codecs: []string{CodecMsgpack, CodecBSON}, Compressions: []string{CompressDeflate, CompressNone}})
+	require.NoError(t, err)
+	require.Equal(t, legacyProtocol, cli.protocol)
+	callEcho(t, cli)
+	cli.Close()
+
+	// the client does not accept the legacy protocol
+	_, err = DialHTTPPathWithOptions("tcp", address, "/rpc", &Options{Codecs: []string{CodecJSON}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "incompatible protocol")
+}
+
+func TestParseProtocolConfig(t *testing.T) {
+	conf, err := ParseConfigFromKV("rpc", map[string]string{"rpc.codec": "msgpack, bson", "rpc.compress": "deflate"})
+	require.NoError(t, err)
+	require.Equal(t, []string{CodecMsgpack, CodecBSON}, conf.Codecs)
+	require.Equal(t, []string{CompressDeflate}, conf.Compressions)
+
+	_, err = ParseConfigFromKV("rpc", map[string]string{"rpc.codec": "xml"})
+	require.Error(t, err)
+	_, err = ParseConfigFromKV("rpc", map[string]string{"rpc.compress": "gzip"})
+	require.Error(t, err)
+}
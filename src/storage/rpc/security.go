@@ -0,0 +1,352 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/ssl"
+)
+
+// the headers of the CONNECT request, which tell the server the security options of the client
+const (
+	headerTLS  = "Cc-Rpc-Tls"
+	headerAuth = "Cc-Rpc-Auth"
+
+	authToken = "token"
+)
+
+// the status of the CONNECT response when the security options of the client and server are not matched
+var (
+	tlsRequired   = "403 TLS Required By CC RPC"
+	tlsNotEnabled = "400 TLS Not Enabled On CC RPC"
+	tokenRequired = "401 Token Required By CC RPC"
+)
+
+var (
+	handshakeTimeout = 10 * time.Second
+	// maxTokenLength limit the token length, the token is read before the client is authenticated
+	maxTokenLength uint32 = 4096
+)
+
+// Errors of the security handshake
+var (
+	ErrTokenTooLong  = errors.New("token too long")
+	ErrTokenMismatch = errors.New("token mismatch")
+)
+
+// Config the config of the rpc connection. the connection is upgraded to TLS after the CONNECT request
+// if TLS is enabled, then the client is authenticated by the shared token if it's set.
+type Config struct {
+	TLS   TLSConfig
+	Token string
+	// Codecs the codecs offered by the client in the order of preference, or accepted by the server
+	Codecs []string
+	// Compressions the compressions offered by the client in the order of preference, or accepted by the server
+	Compressions []string
+}
+
+// TLSConfig the TLS config of the rpc connection, the files are the same with the ones of the http server.
+// the server requires and verifies the client certificate if the CAFile is set, which is the mutual TLS.
+type TLSConfig struct {
+	Enable bool
+	// CAFile the trusted root certificates, used to verify the server for the client and the client for the server
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	// Password the password to decrypt the key file
+	Password string
+	// ServerName used to verify the server certificate, the host of the server address is used if it's not set
+	ServerName string
+	// InsecureSkipVerify the client does not verify the server certificate, for testing only
+	InsecureSkipVerify bool
+}
+
+// ParseConfigFromKV returns the rpc config, the security is disabled if it's not configured
+func ParseConfigFromKV(prefix string, configMap map[string]string) (Config, error) {
+	conf := Config{
+		TLS: TLSConfig{
+			CAFile:     configMap[prefix+".caFile"],
+			CertFile:   configMap[prefix+".certFile"],
+			KeyFile:    configMap[prefix+".keyFile"],
+			Password:   configMap[prefix+".certPassword"],
+			ServerName: configMap[prefix+".serverName"],
+		},
+		Token:        configMap[prefix+".token"],
+		Codecs:       splitHeader(configMap[prefix+".codec"]),
+		Compressions: splitHeader(configMap[prefix+".compress"]),
+	}
+
+	var err error
+	if value, ok := configMap[prefix+".tls"]; ok && len(value) > 0 {
+		if conf.TLS.Enable, err = strconv.ParseBool(value); err != nil {
+			return conf, fmt.Errorf("invalid %s.tls %s, should be true or false", prefix, value)
+		}
+	}
+	if value, ok := configMap[prefix+".insecureSkipVerify"]; ok && len(value) > 0 {
+		if conf.TLS.InsecureSkipVerify, err = strconv.ParseBool(value); err != nil {
+			return conf, fmt.Errorf("invalid %s.insecureSkipVerify %s, should be true or false", prefix, value)
+		}
+	}
+	if uint32(len(conf.Token)) > maxTokenLength {
+		return conf, fmt.Errorf("invalid %s.token, %v", prefix, ErrTokenTooLong)
+	}
+	if err := validateProtocolOptions(conf.Codecs, conf.Compressions); err != nil {
+		return conf, fmt.Errorf("invalid %s config, %v", prefix, err)
+	}
+	return conf, nil
+}
+
+// ConfigLoader keeps the rpc config up to date with the process config, the config takes effect on the
+// new connections. Reload is registered as the config update hook of the backbone engine.
+type ConfigLoader struct {
+	prefix string
+	lock   sync.RWMutex
+	config Config
+}
+
+// NewConfigLoader returns the loader of the rpc config under the prefix of the process config
+func NewConfigLoader(prefix string) *ConfigLoader {
+	return &ConfigLoader{prefix: prefix}
+}
+
+// Reload parse the rpc config from the process config map, the previous config is kept if it's invalid
+func (l *ConfigLoader) Reload(configMap map[string]string) {
+	conf, err := ParseConfigFromKV(l.prefix, configMap)
+	if err != nil {
+		blog.Errorf("parse rpc config failed, err: %v", err)
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.config = conf
+}
+
+// Config returns the latest loaded rpc config
+func (l *ConfigLoader) Config() Config {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.config
+}
+
+// ClientOptions returns the options used to dial the rpc server
+func (c Config) ClientOptions() (*Options, error) {
+	opts := c.options()
+	if !c.TLS.Enable {
+		return opts, nil
+	}
+
+	var err error
+	switch {
+	case len(c.TLS.CertFile) > 0 && len(c.TLS.KeyFile) > 0:
+		opts.TLSConfig, err = ssl.ClientTLSConfVerity(c.TLS.CAFile, c.TLS.CertFile, c.TLS.KeyFile, c.TLS.Password)
+	case len(c.TLS.CAFile) > 0:
+		opts.TLSConfig, err = ssl.ClientTslConfVerityServer(c.TLS.CAFile)
+	default:
+		opts.TLSConfig = ssl.ClientTslConfNoVerity()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load rpc client tls config failed, err: %v", err)
+	}
+	opts.TLSConfig.ServerName = c.TLS.ServerName
+	opts.TLSConfig.InsecureSkipVerify = c.TLS.InsecureSkipVerify
+	return opts, nil
+}
+
+// ServerOptions returns the options used to serve the rpc clients
+func (c Config) ServerOptions() (*Options, error) {
+	opts := c.options()
+	if !c.TLS.Enable {
+		return opts, nil
+	}
+	if len(c.TLS.CertFile) == 0 || len(c.TLS.KeyFile) == 0 {
+		return nil, errors.New("the cert file and key file of the rpc server are not set")
+	}
+
+	var err error
+	opts.TLSConfig, err = ssl.ServerTslConf(c.TLS.CAFile, c.TLS.CertFile, c.TLS.KeyFile, c.TLS.Password)
+	if err != nil {
+		return nil, fmt.Errorf("load rpc server tls config failed, err: %v", err)
+	}
+	return opts, nil
+}
+
+func (c Config) options() *Options {
+	return &Options{
+		Token:        c.Token,
+		Codecs:       c.Codecs,
+		Compressions: c.Compressions,
+	}
+}
+
+// Options the options of the rpc connection
+type Options struct {
+	// TLSConfig the connection is upgraded to TLS with it after the CONNECT request if it's not nil
+	TLSConfig *tls.Config
+	// Token the shared token to authenticate the client, the authentication is skipped if it's empty
+	Token string
+	// Codecs the codecs offered by the client in the order of preference, bson is used if it's empty.
+	// the codecs accepted by the server, all the codecs are accepted if it's empty.
+	Codecs []string
+	// Compressions the compressions offered by the client in the order of preference, none is used if it's empty.
+	// the compressions accepted by the server, all the compressions are accepted if it's empty.
+	Compressions []string
+	// Versions the protocol versions offered by the client or accepted by the server in the order of preference,
+	// SupportedVersions is used if it's empty.
+	Versions []uint16
+}
+
+func (o *Options) tlsEnabled() bool {
+	return o != nil && o.TLSConfig != nil
+}
+
+func (o *Options) token() string {
+	if o == nil {
+		return ""
+	}
+	return o.Token
+}
+
+// connectRequest returns the CONNECT request carrying the security options and the protocols of the client
+func connectRequest(path string, opts *Options) string {
+	request := "CONNECT " + path + " HTTP/1.0\n" + protocolHeaders(opts)
+	if opts.tlsEnabled() {
+		request += headerTLS + ": true\n"
+	}
+	if len(opts.token()) > 0 {
+		request += headerAuth + ": " + authToken + "\n"
+	}
+	return request + "\n"
+}
+
+// checkConnectRequest check whether the security options of the client match the server, returns the status
+// to reject the client if not matched.
+func checkConnectRequest(req *http.Request, opts *Options) (string, bool) {
+	clientTLS := req.Header.Get(headerTLS) == "true"
+	switch {
+	case opts.tlsEnabled() && !clientTLS:
+		return tlsRequired, false
+	case !opts.tlsEnabled() && clientTLS:
+		return tlsNotEnabled, false
+	case len(opts.token()) > 0 && req.Header.Get(headerAuth) != authToken:
+		return tokenRequired, false
+	}
+	return connected, true
+}
+
+// clientHandshake upgrade the connection to TLS and authenticate the client by the token after the CONNECT
+// request is accepted by the server.
+func clientHandshake(conn net.Conn, address string, opts *Options) (net.Conn, error) {
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return nil, err
+	}
+
+	if opts.tlsEnabled() {
+		conf := opts.TLSConfig.Clone()
+		if len(conf.ServerName) == 0 {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			conf.ServerName = host
+		}
+		tlsConn := tls.Client(conn, conf)
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("[rpc] tls handshake with %s failed: %v", address, err)
+		}
+		conn = tlsConn
+	}
+
+	if token := opts.token(); len(token) > 0 {
+		if err := writeString(conn, token); err != nil {
+			return nil, fmt.Errorf("[rpc] send token to %s failed: %v", address, err)
+		}
+		reason, err := readLimitedString(conn, maxTokenLength)
+		if err != nil {
+			return nil, fmt.Errorf("[rpc] read token authentication result from %s failed: %v", address, err)
+		}
+		if len(reason) > 0 {
+			return nil, fmt.Errorf("[rpc] token authentication to %s failed: %s", address, reason)
+		}
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// serverHandshake upgrade the connection to TLS and authenticate the client by the token after the CONNECT
+// request is accepted.
+func serverHandshake(conn net.Conn, req *http.Request, opts *Options) (net.Conn, error) {
+	if err := conn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return nil, err
+	}
+
+	if opts.tlsEnabled() {
+		tlsConn := tls.Server(conn, opts.TLSConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("tls handshake failed: %v", err)
+		}
+		conn = tlsConn
+	}
+
+	if req.Header.Get(headerAuth) == authToken {
+		token, err := readLimitedString(conn, maxTokenLength)
+		if err != nil {
+			return nil, fmt.Errorf("read token failed: %v", err)
+		}
+
+		expected := opts.token()
+		if len(expected) > 0 && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			_ = writeString(conn, ErrTokenMismatch.Error())
+			return nil, ErrTokenMismatch
+		}
+		if err := writeString(conn, ""); err != nil {
+			return nil, fmt.Errorf("write token authentication result failed: %v", err)
+		}
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// readLimitedString read the string written by writeString, returns error if it's longer than the limit
+func readLimitedString(reader io.Reader, limit uint32) (string, error) {
+	var length uint32
+	if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+		return "", err
+	}
+	if length > limit {
+		return "", ErrTokenTooLong
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,271 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"configcenter/src/common/util"
+)
+
+type certFiles struct {
+	caFile, serverCert, serverKey, clientCert, clientKey string
+}
+
+func writePEM(t *testing.T, path, typ string, data []byte) {
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: data}), 0600))
+}
+
+// generateCerts generate a ca and the server and client certificates signed by it
+func generateCerts(t *testing.T, dir string) certFiles {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cc rpc test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	files := certFiles{caFile: filepath.Join(dir, "ca.crt")}
+	writePEM(t, files.caFile, "CERTIFICATE", caDER)
+
+	issue := func(name string, serial int64, usage x509.ExtKeyUsage) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+
+		certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+		writePEM(t, certFile, "CERTIFICATE", der)
+		writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+		return certFile, keyFile
+	}
+	files.serverCert, files.serverKey = issue("server", 2, x509.ExtKeyUsageServerAuth)
+	files.clientCert, files.clientKey = issue("client", 3, x509.ExtKeyUsageClientAuth)
+	return files
+}
+
+// newSecureServer start a rpc server over loopback with the security config, returns its address
+func newSecureServer(t *testing.T, conf Config) (string, func()) {
+	opts, err := conf.ServerOptions()
+	require.NoError(t, err)
+
+	rpc := NewServer()
+	rpc.SetOptions(opts)
+	rpc.Handle("ok", OK)
+	mux := http.NewServeMux()
+	mux.Handle("/rpc", rpc)
+	ts := httptest.NewServer(mux)
+
+	address, err := util.GetDailAddress(ts.URL)
+	require.NoError(t, err)
+	return address, ts.Close
+}
+
+func dialSecure(t *testing.T, address string, conf Config) (*client, error) {
+	opts, err := conf.ClientOptions()
+	require.NoError(t, err)
+	return DialHTTPPathWithOptions("tcp", address, "/rpc", opts)
+}
+
+func callOK(t *testing.T, cli *client) {
+	defer cli.Close()
+	reply := Reply{}
+	require.NoError(t, cli.Call("ok", &Req{Name: "ok"}, &reply))
+	require.True(t, reply.OK)
+}
+
+func TestParseConfigFromKV(t *testing.T) {
+	conf, err := ParseConfigFromKV("rpc", map[string]string{})
+	require.NoError(t, err)
+	require.False(t, conf.TLS.Enable)
+	require.Empty(t, conf.Token)
+
+	conf, err = ParseConfigFromKV("rpc", map[string]string{
+		"rpc.tls":      "true",
+		"rpc.caFile":   "/data/ca.crt",
+		"rpc.certFile": "/data/cc.crt",
+		"rpc.keyFile":  "/data/cc.key",
+		"rpc.token":    "secret",
+	})
+	require.NoError(t, err)
+	require.True(t, conf.TLS.Enable)
+	require.Equal(t, "/data/ca.crt", conf.TLS.CAFile)
+	require.Equal(t, "secret", conf.Token)
+
+	_, err = ParseConfigFromKV("rpc", map[string]string{"rpc.tls": "yes"})
+	require.Error(t, err)
+
+	_, err = Config{TLS: TLSConfig{Enable: true}}.ServerOptions()
+	require.Error(t, err)
+}
+
+func TestConfigLoader(t *testing.T) {
+	loader := NewConfigLoader("rpc")
+	require.Empty(t, loader.Config().Token)
+
+	loader.Reload(map[string]string{"rpc.token": "secret"})
+	require.Equal(t, "secret", loader.Config().Token)
+
+	// the invalid config is ignored
+	loader.Reload(map[string]string{"rpc.tls": "yes", "rpc.token": "another"})
+	require.Equal(t, "secret", loader.Config().Token)
+
+	loader.Reload(map[string]string{})
+	require.Empty(t, loader.Config().Token)
+}
+
+func TestPlaintext(t *testing.T) {
+	address, closeFn := newSecureServer(t, Config{})
+	defer closeFn()
+
+	cli, err := DialHTTPPath("tcp", address, "/rpc")
+	require.NoError(t, err)
+	callOK(t, cli)
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	files := generateCerts(t, dir)
+
+	address, closeFn := newSecureServer(t, Config{TLS: TLSConfig{
+		Enable:   true,
+		CAFile:   files.caFile,
+		CertFile: files.serverCert,
+		KeyFile:  files.serverKey,
+	}})
+	defer closeFn()
+
+	// the client with the certificate signed by the ca
+	cli, err := dialSecure(t, address, Config{TLS: TLSConfig{
+		Enable:   true,
+		CAFile:   files.caFile,
+		CertFile: files.clientCert,
+		KeyFile:  files.clientKey,
+	}})
+	require.NoError(t, err)
+	callOK(t, cli)
+
+	// the plaintext client is rejected
+	_, err = DialHTTPPath("tcp", address, "/rpc")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), tlsRequired)
+
+	// the client without certificate is rejected by the server
+	cli, err = dialSecure(t, address, Config{TLS: TLSConfig{Enable: true, CAFile: files.caFile}})
+	if err == nil {
+		// the tls 1.3 client finishes the handshake before the server verifies its certificate
+		defer cli.Close()
+		err = cli.Call("ok", &Req{Name: "ok"}, &Reply{})
+	}
+	require.Error(t, err)
+
+	// the client does not trust the server certificate
+	_, err = dialSecure(t, address, Config{TLS: TLSConfig{
+		Enable:   true,
+		CertFile: files.clientCert,
+		KeyFile:  files.clientKey,
+		CAFile:   files.clientCert,
+	}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "tls handshake")
+}
+
+func TestTLSNotEnabled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	files := generateCerts(t, dir)
+
+	address, closeFn := newSecureServer(t, Config{})
+	defer closeFn()
+
+	_, err = dialSecure(t, address, Config{TLS: TLSConfig{Enable: true, CAFile: files.caFile}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), tlsNotEnabled)
+}
+
+func TestToken(t *testing.T) {
+	address, closeFn := newSecureServer(t, Config{Token: "secret"})
+	defer closeFn()
+
+	cli, err := dialSecure(t, address, Config{Token: "secret"})
+	require.NoError(t, err)
+	callOK(t, cli)
+
+	_, err = dialSecure(t, address, Config{Token: "wrong"})
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), ErrTokenMismatch.Error()), err.Error())
+
+	_, err = DialHTTPPath("tcp", address, "/rpc")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), tokenRequired)
+}
+
+func TestTLSWithToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpctls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	files := generateCerts(t, dir)
+
+	address, closeFn := newSecureServer(t, Config{
+		TLS:   TLSConfig{Enable: true, CertFile: files.serverCert, KeyFile: files.serverKey},
+		Token: "secret",
+	})
+	defer closeFn()
+
+	cli, err := dialSecure(t, address, Config{
+		TLS:   TLSConfig{Enable: true, CAFile: files.caFile},
+		Token: "secret",
+	})
+	require.NoError(t, err)
+	callOK(t, cli)
+}
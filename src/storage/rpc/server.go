@@ -17,6 +17,7 @@ import (
 	"io"
 	"net/http"
 	"runtime/debug"
+	"strings"
 
 	"configcenter/src/common/blog"
 	"configcenter/src/common/util"
@@ -28,6 +29,7 @@ type Server struct {
 	codec          Codec
 	handlers       map[string]HandlerFunc
 	streamHandlers map[string]HandlerStreamFunc
+	opts           *Options
 }
 
 // NewServer returns new server
@@ -70,20 +72,47 @@ func (s *Server) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		blog.Errorf("rpc hijack failed %s: %s", req.RemoteAddr, err.Error())
 		return
 	}
-	session, err := NewServerSession(s, conn, "deflate")
+	defer conn.Close()
+
+	if status, ok := checkConnectRequest(req, s.opts); !ok {
+		blog.Errorf("reject rpc client %s, the security options are not matched: %s", req.RemoteAddr, status)
+		if _, err := io.WriteString(conn, "HTTP/1.0 "+status+"\n\n"); err != nil {
+			blog.Errorf("write string failed %s: %v", req.RemoteAddr, err)
+		}
+		return
+	}
+	proto, err := negotiateProtocol(req, s.opts)
 	if err != nil {
-		blog.Errorf("rpc new server session faile %s: %s", req.RemoteAddr, err.Error())
-		if _, err = io.WriteString(conn, "HTTP/1.0 "+connectfaile+"\n\n"); err != nil {
+		blog.Errorf("reject rpc client %s, incompatible protocol: %v", req.RemoteAddr, err)
+		reason := strings.NewReplacer("\r", " ", "\n", " ").Replace(err.Error())
+		if _, err := io.WriteString(conn, "HTTP/1.0 "+incompatible+"\n"+headerReason+": "+reason+"\n\n"); err != nil {
 			blog.Errorf("write string failed %s: %v", req.RemoteAddr, err)
 		}
 		return
 	}
+	codec := s.codec
+	if len(req.Header.Get(headerVersion)) > 0 {
+		codec, _ = GetCodec(proto.Codec)
+	}
 
-	if _, err = io.WriteString(conn, "HTTP/1.0 "+connected+"\n\n"); err != nil {
+	if _, err = io.WriteString(conn, "HTTP/1.0 "+connected+"\n"+protocolResponseHeaders(proto)+"\n"); err != nil {
 		blog.Errorf("write string failed %s: %v", req.RemoteAddr, err)
 		return
 	}
-	blog.V(3).Infof("connected from rpc client %s", req.RemoteAddr)
+
+	secureConn, err := serverHandshake(conn, req, s.opts)
+	if err != nil {
+		blog.Errorf("rpc security handshake with %s failed: %v", req.RemoteAddr, err)
+		return
+	}
+
+	session, err := newServerSession(s, secureConn, proto, codec)
+	if err != nil {
+		blog.Errorf("rpc new server session faile %s: %s", req.RemoteAddr, err.Error())
+		return
+	}
+
+	blog.V(3).Infof("connected from rpc client %s, protocol: %s", req.RemoteAddr, proto)
 	if err = session.Run(); err != nil {
 		blog.Errorf("disconnect from rpc client %s with error: %s ", req.RemoteAddr, err.Error())
 		return
@@ -101,7 +130,12 @@ func (s *Server) HandleStream(name string, f HandlerStreamFunc) {
 	s.streamHandlers[name] = f
 }
 
-// SetCodec set server codec
+// SetOptions set the security options of the server, the clients are served without security if it's nil
+func (s *Server) SetOptions(opts *Options) {
+	s.opts = opts
+}
+
+// SetCodec set the codec of the clients which do not negotiate the protocol
 func (s *Server) SetCodec(codec Codec) {
 	s.codec = codec
 }
@@ -114,11 +148,17 @@ type ServerSession struct {
 	responses chan *Message
 	done      *util.AtomicBool
 	stream    *streamstore
+	codec     Codec
 }
 
 // NewServerSession returns a new ServerSession
 func NewServerSession(srv *Server, conn io.ReadWriteCloser, compress string) (*ServerSession, error) {
-	wire, err := NewBinaryWire(conn, compress)
+	return newServerSession(srv, conn, Protocol{Version: MagicVersion, Compress: compress}, srv.codec)
+}
+
+// newServerSession returns a new ServerSession using the negotiated protocol and codec
+func newServerSession(srv *Server, conn io.ReadWriteCloser, proto Protocol, codec Codec) (*ServerSession, error) {
+	wire, err := newProtocolWire(conn, proto)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +168,8 @@ func NewServerSession(srv *Server, conn io.ReadWriteCloser, compress string) (*S
 		responses: make(chan *Message, 1024),
 		done:      util.NewBool(false),
 		stream:    newStreamStore(),
-		request:   Message{codec: srv.codec},
+		request:   Message{codec: codec},
+		codec:     codec,
 	}, nil
 }
 
@@ -144,7 +185,7 @@ func (s *ServerSession) Stop() {
 }
 
 func (s *ServerSession) readFromWire() error {
-	msg := Message{codec: s.srv.codec}
+	msg := Message{codec: s.codec}
 	err := s.wire.Read(&msg)
 	if err == io.EOF {
 		return err
@@ -20,6 +20,7 @@ import (
 	"sync"
 
 	"github.com/rentiansheng/bk_bson/bson"
+	"github.com/ugorji/go/codec"
 
 	"configcenter/src/common/util"
 )
@@ -204,6 +205,25 @@ func (bsonCodec) Encode(v interface{}) ([]byte, error) {
 	return bson.Marshal(v)
 }
 
+type msgpackCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+// MsgpackCodec implements Codec interface
+var MsgpackCodec Codec = &msgpackCodec{handle: &codec.MsgpackHandle{WriteExt: true, RawToString: true}}
+
+func (c *msgpackCodec) Decode(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(v)
+}
+func (c *msgpackCodec) Encode(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	var data []byte
+	err := codec.NewEncoderBytes(&data, c.handle).Encode(v)
+	return data, err
+}
+
 const (
 	// MagicVersion is the cc rpc protocol version
 	MagicVersion = uint16(0x1b01) // cmdb01
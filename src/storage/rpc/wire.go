@@ -31,18 +31,26 @@ type BinaryWire struct {
 	conn   io.ReadWriteCloser
 	writer flushWriter
 	reader io.Reader
+	// version the protocol version of the messages
+	version uint16
 }
 
 // NewBinaryWire returns a new BinaryWire
 func NewBinaryWire(rwc io.ReadWriteCloser, compress string) (*BinaryWire, error) {
-	compressor, err := newCompressor(rwc, rwc, compress)
+	return newProtocolWire(rwc, Protocol{Version: MagicVersion, Compress: compress})
+}
+
+// newProtocolWire returns a new BinaryWire with the negotiated protocol
+func newProtocolWire(rwc io.ReadWriteCloser, proto Protocol) (*BinaryWire, error) {
+	compressor, err := newCompressor(rwc, rwc, proto.Compress)
 	if err != nil {
 		return nil, err
 	}
 	return &BinaryWire{
-		conn:   rwc,
-		writer: compressor,
-		reader: compressor,
+		conn:    rwc,
+		writer:  compressor,
+		reader:  compressor,
+		version: proto.Version,
 	}, nil
 }
 
@@ -53,7 +61,7 @@ func (w *BinaryWire) Write(msg *Message) error {
 	var err error
 	// LittleEndian: x86 cpu 为小端字节序
 	// 如 0x01234567，地址范围为0x100~0x103字节,小端字节序则存储为: 0x100: 67, 0x101: 45,..
-	if err = binary.Write(w.writer, binary.LittleEndian, msg.magicVersion); err != nil {
+	if err = binary.Write(w.writer, binary.LittleEndian, w.version); err != nil {
 		return err
 	}
 	if err = binary.Write(w.writer, binary.LittleEndian, msg.seq); err != nil {
@@ -80,8 +88,8 @@ func (w *BinaryWire) Read(msg *Message) error {
 		return err
 	}
 
-	if msg.magicVersion != MagicVersion {
-		return fmt.Errorf("Wrong API version received: 0x%x", &msg.magicVersion)
+	if msg.magicVersion != w.version {
+		return fmt.Errorf("Wrong API version received: 0x%x, expected: 0x%x", msg.magicVersion, w.version)
 	}
 
 	if err = binary.Read(w.reader, binary.LittleEndian, &msg.seq); err != nil {
@@ -73,7 +73,9 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		blog.Infof("connected to mongo %v", tmServer.config.MongoDB.BuildURI())
 
 		// set logics service
-		coreService.SetConfig(engine, db, tmServer.config.Transaction)
+		if err := coreService.SetConfig(engine, db, tmServer.config.Transaction); err != nil {
+			return fmt.Errorf("set config failed, err: %v", err)
+		}
 		break
 	}
 	tmServer.engin = engine
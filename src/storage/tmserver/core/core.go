@@ -27,6 +27,8 @@ type Core interface {
 	ExecuteCommand(ctx ContextParams, input rpc.Request) (*types.OPReply, error)
 	Subscribe(chan *types.Transaction)
 	UnSubscribe(chan<- *types.Transaction)
+	ListTransactions() []session.TransactionInfo
+	ForceAbort(txnID string) error
 }
 
 type core struct {
@@ -94,3 +96,11 @@ func (c *core) Subscribe(ch chan *types.Transaction) {
 func (c *core) UnSubscribe(ch chan<- *types.Transaction) {
 	c.txn.UnSubscribe(ch)
 }
+
+func (c *core) ListTransactions() []session.TransactionInfo {
+	return c.txn.ListTransactions()
+}
+
+func (c *core) ForceAbort(txnID string) error {
+	return c.txn.ForceAbort(txnID)
+}
@@ -15,9 +15,11 @@ package session
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/xid"
 
 	"configcenter/src/common"
@@ -40,13 +42,18 @@ type Manager struct {
 
 	eventChan   chan *types.Transaction
 	subscribers map[chan<- *types.Transaction]bool
+	metrics     *metrics
 
 	ctx          context.Context
 	sessionMutex sync.Mutex
 	pubsubMutex  sync.Mutex
 }
 
-func New(ctx context.Context, opt options.TransactionConfig, db mongodb.Client, listen string) (*Manager, error) {
+// ErrSessionNotFound the transaction is not in progress on this tmserver
+var ErrSessionNotFound = errors.New("session not found")
+
+// New create a transaction manager, the transaction metrics will be registered to reg if it is not nil
+func New(ctx context.Context, opt options.TransactionConfig, db mongodb.Client, listen string, reg prometheus.Registerer) (*Manager, error) {
 	tm := &Manager{
 		enable:       opt.IsTransactionEnable(),
 		processor:    listen,
@@ -59,8 +66,14 @@ func New(ctx context.Context, opt options.TransactionConfig, db mongodb.Client,
 
 		ctx: ctx,
 	}
+	m, err := newMetrics(tm, reg)
+	if err != nil {
+		return nil, err
+	}
+	tm.metrics = m
+
 	dbRawSession := tm.db.Session().Create()
-	err := dbRawSession.Open()
+	err = dbRawSession.Open()
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +132,7 @@ func (tm *Manager) reconcileCache() {
 			for _, session := range tm.cache {
 				if time.Since(session.Txninst.LastTime) > tm.txnLifeLimit {
 					// ignore the abort error, cause the session will not be used again
-					go tm.Abort(session.Txninst.TxnID)
+					go tm.abort(session.Txninst.TxnID, opTimeout)
 				}
 			}
 			tm.sessionMutex.Unlock()
@@ -172,6 +185,7 @@ func (tm *Manager) reconcilePersistence() {
 							// the reconcile will handle this error, so we will not return this error
 							blog.Errorf("save transaction [%s] status to %v faile: %s", txn.TxnID, types.TxStatusException, err.Error())
 						}
+						tm.metrics.txnTotal.WithLabelValues(opTimeout, statusLabel(types.TxStatusException)).Inc()
 						ntxn := txn
 						tm.eventChan <- &ntxn
 					}
@@ -214,6 +228,23 @@ func (tm *Manager) removeSession(txnID string) {
 	tm.sessionMutex.Unlock()
 }
 
+// takeSession remove the session from cache and return it,
+// so that only one of the concurrent finishers can finish the transaction
+func (tm *Manager) takeSession(txnID string) *Session {
+	tm.sessionMutex.Lock()
+	defer tm.sessionMutex.Unlock()
+	session := tm.cache[txnID]
+	delete(tm.cache, txnID)
+	return session
+}
+
+// setStatus set the status of the transaction with the same lock ListTransactions holds
+func (tm *Manager) setStatus(session *Session, status types.TxStatus) {
+	tm.sessionMutex.Lock()
+	session.Txninst.Status = status
+	tm.sessionMutex.Unlock()
+}
+
 func (tm *Manager) CreateTransaction(requestID string) (*Session, error) {
 	txn := types.Transaction{
 		RequestID:  requestID,
@@ -272,20 +303,20 @@ func (tm *Manager) Commit(txnID string) error {
 		// not start transaction, return
 		return nil
 	}
-	session := tm.GetSession(txnID)
+	// take the session out of cache first, so that a concurrent abort or timeout
+	// can not finish the same transaction again
+	session := tm.takeSession(txnID)
 	if session == nil {
-		return errors.New("session not found")
+		return ErrSessionNotFound
 	}
 	txnerr := session.CommitTransaction()
-	defer func() {
-		session.Close()
-		tm.removeSession(txnID)
-	}()
+	defer session.Close()
 	if nil != txnerr {
-		session.Txninst.Status = types.TxStatusException
+		tm.setStatus(session, types.TxStatusException)
 	} else {
-		session.Txninst.Status = types.TxStatusCommitted
+		tm.setStatus(session, types.TxStatusCommitted)
 	}
+	tm.metrics.observe(opCommit, session)
 	tm.eventChan <- session.Txninst
 
 	tranCond := mongo.NewCondition()
@@ -308,20 +339,35 @@ func (tm *Manager) Abort(txnID string) error {
 		// not start transaction, return
 		return nil
 	}
-	session := tm.GetSession(txnID)
+	return tm.abort(txnID, opAbort)
+}
+
+// ForceAbort abort a transaction in progress on this tmserver by administrator,
+// no matter whether the client still holds it.
+func (tm *Manager) ForceAbort(txnID string) error {
+	if !tm.enable {
+		return errors.New("transaction is not enabled")
+	}
+	if txnID == "" {
+		return ErrSessionNotFound
+	}
+	blog.Warnf("force abort transaction [%s]", txnID)
+	return tm.abort(txnID, opForceAbort)
+}
+
+func (tm *Manager) abort(txnID string, operation string) error {
+	session := tm.takeSession(txnID)
 	if session == nil {
-		return errors.New("session not found")
+		return ErrSessionNotFound
 	}
 	txnerr := session.AbortTransaction()
-	defer func() {
-		session.Close()
-		tm.removeSession(txnID)
-	}()
+	defer session.Close()
 	if nil != txnerr {
-		session.Txninst.Status = types.TxStatusException
+		tm.setStatus(session, types.TxStatusException)
 	} else {
-		session.Txninst.Status = types.TxStatusAborted
+		tm.setStatus(session, types.TxStatusAborted)
 	}
+	tm.metrics.observe(operation, session)
 	tm.eventChan <- session.Txninst
 	tranCond := mongo.NewCondition()
 	tranCond.Element(&mongo.Eq{Key: common.BKTxnIDField, Val: txnID})
@@ -338,3 +384,40 @@ func (tm *Manager) Abort(txnID string) error {
 	}
 	return nil
 }
+
+// TransactionInfo describe a transaction in progress
+type TransactionInfo struct {
+	TxnID      string    `json:"bk_txn_id"`
+	RequestID  string    `json:"request_id"`
+	Processor  string    `json:"processor"`
+	Status     string    `json:"status"`
+	CreateTime time.Time `json:"create_time"`
+	LastTime   time.Time `json:"last_time"`
+	// Age seconds since the transaction was created
+	Age float64 `json:"age"`
+}
+
+// ListTransactions returns the transactions in progress on this tmserver, the oldest first.
+// the transaction fields are copied under the session lock, which is also held when they are changed.
+func (tm *Manager) ListTransactions() []TransactionInfo {
+	tm.sessionMutex.Lock()
+	infos := make([]TransactionInfo, 0, len(tm.cache))
+	for _, session := range tm.cache {
+		txn := session.Txninst
+		infos = append(infos, TransactionInfo{
+			TxnID:      txn.TxnID,
+			RequestID:  txn.RequestID,
+			Processor:  txn.Processor,
+			Status:     txn.Status.String(),
+			CreateTime: txn.CreateTime,
+			LastTime:   txn.LastTime,
+			Age:        time.Since(txn.CreateTime).Seconds(),
+		})
+	}
+	tm.sessionMutex.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreateTime.Before(infos[j].CreateTime)
+	})
+	return infos
+}
@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package session
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"configcenter/src/storage/types"
+)
+
+// the operations which finish a transaction, used as the value of the operation label
+const (
+	opCommit     = "commit"
+	opAbort      = "abort"
+	opTimeout    = "timeout"
+	opForceAbort = "force_abort"
+)
+
+type metrics struct {
+	txnTotal    *prometheus.CounterVec
+	txnDuration *prometheus.HistogramVec
+	txnInflight prometheus.GaugeFunc
+}
+
+func newMetrics(tm *Manager, reg prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		txnTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cmdb_tmserver_transaction_total",
+			Help: "total number of finished transactions, by operation and final status.",
+		}, []string{"operation", "status"}),
+		txnDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cmdb_tmserver_transaction_duration_seconds",
+			Help:    "lifetime of the finished transactions in seconds.",
+			Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 120, 300},
+		}, []string{"operation"}),
+		txnInflight: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cmdb_tmserver_transaction_in_flight",
+			Help: "current number of transactions in progress on this tmserver.",
+		}, func() float64 {
+			tm.sessionMutex.Lock()
+			defer tm.sessionMutex.Unlock()
+			return float64(len(tm.cache))
+		}),
+	}
+
+	if reg == nil {
+		return m, nil
+	}
+
+	if err := reg.Register(m.txnTotal); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			m.txnTotal = are.ExistingCollector.(*prometheus.CounterVec)
+		} else {
+			return nil, err
+		}
+	}
+	if err := reg.Register(m.txnDuration); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			m.txnDuration = are.ExistingCollector.(*prometheus.HistogramVec)
+		} else {
+			return nil, err
+		}
+	}
+	if err := reg.Register(m.txnInflight); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// observe records a finished transaction
+func (m *metrics) observe(operation string, sess *Session) {
+	m.txnTotal.WithLabelValues(operation, statusLabel(sess.Txninst.Status)).Inc()
+	m.txnDuration.WithLabelValues(operation).Observe(time.Since(sess.Txninst.CreateTime).Seconds())
+}
+
+func statusLabel(status types.TxStatus) string {
+	switch status {
+	case types.TxStatusCommitted:
+		return "committed"
+	case types.TxStatusAborted:
+		return "aborted"
+	case types.TxStatusException:
+		return "exception"
+	default:
+		return "unknown"
+	}
+}
@@ -15,6 +15,7 @@ package service
 import (
 	"context"
 
+	"configcenter/src/common/tracing"
 	"configcenter/src/storage/rpc"
 	"configcenter/src/storage/tmserver/core"
 	"configcenter/src/storage/types"
@@ -33,7 +34,22 @@ func (s *coreService) DBOperation(input rpc.Request) (interface{}, error) {
 	ctx.Context = context.Background()
 	ctx.ListenIP = s.listenIP
 
-	return s.core.ExecuteCommand(ctx, input)
+	// continue the trace of the caller
+	parent, _ := tracing.ParseTraceParent(ctx.Header.TraceParent)
+	var span *tracing.Span
+	ctx.Context, span = tracing.StartSpanWithParent(ctx.Context, parent, "rpc "+ctx.Header.OPCode.String(), tracing.SpanKindServer)
+	span.SetAttribute(tracing.AttrRPCSystem, "cc-rpc")
+	span.SetAttribute(tracing.AttrRPCMethod, types.CommandRDBOperation)
+	span.SetAttribute(tracing.AttrRequestID, ctx.Header.RequestID)
+	defer span.End()
+
+	result, err := s.core.ExecuteCommand(ctx, input)
+	if err != nil {
+		span.SetError(err)
+	} else if result != nil && !result.Success {
+		span.SetStatus(tracing.StatusError, result.Message)
+	}
+	return result, err
 
 }
 
@@ -46,6 +46,7 @@ func New(ip string, port uint) Service {
 type coreService struct {
 	engine     *backbone.Engine
 	rpc        *rpc.Server
+	rpcConfig  *rpc.ConfigLoader
 	dbProxy    mongodb.Client
 	core       core.Core
 	listenIP   string
@@ -58,6 +59,15 @@ func (s *coreService) SetConfig(engin *backbone.Engine, db mongodb.Client, txnCf
 	s.engine = engin
 	s.dbProxy = db
 	s.rpc = rpc.NewServer()
+	// the token of the administration apis is reloaded with the process config, while the security options
+	// of the rpc server take effect after restart.
+	s.rpcConfig = rpc.NewConfigLoader("rpc")
+	engin.AddConfigUpdateHook(s.rpcConfig.Reload)
+	rpcOpts, err := s.rpcConfig.Config().ServerOptions()
+	if err != nil {
+		return err
+	}
+	s.rpc.SetOptions(rpcOpts)
 
 	// init all handlers
 	s.rpc.Handle(types.CommandRDBOperation, s.DBOperation)
@@ -68,7 +78,7 @@ func (s *coreService) SetConfig(engin *backbone.Engine, db mongodb.Client, txnCf
 		core.ContextParams{
 			Context:  context.Background(),
 			ListenIP: s.listenIP,
-		}, txnCfg, db, s.listenIP)
+		}, txnCfg, db, s.listenIP, engin.Metric().Registry())
 	if err != nil {
 		return err
 	}
@@ -101,5 +111,9 @@ func (s *coreService) WebService() *restful.WebService {
 		s.rpc.ServeHTTP(resp.ResponseWriter, req.Request)
 	}))
 
+	// transaction administration, authenticated by the rpc token
+	ws.Route(ws.GET("/transactions").Filter(s.authAdmin).To(s.ListTransactions).Produces(restful.MIME_JSON))
+	ws.Route(ws.POST("/transactions/{txn_id}/abort").Filter(s.authAdmin).To(s.AbortTransaction).Produces(restful.MIME_JSON))
+
 	return ws
 }
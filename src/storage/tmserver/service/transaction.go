@@ -0,0 +1,86 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	restful "github.com/emicklei/go-restful"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/tmserver/core/session"
+)
+
+// HeaderAdminToken the header carrying the rpc token to call the transaction administration apis
+const HeaderAdminToken = "Cc-Rpc-Token"
+
+// authAdmin only accept the administration requests carrying the rpc token, the apis are forbidden
+// if the token is not configured, cause the transactions of all the services can be aborted with them.
+func (s *coreService) authAdmin(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	token := s.rpcConfig.Config().Token
+	if len(token) == 0 {
+		blog.Errorf("request %s from %s is forbidden, the rpc token is not configured, rid: %s", req.Request.URL.Path, req.Request.RemoteAddr, rid)
+		defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+		_ = resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: defErr.Error(common.CCErrCommAuthNotHavePermission)})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(req.HeaderParameter(HeaderAdminToken)), []byte(token)) != 1 {
+		blog.Errorf("request %s from %s is unauthorized, the rpc token is mismatched, rid: %s", req.Request.URL.Path, req.Request.RemoteAddr, rid)
+		defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+		_ = resp.WriteError(http.StatusUnauthorized, &metadata.RespError{Msg: defErr.Error(common.CCErrCommAuthorizeFailed)})
+		return
+	}
+	chain.ProcessFilter(req, resp)
+}
+
+// ListTransactionsResult the transactions in progress on this tmserver
+type ListTransactionsResult struct {
+	Processor string                    `json:"processor"`
+	Count     int                       `json:"count"`
+	Info      []session.TransactionInfo `json:"info"`
+}
+
+// ListTransactions list the transactions in progress with their age, request id and processor
+func (s *coreService) ListTransactions(req *restful.Request, resp *restful.Response) {
+	txns := s.core.ListTransactions()
+	result := ListTransactionsResult{
+		Processor: s.listenIP,
+		Count:     len(txns),
+		Info:      txns,
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// AbortTransaction force abort a stuck transaction
+func (s *coreService) AbortTransaction(req *restful.Request, resp *restful.Response) {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+	txnID := req.PathParameter("txn_id")
+
+	if err := s.core.ForceAbort(txnID); err != nil {
+		blog.Errorf("force abort transaction [%s] failed, err: %v, rid: %s", txnID, err, rid)
+		if err == session.ErrSessionNotFound {
+			_ = resp.WriteError(http.StatusNotFound, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommTransactionNotFound, txnID)})
+			return
+		}
+		_ = resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommAbortTransactionFailed, err.Error())})
+		return
+	}
+	blog.Infof("transaction [%s] is force aborted, rid: %s", txnID, rid)
+	_ = resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
@@ -21,6 +21,13 @@ type MsgHeader struct {
 	OPCode    OPCode
 	TxnID     string
 	RequestID string
+	// TraceParent the w3c trace context of the caller span
+	TraceParent string
+}
+
+// SetTraceParent set the trace context of the caller span
+func (h *MsgHeader) SetTraceParent(traceParent string) {
+	h.TraceParent = traceParent
 }
 
 // OPCode operation code type
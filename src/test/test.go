@@ -14,6 +14,7 @@ import (
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/common"
 	"configcenter/src/common/backbone/service_mange/zk"
+	"configcenter/src/common/registerdiscover"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/test/run"
 	testutil "configcenter/src/test/util"
@@ -53,7 +54,7 @@ func init() {
 	client := zk.NewZkClient(tConfig.ZkAddr, 5*time.Second)
 	Expect(client.Start()).Should(BeNil())
 	Expect(client.Ping()).Should(BeNil())
-	disc, err := discovery.NewServiceDiscovery(client)
+	disc, err := discovery.NewServiceDiscovery(registerdiscover.NewRegDiscoverEx(client))
 	Expect(err).Should(BeNil())
 	c := &util.APIMachineryConfig{
 		QPS:       20000,
@@ -0,0 +1,232 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"configcenter/src/common/types"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewTxnCommand())
+}
+
+type txnConf struct {
+	txnAddr string
+	txnID   string
+}
+
+func NewTxnCommand() *cobra.Command {
+	conf := new(txnConf)
+
+	cmd := &cobra.Command{
+		Use:   "txn",
+		Short: "transaction operations of the txc servers",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	subCmds := make([]*cobra.Command, 0)
+
+	subCmds = append(subCmds, &cobra.Command{
+		Use:   "list",
+		Short: "list the transactions in progress",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTxnListCmd(conf)
+		},
+	})
+
+	abortCmd := &cobra.Command{
+		Use:   "abort",
+		Short: "force abort a stuck transaction",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTxnAbortCmd(conf)
+		},
+	}
+	abortCmd.Flags().StringVar(&conf.txnID, "txn-id", "", "the id of the transaction to be aborted")
+	_ = abortCmd.MarkFlagRequired("txn-id")
+	subCmds = append(subCmds, abortCmd)
+
+	for _, subCmd := range subCmds {
+		cmd.AddCommand(subCmd)
+	}
+	conf.addFlags(cmd)
+
+	return cmd
+}
+
+func (c *txnConf) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&c.txnAddr, "txn-addr", "", "the address of the txc servers, separated by comma, e.g. http://127.0.0.1:60008. discover from zookeeper if not set")
+}
+
+type txnService struct {
+	addrs  []string
+	client *http.Client
+}
+
+func newTxnService(zkaddr string, txnAddr string) (*txnService, error) {
+	srv := &txnService{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	if txnAddr != "" {
+		for _, addr := range strings.Split(txnAddr, ",") {
+			addr = strings.TrimSpace(addr)
+			if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+				addr = "http://" + addr
+			}
+			srv.addrs = append(srv.addrs, strings.TrimRight(addr, "/"))
+		}
+		return srv, nil
+	}
+
+	service, err := config.NewZkService(zkaddr)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.ZkCli.Ping(); err != nil {
+		if err = service.ZkCli.Connect(); err != nil {
+			return nil, err
+		}
+	}
+	path := fmt.Sprintf("%s/%s", types.CC_SERV_BASEPATH, types.CC_MODULE_TXC)
+	children, err := service.ZkCli.GetChildren(path)
+	if err != nil {
+		return nil, fmt.Errorf("get txc servers from zk path [%s] failed: %v", path, err)
+	}
+	for _, child := range children {
+		data, err := service.ZkCli.Get(path + "/" + child)
+		if err != nil {
+			return nil, err
+		}
+		info := new(types.ServerInfo)
+		if err := json.Unmarshal([]byte(data), info); err != nil {
+			return nil, fmt.Errorf("parse txc server info [%s] failed: %v", data, err)
+		}
+		srv.addrs = append(srv.addrs, info.Address())
+	}
+	if len(srv.addrs) == 0 {
+		return nil, errors.New("no txc server found, set the txn-addr flag")
+	}
+	return srv, nil
+}
+
+type txnInfo struct {
+	TxnID      string    `json:"bk_txn_id"`
+	RequestID  string    `json:"request_id"`
+	Processor  string    `json:"processor"`
+	Status     string    `json:"status"`
+	CreateTime time.Time `json:"create_time"`
+	LastTime   time.Time `json:"last_time"`
+	Age        float64   `json:"age"`
+}
+
+type txnResponse struct {
+	Result  bool            `json:"result"`
+	Code    int             `json:"bk_error_code"`
+	Message string          `json:"bk_error_msg"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (s *txnService) do(method, url string) (*txnResponse, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	result := new(txnResponse)
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("http status: %s, body: %s", resp.Status, string(body))
+	}
+	return result, nil
+}
+
+func runTxnListCmd(c *txnConf) error {
+	srv, err := newTxnService(config.Conf.ZkAddr, c.txnAddr)
+	if err != nil {
+		return err
+	}
+	return srv.list()
+}
+
+func (s *txnService) list() error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TxnID\tRequestID\tProcessor\tStatus\tAge\tLastTime")
+	for _, addr := range s.addrs {
+		resp, err := s.do(http.MethodGet, addr+"/txn/v3/transactions")
+		if err != nil {
+			fmt.Print(WithRedColor(fmt.Sprintf("list transactions of %s failed: %v", addr, err)))
+			continue
+		}
+		if !resp.Result {
+			fmt.Print(WithRedColor(fmt.Sprintf("list transactions of %s failed: %s", addr, resp.Message)))
+			continue
+		}
+		data := struct {
+			Info []txnInfo `json:"info"`
+		}{}
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			return err
+		}
+		for _, txn := range data.Info {
+			age := time.Duration(txn.Age * float64(time.Second)).Round(time.Millisecond)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", txn.TxnID, txn.RequestID, txn.Processor, txn.Status, age, txn.LastTime.Format(time.RFC3339))
+		}
+	}
+	return w.Flush()
+}
+
+func runTxnAbortCmd(c *txnConf) error {
+	srv, err := newTxnService(config.Conf.ZkAddr, c.txnAddr)
+	if err != nil {
+		return err
+	}
+	return srv.abort(c.txnID)
+}
+
+// abort send the abort request to all the txc servers, only the one holding the transaction will abort it
+func (s *txnService) abort(txnID string) error {
+	for _, addr := range s.addrs {
+		resp, err := s.do(http.MethodPost, fmt.Sprintf("%s/txn/v3/transactions/%s/abort", addr, txnID))
+		if err != nil {
+			fmt.Print(WithRedColor(fmt.Sprintf("abort transaction on %s failed: %v", addr, err)))
+			continue
+		}
+		if resp.Result {
+			fmt.Print(WithGreenColor(fmt.Sprintf("transaction %s is aborted by %s", txnID, addr)))
+			return nil
+		}
+	}
+	return fmt.Errorf("transaction %s is not in progress on any txc server", txnID)
+}
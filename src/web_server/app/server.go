@@ -24,8 +24,10 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/types"
 	"configcenter/src/common/version"
+	"configcenter/src/storage/dal/mongo/remote"
 	"configcenter/src/storage/dal/redis"
 	"configcenter/src/web_server/app/options"
 	"configcenter/src/web_server/logics"
@@ -108,7 +110,15 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 
 	service.Engine = engine
 	service.CacheCli = cacheCli
+	txn, err := remote.NewWithDiscover(engine)
+	if err != nil {
+		// only the all or nothing excel import depends on the transaction, do not block the web server
+		blog.Errorf("new transaction client failed, all or nothing import is not available, err: %v", err)
+	}
 	service.Logics = &logics.Logics{Engine: engine}
+	if txn != nil {
+		service.Logics.Txn = txn
+	}
 	service.Config = &webSvr.Config
 
 	if webSvr.Config.LoginVersion != common.BKDefaultLoginUserPluginVersion && webSvr.Config.LoginVersion != "" {
@@ -17,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	"configcenter/src/common"
@@ -32,14 +33,33 @@ import (
 
 // BuildExcelFromData product excel from data
 func (lgc *Logics) BuildExcelFromData(ctx context.Context, objID string, fields map[string]Property, filter []string, data []mapstr.MapStr, xlsxFile *xlsx.File, header http.Header, meta *metadata.Metadata) error {
+	sheet, sortedFields, err := lgc.prepareInstExcelSheet(ctx, objID, fields, filter, xlsxFile, header)
+	if err != nil {
+		return err
+	}
+
+	instPrimaryKeyValMap := make(map[int64][]PropertyPrimaryVal)
+	_, err = lgc.appendInstExcelRows(ctx, objID, sheet, common.HostAddMethodExcelIndexOffset, sortedFields, data, instPrimaryKeyValMap, header)
+	if err != nil {
+		return err
+	}
+
+	err = lgc.BuildAssociationExcelFromData(ctx, objID, instPrimaryKeyValMap, xlsxFile, header, meta)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// prepareInstExcelSheet add the instance sheet with its header, returns the sheet and the sorted fields
+func (lgc *Logics) prepareInstExcelSheet(ctx context.Context, objID string, fields map[string]Property, filter []string, xlsxFile *xlsx.File, header http.Header) (*xlsx.Sheet, []Property, error) {
 	rid := util.GetHTTPCCRequestID(header)
 
 	ccLang := lgc.Language.CreateDefaultCCLanguageIf(util.GetLanguage(header))
-	ccErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 	sheet, err := xlsxFile.AddSheet("inst")
 	if err != nil {
 		blog.Errorf("setExcelRowDataByIndex add excel sheet error, err:%s, rid:%s", err.Error(), rid)
-		return err
+		return nil, nil, err
 
 	}
 	addSystemField(fields, common.BKInnerObjIDObject, ccLang)
@@ -51,19 +71,23 @@ func (lgc *Logics) BuildExcelFromData(ctx context.Context, objID string, fields
 	}
 
 	sortedFields := SortByIsRequired(fields)
-	instPrimaryKeyValMap := make(map[int64][]PropertyPrimaryVal)
 	productExcelHeader(ctx, sortedFields, filter, sheet, ccLang)
-	// indexID := getFieldsIDIndexMap(fields)
+	return sheet, sortedFields, nil
+}
 
-	rowIndex := common.HostAddMethodExcelIndexOffset
+// appendInstExcelRows write the instances into the sheet begin with rowIndex, returns the next row index
+func (lgc *Logics) appendInstExcelRows(ctx context.Context, objID string, sheet *xlsx.Sheet, rowIndex int, sortedFields []Property, data []mapstr.MapStr,
+	instPrimaryKeyValMap map[int64][]PropertyPrimaryVal, header http.Header) (int, error) {
 
+	rid := util.GetHTTPCCRequestID(header)
+	ccErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 	for _, rowMap := range data {
 
 		instIDKey := metadata.GetInstIDFieldByObjID(objID)
 		instID, err := rowMap.Int64(instIDKey)
 		if err != nil {
 			blog.Errorf("setExcelRowDataByIndex inst:%+v, not inst id key:%s, objID:%s, rid:%s", rowMap, instIDKey, objID, rid)
-			return ccErr.Errorf(common.CCErrCommInstFieldNotFound, "instIDKey", objID)
+			return rowIndex, ccErr.Errorf(common.CCErrCommInstFieldNotFound, "instIDKey", objID)
 		}
 
 		primaryKeyArr := setExcelRowDataByIndex(rowMap, sheet, rowIndex, sortedFields)
@@ -72,6 +96,21 @@ func (lgc *Logics) BuildExcelFromData(ctx context.Context, objID string, fields
 		rowIndex++
 
 	}
+	return rowIndex, nil
+}
+
+// BuildHostExcelFromData product excel from data
+func (lgc *Logics) BuildHostExcelFromData(ctx context.Context, objID string, fields map[string]Property, filter []string, data []mapstr.MapStr, xlsxFile *xlsx.File, header http.Header, meta *metadata.Metadata) error {
+	sheet, sortedFields, err := lgc.prepareHostExcelSheet(ctx, fields, filter, xlsxFile, header)
+	if err != nil {
+		return err
+	}
+
+	instPrimaryKeyValMap := make(map[int64][]PropertyPrimaryVal)
+	_, err = lgc.appendHostExcelRows(ctx, objID, sheet, common.HostAddMethodExcelIndexOffset, sortedFields, data, instPrimaryKeyValMap, header)
+	if err != nil {
+		return err
+	}
 
 	err = lgc.BuildAssociationExcelFromData(ctx, objID, instPrimaryKeyValMap, xlsxFile, header, meta)
 	if err != nil {
@@ -80,18 +119,18 @@ func (lgc *Logics) BuildExcelFromData(ctx context.Context, objID string, fields
 	return nil
 }
 
-// BuildHostExcelFromData product excel from data
-func (lgc *Logics) BuildHostExcelFromData(ctx context.Context, objID string, fields map[string]Property, filter []string, data []mapstr.MapStr, xlsxFile *xlsx.File, header http.Header, meta *metadata.Metadata) error {
+const extFieldsTopoID = "cc_ext_field_topo"
+
+// prepareHostExcelSheet add the host sheet with its header, returns the sheet and the sorted fields
+func (lgc *Logics) prepareHostExcelSheet(ctx context.Context, fields map[string]Property, filter []string, xlsxFile *xlsx.File, header http.Header) (*xlsx.Sheet, []Property, error) {
 	rid := util.ExtractRequestIDFromContext(ctx)
 	ccLang := lgc.Language.CreateDefaultCCLanguageIf(util.GetLanguage(header))
-	ccErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 
 	sheet, err := xlsxFile.AddSheet("host")
 	if err != nil {
 		blog.Errorf("BuildHostExcelFromData add excel sheet error, err:%s, rid:%s", err.Error(), rid)
-		return err
+		return nil, nil, err
 	}
-	extFieldsTopoID := "cc_ext_field_topo"
 	extFields := map[string]string{
 		extFieldsTopoID: ccLang.Language("web_ext_field_topo"),
 	}
@@ -100,17 +139,22 @@ func (lgc *Logics) BuildHostExcelFromData(ctx context.Context, objID string, fie
 
 	sortedFields := SortByIsRequired(fields)
 	productExcelHeader(ctx, sortedFields, filter, sheet, ccLang)
+	return sheet, sortedFields, nil
+}
 
-	instPrimaryKeyValMap := make(map[int64][]PropertyPrimaryVal)
-	// indexID := getFieldsIDIndexMap(fields)
-	rowIndex := common.HostAddMethodExcelIndexOffset
+// appendHostExcelRows write the hosts into the sheet begin with rowIndex, returns the next row index
+func (lgc *Logics) appendHostExcelRows(ctx context.Context, objID string, sheet *xlsx.Sheet, rowIndex int, sortedFields []Property, data []mapstr.MapStr,
+	instPrimaryKeyValMap map[int64][]PropertyPrimaryVal, header http.Header) (int, error) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ccErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 	for _, hostData := range data {
 
 		rowMap, err := mapstr.NewFromInterface(hostData[common.BKInnerObjIDHost])
 		if err != nil {
 			blog.ErrorJSON("BuildHostExcelFromData failed, hostData: %s, err: %s, rid: %s", hostData, err.Error(), rid)
 			msg := fmt.Sprintf("data format error:%v", hostData)
-			return errors.New(msg)
+			return rowIndex, errors.New(msg)
 		}
 		moduleMap, ok := hostData[common.BKInnerObjIDModule].([]interface{})
 		if ok {
@@ -122,19 +166,14 @@ func (lgc *Logics) BuildHostExcelFromData(ctx context.Context, objID string, fie
 		instID, err := rowMap.Int64(instIDKey)
 		if err != nil {
 			blog.Errorf("setExcelRowDataByIndex inst:%+v, not inst id key:%s, objID:%s, rid:%s", rowMap, instIDKey, objID, rid)
-			return ccErr.Errorf(common.CCErrCommInstFieldNotFound, "instIDKey", objID)
+			return rowIndex, ccErr.Errorf(common.CCErrCommInstFieldNotFound, "instIDKey", objID)
 		}
 		primaryKeyArr := setExcelRowDataByIndex(rowMap, sheet, rowIndex, sortedFields)
 		instPrimaryKeyValMap[instID] = primaryKeyArr
 		rowIndex++
 
 	}
-
-	err = lgc.BuildAssociationExcelFromData(ctx, objID, instPrimaryKeyValMap, xlsxFile, header, meta)
-	if err != nil {
-		return err
-	}
-	return nil
+	return rowIndex, nil
 }
 
 func (lgc *Logics) BuildAssociationExcelFromData(ctx context.Context, objID string, instPrimaryInfo map[int64][]PropertyPrimaryVal, xlsxFile *xlsx.File, header http.Header, meta *metadata.Metadata) error {
@@ -246,6 +285,36 @@ func AddDownExcelHttpHeader(c *gin.Context, name string) {
 // GetExcelData excel数据，一个kv结构，key行数（excel中的行数），value内容
 func GetExcelData(ctx context.Context, sheet *xlsx.Sheet, fields map[string]Property, defFields common.KvMap, isCheckHeader bool, firstRow int, defLang lang.DefaultCCLanguageIf) (map[int]map[string]interface{}, []string, error) {
 
+	hosts, rowErrMsg, err := GetExcelDataWithRowErrors(ctx, sheet, fields, defFields, isCheckHeader, firstRow, defLang)
+	if nil != err {
+		return nil, nil, err
+	}
+	if 0 != len(rowErrMsg) {
+		return nil, flattenRowErrors(rowErrMsg), nil
+	}
+
+	return hosts, nil, nil
+
+}
+
+// flattenRowErrors get the error messages of all the rows, sorted by the row number
+func flattenRowErrors(rowErrMsg map[int][]string) []string {
+	rows := make([]int, 0)
+	for row := range rowErrMsg {
+		rows = append(rows, row)
+	}
+	sort.Ints(rows)
+	errMsg := make([]string, 0)
+	for _, row := range rows {
+		errMsg = append(errMsg, rowErrMsg[row]...)
+	}
+	return errMsg
+}
+
+// GetExcelDataWithRowErrors get excel data like GetExcelData, but the error messages are returned by the row number,
+// which is the same as the key of the data, so the valid rows can still be used
+func GetExcelDataWithRowErrors(ctx context.Context, sheet *xlsx.Sheet, fields map[string]Property, defFields common.KvMap, isCheckHeader bool, firstRow int, defLang lang.DefaultCCLanguageIf) (map[int]map[string]interface{}, map[int][]string, error) {
+
 	var err error
 	nameIndexMap, err := checkExcelHealer(ctx, sheet, fields, isCheckHeader, defLang)
 	if nil != err {
@@ -256,13 +325,13 @@ func GetExcelData(ctx context.Context, sheet *xlsx.Sheet, fields map[string]Prop
 	if 0 != firstRow {
 		index = firstRow
 	}
-	errMsg := make([]string, 0)
+	errMsg := make(map[int][]string)
 	rowCnt := len(sheet.Rows)
 	for ; index < rowCnt; index++ {
 		row := sheet.Rows[index]
 		host, getErr := getDataFromByExcelRow(ctx, row, index, fields, defFields, nameIndexMap, defLang)
 		if 0 != len(getErr) {
-			errMsg = append(errMsg, getErr...)
+			errMsg[index+1] = getErr
 			continue
 		}
 		if 0 == len(host) {
@@ -271,11 +340,8 @@ func GetExcelData(ctx context.Context, sheet *xlsx.Sheet, fields map[string]Prop
 			hosts[index+1] = host
 		}
 	}
-	if 0 != len(errMsg) {
-		return nil, errMsg, nil
-	}
 
-	return hosts, nil, nil
+	return hosts, errMsg, nil
 
 }
 
@@ -129,6 +129,19 @@ func setExcelRowDataByIndex(rowMap mapstr.MapStr, sheet *xlsx.Sheet, rowIndex in
 				cell.SetString(cellVal)
 			}
 
+		case common.FieldTypeEnumMulti:
+			arrVal, ok := property.Option.([]interface{})
+			if ok {
+				cell.SetString(getEnumMultiNamesByIDs(val, arrVal))
+			}
+
+		case common.FieldTypeReference:
+			if name := getReferenceDisplayName(rowMap, property.ID); "" != name {
+				cell.SetString(name)
+			} else if intVal, err := util.GetInt64ByInterface(val); nil == err {
+				cell.SetInt64(intVal)
+			}
+
 		case common.FieldTypeBool:
 			bl, ok := val.(bool)
 			if ok {
@@ -246,6 +259,13 @@ func getDataFromByExcelRow(ctx context.Context, row *xlsx.Row, rowIndex int, fie
 			if optionOk {
 				host[fieldName] = getEnumIDByName(cell.Value, option)
 			}
+		case common.FieldTypeEnumMulti:
+			option, optionOk := field.Option.([]interface{})
+			if optionOk {
+				host[fieldName] = getEnumMultiIDsByNames(cell.Value, option)
+			}
+		case common.FieldTypeIP, common.FieldTypeCIDR:
+			host[fieldName] = strings.TrimSpace(cell.Value)
 		case common.FieldTypeInt:
 			intVal, err := util.GetInt64ByInterface(host[fieldName])
 			// convertor int not err , set field value to correct type
@@ -0,0 +1,253 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/rentiansheng/xlsx"
+)
+
+// ExportTaskPageSize the page size used to fetch data in the asynchronous export
+const ExportTaskPageSize = 500
+
+// ExportProgressFunc is called after each page is written to the excel
+type ExportProgressFunc func(exported, total int)
+
+// BuildExportExcelByPage build the excel of an asynchronous export task into filePath. the data is fetched page by page
+// instead of a single query, the field filters are the same as the synchronous export. the rows of the data sheet are
+// streamed to a temporary file page by page, only the header, association and comment sheets are kept in memory.
+func (lgc *Logics) BuildExportExcelByPage(ctx context.Context, task *metadata.ExportExcelTask, header http.Header, filePath string, progress ExportProgressFunc) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	objID := task.ObjID
+	isHost := objID == common.BKInnerObjIDHost
+	meta := task.Metadata
+	if meta == nil {
+		meta = &metadata.Metadata{}
+	}
+
+	var filterFields []string
+	if isHost {
+		filterFields = GetFilterFields(objID)
+	}
+	customFields := GetCustomFields(filterFields, task.CustomFields)
+	fields, err := lgc.GetObjFieldIDs(objID, filterFields, customFields, header, meta)
+	if err != nil {
+		blog.Errorf("BuildExportExcelByPage failed, get object: %s fields failed, err: %v, rid: %s", objID, err, rid)
+		return err
+	}
+
+	// the data sheet must be the first sheet of the file, its rows are spliced in by saveStreamedExcel
+	file := xlsx.NewFile()
+	var sortedFields []Property
+	if isHost {
+		_, sortedFields, err = lgc.prepareHostExcelSheet(ctx, fields, nil, file, header)
+	} else {
+		_, sortedFields, err = lgc.prepareInstExcelSheet(ctx, objID, fields, nil, file, header)
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsPath := filePath + ".rows"
+	rowsFile, err := os.Create(rowsPath)
+	if err != nil {
+		blog.Errorf("BuildExportExcelByPage failed, create rows file %s failed, err: %v, rid: %s", rowsPath, err, rid)
+		return err
+	}
+	defer os.Remove(rowsPath)
+	defer rowsFile.Close()
+	rowsWriter := bufio.NewWriter(rowsFile)
+
+	rowIndex := common.HostAddMethodExcelIndexOffset
+	instPrimaryKeyValMap := make(map[int64][]PropertyPrimaryVal)
+	exported := 0
+	for start := 0; ; start += ExportTaskPageSize {
+		var data []mapstr.MapStr
+		var total int
+		page := &metadata.BasePage{Start: start, Limit: ExportTaskPageSize}
+		if isHost {
+			data, total, err = lgc.GetHostData(task.AppID, task.InstIDs, header, page)
+		} else {
+			data, total, err = lgc.GetInstData(task.OwnerID, objID, task.InstIDs, header, nil, meta, page)
+		}
+		if err != nil {
+			blog.Errorf("BuildExportExcelByPage failed, get object: %s data from %d failed, err: %v, rid: %s", objID, start, err, rid)
+			return err
+		}
+
+		// the page is written into a scratch sheet, then flushed to the rows file and dropped
+		pageSheet, err := xlsx.NewFile().AddSheet("rows")
+		if err != nil {
+			return err
+		}
+		if isHost {
+			_, err = lgc.appendHostExcelRows(ctx, objID, pageSheet, 0, sortedFields, data, instPrimaryKeyValMap, header)
+		} else {
+			_, err = lgc.appendInstExcelRows(ctx, objID, pageSheet, 0, sortedFields, data, instPrimaryKeyValMap, header)
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeExcelRowsXML(rowsWriter, pageSheet, rowIndex); err != nil {
+			blog.Errorf("BuildExportExcelByPage failed, write object: %s rows from %d failed, err: %v, rid: %s", objID, start, err, rid)
+			return err
+		}
+		rowIndex += len(data)
+
+		exported += len(data)
+		if progress != nil {
+			progress(exported, total)
+		}
+		if len(data) < ExportTaskPageSize || exported >= total {
+			break
+		}
+	}
+	if err := rowsWriter.Flush(); err != nil {
+		blog.Errorf("BuildExportExcelByPage failed, flush rows file %s failed, err: %v, rid: %s", rowsPath, err, rid)
+		return err
+	}
+
+	if err := lgc.BuildAssociationExcelFromData(ctx, objID, instPrimaryKeyValMap, file, header, meta); err != nil {
+		blog.Errorf("BuildExportExcelByPage failed, build object: %s association failed, err: %v, rid: %s", objID, err, rid)
+		return err
+	}
+	ProductExcelCommentSheet(ctx, file, lgc.Language.CreateDefaultCCLanguageIf(util.GetLanguage(header)))
+
+	if _, err := rowsFile.Seek(0, io.SeekStart); err != nil {
+		blog.Errorf("BuildExportExcelByPage failed, seek rows file %s failed, err: %v, rid: %s", rowsPath, err, rid)
+		return err
+	}
+	if err := saveStreamedExcel(file, rowsFile, filePath); err != nil {
+		blog.Errorf("BuildExportExcelByPage failed, save file %s failed, err: %v, rid: %s", filePath, err, rid)
+		return err
+	}
+	return nil
+}
+
+const (
+	streamedSheetPart = "xl/worksheets/sheet1.xml"
+	endSheetDataTag   = "</sheetData>"
+)
+
+var sheetDimensionRegexp = regexp.MustCompile(`<dimension ref="[^"]*"></dimension>`)
+
+// saveStreamedExcel save the file to filePath with the rows read from rows appended to the data of its first sheet.
+// the file is written to a temporary path and renamed, so that a partial file is never downloaded.
+func saveStreamedExcel(file *xlsx.File, rows io.Reader, filePath string) error {
+	parts, err := file.MarshallParts()
+	if err != nil {
+		return err
+	}
+	sheetXML, ok := parts[streamedSheetPart]
+	if !ok {
+		return fmt.Errorf("sheet part %s not found", streamedSheetPart)
+	}
+	// the dimension is computed from the header rows only, it is optional but must not be wrong
+	sheetXML = sheetDimensionRegexp.ReplaceAllString(sheetXML, "")
+	sheetParts := strings.SplitN(sheetXML, endSheetDataTag, 2)
+	if len(sheetParts) != 2 {
+		return fmt.Errorf("sheet part %s has no %s tag", streamedSheetPart, endSheetDataTag)
+	}
+
+	tmpPath := filePath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	defer out.Close()
+
+	zipWriter := zip.NewWriter(out)
+	for path, data := range parts {
+		w, err := zipWriter.Create(path)
+		if err != nil {
+			return err
+		}
+		if path != streamedSheetPart {
+			if _, err := io.WriteString(w, data); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := io.WriteString(w, sheetParts[0]); err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, rows); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, endSheetDataTag+sheetParts[1]); err != nil {
+			return err
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+// writeExcelRowsXML write the rows of the sheet as the sheetData xml, the first row is written as the row
+// at rowOffset. strings are written inline so that no shared string table is needed.
+func writeExcelRowsXML(w io.Writer, sheet *xlsx.Sheet, rowOffset int) error {
+	for rowIdx, row := range sheet.Rows {
+		if _, err := fmt.Fprintf(w, `<row r="%d">`, rowOffset+rowIdx+1); err != nil {
+			return err
+		}
+		for colIdx, cell := range row.Cells {
+			ref := xlsx.GetCellIDStringFromCoords(colIdx, rowOffset+rowIdx)
+			var err error
+			switch cell.Type() {
+			case xlsx.CellTypeNumeric:
+				_, err = fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, cell.Value)
+			case xlsx.CellTypeBool:
+				_, err = fmt.Fprintf(w, `<c r="%s" t="b"><v>%s</v></c>`, ref, cell.Value)
+			default:
+				if cell.Value == "" {
+					continue
+				}
+				if _, err = fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref); err != nil {
+					return err
+				}
+				if err = xml.EscapeText(w, []byte(cell.Value)); err != nil {
+					return err
+				}
+				_, err = io.WriteString(w, `</t></is></c>`)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, `</row>`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
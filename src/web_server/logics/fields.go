@@ -268,7 +268,13 @@ func getPropertyTypeAliasName(propertyType string, defLang lang.DefaultCCLanguag
 	case common.FieldTypeUser:
 	case common.FieldTypeBool:
 	case common.FieldTypeTimeZone:
-
+	case common.FieldTypeIP:
+	case common.FieldTypeCIDR:
+	case common.FieldTypeEnumMulti:
+	case common.FieldTypeReference:
+	case common.FieldTypeComputed:
+	case common.FieldTypeTable:
+		skip = true
 	}
 	if "" == name {
 		name = propertyType
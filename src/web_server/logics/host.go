@@ -29,10 +29,39 @@ import (
 	"github.com/rentiansheng/xlsx"
 )
 
-// GetHostData get host data from excel
-func (lgc *Logics) GetHostData(appIDStr, hostIDStr string, header http.Header) ([]mapstr.MapStr, error) {
+// GetHostData get the hosts to be exported and the total count, all the hosts are returned if page is nil,
+// otherwise one page of them sorted by host id.
+func (lgc *Logics) GetHostData(appIDStr, hostIDStr string, header http.Header, page *metadata.BasePage) ([]mapstr.MapStr, int, error) {
 	rid := util.GetHTTPCCRequestID(header)
-	hostInfo := make([]mapstr.MapStr, 0)
+	sHostCond, err := buildHostSearchCond(appIDStr, hostIDStr)
+	if err != nil {
+		return nil, 0, err
+	}
+	sHostCond["page"] = make(map[string]interface{})
+	if page != nil {
+		sHostCond["page"] = map[string]interface{}{
+			"start": page.Start,
+			"limit": page.Limit,
+			"sort":  common.BKHostIDField,
+		}
+	}
+
+	result, err := lgc.Engine.CoreAPI.ApiServer().GetHostData(context.Background(), header, sHostCond)
+	if nil != err {
+		blog.Errorf("GetHostData failed, search condition: %+v, err: %+v, rid: %s", sHostCond, err, rid)
+		return nil, 0, err
+	}
+
+	if !result.Result {
+		blog.Errorf("GetHostData failed, search condition: %+v, result: %+v, rid: %s", sHostCond, result, rid)
+		return nil, 0, lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header)).New(result.Code, result.ErrMsg)
+	}
+
+	return result.Data.Info, result.Data.Count, nil
+}
+
+// buildHostSearchCond build the host search condition of excel export without page
+func buildHostSearchCond(appIDStr, hostIDStr string) (map[string]interface{}, error) {
 	sHostCond := make(map[string]interface{})
 	appID, err := strconv.ParseInt(appIDStr, 10, 64)
 	if err != nil {
@@ -51,7 +80,6 @@ func (lgc *Logics) GetHostData(appIDStr, hostIDStr string, header http.Header) (
 		sHostCond[common.BKAppIDField] = appID
 		sHostCond["ip"] = make(map[string]interface{})
 		sHostCond["condition"] = make([]interface{}, 0)
-		sHostCond["page"] = make(map[string]interface{})
 	} else {
 		sHostCond[common.BKAppIDField] = -1
 		sHostCond["ip"] = make(map[string]interface{})
@@ -92,21 +120,9 @@ func (lgc *Logics) GetHostData(appIDStr, hostIDStr string, header http.Header) (
 		condArr = append(condArr, condition)
 
 		sHostCond["condition"] = condArr
-		sHostCond["page"] = make(map[string]interface{})
-
-	}
-	result, err := lgc.Engine.CoreAPI.ApiServer().GetHostData(context.Background(), header, sHostCond)
-	if nil != err {
-		blog.Errorf("GetHostData failed, search condition: %+v, err: %+v, rid: %s", sHostCond, err, rid)
-		return hostInfo, err
-	}
 
-	if !result.Result {
-		blog.Errorf("GetHostData failed, search condition: %+v, result: %+v, rid: %s", sHostCond, result, rid)
-		return nil, lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header)).New(result.Code, result.ErrMsg)
 	}
-
-	return result.Data.Info, nil
+	return sHostCond, nil
 }
 
 // GetImportHosts get import hosts
@@ -127,7 +143,17 @@ func (lgc *Logics) GetImportHosts(f *xlsx.File, header http.Header, defLang lang
 		return nil, nil, errors.New(defLang.Language("web_excel_sheet_not_found"))
 	}
 
-	return GetExcelData(ctx, sheet, fields, common.KvMap{"import_from": common.HostAddMethodExcel}, true, 0, defLang)
+	hosts, rowErrMsg, err := GetExcelDataWithRowErrors(ctx, sheet, fields, common.KvMap{"import_from": common.HostAddMethodExcel}, true, 0, defLang)
+	if nil != err {
+		return nil, nil, err
+	}
+	if err := lgc.resolveReferenceNames(ctx, header, fields, hosts, rowErrMsg, defLang, meta); nil != err {
+		return nil, nil, err
+	}
+	if 0 != len(rowErrMsg) {
+		return nil, flattenRowErrors(rowErrMsg), nil
+	}
+	return hosts, nil, nil
 }
 
 // ImportHosts import host info
@@ -153,6 +179,14 @@ func (lgc *Logics) ImportHosts(ctx context.Context, f *xlsx.File, header http.He
 			},
 		}
 	}
+	return lgc.commitImportHosts(ctx, f, header, hosts)
+}
+
+// commitImportHosts add or update the hosts and import the association sheet of the excel
+func (lgc *Logics) commitImportHosts(ctx context.Context, f *xlsx.File, header http.Header,
+	hosts map[int]map[string]interface{}) *metadata.ResponseDataMapStr {
+
+	defErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 	var resultErr error
 	result := &metadata.ResponseDataMapStr{}
 	result.BaseResp.Result = true
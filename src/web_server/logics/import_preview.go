@@ -0,0 +1,565 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	lang "configcenter/src/common/language"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
+	"configcenter/src/common/util"
+
+	"github.com/rentiansheng/xlsx"
+)
+
+// importPreviewBatchSize how many rows are matched with the existing instances in one query
+const importPreviewBatchSize = 100
+
+// findExistingFunc find the existing instances, each condition is the key fields of a row
+type findExistingFunc func(ctx context.Context, conds []mapstr.MapStr) ([]mapstr.MapStr, error)
+
+// PreviewImportInsts classify the rows of an instance excel without changing anything.
+// the returned rows are the data to be committed, the instance id of the rows to be updated is filled in,
+// so that the rows matched by the unique check fields are updated instead of created.
+func (lgc *Logics) PreviewImportInsts(ctx context.Context, f *xlsx.File, objID string, header http.Header, defLang lang.DefaultCCLanguageIf,
+	meta *metadata.Metadata) (*metadata.ImportPreviewResult, map[int]map[string]interface{}, error) {
+
+	ownerID := util.GetOwnerID(header)
+	find := func(ctx context.Context, conds []mapstr.MapStr) ([]mapstr.MapStr, error) {
+		return lgc.findInstsByKeys(ctx, ownerID, objID, conds, header, meta)
+	}
+	return lgc.previewImportExcel(ctx, f, objID, header, defLang, meta, find)
+}
+
+// PreviewImportHosts classify the rows of a host excel without changing anything.
+func (lgc *Logics) PreviewImportHosts(ctx context.Context, f *xlsx.File, header http.Header, defLang lang.DefaultCCLanguageIf,
+	meta *metadata.Metadata) (*metadata.ImportPreviewResult, map[int]map[string]interface{}, error) {
+
+	find := func(ctx context.Context, conds []mapstr.MapStr) ([]mapstr.MapStr, error) {
+		return lgc.findHostsByKeys(ctx, conds, header)
+	}
+	return lgc.previewImportExcel(ctx, f, common.BKInnerObjIDHost, header, defLang, meta, find)
+}
+
+func (lgc *Logics) previewImportExcel(ctx context.Context, f *xlsx.File, objID string, header http.Header, defLang lang.DefaultCCLanguageIf,
+	meta *metadata.Metadata, find findExistingFunc) (*metadata.ImportPreviewResult, map[int]map[string]interface{}, error) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	fields, err := lgc.GetObjFieldIDs(objID, nil, nil, header, meta)
+	if nil != err {
+		return nil, nil, errors.New(defLang.Languagef("web_get_object_field_failure", err.Error()))
+	}
+	if 0 == len(f.Sheets) {
+		blog.Errorf("preview import object %s, but the excel file sheets is empty, rid: %s", objID, rid)
+		return nil, nil, errors.New(defLang.Language("web_excel_content_empty"))
+	}
+	sheet := f.Sheets[0]
+	if nil == sheet {
+		blog.Errorf("preview import object %s, but the excel file sheet is empty, rid: %s", objID, rid)
+		return nil, nil, errors.New(defLang.Language("web_excel_sheet_not_found"))
+	}
+
+	rows, rowErrs, err := GetExcelDataWithRowErrors(ctx, sheet, fields, common.KvMap{"import_from": common.HostAddMethodExcel}, true, 0, defLang)
+	if nil != err {
+		blog.Errorf("preview import object %s, get excel data failed, err: %v, rid: %s", objID, err, rid)
+		return nil, nil, err
+	}
+	if err := lgc.resolveReferenceNames(ctx, header, fields, rows, rowErrs, defLang, meta); nil != err {
+		blog.Errorf("preview import object %s, resolve reference names failed, err: %v, rid: %s", objID, err, rid)
+		return nil, nil, err
+	}
+
+	preview, err := previewImportRows(ctx, objID, rows, rowErrs, fields, defLang, find)
+	if nil != err {
+		blog.Errorf("preview import object %s, match existing instances failed, err: %v, rid: %s", objID, err, rid)
+		return nil, nil, err
+	}
+	return preview, rows, nil
+}
+
+type importRowKey struct {
+	row  int
+	key  string
+	cond mapstr.MapStr
+}
+
+// previewImportRows classify the rows. a row is matched with an existing instance by its instance id if it is set,
+// otherwise by the fields of the must check unique.
+func previewImportRows(ctx context.Context, objID string, rows map[int]map[string]interface{}, rowErrs map[int][]string,
+	fields map[string]Property, defLang lang.DefaultCCLanguageIf, find findExistingFunc) (*metadata.ImportPreviewResult, error) {
+
+	idField := metadata.GetInstIDFieldByObjID(objID)
+	uniqueFields := make([]string, 0)
+	for id, field := range fields {
+		if field.IsOnly {
+			uniqueFields = append(uniqueFields, id)
+		}
+	}
+	sort.Strings(uniqueFields)
+
+	rowNums := make([]int, 0)
+	for row := range rows {
+		rowNums = append(rowNums, row)
+	}
+	for row := range rowErrs {
+		if _, exist := rows[row]; !exist {
+			rowNums = append(rowNums, row)
+		}
+	}
+	sort.Ints(rowNums)
+
+	previews := make(map[int]*metadata.ImportRowPreview)
+	keys := make([]importRowKey, 0)
+	keyRows := make(map[string]int)
+	for _, row := range rowNums {
+		if errs, exist := rowErrs[row]; exist {
+			previews[row] = &metadata.ImportRowPreview{Row: row, Action: metadata.ImportRowActionInvalid, Errors: errs}
+			continue
+		}
+		data := rows[row]
+		if data == nil {
+			// ignore empty excel line
+			continue
+		}
+
+		key, cond, errMsg := importRowKeyOf(data, idField, uniqueFields, defLang)
+		if errMsg != "" {
+			previews[row] = &metadata.ImportRowPreview{Row: row, Action: metadata.ImportRowActionInvalid, Errors: []string{errMsg}}
+			continue
+		}
+		if dupRow, exist := keyRows[key]; exist {
+			previews[row] = &metadata.ImportRowPreview{Row: row, Action: metadata.ImportRowActionInvalid,
+				Errors: []string{defLang.Languagef("web_import_row_duplicate", dupRow)}}
+			continue
+		}
+		keyRows[key] = row
+		keys = append(keys, importRowKey{row: row, key: key, cond: cond})
+	}
+
+	existing := make(map[string]mapstr.MapStr)
+	for start := 0; start < len(keys); start += importPreviewBatchSize {
+		end := start + importPreviewBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		conds := make([]mapstr.MapStr, 0)
+		for _, key := range keys[start:end] {
+			conds = append(conds, key.cond)
+		}
+		insts, err := find(ctx, conds)
+		if err != nil {
+			return nil, err
+		}
+		for _, inst := range insts {
+			if id, exist := inst[idField]; exist {
+				existing["id:"+importValueString(id)] = inst
+			}
+			if key, ok := importUniqueKeyOf(inst, uniqueFields); ok {
+				existing[key] = inst
+			}
+		}
+	}
+
+	for _, key := range keys {
+		data := rows[key.row]
+		preview := &metadata.ImportRowPreview{Row: key.row}
+		previews[key.row] = preview
+
+		inst, matched := existing[key.key]
+		if !matched {
+			if _, byID := key.cond[idField]; byID {
+				preview.Action = metadata.ImportRowActionInvalid
+				preview.Errors = []string{defLang.Languagef("web_import_inst_not_found", key.cond[idField])}
+				continue
+			}
+			preview.Action = metadata.ImportRowActionCreate
+			for _, id := range sortedFieldIDs(fields) {
+				if fields[id].IsRequire && importValueString(data[id]) == "" {
+					preview.Action = metadata.ImportRowActionInvalid
+					preview.Errors = append(preview.Errors, defLang.Languagef("web_import_required_field_empty", fields[id].Name))
+				}
+			}
+			continue
+		}
+
+		instID, err := util.GetInt64ByInterface(inst[idField])
+		if err != nil {
+			return nil, fmt.Errorf("parse instance id of %v failed, err: %v", inst, err)
+		}
+		preview.InstID = instID
+		for _, id := range sortedFieldIDs(fields) {
+			after, exist := data[id]
+			if !exist || id == idField {
+				continue
+			}
+			if importValueString(after) != importValueString(inst[id]) {
+				preview.Diff = append(preview.Diff, metadata.ImportFieldDiff{PropertyID: id, Before: inst[id], After: after})
+			}
+		}
+		if len(preview.Diff) == 0 {
+			preview.Action = metadata.ImportRowActionUnchanged
+		} else {
+			preview.Action = metadata.ImportRowActionUpdate
+			data[idField] = instID
+		}
+	}
+
+	result := &metadata.ImportPreviewResult{
+		Rows:    make([]metadata.ImportRowPreview, 0),
+		Summary: make(map[metadata.ImportRowAction]int),
+	}
+	for _, row := range rowNums {
+		if preview, exist := previews[row]; exist {
+			result.Rows = append(result.Rows, *preview)
+			result.Summary[preview.Action]++
+		}
+	}
+	return result, nil
+}
+
+// ImportRowsToCommit get the rows to be committed according to the preview, only the rows to be created or updated
+// are kept, the other rows are set to nil which is ignored as an empty excel line.
+func ImportRowsToCommit(preview *metadata.ImportPreviewResult, rows map[int]map[string]interface{}) map[int]map[string]interface{} {
+	commit := make(map[int]map[string]interface{})
+	for _, row := range preview.Rows {
+		if row.Action == metadata.ImportRowActionCreate || row.Action == metadata.ImportRowActionUpdate {
+			commit[row.Row] = rows[row.Row]
+		}
+	}
+	return commit
+}
+
+func importRowKeyOf(data map[string]interface{}, idField string, uniqueFields []string, defLang lang.DefaultCCLanguageIf) (string, mapstr.MapStr, string) {
+	if id, exist := data[idField]; exist && importValueString(id) != "" {
+		instID, err := util.GetInt64ByInterface(id)
+		if err != nil {
+			return "", nil, defLang.Languagef("web_import_inst_not_found", id)
+		}
+		return "id:" + strconv.FormatInt(instID, 10), mapstr.MapStr{idField: instID}, ""
+	}
+
+	cond := mapstr.New()
+	for _, field := range uniqueFields {
+		val, exist := data[field]
+		if (!exist || importValueString(val) == "") && field == common.BKCloudIDField {
+			// the host is imported to the default cloud area if it is not set
+			val, exist = int64(common.BKDefaultDirSubArea), true
+		}
+		if !exist || importValueString(val) == "" {
+			return "", nil, defLang.Languagef("web_import_unique_field_empty", field)
+		}
+		cond[field] = val
+	}
+	key, _ := importUniqueKeyOf(cond, uniqueFields)
+	return key, cond, ""
+}
+
+func importUniqueKeyOf(data map[string]interface{}, uniqueFields []string) (string, bool) {
+	if len(uniqueFields) == 0 {
+		return "", false
+	}
+	vals := make([]string, 0)
+	for _, field := range uniqueFields {
+		val := importValueString(data[field])
+		if val == "" {
+			return "", false
+		}
+		vals = append(vals, val)
+	}
+	return "unique:" + strings.Join(vals, common.ExcelAsstPrimaryKeySplitChar), true
+}
+
+// importValueString format the value so that the values from excel and from db are comparable
+func importValueString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		floatVal, err := util.GetFloat64ByInterface(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return strconv.FormatFloat(floatVal, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedFieldIDs(fields map[string]Property) []string {
+	ids := make([]string, 0)
+	for id := range fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (lgc *Logics) findInstsByKeys(ctx context.Context, ownerID, objID string, conds []mapstr.MapStr, header http.Header, meta *metadata.Metadata) ([]mapstr.MapStr, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	searchCond := mapstr.MapStr{
+		"fields": []string{},
+		"condition": mapstr.MapStr{
+			common.BKObjIDField: objID,
+			common.BKDBOR:       conds,
+		},
+		"page": mapstr.MapStr{
+			"start": 0,
+			"limit": common.BKNoLimit,
+		},
+		metadata.BKMetadata: meta,
+	}
+	result, err := lgc.Engine.CoreAPI.ApiServer().GetInstDetail(ctx, header, ownerID, objID, searchCond)
+	if nil != err {
+		blog.Errorf("find import instances by keys failed, search condition: %#v, err: %v, rid: %s", searchCond, err, rid)
+		return nil, lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header)).Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("find import instances by keys failed, search condition: %#v, result: %+v, rid: %s", searchCond, result, rid)
+		return nil, lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header)).New(result.Code, result.ErrMsg)
+	}
+	return result.Data.Info, nil
+}
+
+func (lgc *Logics) findHostsByKeys(ctx context.Context, conds []mapstr.MapStr, header http.Header) ([]mapstr.MapStr, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	keyRules := make([]querybuilder.Rule, 0)
+	for _, cond := range conds {
+		rules := make([]querybuilder.Rule, 0)
+		for field, val := range cond {
+			rules = append(rules, querybuilder.AtomRule{
+				Field:    field,
+				Operator: querybuilder.OperatorEqual,
+				Value:    val,
+			})
+		}
+		keyRules = append(keyRules, querybuilder.CombinedRule{
+			Condition: querybuilder.ConditionAnd,
+			Rules:     rules,
+		})
+	}
+	option := metadata.ListHostsWithNoBizParameter{
+		HostPropertyFilter: &querybuilder.QueryFilter{
+			Rule: querybuilder.CombinedRule{
+				Condition: querybuilder.ConditionOr,
+				Rules:     keyRules,
+			},
+		},
+		Page: metadata.BasePage{
+			Limit: common.BKNoLimit,
+		},
+	}
+	result, err := lgc.Engine.CoreAPI.ApiServer().ListHostWithoutApp(ctx, header, option)
+	if nil != err {
+		blog.Errorf("find import hosts by keys failed, option: %#v, err: %v, rid: %s", option, err, rid)
+		return nil, lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header)).Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("find import hosts by keys failed, option: %#v, result: %+v, rid: %s", option, result, rid)
+		return nil, lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header)).New(result.Code, result.ErrMsg)
+	}
+
+	hosts := make([]mapstr.MapStr, 0)
+	for _, host := range result.Data.Info {
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// ImportInstsWithMode import the instances of the excel with the import mode, returns the preview of the rows
+// in the result data if the mode is not the default one.
+func (lgc *Logics) ImportInstsWithMode(ctx context.Context, f *xlsx.File, objID string, header http.Header, defLang lang.DefaultCCLanguageIf,
+	meta *metadata.Metadata, mode metadata.ImportMode) (resultData mapstr.MapStr, errCode int, err error) {
+
+	if mode == metadata.ImportModeDefault {
+		return lgc.ImportInsts(ctx, f, objID, header, defLang, meta)
+	}
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	defErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	preview, rows, err := lgc.PreviewImportInsts(ctx, f, objID, header, defLang, meta)
+	if err != nil {
+		blog.Errorf("import %s instances with mode %s, preview failed, err: %v, rid: %s", objID, mode, err, rid)
+		return nil, common.CCErrWebFileContentFail, err
+	}
+	if mode == metadata.ImportModeAllOrNothing && preview.HasInvalidRow() {
+		return mapstr.MapStr{"preview": preview}, common.CCErrWebImportHasInvalidRow, defErr.Error(common.CCErrWebImportHasInvalidRow)
+	}
+
+	insts := ImportRowsToCommit(preview, rows)
+	if mode != metadata.ImportModeAllOrNothing {
+		resultData, errCode, err = lgc.commitImportInsts(ctx, f, objID, header, meta, insts)
+		if resultData == nil {
+			resultData = mapstr.New()
+		}
+		resultData.Set("preview", preview)
+		return resultData, errCode, err
+	}
+
+	err = lgc.importInTransaction(ctx, header, func(header http.Header) (bool, error) {
+		resultData, errCode, err = lgc.commitImportInsts(ctx, f, objID, header, meta, insts)
+		if err != nil {
+			return false, err
+		}
+		return !importResultHasError(resultData), nil
+	})
+	if err != nil {
+		blog.Errorf("import %s instances all or nothing failed, err: %v, rid: %s", objID, err, rid)
+		if errCode == 0 {
+			errCode = common.CCErrWebImportTxnNotAvailable
+		}
+	} else if importResultHasError(resultData) {
+		errCode = common.CCErrWebImportHasInvalidRow
+		err = defErr.Error(common.CCErrWebImportHasInvalidRow)
+	}
+	if resultData == nil {
+		resultData = mapstr.New()
+	}
+	resultData.Set("preview", preview)
+	return resultData, errCode, err
+}
+
+// ImportHostsWithMode import the hosts of the excel with the import mode, returns the preview of the rows
+// in the result data if the mode is not the default one.
+func (lgc *Logics) ImportHostsWithMode(ctx context.Context, f *xlsx.File, header http.Header, defLang lang.DefaultCCLanguageIf,
+	meta *metadata.Metadata, mode metadata.ImportMode) *metadata.ResponseDataMapStr {
+
+	if mode == metadata.ImportModeDefault {
+		return lgc.ImportHosts(ctx, f, header, defLang, meta)
+	}
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	defErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	preview, rows, err := lgc.PreviewImportHosts(ctx, f, header, defLang, meta)
+	if err != nil {
+		blog.Errorf("import hosts with mode %s, preview failed, err: %v, rid: %s", mode, err, rid)
+		return &metadata.ResponseDataMapStr{
+			BaseResp: metadata.BaseResp{Result: false, Code: common.CCErrWebFileContentFail, ErrMsg: err.Error()},
+		}
+	}
+	if mode == metadata.ImportModeAllOrNothing && preview.HasInvalidRow() {
+		return &metadata.ResponseDataMapStr{
+			BaseResp: metadata.BaseResp{
+				Result: false,
+				Code:   common.CCErrWebImportHasInvalidRow,
+				ErrMsg: defErr.Error(common.CCErrWebImportHasInvalidRow).Error(),
+			},
+			Data: mapstr.MapStr{"preview": preview},
+		}
+	}
+
+	hosts := ImportRowsToCommit(preview, rows)
+	var result *metadata.ResponseDataMapStr
+	if mode != metadata.ImportModeAllOrNothing {
+		result = lgc.commitImportHosts(ctx, f, header, hosts)
+	} else {
+		err = lgc.importInTransaction(ctx, header, func(header http.Header) (bool, error) {
+			result = lgc.commitImportHosts(ctx, f, header, hosts)
+			return result.Result && !importResultHasError(result.Data), nil
+		})
+		if err != nil {
+			blog.Errorf("import hosts all or nothing failed, err: %v, rid: %s", err, rid)
+			result = &metadata.ResponseDataMapStr{
+				BaseResp: metadata.BaseResp{Result: false, Code: common.CCErrWebImportTxnNotAvailable, ErrMsg: err.Error()},
+			}
+		} else if result.Result && importResultHasError(result.Data) {
+			result.BaseResp = metadata.BaseResp{
+				Result: false,
+				Code:   common.CCErrWebImportHasInvalidRow,
+				ErrMsg: defErr.Error(common.CCErrWebImportHasInvalidRow).Error(),
+			}
+		}
+	}
+	if result.Data == nil {
+		result.Data = mapstr.New()
+	}
+	result.Data.Set("preview", preview)
+	return result
+}
+
+// importInTransaction run the import in a transaction, the transaction is committed only if the import returns true,
+// otherwise it is aborted.
+func (lgc *Logics) importInTransaction(ctx context.Context, header http.Header, doImport func(header http.Header) (bool, error)) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	defErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	if lgc.Txn == nil {
+		return defErr.Error(common.CCErrWebImportTxnNotAvailable)
+	}
+
+	tx, err := lgc.Txn.Start(ctx)
+	if err != nil {
+		blog.Errorf("start import transaction failed, err: %v, rid: %s", err, rid)
+		return defErr.Error(common.CCErrWebImportTxnNotAvailable)
+	}
+	txnHeader := tx.TxnInfo().IntoHeader(util.CloneHeader(header))
+
+	success, err := doImport(txnHeader)
+	if err != nil || !success {
+		if txnErr := tx.Abort(ctx); txnErr != nil {
+			blog.Errorf("abort import transaction[id: %s] failed, err: %v, rid: %s", tx.TxnInfo().TxnID, txnErr, rid)
+		}
+		return err
+	}
+	if txnErr := tx.Commit(ctx); txnErr != nil {
+		blog.Errorf("commit import transaction[id: %s] failed, err: %v, rid: %s", tx.TxnInfo().TxnID, txnErr, rid)
+		return defErr.Error(common.CCErrWebImportTxnNotAvailable)
+	}
+	return nil
+}
+
+// importResultHasError check whether any row or association failed in the import result
+func importResultHasError(data mapstr.MapStr) bool {
+	for _, key := range []string{"error", "update_error", "asst_error"} {
+		val, exist := data[key]
+		if !exist || val == nil {
+			continue
+		}
+		switch v := val.(type) {
+		case []interface{}:
+			if len(v) > 0 {
+				return true
+			}
+		case map[string]interface{}:
+			if len(v) > 0 {
+				return true
+			}
+		case mapstr.MapStr:
+			if len(v) > 0 {
+				return true
+			}
+		case []string:
+			if len(v) > 0 {
+				return true
+			}
+		case []metadata.RowMsgData:
+			if len(v) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
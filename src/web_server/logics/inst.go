@@ -47,13 +47,26 @@ func (lgc *Logics) GetImportInsts(ctx context.Context, f *xlsx.File, objID strin
 		return nil, nil, errors.New(defLang.Language("web_excel_sheet_not_found"))
 	}
 	if isInst {
-		return GetExcelData(ctx, sheet, fields, common.KvMap{"import_from": common.HostAddMethodExcel}, true, headerRow, defLang)
+		insts, rowErrMsg, err := GetExcelDataWithRowErrors(ctx, sheet, fields, common.KvMap{"import_from": common.HostAddMethodExcel}, true, headerRow, defLang)
+		if nil != err {
+			return nil, nil, err
+		}
+		if err := lgc.resolveReferenceNames(ctx, header, fields, insts, rowErrMsg, defLang, meta); nil != err {
+			return nil, nil, err
+		}
+		if 0 != len(rowErrMsg) {
+			return nil, flattenRowErrors(rowErrMsg), nil
+		}
+		return insts, nil, nil
 	} else {
 		return GetRawExcelData(ctx, sheet, common.KvMap{"import_from": common.HostAddMethodExcel}, headerRow, defLang)
 	}
 }
 
-func (lgc *Logics) GetInstData(ownerID, objID, instIDStr string, header http.Header, kvMap mapstr.MapStr, meta *metadata.Metadata) ([]mapstr.MapStr, error) {
+// GetInstData get the instances to be exported and the total count, all the instances are returned if page is nil,
+// otherwise one page of them sorted by instance id. the names of the object attributes are set into kvMap if it's not nil.
+func (lgc *Logics) GetInstData(ownerID, objID, instIDStr string, header http.Header, kvMap mapstr.MapStr, meta *metadata.Metadata,
+	page *metadata.BasePage) ([]mapstr.MapStr, int, error) {
 	rid := util.GetHTTPCCRequestID(header)
 	defErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 	instIDArr := strings.Split(instIDStr, ",")
@@ -74,21 +87,32 @@ func (lgc *Logics) GetInstData(ownerID, objID, instIDStr string, header http.Hea
 		common.BKObjIDField:   objID,
 	}
 	searchCond["page"] = nil
+	if page != nil {
+		searchCond["page"] = mapstr.MapStr{
+			"start": page.Start,
+			"limit": page.Limit,
+			"sort":  common.BKInstIDField,
+		}
+	}
 	searchCond[metadata.BKMetadata] = meta
 	result, err := lgc.Engine.CoreAPI.ApiServer().GetInstDetail(context.Background(), header, ownerID, objID, searchCond)
 	if nil != err {
 		blog.Errorf("get inst data detail error:%v , search condition:%#v, rid: %s", err, searchCond, rid)
-		return nil, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		return nil, 0, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
 	}
 
 	if !result.Result {
 		blog.Errorf("get inst data detail error:%v , search condition:%#v, rid: %s", result.ErrMsg, searchCond, rid)
-		return nil, defErr.Error(result.Code)
+		return nil, 0, defErr.Error(result.Code)
 	}
 
 	if 0 == result.Data.Count {
 		blog.Errorf("get inst data detail, but got 0 instances , search condition:%#v, rid: %s", searchCond, rid)
-		return nil, defErr.Error(common.CCErrAPINoObjectInstancesIsFound)
+		return nil, 0, defErr.Error(common.CCErrAPINoObjectInstancesIsFound)
+	}
+
+	if kvMap == nil {
+		return result.Data.Info, result.Data.Count, nil
 	}
 
 	// read object attributes
@@ -98,19 +122,19 @@ func (lgc *Logics) GetInstData(ownerID, objID, instIDStr string, header http.Hea
 	attrResult, aErr := lgc.Engine.CoreAPI.ApiServer().GetObjectAttr(context.Background(), header, attrCond)
 	if nil != aErr {
 		blog.Errorf("get object: %s instance, but get object attr error: %v, rid: %s", objID, aErr, rid)
-		return nil, defErr.Error(common.CCErrTopoObjectAttributeSelectFailed)
+		return nil, 0, defErr.Error(common.CCErrTopoObjectAttributeSelectFailed)
 	}
 
 	if !attrResult.Result {
 		blog.Errorf("get object: %s instance, but get object attr error: %s, rid: %s", objID, attrResult.Code, rid)
-		return nil, defErr.Error(common.CCErrTopoObjectAttributeSelectFailed)
+		return nil, 0, defErr.Error(common.CCErrTopoObjectAttributeSelectFailed)
 	}
 
 	for _, cell := range attrResult.Data {
 		kvMap.Set(cell.PropertyID, cell.PropertyName)
 	}
 
-	return result.Data.Info, nil
+	return result.Data.Info, result.Data.Count, nil
 }
 
 // ImportHosts import host info
@@ -129,6 +153,16 @@ func (lgc *Logics) ImportInsts(ctx context.Context, f *xlsx.File, objID string,
 		return resultData, common.CCErrWebFileContentFail, defErr.Errorf(common.CCErrWebFileContentFail, " file empty")
 	}
 
+	return lgc.commitImportInsts(ctx, f, objID, header, meta, insts)
+}
+
+// commitImportInsts add or update the instances and import the association sheet of the excel
+func (lgc *Logics) commitImportInsts(ctx context.Context, f *xlsx.File, objID string, header http.Header, meta *metadata.Metadata,
+	insts map[int]map[string]interface{}) (resultData mapstr.MapStr, errCode int, err error) {
+
+	defErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	resultData = mapstr.New()
+
 	var resultErr error
 	result := &metadata.ResponseDataMapStr{}
 	result.BaseResp.Result = true
@@ -14,8 +14,11 @@ package logics
 
 import (
 	"configcenter/src/common/backbone"
+	"configcenter/src/storage/dal"
 )
 
 type Logics struct {
 	*backbone.Engine
+	// Txn is used to commit the excel import in one transaction, it is nil if the txn server is not available
+	Txn dal.Transcation
 }
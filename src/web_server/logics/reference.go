@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	lang "configcenter/src/common/language"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
+	"configcenter/src/common/util"
+)
+
+// getReferenceObjID get the target object of the reference attribute from the option
+func getReferenceObjID(option interface{}) string {
+	switch opt := option.(type) {
+	case map[string]interface{}:
+		return util.GetStrByInterface(opt[common.BKObjIDField])
+	case mapstr.MapStr:
+		return util.GetStrByInterface(opt[common.BKObjIDField])
+	}
+	return ""
+}
+
+// getReferenceDisplayName get the name of the referred instance from the display info in the search result
+func getReferenceDisplayName(rowMap mapstr.MapStr, propertyID string) string {
+	display, ok := rowMap[metadata.ReferenceDisplayField].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	ref, ok := display[propertyID].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	return util.GetStrByInterface(ref[common.BKInstNameField])
+}
+
+// referenceNameField the field used as the name of the referred instance in excel
+func referenceNameField(objID string) string {
+	if objID == common.BKInnerObjIDHost {
+		return common.BKHostInnerIPField
+	}
+	return common.GetInstNameField(objID)
+}
+
+// resolveReferenceNames convert the instance names of the reference fields in excel to the instance ids,
+// a numeric cell is used as the instance id directly. the errors are added to the row error messages.
+func (lgc *Logics) resolveReferenceNames(ctx context.Context, header http.Header, fields map[string]Property,
+	rows map[int]map[string]interface{}, rowErrMsg map[int][]string, defLang lang.DefaultCCLanguageIf, meta *metadata.Metadata) error {
+
+	for _, field := range fields {
+		if field.PropertyType != common.FieldTypeReference {
+			continue
+		}
+		objID := getReferenceObjID(field.Option)
+		if objID == "" {
+			continue
+		}
+
+		names := make([]string, 0)
+		for _, row := range rows {
+			if name, ok := row[field.ID].(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		names = util.StrArrayUnique(names)
+
+		nameIDs, err := lgc.findInstIDsByNames(ctx, header, objID, names, meta)
+		if err != nil {
+			return err
+		}
+		for rowNum, row := range rows {
+			name, ok := row[field.ID].(string)
+			if !ok || name == "" {
+				continue
+			}
+			ids := nameIDs[name]
+			if len(ids) != 1 {
+				rowErrMsg[rowNum] = append(rowErrMsg[rowNum], defLang.Languagef("web_import_reference_not_found", rowNum, field.Name, name))
+				continue
+			}
+			row[field.ID] = ids[0]
+		}
+	}
+	return nil
+}
+
+// findInstIDsByNames find the instance ids of the names, a name may match several instances
+func (lgc *Logics) findInstIDsByNames(ctx context.Context, header http.Header, objID string, names []string,
+	meta *metadata.Metadata) (map[string][]int64, error) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	defErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	idField := common.GetInstIDField(objID)
+	nameField := referenceNameField(objID)
+
+	var insts []mapstr.MapStr
+	if objID == common.BKInnerObjIDHost {
+		option := metadata.ListHostsWithNoBizParameter{
+			HostPropertyFilter: &querybuilder.QueryFilter{
+				Rule: querybuilder.AtomRule{
+					Field:    nameField,
+					Operator: querybuilder.OperatorIn,
+					Value:    names,
+				},
+			},
+			Page: metadata.BasePage{
+				Limit: common.BKNoLimit,
+			},
+		}
+		result, err := lgc.Engine.CoreAPI.ApiServer().ListHostWithoutApp(ctx, header, option)
+		if nil != err {
+			blog.Errorf("find referred hosts by names failed, names: %v, err: %v, rid: %s", names, err, rid)
+			return nil, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("find referred hosts by names failed, names: %v, result: %+v, rid: %s", names, result, rid)
+			return nil, defErr.New(result.Code, result.ErrMsg)
+		}
+		for _, host := range result.Data.Info {
+			insts = append(insts, host)
+		}
+	} else {
+		searchCond := mapstr.MapStr{
+			"fields": []string{idField, nameField},
+			"condition": mapstr.MapStr{
+				nameField: mapstr.MapStr{common.BKDBIN: names},
+			},
+			"page": mapstr.MapStr{
+				"start": 0,
+				"limit": common.BKNoLimit,
+			},
+			metadata.BKMetadata: meta,
+		}
+		result, err := lgc.Engine.CoreAPI.ApiServer().GetInstDetail(ctx, header, util.GetOwnerID(header), objID, searchCond)
+		if nil != err {
+			blog.Errorf("find referred %s instances by names failed, names: %v, err: %v, rid: %s", objID, names, err, rid)
+			return nil, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("find referred %s instances by names failed, names: %v, result: %+v, rid: %s", objID, names, result, rid)
+			return nil, defErr.New(result.Code, result.ErrMsg)
+		}
+		insts = result.Data.Info
+	}
+
+	nameIDs := make(map[string][]int64)
+	for _, inst := range insts {
+		id, err := util.GetInt64ByInterface(inst[idField])
+		if err != nil {
+			continue
+		}
+		name := util.GetStrByInterface(inst[nameField])
+		nameIDs[name] = append(nameIDs[name], id)
+	}
+	return nameIDs, nil
+}
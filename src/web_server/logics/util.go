@@ -97,6 +97,38 @@ func getEnumIDByName(name string, items []interface{}) string {
 	return id
 }
 
+// getEnumMultiNamesByIDs get the option names of the multi-select enum value, joined by the split char
+func getEnumMultiNamesByIDs(val interface{}, items []interface{}) string {
+	ids, ok := val.([]interface{})
+	if !ok {
+		return ""
+	}
+	names := make([]string, 0)
+	for _, id := range ids {
+		strID, ok := id.(string)
+		if !ok {
+			continue
+		}
+		if name := getEnumNameByID(strID, items); "" != name {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, common.ExcelEnumMultiSplitChar)
+}
+
+// getEnumMultiIDsByNames get the option ids of the multi-select enum from the names joined by the split char
+func getEnumMultiIDsByNames(names string, items []interface{}) []interface{} {
+	ids := make([]interface{}, 0)
+	for _, name := range strings.Split(names, common.ExcelEnumMultiSplitChar) {
+		name = strings.TrimSpace(name)
+		if "" == name {
+			continue
+		}
+		ids = append(ids, getEnumIDByName(name, items))
+	}
+	return ids
+}
+
 // getEnumNames get enum name from option
 func getEnumNames(items []interface{}) []string {
 	var names []string
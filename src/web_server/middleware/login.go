@@ -13,6 +13,9 @@
 package middleware
 
 import (
+	"net"
+	"net/http"
+	"net/url"
 	"plugin"
 	"strings"
 
@@ -47,6 +50,19 @@ func ValidLogin(config options.Config, disc discovery.DiscoveryInterface) gin.Ha
 		case "healthz", "metrics":
 			c.Next()
 			return
+		case "internal":
+			// internal apis are called back by task server, they have no login session,
+			// so only the requests from the registered task servers are accepted.
+			if isFromTaskServer(c, disc) == false {
+				blog.Errorf("internal api %s is requested by %s which is not a task server, rid: %s", c.Request.URL.Path, c.Request.RemoteAddr, rid)
+				c.JSON(http.StatusForbidden, gin.H{
+					"status": "forbidden",
+				})
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
 		}
 
 		if isAuthed(c, config) {
@@ -96,6 +112,31 @@ func ValidLogin(config options.Config, disc discovery.DiscoveryInterface) gin.Ha
 
 }
 
+// isFromTaskServer check whether the request comes from one of the task servers registered in discovery
+func isFromTaskServer(c *gin.Context, disc discovery.DiscoveryInterface) bool {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	remoteIP, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		blog.Errorf("parse remote address %s failed, err: %v, rid: %s", c.Request.RemoteAddr, err, rid)
+		return false
+	}
+	servers, err := disc.TaskServer().GetServers()
+	if err != nil {
+		blog.Errorf("get task servers failed, err: %v, rid: %s", err, rid)
+		return false
+	}
+	for _, server := range servers {
+		serverURL, err := url.Parse(server)
+		if err != nil {
+			continue
+		}
+		if serverURL.Hostname() == remoteIP {
+			return true
+		}
+	}
+	return false
+}
+
 // IsAuthed check user is authed
 func isAuthed(c *gin.Context, config options.Config) bool {
 	rid := util.GetHTTPCCRequestID(c.Request.Header)
@@ -0,0 +1,346 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/httpclient"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	webCommon "configcenter/src/web_server/common"
+	"configcenter/src/web_server/logics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/xid"
+)
+
+// exportTaskExpire how long the progress and the file of an asynchronous export are kept
+const exportTaskExpire = 24 * time.Hour
+
+// CreateExportHostTask create an asynchronous host export task, the form values are the same as ExportHost
+func (s *Service) CreateExportHostTask(c *gin.Context) {
+	webCommon.SetProxyHeader(c)
+	task := &metadata.ExportExcelTask{
+		ObjID:        common.BKInnerObjIDHost,
+		OwnerID:      util.GetOwnerID(c.Request.Header),
+		AppID:        c.PostForm(common.BKAppIDField),
+		InstIDs:      c.PostForm(common.BKHostIDField),
+		CustomFields: c.PostForm(common.ExportCustomFields),
+		Metadata:     &metadata.Metadata{},
+	}
+	s.createExportTask(c, task)
+}
+
+// CreateExportInstTask create an asynchronous instance export task, the form values are the same as ExportInst
+func (s *Service) CreateExportInstTask(c *gin.Context) {
+	webCommon.SetProxyHeader(c)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(webCommon.GetLanguageByHTTPRequest(c))
+
+	metaInfo, err := parseMetadata(c.PostForm(metadata.BKMetadata))
+	if err != nil {
+		msg := getReturnStr(common.CCErrCommJSONUnmarshalFailed, defErr.Error(common.CCErrCommJSONUnmarshalFailed).Error(), nil)
+		c.String(http.StatusOK, msg)
+		return
+	}
+	task := &metadata.ExportExcelTask{
+		ObjID:        c.Param(common.BKObjIDField),
+		OwnerID:      c.Param(common.BKOwnerIDField),
+		InstIDs:      c.PostForm(common.BKInstIDField),
+		CustomFields: c.PostForm(common.ExportCustomFields),
+		Metadata:     metaInfo,
+	}
+	s.createExportTask(c, task)
+}
+
+func (s *Service) createExportTask(c *gin.Context, task *metadata.ExportExcelTask) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	ctx := util.NewContextFromGinContext(c)
+	header := c.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+
+	task.ExportID = xid.New().String()
+	result, err := s.CoreAPI.TaskServer().Task().Create(ctx, header, common.ExportExcelTaskName, task.ExportID, []interface{}{task})
+	if err != nil {
+		blog.Errorf("create export task failed, task: %+v, err: %v, rid: %s", task, err, rid)
+		msg := getReturnStr(common.CCErrCommHTTPDoRequestFailed, defErr.Error(common.CCErrCommHTTPDoRequestFailed).Error(), nil)
+		c.String(http.StatusOK, msg)
+		return
+	}
+	if !result.Result {
+		blog.Errorf("create export task failed, task: %+v, result: %+v, rid: %s", task, result, rid)
+		c.String(http.StatusOK, getReturnStr(result.Code, result.ErrMsg, nil))
+		return
+	}
+
+	s.setExportProgress(task.ExportID, 0, 0, rid)
+	c.String(http.StatusOK, getReturnStr(0, "", metadata.ExportExcelProgress{
+		TaskID: result.Data.TaskID,
+		Status: result.Data.Status,
+	}))
+}
+
+// GetExportTaskProgress get the progress of an asynchronous export task created by current user
+func (s *Service) GetExportTaskProgress(c *gin.Context) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	webCommon.SetProxyHeader(c)
+
+	task, errCode, err := s.getUserExportTask(c)
+	if err != nil {
+		c.String(http.StatusOK, getReturnStr(errCode, err.Error(), nil))
+		return
+	}
+
+	progress := s.getExportProgress(task.Flag, rid)
+	progress.TaskID = task.TaskID
+	progress.Status = task.Status
+	c.String(http.StatusOK, getReturnStr(0, "", progress))
+}
+
+// DownloadExportTaskFile download the excel of a finished asynchronous export task created by current user
+func (s *Service) DownloadExportTaskFile(c *gin.Context) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	webCommon.SetProxyHeader(c)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(c.Request.Header))
+
+	task, errCode, err := s.getUserExportTask(c)
+	if err != nil {
+		c.String(http.StatusOK, getReturnStr(errCode, err.Error(), nil))
+		return
+	}
+	if !task.Status.IsSuccessful() {
+		blog.Errorf("download export file failed, task %s status is %d, rid: %s", task.TaskID, task.Status, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebExportTaskNotFinished, defErr.Error(common.CCErrWebExportTaskNotFinished).Error(), nil))
+		return
+	}
+
+	filePath := exportTaskFilePath(task.Flag)
+	if _, err := os.Stat(filePath); err != nil {
+		// the file is saved on the web server that executed the task, route the download to it
+		node := s.getExportNode(task.Flag, rid)
+		if node != "" && node != common.GetServerInfo().Address() {
+			blog.V(4).Infof("export file %s is not on this node, proxy the download to %s, rid: %s", filePath, node, rid)
+			httpclient.ProxyHttp(c, node)
+			return
+		}
+		blog.Errorf("download export file failed, stat file %s failed, err: %v, rid: %s", filePath, err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebExportTaskNotFound, defErr.Error(common.CCErrWebExportTaskNotFound).Error(), nil))
+		return
+	}
+
+	taskData, err := decodeExportTask(task)
+	if err != nil {
+		blog.Errorf("download export file failed, decode task %s failed, err: %v, rid: %s", task.TaskID, err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrCommJSONUnmarshalFailed, defErr.Error(common.CCErrCommJSONUnmarshalFailed).Error(), nil))
+		return
+	}
+	if taskData.ObjID == common.BKInnerObjIDHost {
+		logics.AddDownExcelHttpHeader(c, "bk_cmdb_export_host.xlsx")
+	} else {
+		logics.AddDownExcelHttpHeader(c, fmt.Sprintf("bk_cmdb_export_inst_%s.xlsx", taskData.ObjID))
+	}
+	c.File(filePath)
+}
+
+// ExportTaskHandler execute an asynchronous export task, it is called by task server,
+// the login middleware only accepts the requests of the registered task servers to it.
+// only the task recorded in task server is trusted, the data is fetched with the header of the task creator,
+// so the export is authorized the same as the synchronous export.
+func (s *Service) ExportTaskHandler(c *gin.Context) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(c.Request.Header))
+
+	input := new(metadata.ExportExcelTask)
+	if err := c.BindJSON(input); err != nil {
+		blog.Errorf("execute export task failed, unmarshal body failed, err: %v, rid: %s", err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrCommJSONUnmarshalFailed, defErr.Error(common.CCErrCommJSONUnmarshalFailed).Error(), nil))
+		return
+	}
+
+	task, err := s.findExportTask(c.Request.Header, input.ExportID)
+	if err != nil {
+		blog.Errorf("execute export task failed, find task %s failed, err: %v, rid: %s", input.ExportID, err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebExportTaskNotFound, defErr.Error(common.CCErrWebExportTaskNotFound).Error(), nil))
+		return
+	}
+	if task.Status != metadata.APITaskStatuExecute {
+		blog.Errorf("execute export task failed, task %s status is %d, rid: %s", task.TaskID, task.Status, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebExportTaskNotFound, defErr.Error(common.CCErrWebExportTaskNotFound).Error(), nil))
+		return
+	}
+	taskData, err := decodeExportTask(task)
+	if err != nil {
+		blog.Errorf("execute export task failed, decode task %s failed, err: %v, rid: %s", task.TaskID, err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrCommJSONUnmarshalFailed, defErr.Error(common.CCErrCommJSONUnmarshalFailed).Error(), nil))
+		return
+	}
+
+	filePath := exportTaskFilePath(taskData.ExportID)
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModeDir|os.ModePerm); err != nil {
+		blog.Errorf("execute export task %s failed, make dir failed, err: %v, rid: %s", task.TaskID, err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebFileSaveFail, defErr.Errorf(common.CCErrWebFileSaveFail, err.Error()).Error(), nil))
+		return
+	}
+	removeExpiredExportFiles(filepath.Dir(filePath), rid)
+
+	header := util.CloneHeader(task.Header)
+	header.Set(common.BKHTTPCCRequestID, rid)
+	ctx := util.NewContextFromHTTPHeader(header)
+	err = s.Logics.BuildExportExcelByPage(ctx, taskData, header, filePath, func(exported, total int) {
+		s.setExportProgress(taskData.ExportID, exported, total, rid)
+	})
+	if err != nil {
+		blog.Errorf("execute export task %s failed, build excel failed, err: %v, rid: %s", task.TaskID, err, rid)
+		reply := getReturnStr(common.CCErrWebCreateEXCELFail, defErr.Errorf(common.CCErrWebCreateEXCELFail, err.Error()).Error(), nil)
+		c.String(http.StatusOK, reply)
+		return
+	}
+
+	// the file is only on this node, the download on the other nodes is proxied to it
+	if err := s.setExportNode(taskData.ExportID, common.GetServerInfo().Address()); err != nil {
+		blog.Errorf("execute export task %s failed, set export node failed, err: %v, rid: %s", task.TaskID, err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebFileSaveFail, defErr.Errorf(common.CCErrWebFileSaveFail, err.Error()).Error(), nil))
+		return
+	}
+
+	c.String(http.StatusOK, getReturnStr(0, "", nil))
+}
+
+// getUserExportTask get the export task by task id in path, the task must be created by current user
+func (s *Service) getUserExportTask(c *gin.Context) (*metadata.APITaskDetail, int, error) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	ctx := util.NewContextFromGinContext(c)
+	header := c.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+
+	taskID := c.Param("task_id")
+	result, err := s.CoreAPI.TaskServer().Task().TaskDetail(ctx, header, taskID)
+	if err != nil {
+		blog.Errorf("get export task %s failed, err: %v, rid: %s", taskID, err, rid)
+		return nil, common.CCErrCommHTTPDoRequestFailed, defErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("get export task %s failed, result: %+v, rid: %s", taskID, result, rid)
+		return nil, result.Code, defErr.New(result.Code, result.ErrMsg)
+	}
+
+	task := result.Data.Info
+	if task.Name != common.ExportExcelTaskName || task.User != util.GetUser(header) {
+		blog.Errorf("get export task %s failed, task name %s, user %s not match, rid: %s", taskID, task.Name, task.User, rid)
+		return nil, common.CCErrWebExportTaskNotFound, defErr.Error(common.CCErrWebExportTaskNotFound)
+	}
+	return &task, 0, nil
+}
+
+func (s *Service) findExportTask(header http.Header, exportID string) (*metadata.APITaskDetail, error) {
+	input := &metadata.ListAPITaskRequest{
+		Condition: mapstr.MapStr{"flag": exportID},
+		Page:      metadata.BasePage{Limit: 1},
+	}
+	result, err := s.CoreAPI.TaskServer().Task().ListTask(context.Background(), header, common.ExportExcelTaskName, input)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Result {
+		return nil, fmt.Errorf("list task failed, code: %d, message: %s", result.Code, result.ErrMsg)
+	}
+	if len(result.Data.Info) == 0 || exportID == "" {
+		return nil, fmt.Errorf("task %s not found", exportID)
+	}
+	return &result.Data.Info[0], nil
+}
+
+func (s *Service) setExportProgress(exportID string, exported, total int, rid string) {
+	progress, _ := json.Marshal(metadata.ExportExcelProgress{Exported: exported, Total: total})
+	key := common.RedisWebExportTaskProgressPrefix + exportID
+	if err := s.CacheCli.Set(key, string(progress), exportTaskExpire).Err(); err != nil {
+		blog.Warnf("set export task %s progress failed, err: %v, rid: %s", exportID, err, rid)
+	}
+}
+
+func (s *Service) setExportNode(exportID, node string) error {
+	return s.CacheCli.Set(common.RedisWebExportTaskNodePrefix+exportID, node, exportTaskExpire).Err()
+}
+
+// getExportNode get the address of the web server which saved the export file
+func (s *Service) getExportNode(exportID, rid string) string {
+	node, err := s.CacheCli.Get(common.RedisWebExportTaskNodePrefix + exportID).Result()
+	if err != nil {
+		blog.Warnf("get export task %s node failed, err: %v, rid: %s", exportID, err, rid)
+		return ""
+	}
+	return node
+}
+
+func (s *Service) getExportProgress(exportID, rid string) metadata.ExportExcelProgress {
+	progress := metadata.ExportExcelProgress{}
+	val, err := s.CacheCli.Get(common.RedisWebExportTaskProgressPrefix + exportID).Result()
+	if err != nil {
+		blog.Warnf("get export task %s progress failed, err: %v, rid: %s", exportID, err, rid)
+		return progress
+	}
+	if err := json.Unmarshal([]byte(val), &progress); err != nil {
+		blog.Warnf("unmarshal export task %s progress %s failed, err: %v, rid: %s", exportID, val, err, rid)
+	}
+	return progress
+}
+
+func decodeExportTask(task *metadata.APITaskDetail) (*metadata.ExportExcelTask, error) {
+	if len(task.Detail) == 0 {
+		return nil, fmt.Errorf("task %s has no detail", task.TaskID)
+	}
+	raw, err := json.Marshal(task.Detail[0].Data)
+	if err != nil {
+		return nil, err
+	}
+	taskData := new(metadata.ExportExcelTask)
+	if err := json.Unmarshal(raw, taskData); err != nil {
+		return nil, err
+	}
+	if taskData.ExportID != task.Flag {
+		return nil, fmt.Errorf("task %s export id %s not match flag %s", task.TaskID, taskData.ExportID, task.Flag)
+	}
+	return taskData, nil
+}
+
+// exportTaskFilePath the export file is saved in the resource path of the web server which executed the task,
+// the download is proxied to that web server when there are multiple web servers.
+func exportTaskFilePath(exportID string) string {
+	return fmt.Sprintf("%s/export/task/%s.xlsx", webCommon.ResourcePath, exportID)
+}
+
+func removeExpiredExportFiles(dir string, rid string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		blog.Warnf("read export task dir %s failed, err: %v, rid: %s", dir, err, rid)
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() || time.Since(file.ModTime()) < exportTaskExpire {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, file.Name())); err != nil {
+			blog.Warnf("remove expired export file %s failed, err: %v, rid: %s", file.Name(), err, rid)
+		}
+	}
+}
@@ -43,6 +43,10 @@ func (s *Service) ImportHost(c *gin.Context) {
 	language := webCommon.GetLanguageByHTTPRequest(c)
 	defLang := s.Language.CreateDefaultCCLanguageIf(language)
 	defErr := s.CCErr.CreateDefaultCCErrorIf(language)
+	mode, ok := parseImportMode(c, defErr)
+	if !ok {
+		return
+	}
 	file, err := c.FormFile("file")
 	if nil != err {
 		blog.Errorf("ImportHost failed, get file from form data failed, err: %+v, rid: %s", err, rid)
@@ -84,7 +88,7 @@ func (s *Service) ImportHost(c *gin.Context) {
 		c.String(http.StatusOK, string(msg))
 		return
 	}
-	result := s.Logics.ImportHosts(ctx, f, c.Request.Header, defLang, &metadata.Metadata{})
+	result := s.Logics.ImportHostsWithMode(ctx, f, c.Request.Header, defLang, &metadata.Metadata{}, mode)
 
 	c.JSON(http.StatusOK, result)
 }
@@ -103,7 +107,7 @@ func (s *Service) ExportHost(c *gin.Context) {
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 	customFieldsStr := c.PostForm(common.ExportCustomFields)
 
-	hostInfo, err := s.Logics.GetHostData(appIDStr, hostIDStr, header)
+	hostInfo, _, err := s.Logics.GetHostData(appIDStr, hostIDStr, header, nil)
 	if err != nil {
 		blog.Errorf("ExportHost failed, get hosts by id [%+v] failed, err: %v, rid: %s", hostIDStr, err, rid)
 		msg := getReturnStr(common.CCErrWebGetHostFail, defErr.Errorf(common.CCErrWebGetHostFail, err.Error()).Error(), nil)
@@ -0,0 +1,134 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	webCommon "configcenter/src/web_server/common"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rentiansheng/xlsx"
+)
+
+// PreviewImportHost classify the rows of the host excel into create, update, unchanged and invalid without importing them
+func (s *Service) PreviewImportHost(c *gin.Context) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	ctx := util.NewContextFromHTTPHeader(c.Request.Header)
+	webCommon.SetProxyHeader(c)
+	language := webCommon.GetLanguageByHTTPRequest(c)
+	defLang := s.Language.CreateDefaultCCLanguageIf(language)
+
+	f, ok := s.openImportExcel(c, "previewhost")
+	if !ok {
+		return
+	}
+
+	preview, _, err := s.Logics.PreviewImportHosts(ctx, f, c.Request.Header, defLang, &metadata.Metadata{})
+	if err != nil {
+		blog.Errorf("PreviewImportHost failed, err: %v, rid: %s", err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebFileContentFail, err.Error(), nil))
+		return
+	}
+	c.String(http.StatusOK, getReturnStr(0, "", preview))
+}
+
+// PreviewImportInst classify the rows of the instance excel into create, update, unchanged and invalid without importing them
+func (s *Service) PreviewImportInst(c *gin.Context) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	ctx := util.NewContextFromHTTPHeader(c.Request.Header)
+	webCommon.SetProxyHeader(c)
+	objID := c.Param(common.BKObjIDField)
+	language := webCommon.GetLanguageByHTTPRequest(c)
+	defLang := s.Language.CreateDefaultCCLanguageIf(language)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(language)
+
+	metaInfo, err := parseMetadata(c.PostForm(metadata.BKMetadata))
+	if err != nil {
+		msg := getReturnStr(common.CCErrCommJSONUnmarshalFailed, defErr.Error(common.CCErrCommJSONUnmarshalFailed).Error(), nil)
+		c.String(http.StatusOK, msg)
+		return
+	}
+
+	f, ok := s.openImportExcel(c, "previewinsts")
+	if !ok {
+		return
+	}
+
+	preview, _, err := s.Logics.PreviewImportInsts(ctx, f, objID, c.Request.Header, defLang, metaInfo)
+	if err != nil {
+		blog.Errorf("PreviewImportInst failed, object: %s, err: %v, rid: %s", objID, err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebFileContentFail, err.Error(), nil))
+		return
+	}
+	c.String(http.StatusOK, getReturnStr(0, "", preview))
+}
+
+// openImportExcel save the uploaded excel to a temporary file and open it, the response is written if it fails
+func (s *Service) openImportExcel(c *gin.Context, prefix string) (*xlsx.File, bool) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(webCommon.GetLanguageByHTTPRequest(c))
+
+	file, err := c.FormFile("file")
+	if nil != err {
+		blog.Errorf("get file from form data failed, err: %v, rid: %s", err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebFileNoFound, defErr.Error(common.CCErrWebFileNoFound).Error(), nil))
+		return nil, false
+	}
+
+	dir := webCommon.ResourcePath + "/import/"
+	if _, err := os.Stat(dir); nil != err {
+		if err := os.MkdirAll(dir, os.ModeDir|os.ModePerm); err != nil {
+			blog.Errorf("save form data to local file failed, mkdir failed, err: %v, rid: %s", err, rid)
+		}
+	}
+	filePath := fmt.Sprintf("%s/%s-%d-%d.xlsx", dir, prefix, time.Now().UnixNano(), rand.Uint32())
+	if err := c.SaveUploadedFile(file, filePath); nil != err {
+		blog.Errorf("save form data to local file failed, err: %v, rid: %s", err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebFileSaveFail, defErr.Errorf(common.CCErrWebFileSaveFail, err.Error()).Error(), nil))
+		return nil, false
+	}
+	defer func() {
+		if err := os.Remove(filePath); err != nil {
+			blog.Errorf("remove temporary file %s failed, err: %v, rid: %s", filePath, err, rid)
+		}
+	}()
+
+	f, err := xlsx.OpenFile(filePath)
+	if nil != err {
+		blog.Errorf("open form data as excel file failed, err: %v, rid: %s", err, rid)
+		c.String(http.StatusOK, getReturnStr(common.CCErrWebOpenFileFail, defErr.Errorf(common.CCErrWebOpenFileFail, err.Error()).Error(), nil))
+		return nil, false
+	}
+	return f, true
+}
+
+// parseImportMode get the import mode from the form data, the response is written if it is invalid
+func parseImportMode(c *gin.Context, defErr errors.DefaultCCErrorIf) (metadata.ImportMode, bool) {
+	mode := metadata.ImportMode(c.PostForm("import_mode"))
+	switch mode {
+	case metadata.ImportModeDefault, metadata.ImportModeValidOnly, metadata.ImportModeAllOrNothing:
+		return mode, true
+	}
+	c.String(http.StatusOK, getReturnStr(common.CCErrCommParamsInvalid, defErr.Errorf(common.CCErrCommParamsInvalid, "import_mode").Error(), nil))
+	return mode, false
+}
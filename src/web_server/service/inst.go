@@ -40,6 +40,10 @@ func (s *Service) ImportInst(c *gin.Context) {
 	language := webCommon.GetLanguageByHTTPRequest(c)
 	defLang := s.Language.CreateDefaultCCLanguageIf(language)
 	defErr := s.CCErr.CreateDefaultCCErrorIf(language)
+	mode, ok := parseImportMode(c, defErr)
+	if !ok {
+		return
+	}
 
 	file, err := c.FormFile("file")
 	if nil != err {
@@ -85,7 +89,7 @@ func (s *Service) ImportInst(c *gin.Context) {
 		return
 	}
 
-	data, errCode, err := s.Logics.ImportInsts(context.Background(), f, objID, c.Request.Header, defLang, metaInfo)
+	data, errCode, err := s.Logics.ImportInstsWithMode(context.Background(), f, objID, c.Request.Header, defLang, metaInfo, mode)
 
 	if nil != err {
 		msg := getReturnStr(errCode, err.Error(), data)
@@ -119,7 +123,7 @@ func (s *Service) ExportInst(c *gin.Context) {
 	}
 
 	kvMap := mapstr.MapStr{}
-	instInfo, err := s.Logics.GetInstData(ownerID, objID, instIDStr, pheader, kvMap, metaInfo)
+	instInfo, _, err := s.Logics.GetInstData(ownerID, objID, instIDStr, pheader, kvMap, metaInfo, nil)
 	if err != nil {
 		msg := getReturnStr(common.CCErrWebGetObjectFail, defErr.Errorf(common.CCErrWebGetObjectFail, err.Error()).Error(), nil)
 		fmt.Println("return msg: ", msg)
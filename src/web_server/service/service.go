@@ -51,11 +51,17 @@ func (s *Service) WebService() *gin.Engine {
 	ws.LoadHTMLFiles(s.Config.Site.HtmlRoot + "/index.html")
 
 	ws.POST("/hosts/import", s.ImportHost)
+	ws.POST("/hosts/import/preview", s.PreviewImportHost)
 	ws.POST("/hosts/export", s.ExportHost)
 	ws.GET("/hosts/:bk_host_id/listen_ip_options", s.ListenIPOptions)
 	ws.POST("/importtemplate/:bk_obj_id", s.BuildDownLoadExcelTemplate)
 	ws.POST("/insts/owner/:bk_supplier_account/object/:bk_obj_id/import", s.ImportInst)
+	ws.POST("/insts/owner/:bk_supplier_account/object/:bk_obj_id/import/preview", s.PreviewImportInst)
 	ws.POST("/insts/owner/:bk_supplier_account/object/:bk_obj_id/export", s.ExportInst)
+	ws.POST("/hosts/export/task", s.CreateExportHostTask)
+	ws.POST("/insts/owner/:bk_supplier_account/object/:bk_obj_id/export/task", s.CreateExportInstTask)
+	ws.GET("/export/task/:task_id", s.GetExportTaskProgress)
+	ws.GET("/export/task/:task_id/download", s.DownloadExportTaskFile)
 	ws.POST("/logout", s.LogOutUser)
 	ws.POST("/object/owner/:bk_supplier_account/object/:bk_obj_id/import", s.ImportObject)
 	ws.POST("/object/owner/:bk_supplier_account/object/:bk_obj_id/export", s.ExportObject)
@@ -68,6 +74,7 @@ func (s *Service) WebService() *gin.Engine {
 	ws.POST("/biz/search/web", s.SearchBusiness)
 
 	ws.GET("/healthz", s.Healthz)
+	ws.POST("/internal/task/export", s.ExportTaskHandler)
 	ws.GET("/", s.Index)
 
 	ws.POST("/netdevice/import", s.ImportNetDevice)